@@ -347,6 +347,12 @@ BINARIES:=./istioctl/cmd/istioctl \
   ./pkg/test/echo/cmd/client \
   ./pkg/test/echo/cmd/server \
   ./mixer/test/policybackend \
+  ./pkg/test/fakes/jwks/jwksserver \
+  ./pkg/test/fakes/extauthz/extauthzserver \
+  ./pkg/test/fakes/externalca/externalcaserver \
+  ./pkg/test/fakes/oidc/oidcserver \
+  ./pkg/test/fakes/stackdriver/stackdriverserver \
+  ./pkg/test/fakes/sds/sdsserver \
   ./tools/hyperistio \
   ./tools/istio-iptables
 
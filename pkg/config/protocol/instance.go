@@ -46,6 +46,10 @@ const (
 	Redis Instance = "Redis"
 	// MySQL declares that the port carries MySQL traffic.
 	MySQL Instance = "MySQL"
+	// Thrift declares that the port carries Thrift traffic.
+	Thrift Instance = "Thrift"
+	// Dubbo declares that the port carries Dubbo traffic.
+	Dubbo Instance = "Dubbo"
 	// Unsupported - value to signify that the protocol is unsupported.
 	Unsupported Instance = "UnsupportedProtocol"
 )
@@ -75,6 +79,10 @@ func Parse(s string) Instance {
 		return Redis
 	case "mysql":
 		return MySQL
+	case "thrift":
+		return Thrift
+	case "dubbo":
+		return Dubbo
 	}
 
 	return Unsupported
@@ -103,7 +111,7 @@ func (i Instance) IsHTTP() bool {
 // IsTCP is true for protocols that use TCP as transport protocol
 func (i Instance) IsTCP() bool {
 	switch i {
-	case TCP, HTTPS, TLS, Mongo, Redis, MySQL:
+	case TCP, HTTPS, TLS, Mongo, Redis, MySQL, Thrift, Dubbo:
 		return true
 	default:
 		return false
@@ -55,6 +55,10 @@ func TestParse(t *testing.T) {
 		{"mysql", protocol.MySQL},
 		{"MYSQL", protocol.MySQL},
 		{"MySQL", protocol.MySQL},
+		{"thrift", protocol.Thrift},
+		{"Thrift", protocol.Thrift},
+		{"dubbo", protocol.Dubbo},
+		{"Dubbo", protocol.Dubbo},
 		{"", protocol.Unsupported},
 		{"SMTP", protocol.Unsupported},
 	}
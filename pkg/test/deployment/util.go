@@ -19,6 +19,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/gogo/protobuf/jsonpb"
 
@@ -26,6 +27,10 @@ import (
 	"istio.io/istio/pkg/test/scopes"
 )
 
+const (
+	proxyContainerName = "istio-proxy"
+)
+
 // DumpPodState logs the current pod state.
 func DumpPodState(workDir string, namespace string, accessor *kube.Accessor) {
 	pods, err := accessor.GetPods(namespace)
@@ -92,6 +97,115 @@ func DumpPodEvents(workDir, namespace string, accessor *kube.Accessor) {
 	}
 }
 
+// DumpPodLogs writes the logs of all containers of the pods in the namespace to the workDir.
+func DumpPodLogs(workDir, namespace string, accessor *kube.Accessor) {
+	pods, err := accessor.GetPods(namespace)
+	if err != nil {
+		scopes.CI.Errorf("Error getting pods list via kubectl: %v", err)
+		return
+	}
+
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			l, err := accessor.Logs(pod.Namespace, pod.Name, container.Name, false /* previousLog */)
+			if err != nil {
+				scopes.CI.Errorf("Unable to get logs for pod/container: %s/%s/%s", pod.Namespace, pod.Name, container.Name)
+				continue
+			}
+
+			fname := path.Join(workDir, fmt.Sprintf("%s-%s.log", pod.Name, container.Name))
+			if err = ioutil.WriteFile(fname, []byte(l), os.ModePerm); err != nil {
+				scopes.CI.Errorf("Unable to write logs for pod/container: %s/%s/%s", pod.Namespace, pod.Name, container.Name)
+			}
+		}
+	}
+}
+
+// DumpProxyState writes the config dump, clusters and stats of the Envoy sidecars of the pods in the namespace
+// to the workDir.
+func DumpProxyState(workDir, namespace string, accessor *kube.Accessor) {
+	pods, err := accessor.GetPods(namespace)
+	if err != nil {
+		scopes.CI.Errorf("Error getting pods list via kubectl: %v", err)
+		return
+	}
+
+	for _, pod := range pods {
+		hasProxy := false
+		for _, container := range pod.Spec.Containers {
+			if container.Name == proxyContainerName {
+				hasProxy = true
+				break
+			}
+		}
+		if !hasProxy {
+			continue
+		}
+
+		for _, query := range []struct {
+			path string
+			ext  string
+		}{
+			{path: "config_dump", ext: "json"},
+			{path: "clusters", ext: "txt"},
+			{path: "stats", ext: "txt"},
+		} {
+			out, err := accessor.Exec(pod.Namespace, pod.Name, proxyContainerName, "pilot-agent request GET "+query.path)
+			if err != nil {
+				scopes.CI.Errorf("Unable to get Envoy %s for pod: %s/%s: %v", query.path, pod.Namespace, pod.Name, err)
+				continue
+			}
+
+			fname := path.Join(workDir, fmt.Sprintf("%s-proxy-%s.%s", pod.Name, query.path, query.ext))
+			if err = ioutil.WriteFile(fname, []byte(out), os.ModePerm); err != nil {
+				scopes.CI.Errorf("Unable to write Envoy %s for pod: %s/%s: %v", query.path, pod.Namespace, pod.Name, err)
+			}
+		}
+	}
+}
+
+// DumpIstioConfig writes the Istio configuration resources of the namespace to the workDir, one file per
+// resource type.
+func DumpIstioConfig(workDir, namespace string, accessor *kube.Accessor) {
+	crds, err := accessor.GetCustomResourceDefinitions()
+	if err != nil {
+		scopes.CI.Errorf("Error getting custom resource definitions via kubectl: %v", err)
+		return
+	}
+
+	for _, crd := range crds {
+		if !strings.HasSuffix(crd.Spec.Group, "istio.io") {
+			continue
+		}
+
+		resourceType := crd.Spec.Names.Plural + "." + crd.Spec.Group
+		out, err := accessor.GetResourcesYAML(namespace, resourceType)
+		if err != nil {
+			scopes.CI.Errorf("Unable to get %s in namespace %s: %v", resourceType, namespace, err)
+			continue
+		}
+		// Skip the empty lists.
+		if !strings.Contains(out, "kind: ") || strings.Contains(out, "items: []") {
+			continue
+		}
+
+		fname := path.Join(workDir, fmt.Sprintf("config_%s_%s.yaml", namespace, resourceType))
+		if err = ioutil.WriteFile(fname, []byte(out), os.ModePerm); err != nil {
+			scopes.CI.Errorf("Unable to write %s in namespace %s: %v", resourceType, namespace, err)
+		}
+	}
+}
+
+// DumpNamespace writes the state, events and logs of the pods, the state of the Envoy sidecars and the Istio
+// configuration of the namespace to the workDir.
+func DumpNamespace(workDir, namespace string, accessor *kube.Accessor) {
+	DumpPodState(workDir, namespace, accessor)
+	DumpPodEvents(workDir, namespace, accessor)
+	DumpPodLogs(workDir, namespace, accessor)
+	DumpProxyState(workDir, namespace, accessor)
+	DumpIstioConfig(workDir, namespace, accessor)
+}
+
 /*
 // DumpPodData copies pod logs from Kubernetes to the specified workDir.
 func DumpPodData(workDir, namespace string, accessor *kube.Accessor) {
@@ -0,0 +1,122 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// Matcher checks a single response. Matchers can be passed to ParsedResponses.Check and CheckOrFail.
+type Matcher func(int, *ParsedResponse) error
+
+// HasSourceIdentity matches responses to requests whose client certificate had the given URI SAN
+// (e.g. "spiffe://cluster.local/ns/default/sa/a").
+func HasSourceIdentity(identity string) Matcher {
+	return func(i int, r *ParsedResponse) error {
+		if r.ClientCert.URI != identity {
+			return fmt.Errorf("response[%d] source identity: expected %s, received %q", i, identity, r.ClientCert.URI)
+		}
+		return nil
+	}
+}
+
+// HasNoSourceIdentity matches responses to requests that were not made with a client certificate.
+func HasNoSourceIdentity() Matcher {
+	return func(i int, r *ParsedResponse) error {
+		if r.ClientCert.URI != "" {
+			return fmt.Errorf("response[%d] source identity: expected none, received %s", i, r.ClientCert.URI)
+		}
+		return nil
+	}
+}
+
+// HasDestinationIdentity matches responses whose server-side sidecar had the given URI SAN.
+func HasDestinationIdentity(identity string) Matcher {
+	return func(i int, r *ParsedResponse) error {
+		if r.ClientCert.By != identity {
+			return fmt.Errorf("response[%d] destination identity: expected %s, received %q", i, identity, r.ClientCert.By)
+		}
+		return nil
+	}
+}
+
+// HasDNSSAN matches responses to requests whose client certificate included the given DNS SAN.
+func HasDNSSAN(name string) Matcher {
+	return func(i int, r *ParsedResponse) error {
+		for _, dns := range r.ClientCert.DNS {
+			if dns == name {
+				return nil
+			}
+		}
+		return fmt.Errorf("response[%d] DNS SANs: expected %s, received %v", i, name, r.ClientCert.DNS)
+	}
+}
+
+// HasCertificateHash matches responses to requests whose client certificate had the given SHA256 hash. An empty
+// hash matches any client certificate.
+func HasCertificateHash(hash string) Matcher {
+	return func(i int, r *ParsedResponse) error {
+		if r.ClientCert.Hash == "" {
+			return fmt.Errorf("response[%d]: no client certificate hash received", i)
+		}
+		if hash != "" && r.ClientCert.Hash != hash {
+			return fmt.Errorf("response[%d] client certificate hash: expected %s, received %s", i, hash, r.ClientCert.Hash)
+		}
+		return nil
+	}
+}
+
+// HasPeerCertificateURISAN matches responses to requests whose peer certificate (i.e. the leaf of the chain
+// presented to a server terminating TLS) had the given URI SAN.
+func HasPeerCertificateURISAN(uri string) Matcher {
+	return func(i int, r *ParsedResponse) error {
+		if len(r.PeerCertificates) == 0 {
+			return fmt.Errorf("response[%d]: no peer certificate received", i)
+		}
+		var sans []string
+		for _, u := range r.PeerCertificates[0].URIs {
+			if u.String() == uri {
+				return nil
+			}
+			sans = append(sans, u.String())
+		}
+		return fmt.Errorf("response[%d] peer certificate URI SANs: expected %s, received %v", i, uri, sans)
+	}
+}
+
+// HasPeerCertificateIssuedBy matches responses to requests whose peer certificate chain verifies against the
+// given root certificate. Intermediates are taken from the chain.
+func HasPeerCertificateIssuedBy(root *x509.Certificate) Matcher {
+	return func(i int, r *ParsedResponse) error {
+		if len(r.PeerCertificates) == 0 {
+			return fmt.Errorf("response[%d]: no peer certificate received", i)
+		}
+		roots := x509.NewCertPool()
+		roots.AddCert(root)
+		intermediates := x509.NewCertPool()
+		for _, c := range r.PeerCertificates[1:] {
+			intermediates.AddCert(c)
+		}
+		if _, err := r.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			return fmt.Errorf("response[%d] peer certificate not issued by %s: %v", i, root.Subject, err)
+		}
+		return nil
+	}
+}
@@ -15,14 +15,22 @@
 package client
 
 import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"google.golang.org/grpc/codes"
 
 	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/echo/common/response"
 	"istio.io/istio/pkg/test/echo/proto"
 )
@@ -30,12 +38,63 @@ import (
 var (
 	requestIDFieldRegex      = regexp.MustCompile("(?i)" + string(response.RequestIDField) + "=(.*)")
 	serviceVersionFieldRegex = regexp.MustCompile(string(response.ServiceVersionField) + "=(.*)")
+	clusterFieldRegex        = regexp.MustCompile("(?m)(?:^|\\s)" + string(response.ClusterField) + "=(.*)$")
+	localityFieldRegex       = regexp.MustCompile("(?m)(?:^|\\s)" + string(response.LocalityField) + "=(.*)$")
 	servicePortFieldRegex    = regexp.MustCompile(string(response.ServicePortField) + "=(.*)")
 	statusCodeFieldRegex     = regexp.MustCompile(string(response.StatusCodeField) + "=(.*)")
 	hostFieldRegex           = regexp.MustCompile(string(response.HostField) + "=(.*)")
 	hostnameFieldRegex       = regexp.MustCompile(string(response.HostnameField) + "=(.*)")
+	sourcePrincipalRegex     = regexp.MustCompile(string(response.SourcePrincipalField) + "=(.*)")
+	destPrincipalRegex       = regexp.MustCompile(string(response.DestinationPrincipalField) + "=(.*)")
+	tcpResultRegex           = regexp.MustCompile(string(response.TCPResultField) + "=(.*)")
+	tcpBytesEchoedRegex      = regexp.MustCompile(string(response.TCPBytesEchoedField) + "=(.*)")
+	protocolRegex            = regexp.MustCompile("(?m)(?:^|\\s)" + string(response.ProtocolField) + "=(.*)$")
+	clientProtocolRegex      = regexp.MustCompile(string(response.ClientProtocolField) + "=(.*)")
+	alpnRegex                = regexp.MustCompile(string(response.ALPNField) + "=(.*)")
+	streamMessageRegex       = regexp.MustCompile(string(response.StreamMessageField) + "=(\\d+) (\\S+)")
+	streamCodeRegex          = regexp.MustCompile(string(response.StreamCodeField) + "=(.*)")
+	streamErrorRegex         = regexp.MustCompile(string(response.StreamErrorField) + "=(.*)")
+	greetingLatencyRegex     = regexp.MustCompile(string(response.GreetingLatencyField) + "=(.*)")
+	udpMessageRegex          = regexp.MustCompile(string(response.UDPMessageField) + "=(.*)")
+	webSocketMessageRegex    = regexp.MustCompile(string(response.WebSocketMessageField) + "=(.*)")
+	xfccHeaderRegex          = regexp.MustCompile("(?im)^" + common.XFCCHeader + "=(.*)$")
+	sourceWorkloadRegex      = regexp.MustCompile(string(response.SourceWorkloadField) + "=(.*)")
+	peerCertificateRegex     = regexp.MustCompile(string(response.PeerCertificateField) + "=(.*)")
+	serverCertificateRegex   = regexp.MustCompile(string(response.ServerCertificateField) + "=(.*)")
+	ipFamilyRegex            = regexp.MustCompile(string(response.IPFamilyField) + "=(.*)")
+	resolvedAddressRegex     = regexp.MustCompile(string(response.ResolvedAddressField) + "=(.*)")
+	drainingRegex            = regexp.MustCompile(string(response.DrainingField) + "=(.*)")
+	inFlightRegex            = regexp.MustCompile(string(response.InFlightField) + "=(.*)")
+	connectionReusedRegex    = regexp.MustCompile(string(response.ConnectionReusedField) + "=(.*)")
+	latencyRegex             = regexp.MustCompile("(?m)(?:^|\\s)" + string(response.LatencyField) + "=(.*)$")
+	responseHeaderRegex      = regexp.MustCompile(string(response.ResponseHeaderField) + "=([^:]*):(.*)")
+	bodySizeRegex            = regexp.MustCompile(string(response.BodySizeField) + "=(\\d+)")
+	capturedRequestRegex     = regexp.MustCompile(string(response.CapturedRequestField) + "=(.*)")
+	requestCountRegex        = regexp.MustCompile(string(response.RequestCountField) + "=(\\d+)")
+	mirroredCountRegex       = regexp.MustCompile(string(response.MirroredCountField) + "=(\\d+)")
+	grpcCodeRegex            = regexp.MustCompile(string(response.GRPCCodeField) + "=(\\d+)")
+	grpcMessageRegex         = regexp.MustCompile(string(response.GRPCMessageField) + "=(.*)")
+	grpcDetailRegex          = regexp.MustCompile(string(response.GRPCDetailField) + "=(.*)")
 )
 
+// GRPCStatus is the status a gRPC call completed with.
+type GRPCStatus struct {
+	Code    codes.Code
+	Message string
+	// Details of the status, each as the type URL of the detail followed by its text encoding, if the type
+	// is known to the echo server.
+	Details []string
+}
+
+// StreamMessage is the timing of a single message of a gRPC stream.
+type StreamMessage struct {
+	// Index of the message within the stream.
+	Index int
+	// Latency of the message: the round trip for bidirectional streams, the time since the previous message
+	// for server streams.
+	Latency time.Duration
+}
+
 // ParsedResponse represents a response to a single echo request.
 type ParsedResponse struct {
 	// Body is the body of the response
@@ -44,6 +103,16 @@ type ParsedResponse struct {
 	ID string
 	// Version is the version of the resource in the response
 	Version string
+	// Cluster is the name of the cluster of the instance that served the request. Empty if the server was
+	// not configured with a cluster name.
+	Cluster string
+	// Locality of the instance that served the request. Empty if the server was not configured with a
+	// locality.
+	Locality string
+	// Draining indicates that the server was draining when it responded.
+	Draining bool
+	// InFlight is the number of requests in flight on the server, for requests to the drain path.
+	InFlight int
 	// Port is the port of the resource in the response
 	Port string
 	// Code is the response code
@@ -52,6 +121,78 @@ type ParsedResponse struct {
 	Host string
 	// Hostname is the host that responded to the request
 	Hostname string
+	// SourceWorkload is the hostname (i.e. the pod name in Kubernetes) of the workload that made the request.
+	SourceWorkload string
+	// PeerCertificates is the certificate chain presented by the client, leaf first, when the server
+	// terminated TLS itself (i.e. on a port with TLS enabled). Empty if no certificate was presented.
+	PeerCertificates []*x509.Certificate
+	// ServerCertificates is the certificate chain presented to the client by the server, leaf first, when
+	// the request was made over TLS. It identifies where TLS was terminated, e.g. a gateway or the backend.
+	ServerCertificates []*x509.Certificate
+	// IPFamily of the address the client sent the request to. Empty if the request was not made over IP.
+	IPFamily response.IPFamily
+	// ConnectionReused indicates that the client sent the request over a connection that an earlier request
+	// of the same call was sent over.
+	ConnectionReused bool
+	// Latency of the request as measured by the client, from sending the request until the response was
+	// read.
+	Latency time.Duration
+	// SourcePrincipal is the identity of the verified client certificate. Empty if the request was not
+	// made over mutual TLS.
+	SourcePrincipal string
+	// DestinationPrincipal is the identity of the server sidecar that terminated mutual TLS.
+	DestinationPrincipal string
+	// ClientCert is the most recent element of the X-Forwarded-Client-Cert header received by the server. Zero
+	// if the request was not made over mutual TLS.
+	ClientCert common.XFCCElement
+	// TCPResult is the connection level outcome of a request made with the TCP scheme. Empty for other schemes.
+	TCPResult response.TCPResult
+	// TCPBytesEchoed is the number of bytes read back over the connection of a request made with the TCP scheme.
+	TCPBytesEchoed int
+	// Protocol is the protocol the request was received over by the server, e.g. "HTTP/2.0".
+	Protocol string
+	// ClientProtocol is the protocol of the response received by the client, e.g. "HTTP/2.0".
+	ClientProtocol string
+	// ALPN is the protocol negotiated through ALPN by the client. Empty if the request was not made over TLS
+	// or no protocol was negotiated.
+	ALPN string
+	// StreamMessages are the messages exchanged over the stream, for gRPC requests made with stream messages.
+	StreamMessages []StreamMessage
+	// StreamCode is the final status of the stream, for gRPC requests made with stream messages.
+	StreamCode codes.Code
+	// StreamError is the error the stream failed with, if any.
+	StreamError string
+	// GRPCStatus of the call, for gRPC requests made with CallOptions.GRPCStatus. Nil if not reported.
+	GRPCStatus *GRPCStatus
+	// GreetingLatency is the time from connecting until the greeting of the server was received, for requests
+	// made with the TCPServerFirst scheme. Protocol sniffing on the server side delays the greeting.
+	GreetingLatency time.Duration
+	// UDPMessage is the datagram payload received by the server, for a request made with the UDP scheme.
+	UDPMessage string
+	// ResolvedAddresses are the addresses the host resolved to from the calling workload, for a request made
+	// with the DNS scheme.
+	ResolvedAddresses []string
+	// WebSocketMessages are the frames echoed back by the server, in order, for a request made with the
+	// WebSocket scheme.
+	WebSocketMessages []string
+	// ResponseHeaders received by the client, for HTTP requests.
+	ResponseHeaders http.Header
+	// BodySize is the size of the body received by the client in bytes, for HTTP requests.
+	BodySize int
+	// CapturedRequests are the requests recently received by the server, oldest first, for a request to
+	// common.RequestsPath.
+	CapturedRequests []response.Request
+	// RequestCounts of the requests received by the server, for a request to common.CountsPath.
+	RequestCounts response.RequestCounts
+}
+
+// PeerCertificatesPEM returns the PEM encoding of the certificate chain presented by the client.
+func (r *ParsedResponse) PeerCertificatesPEM() string {
+	out := strings.Builder{}
+	for _, c := range r.PeerCertificates {
+		_ = pem.Encode(&out, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	return out.String()
 }
 
 // IsOK indicates whether or not the code indicates a successful request.
@@ -110,6 +251,114 @@ func (r ParsedResponses) CheckOKOrFail(t test.Failer) ParsedResponses {
 	return r
 }
 
+// CheckTCPResult verifies that all requests made with the TCP scheme had the expected connection level outcome.
+func (r ParsedResponses) CheckTCPResult(expected response.TCPResult) error {
+	return r.Check(func(i int, resp *ParsedResponse) error {
+		if resp.TCPResult != expected {
+			return fmt.Errorf("response[%d] TCP result: expected %s, received %q (%d bytes echoed)",
+				i, expected, resp.TCPResult, resp.TCPBytesEchoed)
+		}
+		return nil
+	})
+}
+
+func (r ParsedResponses) CheckTCPResultOrFail(t test.Failer, expected response.TCPResult) ParsedResponses {
+	t.Helper()
+	if err := r.CheckTCPResult(expected); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// CheckStream verifies that all streams completed the expected number of messages and ended with the
+// expected status.
+func (r ParsedResponses) CheckStream(expectedMessages int, expectedCode codes.Code) error {
+	return r.Check(func(i int, resp *ParsedResponse) error {
+		if len(resp.StreamMessages) != expectedMessages || resp.StreamCode != expectedCode {
+			return fmt.Errorf("response[%d] stream: expected %d messages and status %s, received %d messages and status %s (%s)",
+				i, expectedMessages, expectedCode, len(resp.StreamMessages), resp.StreamCode, resp.StreamError)
+		}
+		return nil
+	})
+}
+
+func (r ParsedResponses) CheckStreamOrFail(t test.Failer, expectedMessages int, expectedCode codes.Code) ParsedResponses {
+	t.Helper()
+	if err := r.CheckStream(expectedMessages, expectedCode); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// CheckGRPCCode verifies that all gRPC calls completed with the expected status code, e.g. PermissionDenied
+// for calls rejected by authorization and Unauthenticated for calls rejected by authentication. The calls
+// must be made with CallOptions.GRPCStatus.
+func (r ParsedResponses) CheckGRPCCode(expected codes.Code) error {
+	return r.Check(func(i int, resp *ParsedResponse) error {
+		if resp.GRPCStatus == nil {
+			return fmt.Errorf("response[%d]: no gRPC status reported", i)
+		}
+		if resp.GRPCStatus.Code != expected {
+			return fmt.Errorf("response[%d] gRPC status: expected %s, received %s (%s)",
+				i, expected, resp.GRPCStatus.Code, resp.GRPCStatus.Message)
+		}
+		return nil
+	})
+}
+
+func (r ParsedResponses) CheckGRPCCodeOrFail(t test.Failer, expected codes.Code) ParsedResponses {
+	t.Helper()
+	if err := r.CheckGRPCCode(expected); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// CheckProtocol verifies that all requests were received by the server over the expected protocol, e.g.
+// "HTTP/2.0".
+func (r ParsedResponses) CheckProtocol(expected string) error {
+	return r.Check(func(i int, resp *ParsedResponse) error {
+		if resp.Protocol != expected {
+			return fmt.Errorf("response[%d] Protocol: expected %s, received %s", i, expected, resp.Protocol)
+		}
+		return nil
+	})
+}
+
+func (r ParsedResponses) CheckProtocolOrFail(t test.Failer, expected string) ParsedResponses {
+	t.Helper()
+	if err := r.CheckProtocol(expected); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// CheckWebSocketMessages verifies that the server echoed back exactly the given frames, in order, for all
+// requests made with the WebSocket scheme.
+func (r ParsedResponses) CheckWebSocketMessages(expected ...string) error {
+	return r.Check(func(i int, resp *ParsedResponse) error {
+		if len(resp.WebSocketMessages) != len(expected) {
+			return fmt.Errorf("response[%d] WebSocket messages: expected %q, received %q",
+				i, expected, resp.WebSocketMessages)
+		}
+		for j, m := range resp.WebSocketMessages {
+			if m != expected[j] {
+				return fmt.Errorf("response[%d] WebSocket messages: expected %q, received %q",
+					i, expected, resp.WebSocketMessages)
+			}
+		}
+		return nil
+	})
+}
+
+func (r ParsedResponses) CheckWebSocketMessagesOrFail(t test.Failer, expected ...string) ParsedResponses {
+	t.Helper()
+	if err := r.CheckWebSocketMessages(expected...); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
 func (r ParsedResponses) CheckHost(expected string) error {
 	return r.Check(func(i int, response *ParsedResponse) error {
 		if response.Host != expected {
@@ -127,6 +376,91 @@ func (r ParsedResponses) CheckHostOrFail(t test.Failer, expected string) ParsedR
 	return r
 }
 
+// CheckSourceWorkload verifies that all of the requests were made by the workload with the given name.
+func (r ParsedResponses) CheckSourceWorkload(expected string) error {
+	return r.Check(func(i int, response *ParsedResponse) error {
+		if response.SourceWorkload != expected {
+			return fmt.Errorf("response[%d] SourceWorkload: expected %s, received %s", i, expected, response.SourceWorkload)
+		}
+		return nil
+	})
+}
+
+func (r ParsedResponses) CheckSourceWorkloadOrFail(t test.Failer, expected string) ParsedResponses {
+	t.Helper()
+	if err := r.CheckSourceWorkload(expected); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// CheckCluster verifies that all of the responses were served by an instance in the given cluster.
+func (r ParsedResponses) CheckCluster(expected string) error {
+	return r.Check(func(i int, response *ParsedResponse) error {
+		if response.Cluster != expected {
+			return fmt.Errorf("response[%d] Cluster: expected %s, received %s", i, expected, response.Cluster)
+		}
+		return nil
+	})
+}
+
+// CheckClusterOrFail calls CheckCluster and fails t if an error occurs.
+func (r ParsedResponses) CheckClusterOrFail(t test.Failer, expected string) ParsedResponses {
+	t.Helper()
+	if err := r.CheckCluster(expected); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// Clusters returns the number of responses served by each cluster.
+func (r ParsedResponses) Clusters() map[string]int {
+	clusters := make(map[string]int)
+	for _, response := range r {
+		clusters[response.Cluster]++
+	}
+	return clusters
+}
+
+// NewConnections returns the number of responses to requests that were sent over a new connection.
+func (r ParsedResponses) NewConnections() int {
+	count := 0
+	for _, response := range r {
+		if !response.ConnectionReused {
+			count++
+		}
+	}
+	return count
+}
+
+// CheckLocality verifies that all of the responses were served by an instance in the given locality.
+func (r ParsedResponses) CheckLocality(expected string) error {
+	return r.Check(func(i int, response *ParsedResponse) error {
+		if response.Locality != expected {
+			return fmt.Errorf("response[%d] Locality: expected %s, received %s", i, expected, response.Locality)
+		}
+		return nil
+	})
+}
+
+// CheckLocalityOrFail calls CheckLocality and fails t if an error occurs.
+func (r ParsedResponses) CheckLocalityOrFail(t test.Failer, expected string) ParsedResponses {
+	t.Helper()
+	if err := r.CheckLocality(expected); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// Localities returns the number of responses served by each locality.
+func (r ParsedResponses) Localities() map[string]int {
+	localities := make(map[string]int)
+	for _, response := range r {
+		localities[response.Locality]++
+	}
+	return localities
+}
+
 func (r ParsedResponses) CheckPort(expected int) error {
 	expectedStr := strconv.Itoa(expected)
 	return r.Check(func(i int, response *ParsedResponse) error {
@@ -177,6 +511,36 @@ func parseResponse(output string) *ParsedResponse {
 		out.Version = match[1]
 	}
 
+	match = clusterFieldRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.Cluster = match[1]
+	}
+
+	match = localityFieldRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.Locality = match[1]
+	}
+
+	match = drainingRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.Draining, _ = strconv.ParseBool(match[1])
+	}
+
+	match = inFlightRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.InFlight, _ = strconv.Atoi(match[1])
+	}
+
+	match = connectionReusedRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.ConnectionReused, _ = strconv.ParseBool(match[1])
+	}
+
+	match = latencyRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.Latency, _ = time.ParseDuration(match[1])
+	}
+
 	match = servicePortFieldRegex.FindStringSubmatch(output)
 	if match != nil {
 		out.Port = match[1]
@@ -197,5 +561,151 @@ func parseResponse(output string) *ParsedResponse {
 		out.Hostname = match[1]
 	}
 
+	match = sourcePrincipalRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.SourcePrincipal = match[1]
+	}
+
+	match = destPrincipalRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.DestinationPrincipal = match[1]
+	}
+
+	match = tcpResultRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.TCPResult = response.TCPResult(match[1])
+	}
+
+	match = tcpBytesEchoedRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.TCPBytesEchoed, _ = strconv.Atoi(match[1])
+	}
+
+	match = protocolRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.Protocol = match[1]
+	}
+
+	match = clientProtocolRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.ClientProtocol = match[1]
+	}
+
+	match = alpnRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.ALPN = match[1]
+	}
+
+	for _, m := range streamMessageRegex.FindAllStringSubmatch(output, -1) {
+		index, _ := strconv.Atoi(m[1])
+		latency, _ := time.ParseDuration(m[2])
+		out.StreamMessages = append(out.StreamMessages, StreamMessage{Index: index, Latency: latency})
+	}
+
+	match = streamCodeRegex.FindStringSubmatch(output)
+	if match != nil {
+		code, _ := strconv.Atoi(match[1])
+		out.StreamCode = codes.Code(code)
+	}
+
+	match = streamErrorRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.StreamError = match[1]
+	}
+
+	match = grpcCodeRegex.FindStringSubmatch(output)
+	if match != nil {
+		code, _ := strconv.Atoi(match[1])
+		out.GRPCStatus = &GRPCStatus{Code: codes.Code(code)}
+		match = grpcMessageRegex.FindStringSubmatch(output)
+		if match != nil {
+			out.GRPCStatus.Message = match[1]
+		}
+		for _, match := range grpcDetailRegex.FindAllStringSubmatch(output, -1) {
+			out.GRPCStatus.Details = append(out.GRPCStatus.Details, match[1])
+		}
+	}
+
+	match = sourceWorkloadRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.SourceWorkload = match[1]
+	}
+
+	match = ipFamilyRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.IPFamily = response.IPFamily(match[1])
+	}
+
+	for _, match := range resolvedAddressRegex.FindAllStringSubmatch(output, -1) {
+		out.ResolvedAddresses = append(out.ResolvedAddresses, match[1])
+	}
+
+	out.PeerCertificates = parseCertificates(peerCertificateRegex, output)
+	out.ServerCertificates = parseCertificates(serverCertificateRegex, output)
+
+	match = greetingLatencyRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.GreetingLatency, _ = time.ParseDuration(match[1])
+	}
+
+	match = udpMessageRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.UDPMessage = match[1]
+	}
+
+	for _, m := range webSocketMessageRegex.FindAllStringSubmatch(output, -1) {
+		out.WebSocketMessages = append(out.WebSocketMessages, m[1])
+	}
+
+	for _, m := range responseHeaderRegex.FindAllStringSubmatch(output, -1) {
+		if out.ResponseHeaders == nil {
+			out.ResponseHeaders = make(http.Header)
+		}
+		out.ResponseHeaders.Add(m[1], m[2])
+	}
+
+	match = bodySizeRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.BodySize, _ = strconv.Atoi(match[1])
+	}
+
+	match = requestCountRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.RequestCounts.Total, _ = strconv.Atoi(match[1])
+	}
+
+	match = mirroredCountRegex.FindStringSubmatch(output)
+	if match != nil {
+		out.RequestCounts.Mirrored, _ = strconv.Atoi(match[1])
+	}
+
+	for _, m := range capturedRequestRegex.FindAllStringSubmatch(output, -1) {
+		req := response.Request{}
+		if err := json.Unmarshal([]byte(m[1]), &req); err == nil {
+			out.CapturedRequests = append(out.CapturedRequests, req)
+		}
+	}
+
+	// Multiple values may be received if the header was appended by several hops; the last one is the most recent.
+	if matches := xfccHeaderRegex.FindAllStringSubmatch(output, -1); len(matches) > 0 {
+		out.ClientCert = common.LastXFCCElement(strings.TrimSpace(matches[len(matches)-1][1]))
+	}
+
 	return &out
 }
+
+// parseCertificates returns the certificates written as base64 encoded DER in the matches of the regex.
+// Fields that can't be decoded are skipped.
+func parseCertificates(regex *regexp.Regexp, output string) []*x509.Certificate {
+	var out []*x509.Certificate
+	for _, match := range regex.FindAllStringSubmatch(output, -1) {
+		der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(match[1]))
+		if err != nil {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(der); err == nil {
+			out = append(out, cert)
+		}
+	}
+	return out
+}
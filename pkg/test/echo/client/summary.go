@@ -0,0 +1,171 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test"
+)
+
+// Summary aggregates the responses to a batch of requests, e.g. a call made with a Count and a Concurrency,
+// so that assertions can be made about the success rate and the latency of the batch as a whole.
+type Summary struct {
+	// Total number of responses.
+	Total int
+	// Successes is the number of responses with status code 200.
+	Successes int
+	// Codes is the number of responses with each status code.
+	Codes map[string]int
+
+	// latencies of all responses, in ascending order.
+	latencies []time.Duration
+}
+
+// Summary of the responses.
+func (r ParsedResponses) Summary() *Summary {
+	s := &Summary{
+		Total:     len(r),
+		Codes:     make(map[string]int),
+		latencies: make([]time.Duration, 0, len(r)),
+	}
+	for _, resp := range r {
+		if resp.IsOK() {
+			s.Successes++
+		}
+		s.Codes[resp.Code]++
+		s.latencies = append(s.latencies, resp.Latency)
+	}
+	sort.Slice(s.latencies, func(i, j int) bool {
+		return s.latencies[i] < s.latencies[j]
+	})
+	return s
+}
+
+// SuccessRate is the fraction of successful responses, between 0 and 1.
+func (s *Summary) SuccessRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Successes) / float64(s.Total)
+}
+
+// Percentile returns the latency below or at which the given percentage (between 0 and 100) of responses
+// were received, using the nearest rank method.
+func (s *Summary) Percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(s.latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(s.latencies) {
+		rank = len(s.latencies)
+	}
+	return s.latencies[rank-1]
+}
+
+// P50 is the median latency.
+func (s *Summary) P50() time.Duration {
+	return s.Percentile(50)
+}
+
+// P90 is the 90th percentile latency.
+func (s *Summary) P90() time.Duration {
+	return s.Percentile(90)
+}
+
+// P99 is the 99th percentile latency.
+func (s *Summary) P99() time.Duration {
+	return s.Percentile(99)
+}
+
+// Max is the highest latency.
+func (s *Summary) Max() time.Duration {
+	return s.Percentile(100)
+}
+
+// CheckSuccessRate verifies that at least the given fraction of responses were successful.
+func (s *Summary) CheckSuccessRate(min float64) error {
+	if rate := s.SuccessRate(); rate < min {
+		return fmt.Errorf("success rate %.3f below %.3f: %s", rate, min, s)
+	}
+	return nil
+}
+
+// CheckSuccessRateOrFail calls CheckSuccessRate and fails t if an error occurs.
+func (s *Summary) CheckSuccessRateOrFail(t test.Failer, min float64) *Summary {
+	t.Helper()
+	if err := s.CheckSuccessRate(min); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// CheckLatency verifies that the latency at the given percentile does not exceed max.
+func (s *Summary) CheckLatency(percentile float64, max time.Duration) error {
+	if l := s.Percentile(percentile); l > max {
+		return fmt.Errorf("p%v latency %v exceeds %v: %s", percentile, l, max, s)
+	}
+	return nil
+}
+
+// CheckLatencyOrFail calls CheckLatency and fails t if an error occurs.
+func (s *Summary) CheckLatencyOrFail(t test.Failer, percentile float64, max time.Duration) *Summary {
+	t.Helper()
+	if err := s.CheckLatency(percentile, max); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// CheckLatencyOverhead verifies that the latency at the given percentile exceeds the one of the baseline by
+// at most max. This allows to assert on the latency added by a feature, e.g. JWT validation, by comparing
+// against the same batch of requests made without the feature.
+func (s *Summary) CheckLatencyOverhead(baseline *Summary, percentile float64, max time.Duration) error {
+	l, b := s.Percentile(percentile), baseline.Percentile(percentile)
+	if l-b > max {
+		return fmt.Errorf("p%v latency %v exceeds the baseline %v by more than %v", percentile, l, b, max)
+	}
+	return nil
+}
+
+// CheckLatencyOverheadOrFail calls CheckLatencyOverhead and fails t if an error occurs.
+func (s *Summary) CheckLatencyOverheadOrFail(t test.Failer, baseline *Summary, percentile float64,
+	max time.Duration) *Summary {
+	t.Helper()
+	if err := s.CheckLatencyOverhead(baseline, percentile, max); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (s *Summary) String() string {
+	codes := make([]string, 0, len(s.Codes))
+	for code := range s.Codes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for i, code := range codes {
+		codes[i] = fmt.Sprintf("%s:%d", code, s.Codes[code])
+	}
+	return fmt.Sprintf("total=%d success=%.3f codes=[%s] p50=%v p90=%v p99=%v max=%v",
+		s.Total, s.SuccessRate(), strings.Join(codes, " "), s.P50(), s.P90(), s.P99(), s.Max())
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	var responses ParsedResponses
+	for i := 1; i <= 100; i++ {
+		code := "200"
+		if i%10 == 0 {
+			code = "503"
+		}
+		responses = append(responses, &ParsedResponse{Code: code, Latency: time.Duration(i) * time.Millisecond})
+	}
+
+	s := responses.Summary()
+	if s.Total != 100 || s.Successes != 90 {
+		t.Fatalf("unexpected counts: %s", s)
+	}
+	if s.Codes["200"] != 90 || s.Codes["503"] != 10 {
+		t.Fatalf("unexpected codes: %v", s.Codes)
+	}
+	if s.P50() != 50*time.Millisecond || s.P99() != 99*time.Millisecond || s.Max() != 100*time.Millisecond {
+		t.Fatalf("unexpected percentiles: %s", s)
+	}
+
+	if err := s.CheckSuccessRate(0.9); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckSuccessRate(0.95); err == nil {
+		t.Fatal("expected success rate check to fail")
+	}
+	if err := s.CheckLatency(90, 90*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckLatency(99, 90*time.Millisecond); err == nil {
+		t.Fatal("expected latency check to fail")
+	}
+
+	baseline := ParsedResponses{{Code: "200", Latency: 95 * time.Millisecond}}.Summary()
+	if err := s.CheckLatencyOverhead(baseline, 99, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CheckLatencyOverhead(baseline, 99, 3*time.Millisecond); err == nil {
+		t.Fatal("expected latency overhead check to fail")
+	}
+}
+
+func TestSummaryEmpty(t *testing.T) {
+	s := ParsedResponses{}.Summary()
+	if s.SuccessRate() != 0 || s.P99() != 0 {
+		t.Fatalf("unexpected summary: %s", s)
+	}
+}
@@ -19,6 +19,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
@@ -42,9 +43,27 @@ var (
 	headerVal string
 	headers   string
 	msg       string
+	http2     bool
+	alpn      []string
+
+	concurrency int
+
+	newConnectionPerRequest bool
+	reuseConnection         bool
+	reportGRPCStatus        bool
+
+	streamMessages  int
+	serverStreaming bool
+	streamInterval  time.Duration
 
 	caFile string
 
+	clientCert string
+	clientKey  string
+	caCert     string
+	serverName string
+	proxy      string
+
 	loggingOptions = log.DefaultOptions()
 
 	rootCmd = &cobra.Command{
@@ -118,6 +137,34 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&caFile, "ca", "/cert.crt", "CA root cert file")
 	rootCmd.PersistentFlags().StringVar(&msg, "msg", "HelloWorld",
 		"message to send (for websockets)")
+	rootCmd.PersistentFlags().BoolVar(&http2, "http2", false,
+		"send HTTP/2 requests: h2c with prior knowledge for http URLs, h2 over ALPN for https URLs")
+	rootCmd.PersistentFlags().StringSliceVar(&alpn, "alpn", nil,
+		"ALPN protocols to offer for TLS requests")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 0,
+		"maximum number of requests in flight (0 to send all requests in parallel)")
+	rootCmd.PersistentFlags().BoolVar(&newConnectionPerRequest, "new-connection-per-request", false,
+		"open a new connection for every request")
+	rootCmd.PersistentFlags().BoolVar(&reuseConnection, "reuse-connection", false,
+		"send the requests one after another over a single pooled connection")
+	rootCmd.PersistentFlags().BoolVar(&reportGRPCStatus, "grpc-status", false,
+		"report the status of gRPC calls, rather than failing on unary calls failing with a status")
+	rootCmd.PersistentFlags().IntVar(&streamMessages, "stream-messages", 0,
+		"number of messages to exchange over a stream for each gRPC request (0 for unary calls)")
+	rootCmd.PersistentFlags().BoolVar(&serverStreaming, "server-streaming", false,
+		"use server streaming instead of bidirectional streaming for gRPC stream requests")
+	rootCmd.PersistentFlags().DurationVar(&streamInterval, "stream-interval", 0,
+		"interval between the messages of a gRPC stream")
+	rootCmd.PersistentFlags().StringVar(&clientCert, "client-cert", "",
+		"client certificate file presented for TLS requests")
+	rootCmd.PersistentFlags().StringVar(&clientKey, "client-key", "",
+		"client key file for --client-cert")
+	rootCmd.PersistentFlags().StringVar(&caCert, "ca-cert", "",
+		"root certificate file used to verify the server of TLS requests (not verified if empty)")
+	rootCmd.PersistentFlags().StringVar(&serverName, "server-name", "",
+		"TLS server name (SNI) sent by TLS requests, if different from the host")
+	rootCmd.PersistentFlags().StringVar(&proxy, "proxy", "",
+		"URL of an http or socks5 forward proxy to send the requests through")
 
 	loggingOptions.AttachCobraFlags(rootCmd)
 
@@ -130,7 +177,21 @@ func getRequest() (*proto.ForwardEchoRequest, error) {
 		TimeoutMicros: common.DurationToMicros(timeout),
 		Count:         int32(count),
 		Qps:           int32(qps),
+		Concurrency:   int32(concurrency),
 		Message:       msg,
+		Http2:         http2,
+		Alpn:          alpn,
+
+		NewConnectionPerRequest: newConnectionPerRequest,
+		ReuseConnection:         reuseConnection,
+		ReportGrpcStatus:        reportGRPCStatus,
+
+		StreamMessages:       int32(streamMessages),
+		ServerStreaming:      serverStreaming,
+		StreamIntervalMicros: common.DurationToMicros(streamInterval),
+
+		ServerName: serverName,
+		Proxy:      proxy,
 	}
 
 	// Old http add header - deprecated
@@ -157,6 +218,25 @@ func getRequest() (*proto.ForwardEchoRequest, error) {
 			})
 		}
 	}
+
+	// Load the TLS certificates.
+	for _, f := range []struct {
+		file string
+		out  *string
+	}{
+		{clientCert, &request.Cert},
+		{clientKey, &request.Key},
+		{caCert, &request.CaCert},
+	} {
+		if f.file == "" {
+			continue
+		}
+		content, err := ioutil.ReadFile(f.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading %s: %v", f.file, err)
+		}
+		*f.out = string(content)
+	}
 	return request, nil
 }
 
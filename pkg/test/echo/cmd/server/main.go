@@ -19,6 +19,7 @@ import (
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -30,12 +31,20 @@ import (
 )
 
 var (
-	httpPorts []int
-	grpcPorts []int
-	uds       string
-	version   string
-	crt       string
-	key       string
+	httpPorts    []int
+	grpcPorts    []int
+	udpPorts     []int
+	sfPorts      []int
+	thriftPorts  []int
+	dubboPorts   []int
+	tlsPorts     []int
+	uds          string
+	version      string
+	cluster      string
+	locality     string
+	crt          string
+	key          string
+	drainTimeout time.Duration
 
 	loggingOptions = log.DefaultOptions()
 
@@ -46,7 +55,7 @@ var (
 		Long:              `Echo application for testing Istio E2E`,
 		PersistentPreRunE: configureLogging,
 		Run: func(cmd *cobra.Command, args []string) {
-			ports := make(model.PortList, len(httpPorts)+len(grpcPorts))
+			ports := make(model.PortList, len(httpPorts)+len(grpcPorts)+len(udpPorts)+len(sfPorts)+len(thriftPorts)+len(dubboPorts))
 			portIndex := 0
 			for i, p := range httpPorts {
 				ports[portIndex] = &model.Port{
@@ -65,11 +74,55 @@ var (
 				portIndex++
 			}
 
+			for i, p := range udpPorts {
+				ports[portIndex] = &model.Port{
+					Name:     "udp-" + strconv.Itoa(i),
+					Protocol: protocol.UDP,
+					Port:     p,
+				}
+				portIndex++
+			}
+
+			for i, p := range sfPorts {
+				ports[portIndex] = &model.Port{
+					Name:     "mysql-" + strconv.Itoa(i),
+					Protocol: protocol.MySQL,
+					Port:     p,
+				}
+				portIndex++
+			}
+
+			for i, p := range thriftPorts {
+				ports[portIndex] = &model.Port{
+					Name:     "thrift-" + strconv.Itoa(i),
+					Protocol: protocol.Thrift,
+					Port:     p,
+				}
+				portIndex++
+			}
+
+			for i, p := range dubboPorts {
+				ports[portIndex] = &model.Port{
+					Name:     "dubbo-" + strconv.Itoa(i),
+					Protocol: protocol.Dubbo,
+					Port:     p,
+				}
+				portIndex++
+			}
+
+			// For compatibility, all gRPC ports serve TLS if a certificate is given without any TLS ports.
+			if len(tlsPorts) == 0 && crt != "" && key != "" {
+				tlsPorts = grpcPorts
+			}
+
 			s := server.New(server.Config{
 				Ports:     ports,
 				TLSCert:   crt,
 				TLSKey:    key,
+				TLSPorts:  tlsPorts,
 				Version:   version,
+				Cluster:   cluster,
+				Locality:  locality,
 				UDSServer: uds,
 			})
 
@@ -81,10 +134,16 @@ var (
 				_ = s.Close()
 			}()
 
-			// Wait for the process to be shutdown.
+			// Wait for the process to be shutdown, or for the server to be drained through its drain path.
 			sigs := make(chan os.Signal, 1)
 			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-			<-sigs
+			select {
+			case sig := <-sigs:
+				if sig == syscall.SIGTERM && drainTimeout > 0 {
+					_ = s.Drain(drainTimeout)
+				}
+			case <-s.Done():
+			}
 		},
 	}
 )
@@ -99,8 +158,19 @@ func configureLogging(_ *cobra.Command, _ []string) error {
 func init() {
 	rootCmd.PersistentFlags().IntSliceVar(&httpPorts, "port", []int{8080}, "HTTP/1.1 ports")
 	rootCmd.PersistentFlags().IntSliceVar(&grpcPorts, "grpc", []int{7070}, "GRPC ports")
+	rootCmd.PersistentFlags().IntSliceVar(&udpPorts, "udp", []int{}, "UDP ports")
+	rootCmd.PersistentFlags().IntSliceVar(&sfPorts, "server-first", []int{},
+		"Server-first TCP ports, on which the server sends a greeting before the client sends anything")
+	rootCmd.PersistentFlags().IntSliceVar(&thriftPorts, "thrift", []int{}, "Thrift ports, serving framed binary Thrift")
+	rootCmd.PersistentFlags().IntSliceVar(&dubboPorts, "dubbo", []int{}, "Dubbo ports, serving Hessian2 serialized Dubbo")
 	rootCmd.PersistentFlags().StringVar(&uds, "uds", "", "HTTP server on unix domain socket")
 	rootCmd.PersistentFlags().StringVar(&version, "version", "", "Version string")
+	rootCmd.PersistentFlags().StringVar(&cluster, "cluster", "", "Cluster where this server is deployed")
+	rootCmd.PersistentFlags().StringVar(&locality, "locality", "", "Locality where this server is deployed")
+	rootCmd.PersistentFlags().IntSliceVar(&tlsPorts, "tls", []int{},
+		"Ports that serve TLS with the --crt and --key certificate. Defaults to the gRPC ports")
+	rootCmd.PersistentFlags().DurationVar(&drainTimeout, "drain-timeout", 0,
+		"On SIGTERM, how long to wait for the requests in flight to complete before exiting. Exits immediately if 0")
 	rootCmd.PersistentFlags().StringVar(&crt, "crt", "", "gRPC TLS server-side certificate")
 	rootCmd.PersistentFlags().StringVar(&key, "key", "", "gRPC TLS server-side key")
 
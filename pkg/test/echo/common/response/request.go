@@ -0,0 +1,57 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package response
+
+import (
+	"time"
+)
+
+// CapturedRequestField reports a request received by the echo server, as the JSON encoding of a Request, in
+// the responses of common.RequestsPath.
+const CapturedRequestField Field = "CapturedRequest"
+
+const (
+	// RequestCountField reports the number of requests counted for common.CountsPath.
+	RequestCountField Field = "RequestCount"
+	// MirroredCountField reports the number of mirrored requests counted for common.CountsPath.
+	MirroredCountField Field = "MirroredCount"
+)
+
+// Request received by the echo server, as captured for inspection by tests. Unlike the echoed response, it
+// reports the request exactly as the server received it, e.g. after the sidecar stripped or added headers.
+type Request struct {
+	// Time the request was received.
+	Time time.Time `json:"time"`
+	// Protocol of the request, e.g. "HTTP/1.1", or "GRPC" for gRPC calls.
+	Protocol string `json:"protocol"`
+	// Method of HTTP requests, or the full method of gRPC calls.
+	Method string `json:"method"`
+	// Host (or authority) of the request.
+	Host string `json:"host"`
+	// URL of HTTP requests, including the query.
+	URL string `json:"url,omitempty"`
+	// Port the request was received on. Zero for the Unix domain socket.
+	Port int `json:"port"`
+	// Headers of HTTP requests, or the metadata of gRPC calls.
+	Headers map[string][]string `json:"headers"`
+}
+
+// RequestCounts of the requests received by an echo server, as reported for common.CountsPath.
+type RequestCounts struct {
+	// Total number of requests.
+	Total int
+	// Mirrored is the number of requests that were mirrored to the server.
+	Mirrored int
+}
@@ -29,10 +29,69 @@ var (
 type Field string
 
 const (
-	RequestIDField      Field = "X-Request-Id"
-	ServiceVersionField Field = "ServiceVersion"
-	ServicePortField    Field = "ServicePort"
-	StatusCodeField     Field = "StatusCode"
-	HostField           Field = "Host"
-	HostnameField       Field = "Hostname"
+	RequestIDField            Field = "X-Request-Id"
+	ServiceVersionField       Field = "ServiceVersion"
+	ClusterField              Field = "Cluster"
+	LocalityField             Field = "Locality"
+	ServicePortField          Field = "ServicePort"
+	StatusCodeField           Field = "StatusCode"
+	HostField                 Field = "Host"
+	HostnameField             Field = "Hostname"
+	SourcePrincipalField      Field = "SourcePrincipal"
+	DestinationPrincipalField Field = "DestinationPrincipal"
+	TCPResultField            Field = "TCPResult"
+	TCPBytesEchoedField       Field = "TCPBytesEchoed"
+	TCPErrorField             Field = "TCPError"
+	WebSocketMessageField     Field = "WebSocketMessage"
+	ProtocolField             Field = "Proto"
+	ClientProtocolField       Field = "ClientProtocol"
+	ALPNField                 Field = "ALPN"
+	UDPMessageField           Field = "UDPMessage"
+	RPCMessageField           Field = "RPCMessage"
+	StreamMessageField        Field = "StreamMessage"
+	StreamCodeField           Field = "StreamCode"
+	StreamErrorField          Field = "StreamError"
+	GreetingLatencyField      Field = "GreetingLatency"
+	SourceWorkloadField       Field = "SourceWorkload"
+	PeerCertificateField      Field = "PeerCertificate"
+	ServerCertificateField    Field = "ServerCertificate"
+	IPFamilyField             Field = "IPFamily"
+	ResolvedAddressField      Field = "ResolvedAddress"
+	DrainingField             Field = "Draining"
+	InFlightField             Field = "InFlight"
+	ConnectionReusedField     Field = "ConnectionReused"
+	LatencyField              Field = "Latency"
+	GRPCCodeField             Field = "GrpcCode"
+	GRPCMessageField          Field = "GrpcMessage"
+	GRPCDetailField           Field = "GrpcDetail"
+	ResponseHeaderField       Field = "ResponseHeader"
+	BodySizeField             Field = "BodySize"
+	PaddingField              Field = "Padding"
+)
+
+// IPFamily of an address, named like the IP families of Kubernetes services.
+type IPFamily string
+
+const (
+	IPv4 IPFamily = "IPv4"
+	IPv6 IPFamily = "IPv6"
+)
+
+// TCPResult is the connection level outcome of a request made with the TCP scheme.
+type TCPResult string
+
+const (
+	// TCPResultOK indicates that a response was read and the connection was closed by the server.
+	TCPResultOK TCPResult = "ok"
+	// TCPResultRefused indicates that the connection could not be established.
+	TCPResultRefused TCPResult = "refused"
+	// TCPResultReset indicates that the connection was reset after it was established.
+	TCPResultReset TCPResult = "reset"
+	// TCPResultClosed indicates that the connection was closed after it was established, before a complete
+	// response was read.
+	TCPResultClosed TCPResult = "closed"
+	// TCPResultTimeout indicates that the request timed out while connecting or waiting for a response.
+	TCPResultTimeout TCPResult = "timeout"
+	// TCPResultError indicates any other failure.
+	TCPResultError TCPResult = "error"
 )
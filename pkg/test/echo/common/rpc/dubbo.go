@@ -0,0 +1,229 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+const (
+	dubboMagic      = 0xdabb
+	dubboHeaderSize = 16
+
+	dubboFlagRequest = 0x80
+	dubboFlagTwoWay  = 0x40
+	dubboHessian2    = 2
+
+	dubboStatusOK = 20
+
+	// dubboResponseValue is the Hessian2 compact encoding of the int 1, which precedes a response value.
+	dubboResponseValue = 0x91
+
+	dubboVersion = "2.0.2"
+	// DubboService is the interface invoked by the echo client.
+	DubboService        = "io.istio.echo.EchoTestService"
+	dubboServiceVersion = "0.0.0"
+	dubboStringArg      = "Ljava/lang/String;"
+)
+
+var _ Codec = dubboCodec{}
+
+// dubboCodec encodes a request as a two-way invocation of a method with a single string argument, and a
+// response as a successful string value.
+type dubboCodec struct{}
+
+func (dubboCodec) WriteRequest(w io.Writer, m Message) error {
+	var body bytes.Buffer
+	for _, s := range []string{dubboVersion, DubboService, dubboServiceVersion, m.Method, dubboStringArg, m.Payload} {
+		writeHessianString(&body, s)
+	}
+	// Attachments.
+	body.WriteByte('H')
+	for _, s := range []string{"path", DubboService, "interface", DubboService, "version", dubboServiceVersion} {
+		writeHessianString(&body, s)
+	}
+	body.WriteByte('Z')
+	return writeDubbo(w, dubboFlagRequest|dubboFlagTwoWay|dubboHessian2, 0, m.ID, body.Bytes())
+}
+
+func (dubboCodec) ReadRequest(r io.Reader) (Message, error) {
+	flags, _, id, body, err := readDubbo(r)
+	if err != nil {
+		return Message{}, err
+	}
+	if flags&dubboFlagRequest == 0 {
+		return Message{}, fmt.Errorf("expected a dubbo request")
+	}
+
+	// The body starts with the version, service, service version, method, argument types and arguments.
+	// The attachments that follow are ignored.
+	b := bufio.NewReader(bytes.NewReader(body))
+	fields := make([]string, 6)
+	for i := range fields {
+		if fields[i], err = readHessianString(b); err != nil {
+			return Message{}, err
+		}
+	}
+	if fields[4] != dubboStringArg {
+		return Message{}, fmt.Errorf("unsupported dubbo arguments %q", fields[4])
+	}
+	return Message{
+		ID:      id,
+		Method:  fields[3],
+		Payload: fields[5],
+	}, nil
+}
+
+func (dubboCodec) WriteResponse(w io.Writer, m Message) error {
+	var body bytes.Buffer
+	body.WriteByte(dubboResponseValue)
+	writeHessianString(&body, m.Payload)
+	return writeDubbo(w, dubboHessian2, dubboStatusOK, m.ID, body.Bytes())
+}
+
+func (dubboCodec) ReadResponse(r io.Reader) (Message, error) {
+	flags, status, id, body, err := readDubbo(r)
+	if err != nil {
+		return Message{}, err
+	}
+	if flags&dubboFlagRequest != 0 {
+		return Message{}, fmt.Errorf("expected a dubbo response")
+	}
+	if status != dubboStatusOK {
+		return Message{}, fmt.Errorf("dubbo response status %d", status)
+	}
+	if len(body) == 0 || body[0] != dubboResponseValue {
+		return Message{}, fmt.Errorf("dubbo response has no value")
+	}
+	payload, err := readHessianString(bufio.NewReader(bytes.NewReader(body[1:])))
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{
+		ID:      id,
+		Payload: payload,
+	}, nil
+}
+
+func writeDubbo(w io.Writer, flags, status byte, id int64, body []byte) error {
+	header := make([]byte, dubboHeaderSize, dubboHeaderSize+len(body))
+	binary.BigEndian.PutUint16(header[0:], dubboMagic)
+	header[2] = flags
+	header[3] = status
+	binary.BigEndian.PutUint64(header[4:], uint64(id))
+	binary.BigEndian.PutUint32(header[12:], uint32(len(body)))
+	_, err := w.Write(append(header, body...))
+	return err
+}
+
+func readDubbo(r io.Reader) (flags, status byte, id int64, body []byte, err error) {
+	header := make([]byte, dubboHeaderSize)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return
+	}
+	if binary.BigEndian.Uint16(header[0:]) != dubboMagic {
+		err = fmt.Errorf("invalid dubbo magic %#x", header[0:2])
+		return
+	}
+	flags = header[2]
+	status = header[3]
+	id = int64(binary.BigEndian.Uint64(header[4:]))
+	size := binary.BigEndian.Uint32(header[12:])
+	if size > maxMessageSize {
+		err = fmt.Errorf("dubbo body of %d bytes exceeds the maximum size", size)
+		return
+	}
+	body = make([]byte, size)
+	_, err = io.ReadFull(r, body)
+	return
+}
+
+// writeHessianString writes s as a Hessian2 string. Hessian2 counts the length in UTF-16 code units, this
+// implementation counts runes, which is only equivalent for the basic multilingual plane.
+func writeHessianString(b *bytes.Buffer, s string) {
+	for {
+		length := utf8.RuneCountInString(s)
+		switch {
+		case length <= 31:
+			b.WriteByte(byte(length))
+		case length <= 1023:
+			b.WriteByte(byte(0x30 + length>>8))
+			b.WriteByte(byte(length))
+		case length <= 0xffff:
+			b.WriteByte('S')
+			_ = binary.Write(b, binary.BigEndian, uint16(length))
+		default:
+			// Write a non-final chunk and continue with the remainder.
+			chunk := 0
+			for i := 0; i < 0xffff; i++ {
+				_, size := utf8.DecodeRuneInString(s[chunk:])
+				chunk += size
+			}
+			b.WriteByte('R')
+			_ = binary.Write(b, binary.BigEndian, uint16(0xffff))
+			b.WriteString(s[:chunk])
+			s = s[chunk:]
+			continue
+		}
+		b.WriteString(s)
+		return
+	}
+}
+
+func readHessianString(r *bufio.Reader) (string, error) {
+	var out bytes.Buffer
+	for {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		var length int
+		final := true
+		switch {
+		case tag <= 0x1f:
+			length = int(tag)
+		case tag >= 0x30 && tag <= 0x33:
+			next, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			length = int(tag-0x30)<<8 | int(next)
+		case tag == 'S' || tag == 'R':
+			var l uint16
+			if err := binary.Read(r, binary.BigEndian, &l); err != nil {
+				return "", err
+			}
+			length = int(l)
+			final = tag == 'S'
+		default:
+			return "", fmt.Errorf("unexpected hessian tag %#x, expected a string", tag)
+		}
+		for i := 0; i < length; i++ {
+			c, _, err := r.ReadRune()
+			if err != nil {
+				return "", err
+			}
+			out.WriteRune(c)
+		}
+		if final {
+			return out.String(), nil
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rpc implements minimal codecs for the RPC protocols supported by the echo application. Each
+// message carries a single string, which is enough for the proxy to recognize the protocol and for the
+// echo server to return the usual response fields.
+package rpc
+
+import (
+	"io"
+)
+
+// Message exchanged by the echo client and server.
+type Message struct {
+	// ID correlates a response with its request.
+	ID int64
+	// Method that is invoked.
+	Method string
+	// Payload is the single string argument of a request, or the result of a response.
+	Payload string
+}
+
+// Codec reads and writes the requests and responses of an RPC protocol.
+type Codec interface {
+	WriteRequest(w io.Writer, m Message) error
+	ReadRequest(r io.Reader) (Message, error)
+	WriteResponse(w io.Writer, m Message) error
+	ReadResponse(r io.Reader) (Message, error)
+}
+
+const (
+	// EchoMethod is the method invoked by the echo client.
+	EchoMethod = "echo"
+
+	// maxMessageSize bounds the size of the messages that are read, to protect against corrupt frames.
+	maxMessageSize = 1 << 20
+)
+
+var (
+	// Thrift uses the framed transport with the strict binary protocol.
+	Thrift Codec = thriftCodec{}
+	// Dubbo uses the Dubbo header with a Hessian2 serialized body.
+	Dubbo Codec = dubboCodec{}
+)
@@ -0,0 +1,72 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		codec Codec
+	}{
+		{"thrift", Thrift},
+		{"dubbo", Dubbo},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			for _, payload := range []string{"", "hello", strings.Repeat("x", 500), strings.Repeat("é", 70000)} {
+				var b bytes.Buffer
+				req := Message{ID: 7, Method: EchoMethod, Payload: payload}
+				if err := c.codec.WriteRequest(&b, req); err != nil {
+					t.Fatal(err)
+				}
+				got, err := c.codec.ReadRequest(&b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != req {
+					t.Fatalf("request: got %+v, want %+v", got, req)
+				}
+
+				resp := Message{ID: 7, Payload: payload}
+				if err := c.codec.WriteResponse(&b, resp); err != nil {
+					t.Fatal(err)
+				}
+				got, err = c.codec.ReadResponse(&b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.ID != resp.ID || got.Payload != resp.Payload {
+					t.Fatalf("response: got %+v, want %+v", got, resp)
+				}
+			}
+		})
+	}
+}
+
+func TestReadRejectsWrongDirection(t *testing.T) {
+	for _, codec := range []Codec{Thrift, Dubbo} {
+		var b bytes.Buffer
+		if err := codec.WriteResponse(&b, Message{ID: 1, Payload: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := codec.ReadRequest(&b); err == nil {
+			t.Fatalf("%T: expected an error reading a response as a request", codec)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	thriftVersion1    = 0x80010000
+	thriftVersionMask = 0xffff0000
+
+	thriftCall  = 1
+	thriftReply = 2
+
+	thriftTypeStop   = 0
+	thriftTypeString = 11
+
+	// The arguments of a call and the result of a reply are both structs with a single string field.
+	thriftArgFieldID    = 1
+	thriftResultFieldID = 0
+)
+
+var _ Codec = thriftCodec{}
+
+// thriftCodec encodes a request as a call with a single string argument, and a response as a reply with a
+// string result.
+type thriftCodec struct{}
+
+func (thriftCodec) WriteRequest(w io.Writer, m Message) error {
+	return writeThrift(w, thriftCall, thriftArgFieldID, m)
+}
+
+func (thriftCodec) ReadRequest(r io.Reader) (Message, error) {
+	return readThrift(r, thriftCall)
+}
+
+func (thriftCodec) WriteResponse(w io.Writer, m Message) error {
+	return writeThrift(w, thriftReply, thriftResultFieldID, m)
+}
+
+func (thriftCodec) ReadResponse(r io.Reader) (Message, error) {
+	return readThrift(r, thriftReply)
+}
+
+func writeThrift(w io.Writer, messageType uint32, fieldID int16, m Message) error {
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.BigEndian, uint32(thriftVersion1|messageType))
+	writeThriftString(&b, m.Method)
+	_ = binary.Write(&b, binary.BigEndian, int32(m.ID))
+	b.WriteByte(thriftTypeString)
+	_ = binary.Write(&b, binary.BigEndian, fieldID)
+	writeThriftString(&b, m.Payload)
+	b.WriteByte(thriftTypeStop)
+
+	// Framed transport: the message is prefixed with its length.
+	frame := make([]byte, 4, 4+b.Len())
+	binary.BigEndian.PutUint32(frame, uint32(b.Len()))
+	_, err := w.Write(append(frame, b.Bytes()...))
+	return err
+}
+
+func writeThriftString(b *bytes.Buffer, s string) {
+	_ = binary.Write(b, binary.BigEndian, int32(len(s)))
+	b.WriteString(s)
+}
+
+func readThrift(r io.Reader, messageType uint32) (Message, error) {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return Message{}, err
+	}
+	if size > maxMessageSize {
+		return Message{}, fmt.Errorf("thrift frame of %d bytes exceeds the maximum size", size)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return Message{}, err
+	}
+	b := bytes.NewReader(frame)
+
+	var version uint32
+	if err := binary.Read(b, binary.BigEndian, &version); err != nil {
+		return Message{}, err
+	}
+	if version&thriftVersionMask != thriftVersion1 {
+		return Message{}, fmt.Errorf("unsupported thrift version %#x", version)
+	}
+	if version&^thriftVersionMask != messageType {
+		return Message{}, fmt.Errorf("unexpected thrift message type %d", version&^thriftVersionMask)
+	}
+
+	m := Message{}
+	var err error
+	if m.Method, err = readThriftString(b); err != nil {
+		return Message{}, err
+	}
+	var seqID int32
+	if err := binary.Read(b, binary.BigEndian, &seqID); err != nil {
+		return Message{}, err
+	}
+	m.ID = int64(seqID)
+
+	// Read the fields of the struct until the stop field. Only string fields are supported.
+	for {
+		fieldType, err := b.ReadByte()
+		if err != nil {
+			return Message{}, err
+		}
+		if fieldType == thriftTypeStop {
+			return m, nil
+		}
+		if fieldType != thriftTypeString {
+			return Message{}, fmt.Errorf("unsupported thrift field type %d", fieldType)
+		}
+		var fieldID int16
+		if err := binary.Read(b, binary.BigEndian, &fieldID); err != nil {
+			return Message{}, err
+		}
+		if m.Payload, err = readThriftString(b); err != nil {
+			return Message{}, err
+		}
+	}
+}
+
+func readThriftString(r *bytes.Reader) (string, error) {
+	var size int32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return "", err
+	}
+	if size < 0 || int(size) > r.Len() {
+		return "", fmt.Errorf("invalid thrift string length %d", size)
+	}
+	s := make([]byte, size)
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
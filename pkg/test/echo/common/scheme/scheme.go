@@ -24,4 +24,18 @@ const (
 	GRPCS      Instance = "grpcs"
 	WebSocket  Instance = "ws"
 	WebSocketS Instance = "wss"
+	// TCP sends a single request over a raw TCP connection and reports connection level failures in the
+	// response, rather than failing the call.
+	TCP Instance = "tcp"
+	// TCPServerFirst waits for the greeting of a server-first protocol before sending the request over a
+	// raw TCP connection. Connection level failures are reported like for TCP.
+	TCPServerFirst Instance = "tcp-server-first"
+	// UDP sends the message of the request as a single datagram and waits for it to be echoed back.
+	UDP Instance = "udp"
+	// DNS resolves the host of the request from the calling workload, without sending a request to it.
+	DNS Instance = "dns"
+	// Thrift invokes the echo method with the message of the request over framed binary Thrift.
+	Thrift Instance = "thrift"
+	// Dubbo invokes the echo method with the message of the request over Dubbo with Hessian2 serialization.
+	Dubbo Instance = "dubbo"
 )
@@ -25,6 +25,35 @@ const (
 	ConnectionTimeout     = 1 * time.Minute
 	DefaultRequestTimeout = 15 * time.Second
 	DefaultCount          = 1
+	DefaultDrainTimeout   = 30 * time.Second
+
+	// DrainPath of the HTTP endpoints reports whether the server is draining and the number of requests in
+	// flight. With the start query parameter, it also starts draining the server, waiting for the requests in
+	// flight for the duration given by the timeout query parameter (DefaultDrainTimeout if not set) before
+	// shutting down.
+	DrainPath = "/drain"
+
+	// RequestsPath of the HTTP endpoints reports the most recent requests received by all endpoints of the
+	// server, oldest first, each in a response.CapturedRequestField. The count query parameter limits the
+	// number of requests reported. Requests to the drain and requests paths are not recorded.
+	RequestsPath = "/requests"
+
+	// CountsPath of the HTTP endpoints reports the number of requests received by all endpoints of the server
+	// since it started, and how many of them were mirrored, i.e. had a Host with the ShadowHostSuffix. The
+	// path query parameter only counts the requests whose path has the given prefix. Like for RequestsPath,
+	// requests to the drain, requests and counts paths are not counted.
+	CountsPath = "/counts"
+
+	// ShadowHostSuffix is appended by Envoy to the Host of mirrored requests.
+	ShadowHostSuffix = "-shadow"
+
+	// StreamEventsParam of HTTP requests makes the server stream the given number of server-sent events after
+	// the echoed body, each on a line with the StreamEventPrefix, over a long-lived response.
+	StreamEventsParam = "events"
+	// StreamIntervalParam of HTTP requests is the duration between the streamed events.
+	StreamIntervalParam = "interval"
+	// StreamEventPrefix of the lines of server-sent events.
+	StreamEventPrefix = "data: "
 )
 
 // FillInDefaults fills in the timeout and count if not specified in the given message.
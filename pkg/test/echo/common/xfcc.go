@@ -0,0 +1,98 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"strings"
+)
+
+const (
+	// XFCCHeader is the header used by Envoy to forward the details of the verified client certificate.
+	XFCCHeader = "X-Forwarded-Client-Cert"
+)
+
+// XFCCElement is a single element of an X-Forwarded-Client-Cert header value, added by one proxy hop.
+type XFCCElement struct {
+	// By is the URI SAN of the certificate of the proxy that added the element (i.e. the destination).
+	By string
+	// Hash is the hex encoded SHA256 digest of the client certificate.
+	Hash string
+	// Subject of the client certificate.
+	Subject string
+	// URI SAN of the client certificate (i.e. the source identity).
+	URI string
+	// DNS SANs of the client certificate.
+	DNS []string
+}
+
+// ParseXFCC parses the given X-Forwarded-Client-Cert header value into its elements, in the order they were
+// added. Unknown keys are ignored.
+func ParseXFCC(value string) []XFCCElement {
+	out := make([]XFCCElement, 0)
+	if strings.TrimSpace(value) == "" {
+		return out
+	}
+
+	for _, element := range splitOutsideQuotes(value, ',') {
+		e := XFCCElement{}
+		for _, pair := range splitOutsideQuotes(element, ';') {
+			parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			v := strings.Trim(parts[1], "\"")
+			switch strings.ToLower(parts[0]) {
+			case "by":
+				e.By = v
+			case "hash":
+				e.Hash = v
+			case "subject":
+				e.Subject = v
+			case "uri":
+				e.URI = v
+			case "dns":
+				e.DNS = append(e.DNS, v)
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// LastXFCCElement returns the element added by the most recent hop of the given X-Forwarded-Client-Cert header
+// value, i.e. by the server-side sidecar. The zero value is returned if the header is empty.
+func LastXFCCElement(value string) XFCCElement {
+	elements := ParseXFCC(value)
+	if len(elements) == 0 {
+		return XFCCElement{}
+	}
+	return elements[len(elements)-1]
+}
+
+func splitOutsideQuotes(s string, sep rune) []string {
+	out := make([]string, 0)
+	quoted := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseXFCC(t *testing.T) {
+	cases := []struct {
+		name     string
+		value    string
+		expected []XFCCElement
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: []XFCCElement{},
+		},
+		{
+			name: "single element",
+			value: `By=spiffe://cluster.local/ns/default/sa/b;Hash=abc123;` +
+				`Subject="O=Istio,CN=a";URI=spiffe://cluster.local/ns/default/sa/a;DNS=a.default;DNS=a`,
+			expected: []XFCCElement{
+				{
+					By:      "spiffe://cluster.local/ns/default/sa/b",
+					Hash:    "abc123",
+					Subject: "O=Istio,CN=a",
+					URI:     "spiffe://cluster.local/ns/default/sa/a",
+					DNS:     []string{"a.default", "a"},
+				},
+			},
+		},
+		{
+			name:  "multiple hops",
+			value: "By=spiffe://td/ns/ns/sa/gw;URI=spiffe://td/ns/ns/sa/a,By=spiffe://td/ns/ns/sa/b;URI=spiffe://td/ns/ns/sa/gw",
+			expected: []XFCCElement{
+				{By: "spiffe://td/ns/ns/sa/gw", URI: "spiffe://td/ns/ns/sa/a"},
+				{By: "spiffe://td/ns/ns/sa/b", URI: "spiffe://td/ns/ns/sa/gw"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := ParseXFCC(c.value); !reflect.DeepEqual(got, c.expected) {
+				t.Fatalf("expected %+v, got %+v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestLastXFCCElement(t *testing.T) {
+	got := LastXFCCElement("By=gw;URI=a,By=b;URI=gw")
+	if got.By != "b" || got.URI != "gw" {
+		t.Fatalf("unexpected element: %+v", got)
+	}
+	if got := LastXFCCElement(""); !reflect.DeepEqual(got, XFCCElement{}) {
+		t.Fatalf("expected zero element, got %+v", got)
+	}
+}
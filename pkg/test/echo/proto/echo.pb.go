@@ -149,15 +149,29 @@ func (m *Header) GetValue() string {
 }
 
 type ForwardEchoRequest struct {
-	Count                int32     `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
-	Qps                  int32     `protobuf:"varint,2,opt,name=qps,proto3" json:"qps,omitempty"`
-	TimeoutMicros        int64     `protobuf:"varint,3,opt,name=timeout_micros,json=timeoutMicros,proto3" json:"timeout_micros,omitempty"`
-	Url                  string    `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
-	Headers              []*Header `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty"`
-	Message              string    `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	Count                   int32     `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Qps                     int32     `protobuf:"varint,2,opt,name=qps,proto3" json:"qps,omitempty"`
+	TimeoutMicros           int64     `protobuf:"varint,3,opt,name=timeout_micros,json=timeoutMicros,proto3" json:"timeout_micros,omitempty"`
+	Url                     string    `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	Headers                 []*Header `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty"`
+	Message                 string    `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`
+	Http2                   bool      `protobuf:"varint,7,opt,name=http2,proto3" json:"http2,omitempty"`
+	Alpn                    []string  `protobuf:"bytes,8,rep,name=alpn,proto3" json:"alpn,omitempty"`
+	StreamMessages          int32     `protobuf:"varint,9,opt,name=stream_messages,json=streamMessages,proto3" json:"stream_messages,omitempty"`
+	ServerStreaming         bool      `protobuf:"varint,10,opt,name=server_streaming,json=serverStreaming,proto3" json:"server_streaming,omitempty"`
+	StreamIntervalMicros    int64     `protobuf:"varint,11,opt,name=stream_interval_micros,json=streamIntervalMicros,proto3" json:"stream_interval_micros,omitempty"`
+	Cert                    string    `protobuf:"bytes,12,opt,name=cert,proto3" json:"cert,omitempty"`
+	Key                     string    `protobuf:"bytes,13,opt,name=key,proto3" json:"key,omitempty"`
+	CaCert                  string    `protobuf:"bytes,14,opt,name=ca_cert,json=caCert,proto3" json:"ca_cert,omitempty"`
+	ServerName              string    `protobuf:"bytes,15,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
+	NewConnectionPerRequest bool      `protobuf:"varint,16,opt,name=new_connection_per_request,json=newConnectionPerRequest,proto3" json:"new_connection_per_request,omitempty"`
+	ReuseConnection         bool      `protobuf:"varint,17,opt,name=reuse_connection,json=reuseConnection,proto3" json:"reuse_connection,omitempty"`
+	Concurrency             int32     `protobuf:"varint,18,opt,name=concurrency,proto3" json:"concurrency,omitempty"`
+	ReportGrpcStatus        bool      `protobuf:"varint,19,opt,name=report_grpc_status,json=reportGrpcStatus,proto3" json:"report_grpc_status,omitempty"`
+	Proxy                   string    `protobuf:"bytes,20,opt,name=proxy,proto3" json:"proxy,omitempty"`
+	XXX_NoUnkeyedLiteral    struct{}  `json:"-"`
+	XXX_unrecognized        []byte    `json:"-"`
+	XXX_sizecache           int32     `json:"-"`
 }
 
 func (m *ForwardEchoRequest) Reset()         { *m = ForwardEchoRequest{} }
@@ -227,6 +241,104 @@ func (m *ForwardEchoRequest) GetMessage() string {
 	return ""
 }
 
+func (m *ForwardEchoRequest) GetHttp2() bool {
+	if m != nil {
+		return m.Http2
+	}
+	return false
+}
+
+func (m *ForwardEchoRequest) GetAlpn() []string {
+	if m != nil {
+		return m.Alpn
+	}
+	return nil
+}
+
+func (m *ForwardEchoRequest) GetStreamMessages() int32 {
+	if m != nil {
+		return m.StreamMessages
+	}
+	return 0
+}
+
+func (m *ForwardEchoRequest) GetServerStreaming() bool {
+	if m != nil {
+		return m.ServerStreaming
+	}
+	return false
+}
+
+func (m *ForwardEchoRequest) GetStreamIntervalMicros() int64 {
+	if m != nil {
+		return m.StreamIntervalMicros
+	}
+	return 0
+}
+
+func (m *ForwardEchoRequest) GetCert() string {
+	if m != nil {
+		return m.Cert
+	}
+	return ""
+}
+
+func (m *ForwardEchoRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ForwardEchoRequest) GetCaCert() string {
+	if m != nil {
+		return m.CaCert
+	}
+	return ""
+}
+
+func (m *ForwardEchoRequest) GetServerName() string {
+	if m != nil {
+		return m.ServerName
+	}
+	return ""
+}
+
+func (m *ForwardEchoRequest) GetNewConnectionPerRequest() bool {
+	if m != nil {
+		return m.NewConnectionPerRequest
+	}
+	return false
+}
+
+func (m *ForwardEchoRequest) GetReuseConnection() bool {
+	if m != nil {
+		return m.ReuseConnection
+	}
+	return false
+}
+
+func (m *ForwardEchoRequest) GetConcurrency() int32 {
+	if m != nil {
+		return m.Concurrency
+	}
+	return 0
+}
+
+func (m *ForwardEchoRequest) GetReportGrpcStatus() bool {
+	if m != nil {
+		return m.ReportGrpcStatus
+	}
+	return false
+}
+
+func (m *ForwardEchoRequest) GetProxy() string {
+	if m != nil {
+		return m.Proxy
+	}
+	return ""
+}
+
 type ForwardEchoResponse struct {
 	Output               []string `protobuf:"bytes,1,rep,name=output,proto3" json:"output,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -266,37 +378,111 @@ func (m *ForwardEchoResponse) GetOutput() []string {
 	return nil
 }
 
+type EchoServerStreamRequest struct {
+	Message              string   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Count                int32    `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	IntervalMicros       int64    `protobuf:"varint,3,opt,name=interval_micros,json=intervalMicros,proto3" json:"interval_micros,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EchoServerStreamRequest) Reset()         { *m = EchoServerStreamRequest{} }
+func (m *EchoServerStreamRequest) String() string { return proto.CompactTextString(m) }
+func (*EchoServerStreamRequest) ProtoMessage()    {}
+func (*EchoServerStreamRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_08134aea513e0001, []int{5}
+}
+
+func (m *EchoServerStreamRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EchoServerStreamRequest.Unmarshal(m, b)
+}
+func (m *EchoServerStreamRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EchoServerStreamRequest.Marshal(b, m, deterministic)
+}
+func (m *EchoServerStreamRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EchoServerStreamRequest.Merge(m, src)
+}
+func (m *EchoServerStreamRequest) XXX_Size() int {
+	return xxx_messageInfo_EchoServerStreamRequest.Size(m)
+}
+func (m *EchoServerStreamRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EchoServerStreamRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EchoServerStreamRequest proto.InternalMessageInfo
+
+func (m *EchoServerStreamRequest) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *EchoServerStreamRequest) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *EchoServerStreamRequest) GetIntervalMicros() int64 {
+	if m != nil {
+		return m.IntervalMicros
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*EchoRequest)(nil), "proto.EchoRequest")
 	proto.RegisterType((*EchoResponse)(nil), "proto.EchoResponse")
 	proto.RegisterType((*Header)(nil), "proto.Header")
 	proto.RegisterType((*ForwardEchoRequest)(nil), "proto.ForwardEchoRequest")
 	proto.RegisterType((*ForwardEchoResponse)(nil), "proto.ForwardEchoResponse")
+	proto.RegisterType((*EchoServerStreamRequest)(nil), "proto.EchoServerStreamRequest")
 }
 
 func init() { proto.RegisterFile("echo.proto", fileDescriptor_08134aea513e0001) }
 
 var fileDescriptor_08134aea513e0001 = []byte{
-	// 301 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x50, 0xcb, 0x4a, 0xf3, 0x40,
-	0x18, 0x25, 0x7f, 0x3a, 0x29, 0xfd, 0xfa, 0x57, 0x65, 0x5a, 0x64, 0xec, 0xaa, 0x04, 0xa4, 0xd9,
-	0x58, 0xa5, 0xbe, 0x82, 0x8a, 0x1b, 0x37, 0xa3, 0x7b, 0x89, 0xe9, 0x87, 0x09, 0x26, 0x9d, 0x74,
-	0x2e, 0x15, 0xdf, 0xc0, 0x47, 0xf2, 0xf1, 0x64, 0x2e, 0x85, 0x04, 0xc5, 0x55, 0xe6, 0x5c, 0x72,
-	0xe6, 0xcc, 0x01, 0xc0, 0xa2, 0x14, 0xab, 0x56, 0x0a, 0x2d, 0x28, 0x71, 0x9f, 0x74, 0x09, 0xe3,
-	0xdb, 0xa2, 0x14, 0x1c, 0x77, 0x06, 0x95, 0xa6, 0x0c, 0x86, 0x0d, 0x2a, 0x95, 0xbf, 0x22, 0x8b,
-	0x16, 0x51, 0x36, 0xe2, 0x07, 0x98, 0x66, 0xf0, 0xdf, 0x1b, 0x55, 0x2b, 0xb6, 0x0a, 0xff, 0x70,
-	0x5e, 0x41, 0x72, 0x8f, 0xf9, 0x06, 0x25, 0x3d, 0x81, 0xf8, 0x0d, 0x3f, 0x82, 0x6e, 0x8f, 0x74,
-	0x06, 0x64, 0x9f, 0xd7, 0x06, 0xd9, 0x3f, 0xc7, 0x79, 0x90, 0x7e, 0x45, 0x40, 0xef, 0x84, 0x7c,
-	0xcf, 0xe5, 0xa6, 0x5b, 0x66, 0x06, 0xa4, 0x10, 0x66, 0xab, 0x5d, 0x00, 0xe1, 0x1e, 0xd8, 0xd0,
-	0x5d, 0xab, 0x5c, 0x00, 0xe1, 0xf6, 0x48, 0xcf, 0xe1, 0x48, 0x57, 0x0d, 0x0a, 0xa3, 0x9f, 0x9b,
-	0xaa, 0x90, 0x42, 0xb1, 0x78, 0x11, 0x65, 0x31, 0x9f, 0x04, 0xf6, 0xc1, 0x91, 0xf6, 0x47, 0x23,
-	0x6b, 0x36, 0xf0, 0x6d, 0x8c, 0xac, 0xe9, 0x12, 0x86, 0xa5, 0x6b, 0xaa, 0x18, 0x59, 0xc4, 0xd9,
-	0x78, 0x3d, 0xf1, 0xe3, 0xac, 0x7c, 0x7f, 0x7e, 0x50, 0xbb, 0x8f, 0x4d, 0xfa, 0x8f, 0xbd, 0x80,
-	0x69, 0xaf, 0x79, 0x58, 0xe7, 0x14, 0x12, 0x61, 0x74, 0x6b, 0x6c, 0xf7, 0x38, 0x1b, 0xf1, 0x80,
-	0xd6, 0x9f, 0x11, 0x1c, 0x5b, 0xe3, 0x13, 0x2a, 0xfd, 0x88, 0x72, 0x5f, 0x15, 0x48, 0x2f, 0x61,
-	0x60, 0x29, 0x4a, 0xc3, 0xe5, 0x9d, 0x09, 0xe6, 0xd3, 0x1e, 0x17, 0xc2, 0x6f, 0x60, 0xdc, 0xb9,
-	0x93, 0x9e, 0x05, 0xcf, 0xcf, 0x05, 0xe7, 0xf3, 0xdf, 0x24, 0x9f, 0xf2, 0x92, 0x38, 0xe9, 0xfa,
-	0x3b, 0x00, 0x00, 0xff, 0xff, 0x11, 0x07, 0xc8, 0x61, 0x15, 0x02, 0x00, 0x00,
+	// 588 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x8d, 0x53, 0xdb, 0x6e, 0x13, 0x31,
+	0x10, 0x55, 0x2e, 0x9b, 0x34, 0xb3, 0xcd, 0x05, 0x27, 0x6a, 0x4c, 0x1e, 0x20, 0x8a, 0x84, 0x1a,
+	0x24, 0x28, 0x55, 0xe0, 0x05, 0xf1, 0x58, 0x6e, 0x7d, 0x28, 0x42, 0x1b, 0xde, 0x57, 0xc6, 0xb5,
+	0x92, 0x15, 0xc9, 0xee, 0xd6, 0xf6, 0xb6, 0xe4, 0x27, 0xf8, 0x00, 0xbe, 0x16, 0x7b, 0xec, 0x90,
+	0x4d, 0x89, 0x80, 0xa7, 0xf5, 0x9c, 0x73, 0x3c, 0x9e, 0x33, 0x33, 0x0b, 0x20, 0xf8, 0x32, 0x3b,
+	0xcb, 0x65, 0xa6, 0x33, 0x12, 0xe0, 0x67, 0x72, 0x0a, 0xe1, 0x3b, 0x03, 0x46, 0xe2, 0xa6, 0x10,
+	0x4a, 0x13, 0x0a, 0xcd, 0xb5, 0x50, 0x8a, 0x2d, 0x04, 0xad, 0x8c, 0x2b, 0xd3, 0x56, 0xb4, 0x0d,
+	0x27, 0x53, 0x38, 0x76, 0x42, 0x95, 0x67, 0xa9, 0x12, 0x7f, 0x51, 0x9e, 0x43, 0xe3, 0xa3, 0x60,
+	0xd7, 0x42, 0x92, 0x1e, 0xd4, 0xbe, 0x89, 0x8d, 0xe7, 0xed, 0x91, 0x0c, 0x20, 0xb8, 0x65, 0xab,
+	0x42, 0xd0, 0x2a, 0x62, 0x2e, 0x98, 0xfc, 0x0c, 0x80, 0xbc, 0xcf, 0xe4, 0x1d, 0x93, 0xd7, 0xe5,
+	0x62, 0x8c, 0x98, 0x67, 0x45, 0xaa, 0x31, 0x41, 0x10, 0xb9, 0xc0, 0x26, 0xbd, 0xc9, 0x15, 0x26,
+	0x08, 0x22, 0x7b, 0x24, 0x4f, 0xa0, 0xa3, 0x93, 0xb5, 0xc8, 0x0a, 0x1d, 0xaf, 0x13, 0x2e, 0x33,
+	0x45, 0x6b, 0x86, 0xac, 0x45, 0x6d, 0x8f, 0x5e, 0x21, 0x68, 0x2f, 0x16, 0x72, 0x45, 0xeb, 0xae,
+	0x1a, 0x73, 0x24, 0xa7, 0xd0, 0x5c, 0x62, 0xa5, 0x8a, 0x06, 0xe3, 0xda, 0x34, 0x9c, 0xb5, 0x5d,
+	0x73, 0xce, 0x5c, 0xfd, 0xd1, 0x96, 0x2d, 0x9b, 0x6d, 0xec, 0x99, 0xb5, 0x35, 0x2e, 0xb5, 0xce,
+	0x67, 0xb4, 0x69, 0xf0, 0xa3, 0xc8, 0x05, 0x84, 0x40, 0x9d, 0xad, 0xf2, 0x94, 0x1e, 0x99, 0xac,
+	0xad, 0x08, 0xcf, 0xe6, 0xb1, 0xae, 0xd2, 0x52, 0xb0, 0x75, 0xec, 0xef, 0x2a, 0xda, 0x42, 0x0f,
+	0x1d, 0x07, 0x5f, 0x79, 0x94, 0x3c, 0x85, 0x9e, 0x12, 0xf2, 0x56, 0xc8, 0xd8, 0x11, 0x49, 0xba,
+	0xa0, 0x80, 0xd9, 0xbb, 0x0e, 0x9f, 0x6f, 0x61, 0xf2, 0x0a, 0x4e, 0x7c, 0xce, 0x24, 0xd5, 0x86,
+	0x63, 0xab, 0x6d, 0x07, 0x42, 0xec, 0xc0, 0xc0, 0xb1, 0x97, 0x9e, 0xf4, 0x8d, 0x30, 0xd5, 0x71,
+	0x21, 0x35, 0x3d, 0x46, 0x2b, 0x78, 0xde, 0x8e, 0xaa, 0xbd, 0x1b, 0xd5, 0x10, 0x9a, 0x9c, 0xc5,
+	0x28, 0xec, 0x20, 0xda, 0xe0, 0xec, 0xc2, 0x4a, 0x1f, 0x43, 0xe8, 0xeb, 0x4b, 0xd9, 0x5a, 0xd0,
+	0x2e, 0x92, 0xe0, 0xa0, 0x4f, 0x06, 0x21, 0x6f, 0x60, 0x94, 0x8a, 0xbb, 0x98, 0x67, 0x69, 0x2a,
+	0xb8, 0x4e, 0xb2, 0x34, 0xce, 0x8d, 0x58, 0xba, 0xa9, 0xd2, 0x1e, 0x5a, 0x19, 0x1a, 0xc5, 0xc5,
+	0x6f, 0xc1, 0x67, 0xd3, 0x6c, 0x3f, 0x74, 0xe3, 0x5e, 0x8a, 0x42, 0x89, 0xd2, 0x75, 0xfa, 0xc0,
+	0xb9, 0x47, 0x7c, 0x77, 0x89, 0x8c, 0x21, 0x34, 0x22, 0x5e, 0x48, 0x29, 0x52, 0xbe, 0xa1, 0x04,
+	0xbb, 0x59, 0x86, 0xc8, 0x33, 0x20, 0x52, 0xe4, 0x99, 0xd4, 0xf1, 0x42, 0xe6, 0xdc, 0xf4, 0x93,
+	0xe9, 0x42, 0xd1, 0x3e, 0xa6, 0xeb, 0x39, 0xe6, 0x83, 0x21, 0xe6, 0x88, 0xdb, 0x59, 0x9a, 0xf1,
+	0x7f, 0xdf, 0xd0, 0x81, 0x5b, 0x4e, 0x0c, 0x26, 0xcf, 0xa1, 0xbf, 0xb7, 0x9b, 0x7e, 0xff, 0x4f,
+	0xa0, 0x61, 0x56, 0x2b, 0x2f, 0xec, 0x76, 0xda, 0x21, 0xfb, 0x68, 0x22, 0x61, 0x68, 0x75, 0xf3,
+	0xd2, 0xa4, 0xfe, 0xf9, 0x73, 0xed, 0x36, 0xbd, 0x5a, 0xde, 0x74, 0xb3, 0x31, 0xf7, 0xc7, 0xea,
+	0x16, 0xbb, 0x93, 0xec, 0x0d, 0x74, 0xf6, 0xa3, 0x0a, 0x5d, 0xfb, 0xe8, 0x17, 0xf3, 0x8a, 0x7d,
+	0x38, 0xe1, 0x82, 0xbc, 0x80, 0xba, 0x85, 0x08, 0xf1, 0x2b, 0x5d, 0xfa, 0xb1, 0x46, 0xfd, 0x3d,
+	0xcc, 0x1b, 0x7a, 0x0b, 0x61, 0xc9, 0x27, 0x79, 0xe8, 0x35, 0x7f, 0xfe, 0x97, 0xa3, 0xd1, 0x21,
+	0xca, 0x67, 0x79, 0x0d, 0x80, 0xf6, 0xd1, 0xf8, 0x7f, 0x3f, 0x3e, 0xad, 0x9c, 0x57, 0xc8, 0x25,
+	0xf4, 0xee, 0x77, 0x8e, 0x3c, 0x2a, 0x89, 0x0f, 0xb4, 0xf4, 0x60, 0xb2, 0xf3, 0xca, 0xd7, 0x06,
+	0xa2, 0x2f, 0x7f, 0x01, 0xb5, 0x35, 0x8e, 0xf3, 0xf1, 0x04, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -313,6 +499,8 @@ const _ = grpc.SupportPackageIsVersion4
 type EchoTestServiceClient interface {
 	Echo(ctx context.Context, in *EchoRequest, opts ...grpc.CallOption) (*EchoResponse, error)
 	ForwardEcho(ctx context.Context, in *ForwardEchoRequest, opts ...grpc.CallOption) (*ForwardEchoResponse, error)
+	EchoStream(ctx context.Context, opts ...grpc.CallOption) (EchoTestService_EchoStreamClient, error)
+	EchoServerStream(ctx context.Context, in *EchoServerStreamRequest, opts ...grpc.CallOption) (EchoTestService_EchoServerStreamClient, error)
 }
 
 type echoTestServiceClient struct {
@@ -341,10 +529,75 @@ func (c *echoTestServiceClient) ForwardEcho(ctx context.Context, in *ForwardEcho
 	return out, nil
 }
 
+func (c *echoTestServiceClient) EchoStream(ctx context.Context, opts ...grpc.CallOption) (EchoTestService_EchoStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_EchoTestService_serviceDesc.Streams[0], "/proto.EchoTestService/EchoStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &echoTestServiceEchoStreamClient{stream}
+	return x, nil
+}
+
+type EchoTestService_EchoStreamClient interface {
+	Send(*EchoRequest) error
+	Recv() (*EchoResponse, error)
+	grpc.ClientStream
+}
+
+type echoTestServiceEchoStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *echoTestServiceEchoStreamClient) Send(m *EchoRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *echoTestServiceEchoStreamClient) Recv() (*EchoResponse, error) {
+	m := new(EchoResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *echoTestServiceClient) EchoServerStream(ctx context.Context, in *EchoServerStreamRequest, opts ...grpc.CallOption) (EchoTestService_EchoServerStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_EchoTestService_serviceDesc.Streams[1], "/proto.EchoTestService/EchoServerStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &echoTestServiceEchoServerStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EchoTestService_EchoServerStreamClient interface {
+	Recv() (*EchoResponse, error)
+	grpc.ClientStream
+}
+
+type echoTestServiceEchoServerStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *echoTestServiceEchoServerStreamClient) Recv() (*EchoResponse, error) {
+	m := new(EchoResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // EchoTestServiceServer is the server API for EchoTestService service.
 type EchoTestServiceServer interface {
 	Echo(context.Context, *EchoRequest) (*EchoResponse, error)
 	ForwardEcho(context.Context, *ForwardEchoRequest) (*ForwardEchoResponse, error)
+	EchoStream(EchoTestService_EchoStreamServer) error
+	EchoServerStream(*EchoServerStreamRequest, EchoTestService_EchoServerStreamServer) error
 }
 
 func RegisterEchoTestServiceServer(s *grpc.Server, srv EchoTestServiceServer) {
@@ -387,6 +640,53 @@ func _EchoTestService_ForwardEcho_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EchoTestService_EchoStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EchoTestServiceServer).EchoStream(&echoTestServiceEchoStreamServer{stream})
+}
+
+type EchoTestService_EchoStreamServer interface {
+	Send(*EchoResponse) error
+	Recv() (*EchoRequest, error)
+	grpc.ServerStream
+}
+
+type echoTestServiceEchoStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *echoTestServiceEchoStreamServer) Send(m *EchoResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *echoTestServiceEchoStreamServer) Recv() (*EchoRequest, error) {
+	m := new(EchoRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _EchoTestService_EchoServerStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EchoServerStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EchoTestServiceServer).EchoServerStream(m, &echoTestServiceEchoServerStreamServer{stream})
+}
+
+type EchoTestService_EchoServerStreamServer interface {
+	Send(*EchoResponse) error
+	grpc.ServerStream
+}
+
+type echoTestServiceEchoServerStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *echoTestServiceEchoServerStreamServer) Send(m *EchoResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _EchoTestService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.EchoTestService",
 	HandlerType: (*EchoTestServiceServer)(nil),
@@ -400,6 +700,18 @@ var _EchoTestService_serviceDesc = grpc.ServiceDesc{
 			Handler:    _EchoTestService_ForwardEcho_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EchoStream",
+			Handler:       _EchoTestService_EchoStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "EchoServerStream",
+			Handler:       _EchoTestService_EchoServerStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "echo.proto",
 }
@@ -0,0 +1,82 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Drainer tracks the requests in flight on the endpoints of a server, so that the server can be shut down
+// gracefully. A nil Drainer tracks nothing and never drains.
+type Drainer struct {
+	draining uint32
+	inFlight int64
+
+	// OnDrain is called when draining is requested through the drain path of an HTTP endpoint, with the
+	// requested timeout. It is called asynchronously.
+	OnDrain func(timeout time.Duration)
+}
+
+// Begin records the start of a request, and returns the function that records its completion.
+func (d *Drainer) Begin() func() {
+	if d == nil {
+		return func() {}
+	}
+	atomic.AddInt64(&d.inFlight, 1)
+	return func() {
+		atomic.AddInt64(&d.inFlight, -1)
+	}
+}
+
+// InFlight returns the number of requests in flight.
+func (d *Drainer) InFlight() int64 {
+	if d == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&d.inFlight)
+}
+
+// IsDraining indicates whether the server is draining.
+func (d *Drainer) IsDraining() bool {
+	return d != nil && atomic.LoadUint32(&d.draining) == 1
+}
+
+// Drain marks the server as draining, and waits until no requests are in flight or the timeout expires.
+// It returns false if requests were still in flight at the timeout.
+func (d *Drainer) Drain(timeout time.Duration) bool {
+	if d == nil {
+		return true
+	}
+	atomic.StoreUint32(&d.draining, 1)
+
+	deadline := time.Now().Add(timeout)
+	for d.InFlight() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
+
+// requestDrain calls OnDrain and returns true, if set.
+func (d *Drainer) requestDrain(timeout time.Duration) bool {
+	if d == nil || d.OnDrain == nil {
+		return false
+	}
+	go d.OnDrain(timeout)
+	return true
+}
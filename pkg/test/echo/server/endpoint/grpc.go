@@ -17,14 +17,18 @@ package endpoint
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"strconv"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/echo/common/response"
@@ -57,12 +61,17 @@ func (s *grpcInstance) Start(onReady OnReadyFunc) error {
 	s.Port.Port = p
 	fmt.Printf("Listening GRPC on %v\n", p)
 
-	if s.TLSCert != "" && s.TLSKey != "" {
+	if s.TLS {
 		// Create the TLS credentials
-		creds, errCreds := credentials.NewServerTLSFromFile(s.TLSCert, s.TLSKey)
-		if errCreds != nil {
-			log.Errorf("could not load TLS keys: %s", errCreds)
+		cert, errCert := tls.LoadX509KeyPair(s.TLSCert, s.TLSKey)
+		if errCert != nil {
+			log.Errorf("could not load TLS keys: %s", errCert)
 		}
+		// Request, but don't verify, client certificates so that they can be reported in the responses.
+		creds := credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequestClientCert,
+		})
 		s.server = grpc.NewServer(grpc.Creds(creds))
 	} else {
 		s.server = grpc.NewServer()
@@ -122,6 +131,76 @@ type grpcHandler struct {
 }
 
 func (h *grpcHandler) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.EchoResponse, error) {
+	defer h.Drainer.Begin()()
+	h.record(ctx)
+	return &proto.EchoResponse{Message: h.echoBody(ctx, req.GetMessage())}, nil
+}
+
+func (h *grpcHandler) EchoStream(stream proto.EchoTestService_EchoStreamServer) error {
+	defer h.Drainer.Begin()()
+	h.record(stream.Context())
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&proto.EchoResponse{Message: h.echoBody(stream.Context(), req.GetMessage())}); err != nil {
+			return err
+		}
+	}
+}
+
+func (h *grpcHandler) EchoServerStream(req *proto.EchoServerStreamRequest,
+	stream proto.EchoTestService_EchoServerStreamServer) error {
+	defer h.Drainer.Begin()()
+	h.record(stream.Context())
+	interval := common.MicrosToDuration(req.GetIntervalMicros())
+	for i := 0; i < int(req.GetCount()); i++ {
+		if i > 0 && interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-stream.Context().Done():
+				return stream.Context().Err()
+			}
+		}
+		message := fmt.Sprintf("%s #%d", req.GetMessage(), i)
+		if err := stream.Send(&proto.EchoResponse{Message: h.echoBody(stream.Context(), message)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record the call of the given context with the Recorder. Calls are recorded once, rather than for every
+// message of a stream.
+func (h *grpcHandler) record(ctx context.Context) {
+	req := response.Request{
+		Time:     time.Now(),
+		Protocol: "GRPC",
+		Headers:  make(map[string][]string),
+	}
+	if h.Port != nil {
+		req.Port = h.Port.Port
+	}
+	if method, ok := grpc.Method(ctx); ok {
+		req.Method = method
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			if key == ":authority" {
+				req.Host = values[0]
+				continue
+			}
+			req.Headers[key] = values
+		}
+	}
+	h.Recorder.Record(req)
+}
+
+func (h *grpcHandler) echoBody(ctx context.Context, message string) string {
 	body := bytes.Buffer{}
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		for key, values := range md {
@@ -133,6 +212,17 @@ func (h *grpcHandler) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.
 				writeField(&body, field, value)
 			}
 		}
+		if id := md.Get(string(response.RequestIDField)); len(id) > 0 {
+			log.Infof("GRPC Request: ID: %s", id[0])
+		}
+		if xfcc := md.Get(common.XFCCHeader); len(xfcc) > 0 {
+			writePrincipals(&body, xfcc[len(xfcc)-1])
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			writePeerCertificates(&body, info.State.PeerCertificates)
+		}
 	}
 	portNumber := 0
 	if h.Port != nil {
@@ -142,13 +232,22 @@ func (h *grpcHandler) Echo(ctx context.Context, req *proto.EchoRequest) (*proto.
 	writeField(&body, response.StatusCodeField, response.StatusCodeOK)
 	writeField(&body, response.ServiceVersionField, h.Version)
 	writeField(&body, response.ServicePortField, strconv.Itoa(portNumber))
-	writeField(&body, response.Field("Echo"), req.GetMessage())
+	if h.Cluster != "" {
+		writeField(&body, response.ClusterField, h.Cluster)
+	}
+	if h.Locality != "" {
+		writeField(&body, response.LocalityField, h.Locality)
+	}
+	if h.Drainer.IsDraining() {
+		writeField(&body, response.DrainingField, "true")
+	}
+	writeField(&body, response.Field("Echo"), message)
 
 	if hostname, err := os.Hostname(); err == nil {
 		writeField(&body, response.HostnameField, hostname)
 	}
 
-	return &proto.EchoResponse{Message: body.String()}, nil
+	return body.String()
 }
 
 func (h *grpcHandler) ForwardEcho(ctx context.Context, req *proto.ForwardEchoRequest) (*proto.ForwardEchoResponse, error) {
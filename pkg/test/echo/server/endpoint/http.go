@@ -17,6 +17,8 @@ package endpoint
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net"
@@ -87,6 +89,11 @@ func (s *httpInstance) Start(onReady OnReadyFunc) error {
 
 	if s.isUDS() {
 		fmt.Printf("Listening HTTP/1.1 on %v\n", s.UDSServer)
+	} else if s.TLS {
+		s.server.Addr = fmt.Sprintf(":%d", port)
+		// Request, but don't verify, client certificates so that they can be reported in the responses.
+		s.server.TLSConfig = &tls.Config{ClientAuth: tls.RequestClientCert}
+		fmt.Printf("Listening HTTPS/1.1 on %v\n", port)
 	} else {
 		s.server.Addr = fmt.Sprintf(":%d", port)
 		fmt.Printf("Listening HTTP/1.1 on %v\n", port)
@@ -94,6 +101,10 @@ func (s *httpInstance) Start(onReady OnReadyFunc) error {
 
 	// Start serving HTTP traffic.
 	go func() {
+		if s.TLS {
+			_ = s.server.ServeTLS(listener, s.TLSCert, s.TLSKey)
+			return
+		}
 		_ = s.server.Serve(listener)
 	}()
 
@@ -123,8 +134,19 @@ func (s *httpInstance) awaitReady(onReady OnReadyFunc, port int) {
 		url = fmt.Sprintf("http://127.0.0.1:%d", port)
 	}
 
+	get := http.Get
+	if s.TLS {
+		url = fmt.Sprintf("https://127.0.0.1:%d", port)
+		tlsClient := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+		get = tlsClient.Get
+	}
+
 	err := retry.UntilSuccess(func() error {
-		resp, err := http.Get(url)
+		resp, err := get(url)
 		if err != nil {
 			return err
 		}
@@ -167,8 +189,21 @@ type codeAndSlices struct {
 }
 
 func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	log.Infof("HTTP Request:\n  Method: %s\n  URL: %v,\n  Host: %s\n  Headers: %v}",
-		r.Method, r.URL, r.Host, r.Header)
+	log.Infof("HTTP Request:\n  ID: %s\n  Method: %s\n  URL: %v,\n  Host: %s\n  Headers: %v}",
+		r.Header.Get(string(response.RequestIDField)), r.Method, r.URL, r.Host, r.Header)
+
+	if r.URL.Path == common.DrainPath {
+		h.drain(w, r)
+		return
+	}
+	if r.URL.Path == common.RequestsPath {
+		h.requests(w, r)
+		return
+	}
+	if r.URL.Path == common.CountsPath {
+		h.counts(w, r)
+		return
+	}
 
 	if !h.IsServerReady() {
 		// Handle readiness probe failure.
@@ -177,6 +212,10 @@ func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	done := h.Drainer.Begin()
+	defer done()
+	h.record(r)
+
 	if common.IsWebSocketRequest(r) {
 		h.webSocketEcho(w, r)
 	} else {
@@ -202,6 +241,17 @@ func (h *httpHandler) echo(w http.ResponseWriter, r *http.Request) {
 		writeError(&body, "response headers error: "+err.Error())
 	}
 
+	// If the request has form ?events=count[&interval=duration], stream that many server-sent events after
+	// the body. The headers must be set before the status code is written.
+	events, interval, err := streamParams(r)
+	if err != nil {
+		writeError(&body, "stream error: "+err.Error())
+	}
+	if events > 0 {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
 	// If the request has form ?codes=code[:chance][,code[:chance]]* return those codes, rather than 200
 	// For example, ?codes=500:1,200:1 returns 500 1/2 times and 200 1/2 times
 	// For example, ?codes=500:90,200:10 returns 500 90% of times and 200 10% of times
@@ -209,8 +259,32 @@ func (h *httpHandler) echo(w http.ResponseWriter, r *http.Request) {
 		writeError(&body, "codes error: "+err.Error())
 	}
 
+	// If the request has form ?delay=duration, hold the request for that long before responding, so that it
+	// stays in flight e.g. while the server is drained.
+	if delay := r.FormValue("delay"); delay != "" {
+		if d, err := time.ParseDuration(delay); err != nil {
+			writeError(&body, "delay error: "+err.Error())
+		} else {
+			time.Sleep(d)
+		}
+	}
+
 	h.addResponsePayload(r, &body)
 
+	// If the request has form ?size=bytes, pad the body to that size, e.g. for testing buffer limits.
+	if size := r.FormValue("size"); size != "" {
+		if n, err := strconv.Atoi(size); err != nil {
+			writeError(&body, "size error: "+err.Error())
+		} else {
+			writePadding(&body, n)
+		}
+	}
+
+	if events > 0 {
+		streamEvents(w, r, &body, events, interval)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/text")
 	if _, err := w.Write(body.Bytes()); err != nil {
 		log.Warna(err)
@@ -218,36 +292,171 @@ func (h *httpHandler) echo(w http.ResponseWriter, r *http.Request) {
 	log.Infof("Response Headers: %+v", w.Header())
 }
 
-func (h *httpHandler) webSocketEcho(w http.ResponseWriter, r *http.Request) {
-	// adapted from https://github.com/gorilla/websocket/blob/master/examples/echo/server.go
-	// First send upgrade headers
-	c, err := webSocketUpgrader.Upgrade(w, r, nil)
+// streamParams returns the number of events to stream and the interval between them.
+func streamParams(r *http.Request) (int, time.Duration, error) {
+	e := r.FormValue(common.StreamEventsParam)
+	if e == "" {
+		return 0, 0, nil
+	}
+	events, err := strconv.Atoi(e)
 	if err != nil {
-		log.Warn("websocket-echo upgrade failed: " + err.Error())
+		return 0, 0, err
+	}
+	var interval time.Duration
+	if i := r.FormValue(common.StreamIntervalParam); i != "" {
+		if interval, err = time.ParseDuration(i); err != nil {
+			return 0, 0, err
+		}
+	}
+	return events, interval, nil
+}
+
+// streamEvents writes the body, followed by the given number of server-sent events, flushing each of them so
+// that the response stays open between the events.
+func streamEvents(w http.ResponseWriter, r *http.Request, body *bytes.Buffer, events int, interval time.Duration) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(body, "stream error: streaming not supported")
+		_, _ = w.Write(body.Bytes())
+		return
+	}
+	if _, err := w.Write(body.Bytes()); err != nil {
+		log.Warna(err)
 		return
 	}
+	flusher.Flush()
 
-	defer func() { _ = c.Close() }()
+	for i := 0; i < events; i++ {
+		if i > 0 && interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-r.Context().Done():
+				log.Infof("Event stream closed by the client after %d events", i)
+				return
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%sevent #%d\n\n", common.StreamEventPrefix, i); err != nil {
+			log.Warnf("Event stream failed after %d events: %v", i, err)
+			return
+		}
+		flusher.Flush()
+	}
+}
 
-	// ping
-	mt, message, err := c.ReadMessage()
-	if err != nil {
-		log.Warn("websocket-echo read failed: " + err.Error())
-		return
+// drain reports the drain status of the server, and starts draining it if requested. It is served
+// regardless of the readiness of the server, so that the status can be followed while draining.
+func (h *httpHandler) drain(w http.ResponseWriter, r *http.Request) {
+	body := bytes.Buffer{}
+	requested := false
+	if _, start := r.URL.Query()["start"]; start {
+		timeout := common.DefaultDrainTimeout
+		if t := r.FormValue("timeout"); t != "" {
+			d, err := time.ParseDuration(t)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				writeError(&body, "timeout error: "+err.Error())
+				_, _ = w.Write(body.Bytes())
+				return
+			}
+			timeout = d
+		}
+		log.Infof("Draining requested, timeout %v", timeout)
+		requested = h.Drainer.requestDrain(timeout)
+	}
+
+	writeField(&body, response.StatusCodeField, response.StatusCodeOK)
+	// Draining starts asynchronously, so it is reported right away when requested.
+	writeField(&body, response.DrainingField, strconv.FormatBool(requested || h.Drainer.IsDraining()))
+	writeField(&body, response.InFlightField, strconv.FormatInt(h.Drainer.InFlight(), 10))
+	if hostname, err := os.Hostname(); err == nil {
+		writeField(&body, response.HostnameField, hostname)
 	}
+	w.Header().Set("Content-Type", "application/text")
+	_, _ = w.Write(body.Bytes())
+}
+
+// record the given request with the Recorder.
+func (h *httpHandler) record(r *http.Request) {
+	port := 0
+	if h.Port != nil {
+		port = h.Port.Port
+	}
+	headers := make(map[string][]string, len(r.Header))
+	for k, v := range r.Header {
+		headers[k] = v
+	}
+	h.Recorder.Record(response.Request{
+		Time:     time.Now(),
+		Protocol: r.Proto,
+		Method:   r.Method,
+		Host:     r.Host,
+		URL:      r.URL.String(),
+		Port:     port,
+		Headers:  headers,
+	})
+}
 
+// requests reports the most recent requests recorded by the Recorder. Like the drain path, it is served
+// regardless of the readiness of the server.
+func (h *httpHandler) requests(w http.ResponseWriter, r *http.Request) {
 	body := bytes.Buffer{}
-	h.addResponsePayload(r, &body)
-	body.Write(message)
+	count := 0
+	if c := r.FormValue("count"); c != "" {
+		n, err := strconv.Atoi(c)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			writeError(&body, "count error: "+err.Error())
+			_, _ = w.Write(body.Bytes())
+			return
+		}
+		count = n
+	}
 
 	writeField(&body, response.StatusCodeField, response.StatusCodeOK)
+	for _, req := range h.Recorder.Last(count) {
+		out, err := json.Marshal(req)
+		if err != nil {
+			writeError(&body, "marshal error: "+err.Error())
+			continue
+		}
+		writeField(&body, response.CapturedRequestField, string(out))
+	}
+	w.Header().Set("Content-Type", "application/text")
+	_, _ = w.Write(body.Bytes())
+}
 
-	// pong
-	err = c.WriteMessage(mt, body.Bytes())
+func (h *httpHandler) webSocketEcho(w http.ResponseWriter, r *http.Request) {
+	// adapted from https://github.com/gorilla/websocket/blob/master/examples/echo/server.go
+	// First send upgrade headers
+	c, err := webSocketUpgrader.Upgrade(w, r, nil)
 	if err != nil {
-		writeError(&body, "websocket-echo write failed: "+err.Error())
+		log.Warn("websocket-echo upgrade failed: " + err.Error())
 		return
 	}
+
+	defer func() { _ = c.Close() }()
+
+	// Echo every frame until the client closes the connection, so that several messages can be exchanged
+	// over the same upgraded connection.
+	for {
+		mt, message, err := c.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Warn("websocket-echo read failed: " + err.Error())
+			}
+			return
+		}
+
+		body := bytes.Buffer{}
+		h.addResponsePayload(r, &body)
+		writeField(&body, response.WebSocketMessageField, string(message))
+		writeField(&body, response.StatusCodeField, response.StatusCodeOK)
+
+		if err := c.WriteMessage(mt, body.Bytes()); err != nil {
+			log.Warn("websocket-echo write failed: " + err.Error())
+			return
+		}
+	}
 }
 
 // nolint: interfacer
@@ -259,11 +468,20 @@ func (h *httpHandler) addResponsePayload(r *http.Request, body *bytes.Buffer) {
 
 	writeField(body, response.ServiceVersionField, h.Version)
 	writeField(body, response.ServicePortField, port)
+	if h.Cluster != "" {
+		writeField(body, response.ClusterField, h.Cluster)
+	}
+	if h.Locality != "" {
+		writeField(body, response.LocalityField, h.Locality)
+	}
 	writeField(body, response.HostField, r.Host)
+	if h.Drainer.IsDraining() {
+		writeField(body, response.DrainingField, "true")
+	}
 
 	writeField(body, response.Field("Method"), r.Method)
 	writeField(body, response.Field("URL"), r.URL.String())
-	writeField(body, response.Field("Proto"), r.Proto)
+	writeField(body, response.ProtocolField, r.Proto)
 	writeField(body, response.Field("RemoteAddr"), r.RemoteAddr)
 	writeField(body, response.Field("Method"), r.Method)
 
@@ -272,12 +490,24 @@ func (h *httpHandler) addResponsePayload(r *http.Request, body *bytes.Buffer) {
 			writeField(body, response.Field(name), value)
 		}
 	}
+	writePrincipals(body, r.Header.Get(common.XFCCHeader))
+	if r.TLS != nil {
+		writePeerCertificates(body, r.TLS.PeerCertificates)
+	}
 
 	if hostname, err := os.Hostname(); err == nil {
 		writeField(body, response.HostnameField, hostname)
 	}
 }
 
+// writePadding pads the body to the given size with a padding field, if it is shorter.
+func writePadding(body *bytes.Buffer, size int) {
+	overhead := len(response.PaddingField) + len("=\n")
+	if n := size - body.Len() - overhead; n > 0 {
+		writeField(body, response.PaddingField, strings.Repeat("x", n))
+	}
+}
+
 func setHeaderResponseFromHeaders(request *http.Request, response http.ResponseWriter) error {
 	s := request.FormValue("headers")
 	if len(s) == 0 {
@@ -285,7 +515,7 @@ func setHeaderResponseFromHeaders(request *http.Request, response http.ResponseW
 	}
 	responseHeaders := strings.Split(s, ",")
 	for _, responseHeader := range responseHeaders {
-		parts := strings.Split(responseHeader, ":")
+		parts := strings.SplitN(responseHeader, ":", 2)
 		// require name:value format
 		if len(parts) != 2 {
 			return fmt.Errorf("invalid %q (want name:value)", responseHeader)
@@ -383,3 +613,15 @@ func validateCodeAndSlices(codecount string) (codeAndSlices, error) {
 
 	return codeAndSlices{n, count}, nil
 }
+
+// counts reports the numbers of requests counted by the Recorder for the paths with the prefix given by the
+// path query parameter.
+func (h *httpHandler) counts(w http.ResponseWriter, r *http.Request) {
+	body := bytes.Buffer{}
+	counts := h.Recorder.Counts(r.FormValue("path"))
+	writeField(&body, response.StatusCodeField, response.StatusCodeOK)
+	writeField(&body, response.RequestCountField, strconv.Itoa(counts.Total))
+	writeField(&body, response.MirroredCountField, strconv.Itoa(counts.Mirrored))
+	w.Header().Set("Content-Type", "application/text")
+	_, _ = w.Write(body.Bytes())
+}
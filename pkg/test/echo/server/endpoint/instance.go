@@ -33,11 +33,16 @@ type OnReadyFunc func()
 type Config struct {
 	IsServerReady IsServerReadyFunc
 	Version       string
+	Cluster       string
+	Locality      string
 	TLSCert       string
 	TLSKey        string
+	TLS           bool
 	UDSServer     string
 	Dialer        common.Dialer
 	Port          *model.Port
+	Drainer       *Drainer
+	Recorder      *Recorder
 }
 
 // Instance of an endpoint that serves the Echo application on a single port/protocol.
@@ -54,6 +59,14 @@ func New(cfg Config) (Instance, error) {
 			return newHTTP(cfg), nil
 		case protocol.HTTP2, protocol.GRPC:
 			return newGRPC(cfg), nil
+		case protocol.UDP:
+			return newUDP(cfg), nil
+		case protocol.MySQL:
+			return newServerFirst(cfg), nil
+		case protocol.Thrift:
+			return newThrift(cfg), nil
+		case protocol.Dubbo:
+			return newDubbo(cfg), nil
 		default:
 			return nil, fmt.Errorf("unsupported protocol: %s", cfg.Port.Protocol)
 		}
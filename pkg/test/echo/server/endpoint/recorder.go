@@ -0,0 +1,120 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"strings"
+	"sync"
+
+	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/echo/common/response"
+)
+
+// DefaultRecorderCapacity is the number of requests kept by the Recorder of the server.
+const DefaultRecorderCapacity = 100
+
+// Recorder keeps the most recent requests received by the endpoints of a server, so that tests can inspect
+// them through common.RequestsPath. It also counts all of the requests by path, for common.CountsPath. A nil
+// Recorder records nothing.
+type Recorder struct {
+	mutex    sync.Mutex
+	capacity int
+	requests []response.Request
+	// next is the index of the slot the next request is recorded in, once requests is full.
+	next int
+	// counts of the requests by path, without the query.
+	counts map[string]response.RequestCounts
+}
+
+// NewRecorder returns a Recorder keeping the given number of requests.
+func NewRecorder(capacity int) *Recorder {
+	return &Recorder{
+		capacity: capacity,
+		counts:   make(map[string]response.RequestCounts),
+	}
+}
+
+// Record the given request, dropping the oldest one if the recorder is full.
+func (r *Recorder) Record(req response.Request) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	path := strings.SplitN(req.URL, "?", 2)[0]
+	counts := r.counts[path]
+	counts.Total++
+	if isMirrored(req.Host) {
+		counts.Mirrored++
+	}
+	r.counts[path] = counts
+
+	if r.capacity <= 0 {
+		return
+	}
+	if len(r.requests) < r.capacity {
+		r.requests = append(r.requests, req)
+		return
+	}
+	r.requests[r.next] = req
+	r.next = (r.next + 1) % r.capacity
+}
+
+// Last returns the last n recorded requests, oldest first. If n is zero or less, all of them are returned.
+func (r *Recorder) Last(n int) []response.Request {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	out := make([]response.Request, 0, len(r.requests))
+	out = append(out, r.requests[r.next:]...)
+	out = append(out, r.requests[:r.next]...)
+	if n > 0 && n < len(out) {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+// Counts returns the number of requests recorded for the paths with the given prefix. An empty prefix counts
+// all of the requests.
+func (r *Recorder) Counts(pathPrefix string) response.RequestCounts {
+	out := response.RequestCounts{}
+	if r == nil {
+		return out
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for path, counts := range r.counts {
+		if strings.HasPrefix(path, pathPrefix) {
+			out.Total += counts.Total
+			out.Mirrored += counts.Mirrored
+		}
+	}
+	return out
+}
+
+// isMirrored returns whether the given Host is the one of a mirrored request. Depending on its version, Envoy
+// appends the shadow suffix to the whole Host, or to the host before the port.
+func isMirrored(host string) bool {
+	if strings.HasSuffix(host, common.ShadowHostSuffix) {
+		return true
+	}
+	if i := strings.LastIndex(host, ":"); i > strings.LastIndex(host, "]") {
+		return strings.HasSuffix(host[:i], common.ShadowHostSuffix)
+	}
+	return false
+}
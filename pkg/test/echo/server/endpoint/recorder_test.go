@@ -0,0 +1,75 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"strconv"
+	"testing"
+
+	"istio.io/istio/pkg/test/echo/common/response"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(3)
+	for i := 0; i < 5; i++ {
+		r.Record(response.Request{URL: strconv.Itoa(i)})
+	}
+
+	urls := func(requests []response.Request) string {
+		out := ""
+		for _, req := range requests {
+			out += req.URL
+		}
+		return out
+	}
+	if got := urls(r.Last(0)); got != "234" {
+		t.Fatalf("Last(0): got %s, want 234", got)
+	}
+	if got := urls(r.Last(2)); got != "34" {
+		t.Fatalf("Last(2): got %s, want 34", got)
+	}
+
+	var nilRecorder *Recorder
+	nilRecorder.Record(response.Request{})
+	if got := nilRecorder.Last(1); len(got) != 0 {
+		t.Fatalf("nil recorder: got %v", got)
+	}
+}
+
+func TestRecorderCounts(t *testing.T) {
+	r := NewRecorder(1)
+	for _, req := range []response.Request{
+		{URL: "/a?x=y", Host: "b:80"},
+		{URL: "/a", Host: "b:80-shadow"},
+		{URL: "/a/b", Host: "b-shadow:80"},
+		{URL: "/c", Host: "b-shadow"},
+	} {
+		r.Record(req)
+	}
+
+	for _, c := range []struct {
+		prefix   string
+		expected response.RequestCounts
+	}{
+		{"", response.RequestCounts{Total: 4, Mirrored: 3}},
+		{"/a", response.RequestCounts{Total: 3, Mirrored: 2}},
+		{"/a/b", response.RequestCounts{Total: 1, Mirrored: 1}},
+		{"/d", response.RequestCounts{}},
+	} {
+		if got := r.Counts(c.prefix); got != c.expected {
+			t.Errorf("Counts(%q): got %+v, want %+v", c.prefix, got, c.expected)
+		}
+	}
+}
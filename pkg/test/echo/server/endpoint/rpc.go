@@ -0,0 +1,135 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/echo/common/rpc"
+	"istio.io/pkg/log"
+)
+
+var _ Instance = &rpcInstance{}
+
+// rpcInstance serves an RPC protocol, such as Thrift or Dubbo. Every request on a connection is answered
+// with the usual response fields as the result, until the client closes the connection.
+type rpcInstance struct {
+	Config
+	name     string
+	codec    rpc.Codec
+	listener net.Listener
+}
+
+func newThrift(config Config) Instance {
+	return &rpcInstance{
+		Config: config,
+		name:   "Thrift",
+		codec:  rpc.Thrift,
+	}
+}
+
+func newDubbo(config Config) Instance {
+	return &rpcInstance{
+		Config: config,
+		name:   "Dubbo",
+		codec:  rpc.Dubbo,
+	}
+}
+
+func (s *rpcInstance) Start(onReady OnReadyFunc) error {
+	// Listen on the given port and update the port if it changed from what was passed in.
+	listener, p, err := listenOnPort(s.Port.Port)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	// Store the actual listening port back to the argument.
+	s.Port.Port = p
+	fmt.Printf("Listening %s on %v\n", s.name, p)
+
+	go s.serve()
+
+	onReady()
+	return nil
+}
+
+func (s *rpcInstance) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			// The listener was closed.
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *rpcInstance) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	if !s.IsServerReady() {
+		log.Infof("%s service not ready, closing connection from %s", s.name, conn.RemoteAddr())
+		return
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		req, err := s.codec.ReadRequest(reader)
+		if err != nil {
+			if err != io.EOF {
+				log.Warnf("%s read from %s failed: %v", s.name, conn.RemoteAddr(), err)
+			}
+			return
+		}
+		log.Infof("%s Request:\n  RemoteAddr: %s\n  Method: %s\n  Message: %s",
+			s.name, conn.RemoteAddr(), req.Method, req.Payload)
+
+		var body bytes.Buffer
+		writeField(&body, response.ServiceVersionField, s.Version)
+		writeField(&body, response.ServicePortField, strconv.Itoa(s.Port.Port))
+		if s.Cluster != "" {
+			writeField(&body, response.ClusterField, s.Cluster)
+		}
+		if s.Locality != "" {
+			writeField(&body, response.LocalityField, s.Locality)
+		}
+		writeField(&body, response.Field("RemoteAddr"), conn.RemoteAddr().String())
+		writeField(&body, response.Field("Method"), req.Method)
+		writeField(&body, response.RPCMessageField, req.Payload)
+		if hostname, err := os.Hostname(); err == nil {
+			writeField(&body, response.HostnameField, hostname)
+		}
+		writeField(&body, response.StatusCodeField, response.StatusCodeOK)
+
+		if err := s.codec.WriteResponse(conn, rpc.Message{ID: req.ID, Method: req.Method, Payload: body.String()}); err != nil {
+			log.Warnf("%s write to %s failed: %v", s.name, conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+func (s *rpcInstance) Close() error {
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
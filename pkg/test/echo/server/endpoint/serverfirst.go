@@ -0,0 +1,124 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/pkg/log"
+)
+
+const (
+	serverFirstReadTimeout = 10 * time.Second
+)
+
+var _ Instance = &serverFirstInstance{}
+
+// serverFirstInstance simulates a server-first protocol, such as MySQL: as soon as a connection is
+// accepted, the server sends a greeting with the usual response fields, terminated by an empty line. It
+// then reads a single line from the client, echoes it back and closes the connection.
+type serverFirstInstance struct {
+	Config
+	listener net.Listener
+}
+
+func newServerFirst(config Config) Instance {
+	return &serverFirstInstance{
+		Config: config,
+	}
+}
+
+func (s *serverFirstInstance) Start(onReady OnReadyFunc) error {
+	// Listen on the given port and update the port if it changed from what was passed in.
+	listener, p, err := listenOnPort(s.Port.Port)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	// Store the actual listening port back to the argument.
+	s.Port.Port = p
+	fmt.Printf("Listening server-first TCP on %v\n", p)
+
+	go s.serve()
+
+	// The greeting doesn't depend on any other endpoint, so the port is ready as soon as it is bound.
+	onReady()
+	return nil
+}
+
+func (s *serverFirstInstance) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			// The listener was closed.
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *serverFirstInstance) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	if !s.IsServerReady() {
+		log.Infof("server-first service not ready, closing connection from %s", conn.RemoteAddr())
+		return
+	}
+
+	var greeting bytes.Buffer
+	writeField(&greeting, response.ServiceVersionField, s.Version)
+	writeField(&greeting, response.ServicePortField, strconv.Itoa(s.Port.Port))
+	if s.Cluster != "" {
+		writeField(&greeting, response.ClusterField, s.Cluster)
+	}
+	if s.Locality != "" {
+		writeField(&greeting, response.LocalityField, s.Locality)
+	}
+	writeField(&greeting, response.Field("RemoteAddr"), conn.RemoteAddr().String())
+	if hostname, err := os.Hostname(); err == nil {
+		writeField(&greeting, response.HostnameField, hostname)
+	}
+	writeField(&greeting, response.StatusCodeField, response.StatusCodeOK)
+	greeting.WriteString("\n")
+
+	if _, err := conn.Write(greeting.Bytes()); err != nil {
+		log.Warnf("server-first greeting to %s failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(serverFirstReadTimeout))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		log.Warnf("server-first read from %s failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+	var echo bytes.Buffer
+	writeField(&echo, response.Field("Echo"), line[:len(line)-1])
+	_, _ = conn.Write(echo.Bytes())
+}
+
+func (s *serverFirstInstance) Close() error {
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
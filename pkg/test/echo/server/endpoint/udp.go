@@ -0,0 +1,110 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package endpoint
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/pkg/log"
+)
+
+const (
+	// maxDatagramSize is the largest UDP payload that can be received.
+	maxDatagramSize = 65535
+)
+
+var _ Instance = &udpInstance{}
+
+// udpInstance echoes every datagram it receives back to the sender, together with the usual response
+// fields.
+type udpInstance struct {
+	Config
+	conn net.PacketConn
+}
+
+func newUDP(config Config) Instance {
+	return &udpInstance{
+		Config: config,
+	}
+}
+
+func (s *udpInstance) Start(onReady OnReadyFunc) error {
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", s.Port.Port))
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	// Store the actual listening port back to the argument.
+	s.Port.Port = conn.LocalAddr().(*net.UDPAddr).Port
+	fmt.Printf("Listening UDP on %v\n", s.Port.Port)
+
+	go s.serve()
+
+	// There is no handshake to wait for, the socket is ready as soon as it is bound.
+	onReady()
+	return nil
+}
+
+func (s *udpInstance) serve() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			// The connection was closed.
+			return
+		}
+
+		if !s.IsServerReady() {
+			log.Infof("UDP service not ready, dropping datagram from %s", addr)
+			continue
+		}
+
+		message := string(buf[:n])
+		log.Infof("UDP Request:\n  RemoteAddr: %s\n  Message: %s", addr, message)
+
+		var body bytes.Buffer
+		writeField(&body, response.ServiceVersionField, s.Version)
+		writeField(&body, response.ServicePortField, strconv.Itoa(s.Port.Port))
+		if s.Cluster != "" {
+			writeField(&body, response.ClusterField, s.Cluster)
+		}
+		if s.Locality != "" {
+			writeField(&body, response.LocalityField, s.Locality)
+		}
+		writeField(&body, response.Field("RemoteAddr"), addr.String())
+		writeField(&body, response.UDPMessageField, message)
+		if hostname, err := os.Hostname(); err == nil {
+			writeField(&body, response.HostnameField, hostname)
+		}
+		writeField(&body, response.StatusCodeField, response.StatusCodeOK)
+
+		if _, err := s.conn.WriteTo(body.Bytes(), addr); err != nil {
+			log.Warnf("UDP write to %s failed: %v", addr, err)
+		}
+	}
+}
+
+func (s *udpInstance) Close() error {
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
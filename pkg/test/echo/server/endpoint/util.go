@@ -16,10 +16,13 @@ package endpoint
 
 import (
 	"bytes"
+	"crypto/x509"
+	"encoding/base64"
 	"fmt"
 	"net"
 	"os"
 
+	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/echo/common/response"
 )
 
@@ -47,3 +50,24 @@ func listenOnUDS(uds string) (net.Listener, error) {
 func writeField(out *bytes.Buffer, field response.Field, value string) {
 	_, _ = out.WriteString(string(field) + "=" + value + "\n")
 }
+
+// writePeerCertificates writes the certificate chain presented by the client over TLS, leaf first. Each
+// certificate is written as the base64 encoded DER, i.e. the body of its PEM block, in a separate field.
+// nolint: interfacer
+func writePeerCertificates(out *bytes.Buffer, certs []*x509.Certificate) {
+	for _, c := range certs {
+		writeField(out, response.PeerCertificateField, base64.StdEncoding.EncodeToString(c.Raw))
+	}
+}
+
+// writePrincipals writes the principals derived from the given X-Forwarded-Client-Cert value, if any.
+// nolint: interfacer
+func writePrincipals(out *bytes.Buffer, xfcc string) {
+	e := common.LastXFCCElement(xfcc)
+	if e.URI != "" {
+		writeField(out, response.SourcePrincipalField, e.URI)
+	}
+	if e.By != "" {
+		writeField(out, response.DestinationPrincipalField, e.By)
+	}
+}
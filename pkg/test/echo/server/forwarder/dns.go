@@ -0,0 +1,67 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+
+	"istio.io/istio/pkg/test/echo/common/response"
+)
+
+var _ protocol = &dnsProtocol{}
+
+// dnsProtocol resolves the host of the request with the resolver of the workload, rather than sending a
+// request to it, and reports the resolved addresses.
+type dnsProtocol struct {
+	resolver *net.Resolver
+}
+
+func newDNSProtocol() *dnsProtocol {
+	return &dnsProtocol{resolver: net.DefaultResolver}
+}
+
+func (c *dnsProtocol) makeRequest(ctx context.Context, req *request) (string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", err
+	}
+
+	var outBuffer bytes.Buffer
+	outBuffer.WriteString(fmt.Sprintf("[%d] Url=%s\n", req.RequestID, req.URL))
+
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	addresses, err := c.resolver.LookupHost(ctx, u.Hostname())
+	if err != nil {
+		return outBuffer.String(), err
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.ResolvedAddressField, address))
+	}
+	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.StatusCodeField, response.StatusCodeOK))
+
+	return outBuffer.String(), nil
+}
+
+func (c *dnsProtocol) Close() error {
+	return nil
+}
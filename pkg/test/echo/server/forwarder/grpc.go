@@ -18,20 +18,43 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	golangproto "github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 
+	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/echo/common/response"
 	"istio.io/istio/pkg/test/echo/proto"
 )
 
 var _ protocol = &grpcProtocol{}
 
 type grpcProtocol struct {
+	// conn shared by all requests, unless dial is set.
 	conn   *grpc.ClientConn
 	client proto.EchoTestServiceClient
+	// dial opens a new connection for every request, if set.
+	dial func() (*grpc.ClientConn, error)
+	// requests is the number of requests sent over conn so far.
+	requests int32
+
+	// If non-zero, each request exchanges this number of messages over a stream instead of making a unary call.
+	streamMessages  int
+	serverStreaming bool
+	streamInterval  time.Duration
+
+	// reportStatus writes the status of every call to the output, and reports unary calls failing with a
+	// status in the output rather than as errors.
+	reportStatus bool
 }
 
 func (c *grpcProtocol) makeRequest(ctx context.Context, req *request) (string, error) {
@@ -47,18 +70,38 @@ func (c *grpcProtocol) makeRequest(ctx context.Context, req *request) (string, e
 			outMD.Set(k, v...)
 		}
 	}
-	outMD.Set("X-Request-Id", strconv.Itoa(req.RequestID))
+	if req.Header.Get(requestIDHeader) == "" {
+		outMD.Set(requestIDHeader, strconv.Itoa(req.RequestID))
+	}
 	ctx = metadata.NewOutgoingContext(ctx, outMD)
 
+	client, reused, release, err := c.connect()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	if c.streamMessages > 0 {
+		return c.makeStreamRequest(ctx, client, reused, req), nil
+	}
+
 	var outBuffer bytes.Buffer
 	grpcReq := &proto.EchoRequest{
 		Message: fmt.Sprintf("request #%d", req.RequestID),
 	}
 	outBuffer.WriteString(fmt.Sprintf("[%d] grpcecho.Echo(%v)\n", req.RequestID, req))
 
-	resp, err := c.client.Echo(ctx, grpcReq)
+	var p peer.Peer
+	resp, err := client.Echo(ctx, grpcReq, grpc.Peer(&p))
 	if err != nil {
-		return "", err
+		if _, ok := status.FromError(err); !ok || !c.reportStatus {
+			return "", err
+		}
+	}
+	writeIPFamily(req.RequestID, p.Addr, &outBuffer)
+	writeConnectionReused(req.RequestID, reused, &outBuffer)
+	if c.reportStatus {
+		writeGRPCStatus(req.RequestID, err, &outBuffer)
 	}
 
 	// when the underlying HTTP2 request returns status 404, GRPC
@@ -72,6 +115,143 @@ func (c *grpcProtocol) makeRequest(ctx context.Context, req *request) (string, e
 	return outBuffer.String(), nil
 }
 
+// connect returns the client to send a request with, and whether the request reuses a connection that an
+// earlier request was sent over. The returned function releases the connection once the request completes.
+func (c *grpcProtocol) connect() (proto.EchoTestServiceClient, bool, func(), error) {
+	if c.dial == nil {
+		return c.client, atomic.AddInt32(&c.requests, 1) > 1, func() {}, nil
+	}
+	conn, err := c.dial()
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return proto.NewEchoTestServiceClient(conn), false, func() { _ = conn.Close() }, nil
+}
+
+// makeStreamRequest exchanges the configured number of messages over a stream. The latency of every
+// message is reported: for bidirectional streams it is the round trip of the message, for server streams
+// the time since the previous message was received. Like for the TCP scheme, failures of the stream are
+// reported in the output rather than as errors, so that callers can tell after how many messages the
+// stream was reset.
+func (c *grpcProtocol) makeStreamRequest(ctx context.Context, client proto.EchoTestServiceClient, reused bool,
+	req *request) string {
+	var outBuffer bytes.Buffer
+	writeConnectionReused(req.RequestID, reused, &outBuffer)
+	message := fmt.Sprintf("request #%d", req.RequestID)
+
+	writeMessage := func(index int, latency time.Duration, resp *proto.EchoResponse) {
+		for _, line := range strings.Split(resp.GetMessage(), "\n") {
+			if line != "" {
+				outBuffer.WriteString(fmt.Sprintf("[%d body] %s\n", req.RequestID, line))
+			}
+		}
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%d %s\n", req.RequestID, response.StreamMessageField, index, latency))
+	}
+
+	var err error
+	if c.serverStreaming {
+		outBuffer.WriteString(fmt.Sprintf("[%d] grpcecho.EchoServerStream(%v)\n", req.RequestID, req))
+		err = c.serverStream(ctx, client, message, writeMessage)
+	} else {
+		outBuffer.WriteString(fmt.Sprintf("[%d] grpcecho.EchoStream(%v)\n", req.RequestID, req))
+		err = c.bidiStream(ctx, client, message, writeMessage)
+	}
+
+	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%d\n", req.RequestID, response.StreamCodeField, status.Code(err)))
+	if err != nil {
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%v\n", req.RequestID, response.StreamErrorField, err))
+	}
+	if c.reportStatus {
+		writeGRPCStatus(req.RequestID, err, &outBuffer)
+	}
+	return outBuffer.String()
+}
+
+// writeGRPCStatus writes the code, message and details of the status of a call that completed with the given
+// error. Details of types that are not linked into the echo server are written with their type URL only.
+func writeGRPCStatus(requestID int, err error, out *bytes.Buffer) {
+	s := status.Convert(err)
+	out.WriteString(fmt.Sprintf("[%d] %s=%d\n", requestID, response.GRPCCodeField, s.Code()))
+	if s.Message() != "" {
+		out.WriteString(fmt.Sprintf("[%d] %s=%s\n", requestID, response.GRPCMessageField,
+			strings.Replace(s.Message(), "\n", " ", -1)))
+	}
+	for _, d := range s.Proto().GetDetails() {
+		detail := d.GetTypeUrl()
+		var any ptypes.DynamicAny
+		if e := ptypes.UnmarshalAny(d, &any); e == nil {
+			detail += " " + golangproto.CompactTextString(any.Message)
+		}
+		out.WriteString(fmt.Sprintf("[%d] %s=%s\n", requestID, response.GRPCDetailField, detail))
+	}
+}
+
+func (c *grpcProtocol) bidiStream(ctx context.Context, client proto.EchoTestServiceClient, message string,
+	onMessage func(int, time.Duration, *proto.EchoResponse)) error {
+	stream, err := client.EchoStream(ctx)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < c.streamMessages; i++ {
+		if i > 0 && c.streamInterval > 0 {
+			select {
+			case <-time.After(c.streamInterval):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		start := time.Now()
+		if err := stream.Send(&proto.EchoRequest{Message: fmt.Sprintf("%s #%d", message, i)}); err != nil {
+			if err == io.EOF {
+				// The stream was terminated by the server, the status is returned by Recv.
+				_, err = stream.Recv()
+			}
+			return err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		onMessage(i, time.Since(start), resp)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+func (c *grpcProtocol) serverStream(ctx context.Context, client proto.EchoTestServiceClient, message string,
+	onMessage func(int, time.Duration, *proto.EchoResponse)) error {
+	start := time.Now()
+	stream, err := client.EchoServerStream(ctx, &proto.EchoServerStreamRequest{
+		Message:        message,
+		Count:          int32(c.streamMessages),
+		IntervalMicros: common.DurationToMicros(c.streamInterval),
+	})
+	if err != nil {
+		return err
+	}
+	for i := 0; ; i++ {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		onMessage(i, now.Sub(start), resp)
+		start = now
+	}
+}
+
 func (c *grpcProtocol) Close() error {
+	if c.conn == nil {
+		return nil
+	}
 	return c.conn.Close()
 }
@@ -15,12 +15,21 @@
 package forwarder
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
 
 	"istio.io/istio/pkg/test/echo/common"
 	"istio.io/istio/pkg/test/echo/common/response"
@@ -29,18 +38,58 @@ import (
 var _ protocol = &httpProtocol{}
 
 type httpProtocol struct {
+	// client shared by all requests, so that they can reuse pooled connections.
 	client *http.Client
-	do     common.HTTPDoFunc
+	// newClient creates a client for every request instead, if client is nil. The connections of the
+	// client are closed once the request completes.
+	newClient func() *http.Client
+	tlsConfig *tls.Config
+	// serverName overrides the SNI, which otherwise is the Host of the request.
+	serverName string
+	do         common.HTTPDoFunc
+
+	// If non-zero, each request asks the server to stream this number of events over a long-lived response,
+	// as server-sent events.
+	streamMessages int
+	streamInterval time.Duration
+}
+
+// newHTTP2Transport returns a transport that only speaks HTTP/2. Without TLS, HTTP/2 is used with prior
+// knowledge (h2c), otherwise h2 is negotiated through ALPN.
+func newHTTP2Transport(tlsConfig *tls.Config, useTLS bool,
+	dialContext func(ctx context.Context, network, addr string) (net.Conn, error)) http.RoundTripper {
+	if dialContext == nil {
+		dialContext = (&net.Dialer{}).DialContext
+	}
+	return &http2.Transport{
+		TLSClientConfig: tlsConfig,
+		AllowHTTP:       true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			conn, err := dialContext(context.Background(), network, addr)
+			if err != nil || !useTLS {
+				return conn, err
+			}
+			tlsConn := tls.Client(conn, cfg)
+			if err := tlsConn.Handshake(); err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		},
+	}
 }
 
 func (c *httpProtocol) setHost(r *http.Request, host string) {
 	r.Host = host
 
 	if r.URL.Scheme == "https" {
-		// Set SNI value to be same as the request Host
+		// Set SNI value to be same as the request Host, unless overridden
 		// For use with SNI routing tests
-		httpTransport := c.client.Transport.(*http.Transport)
-		httpTransport.TLSClientConfig.ServerName = host
+		if c.serverName != "" {
+			c.tlsConfig.ServerName = c.serverName
+		} else {
+			c.tlsConfig.ServerName = host
+		}
 	}
 }
 
@@ -49,10 +98,28 @@ func (c *httpProtocol) makeRequest(ctx context.Context, req *request) (string, e
 	if err != nil {
 		return "", err
 	}
+	if c.streamMessages > 0 {
+		query := httpReq.URL.Query()
+		query.Set(common.StreamEventsParam, strconv.Itoa(c.streamMessages))
+		if c.streamInterval > 0 {
+			query.Set(common.StreamIntervalParam, c.streamInterval.String())
+		}
+		httpReq.URL.RawQuery = query.Encode()
+	}
 
 	// Set the per-request timeout.
 	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
 	defer cancel()
+	// Record the connection the request is sent on, to report its address family and whether it was
+	// reused from an earlier request.
+	var remoteAddr net.Addr
+	var reused bool
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			remoteAddr = info.Conn.RemoteAddr()
+			reused = info.Reused
+		},
+	})
 	httpReq = httpReq.WithContext(ctx)
 
 	var outBuffer bytes.Buffer
@@ -68,19 +135,42 @@ func (c *httpProtocol) makeRequest(ctx context.Context, req *request) (string, e
 
 	c.setHost(httpReq, host)
 
-	httpResp, err := c.do(c.client, httpReq)
+	client := c.client
+	if client == nil {
+		client = c.newClient()
+		httpReq.Close = true
+		defer closeIdleConnections(client)
+	}
+
+	httpResp, err := c.do(client, httpReq)
 	if err != nil {
 		return outBuffer.String(), err
 	}
 
 	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%d\n", req.RequestID, response.StatusCodeField, httpResp.StatusCode))
+	if remoteAddr != nil {
+		writeIPFamily(req.RequestID, remoteAddr, &outBuffer)
+		writeConnectionReused(req.RequestID, reused, &outBuffer)
+	}
+	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.ClientProtocolField, httpResp.Proto))
+	if httpResp.TLS != nil {
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.ALPNField,
+			httpResp.TLS.NegotiatedProtocol))
+		writeServerCertificates(req.RequestID, httpResp.TLS.PeerCertificates, &outBuffer)
+	}
 
 	for key, values := range httpResp.Header {
 		for _, value := range values {
-			outBuffer.WriteString(fmt.Sprintf("[%d] ResponseHeader=%s:%s\n", req.RequestID, key, value))
+			outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s:%s\n", req.RequestID, response.ResponseHeaderField, key, value))
 		}
 	}
 
+	if c.streamMessages > 0 {
+		c.readEvents(req.RequestID, httpResp.Body, &outBuffer)
+		_ = httpResp.Body.Close()
+		return outBuffer.String(), nil
+	}
+
 	data, err := ioutil.ReadAll(httpResp.Body)
 	defer func() {
 		if err = httpResp.Body.Close(); err != nil {
@@ -92,8 +182,11 @@ func (c *httpProtocol) makeRequest(ctx context.Context, req *request) (string, e
 		return outBuffer.String(), err
 	}
 
+	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%d\n", req.RequestID, response.BodySizeField, len(data)))
+	padding := string(response.PaddingField) + "="
 	for _, line := range strings.Split(string(data), "\n") {
-		if line != "" {
+		// The padding of large responses is only reported through the body size.
+		if line != "" && !strings.HasPrefix(line, padding) {
 			outBuffer.WriteString(fmt.Sprintf("[%d body] %s\n", req.RequestID, line))
 		}
 	}
@@ -101,6 +194,45 @@ func (c *httpProtocol) makeRequest(ctx context.Context, req *request) (string, e
 	return outBuffer.String(), nil
 }
 
+// readEvents reads the server-sent events of a streamed response, reporting the time since the previous event
+// (or since the headers were received) for every event. Like for gRPC streams, failures of the stream are
+// reported in the output rather than as errors, so that callers can tell after how many events the stream was
+// reset, e.g. by an idle timeout or a policy change.
+func (c *httpProtocol) readEvents(requestID int, body io.Reader, out *bytes.Buffer) {
+	reader := bufio.NewReader(body)
+	start := time.Now()
+	index := 0
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, common.StreamEventPrefix) {
+			now := time.Now()
+			out.WriteString(fmt.Sprintf("[%d body] %s\n", requestID, strings.TrimPrefix(line, common.StreamEventPrefix)))
+			out.WriteString(fmt.Sprintf("[%d] %s=%d %s\n", requestID, response.StreamMessageField, index, now.Sub(start)))
+			index++
+			start = now
+		} else if line != "" {
+			out.WriteString(fmt.Sprintf("[%d body] %s\n", requestID, line))
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			out.WriteString(fmt.Sprintf("[%d] %s=%v\n", requestID, response.StreamErrorField, err))
+			return
+		}
+	}
+}
+
 func (c *httpProtocol) Close() error {
+	if c.client != nil {
+		closeIdleConnections(c.client)
+	}
 	return nil
 }
+
+func closeIdleConnections(client *http.Client) {
+	if t, ok := client.Transport.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
@@ -16,13 +16,16 @@ package forwarder
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/golang/sync/errgroup"
 
 	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/echo/common/response"
 	"istio.io/istio/pkg/test/echo/proto"
 	"istio.io/pkg/log"
 )
@@ -52,6 +55,10 @@ type Instance struct {
 	qps     int
 	header  http.Header
 	message string
+	// concurrency is the maximum number of requests in flight. If zero, all requests are sent in parallel.
+	concurrency int
+	// hostname of the forwarder, reported as the source workload of each request.
+	hostname string
 }
 
 // New creates a new forwarder Instance.
@@ -63,14 +70,23 @@ func New(cfg Config) (*Instance, error) {
 		return nil, err
 	}
 
+	concurrency := int(cfg.Request.Concurrency)
+	if cfg.Request.ReuseConnection {
+		// Send each request only once the previous one completed, so that it reuses the pooled connection.
+		concurrency = 1
+	}
+
+	hostname, _ := os.Hostname()
 	return &Instance{
-		p:       p,
-		url:     cfg.Request.Url,
-		timeout: common.GetTimeout(cfg.Request),
-		count:   common.GetCount(cfg.Request),
-		qps:     int(cfg.Request.Qps),
-		header:  common.GetHeaders(cfg.Request),
-		message: cfg.Request.Message,
+		p:           p,
+		url:         cfg.Request.Url,
+		timeout:     common.GetTimeout(cfg.Request),
+		count:       common.GetCount(cfg.Request),
+		qps:         int(cfg.Request.Qps),
+		header:      common.GetHeaders(cfg.Request),
+		message:     cfg.Request.Message,
+		concurrency: concurrency,
+		hostname:    hostname,
 	}, nil
 }
 
@@ -87,25 +103,43 @@ func (i *Instance) Run(ctx context.Context) (*proto.ForwardEchoResponse, error)
 		throttle = time.NewTicker(sleepTime)
 	}
 
+	var inFlight chan struct{}
+	if i.concurrency > 0 {
+		inFlight = make(chan struct{}, i.concurrency)
+	}
+
 	for reqIndex := 0; reqIndex < i.count; reqIndex++ {
 		r := request{
 			RequestID: reqIndex,
 			URL:       i.url,
 			Message:   i.message,
-			Header:    i.header,
+			Header:    i.requestHeader(reqIndex),
 			Timeout:   i.timeout,
 		}
+		if id := r.Header.Get(requestIDHeader); id != "" {
+			log.Infof("[%d] Forwarding request %s to %s", reqIndex, id, i.url)
+		}
 
 		if throttle != nil {
 			<-throttle.C
 		}
 
-		// TODO(nmittler): Refactor this to limit the number of go routines.
+		if inFlight != nil {
+			inFlight <- struct{}{}
+		}
 		g.Go(func() error {
+			if inFlight != nil {
+				defer func() { <-inFlight }()
+			}
+			start := time.Now()
 			resp, err := i.p.makeRequest(ctx, &r)
 			if err != nil {
 				return err
 			}
+			resp = fmt.Sprintf("[%d] %s=%s\n", r.RequestID, response.LatencyField, time.Since(start)) + resp
+			if i.hostname != "" {
+				resp = fmt.Sprintf("[%d] %s=%s\n", r.RequestID, response.SourceWorkloadField, i.hostname) + resp
+			}
 			responses[r.RequestID] = resp
 			return nil
 		})
@@ -120,6 +154,22 @@ func (i *Instance) Run(ctx context.Context) (*proto.ForwardEchoResponse, error)
 	}, nil
 }
 
+// requestHeader returns the header of the request with the given index. If the forwarded request has an
+// X-Request-Id, the index is appended to it, so that every request has a unique ID which can be correlated
+// with the logs of the server and of the proxies.
+func (i *Instance) requestHeader(reqIndex int) http.Header {
+	id := i.header.Get(requestIDHeader)
+	if id == "" || i.count == 1 {
+		return i.header
+	}
+	header := make(http.Header, len(i.header))
+	for k, v := range i.header {
+		header[k] = v
+	}
+	header.Set(requestIDHeader, fmt.Sprintf("%s-%d", id, reqIndex))
+	return header
+}
+
 func (i *Instance) Close() error {
 	return i.p.Close()
 }
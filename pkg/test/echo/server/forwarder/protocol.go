@@ -19,6 +19,8 @@ package forwarder
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -32,6 +34,7 @@ import (
 	"google.golang.org/grpc/credentials"
 
 	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/echo/common/rpc"
 	"istio.io/istio/pkg/test/echo/common/scheme"
 	"istio.io/istio/pkg/test/echo/proto"
 )
@@ -71,28 +74,75 @@ func newProtocol(cfg Config) (protocol, error) {
 	timeout := common.GetTimeout(cfg.Request)
 	headers := common.GetHeaders(cfg.Request)
 
+	tlsConfig, err := newTLSConfig(cfg.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyURL, err := parseProxyURL(cfg.Request.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL != nil && len(cfg.UDS) > 0 {
+		return nil, errors.New("requests over a unix domain socket can't be sent through a proxy")
+	}
+	if proxyURL != nil && !proxySupported(scheme.Instance(u.Scheme)) {
+		return nil, fmt.Errorf("%s requests can't be sent through a proxy", u.Scheme)
+	}
+
 	switch scheme.Instance(u.Scheme) {
 	case scheme.HTTP, scheme.HTTPS:
-		return &httpProtocol{
-			client: &http.Client{
-				Transport: &http.Transport{
-					TLSClientConfig: &tls.Config{
-						InsecureSkipVerify: true,
-					},
-					DialContext: httpDialContext,
-				},
-				Timeout: timeout,
-			},
-			do: cfg.Dialer.HTTP,
-		}, nil
+		tlsConfig.NextProtos = cfg.Request.Alpn
+		if proxyURL != nil && cfg.Request.Http2 {
+			return nil, errors.New("HTTP/2 requests can't be sent through a proxy")
+		}
+		newClient := func() *http.Client {
+			t := &http.Transport{
+				TLSClientConfig:   tlsConfig,
+				DialContext:       httpDialContext,
+				DisableKeepAlives: cfg.Request.NewConnectionPerRequest,
+			}
+			if proxyURL != nil {
+				// Plain HTTP requests are sent to HTTP proxies in absolute form, all others are tunneled.
+				t.Proxy = http.ProxyURL(proxyURL)
+			}
+			var transport http.RoundTripper = t
+			if cfg.Request.Http2 {
+				transport = newHTTP2Transport(tlsConfig, scheme.Instance(u.Scheme) == scheme.HTTPS, httpDialContext)
+			}
+			return &http.Client{
+				Transport: transport,
+				Timeout:   timeout,
+			}
+		}
+		p := &httpProtocol{
+			tlsConfig:      tlsConfig,
+			serverName:     cfg.Request.ServerName,
+			do:             cfg.Dialer.HTTP,
+			streamMessages: int(cfg.Request.StreamMessages),
+			streamInterval: common.MicrosToDuration(cfg.Request.StreamIntervalMicros),
+		}
+		if cfg.Request.NewConnectionPerRequest {
+			p.newClient = newClient
+		} else {
+			p.client = newClient()
+		}
+		return p, nil
 	case scheme.GRPC, scheme.GRPCS:
 		// grpc-go sets incorrect authority header
 		authority := headers.Get(hostHeader)
+		serverName := authority
+		if cfg.Request.ServerName != "" {
+			serverName = cfg.Request.ServerName
+		}
 
 		// transport security
 		security := grpc.WithInsecure()
-		if scheme.Instance(u.Scheme) == scheme.GRPCS {
-			creds, err := credentials.NewClientTLSFromFile(cfg.TLSCert, authority)
+		if scheme.Instance(u.Scheme) == scheme.GRPCS && hasClientTLS(cfg.Request) {
+			tlsConfig.ServerName = serverName
+			security = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+		} else if scheme.Instance(u.Scheme) == scheme.GRPCS {
+			creds, err := credentials.NewClientTLSFromFile(cfg.TLSCert, serverName)
 			if err != nil {
 				log.Fatalf("failed to load client certs %s %v", cfg.TLSCert, err)
 			}
@@ -102,33 +152,117 @@ func newProtocol(cfg Config) (protocol, error) {
 		// Strip off the scheme from the address.
 		address := rawURL[len(u.Scheme+"://"):]
 
-		// Connect to the GRPC server.
-		ctx, cancel := context.WithTimeout(context.Background(), common.ConnectionTimeout)
-		defer cancel()
-		grpcConn, err := cfg.Dialer.GRPC(ctx,
-			address,
+		opts := []grpc.DialOption{
 			security,
 			grpc.WithAuthority(authority),
-			grpc.WithBlock())
-		if err != nil {
-			return nil, err
+			grpc.WithBlock(),
 		}
-		return &grpcProtocol{
-			conn:   grpcConn,
-			client: proto.NewEchoTestServiceClient(grpcConn),
-		}, nil
+		if proxyURL != nil {
+			proxyDial, err := newProxyDialer(proxyURL, (&net.Dialer{}).DialContext)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+				return proxyDial(ctx, "tcp", addr)
+			}))
+		}
+
+		// Connect to the GRPC server.
+		dial := func() (*grpc.ClientConn, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), common.ConnectionTimeout)
+			defer cancel()
+			return cfg.Dialer.GRPC(ctx, address, opts...)
+		}
+		p := &grpcProtocol{
+			streamMessages:  int(cfg.Request.StreamMessages),
+			serverStreaming: cfg.Request.ServerStreaming,
+			streamInterval:  common.MicrosToDuration(cfg.Request.StreamIntervalMicros),
+			reportStatus:    cfg.Request.ReportGrpcStatus,
+		}
+		if cfg.Request.NewConnectionPerRequest {
+			p.dial = dial
+		} else {
+			grpcConn, err := dial()
+			if err != nil {
+				return nil, err
+			}
+			p.conn = grpcConn
+			p.client = proto.NewEchoTestServiceClient(grpcConn)
+		}
+		return p, nil
 	case scheme.WebSocket, scheme.WebSocketS:
 		dialer := &websocket.Dialer{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
+			TLSClientConfig:  tlsConfig,
 			NetDial:          wsDialContext,
 			HandshakeTimeout: timeout,
 		}
+		if proxyURL != nil {
+			dialer.Proxy = http.ProxyURL(proxyURL)
+		}
 		return &websocketProtocol{
 			dialer: dialer,
 		}, nil
+	case scheme.TCP:
+		p := newTCPProtocol(cfg.UDS)
+		p.streamMessages = int(cfg.Request.StreamMessages)
+		p.streamInterval = common.MicrosToDuration(cfg.Request.StreamIntervalMicros)
+		if proxyURL != nil {
+			if p.dial, err = newProxyDialer(proxyURL, p.dial); err != nil {
+				return nil, err
+			}
+		}
+		return p, nil
+	case scheme.TCPServerFirst:
+		return newServerFirstProtocol(cfg.UDS), nil
+	case scheme.UDP:
+		return newUDPProtocol(), nil
+	case scheme.DNS:
+		return newDNSProtocol(), nil
+	case scheme.Thrift:
+		return newRPCProtocol(rpc.Thrift), nil
+	case scheme.Dubbo:
+		return newRPCProtocol(rpc.Dubbo), nil
 	}
 
 	return nil, fmt.Errorf("unrecognized protocol %q", u.String())
 }
+
+// proxySupported indicates whether requests with the given scheme can be sent through a forward proxy.
+func proxySupported(s scheme.Instance) bool {
+	switch s {
+	case scheme.HTTP, scheme.HTTPS, scheme.GRPC, scheme.GRPCS, scheme.WebSocket, scheme.WebSocketS, scheme.TCP:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasClientTLS indicates whether the request provides its own TLS certificates.
+func hasClientTLS(r *proto.ForwardEchoRequest) bool {
+	return r.Cert != "" || r.Key != "" || r.CaCert != ""
+}
+
+// newTLSConfig creates the TLS configuration for requests over TLS. The server certificate is only
+// verified if the request provides root certificates.
+func newTLSConfig(r *proto.ForwardEchoRequest) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+	if r.Cert != "" || r.Key != "" {
+		cert, err := tls.X509KeyPair([]byte(r.Cert), []byte(r.Key))
+		if err != nil {
+			return nil, fmt.Errorf("failed loading client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if r.CaCert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(r.CaCert)) {
+			return nil, errors.New("failed loading root certificates: no certificates found")
+		}
+		tlsConfig.RootCAs = pool
+		tlsConfig.InsecureSkipVerify = false
+	}
+	tlsConfig.ServerName = r.ServerName
+	return tlsConfig, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+const (
+	// httpProxyScheme is the scheme of proxies that tunnel connections with HTTP CONNECT, and forward plain
+	// HTTP requests sent in absolute form.
+	httpProxyScheme = "http"
+	// socks5ProxyScheme is the scheme of SOCKS5 proxies.
+	socks5ProxyScheme = "socks5"
+
+	proxyAuthorizationHeader = "Proxy-Authorization"
+)
+
+type dialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// parseProxyURL parses the URL of the forward proxy of a request. Returns nil if the request isn't sent
+// through a proxy.
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing proxy URL %s: %v", rawURL, err)
+	}
+	switch u.Scheme {
+	case httpProxyScheme, socks5ProxyScheme:
+	default:
+		return nil, fmt.Errorf("unsupported proxy URL %s: the scheme must be %s or %s",
+			rawURL, httpProxyScheme, socks5ProxyScheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("unsupported proxy URL %s: missing host", rawURL)
+	}
+	return u, nil
+}
+
+// newProxyDialer returns a dial function that opens connections through the given proxy, with HTTP CONNECT
+// or SOCKS5 depending on its scheme. The connections of the proxy itself are opened with dial.
+func newProxyDialer(proxyURL *url.URL, dial dialContextFunc) (dialContextFunc, error) {
+	if proxyURL.Scheme == socks5ProxyScheme {
+		var auth *proxy.Auth
+		if proxyURL.User != nil {
+			password, _ := proxyURL.User.Password()
+			auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+		}
+		d, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, contextDialer(dial))
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, network, address string) (net.Conn, error) {
+			if cd, ok := d.(interface {
+				DialContext(ctx context.Context, network, address string) (net.Conn, error)
+			}); ok {
+				return cd.DialContext(ctx, network, address)
+			}
+			return d.Dial(network, address)
+		}, nil
+	}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, proxyURL.Host)
+		if err != nil {
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		tunnel, err := connect(conn, proxyURL, address)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		// The deadline only applies to establishing the tunnel, the connection may outlive the context.
+		_ = conn.SetDeadline(time.Time{})
+		return tunnel, nil
+	}, nil
+}
+
+// connect asks the HTTP proxy on the other end of conn to open a tunnel to address, and returns the tunnel.
+func connect(conn net.Conn, proxyURL *url.URL, address string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set(proxyAuthorizationHeader, "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("failed sending CONNECT %s to proxy %s: %v", address, proxyURL.Host, err)
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading the response to CONNECT %s from proxy %s: %v", address, proxyURL.Host, err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy %s refused CONNECT %s: %s", proxyURL.Host, address, resp.Status)
+	}
+	// The tunnel starts right after the response, so keep any of its data read ahead with the response.
+	return &bufferedConn{Conn: conn, r: r}, nil
+}
+
+// bufferedConn reads from r before reading from the underlying connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	if c.r.Buffered() > 0 {
+		return c.r.Read(b)
+	}
+	return c.Conn.Read(b)
+}
+
+// contextDialer adapts a dial function to a proxy.Dialer.
+type contextDialer dialContextFunc
+
+func (d contextDialer) Dial(network, address string) (net.Conn, error) {
+	return d(context.Background(), network, address)
+}
+
+func (d contextDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d(ctx, network, address)
+}
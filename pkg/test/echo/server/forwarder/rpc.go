@@ -0,0 +1,101 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"istio.io/istio/pkg/test/echo/common/rpc"
+)
+
+var _ protocol = &rpcProtocol{}
+
+// rpcProtocol invokes the echo method of an RPC protocol, such as Thrift or Dubbo, with the message of the
+// request as the argument, and reports the result returned by the server as the response body.
+type rpcProtocol struct {
+	codec  rpc.Codec
+	dialer *net.Dialer
+}
+
+func newRPCProtocol(codec rpc.Codec) *rpcProtocol {
+	return &rpcProtocol{
+		codec:  codec,
+		dialer: &net.Dialer{},
+	}
+}
+
+func (c *rpcProtocol) makeRequest(ctx context.Context, req *request) (string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", err
+	}
+
+	var outBuffer bytes.Buffer
+	outBuffer.WriteString(fmt.Sprintf("[%d] Url=%s\n", req.RequestID, req.URL))
+
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("request #%d", req.RequestID)
+	}
+	outBuffer.WriteString(fmt.Sprintf("[%d] Echo=%s\n", req.RequestID, message))
+
+	// Apply per-request timeout to calculate deadline for reads/writes.
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return outBuffer.String(), err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	writeIPFamily(req.RequestID, conn.RemoteAddr(), &outBuffer)
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return outBuffer.String(), err
+	}
+
+	id := int64(req.RequestID)
+	if err := c.codec.WriteRequest(conn, rpc.Message{ID: id, Method: rpc.EchoMethod, Payload: message}); err != nil {
+		return outBuffer.String(), err
+	}
+	resp, err := c.codec.ReadResponse(bufio.NewReader(conn))
+	if err != nil {
+		return outBuffer.String(), err
+	}
+	if resp.ID != id {
+		return outBuffer.String(), fmt.Errorf("response for request %d, expected %d", resp.ID, id)
+	}
+
+	for _, line := range strings.Split(resp.Payload, "\n") {
+		if line != "" {
+			outBuffer.WriteString(fmt.Sprintf("[%d body] %s\n", req.RequestID, line))
+		}
+	}
+
+	return outBuffer.String(), nil
+}
+
+func (c *rpcProtocol) Close() error {
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/common/response"
+)
+
+var _ protocol = &serverFirstProtocol{}
+
+// serverFirstProtocol talks to a server-first endpoint of the echo server: it waits for the greeting sent
+// by the server before sending anything, then sends the message of the request and reads the echo. Like
+// for the TCP scheme, connection failures are reported in the output rather than as errors.
+type serverFirstProtocol struct {
+	*tcpProtocol
+}
+
+func newServerFirstProtocol(uds string) *serverFirstProtocol {
+	return &serverFirstProtocol{tcpProtocol: newTCPProtocol(uds)}
+}
+
+func (c *serverFirstProtocol) makeRequest(ctx context.Context, req *request) (string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", err
+	}
+
+	var outBuffer bytes.Buffer
+	outBuffer.WriteString(fmt.Sprintf("[%d] Url=%s\n", req.RequestID, req.URL))
+
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("request #%d", req.RequestID)
+	}
+	outBuffer.WriteString(fmt.Sprintf("[%d] Echo=%s\n", req.RequestID, message))
+
+	writeResult := func(result response.TCPResult, echoed int, err error) {
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.TCPResultField, result))
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%d\n", req.RequestID, response.TCPBytesEchoedField, echoed))
+		if err != nil {
+			outBuffer.WriteString(fmt.Sprintf("[%d] %s=%v\n", req.RequestID, response.TCPErrorField, err))
+		}
+	}
+	writeBody := func(data string) {
+		for _, line := range strings.Split(data, "\n") {
+			if line != "" {
+				outBuffer.WriteString(fmt.Sprintf("[%d body] %s\n", req.RequestID, line))
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := c.dial(ctx, "tcp", u.Host)
+	if err != nil {
+		result := tcpResultForError(err)
+		if result == response.TCPResultReset {
+			// A reset while connecting means nothing was listening.
+			result = response.TCPResultRefused
+		}
+		writeResult(result, 0, err)
+		return outBuffer.String(), nil
+	}
+	defer func() { _ = conn.Close() }()
+	writeIPFamily(req.RequestID, conn.RemoteAddr(), &outBuffer)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// The greeting is terminated by an empty line.
+	reader := bufio.NewReader(conn)
+	var greeting strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		greeting.WriteString(line)
+		if err != nil {
+			writeResult(tcpResultForError(err), greeting.Len(), err)
+			writeBody(greeting.String())
+			return outBuffer.String(), nil
+		}
+		if line == "\n" {
+			break
+		}
+	}
+	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.GreetingLatencyField, time.Since(start)))
+
+	if _, err := fmt.Fprintf(conn, "%s\n", message); err != nil {
+		writeResult(tcpResultForError(err), greeting.Len(), err)
+		writeBody(greeting.String())
+		return outBuffer.String(), nil
+	}
+
+	echo, err := ioutil.ReadAll(reader)
+	writeResult(tcpResultForError(err), greeting.Len()+len(echo), err)
+	writeBody(greeting.String() + string(echo))
+	return outBuffer.String(), nil
+}
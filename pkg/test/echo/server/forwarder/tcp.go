@@ -0,0 +1,231 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/echo/common/response"
+)
+
+var _ protocol = &tcpProtocol{}
+
+// tcpProtocol sends a minimal HTTP/1.0 request over a raw TCP connection, so that the echo server on the
+// other end responds as usual. Unlike the other protocols, connection failures are reported in the
+// output rather than as errors, so that callers can tell at which layer the connection was denied.
+type tcpProtocol struct {
+	dial func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// If non-zero, each request asks the server to stream this number of events before it closes the
+	// connection, so that the connection stays open for the duration of the stream.
+	streamMessages int
+	streamInterval time.Duration
+}
+
+func newTCPProtocol(uds string) *tcpProtocol {
+	d := &net.Dialer{}
+	dial := d.DialContext
+	if len(uds) > 0 {
+		dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", uds)
+		}
+	}
+	return &tcpProtocol{dial: dial}
+}
+
+func (c *tcpProtocol) makeRequest(ctx context.Context, req *request) (string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", err
+	}
+
+	var outBuffer bytes.Buffer
+	outBuffer.WriteString(fmt.Sprintf("[%d] Url=%s\n", req.RequestID, req.URL))
+
+	host := u.Host
+	var reqBuffer bytes.Buffer
+	writeHeaders(req.RequestID, req.Header, outBuffer, func(key string, value string) {
+		if key == hostHeader {
+			host = value
+		} else {
+			reqBuffer.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
+		}
+	})
+	if c.streamMessages > 0 {
+		query := u.Query()
+		query.Set(common.StreamEventsParam, strconv.Itoa(c.streamMessages))
+		if c.streamInterval > 0 {
+			query.Set(common.StreamIntervalParam, c.streamInterval.String())
+		}
+		u.RawQuery = query.Encode()
+	}
+	path := u.RequestURI()
+
+	writeResult := func(result response.TCPResult, echoed int, err error) {
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", req.RequestID, response.TCPResultField, result))
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%d\n", req.RequestID, response.TCPBytesEchoedField, echoed))
+		if err != nil {
+			outBuffer.WriteString(fmt.Sprintf("[%d] %s=%v\n", req.RequestID, response.TCPErrorField, err))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	conn, err := c.dial(ctx, "tcp", u.Host)
+	if err != nil {
+		result := tcpResultForError(err)
+		if result == response.TCPResultReset {
+			// A reset while connecting means nothing was listening.
+			result = response.TCPResultRefused
+		}
+		writeResult(result, 0, err)
+		return outBuffer.String(), nil
+	}
+	defer func() { _ = conn.Close() }()
+	writeIPFamily(req.RequestID, conn.RemoteAddr(), &outBuffer)
+	// Every request is sent over a connection of its own.
+	writeConnectionReused(req.RequestID, false, &outBuffer)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := fmt.Fprintf(conn, "GET %s HTTP/1.0\r\nHost: %s\r\n%s\r\n", path, host, reqBuffer.String()); err != nil {
+		writeResult(tcpResultForError(err), 0, err)
+		return outBuffer.String(), nil
+	}
+
+	// Read the whole response before parsing it, so that the number of bytes echoed is known even if the
+	// connection is terminated part way through.
+	var data []byte
+	if c.streamMessages > 0 {
+		data, err = readEvents(req.RequestID, conn, &outBuffer)
+	} else {
+		data, err = ioutil.ReadAll(conn)
+	}
+	if err != nil {
+		writeResult(tcpResultForError(err), len(data), err)
+		return outBuffer.String(), nil
+	}
+	if len(data) == 0 {
+		writeResult(response.TCPResultClosed, 0, nil)
+		return outBuffer.String(), nil
+	}
+
+	httpResp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), nil)
+	if err != nil {
+		writeResult(response.TCPResultClosed, len(data), err)
+		return outBuffer.String(), nil
+	}
+	body, err := ioutil.ReadAll(httpResp.Body)
+	_ = httpResp.Body.Close()
+	if err != nil {
+		writeResult(response.TCPResultClosed, len(data), err)
+		return outBuffer.String(), nil
+	}
+
+	writeResult(response.TCPResultOK, len(data), nil)
+	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%d\n", req.RequestID, response.StatusCodeField, httpResp.StatusCode))
+	for _, line := range strings.Split(string(body), "\n") {
+		if line != "" {
+			outBuffer.WriteString(fmt.Sprintf("[%d body] %s\n", req.RequestID, strings.TrimPrefix(line, common.StreamEventPrefix)))
+		}
+	}
+	return outBuffer.String(), nil
+}
+
+// readEvents reads the whole response from conn, like ioutil.ReadAll, reporting the time since the previous
+// event (or since the request was sent) for every server-sent event as it is received.
+func readEvents(requestID int, conn net.Conn, out *bytes.Buffer) ([]byte, error) {
+	var data bytes.Buffer
+	reader := bufio.NewReader(conn)
+	start := time.Now()
+	index := 0
+	for {
+		line, err := reader.ReadString('\n')
+		data.WriteString(line)
+		if strings.HasPrefix(line, common.StreamEventPrefix) {
+			now := time.Now()
+			out.WriteString(fmt.Sprintf("[%d] %s=%d %s\n", requestID, response.StreamMessageField, index, now.Sub(start)))
+			index++
+			start = now
+		}
+		if err == io.EOF {
+			return data.Bytes(), nil
+		}
+		if err != nil {
+			return data.Bytes(), err
+		}
+	}
+}
+
+func (c *tcpProtocol) Close() error {
+	return nil
+}
+
+// tcpResultForError classifies the given connection error.
+func tcpResultForError(err error) response.TCPResult {
+	if err == nil {
+		return response.TCPResultOK
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return response.TCPResultClosed
+	}
+	if err == context.DeadlineExceeded {
+		return response.TCPResultTimeout
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return response.TCPResultTimeout
+	}
+
+	switch errno(err) {
+	case syscall.ECONNREFUSED:
+		return response.TCPResultRefused
+	case syscall.ECONNRESET, syscall.EPIPE:
+		return response.TCPResultReset
+	}
+	return response.TCPResultError
+}
+
+// errno returns the system call error wrapped by the given network error, or 0.
+func errno(err error) syscall.Errno {
+	for {
+		switch e := err.(type) {
+		case syscall.Errno:
+			return e
+		case *net.OpError:
+			err = e.Err
+		case *os.SyscallError:
+			err = e.Err
+		default:
+			return 0
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	maxDatagramSize = 65535
+)
+
+var _ protocol = &udpProtocol{}
+
+// udpProtocol sends the message of the request as a single datagram and waits for the datagram echoed
+// back by the server.
+type udpProtocol struct {
+	dialer *net.Dialer
+}
+
+func newUDPProtocol() *udpProtocol {
+	return &udpProtocol{dialer: &net.Dialer{}}
+}
+
+func (c *udpProtocol) makeRequest(ctx context.Context, req *request) (string, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", err
+	}
+
+	var outBuffer bytes.Buffer
+	outBuffer.WriteString(fmt.Sprintf("[%d] Url=%s\n", req.RequestID, req.URL))
+
+	message := req.Message
+	if message == "" {
+		message = fmt.Sprintf("request #%d", req.RequestID)
+	}
+	outBuffer.WriteString(fmt.Sprintf("[%d] Echo=%s\n", req.RequestID, message))
+
+	// Apply per-request timeout to calculate deadline for reads/writes.
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+
+	conn, err := c.dialer.DialContext(ctx, "udp", u.Host)
+	if err != nil {
+		return outBuffer.String(), err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	writeIPFamily(req.RequestID, conn.RemoteAddr(), &outBuffer)
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return outBuffer.String(), err
+	}
+
+	if _, err := conn.Write([]byte(message)); err != nil {
+		return outBuffer.String(), err
+	}
+
+	// Datagrams may be dropped silently, in which case the read times out.
+	buf := make([]byte, maxDatagramSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return outBuffer.String(), err
+	}
+
+	for _, line := range strings.Split(string(buf[:n]), "\n") {
+		if line != "" {
+			outBuffer.WriteString(fmt.Sprintf("[%d body] %s\n", req.RequestID, line))
+		}
+	}
+
+	return outBuffer.String(), nil
+}
+
+func (c *udpProtocol) Close() error {
+	return nil
+}
@@ -16,13 +16,19 @@ package forwarder
 
 import (
 	"bytes"
+	"crypto/x509"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"net/textproto"
+
+	"istio.io/istio/pkg/test/echo/common/response"
 )
 
 const (
-	hostHeader = "Host"
+	hostHeader      = "Host"
+	requestIDHeader = "X-Request-Id"
 )
 
 func writeHeaders(requestID int, header http.Header, outBuffer bytes.Buffer, addFn func(string, string)) {
@@ -38,3 +44,36 @@ func writeHeaders(requestID int, header http.Header, outBuffer bytes.Buffer, add
 		}
 	}
 }
+
+// writeIPFamily reports the address family used to reach the given peer address. Nothing is reported for
+// peers that aren't reached over IP, e.g. over a unix domain socket.
+func writeIPFamily(requestID int, addr net.Addr, outBuffer *bytes.Buffer) {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	default:
+		return
+	}
+	family := response.IPv6
+	if ip.To4() != nil {
+		family = response.IPv4
+	}
+	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", requestID, response.IPFamilyField, family))
+}
+
+// writeConnectionReused reports whether the request was sent over a connection used by an earlier request.
+func writeConnectionReused(requestID int, reused bool, outBuffer *bytes.Buffer) {
+	outBuffer.WriteString(fmt.Sprintf("[%d] %s=%t\n", requestID, response.ConnectionReusedField, reused))
+}
+
+// writeServerCertificates reports the certificate chain presented by the server, leaf first, in the same
+// format the echo server reports the certificates of its clients.
+func writeServerCertificates(requestID int, certs []*x509.Certificate, outBuffer *bytes.Buffer) {
+	for _, c := range certs {
+		outBuffer.WriteString(fmt.Sprintf("[%d] %s=%s\n", requestID, response.ServerCertificateField,
+			base64.StdEncoding.EncodeToString(c.Raw)))
+	}
+}
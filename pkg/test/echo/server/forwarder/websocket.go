@@ -42,8 +42,12 @@ func (c *websocketProtocol) makeRequest(ctx context.Context, req *request) (stri
 	// Set the special header to trigger the upgrade to WebSocket.
 	common.SetWebSocketHeader(wsReq)
 
+	// Each line of the message is sent as a separate frame over the same connection.
+	frames := strings.Split(req.Message, "\n")
 	if req.Message != "" {
-		outBuffer.WriteString(fmt.Sprintf("[%d] Echo=%s\n", req.RequestID, req.Message))
+		for _, frame := range frames {
+			outBuffer.WriteString(fmt.Sprintf("[%d] Echo=%s\n", req.RequestID, frame))
+		}
 	}
 
 	conn, _, err := c.dialer.Dial(req.URL, wsReq)
@@ -54,6 +58,7 @@ func (c *websocketProtocol) makeRequest(ctx context.Context, req *request) (stri
 	defer func() {
 		_ = conn.Close()
 	}()
+	writeIPFamily(req.RequestID, conn.RemoteAddr(), &outBuffer)
 
 	// Apply per-request timeout to calculate deadline for reads/writes.
 	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
@@ -68,22 +73,26 @@ func (c *websocketProtocol) makeRequest(ctx context.Context, req *request) (stri
 		return outBuffer.String(), err
 	}
 
-	err = conn.WriteMessage(websocket.TextMessage, []byte(req.Message))
-	if err != nil {
-		return outBuffer.String(), err
-	}
+	for _, frame := range frames {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+			return outBuffer.String(), err
+		}
 
-	_, resp, err := conn.ReadMessage()
-	if err != nil {
-		return outBuffer.String(), err
-	}
+		_, resp, err := conn.ReadMessage()
+		if err != nil {
+			return outBuffer.String(), err
+		}
 
-	for _, line := range strings.Split(string(resp), "\n") {
-		if line != "" {
-			outBuffer.WriteString(fmt.Sprintf("[%d body] %s\n", req.RequestID, line))
+		for _, line := range strings.Split(string(resp), "\n") {
+			if line != "" {
+				outBuffer.WriteString(fmt.Sprintf("[%d body] %s\n", req.RequestID, line))
+			}
 		}
 	}
 
+	// Close the connection gracefully, so the server doesn't wait for more frames.
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
 	return outBuffer.String(), nil
 }
 
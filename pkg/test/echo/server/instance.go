@@ -19,6 +19,7 @@ import (
 	"io"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 
@@ -35,8 +36,12 @@ type Config struct {
 	TLSCert   string
 	TLSKey    string
 	Version   string
+	Cluster   string
+	Locality  string
 	UDSServer string
 	Dialer    common.Dialer
+	// TLSPorts are the ports that serve TLS with TLSCert and TLSKey.
+	TLSPorts []int
 }
 
 var _ io.Closer = &Instance{}
@@ -47,15 +52,27 @@ type Instance struct {
 
 	endpoints []endpoint.Instance
 	ready     uint32
+	drainer   *endpoint.Drainer
+	recorder  *endpoint.Recorder
+	drainOnce sync.Once
+	done      chan struct{}
 }
 
 // New creates a new server instance.
 func New(config Config) *Instance {
 	config.Dialer = config.Dialer.FillInDefaults()
 
-	return &Instance{
-		Config: config,
+	s := &Instance{
+		Config:   config,
+		done:     make(chan struct{}),
+		recorder: endpoint.NewRecorder(endpoint.DefaultRecorderCapacity),
 	}
+	s.drainer = &endpoint.Drainer{
+		OnDrain: func(timeout time.Duration) {
+			_ = s.Drain(timeout)
+		},
+	}
+	return s
 }
 
 // Start the server.
@@ -100,20 +117,54 @@ func (s *Instance) Close() (err error) {
 	return
 }
 
+// Drain shuts the server down gracefully: readiness checks and new HTTP requests fail, and the server waits
+// up to the given timeout for the requests in flight to complete before closing the endpoints. Done is
+// closed once the server is closed. Subsequent calls have no effect.
+func (s *Instance) Drain(timeout time.Duration) (err error) {
+	s.drainOnce.Do(func() {
+		log.Infof("Draining echo server, %d requests in flight", s.drainer.InFlight())
+		if !s.drainer.Drain(timeout) {
+			log.Warnf("Drain timed out after %v with %d requests in flight", timeout, s.drainer.InFlight())
+		}
+		err = s.Close()
+		close(s.done)
+	})
+	return
+}
+
+// Done returns a channel that is closed once the server has been drained.
+func (s *Instance) Done() <-chan struct{} {
+	return s.done
+}
+
 func (s *Instance) newEndpoint(port *model.Port, udsServer string) (endpoint.Instance, error) {
 	return endpoint.New(endpoint.Config{
 		Port:          port,
 		UDSServer:     udsServer,
 		IsServerReady: s.isReady,
 		Version:       s.Version,
+		Cluster:       s.Cluster,
+		Locality:      s.Locality,
 		TLSCert:       s.TLSCert,
 		TLSKey:        s.TLSKey,
+		TLS:           port != nil && s.isTLSPort(port.Port),
 		Dialer:        s.Dialer,
+		Drainer:       s.drainer,
+		Recorder:      s.recorder,
 	})
 }
 
+func (s *Instance) isTLSPort(port int) bool {
+	for _, p := range s.TLSPorts {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Instance) isReady() bool {
-	return atomic.LoadUint32(&s.ready) == 1
+	return atomic.LoadUint32(&s.ready) == 1 && !s.drainer.IsDraining()
 }
 
 func (s *Instance) waitUntilReady() error {
@@ -149,6 +200,10 @@ func (s *Instance) validate() error {
 		case protocol.HTTPS:
 		case protocol.HTTP2:
 		case protocol.GRPC:
+		case protocol.UDP:
+		case protocol.MySQL:
+		case protocol.Thrift:
+		case protocol.Dubbo:
 		default:
 			return fmt.Errorf("protocol %v not currently supported", port.Protocol)
 		}
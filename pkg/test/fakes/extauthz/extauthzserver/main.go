@@ -0,0 +1,80 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/pkg/test/fakes/extauthz"
+	"istio.io/pkg/log"
+)
+
+var (
+	grpcPort   int
+	httpPort   int
+	adminPort  int
+	logOptions *log.Options
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:          "extauthzserver",
+		Short:        "Fake Envoy external authorization server.",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runServer()
+		},
+	}
+
+	rootCmd.SetArgs(os.Args[1:])
+	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	logOptions = log.DefaultOptions()
+	logOptions.AttachCobraFlags(rootCmd)
+
+	rootCmd.PersistentFlags().IntVar(&grpcPort, "grpcPort", extauthz.DefaultGRPCPort, "gRPC ext_authz port")
+	rootCmd.PersistentFlags().IntVar(&httpPort, "httpPort", extauthz.DefaultHTTPPort, "HTTP ext_authz port")
+	rootCmd.PersistentFlags().IntVar(&adminPort, "adminPort", extauthz.DefaultAdminPort, "Admin API port")
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Printf("Error during execution: %v", err)
+		os.Exit(-1)
+	}
+}
+
+func runServer() {
+	if err := log.Configure(logOptions); err != nil {
+		os.Exit(-1)
+	}
+
+	s := extauthz.NewServer(grpcPort, httpPort, adminPort)
+	if err := s.Start(); err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	defer func() { _ = s.Close() }()
+
+	// Wait for the process to be shutdown.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	<-sigs
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz
+
+import (
+	"context"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	authv2 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v2"
+	envoytype "github.com/envoyproxy/go-control-plane/envoy/type"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/status"
+)
+
+var _ authv2.AuthorizationServer = &grpcHandler{}
+
+type grpcHandler struct {
+	server *Server
+}
+
+// Check implements authv2.AuthorizationServer.
+func (h *grpcHandler) Check(_ context.Context, checkReq *authv2.CheckRequest) (*authv2.CheckResponse, error) {
+	attrs := checkReq.GetAttributes()
+	httpReq := attrs.GetRequest().GetHttp()
+
+	req := &Request{
+		Protocol:             ProtocolGRPC,
+		Method:               httpReq.GetMethod(),
+		Host:                 httpReq.GetHost(),
+		Path:                 httpReq.GetPath(),
+		Headers:              make(map[string]string),
+		SourcePrincipal:      attrs.GetSource().GetPrincipal(),
+		DestinationPrincipal: attrs.GetDestination().GetPrincipal(),
+		ContextExtensions:    attrs.GetContextExtensions(),
+	}
+	for name, value := range httpReq.GetHeaders() {
+		req.Headers[name] = value
+	}
+
+	decision := h.server.decide(req)
+	headers := make([]*core.HeaderValueOption, 0, len(decision.Headers))
+	for name, value := range decision.Headers {
+		headers = append(headers, &core.HeaderValueOption{
+			Header: &core.HeaderValue{
+				Key:   name,
+				Value: value,
+			},
+		})
+	}
+
+	if decision.Allow {
+		return &authv2.CheckResponse{
+			Status: &status.Status{Code: int32(code.Code_OK)},
+			HttpResponse: &authv2.CheckResponse_OkResponse{
+				OkResponse: &authv2.OkHttpResponse{
+					Headers: headers,
+				},
+			},
+		}, nil
+	}
+	return &authv2.CheckResponse{
+		Status: &status.Status{Code: int32(code.Code_PERMISSION_DENIED)},
+		HttpResponse: &authv2.CheckResponse_DeniedResponse{
+			DeniedResponse: &authv2.DeniedHttpResponse{
+				Status:  &envoytype.HttpStatus{Code: envoytype.StatusCode_Forbidden},
+				Headers: headers,
+				Body:    "denied by ext_authz",
+			},
+		},
+	}, nil
+}
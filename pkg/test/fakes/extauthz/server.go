@@ -0,0 +1,296 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extauthz implements a fake Envoy external authorization server, supporting both the gRPC and the
+// HTTP ext_authz APIs. Its decisions are driven by rules that can be changed at runtime through an admin API,
+// and it captures the check requests it receives so tests can assert what the proxy forwarded.
+package extauthz
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	authv2 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v2"
+	"google.golang.org/grpc"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// DefaultGRPCPort for the gRPC ext_authz API.
+	DefaultGRPCPort = 9000
+	// DefaultHTTPPort for the HTTP ext_authz API.
+	DefaultHTTPPort = 8000
+	// DefaultAdminPort for the admin API.
+	DefaultAdminPort = 8080
+
+	// RulesPath is the admin path for reading and replacing the decision rules.
+	RulesPath = "/admin/rules"
+	// RequestsPath is the admin path for reading (GET) and clearing (DELETE) the captured requests.
+	RequestsPath = "/admin/requests"
+
+	// ProtocolGRPC indicates a request received on the gRPC ext_authz API.
+	ProtocolGRPC = "grpc"
+	// ProtocolHTTP indicates a request received on the HTTP ext_authz API.
+	ProtocolHTTP = "http"
+
+	// maxCapturedRequests bounds the memory used for capturing requests.
+	maxCapturedRequests = 1000
+)
+
+var scope = log.RegisterScope("fakes", "Scope for all fakes", 0)
+
+// Rule for deciding on a check request. All of the non-empty match fields must match for the rule to apply.
+type Rule struct {
+	// Header name to match. Matched case-insensitively.
+	Header string `json:"header,omitempty"`
+	// Value the header must have. If empty, only the presence of the header is required.
+	Value string `json:"value,omitempty"`
+	// PathPrefix the request path must start with.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// Allow the request if the rule applies, otherwise deny it.
+	Allow bool `json:"allow"`
+	// Headers added to the upstream request if allowed, or to the downstream response if denied.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Rules of the server, evaluated in order. The first rule that applies decides on the request.
+type Rules struct {
+	Rules []Rule `json:"rules,omitempty"`
+	// DefaultAllow decides on requests to which no rule applies.
+	DefaultAllow bool `json:"defaultAllow"`
+}
+
+// Request is a check request captured by the server.
+type Request struct {
+	// Protocol of the ext_authz API, either ProtocolGRPC or ProtocolHTTP.
+	Protocol string `json:"protocol"`
+	Method   string `json:"method,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Path     string `json:"path,omitempty"`
+	// Headers of the original request, as forwarded by the proxy. Header names are lower case.
+	Headers map[string]string `json:"headers,omitempty"`
+	// SourcePrincipal of the downstream peer. Only available on the gRPC API.
+	SourcePrincipal string `json:"sourcePrincipal,omitempty"`
+	// DestinationPrincipal of the workload being called. Only available on the gRPC API.
+	DestinationPrincipal string `json:"destinationPrincipal,omitempty"`
+	// ContextExtensions configured on the proxy. Only available on the gRPC API.
+	ContextExtensions map[string]string `json:"contextExtensions,omitempty"`
+	// Allowed is the decision of the server.
+	Allowed bool `json:"allowed"`
+}
+
+// Server is a fake ext_authz server.
+type Server struct {
+	grpcPort  int
+	httpPort  int
+	adminPort int
+
+	mutex    sync.Mutex
+	rules    Rules
+	requests []Request
+
+	grpcServer  *grpc.Server
+	httpServer  *http.Server
+	adminServer *http.Server
+}
+
+// NewServer returns a new Server listening on the given ports. The server initially allows all requests.
+func NewServer(grpcPort, httpPort, adminPort int) *Server {
+	return &Server{
+		grpcPort:  grpcPort,
+		httpPort:  httpPort,
+		adminPort: adminPort,
+		rules: Rules{
+			DefaultAllow: true,
+		},
+	}
+}
+
+// Start serving all of the APIs.
+func (s *Server) Start() (err error) {
+	listeners := make([]net.Listener, 0, 3)
+	defer func() {
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+		}
+	}()
+	for _, port := range []*int{&s.grpcPort, &s.httpPort, &s.adminPort} {
+		var l net.Listener
+		if l, err = net.Listen("tcp", fmt.Sprintf(":%d", *port)); err != nil {
+			return err
+		}
+		*port = l.Addr().(*net.TCPAddr).Port
+		listeners = append(listeners, l)
+	}
+
+	s.grpcServer = grpc.NewServer()
+	authv2.RegisterAuthorizationServer(s.grpcServer, &grpcHandler{server: s})
+
+	s.httpServer = &http.Server{Handler: http.HandlerFunc(s.handleHTTPCheck)}
+
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc(RulesPath, s.handleRules)
+	adminMux.HandleFunc(RequestsPath, s.handleRequests)
+	s.adminServer = &http.Server{Handler: adminMux}
+
+	go func() {
+		scope.Infof("Serving gRPC ext_authz on port %d", s.grpcPort)
+		_ = s.grpcServer.Serve(listeners[0])
+	}()
+	go func() {
+		scope.Infof("Serving HTTP ext_authz on port %d", s.httpPort)
+		_ = s.httpServer.Serve(listeners[1])
+	}()
+	go func() {
+		scope.Infof("Serving ext_authz admin API on port %d", s.adminPort)
+		_ = s.adminServer.Serve(listeners[2])
+	}()
+	return nil
+}
+
+// GRPCPort returns the port of the gRPC ext_authz API.
+func (s *Server) GRPCPort() int {
+	return s.grpcPort
+}
+
+// HTTPPort returns the port of the HTTP ext_authz API.
+func (s *Server) HTTPPort() int {
+	return s.httpPort
+}
+
+// AdminPort returns the port of the admin API.
+func (s *Server) AdminPort() int {
+	return s.adminPort
+}
+
+// Close the server.
+func (s *Server) Close() error {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	if s.httpServer != nil {
+		_ = s.httpServer.Close()
+	}
+	if s.adminServer != nil {
+		_ = s.adminServer.Close()
+	}
+	return nil
+}
+
+// decide on the given request according to the current rules, and capture it.
+func (s *Server) decide(req *Request) Rule {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	decision := Rule{Allow: s.rules.DefaultAllow}
+	for _, rule := range s.rules.Rules {
+		if rule.matches(req) {
+			decision = rule
+			break
+		}
+	}
+
+	req.Allowed = decision.Allow
+	if len(s.requests) >= maxCapturedRequests {
+		s.requests = s.requests[1:]
+	}
+	s.requests = append(s.requests, *req)
+	scope.Infof("ext_authz %s check %s %s%s: allowed=%v", req.Protocol, req.Method, req.Host, req.Path, req.Allowed)
+	return decision
+}
+
+func (r Rule) matches(req *Request) bool {
+	if r.Header != "" {
+		value, ok := req.Headers[strings.ToLower(r.Header)]
+		if !ok || (r.Value != "" && value != r.Value) {
+			return false
+		}
+	}
+	if r.PathPrefix != "" && !strings.HasPrefix(req.Path, r.PathPrefix) {
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleHTTPCheck(w http.ResponseWriter, r *http.Request) {
+	req := &Request{
+		Protocol: ProtocolHTTP,
+		Method:   r.Method,
+		Host:     r.Host,
+		Path:     r.URL.RequestURI(),
+		Headers:  make(map[string]string),
+	}
+	for name, values := range r.Header {
+		req.Headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	decision := s.decide(req)
+	for name, value := range decision.Headers {
+		w.Header().Set(name, value)
+	}
+	if decision.Allow {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte("denied by ext_authz"))
+}
+
+func (s *Server) handleRules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mutex.Lock()
+		rules := s.rules
+		s.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rules)
+	case http.MethodPut, http.MethodPost:
+		rules := Rules{}
+		if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mutex.Lock()
+		s.rules = rules
+		s.mutex.Unlock()
+		scope.Infof("ext_authz rules set: %+v", rules)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mutex.Lock()
+		requests := append([]Request{}, s.requests...)
+		s.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(requests)
+	case http.MethodDelete:
+		s.mutex.Lock()
+		s.requests = nil
+		s.mutex.Unlock()
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
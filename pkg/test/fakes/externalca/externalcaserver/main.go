@@ -0,0 +1,120 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/pkg/test/fakes/externalca"
+	"istio.io/pkg/log"
+)
+
+var (
+	grpcPort    int
+	adminPort   int
+	signingCert string
+	signingKey  string
+	certChain   string
+	hosts       []string
+	logOptions  *log.Options
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:          "externalcaserver",
+		Short:        "Fake external CA implementing the Istio CA API.",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runServer()
+		},
+	}
+
+	rootCmd.SetArgs(os.Args[1:])
+	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	logOptions = log.DefaultOptions()
+	logOptions.AttachCobraFlags(rootCmd)
+
+	rootCmd.PersistentFlags().IntVar(&grpcPort, "grpcPort", externalca.DefaultGRPCPort, "Istio CA API port")
+	rootCmd.PersistentFlags().IntVar(&adminPort, "adminPort", externalca.DefaultAdminPort, "Admin API port")
+	rootCmd.PersistentFlags().StringVar(&signingCert, "signingCert", "",
+		"PEM file of the signing CA certificate. A self-signed CA is generated if not set")
+	rootCmd.PersistentFlags().StringVar(&signingKey, "signingKey", "", "PEM file of the signing CA key")
+	rootCmd.PersistentFlags().StringVar(&certChain, "certChain", "",
+		"PEM file of the chain from the signing certificate's issuer up to the root")
+	rootCmd.PersistentFlags().StringSliceVar(&hosts, "hosts", nil,
+		"Hosts the Istio CA API is served on. The API is served over TLS if set")
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Printf("Error during execution: %v", err)
+		os.Exit(-1)
+	}
+}
+
+func runServer() {
+	if err := log.Configure(logOptions); err != nil {
+		os.Exit(-1)
+	}
+
+	cfg := externalca.Config{
+		GRPCPort:  grpcPort,
+		AdminPort: adminPort,
+		Hosts:     hosts,
+	}
+	var err error
+	if cfg.SigningCert, err = readOptionalFile(signingCert); err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	if cfg.SigningKey, err = readOptionalFile(signingKey); err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	if cfg.CertChain, err = readOptionalFile(certChain); err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+
+	s, err := externalca.NewServer(cfg)
+	if err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	if err := s.Start(); err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	defer func() { _ = s.Close() }()
+
+	// Wait for the process to be shutdown.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	<-sigs
+}
+
+func readOptionalFile(file string) ([]byte, error) {
+	if file == "" {
+		return nil, nil
+	}
+	return ioutil.ReadFile(file)
+}
@@ -0,0 +1,338 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package externalca implements a fake external certificate authority that signs workload CSRs over the Istio CA
+// gRPC API (istio.v1.auth.IstioCertificateService). Latency and failures can be injected on the signing path at
+// runtime through an admin API.
+package externalca
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/security/pkg/pki/util"
+	pb "istio.io/istio/security/proto"
+	"istio.io/pkg/log"
+)
+
+const (
+	// DefaultGRPCPort for the Istio CA gRPC API.
+	DefaultGRPCPort = 8060
+	// DefaultAdminPort for the admin API.
+	DefaultAdminPort = 8080
+
+	// FaultPath is the admin path for setting (PUT) or clearing (DELETE) the injected fault.
+	FaultPath = "/admin/fault"
+	// StatsPath is the admin path for reading the signing statistics.
+	StatsPath = "/admin/stats"
+	// RootCertPath is the admin path for reading the PEM encoded root certificate of the CA.
+	RootCertPath = "/admin/root"
+
+	// DefaultCertTTL of the signed certificates, if the request doesn't specify a validity duration.
+	DefaultCertTTL = 24 * time.Hour
+
+	selfSignedCATTL = 24 * 365 * time.Hour
+)
+
+var scope = log.RegisterScope("fakes", "Scope for all fakes", 0)
+
+// Fault injected on the signing path.
+type Fault struct {
+	// Delay before responding.
+	Delay time.Duration `json:"delay,omitempty"`
+	// Code is the gRPC status code returned instead of a certificate, if non-zero.
+	Code codes.Code `json:"code,omitempty"`
+}
+
+// Stats about the signing requests served.
+type Stats struct {
+	// Requests is the number of CreateCertificate calls received since the server started.
+	Requests int `json:"requests"`
+	// Failures is the number of CreateCertificate calls that didn't return a certificate, including injected faults.
+	Failures int `json:"failures"`
+	// LastRequest is the time of the most recent CreateCertificate call.
+	LastRequest time.Time `json:"lastRequest,omitempty"`
+	// LastSubjectIDs are the SAN identities of the most recently signed certificate.
+	LastSubjectIDs []string `json:"lastSubjectIDs,omitempty"`
+}
+
+// Config for a Server.
+type Config struct {
+	GRPCPort  int
+	AdminPort int
+
+	// SigningCert and SigningKey are the PEM encoded CA certificate and key used for signing. If not set, a
+	// self-signed CA is generated.
+	SigningCert []byte
+	SigningKey  []byte
+	// CertChain is the PEM encoded chain from the signing certificate's issuer up to and including the root. Empty
+	// if the signing certificate is the root.
+	CertChain []byte
+
+	// Hosts the gRPC API is served on, e.g. the DNS names of its Kubernetes service. If set, the API is served
+	// over TLS with a certificate issued by the CA. Otherwise it is served in plaintext.
+	Hosts []string
+}
+
+// Server is a fake external CA.
+type Server struct {
+	cfg Config
+
+	signingCert *x509.Certificate
+	signingKey  crypto.PrivateKey
+	// chainPEM is appended to each signed certificate; it starts with the signing certificate and ends with the root.
+	chainPEM []string
+
+	mutex sync.Mutex
+	fault Fault
+	stats Stats
+
+	grpcServer  *grpc.Server
+	adminServer *http.Server
+}
+
+// NewServer returns a new Server with the given configuration.
+func NewServer(cfg Config) (*Server, error) {
+	s := &Server{cfg: cfg}
+
+	signingCert, signingKey := cfg.SigningCert, cfg.SigningKey
+	if len(signingCert) == 0 {
+		var err error
+		if signingCert, signingKey, err = util.GenCertKeyFromOptions(util.CertOptions{
+			Org:          "Istio Test External CA",
+			TTL:          selfSignedCATTL,
+			NotBefore:    time.Now().Add(-time.Hour),
+			RSAKeySize:   2048,
+			IsCA:         true,
+			IsSelfSigned: true,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if s.signingCert, err = util.ParsePemEncodedCertificate(signingCert); err != nil {
+		return nil, fmt.Errorf("invalid signing certificate: %v", err)
+	}
+	if s.signingKey, err = util.ParsePemEncodedKey(signingKey); err != nil {
+		return nil, fmt.Errorf("invalid signing key: %v", err)
+	}
+	s.chainPEM = append([]string{string(signingCert)}, splitPEM(cfg.CertChain)...)
+	return s, nil
+}
+
+// Start serving the gRPC and admin APIs.
+func (s *Server) Start() (err error) {
+	listeners := make([]net.Listener, 0, 2)
+	defer func() {
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+		}
+	}()
+	for _, port := range []*int{&s.cfg.GRPCPort, &s.cfg.AdminPort} {
+		var l net.Listener
+		if l, err = net.Listen("tcp", fmt.Sprintf(":%d", *port)); err != nil {
+			return err
+		}
+		*port = l.Addr().(*net.TCPAddr).Port
+		listeners = append(listeners, l)
+	}
+
+	opts := make([]grpc.ServerOption, 0)
+	if len(s.cfg.Hosts) > 0 {
+		var serving tls.Certificate
+		if serving, err = s.servingCert(); err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewServerTLSFromCert(&serving)))
+	}
+	s.grpcServer = grpc.NewServer(opts...)
+	pb.RegisterIstioCertificateServiceServer(s.grpcServer, s)
+
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc(FaultPath, s.handleFault)
+	adminMux.HandleFunc(StatsPath, s.handleStats)
+	adminMux.HandleFunc(RootCertPath, s.handleRootCert)
+	s.adminServer = &http.Server{Handler: adminMux}
+
+	go func() {
+		scope.Infof("Serving Istio CA API on port %d", s.cfg.GRPCPort)
+		_ = s.grpcServer.Serve(listeners[0])
+	}()
+	go func() {
+		scope.Infof("Serving external CA admin API on port %d", s.cfg.AdminPort)
+		_ = s.adminServer.Serve(listeners[1])
+	}()
+	return nil
+}
+
+// GRPCPort returns the port of the Istio CA API.
+func (s *Server) GRPCPort() int {
+	return s.cfg.GRPCPort
+}
+
+// AdminPort returns the port of the admin API.
+func (s *Server) AdminPort() int {
+	return s.cfg.AdminPort
+}
+
+// RootCertPEM returns the PEM encoded root certificate of the CA.
+func (s *Server) RootCertPEM() []byte {
+	return []byte(s.chainPEM[len(s.chainPEM)-1])
+}
+
+// Close the server.
+func (s *Server) Close() error {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	if s.adminServer != nil {
+		_ = s.adminServer.Close()
+	}
+	return nil
+}
+
+// CreateCertificate implements the Istio CA API. The CSR is signed without authenticating the caller, using the
+// identities requested in its SAN extension.
+func (s *Server) CreateCertificate(_ context.Context, request *pb.IstioCertificateRequest) (
+	*pb.IstioCertificateResponse, error) {
+	s.mutex.Lock()
+	s.stats.Requests++
+	s.stats.LastRequest = time.Now()
+	fault := s.fault
+	s.mutex.Unlock()
+
+	resp, ids, err := s.sign(request, fault)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err != nil {
+		s.stats.Failures++
+		scope.Infof("CreateCertificate failed: %v", err)
+		return nil, err
+	}
+	s.stats.LastSubjectIDs = ids
+	return resp, nil
+}
+
+func (s *Server) sign(request *pb.IstioCertificateRequest, fault Fault) (*pb.IstioCertificateResponse, []string, error) {
+	if fault.Delay > 0 {
+		time.Sleep(fault.Delay)
+	}
+	if fault.Code != codes.OK {
+		return nil, nil, status.Errorf(fault.Code, "injected fault")
+	}
+
+	csr, err := util.ParsePemEncodedCSR([]byte(request.Csr))
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid CSR: %v", err)
+	}
+	ids, err := util.ExtractIDs(csr.Extensions)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid CSR SAN: %v", err)
+	}
+	ttl := DefaultCertTTL
+	if request.ValidityDuration > 0 {
+		ttl = time.Duration(request.ValidityDuration) * time.Second
+	}
+
+	der, err := util.GenCertFromCSR(csr, s.signingCert, csr.PublicKey, s.signingKey, ids, ttl, false)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "signing failed: %v", err)
+	}
+	leaf := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	return &pb.IstioCertificateResponse{
+		CertChain: append([]string{leaf}, s.chainPEM...),
+	}, ids, nil
+}
+
+// servingCert issues a certificate for the configured hosts, for serving the gRPC API over TLS.
+func (s *Server) servingCert() (tls.Certificate, error) {
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:       strings.Join(s.cfg.Hosts, ","),
+		Org:        "Istio Test External CA",
+		TTL:        selfSignedCATTL,
+		NotBefore:  time.Now().Add(-time.Hour),
+		SignerCert: s.signingCert,
+		SignerPriv: s.signingKey,
+		RSAKeySize: 2048,
+		IsServer:   true,
+	})
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(append(certPEM, []byte(strings.Join(s.chainPEM, ""))...), keyPEM)
+}
+
+func (s *Server) handleFault(w http.ResponseWriter, r *http.Request) {
+	fault := Fault{}
+	switch r.Method {
+	case http.MethodPut, http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.mutex.Lock()
+	s.fault = fault
+	s.mutex.Unlock()
+	scope.Infof("external CA fault set: %+v", fault)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, _ *http.Request) {
+	s.mutex.Lock()
+	stats := s.stats
+	s.mutex.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
+}
+
+func (s *Server) handleRootCert(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	_, _ = w.Write(s.RootCertPEM())
+}
+
+// splitPEM splits the given PEM encoded certificates into one string per certificate.
+func splitPEM(data []byte) []string {
+	out := make([]string, 0)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return out
+		}
+		out = append(out, string(pem.EncodeToMemory(block)))
+	}
+}
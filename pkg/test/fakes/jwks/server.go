@@ -0,0 +1,254 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwks implements a fake JWKS server, whose keys and failure modes can be changed at runtime
+// through an admin API.
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// DefaultHTTPPort for serving JWKS over plaintext.
+	DefaultHTTPPort = 8000
+	// DefaultHTTPSPort for serving JWKS over TLS.
+	DefaultHTTPSPort = 8443
+
+	// JWKSPath is the path the key set is served on.
+	JWKSPath = "/jwks"
+	// KeysPath is the admin path for replacing the served key set.
+	KeysPath = "/admin/keys"
+	// FaultPath is the admin path for setting or clearing the injected fault.
+	FaultPath = "/admin/fault"
+	// StatsPath is the admin path for reading the request statistics.
+	StatsPath = "/admin/stats"
+
+	emptyJWKS = `{"keys":[]}`
+)
+
+var scope = log.RegisterScope("fakes", "Scope for all fakes", 0)
+
+// Fault injected into the responses of the JWKS endpoint.
+type Fault struct {
+	// Delay before responding.
+	Delay time.Duration `json:"delay,omitempty"`
+	// StatusCode returned instead of the key set, if non-zero.
+	StatusCode int `json:"statusCode,omitempty"`
+}
+
+// Stats about the JWKS requests served.
+type Stats struct {
+	// Requests is the number of requests received on the JWKS endpoint since the server started.
+	Requests int `json:"requests"`
+	// LastRequest is the time of the most recent JWKS request.
+	LastRequest time.Time `json:"lastRequest,omitempty"`
+}
+
+// Server is a fake JWKS server.
+type Server struct {
+	httpPort  int
+	httpsPort int
+
+	mutex sync.Mutex
+	jwks  string
+	fault Fault
+	stats Stats
+
+	httpServer  *http.Server
+	httpsServer *http.Server
+}
+
+// NewServer returns a new Server listening on the given ports. The server initially serves an empty key set.
+func NewServer(httpPort, httpsPort int) *Server {
+	return &Server{
+		httpPort:  httpPort,
+		httpsPort: httpsPort,
+		jwks:      emptyJWKS,
+	}
+}
+
+// Start serving on both ports. The HTTPS port uses a self-signed certificate.
+func (s *Server) Start() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(JWKSPath, s.handleJWKS)
+	mux.HandleFunc(KeysPath, s.handleKeys)
+	mux.HandleFunc(FaultPath, s.handleFault)
+	mux.HandleFunc(StatsPath, s.handleStats)
+
+	cert, err := selfSignedCert()
+	if err != nil {
+		return err
+	}
+
+	httpListener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.httpPort))
+	if err != nil {
+		return err
+	}
+	httpsListener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.httpsPort))
+	if err != nil {
+		_ = httpListener.Close()
+		return err
+	}
+	s.httpPort = httpListener.Addr().(*net.TCPAddr).Port
+	s.httpsPort = httpsListener.Addr().(*net.TCPAddr).Port
+
+	s.httpServer = &http.Server{Handler: mux}
+	s.httpsServer = &http.Server{
+		Handler:   mux,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+
+	go func() {
+		scope.Infof("Serving JWKS over HTTP on port %d", s.httpPort)
+		_ = s.httpServer.Serve(httpListener)
+	}()
+	go func() {
+		scope.Infof("Serving JWKS over HTTPS on port %d", s.httpsPort)
+		_ = s.httpsServer.ServeTLS(httpsListener, "", "")
+	}()
+	return nil
+}
+
+// HTTPPort returns the plaintext port of the server.
+func (s *Server) HTTPPort() int {
+	return s.httpPort
+}
+
+// HTTPSPort returns the TLS port of the server.
+func (s *Server) HTTPSPort() int {
+	return s.httpsPort
+}
+
+// Close the server.
+func (s *Server) Close() error {
+	if s.httpServer != nil {
+		_ = s.httpServer.Close()
+	}
+	if s.httpsServer != nil {
+		_ = s.httpsServer.Close()
+	}
+	return nil
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	s.mutex.Lock()
+	s.stats.Requests++
+	s.stats.LastRequest = time.Now()
+	fault := s.fault
+	jwks := s.jwks
+	s.mutex.Unlock()
+
+	if fault.Delay > 0 {
+		time.Sleep(fault.Delay)
+	}
+	if fault.StatusCode != 0 {
+		w.WriteHeader(fault.StatusCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(jwks))
+}
+
+func (s *Server) handleKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mutex.Lock()
+		jwks := s.jwks
+		s.mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(jwks))
+	case http.MethodPut, http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !json.Valid(body) {
+			http.Error(w, "invalid JWKS", http.StatusBadRequest)
+			return
+		}
+		s.mutex.Lock()
+		s.jwks = string(body)
+		s.mutex.Unlock()
+		scope.Infof("JWKS updated: %s", string(body))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleFault(w http.ResponseWriter, r *http.Request) {
+	fault := Fault{}
+	switch r.Method {
+	case http.MethodPut, http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.mutex.Lock()
+	s.fault = fault
+	s.mutex.Unlock()
+	scope.Infof("JWKS fault set: %+v", fault)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, _ *http.Request) {
+	s.mutex.Lock()
+	stats := s.stats
+	s.mutex.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
+}
+
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"Istio Test"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * 365 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/pkg/test/fakes/oidc"
+	"istio.io/pkg/log"
+)
+
+var (
+	port       int
+	issuer     string
+	logOptions *log.Options
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:          "oidcserver",
+		Short:        "Fake OpenID Connect provider.",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runServer()
+		},
+	}
+
+	rootCmd.SetArgs(os.Args[1:])
+	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	logOptions = log.DefaultOptions()
+	logOptions.AttachCobraFlags(rootCmd)
+
+	rootCmd.PersistentFlags().IntVar(&port, "port", oidc.DefaultPort, "HTTP port")
+	rootCmd.PersistentFlags().StringVar(&issuer, "issuer", "",
+		"Issuer URL the provider is reached at. Defaults to the local address of the server")
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Printf("Error during execution: %v", err)
+		os.Exit(-1)
+	}
+}
+
+func runServer() {
+	if err := log.Configure(logOptions); err != nil {
+		os.Exit(-1)
+	}
+
+	s, err := oidc.NewServer(port, issuer)
+	if err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	if err := s.Start(); err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	defer func() { _ = s.Close() }()
+
+	// Wait for the process to be shutdown.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	<-sigs
+}
@@ -0,0 +1,241 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oidc implements a fake OpenID Connect provider, serving a discovery document, a JWKS and a token
+// endpoint that mints tokens with arbitrary claims.
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	josejwt "gopkg.in/square/go-jose.v2/jwt"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// DefaultPort the provider is served on.
+	DefaultPort = 8000
+
+	// DiscoveryPath is the path of the OpenID Connect discovery document, relative to the issuer.
+	DiscoveryPath = "/.well-known/openid-configuration"
+	// JWKSPath is the path the key set is served on.
+	JWKSPath = "/jwks"
+	// TokenPath is the path of the token endpoint.
+	TokenPath = "/token"
+
+	// Form parameters of the token endpoint.
+	SubjectParam   = "sub"
+	AudienceParam  = "aud"
+	ExpiresInParam = "expires_in"
+	ClaimsParam    = "claims"
+
+	defaultTokenTTL = time.Hour
+)
+
+var scope = log.RegisterScope("fakes", "Scope for all fakes", 0)
+
+// Discovery is the subset of the OpenID Connect discovery document served by the provider.
+type Discovery struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	TokenEndpoint                    string   `json:"token_endpoint"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// TokenResponse of the token endpoint.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Server is a fake OpenID Connect provider.
+type Server struct {
+	port   int
+	issuer string
+
+	key   *rsa.PrivateKey
+	keyID string
+
+	httpServer *http.Server
+}
+
+// NewServer returns a new Server listening on the given port. The issuer is the URL the provider is reached at
+// by the proxies, e.g. "http://oidc.ns.svc.cluster.local:8000". If empty, it defaults to the local address of
+// the server.
+func NewServer(port int, issuer string) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		port:   port,
+		issuer: strings.TrimSuffix(issuer, "/"),
+		key:    key,
+	}
+	thumbprint, err := s.publicJWK().Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	s.keyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+	return s, nil
+}
+
+// Start serving the provider.
+func (s *Server) Start() error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+	s.port = l.Addr().(*net.TCPAddr).Port
+	if s.issuer == "" {
+		s.issuer = fmt.Sprintf("http://127.0.0.1:%d", s.port)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(DiscoveryPath, s.handleDiscovery)
+	mux.HandleFunc(JWKSPath, s.handleJWKS)
+	mux.HandleFunc(TokenPath, s.handleToken)
+	s.httpServer = &http.Server{Handler: mux}
+
+	go func() {
+		scope.Infof("Serving OIDC provider for issuer %s on port %d", s.issuer, s.port)
+		_ = s.httpServer.Serve(l)
+	}()
+	return nil
+}
+
+// Port returns the port of the server.
+func (s *Server) Port() int {
+	return s.port
+}
+
+// Issuer returns the issuer of the tokens minted by the server.
+func (s *Server) Issuer() string {
+	return s.issuer
+}
+
+// Close the server.
+func (s *Server) Close() error {
+	if s.httpServer != nil {
+		return s.httpServer.Close()
+	}
+	return nil
+}
+
+func (s *Server) publicJWK() jose.JSONWebKey {
+	return jose.JSONWebKey{
+		Key:       s.key.Public(),
+		KeyID:     s.keyID,
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, Discovery{
+		Issuer:                           s.issuer,
+		JWKSURI:                          s.issuer + JWKSPath,
+		TokenEndpoint:                    s.issuer + TokenPath,
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{string(jose.RS256)},
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{s.publicJWK()}})
+}
+
+// handleToken mints a token for the requested subject, audiences and claims, without authenticating the client.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	claims := make(map[string]interface{})
+	if raw := r.PostForm.Get(ClaimsParam); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &claims); err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s: %v", ClaimsParam, err), http.StatusBadRequest)
+			return
+		}
+	}
+	ttl := defaultTokenTTL
+	if raw := r.PostForm.Get(ExpiresInParam); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s: %v", ExpiresInParam, err), http.StatusBadRequest)
+			return
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	now := time.Now()
+	claims["iss"] = s.issuer
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+	if sub := r.PostForm.Get(SubjectParam); sub != "" {
+		claims["sub"] = sub
+	}
+	if aud := r.PostForm[AudienceParam]; len(aud) == 1 {
+		claims["aud"] = aud[0]
+	} else if len(aud) > 1 {
+		claims["aud"] = aud
+	}
+
+	opts := (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", s.keyID)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: s.key}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	token, err := josejwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	scope.Infof("OIDC token minted with claims: %v", claims)
+
+	writeJSON(w, TokenResponse{
+		AccessToken: token,
+		IDToken:     token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/pkg/test/fakes/sds"
+	"istio.io/pkg/log"
+)
+
+var (
+	udsPath     string
+	adminPort   int
+	trustDomain string
+	certTTL     time.Duration
+	signingCert string
+	signingKey  string
+	logOptions  *log.Options
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:          "sdsserver",
+		Short:        "Fake external SDS server.",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runServer()
+		},
+	}
+
+	rootCmd.SetArgs(os.Args[1:])
+	rootCmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
+
+	logOptions = log.DefaultOptions()
+	logOptions.AttachCobraFlags(rootCmd)
+
+	rootCmd.PersistentFlags().StringVar(&udsPath, "udsPath", sds.DefaultUDSPath, "Unix domain socket the SDS API is served on")
+	rootCmd.PersistentFlags().IntVar(&adminPort, "adminPort", sds.DefaultAdminPort, "Admin port")
+	rootCmd.PersistentFlags().StringVar(&trustDomain, "trustDomain", sds.DefaultTrustDomain, "Trust domain of the issued identities")
+	rootCmd.PersistentFlags().DurationVar(&certTTL, "certTTL", sds.DefaultCertTTL, "Validity of the issued certificates")
+	rootCmd.PersistentFlags().StringVar(&signingCert, "signingCert", "", "PEM file of the CA certificate (generated if empty)")
+	rootCmd.PersistentFlags().StringVar(&signingKey, "signingKey", "", "PEM file of the CA key")
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Printf("Error during execution: %v", err)
+		os.Exit(-1)
+	}
+}
+
+func runServer() {
+	if err := log.Configure(logOptions); err != nil {
+		os.Exit(-1)
+	}
+
+	cfg := sds.Config{
+		UDSPath:     udsPath,
+		AdminPort:   adminPort,
+		TrustDomain: trustDomain,
+		CertTTL:     certTTL,
+	}
+	if signingCert != "" {
+		var err error
+		if cfg.SigningCert, err = ioutil.ReadFile(signingCert); err != nil {
+			log.Errora(err)
+			os.Exit(-1)
+		}
+		if cfg.SigningKey, err = ioutil.ReadFile(signingKey); err != nil {
+			log.Errora(err)
+			os.Exit(-1)
+		}
+	}
+
+	s, err := sds.NewServer(cfg)
+	if err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	if err := s.Start(); err != nil {
+		log.Errora(err)
+		os.Exit(-1)
+	}
+	defer func() { _ = s.Close() }()
+
+	// Wait for the process to be shutdown.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	<-sigs
+}
@@ -0,0 +1,434 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sds implements a fake external SDS server, standing in for a third-party identity provider (e.g.
+// SPIRE) that serves workload certificates to the sidecars over a Unix domain socket. The certificates are
+// issued by a CA of its own, for the identity in the service account token sent by each sidecar.
+package sds
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	authapi "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+	core "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	sdsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	// DefaultUDSPath is the socket the sidecars connect to, as configured by the sdsUdsPath of the mesh.
+	DefaultUDSPath = "/var/run/sds/uds_path"
+	// DefaultAdminPort for the admin API.
+	DefaultAdminPort = 8080
+	// DefaultTrustDomain of the issued identities.
+	DefaultTrustDomain = "cluster.local"
+
+	// StatsPath is the admin path for reading the request statistics.
+	StatsPath = "/admin/stats"
+	// RootCertPath is the admin path for reading the PEM encoded root certificate of the CA.
+	RootCertPath = "/admin/root"
+
+	// WorkloadCertResourceName is the SDS resource of the workload certificate and key.
+	WorkloadCertResourceName = "default"
+	// RootCertResourceName is the SDS resource of the root certificate used to validate peers.
+	RootCertResourceName = "ROOTCA"
+
+	// DefaultCertTTL of the issued certificates.
+	DefaultCertTTL = 24 * time.Hour
+
+	secretType = "type.googleapis.com/envoy.api.v2.auth.Secret"
+
+	// k8sSAJwtTokenHeaderKey and credentialTokenHeaderKey carry the service account token of the sidecar.
+	k8sSAJwtTokenHeaderKey   = "istio_sds_credentials_header-bin"
+	credentialTokenHeaderKey = "authorization"
+
+	selfSignedCATTL = 24 * 365 * time.Hour
+)
+
+var scope = log.RegisterScope("fakes", "Scope for all fakes", 0)
+
+// Stats about the SDS requests served.
+type Stats struct {
+	// Requests is the number of secrets requested since the server started.
+	Requests int `json:"requests"`
+	// Failures is the number of requests that were rejected, e.g. because of a missing token.
+	Failures int `json:"failures"`
+	// Identities are the SPIFFE identities that workload certificates were issued for.
+	Identities []string `json:"identities,omitempty"`
+}
+
+// Config for a Server.
+type Config struct {
+	// UDSPath of the socket the SDS API is served on.
+	UDSPath string
+	// AdminPort of the admin API.
+	AdminPort int
+	// TrustDomain of the issued identities.
+	TrustDomain string
+	// CertTTL of the issued workload certificates.
+	CertTTL time.Duration
+
+	// SigningCert and SigningKey are the PEM encoded self-signed CA certificate and key used for issuing
+	// workload certificates. If not set, a CA is generated. Servers running on different nodes must share
+	// the CA, for their workloads to trust each other.
+	SigningCert []byte
+	SigningKey  []byte
+}
+
+// Server is a fake external SDS server.
+type Server struct {
+	cfg Config
+
+	rootCertPEM []byte
+	rootCert    *x509.Certificate
+	rootKey     crypto.PrivateKey
+
+	mutex sync.Mutex
+	stats Stats
+
+	grpcServer  *grpc.Server
+	adminServer *http.Server
+}
+
+var _ sdsapi.SecretDiscoveryServiceServer = &Server{}
+
+// NewServer returns a new Server with the given configuration.
+func NewServer(cfg Config) (*Server, error) {
+	if cfg.UDSPath == "" {
+		cfg.UDSPath = DefaultUDSPath
+	}
+	if cfg.TrustDomain == "" {
+		cfg.TrustDomain = DefaultTrustDomain
+	}
+	if cfg.CertTTL == 0 {
+		cfg.CertTTL = DefaultCertTTL
+	}
+
+	certPEM, keyPEM := cfg.SigningCert, cfg.SigningKey
+	if len(certPEM) == 0 {
+		var err error
+		if certPEM, keyPEM, err = GenerateCA(); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	s := &Server{
+		cfg:         cfg,
+		rootCertPEM: certPEM,
+	}
+	if s.rootCert, err = util.ParsePemEncodedCertificate(certPEM); err != nil {
+		return nil, fmt.Errorf("invalid signing certificate: %v", err)
+	}
+	if s.rootKey, err = util.ParsePemEncodedKey(keyPEM); err != nil {
+		return nil, fmt.Errorf("invalid signing key: %v", err)
+	}
+	return s, nil
+}
+
+// GenerateCA returns the PEM encoded certificate and key of a new self-signed CA.
+func GenerateCA() ([]byte, []byte, error) {
+	return util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          "Istio Test External SDS",
+		TTL:          selfSignedCATTL,
+		NotBefore:    time.Now().Add(-time.Hour),
+		RSAKeySize:   2048,
+		IsCA:         true,
+		IsSelfSigned: true,
+	})
+}
+
+// Start serving the SDS and admin APIs.
+func (s *Server) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.cfg.UDSPath), 0755); err != nil {
+		return err
+	}
+	// Remove the socket left behind by a previous instance.
+	if err := os.Remove(s.cfg.UDSPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	udsListener, err := net.Listen("unix", s.cfg.UDSPath)
+	if err != nil {
+		return err
+	}
+	// The sidecars connect as a different user.
+	if err := os.Chmod(s.cfg.UDSPath, 0777); err != nil {
+		_ = udsListener.Close()
+		return err
+	}
+	adminListener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.AdminPort))
+	if err != nil {
+		_ = udsListener.Close()
+		return err
+	}
+	s.cfg.AdminPort = adminListener.Addr().(*net.TCPAddr).Port
+
+	s.grpcServer = grpc.NewServer()
+	sdsapi.RegisterSecretDiscoveryServiceServer(s.grpcServer, s)
+
+	adminMux := http.NewServeMux()
+	adminMux.HandleFunc(StatsPath, s.handleStats)
+	adminMux.HandleFunc(RootCertPath, s.handleRootCert)
+	s.adminServer = &http.Server{Handler: adminMux}
+
+	go func() {
+		scope.Infof("Serving SDS on %s", s.cfg.UDSPath)
+		_ = s.grpcServer.Serve(udsListener)
+	}()
+	go func() {
+		scope.Infof("Serving SDS admin API on port %d", s.cfg.AdminPort)
+		_ = s.adminServer.Serve(adminListener)
+	}()
+	return nil
+}
+
+// AdminPort returns the port of the admin API.
+func (s *Server) AdminPort() int {
+	return s.cfg.AdminPort
+}
+
+// RootCertPEM returns the PEM encoded root certificate of the CA.
+func (s *Server) RootCertPEM() []byte {
+	return s.rootCertPEM
+}
+
+// Close the server.
+func (s *Server) Close() error {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	if s.adminServer != nil {
+		_ = s.adminServer.Close()
+	}
+	return nil
+}
+
+// StreamSecrets implements the SDS API. Every request for a resource that wasn't served yet on the stream is
+// answered with the secret; acknowledgements are ignored, and secrets are never pushed again.
+func (s *Server) StreamSecrets(stream sdsapi.SecretDiscoveryService_StreamSecretsServer) error {
+	served := make(map[string]bool)
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, name := range req.ResourceNames {
+			if served[name] {
+				continue
+			}
+			resp, err := s.respond(stream.Context(), name)
+			if err != nil {
+				return err
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			served[name] = true
+		}
+	}
+}
+
+// FetchSecrets implements the SDS API.
+func (s *Server) FetchSecrets(ctx context.Context, req *xdsapi.DiscoveryRequest) (*xdsapi.DiscoveryResponse, error) {
+	if len(req.ResourceNames) != 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "expected a single resource, got %v", req.ResourceNames)
+	}
+	return s.respond(ctx, req.ResourceNames[0])
+}
+
+// DeltaSecrets is not supported.
+func (s *Server) DeltaSecrets(sdsapi.SecretDiscoveryService_DeltaSecretsServer) error {
+	return status.Error(codes.Unimplemented, "DeltaSecrets not implemented")
+}
+
+func (s *Server) respond(ctx context.Context, resourceName string) (*xdsapi.DiscoveryResponse, error) {
+	s.mutex.Lock()
+	s.stats.Requests++
+	s.mutex.Unlock()
+
+	secret, err := s.secret(ctx, resourceName)
+	if err != nil {
+		s.mutex.Lock()
+		s.stats.Failures++
+		s.mutex.Unlock()
+		scope.Infof("SDS request for %s failed: %v", resourceName, err)
+		return nil, err
+	}
+	resource, err := ptypes.MarshalAny(secret)
+	if err != nil {
+		return nil, err
+	}
+	version := strconv.FormatInt(time.Now().UnixNano(), 10)
+	resp := &xdsapi.DiscoveryResponse{
+		TypeUrl:     secretType,
+		VersionInfo: version,
+		Nonce:       version,
+	}
+	resp.Resources = append(resp.Resources, resource)
+	return resp, nil
+}
+
+func (s *Server) secret(ctx context.Context, resourceName string) (*authapi.Secret, error) {
+	if resourceName == RootCertResourceName {
+		return &authapi.Secret{
+			Name: resourceName,
+			Type: &authapi.Secret_ValidationContext{
+				ValidationContext: &authapi.CertificateValidationContext{
+					TrustedCa: inlineBytes(s.rootCertPEM),
+				},
+			},
+		}, nil
+	}
+
+	token, err := credentialToken(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	ns, sa, err := serviceAccountFromToken(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	id := fmt.Sprintf("spiffe://%s/ns/%s/sa/%s", s.cfg.TrustDomain, ns, sa)
+
+	certPEM, keyPEM, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host:       id,
+		TTL:        s.cfg.CertTTL,
+		NotBefore:  time.Now().Add(-time.Minute),
+		SignerCert: s.rootCert,
+		SignerPriv: s.rootKey,
+		RSAKeySize: 2048,
+		IsClient:   true,
+		IsServer:   true,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "issuing certificate for %s failed: %v", id, err)
+	}
+
+	s.mutex.Lock()
+	s.stats.Identities = appendIfMissing(s.stats.Identities, id)
+	s.mutex.Unlock()
+	scope.Infof("Issued certificate for %s", id)
+
+	return &authapi.Secret{
+		Name: resourceName,
+		Type: &authapi.Secret_TlsCertificate{
+			TlsCertificate: &authapi.TlsCertificate{
+				CertificateChain: inlineBytes(append(certPEM, s.rootCertPEM...)),
+				PrivateKey:       inlineBytes(keyPEM),
+			},
+		},
+	}, nil
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, _ *http.Request) {
+	s.mutex.Lock()
+	stats := s.stats
+	s.mutex.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
+}
+
+func (s *Server) handleRootCert(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	_, _ = w.Write(s.rootCertPEM)
+}
+
+func inlineBytes(b []byte) *core.DataSource {
+	return &core.DataSource{
+		Specifier: &core.DataSource_InlineBytes{
+			InlineBytes: b,
+		},
+	}
+}
+
+// credentialToken returns the service account token the sidecar sent with the request.
+func credentialToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("no metadata in request")
+	}
+	for _, key := range []string{k8sSAJwtTokenHeaderKey, credentialTokenHeaderKey} {
+		if h := md[key]; len(h) == 1 {
+			return strings.TrimPrefix(h[0], "Bearer "), nil
+		}
+	}
+	return "", fmt.Errorf("no credential token in request")
+}
+
+// serviceAccountFromToken returns the namespace and the name of the service account of the given token. Like
+// an external identity provider attesting workloads by other means, the fake trusts the token without
+// verifying it. Both the legacy and the projected (bound) token claims are supported.
+func serviceAccountFromToken(token string) (string, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", "", fmt.Errorf("malformed token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", "", fmt.Errorf("malformed token payload: %v", err)
+	}
+	claims := struct {
+		Namespace      string `json:"kubernetes.io/serviceaccount/namespace"`
+		ServiceAccount string `json:"kubernetes.io/serviceaccount/service-account.name"`
+		Kubernetes     struct {
+			Namespace      string `json:"namespace"`
+			ServiceAccount struct {
+				Name string `json:"name"`
+			} `json:"serviceaccount"`
+		} `json:"kubernetes.io"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", "", fmt.Errorf("malformed token claims: %v", err)
+	}
+	if claims.Kubernetes.Namespace != "" {
+		return claims.Kubernetes.Namespace, claims.Kubernetes.ServiceAccount.Name, nil
+	}
+	if claims.Namespace == "" || claims.ServiceAccount == "" {
+		return "", "", fmt.Errorf("token has no service account claims")
+	}
+	return claims.Namespace, claims.ServiceAccount, nil
+}
+
+func appendIfMissing(list []string, s string) []string {
+	for _, e := range list {
+		if e == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
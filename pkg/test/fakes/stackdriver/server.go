@@ -0,0 +1,304 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stackdriver implements a fake Stackdriver server, which receives metrics and log entries over the
+// Cloud Monitoring and Cloud Logging gRPC APIs and exposes them through an admin API.
+package stackdriver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/genproto/googleapis/api/metric"
+	"google.golang.org/genproto/googleapis/api/monitoredres"
+	loggingpb "google.golang.org/genproto/googleapis/logging/v2"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"istio.io/pkg/log"
+)
+
+const (
+	// DefaultGRPCPort for the Cloud Monitoring and Cloud Logging APIs.
+	DefaultGRPCPort = 8091
+	// DefaultHTTPPort for the admin API.
+	DefaultHTTPPort = 8090
+
+	// TimeSeriesPath is the admin path for listing the received time series, as a ListTimeSeriesResponse.
+	TimeSeriesPath = "/admin/timeseries"
+	// LogEntriesPath is the admin path for listing the received log entries, as a ListLogEntriesResponse.
+	LogEntriesPath = "/admin/logentries"
+	// ResetPath is the admin path for discarding everything received so far.
+	ResetPath = "/admin/reset"
+)
+
+var scope = log.RegisterScope("fakes", "Scope for all fakes", 0)
+
+// Server is a fake Stackdriver server.
+type Server struct {
+	grpcPort int
+	httpPort int
+
+	mutex       sync.Mutex
+	timeSeries  []*monitoringpb.TimeSeries
+	descriptors map[string]*metric.MetricDescriptor
+	logEntries  []*loggingpb.LogEntry
+
+	grpcServer *grpc.Server
+	httpServer *http.Server
+}
+
+// NewServer returns a new Server listening on the given ports.
+func NewServer(grpcPort, httpPort int) *Server {
+	return &Server{
+		grpcPort:    grpcPort,
+		httpPort:    httpPort,
+		descriptors: make(map[string]*metric.MetricDescriptor),
+	}
+}
+
+// Start serving on both ports.
+func (s *Server) Start() error {
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.grpcPort))
+	if err != nil {
+		return err
+	}
+	httpListener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.httpPort))
+	if err != nil {
+		_ = grpcListener.Close()
+		return err
+	}
+	s.grpcPort = grpcListener.Addr().(*net.TCPAddr).Port
+	s.httpPort = httpListener.Addr().(*net.TCPAddr).Port
+
+	s.grpcServer = grpc.NewServer()
+	monitoringpb.RegisterMetricServiceServer(s.grpcServer, &metricServer{s})
+	loggingpb.RegisterLoggingServiceV2Server(s.grpcServer, &loggingServer{s})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(TimeSeriesPath, s.handleTimeSeries)
+	mux.HandleFunc(LogEntriesPath, s.handleLogEntries)
+	mux.HandleFunc(ResetPath, s.handleReset)
+	s.httpServer = &http.Server{Handler: mux}
+
+	go func() {
+		scope.Infof("Serving Stackdriver APIs over gRPC on port %d", s.grpcPort)
+		_ = s.grpcServer.Serve(grpcListener)
+	}()
+	go func() {
+		scope.Infof("Serving Stackdriver admin API over HTTP on port %d", s.httpPort)
+		_ = s.httpServer.Serve(httpListener)
+	}()
+	return nil
+}
+
+// GRPCPort returns the port of the Stackdriver APIs.
+func (s *Server) GRPCPort() int {
+	return s.grpcPort
+}
+
+// HTTPPort returns the port of the admin API.
+func (s *Server) HTTPPort() int {
+	return s.httpPort
+}
+
+// Close the server.
+func (s *Server) Close() error {
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	if s.httpServer != nil {
+		_ = s.httpServer.Close()
+	}
+	return nil
+}
+
+// TimeSeries returns the time series received so far.
+func (s *Server) TimeSeries() []*monitoringpb.TimeSeries {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*monitoringpb.TimeSeries{}, s.timeSeries...)
+}
+
+// LogEntries returns the log entries received so far.
+func (s *Server) LogEntries() []*loggingpb.LogEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*loggingpb.LogEntry{}, s.logEntries...)
+}
+
+// Reset discards the time series and log entries received so far.
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.timeSeries = nil
+	s.logEntries = nil
+}
+
+func (s *Server) handleTimeSeries(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, &monitoringpb.ListTimeSeriesResponse{TimeSeries: s.TimeSeries()})
+}
+
+func (s *Server) handleLogEntries(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, &loggingpb.ListLogEntriesResponse{Entries: s.LogEntries()})
+}
+
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.Reset()
+	scope.Infof("Stackdriver data reset")
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, msg proto.Message) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := (&jsonpb.Marshaler{}).Marshal(w, msg); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var unimplemented = status.Error(codes.Unimplemented, "not implemented by the fake Stackdriver server")
+
+// metricServer implements the Cloud Monitoring API. Only the creation of metric descriptors and time series
+// is supported, as used by the proxies for reporting metrics.
+type metricServer struct {
+	*Server
+}
+
+var _ monitoringpb.MetricServiceServer = &metricServer{}
+
+func (m *metricServer) ListMonitoredResourceDescriptors(context.Context,
+	*monitoringpb.ListMonitoredResourceDescriptorsRequest) (*monitoringpb.ListMonitoredResourceDescriptorsResponse, error) {
+	return nil, unimplemented
+}
+
+func (m *metricServer) GetMonitoredResourceDescriptor(context.Context,
+	*monitoringpb.GetMonitoredResourceDescriptorRequest) (*monitoredres.MonitoredResourceDescriptor, error) {
+	return nil, unimplemented
+}
+
+func (m *metricServer) ListMetricDescriptors(context.Context,
+	*monitoringpb.ListMetricDescriptorsRequest) (*monitoringpb.ListMetricDescriptorsResponse, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	resp := &monitoringpb.ListMetricDescriptorsResponse{}
+	for _, d := range m.descriptors {
+		resp.MetricDescriptors = append(resp.MetricDescriptors, d)
+	}
+	return resp, nil
+}
+
+func (m *metricServer) GetMetricDescriptor(_ context.Context,
+	req *monitoringpb.GetMetricDescriptorRequest) (*metric.MetricDescriptor, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	d, ok := m.descriptors[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "metric descriptor %s not found", req.Name)
+	}
+	return d, nil
+}
+
+func (m *metricServer) CreateMetricDescriptor(_ context.Context,
+	req *monitoringpb.CreateMetricDescriptorRequest) (*metric.MetricDescriptor, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.descriptors[req.MetricDescriptor.Name] = req.MetricDescriptor
+	return req.MetricDescriptor, nil
+}
+
+func (m *metricServer) DeleteMetricDescriptor(_ context.Context,
+	req *monitoringpb.DeleteMetricDescriptorRequest) (*empty.Empty, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.descriptors, req.Name)
+	return &empty.Empty{}, nil
+}
+
+func (m *metricServer) ListTimeSeries(context.Context,
+	*monitoringpb.ListTimeSeriesRequest) (*monitoringpb.ListTimeSeriesResponse, error) {
+	return &monitoringpb.ListTimeSeriesResponse{TimeSeries: m.TimeSeries()}, nil
+}
+
+func (m *metricServer) CreateTimeSeries(_ context.Context, req *monitoringpb.CreateTimeSeriesRequest) (*empty.Empty, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.timeSeries = append(m.timeSeries, req.TimeSeries...)
+	scope.Debugf("Received %d time series for %s", len(req.TimeSeries), req.Name)
+	return &empty.Empty{}, nil
+}
+
+// loggingServer implements the Cloud Logging API. Only the writing of log entries is supported, as used by
+// the proxies for reporting access logs.
+type loggingServer struct {
+	*Server
+}
+
+var _ loggingpb.LoggingServiceV2Server = &loggingServer{}
+
+func (l *loggingServer) DeleteLog(context.Context, *loggingpb.DeleteLogRequest) (*empty.Empty, error) {
+	return nil, unimplemented
+}
+
+func (l *loggingServer) WriteLogEntries(_ context.Context,
+	req *loggingpb.WriteLogEntriesRequest) (*loggingpb.WriteLogEntriesResponse, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, e := range req.Entries {
+		// Apply the defaults of the request, as the real service does.
+		if e.LogName == "" {
+			e.LogName = req.LogName
+		}
+		if e.Resource == nil {
+			e.Resource = req.Resource
+		}
+		if len(req.Labels) > 0 {
+			labels := make(map[string]string, len(req.Labels)+len(e.Labels))
+			for k, v := range req.Labels {
+				labels[k] = v
+			}
+			for k, v := range e.Labels {
+				labels[k] = v
+			}
+			e.Labels = labels
+		}
+		l.logEntries = append(l.logEntries, e)
+	}
+	scope.Debugf("Received %d log entries for %s", len(req.Entries), req.LogName)
+	return &loggingpb.WriteLogEntriesResponse{}, nil
+}
+
+func (l *loggingServer) ListLogEntries(context.Context,
+	*loggingpb.ListLogEntriesRequest) (*loggingpb.ListLogEntriesResponse, error) {
+	return &loggingpb.ListLogEntriesResponse{Entries: l.LogEntries()}, nil
+}
+
+func (l *loggingServer) ListMonitoredResourceDescriptors(context.Context,
+	*loggingpb.ListMonitoredResourceDescriptorsRequest) (*loggingpb.ListMonitoredResourceDescriptorsResponse, error) {
+	return nil, unimplemented
+}
+
+func (l *loggingServer) ListLogs(context.Context, *loggingpb.ListLogsRequest) (*loggingpb.ListLogsResponse, error) {
+	return nil, unimplemented
+}
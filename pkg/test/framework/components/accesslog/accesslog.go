@@ -0,0 +1,69 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package accesslog enables Envoy access logging for selected workloads and lets tests assert on the
+// logged entries.
+package accesslog
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Config for the access log component.
+type Config struct {
+	// Config is used to apply the EnvoyFilter that enables access logging.
+	Config config.Instance
+
+	// Instances whose sidecars log their inbound and outbound requests and connections.
+	Instances []echo.Instance
+}
+
+// Instance of the access log component. Access logging is disabled again when the instance is closed.
+type Instance interface {
+	resource.Resource
+
+	// Entries returns the entries logged by the sidecars of the given echo instance since the access log
+	// component was created or last reset.
+	Entries(i echo.Instance) (Entries, error)
+	EntriesOrFail(t test.Failer, i echo.Instance) Entries
+
+	// WaitForEntry waits until the sidecars of the given echo instance have logged an entry accepted by the
+	// given Matcher, and returns it. Envoy flushes the access log periodically, so entries may show up with
+	// a delay of a few seconds.
+	WaitForEntry(i echo.Instance, m Matcher, opts ...retry.Option) (Entry, error)
+	WaitForEntryOrFail(t test.Failer, i echo.Instance, m Matcher, opts ...retry.Option) Entry
+
+	// Reset discards all entries logged so far, so that only the entries logged afterwards are returned.
+	Reset() error
+	ResetOrFail(t test.Failer)
+}
+
+// New returns a new instance of the access log component.
+func New(ctx resource.Context, cfg Config) (Instance, error) {
+	return newAccessLog(ctx, cfg)
+}
+
+// NewOrFail calls New and fails t if an error occurs.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("accesslog.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,224 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Inbound is the Direction of the entries logged for requests received by a workload.
+	Inbound = "inbound"
+	// Outbound is the Direction of the entries logged for requests sent by a workload.
+	Outbound = "outbound"
+
+	// ShadowAllowed and ShadowDenied are the results of the RBAC shadow engine.
+	ShadowAllowed = "allowed"
+	ShadowDenied  = "denied"
+
+	// directionKey identifies the lines of the sidecar log that were written by the access log.
+	directionKey = "istio_test_direction"
+
+	// emptyValue is logged by Envoy for fields that are not set.
+	emptyValue = "-"
+)
+
+// Entry of the access log.
+type Entry struct {
+	// Direction is either Inbound or Outbound.
+	Direction string `json:"istio_test_direction"`
+	// Protocol is the HTTP protocol, empty for TCP connections.
+	Protocol string `json:"protocol"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	// Authority of the request.
+	Authority string `json:"authority"`
+	// ResponseCode of the request, zero for TCP connections.
+	ResponseCode int `json:"response_code"`
+	// ResponseCodeDetails reported by Envoy, for example "rbac_access_denied" for requests denied by RBAC.
+	ResponseCodeDetails string `json:"response_code_details"`
+	// ResponseFlags reported by Envoy, for example "UH" for requests without healthy upstreams.
+	ResponseFlags   string `json:"response_flags"`
+	UpstreamCluster string `json:"upstream_cluster"`
+	UpstreamHost    string `json:"upstream_host"`
+	// DownstreamRemoteAddress is the address of the peer of the sidecar.
+	DownstreamRemoteAddress string `json:"downstream_remote_address"`
+	// DownstreamPeerPrincipal is the URI SAN of the peer certificate, empty for plaintext requests.
+	DownstreamPeerPrincipal string `json:"downstream_peer_uri_san"`
+	// DownstreamLocalPrincipal is the URI SAN of the certificate presented by the sidecar to its peer.
+	DownstreamLocalPrincipal string `json:"downstream_local_uri_san"`
+	// ShadowEngineResult is the result of the RBAC shadow rules, either ShadowAllowed or ShadowDenied.
+	ShadowEngineResult string `json:"shadow_engine_result"`
+	// ShadowEffectivePolicyID is the ID of the shadow policy that matched the request.
+	ShadowEffectivePolicyID string `json:"shadow_effective_policy_id"`
+}
+
+// String implements fmt.Stringer
+func (e Entry) String() string {
+	out, _ := json.Marshal(e)
+	return string(out)
+}
+
+// parseEntry parses a line of the sidecar log. It returns false if the line is not an access log entry.
+func parseEntry(line string) (Entry, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") || !strings.Contains(line, directionKey) {
+		return Entry{}, false
+	}
+
+	// Envoy logs all fields as strings, and "-" for the unset ones.
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return Entry{}, false
+	}
+	normalized := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		s := fmt.Sprint(v)
+		if v == nil || s == emptyValue {
+			continue
+		}
+		if k == "response_code" {
+			code, err := strconv.Atoi(s)
+			if err != nil {
+				continue
+			}
+			normalized[k] = code
+			continue
+		}
+		normalized[k] = s
+	}
+
+	b, err := json.Marshal(normalized)
+	if err != nil {
+		return Entry{}, false
+	}
+	e := Entry{}
+	if err := json.Unmarshal(b, &e); err != nil {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// parseEntries returns all access log entries of the given sidecar log.
+func parseEntries(logs string) Entries {
+	var out Entries
+	for _, line := range strings.Split(logs, "\n") {
+		if e, ok := parseEntry(line); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Entries of the access log.
+type Entries []Entry
+
+// Match returns the entries accepted by the given Matcher.
+func (e Entries) Match(m Matcher) Entries {
+	var out Entries
+	for _, entry := range e {
+		if m(entry) {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// CheckContains returns an error if none of the entries is accepted by the given Matcher.
+func (e Entries) CheckContains(m Matcher) error {
+	if len(e.Match(m)) == 0 {
+		return fmt.Errorf("no matching access log entry found in:\n%s", e)
+	}
+	return nil
+}
+
+// CheckNotContains returns an error if any of the entries is accepted by the given Matcher.
+func (e Entries) CheckNotContains(m Matcher) error {
+	if matched := e.Match(m); len(matched) > 0 {
+		return fmt.Errorf("unexpected access log entries found:\n%s", matched)
+	}
+	return nil
+}
+
+// String implements fmt.Stringer
+func (e Entries) String() string {
+	lines := make([]string, 0, len(e))
+	for _, entry := range e {
+		lines = append(lines, entry.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Matcher accepts or rejects access log entries.
+type Matcher func(e Entry) bool
+
+// And returns a Matcher that accepts the entries accepted by all of the given matchers.
+func And(matchers ...Matcher) Matcher {
+	return func(e Entry) bool {
+		for _, m := range matchers {
+			if !m(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Direction returns a Matcher for the entries logged in the given direction.
+func Direction(direction string) Matcher {
+	return func(e Entry) bool {
+		return e.Direction == direction
+	}
+}
+
+// Path returns a Matcher for the requests to the given path.
+func Path(path string) Matcher {
+	return func(e Entry) bool {
+		return e.Path == path
+	}
+}
+
+// ResponseCode returns a Matcher for the requests with the given response code.
+func ResponseCode(code int) Matcher {
+	return func(e Entry) bool {
+		return e.ResponseCode == code
+	}
+}
+
+// ResponseCodeDetails returns a Matcher for the requests with the given response code details.
+func ResponseCodeDetails(details string) Matcher {
+	return func(e Entry) bool {
+		return e.ResponseCodeDetails == details
+	}
+}
+
+// PeerPrincipal returns a Matcher for the entries whose downstream peer has the given principal. An empty
+// principal matches the plaintext requests.
+func PeerPrincipal(principal string) Matcher {
+	return func(e Entry) bool {
+		return e.DownstreamPeerPrincipal == principal
+	}
+}
+
+// ShadowResult returns a Matcher for the entries with the given result of the RBAC shadow engine, and,
+// if not empty, the given effective shadow policy.
+func ShadowResult(result, policyID string) Matcher {
+	return func(e Entry) bool {
+		return e.ShadowEngineResult == result && (policyID == "" || e.ShadowEffectivePolicyID == policyID)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accesslog
+
+import (
+	"testing"
+)
+
+const sidecarLogs = `2019-09-10T17:05:27.525231Z	info	Envoy proxy is ready
+{"istio_test_direction":"inbound","protocol":"HTTP/1.1","method":"GET","path":"/allowed","authority":"b:80","response_code":"200","response_code_details":"via_upstream","response_flags":"-","upstream_cluster":"inbound|80|http|b.apps.svc.cluster.local","upstream_host":"127.0.0.1:8090","downstream_remote_address":"10.0.0.1:40000","downstream_peer_uri_san":"spiffe://cluster.local/ns/apps/sa/a","downstream_local_uri_san":"spiffe://cluster.local/ns/apps/sa/b","shadow_engine_result":"denied","shadow_effective_policy_id":"-"}
+not json {"istio_test_direction":"inbound"}
+{"istio_test_direction":"inbound","protocol":"-","method":"-","path":"-","authority":"-","response_code":"0","response_code_details":"-","response_flags":"-","upstream_cluster":"inbound|90||b.apps.svc.cluster.local","upstream_host":"127.0.0.1:9090","downstream_remote_address":"10.0.0.1:40002","downstream_peer_uri_san":"-","downstream_local_uri_san":"-","shadow_engine_result":"-","shadow_effective_policy_id":"-"}
+{"istio_test_direction":"inbound","protocol":"HTTP/1.1","method":"GET","path":"/denied","authority":"b:80","response_code":"403","response_code_details":"rbac_access_denied","response_flags":"-","upstream_cluster":"-","upstream_host":"-","downstream_remote_address":"10.0.0.1:40004","downstream_peer_uri_san":"spiffe://cluster.local/ns/apps/sa/a","downstream_local_uri_san":"spiffe://cluster.local/ns/apps/sa/b","shadow_engine_result":"allowed","shadow_effective_policy_id":"policy-1"}
+`
+
+func TestParseEntries(t *testing.T) {
+	entries := parseEntries(sidecarLogs)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d:\n%s", len(entries), entries)
+	}
+
+	tcp := entries[1]
+	if tcp.ResponseCode != 0 || tcp.Protocol != "" || tcp.DownstreamPeerPrincipal != "" {
+		t.Fatalf("unexpected TCP entry: %s", tcp)
+	}
+
+	if err := entries.CheckContains(And(
+		Direction(Inbound),
+		Path("/allowed"),
+		ResponseCode(200),
+		PeerPrincipal("spiffe://cluster.local/ns/apps/sa/a"),
+		ShadowResult(ShadowDenied, ""),
+	)); err != nil {
+		t.Fatal(err)
+	}
+	if err := entries.CheckContains(And(
+		ResponseCode(403),
+		ResponseCodeDetails("rbac_access_denied"),
+		ShadowResult(ShadowAllowed, "policy-1"),
+	)); err != nil {
+		t.Fatal(err)
+	}
+	if err := entries.CheckNotContains(Direction(Outbound)); err != nil {
+		t.Fatal(err)
+	}
+	if err := entries.CheckNotContains(ShadowResult(ShadowAllowed, "policy-2")); err != nil {
+		t.Fatal(err)
+	}
+}
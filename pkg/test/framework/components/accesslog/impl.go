@@ -0,0 +1,303 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-multierror"
+
+	authzModel "istio.io/istio/pilot/pkg/security/authz/model"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+var (
+	_ Instance  = &accessLogImpl{}
+	_ io.Closer = &accessLogImpl{}
+)
+
+// envoyFilterTemplate adds a JSON access log to the inbound and outbound HTTP connection managers and
+// TCP proxies of a workload. The direction of the entries is logged as a constant field.
+const envoyFilterTemplate = `
+apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: accesslog-{{ .Service }}
+spec:
+  workloadSelector:
+    labels:
+      app: {{ .Service }}
+  configPatches:
+{{- range $direction, $context := .Contexts }}
+{{- range $filter, $rbac := $.Filters }}
+  - applyTo: NETWORK_FILTER
+    match:
+      context: {{ $context }}
+      listener:
+        filterChain:
+          filter:
+            name: {{ $filter }}
+    patch:
+      operation: MERGE
+      value:
+        config:
+          access_log:
+          - name: envoy.file_access_log
+            config:
+              path: /dev/stdout
+              json_format:
+                {{ $.DirectionKey }}: {{ $direction }}
+                protocol: "%PROTOCOL%"
+                method: "%REQ(:METHOD)%"
+                path: "%REQ(X-ENVOY-ORIGINAL-PATH?:PATH)%"
+                authority: "%REQ(:AUTHORITY)%"
+                response_code: "%RESPONSE_CODE%"
+                response_code_details: "%RESPONSE_CODE_DETAILS%"
+                response_flags: "%RESPONSE_FLAGS%"
+                upstream_cluster: "%UPSTREAM_CLUSTER%"
+                upstream_host: "%UPSTREAM_HOST%"
+                downstream_remote_address: "%DOWNSTREAM_REMOTE_ADDRESS%"
+                downstream_peer_uri_san: "%DOWNSTREAM_PEER_URI_SAN%"
+                downstream_local_uri_san: "%DOWNSTREAM_LOCAL_URI_SAN%"
+                shadow_engine_result: "%DYNAMIC_METADATA({{ $rbac }}:shadow_engine_result)%"
+                shadow_effective_policy_id: "%DYNAMIC_METADATA({{ $rbac }}:shadow_effective_policy_id)%"
+{{- end }}
+{{- end }}
+`
+
+type accessLogImpl struct {
+	id  resource.ID
+	cfg Config
+
+	mutex sync.Mutex
+	// applied EnvoyFilter for each of the instances.
+	applied map[echo.Instance]string
+	// offsets are the number of entries logged by each sidecar, by node ID, when the instance was last reset.
+	offsets map[string]int
+}
+
+func newAccessLog(ctx resource.Context, cfg Config) (Instance, error) {
+	if cfg.Config == nil {
+		return nil, fmt.Errorf("accesslog: Config is required")
+	}
+
+	c := &accessLogImpl{
+		cfg:     cfg,
+		applied: make(map[echo.Instance]string),
+		offsets: make(map[string]int),
+	}
+	c.id = ctx.TrackResource(c)
+
+	for _, i := range cfg.Instances {
+		if err := c.enable(i); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Reset(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *accessLogImpl) ID() resource.ID {
+	return c.id
+}
+
+func (c *accessLogImpl) enable(i echo.Instance) error {
+	filter, err := tmpl.Evaluate(envoyFilterTemplate, map[string]interface{}{
+		"Service":      i.Config().Service,
+		"DirectionKey": directionKey,
+		"Contexts": map[string]string{
+			Inbound:  "SIDECAR_INBOUND",
+			Outbound: "SIDECAR_OUTBOUND",
+		},
+		"Filters": map[string]string{
+			"envoy.http_connection_manager": authzModel.RBACHTTPFilterName,
+			"envoy.tcp_proxy":               authzModel.RBACTCPFilterName,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.cfg.Config.Apply(i.Config().Namespace, filter); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	c.applied[i] = filter
+	c.mutex.Unlock()
+
+	// Wait for the access log to show up in the listeners of all sidecars of the instance.
+	sidecars, err := sidecars(i)
+	if err != nil {
+		return err
+	}
+	for _, s := range sidecars {
+		if err := s.WaitForConfig(func(cfg *envoyAdmin.ConfigDump) (bool, error) {
+			if !containsAccessLog(cfg) {
+				return false, fmt.Errorf("access log not configured yet for %s", s.NodeID())
+			}
+			return true, nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsAccessLog(cfg *envoyAdmin.ConfigDump) bool {
+	for _, c := range cfg.Configs {
+		if c.TypeUrl != "type.googleapis.com/envoy.admin.v2alpha.ListenersConfigDump" {
+			continue
+		}
+		listeners := &envoyAdmin.ListenersConfigDump{}
+		if err := ptypes.UnmarshalAny(c, listeners); err != nil {
+			return false
+		}
+		return strings.Contains(proto.MarshalTextString(listeners), directionKey)
+	}
+	return false
+}
+
+func (c *accessLogImpl) Entries(i echo.Instance) (Entries, error) {
+	sidecars, err := sidecars(i)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var out Entries
+	for _, s := range sidecars {
+		logs, err := s.Logs()
+		if err != nil {
+			return nil, err
+		}
+		entries := parseEntries(logs)
+
+		offset := c.offsets[s.NodeID()]
+		if offset > len(entries) {
+			// The sidecar restarted and the previous entries are gone.
+			offset = 0
+		}
+		out = append(out, entries[offset:]...)
+	}
+	return out, nil
+}
+
+func (c *accessLogImpl) EntriesOrFail(t test.Failer, i echo.Instance) Entries {
+	t.Helper()
+	entries, err := c.Entries(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func (c *accessLogImpl) WaitForEntry(i echo.Instance, m Matcher, opts ...retry.Option) (Entry, error) {
+	e, err := retry.Do(func() (interface{}, bool, error) {
+		entries, err := c.Entries(i)
+		if err != nil {
+			return nil, false, err
+		}
+		if matched := entries.Match(m); len(matched) > 0 {
+			return matched[0], true, nil
+		}
+		return nil, false, fmt.Errorf("no matching access log entry for %s in:\n%s", i.Config().Service, entries)
+	}, opts...)
+	if err != nil {
+		return Entry{}, err
+	}
+	return e.(Entry), nil
+}
+
+func (c *accessLogImpl) WaitForEntryOrFail(t test.Failer, i echo.Instance, m Matcher, opts ...retry.Option) Entry {
+	t.Helper()
+	e, err := c.WaitForEntry(i, m, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func (c *accessLogImpl) Reset() error {
+	offsets := make(map[string]int)
+	for _, i := range c.cfg.Instances {
+		sidecars, err := sidecars(i)
+		if err != nil {
+			return err
+		}
+		for _, s := range sidecars {
+			logs, err := s.Logs()
+			if err != nil {
+				return err
+			}
+			offsets[s.NodeID()] = len(parseEntries(logs))
+		}
+	}
+
+	c.mutex.Lock()
+	c.offsets = offsets
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *accessLogImpl) ResetOrFail(t test.Failer) {
+	t.Helper()
+	if err := c.Reset(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *accessLogImpl) Close() (err error) {
+	c.mutex.Lock()
+	applied := c.applied
+	c.applied = make(map[echo.Instance]string)
+	c.mutex.Unlock()
+
+	for i, filter := range applied {
+		err = multierror.Append(err, c.cfg.Config.Delete(i.Config().Namespace, filter)).ErrorOrNil()
+	}
+	return
+}
+
+func sidecars(i echo.Instance) ([]echo.Sidecar, error) {
+	workloads, err := i.Workloads()
+	if err != nil {
+		return nil, err
+	}
+	var out []echo.Sidecar
+	for _, w := range workloads {
+		if s := w.Sidecar(); s != nil {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("accesslog: echo instance %s has no sidecars", i.Config().Service)
+	}
+	return out, nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analysis runs the Istio configuration analyzers, as "istioctl experimental analyze -k" does, against
+// the live configuration of the test environment and returns the resulting messages.
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	cfgKube "istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	analysisTimeout = time.Minute
+)
+
+// Message is a single message reported by the analyzers.
+type Message struct {
+	// Code of the message type, e.g. "IST0101".
+	Code string
+	// Level of the message: "Info", "Warn" or "Error".
+	Level string
+	// Text of the message, including its parameters.
+	Text string
+
+	// Kind, Namespace and Name of the resource the message is about. Empty if unknown.
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// String implements fmt.Stringer
+func (m Message) String() string {
+	return fmt.Sprintf("%s [%s] (%s %s/%s) %s", m.Level, m.Code, m.Kind, m.Namespace, m.Name, m.Text)
+}
+
+// Messages reported by the analyzers.
+type Messages []Message
+
+// WithCode returns the messages with the given code.
+func (m Messages) WithCode(code string) Messages {
+	var out Messages
+	for _, msg := range m {
+		if msg.Code == code {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// ForResource returns the messages about the resource with the given kind and name in the given namespace.
+func (m Messages) ForResource(kind string, ns namespace.Instance, name string) Messages {
+	var out Messages
+	for _, msg := range m {
+		if msg.Kind == kind && msg.Namespace == ns.Name() && msg.Name == name {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// CheckContains returns an error unless there is a message with the given code.
+func (m Messages) CheckContains(code string) error {
+	if len(m.WithCode(code)) == 0 {
+		return fmt.Errorf("expected a message with code %s, found:\n%s", code, m)
+	}
+	return nil
+}
+
+// CheckContainsOrFail calls CheckContains and fails t if an error occurs.
+func (m Messages) CheckContainsOrFail(t test.Failer, code string) {
+	t.Helper()
+	if err := m.CheckContains(code); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CheckNotContains returns an error if there is a message with the given code.
+func (m Messages) CheckNotContains(code string) error {
+	if found := m.WithCode(code); len(found) > 0 {
+		return fmt.Errorf("expected no message with code %s, found:\n%s", code, found)
+	}
+	return nil
+}
+
+// CheckNotContainsOrFail calls CheckNotContains and fails t if an error occurs.
+func (m Messages) CheckNotContainsOrFail(t test.Failer, code string) {
+	t.Helper()
+	if err := m.CheckNotContains(code); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// String implements fmt.Stringer
+func (m Messages) String() string {
+	lines := make([]string, 0, len(m))
+	for _, msg := range m {
+		lines = append(lines, msg.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Run the analyzers against the configuration of the primary cluster of the Kubernetes environment, and return
+// the messages about the resources of the given namespaces. If no namespace is given, all messages are returned.
+func Run(ctx resource.Context, namespaces ...namespace.Instance) (Messages, error) {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return nil, resource.UnsupportedEnvironment(ctx.Environment())
+	}
+
+	k, err := cfgKube.NewInterfacesFromConfigFile(env.Settings().KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	sa := local.NewSourceAnalyzer(metadata.MustGet(), analyzers.AllCombined(), nil)
+	sa.AddRunningKubeSource(k)
+
+	cancel := make(chan struct{})
+	timer := time.AfterFunc(analysisTimeout, func() { close(cancel) })
+	defer timer.Stop()
+
+	result, err := sa.Analyze(cancel)
+	if err != nil {
+		return nil, fmt.Errorf("analysis: %v", err)
+	}
+
+	nsNames := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		nsNames[ns.Name()] = true
+	}
+
+	var out Messages
+	for _, m := range result {
+		msg := toMessage(m)
+		if len(nsNames) > 0 && !nsNames[msg.Namespace] {
+			continue
+		}
+		out = append(out, msg)
+	}
+	return out, nil
+}
+
+// RunOrFail calls Run and fails t if an error occurs.
+func RunOrFail(t test.Failer, ctx resource.Context, namespaces ...namespace.Instance) Messages {
+	t.Helper()
+	out, err := Run(ctx, namespaces...)
+	if err != nil {
+		t.Fatalf("analysis.RunOrFail: %v", err)
+	}
+	return out
+}
+
+func toMessage(m diag.Message) Message {
+	msg := Message{
+		Code:  m.Type.Code(),
+		Level: string(m.Type.Level()),
+		Text:  fmt.Sprintf(m.Type.Template(), m.Parameters...),
+	}
+	if o, ok := m.Origin.(*rt.Origin); ok {
+		msg.Kind = o.Kind
+		msg.Namespace, msg.Name = o.Name.InterpretAsNamespaceAndName()
+	}
+	return msg
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/fakes/extauthz"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Rule for deciding on check requests.
+type Rule = extauthz.Rule
+
+// Rules of the server, evaluated in order.
+type Rules = extauthz.Rules
+
+// Request is a check request captured by the server.
+type Request = extauthz.Request
+
+// Config for the external authorization server.
+type Config struct {
+	// Namespace to deploy the server to. If not set, a new namespace is created. Only used in the
+	// Kubernetes environment.
+	Namespace namespace.Instance
+
+	// Rules initially used by the server. Defaults to allowing all requests.
+	Rules *Rules
+}
+
+// Server represents a deployed fake Envoy external authorization (ext_authz) provider.
+type Server interface {
+	resource.Resource
+
+	// GRPCAddress of the gRPC ext_authz API, in host:port form, as seen from within the cluster.
+	GRPCAddress() string
+
+	// HTTPAddress of the HTTP ext_authz API, in host:port form, as seen from within the cluster.
+	HTTPAddress() string
+
+	// SetRules replaces the rules used for deciding on check requests.
+	SetRules(rules Rules) error
+	SetRulesOrFail(t test.Failer, rules Rules)
+
+	// Requests returns the check requests received by the server, in order.
+	Requests() ([]Request, error)
+	RequestsOrFail(t test.Failer) []Request
+
+	// ClearRequests discards the captured check requests.
+	ClearRequests() error
+	ClearRequestsOrFail(t test.Failer)
+}
+
+// New returns a new instance of the external authorization server.
+func New(ctx resource.Context, cfg Config) (s Server, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Native, func() {
+		s, err = newNative(ctx, cfg)
+	})
+	ctx.Environment().Case(environment.Kube, func() {
+		s, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Server {
+	t.Helper()
+	s, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("authz.NewOrFail: %v", err)
+	}
+	return s
+}
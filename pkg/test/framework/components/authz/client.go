@@ -0,0 +1,110 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/fakes/extauthz"
+)
+
+const (
+	adminTimeout = 10 * time.Second
+)
+
+// client for the admin API of the fake ext_authz server.
+type client struct {
+	// address of the admin API, in host:port form.
+	address string
+}
+
+func (c *client) SetRules(rules Rules) error {
+	body, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(http.MethodPut, extauthz.RulesPath, body)
+	return err
+}
+
+func (c *client) SetRulesOrFail(t test.Failer, rules Rules) {
+	t.Helper()
+	if err := c.SetRules(rules); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *client) Requests() ([]Request, error) {
+	body, err := c.do(http.MethodGet, extauthz.RequestsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]Request, 0)
+	if err := json.Unmarshal(body, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (c *client) RequestsOrFail(t test.Failer) []Request {
+	t.Helper()
+	requests, err := c.Requests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return requests
+}
+
+func (c *client) ClearRequests() error {
+	_, err := c.do(http.MethodDelete, extauthz.RequestsPath, nil)
+	return err
+}
+
+func (c *client) ClearRequestsOrFail(t test.Failer) {
+	t.Helper()
+	if err := c.ClearRequests(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *client) do(method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", c.address, path), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpClient := http.Client{
+		Timeout: adminTimeout,
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ext_authz admin %s %s returned %d: %s", method, path, resp.StatusCode, string(out))
+	}
+	return out, nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"io"
+
+	"istio.io/istio/pkg/test/deployment"
+	"istio.io/istio/pkg/test/fakes/extauthz"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/core/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	serviceName = "ext-authz"
+
+	template = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.app}}
+  labels:
+    app: {{.app}}
+spec:
+  ports:
+  - port: {{.grpcPort}}
+    targetPort: {{.grpcPort}}
+    name: grpc
+  - port: {{.httpPort}}
+    targetPort: {{.httpPort}}
+    name: http
+  selector:
+    app: {{.app}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.app}}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: {{.app}}
+  template:
+    metadata:
+      labels:
+        app: {{.app}}
+      annotations:
+        sidecar.istio.io/inject: "false"
+    spec:
+      containers:
+      - name: app
+        image: "{{.Hub}}/test_extauthz:{{.Tag}}"
+        imagePullPolicy: {{.ImagePullPolicy}}
+        args:
+        - --grpcPort={{.grpcPort}}
+        - --httpPort={{.httpPort}}
+        - --adminPort={{.adminPort}}
+        ports:
+        - name: grpc
+          containerPort: {{.grpcPort}}
+        - name: http
+          containerPort: {{.httpPort}}
+        - name: admin
+          containerPort: {{.adminPort}}
+        readinessProbe:
+          httpGet:
+            path: {{.path}}
+            port: admin
+          initialDelaySeconds: 1
+---
+`
+)
+
+var (
+	_ Server    = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id resource.ID
+
+	*client
+
+	namespace  namespace.Instance
+	forwarder  testKube.PortForwarder
+	deployment *deployment.Instance
+}
+
+func newKube(ctx resource.Context, cfg Config) (Server, error) {
+	env := ctx.Environment().(*kube.Environment)
+	c := &kubeComponent{
+		client:    &client{},
+		namespace: cfg.Namespace,
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	scopes.CI.Infof("=== BEGIN: ext_authz server Deployment ===")
+	defer func() {
+		if err != nil {
+			scopes.CI.Infof("=== FAILED: ext_authz server Deployment ===")
+			_ = c.Close()
+		} else {
+			scopes.CI.Infof("=== SUCCEEDED: ext_authz server Deployment ===")
+		}
+	}()
+
+	if c.namespace == nil {
+		if c.namespace, err = namespace.New(ctx, namespace.Config{
+			Prefix: "ext-authz",
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+
+	yamlContent, err := tmpl.Evaluate(template, map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.Tag,
+		"ImagePullPolicy": s.PullPolicy,
+		"app":             serviceName,
+		"grpcPort":        extauthz.DefaultGRPCPort,
+		"httpPort":        extauthz.DefaultHTTPPort,
+		"adminPort":       extauthz.DefaultAdminPort,
+		"path":            extauthz.RulesPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.deployment = deployment.NewYamlContentDeployment(c.namespace.Name(), yamlContent)
+	if err = c.deployment.Deploy(env.Accessor, false); err != nil {
+		return nil, err
+	}
+
+	fetchFn := env.NewSinglePodFetch(c.namespace.Name(), "app="+serviceName)
+	pods, err := env.WaitUntilPodsAreReady(fetchFn)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err = env.WaitUntilServiceEndpointsAreReady(c.namespace.Name(), serviceName); err != nil {
+		return nil, err
+	}
+
+	if c.forwarder, err = env.NewPortForwarder(pods[0], 0, extauthz.DefaultAdminPort); err != nil {
+		return nil, err
+	}
+	if err = c.forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.client.address = c.forwarder.Address()
+	scopes.Framework.Debugf("initialized ext_authz admin port forwarder: %v", c.forwarder.Address())
+
+	if cfg.Rules != nil {
+		if err = c.SetRules(*cfg.Rules); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) GRPCAddress() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", serviceName, c.namespace.Name(), extauthz.DefaultGRPCPort)
+}
+
+func (c *kubeComponent) HTTPAddress() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", serviceName, c.namespace.Name(), extauthz.DefaultHTTPPort)
+}
+
+func (c *kubeComponent) Close() (err error) {
+	if c.forwarder != nil {
+		err = c.forwarder.Close()
+		c.forwarder = nil
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaos provides a component that injects faults into the pods of a test cluster, such as killing
+// the pods of the control plane or of a workload, taking them down, or partitioning them from the network, so
+// that the behavior of the mesh can be verified while parts of it are unavailable.
+package chaos
+
+import (
+	"sort"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Target selects the pods a fault is injected into.
+type Target struct {
+	// Cluster of the pods. If empty, the primary cluster is used.
+	Cluster string
+	// Namespace of the pods.
+	Namespace string
+	// Labels of the pods.
+	Labels map[string]string
+	// Deployment that manages the pods. Required for taking the pods down.
+	Deployment string
+}
+
+// String implements fmt.Stringer.
+func (t Target) String() string {
+	return t.Namespace + "/" + t.selector()
+}
+
+// selector returns the label selector of the pods.
+func (t Target) selector() string {
+	selectors := make([]string, 0, len(t.Labels))
+	for k, v := range t.Labels {
+		selectors = append(selectors, k+"="+v)
+	}
+	sort.Strings(selectors)
+	return strings.Join(selectors, ",")
+}
+
+// Pilot returns the Target of the Pilot pods of the given Istio deployment.
+func Pilot(cfg istio.Config) Target {
+	return Target{
+		Namespace:  cfg.ConfigNamespace,
+		Labels:     map[string]string{"istio": "pilot"},
+		Deployment: "istio-pilot",
+	}
+}
+
+// IngressGateway returns the Target of the ingress gateway pods of the given Istio deployment.
+func IngressGateway(cfg istio.Config) Target {
+	return Target{
+		Namespace:  cfg.IngressNamespace,
+		Labels:     map[string]string{"istio": "ingressgateway"},
+		Deployment: "istio-ingressgateway",
+	}
+}
+
+// EgressGateway returns the Target of the egress gateway pods of the given Istio deployment.
+func EgressGateway(cfg istio.Config) Target {
+	return Target{
+		Namespace:  cfg.EgressNamespace,
+		Labels:     map[string]string{"istio": "egressgateway"},
+		Deployment: "istio-egressgateway",
+	}
+}
+
+// Workloads returns the Target of all of the workloads of the given echo instance. The workloads of the
+// subsets are managed by separate deployments, so the Target can't be taken down; use Subset instead.
+func Workloads(i echo.Instance) Target {
+	cfg := i.Config()
+	return Target{
+		Cluster:   cfg.Cluster,
+		Namespace: cfg.Namespace.Name(),
+		Labels:    map[string]string{"app": cfg.Service},
+	}
+}
+
+// Subset returns the Target of the workloads of the subset with the given version of the echo instance.
+func Subset(i echo.Instance, version string) Target {
+	cfg := i.Config()
+	return Target{
+		Cluster:    cfg.Cluster,
+		Namespace:  cfg.Namespace.Name(),
+		Labels:     map[string]string{"app": cfg.Service, "version": version},
+		Deployment: cfg.Service + "-" + version,
+	}
+}
+
+// Fault injected into the pods of a Target, which lasts until it is healed.
+type Fault interface {
+	// Target the fault is injected into.
+	Target() Target
+
+	// Heal removes the fault and waits until the pods of the Target are ready again.
+	Heal() error
+	HealOrFail(t test.Failer)
+}
+
+// Instance injects faults. Faults that are not healed when the instance is closed are healed then.
+type Instance interface {
+	resource.Resource
+
+	// Kill deletes the pods of the target. The pods are recreated by their controller, if any. Use
+	// WaitUntilReady to wait for the replacements.
+	Kill(target Target) error
+	KillOrFail(t test.Failer, target Target)
+
+	// Down scales the deployment of the target to zero and waits until all of its pods are gone. Healing the
+	// fault scales the deployment back to its previous number of replicas.
+	Down(target Target) (Fault, error)
+	DownOrFail(t test.Failer, target Target) Fault
+
+	// Partition isolates the pods of the target with a NetworkPolicy that denies all of their ingress and
+	// egress traffic. Healing the fault removes the policy. The cluster must enforce NetworkPolicies.
+	Partition(target Target) (Fault, error)
+	PartitionOrFail(t test.Failer, target Target) Fault
+
+	// WaitUntilReady waits until the target has at least one pod, and all of its pods are ready.
+	WaitUntilReady(target Target) error
+	WaitUntilReadyOrFail(t test.Failer, target Target)
+}
+
+// New returns a new chaos component.
+func New(ctx resource.Context) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Kube, func() {
+		i = newKube(ctx)
+		err = nil
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context) Instance {
+	t.Helper()
+	i, err := New(ctx)
+	if err != nil {
+		t.Fatalf("chaos.NewOrFail: %v", err)
+	}
+	return i
+}
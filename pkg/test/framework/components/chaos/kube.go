@@ -0,0 +1,302 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+	k "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	partitionYAML = `
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: {{ .Name }}
+spec:
+  podSelector:
+    matchLabels:
+{{- range $name, $value := .Labels }}
+      {{ $name }}: {{ printf "%q" $value }}
+{{- end }}
+  policyTypes:
+  - Ingress
+  - Egress
+`
+)
+
+var (
+	retryTimeout = retry.Timeout(5 * time.Minute)
+	retryDelay   = retry.Delay(time.Second)
+
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id  resource.ID
+	env *kube.Environment
+
+	mutex  sync.Mutex
+	faults []Fault
+	// partitions is the number of partitions created, for naming their policies uniquely.
+	partitions int
+}
+
+func newKube(ctx resource.Context) Instance {
+	c := &kubeComponent{
+		env: ctx.Environment().(*kube.Environment),
+	}
+	c.id = ctx.TrackResource(c)
+	return c
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) accessor(target Target) (*k.Accessor, error) {
+	if target.Namespace == "" || len(target.Labels) == 0 {
+		return nil, fmt.Errorf("chaos: target %s must have a namespace and labels", target)
+	}
+	return c.env.Cluster(target.Cluster)
+}
+
+func (c *kubeComponent) Kill(target Target) error {
+	a, err := c.accessor(target)
+	if err != nil {
+		return err
+	}
+	pods, err := a.GetPods(target.Namespace, target.selector())
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("chaos: no pods found for %s", target)
+	}
+	for _, p := range pods {
+		scopes.Framework.Infof("Killing pod %s/%s", p.Namespace, p.Name)
+		if err := a.DeletePod(p.Namespace, p.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *kubeComponent) KillOrFail(t test.Failer, target Target) {
+	t.Helper()
+	if err := c.Kill(target); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *kubeComponent) Down(target Target) (Fault, error) {
+	if target.Deployment == "" {
+		return nil, fmt.Errorf("chaos: target %s has no deployment to scale down", target)
+	}
+	a, err := c.accessor(target)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := a.GetDeployment(target.Namespace, target.Deployment)
+	if err != nil {
+		return nil, err
+	}
+	replicas := 1
+	if deployment.Spec.Replicas != nil {
+		replicas = int(*deployment.Spec.Replicas)
+	}
+
+	scopes.Framework.Infof("Scaling down deployment %s/%s from %d replicas", target.Namespace, target.Deployment, replicas)
+	if err := a.ScaleDeployment(target.Namespace, target.Deployment, 0); err != nil {
+		return nil, err
+	}
+	f := &fault{
+		c:      c,
+		target: target,
+		heal: func() error {
+			scopes.Framework.Infof("Scaling up deployment %s/%s to %d replicas", target.Namespace, target.Deployment, replicas)
+			return a.ScaleDeployment(target.Namespace, target.Deployment, replicas)
+		},
+	}
+	c.add(f)
+
+	if err := a.WaitUntilPodsAreDeleted(a.NewPodFetch(target.Namespace, target.selector()), retryTimeout, retryDelay); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func (c *kubeComponent) DownOrFail(t test.Failer, target Target) Fault {
+	t.Helper()
+	f, err := c.Down(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (c *kubeComponent) Partition(target Target) (Fault, error) {
+	a, err := c.accessor(target)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.partitions++
+	name := fmt.Sprintf("chaos-partition-%d", c.partitions)
+	c.mutex.Unlock()
+
+	policy, err := tmpl.Evaluate(partitionYAML, map[string]interface{}{
+		"Name":   name,
+		"Labels": target.Labels,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scopes.Framework.Infof("Partitioning pods %s", target)
+	if _, err := a.ApplyContents(target.Namespace, policy); err != nil {
+		return nil, err
+	}
+	f := &fault{
+		c:      c,
+		target: target,
+		heal: func() error {
+			scopes.Framework.Infof("Removing partition of pods %s", target)
+			return a.DeleteContents(target.Namespace, policy)
+		},
+	}
+	c.add(f)
+	return f, nil
+}
+
+func (c *kubeComponent) PartitionOrFail(t test.Failer, target Target) Fault {
+	t.Helper()
+	f, err := c.Partition(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (c *kubeComponent) WaitUntilReady(target Target) error {
+	a, err := c.accessor(target)
+	if err != nil {
+		return err
+	}
+	return retry.UntilSuccess(func() error {
+		pods, err := a.GetPods(target.Namespace, target.selector())
+		if err != nil {
+			return err
+		}
+		if len(pods) == 0 {
+			return fmt.Errorf("no pods found for %s", target)
+		}
+		for i := range pods {
+			p := &pods[i]
+			if p.DeletionTimestamp != nil {
+				return fmt.Errorf("pod %s/%s is terminating", p.Namespace, p.Name)
+			}
+			if err := k.CheckPodReady(p); err != nil {
+				return fmt.Errorf("pod %s/%s: %v", p.Namespace, p.Name, err)
+			}
+		}
+		return nil
+	}, retryTimeout, retryDelay)
+}
+
+func (c *kubeComponent) WaitUntilReadyOrFail(t test.Failer, target Target) {
+	t.Helper()
+	if err := c.WaitUntilReady(target); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Close heals all of the faults that are not healed yet.
+func (c *kubeComponent) Close() (err error) {
+	c.mutex.Lock()
+	faults := c.faults
+	c.faults = nil
+	c.mutex.Unlock()
+
+	for i := len(faults) - 1; i >= 0; i-- {
+		err = multierror.Append(err, faults[i].Heal()).ErrorOrNil()
+	}
+	return
+}
+
+func (c *kubeComponent) add(f Fault) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.faults = append(c.faults, f)
+}
+
+func (c *kubeComponent) remove(f Fault) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, existing := range c.faults {
+		if existing == f {
+			c.faults = append(c.faults[:i], c.faults[i+1:]...)
+			return
+		}
+	}
+}
+
+var errHealed = errors.New("chaos: fault already healed")
+
+type fault struct {
+	c      *kubeComponent
+	target Target
+	heal   func() error
+
+	once sync.Once
+}
+
+func (f *fault) Target() Target {
+	return f.target
+}
+
+func (f *fault) Heal() error {
+	err := errHealed
+	f.once.Do(func() {
+		f.c.remove(f)
+		if err = f.heal(); err != nil {
+			return
+		}
+		err = f.c.WaitUntilReady(f.target)
+	})
+	return err
+}
+
+func (f *fault) HealOrFail(t test.Failer) {
+	t.Helper()
+	if err := f.Heal(); err != nil {
+		t.Fatal(err)
+	}
+}
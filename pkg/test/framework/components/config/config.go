@@ -0,0 +1,88 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config applies configuration through Galley and waits until it takes effect in the mesh.
+package config
+
+import (
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/galley"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Config for the config component.
+type Config struct {
+	// Galley used for applying the configuration.
+	Galley galley.Instance
+
+	// WaitFor the sidecars of the given echo instances to receive the configuration after it is applied or
+	// deleted. If empty, only Galley is waited for.
+	WaitFor []echo.Instance
+
+	// Timeout for the sidecars to receive the configuration. Defaults to 30 seconds.
+	Timeout time.Duration
+}
+
+// Resources to apply to a namespace with Instance.ApplyAll.
+type Resources struct {
+	// Namespace the resources are applied to, unless they specify one.
+	Namespace namespace.Instance
+	// YAML of the resources.
+	YAML []string
+}
+
+// Instance applies configuration and waits until it is accepted by Galley and distributed to the sidecars
+// of the Config. Unlike the configuration applied directly through Galley, the configuration applied through
+// the instance is deleted when it is closed, which happens when the context it was created in is done.
+type Instance interface {
+	resource.Resource
+
+	// Apply the given config YAML to the given namespace, and wait until it is distributed.
+	Apply(ns namespace.Instance, yamlText ...string) error
+	ApplyOrFail(t test.Failer, ns namespace.Instance, yamlText ...string)
+
+	// ApplyAll applies all of the given resources, possibly to different namespaces, in a single operation,
+	// and waits once until they are all distributed. This is much faster than applying them one by one.
+	ApplyAll(resources ...Resources) error
+	ApplyAllOrFail(t test.Failer, resources ...Resources)
+
+	// Delete the given config YAML from the given namespace, and wait until the deletion is distributed.
+	Delete(ns namespace.Instance, yamlText ...string) error
+	DeleteOrFail(t test.Failer, ns namespace.Instance, yamlText ...string)
+
+	// ApplyAndMeasure applies the given config YAML to the given namespace, and measures how long it takes
+	// each of the sidecars of the Config to accept it. Galley is not waited for, so that the latencies cover
+	// the whole path from the API server to the sidecars.
+	ApplyAndMeasure(ns namespace.Instance, yamlText ...string) (Propagation, error)
+	ApplyAndMeasureOrFail(t test.Failer, ns namespace.Instance, yamlText ...string) Propagation
+}
+
+// New returns a new instance of the config component.
+func New(ctx resource.Context, cfg Config) (Instance, error) {
+	return newConfig(ctx, cfg)
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("config.NewOrFail: %v", err)
+	}
+	return i
+}
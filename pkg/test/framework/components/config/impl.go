@@ -0,0 +1,346 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-multierror"
+
+	kubeMeta "istio.io/istio/galley/pkg/metadata/kube"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/galley"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/yml"
+)
+
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultNamespace = "default"
+
+	clustersConfigDumpType  = "type.googleapis.com/envoy.admin.v2alpha.ClustersConfigDump"
+	listenersConfigDumpType = "type.googleapis.com/envoy.admin.v2alpha.ListenersConfigDump"
+)
+
+var (
+	_ Instance  = &configImpl{}
+	_ io.Closer = &configImpl{}
+)
+
+// applied config of the instance, deleted on Close.
+type applied struct {
+	ns       namespace.Instance
+	yamlText string
+}
+
+type configImpl struct {
+	id  resource.ID
+	cfg Config
+
+	mutex   sync.Mutex
+	applied []applied
+}
+
+func newConfig(ctx resource.Context, cfg Config) (Instance, error) {
+	if cfg.Galley == nil {
+		return nil, fmt.Errorf("config: Galley is required")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	c := &configImpl{
+		cfg: cfg,
+	}
+	c.id = ctx.TrackResource(c)
+	return c, nil
+}
+
+func (c *configImpl) ID() resource.ID {
+	return c.id
+}
+
+// Apply implements Instance.
+func (c *configImpl) Apply(ns namespace.Instance, yamlText ...string) error {
+	versions, err := c.sidecarVersions()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cfg.Galley.ApplyConfig(ns, yamlText...); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	for _, y := range yamlText {
+		c.applied = append(c.applied, applied{ns: ns, yamlText: y})
+	}
+	c.mutex.Unlock()
+
+	if err := c.waitForGalley(ns, true, yamlText...); err != nil {
+		return err
+	}
+	return c.waitForSidecars(versions)
+}
+
+// ApplyOrFail implements Instance.
+func (c *configImpl) ApplyOrFail(t test.Failer, ns namespace.Instance, yamlText ...string) {
+	t.Helper()
+	if err := c.Apply(ns, yamlText...); err != nil {
+		t.Fatalf("config.ApplyOrFail: %v", err)
+	}
+}
+
+// ApplyAll implements Instance.
+func (c *configImpl) ApplyAll(resources ...Resources) error {
+	versions, err := c.sidecarVersions()
+	if err != nil {
+		return err
+	}
+
+	// Set the namespaces in the resources themselves, so they can be applied together.
+	var all []string
+	for _, r := range resources {
+		for _, y := range r.YAML {
+			if r.Namespace != nil {
+				if y, err = yml.ApplyNamespace(y, r.Namespace.Name()); err != nil {
+					return err
+				}
+			}
+			all = append(all, y)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	if err := c.cfg.Galley.ApplyConfig(nil, all...); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	for _, y := range all {
+		c.applied = append(c.applied, applied{yamlText: y})
+	}
+	c.mutex.Unlock()
+
+	if err := c.waitForGalley(nil, true, all...); err != nil {
+		return err
+	}
+	return c.waitForSidecars(versions)
+}
+
+// ApplyAllOrFail implements Instance.
+func (c *configImpl) ApplyAllOrFail(t test.Failer, resources ...Resources) {
+	t.Helper()
+	if err := c.ApplyAll(resources...); err != nil {
+		t.Fatalf("config.ApplyAllOrFail: %v", err)
+	}
+}
+
+// Delete implements Instance.
+func (c *configImpl) Delete(ns namespace.Instance, yamlText ...string) error {
+	versions, err := c.sidecarVersions()
+	if err != nil {
+		return err
+	}
+
+	if err := c.cfg.Galley.DeleteConfig(ns, yamlText...); err != nil {
+		return err
+	}
+	c.forget(ns, yamlText...)
+
+	if err := c.waitForGalley(ns, false, yamlText...); err != nil {
+		return err
+	}
+	return c.waitForSidecars(versions)
+}
+
+// DeleteOrFail implements Instance.
+func (c *configImpl) DeleteOrFail(t test.Failer, ns namespace.Instance, yamlText ...string) {
+	t.Helper()
+	if err := c.Delete(ns, yamlText...); err != nil {
+		t.Fatalf("config.DeleteOrFail: %v", err)
+	}
+}
+
+// Close deletes the config applied through the instance, most recent first. The deletion is not waited for.
+func (c *configImpl) Close() (err error) {
+	c.mutex.Lock()
+	toDelete := c.applied
+	c.applied = nil
+	c.mutex.Unlock()
+
+	for i := len(toDelete) - 1; i >= 0; i-- {
+		a := toDelete[i]
+		err = multierror.Append(err, c.cfg.Galley.DeleteConfig(a.ns, a.yamlText)).ErrorOrNil()
+	}
+	return
+}
+
+func (c *configImpl) forget(ns namespace.Instance, yamlText ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, y := range yamlText {
+		for i, a := range c.applied {
+			if a.ns == ns && a.yamlText == y {
+				c.applied = append(c.applied[:i], c.applied[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// waitForGalley waits until the resources of the given config are present in, or absent from, the snapshots
+// served by Galley. Resources that are not served by Galley are skipped.
+func (c *configImpl) waitForGalley(ns namespace.Instance, present bool, yamlText ...string) error {
+	for _, y := range yamlText {
+		parts, err := yml.Parse(y)
+		if err != nil {
+			return err
+		}
+		for _, part := range parts {
+			d := part.Descriptor
+			collection, ok := collectionFor(d)
+			if !ok {
+				continue
+			}
+
+			name := d.Metadata.Name
+			nsName := d.Metadata.Namespace
+			if nsName == "" && ns != nil {
+				nsName = ns.Name()
+			}
+			if nsName == "" {
+				nsName = defaultNamespace
+			}
+			fullName := nsName + "/" + name
+
+			if err := c.cfg.Galley.WaitForSnapshot(collection, func(actuals []*galley.SnapshotObject) error {
+				found := false
+				for _, a := range actuals {
+					// Cluster scoped resources are served without a namespace.
+					if a.Metadata.Name == fullName || a.Metadata.Name == name {
+						found = true
+						break
+					}
+				}
+				if found != present {
+					return fmt.Errorf("%s %s: expected present=%v in the Galley snapshot", d.Kind, fullName, present)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			scopes.Framework.Debugf("Galley snapshot of %s updated for %s %s", collection, d.Kind, fullName)
+		}
+	}
+	return nil
+}
+
+func collectionFor(d yml.Descriptor) (string, bool) {
+	for _, spec := range kubeMeta.Types.All() {
+		if spec.Kind == d.Kind && spec.Group == d.Group {
+			return spec.Target.Collection.String(), true
+		}
+	}
+	return "", false
+}
+
+// sidecarVersions returns the versions of the clusters and listeners currently used by the sidecars of the
+// WaitFor instances, keyed by the node ID of the sidecar.
+func (c *configImpl) sidecarVersions() (map[string]string, error) {
+	versions := make(map[string]string)
+	for _, s := range c.sidecars() {
+		dump, err := s.Config()
+		if err != nil {
+			return nil, err
+		}
+		v, err := xdsVersion(dump)
+		if err != nil {
+			return nil, err
+		}
+		versions[s.NodeID()] = v
+	}
+	return versions, nil
+}
+
+// waitForSidecars waits until all the sidecars of the WaitFor instances have been pushed a new version of
+// their clusters and listeners by Pilot.
+func (c *configImpl) waitForSidecars(versions map[string]string) error {
+	for _, s := range c.sidecars() {
+		previous := versions[s.NodeID()]
+		if err := s.WaitForConfig(func(dump *envoyAdmin.ConfigDump) (bool, error) {
+			v, err := xdsVersion(dump)
+			if err != nil {
+				return false, err
+			}
+			if v == previous {
+				return false, fmt.Errorf("sidecar %s: still at version %q", s.NodeID(), v)
+			}
+			return true, nil
+		}, retry.Timeout(c.cfg.Timeout)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *configImpl) sidecars() []echo.Sidecar {
+	var out []echo.Sidecar
+	for _, i := range c.cfg.WaitFor {
+		workloads, err := i.Workloads()
+		if err != nil {
+			scopes.Framework.Warnf("config: failed getting the workloads of %s: %v", i.Config().Service, err)
+			continue
+		}
+		for _, w := range workloads {
+			if w.Sidecar() != nil {
+				out = append(out, w.Sidecar())
+			}
+		}
+	}
+	return out
+}
+
+func xdsVersion(dump *envoyAdmin.ConfigDump) (string, error) {
+	clusters := ""
+	listeners := ""
+	for _, cfg := range dump.Configs {
+		switch cfg.TypeUrl {
+		case clustersConfigDumpType:
+			d := envoyAdmin.ClustersConfigDump{}
+			if err := ptypes.UnmarshalAny(cfg, &d); err != nil {
+				return "", err
+			}
+			clusters = d.VersionInfo
+		case listenersConfigDumpType:
+			d := envoyAdmin.ListenersConfigDump{}
+			if err := ptypes.UnmarshalAny(cfg, &d); err != nil {
+				return "", err
+			}
+			listeners = d.VersionInfo
+		}
+	}
+	return clusters + "/" + listeners, nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// pollInterval bounds the resolution of the measured propagation latencies.
+	pollInterval = 100 * time.Millisecond
+)
+
+// Latency of the propagation of configuration to a single sidecar.
+type Latency struct {
+	// NodeID of the sidecar.
+	NodeID string
+	// Latency from the configuration being applied until the sidecar was observed accepting a new version
+	// of its clusters or listeners. The resolution is limited by the time it takes to read the sidecar config.
+	Latency time.Duration
+}
+
+// Propagation of configuration to the sidecars of a config Instance.
+type Propagation struct {
+	// Applied is the time the configuration was applied.
+	Applied time.Time
+	// Latencies for each sidecar, slowest first.
+	Latencies []Latency
+}
+
+// Max returns the highest latency across the sidecars, or zero if there are none.
+func (p Propagation) Max() time.Duration {
+	if len(p.Latencies) == 0 {
+		return 0
+	}
+	return p.Latencies[0].Latency
+}
+
+// Percentile returns the latency under which the given percentage (0-100) of the sidecars received the
+// configuration.
+func (p Propagation) Percentile(percent float64) time.Duration {
+	if len(p.Latencies) == 0 {
+		return 0
+	}
+	// Latencies are sorted slowest first, so count the sidecars above the percentile from the start.
+	above := int(float64(len(p.Latencies)) * (100 - percent) / 100)
+	if above >= len(p.Latencies) {
+		above = len(p.Latencies) - 1
+	}
+	return p.Latencies[above].Latency
+}
+
+// CheckWithin returns an error listing the sidecars that took longer than budget to receive the configuration.
+func (p Propagation) CheckWithin(budget time.Duration) error {
+	var slow []string
+	for _, l := range p.Latencies {
+		if l.Latency > budget {
+			slow = append(slow, fmt.Sprintf("%s (%v)", l.NodeID, l.Latency))
+		}
+	}
+	if len(slow) > 0 {
+		return fmt.Errorf("config propagation exceeded the budget of %v for %d/%d sidecars: %s",
+			budget, len(slow), len(p.Latencies), strings.Join(slow, ", "))
+	}
+	return nil
+}
+
+// CheckWithinOrFail calls CheckWithin and fails t if an error occurs.
+func (p Propagation) CheckWithinOrFail(t test.Failer, budget time.Duration) {
+	t.Helper()
+	if err := p.CheckWithin(budget); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// String returns a summary of the propagation latencies, suitable for logging.
+func (p Propagation) String() string {
+	sb := strings.Builder{}
+	_, _ = fmt.Fprintf(&sb, "config propagated to %d sidecars: max=%v p90=%v p50=%v",
+		len(p.Latencies), p.Max(), p.Percentile(90), p.Percentile(50))
+	for _, l := range p.Latencies {
+		_, _ = fmt.Fprintf(&sb, "\n  %s: %v", l.NodeID, l.Latency)
+	}
+	return sb.String()
+}
+
+// ApplyAndMeasure implements Instance.
+func (c *configImpl) ApplyAndMeasure(ns namespace.Instance, yamlText ...string) (Propagation, error) {
+	sidecars := c.sidecars()
+	versions, err := c.sidecarVersions()
+	if err != nil {
+		return Propagation{}, err
+	}
+
+	start := time.Now()
+	if err := c.cfg.Galley.ApplyConfig(ns, yamlText...); err != nil {
+		return Propagation{}, err
+	}
+	c.mutex.Lock()
+	for _, y := range yamlText {
+		c.applied = append(c.applied, applied{ns: ns, yamlText: y})
+	}
+	c.mutex.Unlock()
+
+	p, err := measurePropagation(start, sidecars, versions, c.cfg.Timeout)
+	if err != nil {
+		return p, err
+	}
+	scopes.Framework.Infof("%v", p)
+	return p, nil
+}
+
+// ApplyAndMeasureOrFail implements Instance.
+func (c *configImpl) ApplyAndMeasureOrFail(t test.Failer, ns namespace.Instance, yamlText ...string) Propagation {
+	t.Helper()
+	p, err := c.ApplyAndMeasure(ns, yamlText...)
+	if err != nil {
+		t.Fatalf("config.ApplyAndMeasureOrFail: %v", err)
+	}
+	return p
+}
+
+// measurePropagation polls all of the sidecars concurrently until each has moved away from its previous
+// version, and records when that was first observed.
+func measurePropagation(start time.Time, sidecars []echo.Sidecar, versions map[string]string,
+	timeout time.Duration) (Propagation, error) {
+	p := Propagation{Applied: start}
+
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	var errs error
+	for _, s := range sidecars {
+		s := s
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l, err := waitForNewVersion(start, s, versions[s.NodeID()], timeout)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				return
+			}
+			p.Latencies = append(p.Latencies, l)
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(p.Latencies, func(i, j int) bool {
+		if p.Latencies[i].Latency != p.Latencies[j].Latency {
+			return p.Latencies[i].Latency > p.Latencies[j].Latency
+		}
+		return p.Latencies[i].NodeID < p.Latencies[j].NodeID
+	})
+	return p, errs
+}
+
+func waitForNewVersion(start time.Time, s echo.Sidecar, previous string, timeout time.Duration) (Latency, error) {
+	deadline := start.Add(timeout)
+	for {
+		dump, err := s.Config()
+		if err == nil {
+			var v string
+			if v, err = xdsVersion(dump); err == nil && v != previous {
+				return Latency{NodeID: s.NodeID(), Latency: time.Since(start)}, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return Latency{}, fmt.Errorf("sidecar %s: config not received within %v: %v", s.NodeID(), timeout, err)
+			}
+			return Latency{}, fmt.Errorf("sidecar %s: still at version %q after %v", s.NodeID(), previous, timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPropagation(t *testing.T) {
+	p := Propagation{
+		Latencies: []Latency{
+			{NodeID: "d", Latency: 4 * time.Second},
+			{NodeID: "c", Latency: 3 * time.Second},
+			{NodeID: "b", Latency: 2 * time.Second},
+			{NodeID: "a", Latency: time.Second},
+		},
+	}
+
+	if got := p.Max(); got != 4*time.Second {
+		t.Fatalf("Max: got %v", got)
+	}
+	if got := p.Percentile(50); got != 2*time.Second {
+		t.Fatalf("Percentile(50): got %v", got)
+	}
+	if got := p.Percentile(100); got != 4*time.Second {
+		t.Fatalf("Percentile(100): got %v", got)
+	}
+	if got := p.Percentile(0); got != time.Second {
+		t.Fatalf("Percentile(0): got %v", got)
+	}
+	if err := p.CheckWithin(4 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CheckWithin(2 * time.Second); err == nil {
+		t.Fatal("expected the budget to be exceeded")
+	}
+	if got := (Propagation{}).Max(); got != 0 {
+		t.Fatalf("Max of empty: got %v", got)
+	}
+}
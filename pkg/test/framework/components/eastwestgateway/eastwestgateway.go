@@ -0,0 +1,116 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eastwestgateway provides the gateway through which workloads on other networks of a multi-network
+// mesh reach the workloads of a cluster.
+package eastwestgateway
+
+import (
+	"net"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	// CrossNetworkPort is the port on which the gateway passes mTLS traffic from other networks through to the
+	// workloads, routed by SNI.
+	CrossNetworkPort = 15443
+)
+
+// Config for the east-west gateway component.
+type Config struct {
+	Istio istio.Instance
+
+	// Network of the workloads behind the gateway. Required. The workloads of the cluster must be configured
+	// with the same network, e.g. through the global.network Helm value.
+	Network string
+
+	// Cluster where the gateway is deployed. If empty, the primary cluster is used.
+	Cluster string
+
+	// Registry is the name of the service registry that holds the endpoints of the network. Defaults to the
+	// Kubernetes registry for the primary cluster, and to the cluster name for remote clusters.
+	Registry string
+}
+
+// Instance is an east-west gateway. While the instance exists, the mesh networks of the control plane map the
+// network of the instance to the external address of the gateway. The gateway and its network are removed
+// when the instance is closed.
+type Instance interface {
+	resource.Resource
+
+	// Network of the workloads behind the gateway.
+	Network() string
+
+	// Cluster where the gateway is deployed.
+	Cluster() string
+
+	// Address returns the external address of the cross-network port of the gateway (or the NodePort address,
+	// when running under Minikube).
+	Address() (net.TCPAddr, error)
+
+	// WaitUntilReady waits until the gateway is running, has an external address, and listens on the
+	// cross-network port.
+	WaitUntilReady() error
+	WaitUntilReadyOrFail(t test.Failer)
+}
+
+// New deploys a new east-west gateway and adds its network to the mesh networks.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("eastwestgateway.NewOrFail: %v", err)
+	}
+	return i
+}
+
+// Setup is a setup function that deploys an east-west gateway for each of the given configs and waits until
+// they are ready. The Istio instance of each config defaults to the given one.
+func Setup(gateways *[]Instance, ist *istio.Instance, cfgs ...Config) resource.SetupFn {
+	return func(ctx resource.Context) error {
+		out := make([]Instance, 0, len(cfgs))
+		for _, cfg := range cfgs {
+			if cfg.Istio == nil && ist != nil {
+				cfg.Istio = *ist
+			}
+			gw, err := New(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			out = append(out, gw)
+		}
+		for _, gw := range out {
+			if err := gw.WaitUntilReady(); err != nil {
+				return err
+			}
+		}
+		if gateways != nil {
+			*gateways = out
+		}
+		return nil
+	}
+}
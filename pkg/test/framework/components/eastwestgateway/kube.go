@@ -0,0 +1,454 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eastwestgateway
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/core/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	k "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+	"istio.io/istio/pkg/util/gogoprotomarshal"
+)
+
+const (
+	serviceName        = "istio-eastwestgateway"
+	istioLabel         = "eastwestgateway"
+	proxyContainerName = "istio-proxy"
+	proxyAdminPort     = 15000
+
+	// localRegistry is the name of the service registry of the cluster the control plane runs in.
+	localRegistry = "Kubernetes"
+
+	meshConfigMapName = "istio"
+	meshNetworksKey   = "meshNetworks"
+
+	deploymentYAML = `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{ .Service }}-service-account
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Service }}
+  labels:
+    app: {{ .Service }}
+    istio: {{ .IstioLabel }}
+spec:
+  type: LoadBalancer
+  selector:
+    istio: {{ .IstioLabel }}
+  ports:
+  - name: tls
+    port: {{ .Port }}
+    targetPort: {{ .Port }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Service }}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      istio: {{ .IstioLabel }}
+  template:
+    metadata:
+      labels:
+        app: {{ .Service }}
+        istio: {{ .IstioLabel }}
+      annotations:
+        sidecar.istio.io/inject: "false"
+    spec:
+      serviceAccountName: {{ .Service }}-service-account
+      containers:
+      - name: istio-proxy
+        image: {{ .Hub }}/proxyv2:{{ .Tag }}
+        imagePullPolicy: {{ .PullPolicy }}
+        ports:
+        - containerPort: {{ .Port }}
+        - containerPort: 15020
+        args:
+        - proxy
+        - router
+        - --domain
+        - $(POD_NAMESPACE).svc.cluster.local
+        - --serviceCluster
+        - {{ .Service }}
+        - --proxyAdminPort
+        - "15000"
+        - --statusPort
+        - "15020"
+        - --controlPlaneAuthPolicy
+        - MUTUAL_TLS
+        - --discoveryAddress
+        - istio-pilot.{{ .ConfigNamespace }}:15011
+        readinessProbe:
+          failureThreshold: 30
+          httpGet:
+            path: /healthz/ready
+            port: 15020
+            scheme: HTTP
+          initialDelaySeconds: 1
+          periodSeconds: 2
+          successThreshold: 1
+          timeoutSeconds: 1
+        env:
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: POD_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: INSTANCE_IP
+          valueFrom:
+            fieldRef:
+              fieldPath: status.podIP
+        - name: SERVICE_ACCOUNT
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.serviceAccountName
+        - name: ISTIO_META_POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: ISTIO_META_CONFIG_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: ISTIO_META_NETWORK
+          value: {{ printf "%q" .Network }}
+        - name: ISTIO_META_CLUSTER_ID
+          value: {{ printf "%q" .Registry }}
+        - name: ISTIO_METAJSON_LABELS
+          value: '{"app":"{{ .Service }}","istio":"{{ .IstioLabel }}"}'
+        volumeMounts:
+        - name: istio-certs
+          mountPath: /etc/certs
+          readOnly: true
+      volumes:
+      - name: istio-certs
+        secret:
+          secretName: istio.{{ .Service }}-service-account
+          optional: true
+`
+
+	// gatewayYAML passes traffic for the services of the mesh through to the workloads, based on the SNI set by
+	// the client sidecars.
+	gatewayYAML = `
+apiVersion: networking.istio.io/v1alpha3
+kind: Gateway
+metadata:
+  name: {{ .Service }}
+spec:
+  selector:
+    istio: {{ .IstioLabel }}
+  servers:
+  - port:
+      number: {{ .Port }}
+      name: tls
+      protocol: TLS
+    tls:
+      mode: AUTO_PASSTHROUGH
+    hosts:
+    - "*.local"
+`
+)
+
+var (
+	retryTimeout = retry.Timeout(5 * time.Minute)
+	retryDelay   = retry.Delay(5 * time.Second)
+
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id resource.ID
+
+	network   string
+	cluster   string
+	registry  string
+	namespace string
+	minikube  bool
+
+	// accessor is for the cluster of the gateway, primary for the cluster of the control plane.
+	accessor *k.Accessor
+	primary  *k.Accessor
+
+	// systemNamespace holds the mesh networks config map of the control plane.
+	systemNamespace string
+
+	deploymentYAML string
+	gatewayYAML    string
+
+	// previousNetwork is the mesh networks entry replaced by the gateway, restored when it is closed.
+	previousNetwork *meshconfig.Network
+	networkAdded    bool
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	if cfg.Istio == nil {
+		return nil, errors.New("eastwestgateway: Istio must be set")
+	}
+	if cfg.Network == "" {
+		return nil, errors.New("eastwestgateway: Network must be set")
+	}
+
+	env := ctx.Environment().(*kube.Environment)
+	accessor, err := env.Cluster(cfg.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	cluster := cfg.Cluster
+	if cluster == "" {
+		cluster = env.PrimaryClusterName()
+	}
+	registry := cfg.Registry
+	if registry == "" {
+		registry = cluster
+		if cluster == env.PrimaryClusterName() {
+			registry = localRegistry
+		}
+	}
+
+	settings, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	istioCfg := cfg.Istio.Settings()
+	params := map[string]interface{}{
+		"Service":         serviceName,
+		"IstioLabel":      istioLabel,
+		"Port":            CrossNetworkPort,
+		"Hub":             settings.Hub,
+		"Tag":             settings.Tag,
+		"PullPolicy":      settings.PullPolicy,
+		"ConfigNamespace": istioCfg.ConfigNamespace,
+		"Network":         cfg.Network,
+		"Registry":        registry,
+	}
+
+	c := &kubeComponent{
+		network:         cfg.Network,
+		cluster:         cluster,
+		registry:        registry,
+		namespace:       istioCfg.IngressNamespace,
+		minikube:        env.Settings().Minikube,
+		accessor:        accessor,
+		primary:         env.Accessor,
+		systemNamespace: istioCfg.SystemNamespace,
+	}
+	if c.deploymentYAML, err = tmpl.Evaluate(deploymentYAML, params); err != nil {
+		return nil, err
+	}
+	if c.gatewayYAML, err = tmpl.Evaluate(gatewayYAML, params); err != nil {
+		return nil, err
+	}
+	c.id = ctx.TrackResource(c)
+
+	if _, err = c.accessor.ApplyContents(c.namespace, c.deploymentYAML); err != nil {
+		return nil, err
+	}
+	// The Gateway is configuration of the control plane, which runs in the primary cluster.
+	if _, err = c.primary.ApplyContents(c.namespace, c.gatewayYAML); err != nil {
+		return nil, err
+	}
+
+	// Route the traffic for the endpoints of the network to the external address of the gateway.
+	address, err := c.Address()
+	if err != nil {
+		return nil, err
+	}
+	if err = c.addNetwork(address); err != nil {
+		return nil, err
+	}
+	scopes.Framework.Infof("East-west gateway for network %s in cluster %s is at %s", c.network, c.cluster, address.String())
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Network() string {
+	return c.network
+}
+
+func (c *kubeComponent) Cluster() string {
+	return c.cluster
+}
+
+func (c *kubeComponent) Address() (net.TCPAddr, error) {
+	address, err := retry.Do(c.getAddressInner, retryTimeout, retryDelay)
+	if err != nil {
+		return net.TCPAddr{}, err
+	}
+	return address.(net.TCPAddr), nil
+}
+
+// getAddressInner returns the external address of the cross-network port.
+func (c *kubeComponent) getAddressInner() (interface{}, bool, error) {
+	svc, err := c.accessor.GetService(c.namespace, serviceName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// In Minikube, load balancers are not available. Use the node port instead.
+	if c.minikube {
+		pods, err := c.accessor.GetPods(c.namespace, fmt.Sprintf("istio=%s", istioLabel))
+		if err != nil {
+			return nil, false, err
+		}
+		if len(pods) == 0 || pods[0].Status.HostIP == "" {
+			return nil, false, fmt.Errorf("no Host IP available on the east-west gateway node yet")
+		}
+		for _, svcPort := range svc.Spec.Ports {
+			if svcPort.Port == CrossNetworkPort && svcPort.NodePort != 0 {
+				return net.TCPAddr{IP: net.ParseIP(pods[0].Status.HostIP), Port: int(svcPort.NodePort)}, true, nil
+			}
+		}
+		return nil, false, fmt.Errorf("no node port for %d found in service: %s/%s", CrossNetworkPort, c.namespace, serviceName)
+	}
+
+	if len(svc.Status.LoadBalancer.Ingress) == 0 || svc.Status.LoadBalancer.Ingress[0].IP == "" {
+		return nil, false, fmt.Errorf("service ingress is not available yet: %s/%s", svc.Namespace, svc.Name)
+	}
+	return net.TCPAddr{IP: net.ParseIP(svc.Status.LoadBalancer.Ingress[0].IP), Port: CrossNetworkPort}, true, nil
+}
+
+func (c *kubeComponent) WaitUntilReady() error {
+	pods, err := c.accessor.WaitUntilPodsAreReady(c.accessor.NewPodFetch(c.namespace, "istio="+istioLabel))
+	if err != nil {
+		return err
+	}
+	if _, err := c.Address(); err != nil {
+		return err
+	}
+
+	// The listener is only created once the control plane has pushed the Gateway.
+	pod := pods[0]
+	return retry.UntilSuccess(func() error {
+		command := fmt.Sprintf("curl http://127.0.0.1:%d/listeners", proxyAdminPort)
+		listeners, err := c.accessor.Exec(pod.Namespace, pod.Name, proxyContainerName, command)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(listeners, fmt.Sprintf(":%d", CrossNetworkPort)) {
+			return fmt.Errorf("east-west gateway %s/%s has no listener on port %d yet", pod.Namespace, pod.Name, CrossNetworkPort)
+		}
+		return nil
+	}, retryTimeout, retryDelay)
+}
+
+func (c *kubeComponent) WaitUntilReadyOrFail(t test.Failer) {
+	t.Helper()
+	if err := c.WaitUntilReady(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addNetwork maps the registry of the cluster to the network, reachable through the given gateway address.
+func (c *kubeComponent) addNetwork(address net.TCPAddr) error {
+	return c.updateMeshNetworks(func(networks *meshconfig.MeshNetworks) {
+		c.previousNetwork = networks.Networks[c.network]
+		c.networkAdded = true
+		networks.Networks[c.network] = &meshconfig.Network{
+			Endpoints: []*meshconfig.Network_NetworkEndpoints{
+				{
+					Ne: &meshconfig.Network_NetworkEndpoints_FromRegistry{
+						FromRegistry: c.registry,
+					},
+				},
+			},
+			Gateways: []*meshconfig.Network_IstioNetworkGateway{
+				{
+					Gw: &meshconfig.Network_IstioNetworkGateway_Address{
+						Address: address.IP.String(),
+					},
+					Port: uint32(address.Port),
+				},
+			},
+		}
+	})
+}
+
+// updateMeshNetworks applies the given change to the mesh networks of the control plane.
+func (c *kubeComponent) updateMeshNetworks(update func(networks *meshconfig.MeshNetworks)) error {
+	configMaps := c.primary.GetConfigMap(c.systemNamespace)
+	cm, err := configMaps.Get(meshConfigMapName, kubeApiMeta.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed reading config map %s/%s: %v", c.systemNamespace, meshConfigMapName, err)
+	}
+	networks, err := mesh.LoadMeshNetworksConfig(cm.Data[meshNetworksKey])
+	if err != nil {
+		return err
+	}
+
+	update(networks)
+
+	out, err := gogoprotomarshal.ToYAML(networks)
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[meshNetworksKey] = out
+	if _, err = configMaps.Update(cm); err != nil {
+		return fmt.Errorf("failed updating config map %s/%s: %v", c.systemNamespace, meshConfigMapName, err)
+	}
+	return nil
+}
+
+func (c *kubeComponent) Close() (err error) {
+	if c.networkAdded {
+		err = multierror.Append(err, c.updateMeshNetworks(func(networks *meshconfig.MeshNetworks) {
+			if c.previousNetwork != nil {
+				networks.Networks[c.network] = c.previousNetwork
+			} else {
+				delete(networks.Networks, c.network)
+			}
+		})).ErrorOrNil()
+		c.networkAdded = false
+	}
+	if c.gatewayYAML != "" {
+		err = multierror.Append(err, c.primary.DeleteContents(c.namespace, c.gatewayYAML)).ErrorOrNil()
+	}
+	if c.deploymentYAML != "" {
+		err = multierror.Append(err, c.accessor.DeleteContents(c.namespace, c.deploymentYAML)).ErrorOrNil()
+	}
+	return
+}
@@ -19,7 +19,12 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/go-multierror"
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"istio.io/api/annotation"
+
+	"istio.io/istio/pkg/kube/inject"
 )
 
 type AnnotationType string
@@ -50,7 +55,10 @@ var (
 	SidecarTrafficExcludeOutboundIPRanges = workloadAnnotation(annotation.SidecarTrafficExcludeOutboundIPRanges.Name, "")
 	SidecarTrafficIncludeInboundPorts     = workloadAnnotation(annotation.SidecarTrafficIncludeInboundPorts.Name, "")
 	SidecarTrafficExcludeInboundPorts     = workloadAnnotation(annotation.SidecarTrafficExcludeInboundPorts.Name, "")
+	SidecarTrafficExcludeOutboundPorts    = workloadAnnotation(annotation.SidecarTrafficExcludeOutboundPorts.Name, "")
 	SidecarTrafficKubeVirtInterfaces      = workloadAnnotation(annotation.SidecarTrafficKubevirtInterfaces.Name, "")
+	SidecarProxyCPU                       = workloadAnnotation(annotation.SidecarProxyCPU.Name, "")
+	SidecarProxyMemory                    = workloadAnnotation(annotation.SidecarProxyMemory.Name, "")
 
 	KubeServiceAccountsOnVMA = serviceAnnotation(annotation.AlphaKubernetesServiceAccounts.Name, "")
 	CanonicalServiceAccounts = serviceAnnotation(annotation.AlphaCanonicalServiceAccounts.Name, "")
@@ -58,6 +66,17 @@ var (
 	WorkloadIdentity         = workloadAnnotation(annotation.AlphaIdentity.Name, "")
 )
 
+// annotationValidators validate the values of the annotations that have a well known format, by name.
+var annotationValidators = map[string]func(string) error{
+	SidecarTrafficIncludeOutboundIPRanges.Name: inject.ValidateIncludeIPRanges,
+	SidecarTrafficExcludeOutboundIPRanges.Name: inject.ValidateExcludeIPRanges,
+	SidecarTrafficIncludeInboundPorts.Name:     inject.ValidateIncludeInboundPorts,
+	SidecarTrafficExcludeInboundPorts.Name:     inject.ValidateExcludeInboundPorts,
+	SidecarTrafficExcludeOutboundPorts.Name:    inject.ValidateExcludeOutboundPorts,
+	SidecarProxyCPU.Name:                       validateQuantity,
+	SidecarProxyMemory.Name:                    validateQuantity,
+}
+
 type AnnotationValue struct {
 	Value string
 }
@@ -134,6 +153,62 @@ func (a Annotations) SetInt(k Annotation, v int) Annotations {
 	return a
 }
 
+// IncludeOutboundIPRanges limits the outbound traffic captured by the sidecar to the given CIDRs, or "*" for
+// all traffic.
+func (a Annotations) IncludeOutboundIPRanges(cidrs ...string) Annotations {
+	return a.Set(SidecarTrafficIncludeOutboundIPRanges, strings.Join(cidrs, ","))
+}
+
+// ExcludeOutboundIPRanges excludes outbound traffic to the given CIDRs from capture by the sidecar.
+func (a Annotations) ExcludeOutboundIPRanges(cidrs ...string) Annotations {
+	return a.Set(SidecarTrafficExcludeOutboundIPRanges, strings.Join(cidrs, ","))
+}
+
+// IncludeInboundPorts limits the inbound traffic captured by the sidecar to the given ports.
+func (a Annotations) IncludeInboundPorts(ports ...int) Annotations {
+	return a.Set(SidecarTrafficIncludeInboundPorts, joinPorts(ports))
+}
+
+// IncludeAllInboundPorts captures the inbound traffic on all ports.
+func (a Annotations) IncludeAllInboundPorts() Annotations {
+	return a.Set(SidecarTrafficIncludeInboundPorts, "*")
+}
+
+// ExcludeInboundPorts excludes inbound traffic on the given ports from capture by the sidecar.
+func (a Annotations) ExcludeInboundPorts(ports ...int) Annotations {
+	return a.Set(SidecarTrafficExcludeInboundPorts, joinPorts(ports))
+}
+
+// ExcludeOutboundPorts excludes outbound traffic to the given ports from capture by the sidecar.
+func (a Annotations) ExcludeOutboundPorts(ports ...int) Annotations {
+	return a.Set(SidecarTrafficExcludeOutboundPorts, joinPorts(ports))
+}
+
+// ProxyResources sets the CPU and memory requested by the sidecar, as Kubernetes quantities (e.g. "100m",
+// "128Mi"). Empty values are left unset.
+func (a Annotations) ProxyResources(cpu, memory string) Annotations {
+	if cpu != "" {
+		a.Set(SidecarProxyCPU, cpu)
+	}
+	if memory != "" {
+		a.Set(SidecarProxyMemory, memory)
+	}
+	return a
+}
+
+// Validate the values of the annotations with a well known format, the same way the sidecar injector does.
+func (a Annotations) Validate() error {
+	var err error
+	for k, v := range a {
+		if validate, ok := annotationValidators[k.Name]; ok {
+			if e := validate(v.Value); e != nil {
+				err = multierror.Append(err, fmt.Errorf("invalid value %q for annotation %s: %v", v.Value, k.Name, e))
+			}
+		}
+	}
+	return err
+}
+
 func (a Annotations) getOrDefault(k Annotation) *AnnotationValue {
 	anno, ok := a[k]
 	if !ok {
@@ -171,3 +246,16 @@ func toInt(v string) int {
 	}
 	return i
 }
+
+func joinPorts(ports []int) string {
+	out := make([]string, 0, len(ports))
+	for _, p := range ports {
+		out = append(out, strconv.Itoa(p))
+	}
+	return strings.Join(out, ",")
+}
+
+func validateQuantity(v string) error {
+	_, err := resource.ParseQuantity(v)
+	return err
+}
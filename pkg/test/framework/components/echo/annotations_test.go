@@ -0,0 +1,63 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"testing"
+)
+
+func TestAnnotationHelpers(t *testing.T) {
+	a := NewAnnotations().
+		IncludeOutboundIPRanges("10.0.0.0/8", "172.16.0.0/12").
+		ExcludeInboundPorts(8080, 9090).
+		ProxyResources("100m", "")
+
+	if got := a.Get(SidecarTrafficIncludeOutboundIPRanges); got != "10.0.0.0/8,172.16.0.0/12" {
+		t.Fatalf("includeOutboundIPRanges: got %q", got)
+	}
+	if got := a.Get(SidecarTrafficExcludeInboundPorts); got != "8080,9090" {
+		t.Fatalf("excludeInboundPorts: got %q", got)
+	}
+	if got := a.Get(SidecarProxyCPU); got != "100m" {
+		t.Fatalf("proxyCPU: got %q", got)
+	}
+	if _, ok := a[SidecarProxyMemory]; ok {
+		t.Fatal("proxyMemory should not be set")
+	}
+	if err := a.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnnotationsValidate(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations Annotations
+	}{
+		{"cidr", NewAnnotations().IncludeOutboundIPRanges("10.0.0.0")},
+		{"port", NewAnnotations().Set(SidecarTrafficExcludeInboundPorts, "80,http")},
+		{"quantity", NewAnnotations().ProxyResources("", "128MB!")},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.annotations.Validate(); err == nil {
+				t.Fatal("expected validation to fail")
+			}
+		})
+	}
+	if err := NewAnnotations().IncludeAllInboundPorts().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
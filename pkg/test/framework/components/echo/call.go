@@ -15,9 +15,12 @@
 package echo
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"istio.io/istio/pkg/test/echo/client"
 	"istio.io/istio/pkg/test/echo/common/scheme"
 )
 
@@ -26,6 +29,11 @@ type CallOptions struct {
 	// Target instance of the call. Required.
 	Target Instance
 
+	// FromWorkload is the workload of the calling Instance that makes the call. It must be one of the
+	// workloads returned by Workloads. If nil, the first workload is used. The workload that made each
+	// request is reported as the SourceWorkload of the responses.
+	FromWorkload Workload
+
 	// Port on the target Instance. Either Port or PortName must be specified.
 	Port *Port
 
@@ -37,9 +45,14 @@ type CallOptions struct {
 	Scheme scheme.Instance
 
 	// Host specifies the host to be used on the request. If not provided, an appropriate
-	// default is chosen for the target Instance.
+	// default is chosen for the target Instance. The Host (or, for HTTP/2 and gRPC, the authority) sent
+	// with the request can be overridden separately with a "Host" entry in Headers.
 	Host string
 
+	// SNI is the TLS server name sent by calls over TLS. If empty, the Host header of the request is used.
+	// This allows the SNI to differ from the Host, e.g. for testing SNI based routing or authorization.
+	SNI string
+
 	// Path specifies the URL path for the request.
 	Path string
 
@@ -47,9 +60,155 @@ type CallOptions struct {
 	// If Count <= 0, defaults to 1.
 	Count int
 
-	// Headers indicates headers that should be sent in the request. Ignored for WebSocket calls.
+	// Headers indicates headers that should be sent in the request. For WebSocket calls, the headers are
+	// sent with the upgrade request. For gRPC calls, the headers are sent as metadata of every call, with
+	// all of their values, e.g. an authorization token or custom keys; the values of keys ending with "-bin"
+	// are binary. Unless an X-Request-Id is set, a unique one is generated for each attempt of the call.
 	Headers http.Header
 
+	// Concurrency is the maximum number of the Count requests of the call that are in flight at the same
+	// time. If zero, all requests are sent in parallel. The responses of a batch of requests can be
+	// summarized with ParsedResponses.Summary.
+	Concurrency int
+
+	// Message to be sent in the request. For WebSocket calls, each line of the message is sent as a
+	// separate frame over the same connection and echoed back by the server.
+	Message string
+
+	// HTTP2 makes calls with the http scheme use HTTP/2 with prior knowledge (h2c), and calls with the https
+	// scheme negotiate HTTP/2 (h2) through ALPN.
+	HTTP2 bool
+
+	// ALPN protocols offered for calls over TLS. If empty, the defaults of the scheme are used.
+	ALPN []string
+
+	// NewConnectionPerRequest makes each of the Count requests of the call open a connection of its own,
+	// so that authorization applied per connection (e.g. by TCP RBAC filters) is evaluated for every
+	// request. Cannot be combined with ReuseConnection.
+	NewConnectionPerRequest bool
+
+	// ReuseConnection sends the Count requests of the call one after another over a single pooled
+	// connection, so that only the first request opens a connection. This allows to tell authorization
+	// applied per request (e.g. by HTTP RBAC filters) from authorization applied per connection. Requests
+	// with the tcp scheme always open a connection of their own.
+	ReuseConnection bool
+
+	// StreamMessages is the number of messages exchanged over a stream by each gRPC call. If zero, unary
+	// calls are made. For HTTP and TCP calls, the server streams this number of server-sent events over a
+	// long-lived response instead, e.g. for testing idle timeouts or policy changes during a stream; the whole
+	// stream must complete within the Timeout. An HTTP stream that is reset is reported in StreamError only,
+	// a TCP stream in TCPResult.
+	StreamMessages int
+
+	// ServerStreaming makes streaming gRPC calls send a single request, to which the server responds with
+	// StreamMessages messages. Otherwise every message is a request/response exchange over a bidirectional
+	// stream.
+	ServerStreaming bool
+
+	// StreamInterval is the time between the messages of a stream.
+	StreamInterval time.Duration
+
+	// GRPCStatus reports the status of each gRPC call (code, message and details) in
+	// client.ParsedResponse.GRPCStatus. Unary calls failing with a status, e.g. PermissionDenied, then
+	// produce a response rather than failing the call, so that they can be told apart with check.GRPCCode.
+	GRPCStatus bool
+
+	// Cert is the PEM encoded client certificate presented by the echo client for calls over TLS
+	// (e.g. with the https or grpcs scheme). This allows the caller to originate mutual TLS itself,
+	// rather than relying on its sidecar. Requires Key.
+	Cert string
+
+	// Key is the PEM encoded private key for Cert.
+	Key string
+
+	// CACert is the PEM encoded root certificate used to verify the target. If empty, the certificate
+	// of the target is not verified.
+	CACert string
+
+	// Proxy is the URL of a forward proxy the echo client sends the requests through, instead of connecting
+	// to the target directly, e.g. "http://proxy.corp:3128" or "socks5://proxy.corp:1080". Credentials in the
+	// URL are sent to the proxy. HTTP proxies receive plain HTTP requests in absolute form, and tunnel all
+	// other requests with CONNECT, so the sidecar of the client only sees connections to the proxy. Supported
+	// for the http, https, grpc, grpcs, ws, wss and tcp schemes, but not with HTTP2.
+	Proxy string
+
 	// Timeout used for each individual request. Must be > 0, otherwise 30 seconds is used.
 	Timeout time.Duration
+
+	// RetryCount is the number of times the call is retried after a failed attempt. An attempt fails if the
+	// call returns an error, or if Validator rejects its result. If zero, the call is attempted only once,
+	// unless checkers are passed to Call.
+	RetryCount int
+
+	// RetryDelay is the time waited before the first retry. Defaults to 1 second.
+	RetryDelay time.Duration
+
+	// RetryBackoff multiplies the delay after each retry. Values <= 1 keep the delay constant.
+	RetryBackoff float64
+
+	// Validator of the result of an attempt: its responses or the error the call failed with. If it returns
+	// an error, the attempt is considered failed; otherwise the attempt succeeds, even if the call failed.
+	// If nil, an attempt fails only if the call fails.
+	Validator func(client.ParsedResponses, error) error
+}
+
+// CallAttempt is the outcome of a single attempt of a call.
+type CallAttempt struct {
+	// Number of the attempt, starting at 1.
+	Number int
+	// RequestID sent as the X-Request-Id of the attempt. If the attempt made multiple requests, the index of
+	// each request is appended (e.g. "<id>-0") to the IDs received by the server and logged by the proxies.
+	RequestID string
+	// Duration of the attempt, excluding the delay before it.
+	Duration time.Duration
+	// Err returned by the attempt, or nil if it succeeded.
+	Err error
+	// Kind of failure of the attempt, or NoFailure if it succeeded.
+	Kind FailureKind
+}
+
+func (a CallAttempt) String() string {
+	if a.Err == nil {
+		return fmt.Sprintf("attempt %d (request %s) succeeded in %v", a.Number, a.RequestID, a.Duration)
+	}
+	return fmt.Sprintf("attempt %d (request %s) failed in %v (%s): %v", a.Number, a.RequestID, a.Duration,
+		a.Kind, a.Err)
+}
+
+// CallError is returned by calls for which all of the attempts failed.
+type CallError struct {
+	// Call is a description of the call, e.g. "a->'http://b:80/path'", prepended to the error message if set.
+	Call string
+	// Attempts made, in order.
+	Attempts []CallAttempt
+}
+
+// Kind returns the kind of failure of the last attempt.
+func (e *CallError) Kind() FailureKind {
+	return ClassifyError(e)
+}
+
+// Last returns the error of the last attempt.
+func (e *CallError) Last() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+func (e *CallError) Error() string {
+	msg := ""
+	if len(e.Attempts) == 1 {
+		msg = fmt.Sprintf("request %s: %v", e.Attempts[0].RequestID, e.Last())
+	} else {
+		out := make([]string, 0, len(e.Attempts))
+		for _, a := range e.Attempts {
+			out = append(out, a.String())
+		}
+		msg = fmt.Sprintf("all %d attempts failed:\n  %s", len(e.Attempts), strings.Join(out, "\n  "))
+	}
+	if e.Call != "" {
+		return fmt.Sprintf("failed calling %s: %s", e.Call, msg)
+	}
+	return msg
 }
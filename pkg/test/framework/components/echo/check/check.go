@@ -0,0 +1,199 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package check provides composable validators for the results of echo calls.
+package check
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/go-multierror"
+	"google.golang.org/grpc/codes"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/response"
+)
+
+// Checker validates the result of a call: either its responses, or the error it failed with.
+type Checker func(client.ParsedResponses, error) error
+
+// Check the given result. A nil Checker accepts any result.
+func (c Checker) Check(resp client.ParsedResponses, err error) error {
+	if c == nil {
+		return nil
+	}
+	return c(resp, err)
+}
+
+// And returns a Checker that requires all of the given checkers to pass. Nil checkers are ignored.
+func And(checkers ...Checker) Checker {
+	return func(resp client.ParsedResponses, err error) error {
+		for _, c := range checkers {
+			if e := c.Check(resp, err); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+}
+
+// Or returns a Checker that requires any of the given checkers to pass.
+func Or(checkers ...Checker) Checker {
+	return func(resp client.ParsedResponses, err error) error {
+		var out error
+		for _, c := range checkers {
+			e := c.Check(resp, err)
+			if e == nil {
+				return nil
+			}
+			out = multierror.Append(out, e)
+		}
+		return out
+	}
+}
+
+// NoError requires the call to succeed, regardless of the responses.
+func NoError() Checker {
+	return func(_ client.ParsedResponses, err error) error {
+		return err
+	}
+}
+
+// Error requires the call to fail, e.g. because the connection was refused or reset.
+func Error() Checker {
+	return func(resp client.ParsedResponses, err error) error {
+		if err == nil {
+			return fmt.Errorf("expected the call to fail, but received %d responses", len(resp))
+		}
+		return nil
+	}
+}
+
+// Each requires the call to succeed, and each of its responses to match the given matcher.
+func Each(m client.Matcher) Checker {
+	return func(resp client.ParsedResponses, err error) error {
+		if err != nil {
+			return err
+		}
+		return resp.Check(m)
+	}
+}
+
+// Status requires the call to succeed with the given status code for all of the responses.
+func Status(code int) Checker {
+	expected := strconv.Itoa(code)
+	return Each(func(i int, r *client.ParsedResponse) error {
+		if r.Code != expected {
+			return fmt.Errorf("response[%d] status code: expected %s, received %s", i, expected, r.Code)
+		}
+		return nil
+	})
+}
+
+// OK requires the call to succeed with a 200 status code for all of the responses.
+func OK() Checker {
+	return Status(http.StatusOK)
+}
+
+// GRPCCode requires all gRPC calls to complete with the given status code. The calls must be made with
+// echo.CallOptions.GRPCStatus.
+func GRPCCode(code codes.Code) Checker {
+	return func(resp client.ParsedResponses, err error) error {
+		if err != nil {
+			return err
+		}
+		return resp.CheckGRPCCode(code)
+	}
+}
+
+// Denied requires the call to be rejected, either with a 403 status code or, for TCP and other
+// protocols that can't carry a status, by failing.
+func Denied() Checker {
+	return Or(Status(http.StatusForbidden), Error())
+}
+
+// MTLS requires all of the responses to be received by the server sidecar over mutual TLS.
+func MTLS() Checker {
+	return Each(func(i int, r *client.ParsedResponse) error {
+		if r.SourcePrincipal == "" {
+			return fmt.Errorf("response[%d]: no client certificate was verified, request was not mTLS", i)
+		}
+		return nil
+	})
+}
+
+// Plaintext requires none of the responses to be received over mutual TLS.
+func Plaintext() Checker {
+	return Each(func(i int, r *client.ParsedResponse) error {
+		if r.SourcePrincipal != "" {
+			return fmt.Errorf("response[%d]: expected plaintext, but received client principal %s",
+				i, r.SourcePrincipal)
+		}
+		return nil
+	})
+}
+
+// Locality requires all of the responses to be served by instances in the given locality.
+func Locality(locality string) Checker {
+	return func(resp client.ParsedResponses, err error) error {
+		if err != nil {
+			return err
+		}
+		return resp.CheckLocality(locality)
+	}
+}
+
+// ReachedLocalities requires each of the given localities to have served at least one of the responses.
+func ReachedLocalities(localities ...string) Checker {
+	return func(resp client.ParsedResponses, err error) error {
+		if err != nil {
+			return err
+		}
+		reached := resp.Localities()
+		for _, l := range localities {
+			if reached[l] == 0 {
+				return fmt.Errorf("locality %s was not reached, responses were served by %v", l, reached)
+			}
+		}
+		return nil
+	}
+}
+
+// IPFamily requires all of the requests to have been sent to an address of the given family.
+func IPFamily(family response.IPFamily) Checker {
+	return Each(func(i int, r *client.ParsedResponse) error {
+		if r.IPFamily != family {
+			return fmt.Errorf("response[%d] IP family: expected %s, received %s", i, family, r.IPFamily)
+		}
+		return nil
+	})
+}
+
+// ReachedClusters requires each of the given clusters to have served at least one of the responses.
+func ReachedClusters(clusters ...string) Checker {
+	return func(resp client.ParsedResponses, err error) error {
+		if err != nil {
+			return err
+		}
+		reached := resp.Clusters()
+		for _, c := range clusters {
+			if reached[c] == 0 {
+				return fmt.Errorf("cluster %s was not reached, responses were served by %v", c, reached)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package check
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/response"
+)
+
+func TestCheckers(t *testing.T) {
+	ok := client.ParsedResponses{
+		{Code: "200", Cluster: "c1", Locality: "r1.z1", IPFamily: response.IPv4, SourcePrincipal: "spiffe://cluster.local/ns/a/sa/a"},
+		{Code: "200", Cluster: "c2", Locality: "r1.z2", IPFamily: response.IPv4, SourcePrincipal: "spiffe://cluster.local/ns/a/sa/a"},
+	}
+	forbidden := client.ParsedResponses{{Code: "403"}}
+	grpcDenied := client.ParsedResponses{{GRPCStatus: &client.GRPCStatus{Code: codes.PermissionDenied}}}
+	callErr := errors.New("connection reset")
+
+	cases := []struct {
+		name    string
+		checker Checker
+		resp    client.ParsedResponses
+		err     error
+		pass    bool
+	}{
+		{"ok", OK(), ok, nil, true},
+		{"ok with error", OK(), nil, callErr, false},
+		{"ok with forbidden", OK(), forbidden, nil, false},
+		{"status", Status(403), forbidden, nil, true},
+		{"denied by status", Denied(), forbidden, nil, true},
+		{"denied by error", Denied(), nil, callErr, true},
+		{"denied with ok", Denied(), ok, nil, false},
+		{"grpc code", GRPCCode(codes.PermissionDenied), grpcDenied, nil, true},
+		{"wrong grpc code", GRPCCode(codes.Unauthenticated), grpcDenied, nil, false},
+		{"no grpc code", GRPCCode(codes.OK), ok, nil, false},
+		{"mtls", MTLS(), ok, nil, true},
+		{"plaintext", Plaintext(), ok, nil, false},
+		{"reached clusters", ReachedClusters("c1", "c2"), ok, nil, true},
+		{"missed cluster", ReachedClusters("c3"), ok, nil, false},
+		{"locality", Locality("r1.z1"), ok[:1], nil, true},
+		{"wrong locality", Locality("r1.z1"), ok, nil, false},
+		{"reached localities", ReachedLocalities("r1.z1", "r1.z2"), ok, nil, true},
+		{"missed locality", ReachedLocalities("r2.z1"), ok, nil, false},
+		{"ip family", IPFamily(response.IPv4), ok, nil, true},
+		{"wrong ip family", IPFamily(response.IPv6), ok, nil, false},
+		{"and", And(OK(), MTLS(), nil), ok, nil, true},
+		{"and fails", And(OK(), Plaintext()), ok, nil, false},
+		{"or", Or(Plaintext(), MTLS()), ok, nil, true},
+		{"or fails", Or(Plaintext(), Error()), ok, nil, false},
+		{"nil", nil, nil, callErr, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.checker.Check(c.resp, c.err)
+			if c.pass && err != nil {
+				t.Fatalf("expected to pass: %v", err)
+			}
+			if !c.pass && err == nil {
+				t.Fatal("expected to fail")
+			}
+		})
+	}
+}
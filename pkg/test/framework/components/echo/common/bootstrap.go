@@ -0,0 +1,38 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
+	"github.com/golang/protobuf/ptypes"
+)
+
+const bootstrapConfigDumpType = "type.googleapis.com/envoy.admin.v2alpha.BootstrapConfigDump"
+
+// BootstrapFromConfigDump returns the bootstrap configuration of Envoy from its config dump.
+func BootstrapFromConfigDump(cfg *envoyAdmin.ConfigDump) (*envoyAdmin.BootstrapConfigDump, error) {
+	for _, c := range cfg.Configs {
+		if c.TypeUrl == bootstrapConfigDumpType {
+			cd := &envoyAdmin.BootstrapConfigDump{}
+			if err := ptypes.UnmarshalAny(c, cd); err != nil {
+				return nil, err
+			}
+			return cd, nil
+		}
+	}
+	return nil, errors.New("envoy Bootstrap not found in config dump")
+}
@@ -22,6 +22,10 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/echo/client"
@@ -29,6 +33,15 @@ import (
 	"istio.io/istio/pkg/test/echo/common/scheme"
 	"istio.io/istio/pkg/test/echo/proto"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	defaultRetryDelay = time.Second
+	requestIDHeader   = "X-Request-Id"
+	// defaultCheckRetries is the number of retries of calls with checkers, unless configured explicitly.
+	defaultCheckRetries = 20
 )
 
 var (
@@ -42,16 +55,123 @@ var (
 // requests to a target service.
 type OutboundPortSelectorFunc func(servicePort int) (int, error)
 
-func CallEcho(c *client.Instance, opts *echo.CallOptions, outboundPortSelector OutboundPortSelectorFunc) (client.ParsedResponses, error) {
+// CallEcho makes the call described by opts from the given echo client, retrying failed attempts according to
+// the retry options. If all of the attempts fail, the returned error is an *echo.CallError.
+func CallEcho(c *client.Instance, opts *echo.CallOptions, outboundPortSelector OutboundPortSelectorFunc,
+	checkers ...check.Checker) (client.ParsedResponses, error) {
 	if err := fillInCallOptions(opts); err != nil {
 		return nil, err
 	}
+	addCheckers(opts, checkers)
 
 	port, err := outboundPortSelector(opts.Port.ServicePort)
 	if err != nil {
 		return nil, err
 	}
 
+	return callWithRetries(opts, func() (client.ParsedResponses, error) {
+		return callEchoOnce(c, opts, port)
+	})
+}
+
+// CallEchoDirect makes a call from the given echo client to the given address and port, e.g. the IP of a
+// pod, rather than to the service of the Target. The Target, Port and PortName options are ignored, and the
+// Scheme defaults to http.
+func CallEchoDirect(c *client.Instance, address string, port int, opts *echo.CallOptions,
+	checkers ...check.Checker) (client.ParsedResponses, error) {
+	if address == "" || port <= 0 {
+		return nil, fmt.Errorf("callOptions: invalid direct call address %s:%d", address, port)
+	}
+	if opts.Scheme == "" {
+		opts.Scheme = scheme.HTTP
+	}
+	opts.Host = address
+	fillInDefaults(opts)
+	addCheckers(opts, checkers)
+
+	return callWithRetries(opts, func() (client.ParsedResponses, error) {
+		return callEchoOnce(c, opts, port)
+	})
+}
+
+// addCheckers to the Validator of the options. Unless retries are configured explicitly, calls with checkers
+// are retried until the checkers pass.
+func addCheckers(opts *echo.CallOptions, checkers []check.Checker) {
+	if len(checkers) == 0 {
+		return
+	}
+	if opts.Validator != nil {
+		checkers = append([]check.Checker{opts.Validator}, checkers...)
+	}
+	opts.Validator = check.And(checkers...)
+	if opts.RetryCount == 0 {
+		opts.RetryCount = defaultCheckRetries
+	}
+}
+
+// callWithRetries calls fn until an attempt succeeds or the retries are exhausted.
+func callWithRetries(opts *echo.CallOptions, fn func() (client.ParsedResponses, error)) (client.ParsedResponses, error) {
+	callErr := &echo.CallError{}
+	delay := opts.RetryDelay
+	if opts.Headers == nil {
+		opts.Headers = make(http.Header)
+	}
+	requestID := opts.Headers.Get(requestIDHeader)
+	for attempt := 1; ; attempt++ {
+		id := requestID
+		if id == "" {
+			id = uuid.New().String()
+		}
+		opts.Headers.Set(requestIDHeader, id)
+
+		start := time.Now()
+		resp, err := fn()
+		// The kind of failure is classified from the result of the call, since the Validator may reject it
+		// with an error of its own, or accept a call that failed.
+		kind := echo.ClassifyAttempt(resp, err, nil)
+		if opts.Validator != nil {
+			validatorErr := opts.Validator(resp, err)
+			kind = echo.NoFailure
+			if validatorErr != nil {
+				kind = echo.ClassifyAttempt(resp, err, validatorErr)
+			}
+			err = validatorErr
+		}
+		callErr.Attempts = append(callErr.Attempts, echo.CallAttempt{
+			Number:    attempt,
+			RequestID: id,
+			Duration:  time.Since(start),
+			Err:       err,
+			Kind:      kind,
+		})
+
+		if err == nil {
+			if attempt > 1 {
+				scopes.Framework.Infof("call to %s succeeded after %d attempts:\n  %s",
+					opts.Host, attempt, attemptsString(callErr.Attempts))
+			}
+			return resp, nil
+		}
+		if attempt > opts.RetryCount {
+			return nil, callErr
+		}
+
+		time.Sleep(delay)
+		if opts.RetryBackoff > 1 {
+			delay = time.Duration(float64(delay) * opts.RetryBackoff)
+		}
+	}
+}
+
+func attemptsString(attempts []echo.CallAttempt) string {
+	out := make([]string, 0, len(attempts))
+	for _, a := range attempts {
+		out = append(out, a.String())
+	}
+	return strings.Join(out, "\n  ")
+}
+
+func callEchoOnce(c *client.Instance, opts *echo.CallOptions, port int) (client.ParsedResponses, error) {
 	// Forward a request from 'this' service to the destination service.
 	targetHost := net.JoinHostPort(opts.Host, strconv.Itoa(port))
 	targetURL := fmt.Sprintf("%s://%s%s", string(opts.Scheme), targetHost, opts.Path)
@@ -63,15 +183,36 @@ func CallEcho(c *client.Instance, opts *echo.CallOptions, outboundPortSelector O
 	}
 	// Add headers in opts.Headers, e.g., authorization header, etc.
 	// If host header is set, it will override targetService.
-	for k := range opts.Headers {
-		protoHeaders = append(protoHeaders, &proto.Header{Key: k, Value: opts.Headers.Get(k)})
+	for k, values := range opts.Headers {
+		for _, v := range values {
+			protoHeaders = append(protoHeaders, &proto.Header{Key: k, Value: v})
+		}
 	}
 
 	req := &proto.ForwardEchoRequest{
 		Url:           targetURL,
 		Count:         int32(opts.Count),
+		Concurrency:   int32(opts.Concurrency),
 		Headers:       protoHeaders,
 		TimeoutMicros: common.DurationToMicros(opts.Timeout),
+		Message:       opts.Message,
+		Http2:         opts.HTTP2,
+		Alpn:          opts.ALPN,
+
+		NewConnectionPerRequest: opts.NewConnectionPerRequest,
+		ReuseConnection:         opts.ReuseConnection,
+		ReportGrpcStatus:        opts.GRPCStatus,
+
+		StreamMessages:       int32(opts.StreamMessages),
+		ServerStreaming:      opts.ServerStreaming,
+		StreamIntervalMicros: common.DurationToMicros(opts.StreamInterval),
+
+		Cert:   opts.Cert,
+		Key:    opts.Key,
+		CaCert: opts.CACert,
+
+		ServerName: opts.SNI,
+		Proxy:      opts.Proxy,
 	}
 
 	resp, err := c.ForwardEcho(context.Background(), req)
@@ -90,6 +231,14 @@ func fillInCallOptions(opts *echo.CallOptions) error {
 		return errors.New("callOptions: missing Target")
 	}
 
+	if opts.Concurrency < 0 {
+		return errors.New("callOptions: Concurrency must not be negative")
+	}
+
+	if opts.NewConnectionPerRequest && opts.ReuseConnection {
+		return errors.New("callOptions: NewConnectionPerRequest and ReuseConnection are mutually exclusive")
+	}
+
 	targetPorts := opts.Target.Config().Ports
 	if opts.PortName == "" {
 		// Validate the Port value.
@@ -132,15 +281,24 @@ func fillInCallOptions(opts *echo.CallOptions) error {
 		}
 	}
 
-	if opts.Headers == nil {
-		opts.Headers = make(http.Header)
-	}
-
 	if opts.Host == "" {
 		// No host specified, use the fully qualified domain name for the service.
 		opts.Host = opts.Target.Config().FQDN()
 	}
 
+	fillInDefaults(opts)
+	return nil
+}
+
+// fillInDefaults of the options that don't depend on the Target.
+func fillInDefaults(opts *echo.CallOptions) {
+	// Copy the headers, since the request ID is set on them for each attempt.
+	headers := make(http.Header, len(opts.Headers))
+	for k, v := range opts.Headers {
+		headers[k] = v
+	}
+	opts.Headers = headers
+
 	if opts.Timeout <= 0 {
 		opts.Timeout = common.DefaultRequestTimeout
 	}
@@ -149,7 +307,13 @@ func fillInCallOptions(opts *echo.CallOptions) error {
 		opts.Count = common.DefaultCount
 	}
 
-	return nil
+	if opts.RetryCount < 0 {
+		opts.RetryCount = 0
+	}
+
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultRetryDelay
+	}
 }
 
 func schemeForPort(port *echo.Port) (scheme.Instance, error) {
@@ -160,6 +324,14 @@ func schemeForPort(port *echo.Port) (scheme.Instance, error) {
 		return scheme.HTTP, nil
 	case protocol.HTTPS, protocol.TLS:
 		return scheme.HTTPS, nil
+	case protocol.UDP:
+		return scheme.UDP, nil
+	case protocol.MySQL:
+		return scheme.TCPServerFirst, nil
+	case protocol.Thrift:
+		return scheme.Thrift, nil
+	case protocol.Dubbo:
+		return scheme.Dubbo, nil
 	default:
 		return "", fmt.Errorf("failed creating call for port %s: unsupported protocol %s",
 			port.Name, port.Protocol)
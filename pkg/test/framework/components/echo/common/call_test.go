@@ -0,0 +1,104 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+func TestCallWithRetries(t *testing.T) {
+	errFailed := errors.New("failed")
+	cases := []struct {
+		name      string
+		opts      echo.CallOptions
+		failFirst int
+		attempts  int
+		success   bool
+	}{
+		{
+			name:      "no retries",
+			failFirst: 1,
+			attempts:  1,
+		},
+		{
+			name:      "succeeds on retry",
+			opts:      echo.CallOptions{RetryCount: 3},
+			failFirst: 2,
+			attempts:  3,
+			success:   true,
+		},
+		{
+			name:      "retries exhausted",
+			opts:      echo.CallOptions{RetryCount: 2, RetryBackoff: 2},
+			failFirst: 5,
+			attempts:  3,
+		},
+		{
+			name: "validator rejects",
+			opts: echo.CallOptions{RetryCount: 1, Validator: func(client.ParsedResponses, error) error {
+				return errFailed
+			}},
+			attempts: 2,
+		},
+		{
+			name: "validator accepts failure",
+			opts: echo.CallOptions{RetryCount: 3, Validator: func(_ client.ParsedResponses, err error) error {
+				if err == nil {
+					return errors.New("expected failure")
+				}
+				return nil
+			}},
+			failFirst: 5,
+			attempts:  1,
+			success:   true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			opts := c.opts
+			opts.RetryDelay = time.Millisecond
+			calls := 0
+			resp, err := callWithRetries(&opts, func() (client.ParsedResponses, error) {
+				calls++
+				if calls <= c.failFirst {
+					return nil, errFailed
+				}
+				return client.ParsedResponses{&client.ParsedResponse{}}, nil
+			})
+
+			if calls != c.attempts {
+				t.Fatalf("expected %d attempts, got %d", c.attempts, calls)
+			}
+			if c.success {
+				if err != nil || (calls > c.failFirst && len(resp) != 1) {
+					t.Fatalf("expected success, got %v", err)
+				}
+				return
+			}
+			callErr, ok := err.(*echo.CallError)
+			if !ok {
+				t.Fatalf("expected a CallError, got %v", err)
+			}
+			if len(callErr.Attempts) != c.attempts || callErr.Last() != errFailed {
+				t.Fatalf("unexpected attempts: %v", callErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
+	"github.com/golang/protobuf/ptypes"
+)
+
+const (
+	// CertChainFile is the location of the workload certificate chain mounted into the sidecar.
+	CertChainFile = "/etc/certs/cert-chain.pem"
+	// RootCertFile is the location of the trust bundle mounted into the sidecar.
+	RootCertFile = "/etc/certs/root-cert.pem"
+
+	secretsConfigDumpType = "type.googleapis.com/envoy.admin.v2alpha.SecretsConfigDump"
+
+	// defaultSecretName is the name of the SDS secret holding the workload certificate.
+	defaultSecretName = "default"
+	// rootCASecretName is the name of the SDS secret holding the trust bundle.
+	rootCASecretName = "ROOTCA"
+)
+
+// CertificatesFromConfigDump returns the workload certificate chain served to Envoy over SDS, leaf first.
+// Returns nil if Envoy doesn't use SDS for the workload certificate (i.e. it is read from CertChainFile).
+func CertificatesFromConfigDump(cfg *envoyAdmin.ConfigDump) ([]*x509.Certificate, error) {
+	for _, c := range cfg.Configs {
+		if c.TypeUrl != secretsConfigDumpType {
+			continue
+		}
+		dump := envoyAdmin.SecretsConfigDump{}
+		if err := ptypes.UnmarshalAny(c, &dump); err != nil {
+			return nil, err
+		}
+
+		var chain []byte
+		for _, s := range dump.DynamicActiveSecrets {
+			inline := s.GetSecret().GetTlsCertificate().GetCertificateChain().GetInlineBytes()
+			if len(inline) == 0 {
+				continue
+			}
+			if chain == nil || s.GetName() == defaultSecretName {
+				chain = inline
+			}
+		}
+		if chain != nil {
+			return ParseCertificates(chain)
+		}
+	}
+	return nil, nil
+}
+
+// TrustBundleFromConfigDump returns the root certificates of the validation context served to Envoy over SDS.
+// Returns nil if Envoy doesn't use SDS for the validation context (i.e. it is read from RootCertFile).
+func TrustBundleFromConfigDump(cfg *envoyAdmin.ConfigDump) ([]*x509.Certificate, error) {
+	for _, c := range cfg.Configs {
+		if c.TypeUrl != secretsConfigDumpType {
+			continue
+		}
+		dump := envoyAdmin.SecretsConfigDump{}
+		if err := ptypes.UnmarshalAny(c, &dump); err != nil {
+			return nil, err
+		}
+
+		var bundle []byte
+		for _, s := range dump.DynamicActiveSecrets {
+			inline := s.GetSecret().GetValidationContext().GetTrustedCa().GetInlineBytes()
+			if len(inline) == 0 {
+				continue
+			}
+			if bundle == nil || s.GetName() == rootCASecretName {
+				bundle = inline
+			}
+		}
+		if bundle != nil {
+			return ParseCertificates(bundle)
+		}
+	}
+	return nil, nil
+}
+
+// ParseCertificates parses all of the PEM encoded certificates in the given data, in order.
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
@@ -54,6 +54,23 @@ func FillInDefaults(ctx resource.Context, defaultDomain string, c *echo.Config)
 		}
 	}
 
+	// Copy the annotations before changing them, they may be shared with other configs. Naked and the
+	// annotation are kept in sync, so that either can be checked on the resulting Config.
+	annotations := echo.NewAnnotations()
+	for k, v := range c.Annotations {
+		annotations[k] = v
+	}
+	c.Annotations = annotations
+	if c.HostNetwork {
+		// Pods on the host network are never injected.
+		c.Naked = true
+	}
+	if c.Naked {
+		c.Annotations.SetBool(echo.SidecarInject, false)
+	} else if !c.Annotations.GetBool(echo.SidecarInject) {
+		c.Naked = true
+	}
+
 	// Make a copy of the ports array. This avoids potential corruption if multiple Echo
 	// Instances share the same underlying ports array.
 	c.Ports = append([]echo.Port{}, c.Ports...)
@@ -29,6 +29,7 @@ import (
 	"istio.io/istio/pkg/test/echo/client"
 	"istio.io/istio/pkg/test/echo/proto"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
 	"istio.io/istio/pkg/test/framework/components/echo/common"
 	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/util/structpath"
@@ -134,6 +135,10 @@ func (e *testConfig) Address() string {
 	return e.address
 }
 
+func (e *testConfig) HostAddress() string {
+	return ""
+}
+
 func (e *testConfig) Config() echo.Config {
 	return echo.Config{
 		Service: e.service,
@@ -170,11 +175,15 @@ func (*testConfig) WaitUntilCallableOrFail(_ test.Failer, _ ...echo.Instance) {
 	panic("not implemented")
 }
 
-func (*testConfig) Call(_ echo.CallOptions) (client.ParsedResponses, error) {
+func (*testConfig) Call(echo.CallOptions, ...check.Checker) (client.ParsedResponses, error) {
+	panic("not implemented")
+}
+
+func (*testConfig) CallOrFail(test.Failer, echo.CallOptions, ...check.Checker) client.ParsedResponses {
 	panic("not implemented")
 }
 
-func (*testConfig) CallOrFail(_ test.Failer, _ echo.CallOptions) client.ParsedResponses {
+func (*testConfig) Name() string {
 	panic("not implemented")
 }
 
@@ -186,6 +195,14 @@ func (*testConfig) ForwardEcho(context.Context, *proto.ForwardEchoRequest) (clie
 	panic("not implemented")
 }
 
+func (*testConfig) CallDirect(string, int, echo.CallOptions, ...check.Checker) (client.ParsedResponses, error) {
+	panic("not implemented")
+}
+
+func (*testConfig) CallDirectOrFail(test.Failer, string, int, echo.CallOptions, ...check.Checker) client.ParsedResponses {
+	panic("not implemented")
+}
+
 type fakeNamespace struct {
 	name string
 }
@@ -0,0 +1,49 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+var envoyLogLevels = map[string]bool{
+	"trace":    true,
+	"debug":    true,
+	"info":     true,
+	"warning":  true,
+	"error":    true,
+	"critical": true,
+	"off":      true,
+}
+
+// LogLevelPaths returns the paths of the Envoy /logging admin requests for the given log level, which is either a
+// single level for all loggers (e.g. "debug") or a comma separated list of logger:level pairs
+// (e.g. "rbac:debug,jwt:debug").
+func LogLevelPaths(level string) ([]string, error) {
+	if envoyLogLevels[level] {
+		return []string{"logging?level=" + level}, nil
+	}
+
+	var paths []string
+	for _, entry := range strings.Split(level, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || !envoyLogLevels[parts[1]] {
+			return nil, fmt.Errorf("invalid log level %q (want <level> or <logger>:<level>,...)", entry)
+		}
+		paths = append(paths, fmt.Sprintf("logging?%s=%s", parts[0], parts[1]))
+	}
+	return paths, nil
+}
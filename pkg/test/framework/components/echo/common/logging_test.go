@@ -0,0 +1,46 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLogLevelPaths(t *testing.T) {
+	cases := []struct {
+		level string
+		want  []string
+	}{
+		{"debug", []string{"logging?level=debug"}},
+		{"rbac:debug", []string{"logging?rbac=debug"}},
+		{"rbac:debug,jwt:trace", []string{"logging?rbac=debug", "logging?jwt=trace"}},
+	}
+	for _, c := range cases {
+		got, err := LogLevelPaths(c.level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("LogLevelPaths(%q): got %v, want %v", c.level, got, c.want)
+		}
+	}
+
+	for _, level := range []string{"", "verbose", "rbac", "rbac:verbose", ":debug"} {
+		if _, err := LogLevelPaths(level); err == nil {
+			t.Errorf("LogLevelPaths(%q): expected an error", level)
+		}
+	}
+}
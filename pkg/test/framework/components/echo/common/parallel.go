@@ -0,0 +1,55 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// ForEach calls fn for each index in [0, count) in parallel, running at most maxConcurrency calls at a time.
+// A maxConcurrency of zero or less runs all calls at once. The errors of all calls are aggregated.
+func ForEach(count, maxConcurrency int, fn func(index int) error) error {
+	if maxConcurrency <= 0 || maxConcurrency > count {
+		maxConcurrency = count
+	}
+
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, maxConcurrency)
+	aggregateErrMux := &sync.Mutex{}
+	var aggregateErr error
+	for i := 0; i < count; i++ {
+		index := i
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := fn(index); err != nil {
+				aggregateErrMux.Lock()
+				aggregateErr = multierror.Append(aggregateErr, err)
+				aggregateErrMux.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return aggregateErr
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	var running, maxRunning, calls int32
+	err := ForEach(10, 3, func(index int) error {
+		atomic.AddInt32(&calls, 1)
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		if index%4 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if calls != 10 {
+		t.Fatalf("expected 10 calls, got %d", calls)
+	}
+	if maxRunning > 3 {
+		t.Fatalf("expected at most 3 concurrent calls, got %d", maxRunning)
+	}
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestForEachUnlimited(t *testing.T) {
+	if err := ForEach(0, 0, func(int) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := ForEach(5, 0, func(int) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -59,6 +59,8 @@ func newPortGenerator() *portGenerator {
 			protocol.GRPC:    grpcBase,
 			protocol.Mongo:   tcpBase,
 			protocol.MySQL:   tcpBase,
+			protocol.Thrift:  tcpBase,
+			protocol.Dubbo:   tcpBase,
 			protocol.Redis:   tcpBase,
 			protocol.UDP:     tcpBase,
 		},
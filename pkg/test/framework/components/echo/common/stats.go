@@ -0,0 +1,41 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// ParseStats parses the counters and gauges of the plain text output of the Envoy /stats admin endpoint.
+// Histograms are skipped.
+func ParseStats(out string) map[string]int64 {
+	stats := make(map[string]int64)
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.LastIndex(line, ": ")
+		if i < 0 {
+			continue
+		}
+		value, err := strconv.ParseInt(line[i+2:], 10, 64)
+		if err != nil {
+			continue
+		}
+		stats[line[:i]] = value
+	}
+	return stats
+}
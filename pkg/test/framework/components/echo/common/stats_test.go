@@ -0,0 +1,39 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"reflect"
+	"testing"
+
+	"istio.io/istio/pkg/test/framework/components/echo/common"
+)
+
+func TestParseStats(t *testing.T) {
+	out := `cluster.outbound|80||b.default.svc.cluster.local.ssl.versions.TLSv1.2: 3
+listener.10.0.0.1_8080.ssl.ciphers.ECDHE-RSA-AES128-GCM-SHA256: 2
+server.live: 1
+cluster.outbound|80||b.default.svc.cluster.local.upstream_cx_length_ms: P0(nan,0) P25(nan,0)
+not a stat
+`
+	expected := map[string]int64{
+		"cluster.outbound|80||b.default.svc.cluster.local.ssl.versions.TLSv1.2": 3,
+		"listener.10.0.0.1_8080.ssl.ciphers.ECDHE-RSA-AES128-GCM-SHA256":        2,
+		"server.live": 1,
+	}
+	if actual := common.ParseStats(out); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
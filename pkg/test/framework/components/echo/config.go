@@ -16,7 +16,9 @@ package echo
 
 import (
 	"fmt"
+	"time"
 
+	"istio.io/istio/pkg/test/echo/common/response"
 	"istio.io/istio/pkg/test/framework/components/galley"
 	"istio.io/istio/pkg/test/framework/components/namespace"
 	"istio.io/istio/pkg/test/framework/components/pilot"
@@ -43,16 +45,49 @@ type Config struct {
 	// Version indicates the version path for calls to the Echo application.
 	Version string
 
-	// Locality (k8s only) indicates the locality of the deployed app.
+	// Locality (k8s only) indicates the locality of the deployed app, as region.zone.subzone. Subsets may
+	// override it. Responses report the locality of the instance that served them.
 	Locality string
 
+	// LocalityNodeAffinity (k8s only) schedules the workloads only on nodes labeled with the region and zone
+	// of their locality, so that the proxies observe the same locality as the workloads.
+	LocalityNodeAffinity bool
+
 	// Headless (k8s only) indicates that no ClusterIP should be specified.
 	Headless bool
 
+	// IPFamilies (k8s only) of the Service. Giving both IPv4 and IPv6 requests a dual-stack Service, which
+	// falls back to a single family if the cluster doesn't support dual-stack. If empty, the default family
+	// of the cluster is used. Responses report the family each call was made over. Requires Kubernetes 1.20 or
+	// later in all clusters.
+	IPFamilies []response.IPFamily
+
+	// StatefulSet (k8s only) deploys the workloads of each subset as a StatefulSet governed by the Service,
+	// rather than a Deployment, so that the pods have stable names. Combined with Headless, each pod is also
+	// addressable as <pod>.<service FQDN>.
+	StatefulSet bool
+
+	// DrainTimeout (k8s only) is how long the echo server waits on termination for the requests in flight to
+	// complete before it exits. The termination grace period of the pods is extended accordingly. If zero, the
+	// server exits right away.
+	DrainTimeout time.Duration
+
+	// ReadinessProbe (k8s only) of the application container. If nil, the default probe is used.
+	ReadinessProbe *ReadinessProbe
+
+	// StartupTimeout (k8s only) is how long to wait for the workloads to become ready. If zero, the default
+	// timeout of the Kubernetes accessor is used. If the workloads don't become ready in time, the pod
+	// statuses and events, the logs of the sidecar injector and the generated deployment are logged.
+	StartupTimeout time.Duration
+
 	// ServiceAccount (k8s only) indicates that a service account should be created
 	// for the deployment.
 	ServiceAccount bool
 
+	// ServiceAccountName (k8s only) of the service account created for the deployment, instead of the name
+	// of the Service. Implies ServiceAccount.
+	ServiceAccountName string
+
 	// Ports for this application. Port numbers may or may not be used, depending
 	// on the implementation.
 	Ports []Port
@@ -60,9 +95,153 @@ type Config struct {
 	// Annotations provides metadata hints for deployment of the instance.
 	Annotations Annotations
 
+	// Naked deploys the workloads without a sidecar. This is equivalent to disabling the SidecarInject
+	// annotation, which also sets Naked on the Config of the created Instance.
+	Naked bool
+
 	// IncludeInboundPorts provides the ports that inbound listener should capture
 	// "*" means capture all.
 	IncludeInboundPorts string
+
+	// Labels (k8s only) applied to the workloads of all subsets, in addition to the app and version labels.
+	// Labels of a subset override these, e.g. for targeting a subset with workload selectors.
+	Labels map[string]string
+
+	// NodeSelector (k8s only) restricts the workloads to nodes with the given labels.
+	NodeSelector map[string]string
+
+	// Image (k8s only) of the application container, replacing the app image (or the app_sidecar image of
+	// mock VMs) of the hub and tag of the test settings, e.g. with a distroless variant or an image pinned by
+	// digest. It must run the echo server with the same arguments.
+	Image string
+
+	// NodeOS (k8s only) schedules the workloads only on nodes running the given operating system, e.g.
+	// "windows", through the kubernetes.io/os node label. The sidecar only runs on Linux, so other operating
+	// systems require Naked.
+	NodeOS string
+
+	// NodeArch (k8s only) schedules the workloads only on nodes of the given architecture, e.g. "arm64",
+	// through the kubernetes.io/arch node label. The images must be available for that architecture.
+	NodeArch string
+
+	// Tolerations (k8s only) of the workloads, for scheduling onto tainted nodes.
+	Tolerations []Toleration
+
+	// Subsets (k8s only) deploys a separate workload for each subset behind the service. If empty, a
+	// single subset with Version and the workload Annotations is deployed.
+	Subsets []SubsetConfig
+
+	// Cluster (k8s only) is the name of the cluster of a multicluster topology where the workloads are
+	// deployed. The Service is created in all clusters. If empty, the primary cluster is used.
+	// Responses report the cluster of the instance that served them.
+	Cluster string
+
+	// DeployAsVM (k8s only) deploys the workload as a mock VM: a pod without sidecar injection that runs the
+	// application together with istio-proxy, started through the VM flow with the Citadel issued certificate
+	// of the service account. The workload is registered with the mesh through a ServiceEntry rather than
+	// the Kubernetes service registry.
+	DeployAsVM bool
+
+	// TLSSettings (k8s only) provides the certificate served by the application on ports with TLS set.
+	TLSSettings *TLSSettings
+
+	// Restricted (k8s only) deploys the workloads so that the application container satisfies the restricted
+	// pod security profile, for hardened clusters: it runs as a non-root user, without privilege escalation,
+	// with all capabilities dropped and the default seccomp profile. The ports must not be privileged. Unless
+	// Naked, the traffic must be redirected by the Istio CNI plugin, since the istio-init container requires
+	// the NET_ADMIN capability. The injected sidecar is as restricted as the injection template makes it.
+	Restricted bool
+
+	// HostNetwork (k8s only) deploys the workloads on the network of the nodes they run on, so that their
+	// endpoints have the IPs of the nodes. The sidecar injector skips pods on the host network, so the
+	// workloads are always Naked. The instance ports, including the readiness and health ports, are bound on
+	// the nodes, so workloads of the same ports cannot share a node: each subset needs a node of its own.
+	HostNetwork bool
+
+	// MetadataExchange (k8s only) tampers with the peer metadata exchanged by the sidecars of the workloads,
+	// through an EnvoyFilter deployed with them, for testing how the features derived from the peer metadata
+	// (e.g. the workload labels of the metrics of the stats filter) degrade when it is missing. Features
+	// derived from the mTLS identity of the peer, such as authorization by principal, are not affected. It
+	// requires a sidecar, and the EnvoyFilter must be applied after the metadata exchange filter if both are
+	// in the same namespace.
+	MetadataExchange MetadataExchangeMode
+
+	// ClockSkew (k8s only) shifts the clock of the Envoy of the sidecars of the workloads by the given
+	// duration, ahead if positive and behind if negative, with libfaketime preloaded into the sidecar
+	// containers from the image of the --istio.test.faketime.image flag. Envoy then validates the lifetimes of
+	// certificates and JWTs (nbf, exp) against the skewed clock, while the Go binaries of the pods, including
+	// the echo server and pilot-agent, keep the real one. It requires a sidecar. Subsets may override it.
+	ClockSkew time.Duration
+}
+
+// MetadataExchangeMode of the sidecars of echo workloads.
+type MetadataExchangeMode string
+
+const (
+	// MetadataExchangeEnabled leaves the metadata exchange to the configuration of the mesh.
+	MetadataExchangeEnabled MetadataExchangeMode = ""
+	// MetadataExchangeDisabled strips the peer metadata headers from the requests and responses sent and
+	// received by the sidecars, so that neither the workloads nor their peers receive any metadata.
+	MetadataExchangeDisabled MetadataExchangeMode = "disabled"
+	// MetadataExchangeCorrupted replaces the peer metadata headers with values that cannot be decoded.
+	MetadataExchangeCorrupted MetadataExchangeMode = "corrupted"
+)
+
+// SubsetConfig is the configuration of a single version of an echo service.
+type SubsetConfig struct {
+	// Version of the subset, used for the version label of its workloads.
+	Version string
+	// Labels applied to the workloads of the subset, in addition to the app and version labels.
+	Labels map[string]string
+	// Annotations applied to the workloads of the subset. These override the workload annotations of
+	// the Config.
+	Annotations Annotations
+	// Locality of the workloads of the subset, as region.zone.subzone. If empty, the Locality of the
+	// Config is used.
+	Locality string
+	// ServiceAccountName (k8s only) of a service account created for the workloads of the subset, so that
+	// subsets of the same service can have different principals. If empty, the service account of the Config
+	// is used.
+	ServiceAccountName string
+	// ClockSkew (k8s only) of the sidecars of the workloads of the subset. If zero, the ClockSkew of the
+	// Config is used.
+	ClockSkew time.Duration
+}
+
+// ReadinessProbe of the application container, which performs an HTTP GET on the readiness port of the echo
+// server. Zero fields take the default values.
+type ReadinessProbe struct {
+	// Path requested by the probe. Defaults to "/".
+	Path string
+	// InitialDelay before the first probe. Defaults to 10s.
+	InitialDelay time.Duration
+	// Period between probes. Defaults to 10s.
+	Period time.Duration
+	// Timeout of each probe. Defaults to 1s.
+	Timeout time.Duration
+	// FailureThreshold is the number of consecutive failed probes before the container is marked unready.
+	// Defaults to 10.
+	FailureThreshold int
+}
+
+// Toleration of a node taint, as in the Kubernetes pod spec.
+type Toleration struct {
+	// Key of the taint. An empty key with the Exists operator tolerates all taints.
+	Key string
+	// Operator is either Equal or Exists. Defaults to Equal.
+	Operator string
+	// Value of the taint, for the Equal operator.
+	Value string
+	// Effect of the taint, e.g. NoSchedule. Empty tolerates all effects.
+	Effect string
+}
+
+// TLSSettings for ports where the application terminates TLS.
+type TLSSettings struct {
+	// Cert is the PEM encoded certificate chain presented by the application.
+	Cert string
+	// Key is the PEM encoded private key for Cert.
+	Key string
 }
 
 // String implements the Configuration interface (which implements fmt.Stringer)
@@ -70,6 +249,25 @@ func (c Config) String() string {
 	return fmt.Sprint("{service: ", c.Service, ", version: ", c.Version, "}")
 }
 
+// ServiceAccountFor returns the name of the service account of the workloads of the subset with the given
+// version: the service account of the subset if it has one, otherwise the one of the Config, or "default" if
+// no service account is created.
+func (c Config) ServiceAccountFor(version string) string {
+	for _, s := range c.Subsets {
+		if s.Version == version && s.ServiceAccountName != "" {
+			return s.ServiceAccountName
+		}
+	}
+	switch {
+	case c.ServiceAccountName != "":
+		return c.ServiceAccountName
+	case c.ServiceAccount:
+		return c.Service
+	default:
+		return "default"
+	}
+}
+
 // FQDN returns the fully qualified domain name for the service.
 func (c Config) FQDN() string {
 	out := c.Service
@@ -15,21 +15,19 @@
 package docker
 
 import (
-	"sync"
-
-	"github.com/hashicorp/go-multierror"
-
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/common"
 	"istio.io/istio/pkg/test/framework/resource"
 )
 
 var _ echo.Builder = &builder{}
 
 type builder struct {
-	ctx        resource.Context
-	references []*echo.Instance
-	configs    []echo.Config
+	ctx            resource.Context
+	references     []*echo.Instance
+	configs        []echo.Config
+	maxConcurrency int
 }
 
 func NewBuilder(ctx resource.Context) echo.Builder {
@@ -44,6 +42,11 @@ func (b *builder) With(i *echo.Instance, cfg echo.Config) echo.Builder {
 	return b
 }
 
+func (b *builder) WithMaxConcurrency(n int) echo.Builder {
+	b.maxConcurrency = n
+	return b
+}
+
 func (b *builder) Build() error {
 	instances, err := b.newInstances()
 	if err != nil {
@@ -69,40 +72,22 @@ func (b *builder) BuildOrFail(t test.Failer) {
 }
 
 func (b *builder) newInstances() ([]echo.Instance, error) {
-	wg := sync.WaitGroup{}
-	aggregateErrMux := &sync.Mutex{}
-	var aggregateErr error
-
 	instances := make([]echo.Instance, len(b.configs))
-	for index, cfg := range b.configs {
-		index := index
-		cfg := cfg
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			inst, err := newInstance(b.ctx, cfg)
-			if err != nil {
-				aggregateErrMux.Lock()
-				aggregateErr = multierror.Append(aggregateErr, err)
-				aggregateErrMux.Unlock()
-				return
-			}
-			instances[index] = inst
-		}()
-	}
-
-	wg.Wait()
-
-	if aggregateErr != nil {
+	if err := common.ForEach(len(b.configs), b.maxConcurrency, func(index int) error {
+		inst, err := newInstance(b.ctx, b.configs[index])
+		if err != nil {
+			return err
+		}
+		instances[index] = inst
+		return nil
+	}); err != nil {
 		// Close any instances that were successfully created.
 		for _, inst := range instances {
 			if inst != nil {
 				_ = inst.(*instance).Close()
 			}
 		}
-		return nil, aggregateErr
+		return nil, err
 	}
 
 	return instances, nil
@@ -110,24 +95,7 @@ func (b *builder) newInstances() ([]echo.Instance, error) {
 
 func (b *builder) waitUntilAllCallable(instances []echo.Instance) error {
 	// Now wait for each endpoint to be callable from all others.
-	wg := sync.WaitGroup{}
-	aggregateErrMux := &sync.Mutex{}
-	var aggregateErr error
-	for _, inst := range instances {
-		wg.Add(1)
-
-		source := inst
-		go func() {
-			defer wg.Done()
-
-			if err := source.WaitUntilCallable(instances...); err != nil {
-				aggregateErrMux.Lock()
-				aggregateErr = multierror.Append(aggregateErr, err)
-				aggregateErrMux.Unlock()
-			}
-		}()
-	}
-	wg.Wait()
-
-	return aggregateErr
+	return common.ForEach(len(instances), b.maxConcurrency, func(index int) error {
+		return instances[index].WaitUntilCallable(instances...)
+	})
 }
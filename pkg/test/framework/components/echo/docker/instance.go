@@ -27,6 +27,7 @@ import (
 	"istio.io/istio/pkg/test"
 	appEcho "istio.io/istio/pkg/test/echo/client"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
 	"istio.io/istio/pkg/test/framework/components/echo/common"
 	"istio.io/istio/pkg/test/framework/components/environment/native"
 	"istio.io/istio/pkg/test/framework/resource"
@@ -62,6 +63,18 @@ func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err erro
 		return nil, err
 	}
 
+	if len(cfg.Subsets) > 0 {
+		return nil, fmt.Errorf("subsets are not supported in the native environment: %s", cfg.FQDN())
+	}
+
+	if cfg.DeployAsVM {
+		return nil, fmt.Errorf("DeployAsVM is not supported in the native environment: %s", cfg.FQDN())
+	}
+
+	if cfg.Cluster != "" {
+		return nil, fmt.Errorf("clusters are not supported in the native environment: %s", cfg.FQDN())
+	}
+
 	if !cfg.Headless {
 		log.Debugf("Forcing Headless=true for Echo instance %s since "+
 			"ClusterIPs are not supported in the native environment. If using TCP ports,"+
@@ -70,11 +83,12 @@ func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err erro
 		cfg.Headless = true
 	}
 
-	if cfg.ServiceAccount {
+	if cfg.ServiceAccount || cfg.ServiceAccountName != "" {
 		log.Debugf("Forcing ServiceAccounts=false for Echo instance %s since "+
 			"service accounts are not supported by the native environment.",
 			cfg.FQDN())
 		cfg.ServiceAccount = false
+		cfg.ServiceAccountName = ""
 	}
 
 	var dumpDir string
@@ -191,26 +205,48 @@ func (i *instance) Config() echo.Config {
 	return i.cfg
 }
 
-func (i *instance) Call(opts echo.CallOptions) (appEcho.ParsedResponses, error) {
-	out, err := common.CallEcho(i.workloads[0].Instance, &opts, common.IdentityOutboundPortSelector)
+func (i *instance) Call(opts echo.CallOptions, checkers ...check.Checker) (appEcho.ParsedResponses, error) {
+	w, err := i.sourceWorkload(opts.FromWorkload)
+	if err != nil {
+		return nil, err
+	}
+	out, err := common.CallEcho(w.Instance, &opts, common.IdentityOutboundPortSelector, checkers...)
 	if err != nil {
 		if opts.Port != nil {
-			err = fmt.Errorf("failed calling %s->'%s://%s:%d/%s': %v",
+			call := fmt.Sprintf("%s->'%s://%s:%d/%s'",
 				i.Config().Service,
 				strings.ToLower(string(opts.Port.Protocol)),
 				opts.Target.Config().Service,
 				opts.Port.ServicePort,
-				opts.Path,
-				err)
+				opts.Path)
+			if callErr, ok := err.(*echo.CallError); ok {
+				callErr.Call = call
+			} else {
+				err = fmt.Errorf("failed calling %s: %v", call, err)
+			}
 		}
 		return nil, err
 	}
 	return out, nil
 }
 
-func (i *instance) CallOrFail(t test.Failer, opts echo.CallOptions) appEcho.ParsedResponses {
+// sourceWorkload returns the workload of this instance that makes calls from the given workload, or the
+// first workload if nil.
+func (i *instance) sourceWorkload(from echo.Workload) (*workload, error) {
+	if from == nil {
+		return i.workloads[0], nil
+	}
+	for _, w := range i.workloads {
+		if echo.Workload(w) == from {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("workload %s is not a workload of %s", from.Name(), i.Config().Service)
+}
+
+func (i *instance) CallOrFail(t test.Failer, opts echo.CallOptions, checkers ...check.Checker) appEcho.ParsedResponses {
 	t.Helper()
-	r, err := i.Call(opts)
+	r, err := i.Call(opts, checkers...)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -16,6 +16,7 @@ package docker
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -42,6 +43,10 @@ func newPortMap(portMgr reserveport.PortManager, cfg echo.Config) (*portMap, err
 	hasHTTP := false
 	hasGRPC := false
 	for _, p := range cfg.Ports {
+		if p.TLS {
+			return nil, fmt.Errorf("port %s: application TLS is not supported in the native environment", p.Name)
+		}
+
 		// Reserve a host port.
 		hostPort, err := portMgr.ReservePortNumber()
 		if err != nil {
@@ -78,6 +83,14 @@ func (m *portMap) toEchoArgs() []string {
 		portNumber := port.containerPort.ServicePort
 		if port.containerPort.Protocol.IsGRPC() {
 			echoArgs = append(echoArgs, "--grpc", strconv.Itoa(portNumber))
+		} else if port.containerPort.Protocol == protocol.UDP {
+			echoArgs = append(echoArgs, "--udp", strconv.Itoa(portNumber))
+		} else if port.containerPort.Protocol == protocol.MySQL {
+			echoArgs = append(echoArgs, "--server-first", strconv.Itoa(portNumber))
+		} else if port.containerPort.Protocol == protocol.Thrift {
+			echoArgs = append(echoArgs, "--thrift", strconv.Itoa(portNumber))
+		} else if port.containerPort.Protocol == protocol.Dubbo {
+			echoArgs = append(echoArgs, "--dubbo", strconv.Itoa(portNumber))
 		} else {
 			echoArgs = append(echoArgs, "--port", strconv.Itoa(portNumber))
 		}
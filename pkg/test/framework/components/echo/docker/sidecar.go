@@ -17,13 +17,12 @@ package docker
 import (
 	"bytes"
 	"context"
-	"errors"
+	"crypto/x509"
 	"fmt"
 
 	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
-	"github.com/golang/protobuf/ptypes"
 
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/docker"
@@ -50,18 +49,12 @@ func newSidecar(container *docker.Container) (*sidecar, error) {
 
 	// Extract the node ID from Envoy.
 	if err := sidecar.WaitForConfig(func(cfg *envoyAdmin.ConfigDump) (bool, error) {
-		for _, c := range cfg.Configs {
-			if c.TypeUrl == "type.googleapis.com/envoy.admin.v2alpha.BootstrapConfigDump" {
-				cd := envoyAdmin.BootstrapConfigDump{}
-				if err := ptypes.UnmarshalAny(c, &cd); err != nil {
-					return false, err
-				}
-
-				sidecar.nodeID = cd.Bootstrap.Node.Id
-				return true, nil
-			}
+		cd, err := common.BootstrapFromConfigDump(cfg)
+		if err != nil {
+			return false, err
 		}
-		return false, errors.New("envoy Bootstrap not found in config dump")
+		sidecar.nodeID = cd.Bootstrap.Node.Id
+		return true, nil
 	}); err != nil {
 		return nil, err
 	}
@@ -91,6 +84,40 @@ func (s *sidecar) InfoOrFail(t test.Failer) *envoyAdmin.ServerInfo {
 	return info
 }
 
+func (s *sidecar) Version() (echo.ProxyVersion, error) {
+	info, err := s.Info()
+	if err != nil {
+		return echo.ProxyVersion{}, err
+	}
+	return echo.ParseProxyVersion(info.Version)
+}
+
+func (s *sidecar) VersionOrFail(t test.Failer) echo.ProxyVersion {
+	t.Helper()
+	v, err := s.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func (s *sidecar) Bootstrap() (*envoyAdmin.BootstrapConfigDump, error) {
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	return common.BootstrapFromConfigDump(cfg)
+}
+
+func (s *sidecar) BootstrapOrFail(t test.Failer) *envoyAdmin.BootstrapConfigDump {
+	t.Helper()
+	b, err := s.Bootstrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
 func (s *sidecar) Config() (*envoyAdmin.ConfigDump, error) {
 	msg := &envoyAdmin.ConfigDump{}
 	if err := s.adminRequest("config_dump", msg); err != nil {
@@ -156,14 +183,119 @@ func (s *sidecar) ListenersOrFail(t test.Failer) *envoyAdmin.Listeners {
 	return listeners
 }
 
-func (s *sidecar) adminRequest(path string, out proto.Message) error {
+func (s *sidecar) Certificates() ([]*x509.Certificate, error) {
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	certs, err := common.CertificatesFromConfigDump(cfg)
+	if err != nil || certs != nil {
+		return certs, err
+	}
+
+	// Not using SDS, read the mounted certificate chain.
+	result, err := s.container.Exec(context.Background(), "cat", common.CertChainFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed exec on container %s: %v. Command: cat %s. Output:\n%+v",
+			s.container.Name, err, common.CertChainFile, result)
+	}
+	return common.ParseCertificates(result.StdOut)
+}
+
+func (s *sidecar) CertificatesOrFail(t test.Failer) []*x509.Certificate {
+	t.Helper()
+	certs, err := s.Certificates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certs
+}
+
+func (s *sidecar) TrustBundle() ([]*x509.Certificate, error) {
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	roots, err := common.TrustBundleFromConfigDump(cfg)
+	if err != nil || roots != nil {
+		return roots, err
+	}
+
+	// Not using SDS, read the mounted trust bundle.
+	result, err := s.container.Exec(context.Background(), "cat", common.RootCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed exec on container %s: %v. Command: cat %s. Output:\n%+v",
+			s.container.Name, err, common.RootCertFile, result)
+	}
+	return common.ParseCertificates(result.StdOut)
+}
+
+func (s *sidecar) TrustBundleOrFail(t test.Failer) []*x509.Certificate {
+	t.Helper()
+	roots, err := s.TrustBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return roots
+}
+
+func (s *sidecar) Stats() (map[string]int64, error) {
+	result, err := s.adminGet("stats")
+	if err != nil {
+		return nil, err
+	}
+	return common.ParseStats(string(result.StdOut)), nil
+}
+
+func (s *sidecar) StatsOrFail(t test.Failer) map[string]int64 {
+	t.Helper()
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func (s *sidecar) SetLogLevel(level string) error {
+	paths, err := common.LogLevelPaths(level)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := s.adminExec(path, "-X", "POST"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sidecar) SetLogLevelOrFail(t test.Failer, level string) {
+	t.Helper()
+	if err := s.SetLogLevel(level); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (s *sidecar) adminGet(path string) (docker.ExecResult, error) {
+	return s.adminExec(path)
+}
+
+func (s *sidecar) adminExec(path string, curlArgs ...string) (docker.ExecResult, error) {
 	// Exec onto the pod and make a curl request to the admin port, writing
 	arg := fmt.Sprintf("http://%s:%d/%s", localhost, proxyAdminPort, path)
-	result, err := s.container.Exec(context.Background(), "curl", arg)
+	result, err := s.container.Exec(context.Background(), append(append([]string{"curl"}, curlArgs...), arg)...)
 	if err != nil {
-		return fmt.Errorf("failed exec on container %s: %v. Command: curl %s. Output:\n%+v",
+		return result, fmt.Errorf("failed exec on container %s: %v. Command: curl %s. Output:\n%+v",
 			s.container.Name, err, arg, result)
 	}
+	return result, nil
+}
+
+func (s *sidecar) adminRequest(path string, out proto.Message) error {
+	result, err := s.adminGet(path)
+	if err != nil {
+		return err
+	}
 
 	jspb := jsonpb.Unmarshaler{AllowUnknownFields: true}
 	if err := jspb.Unmarshal(bytes.NewReader(result.StdOut), out); err != nil {
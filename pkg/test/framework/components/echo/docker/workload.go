@@ -18,8 +18,10 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,10 +31,13 @@ import (
 	"github.com/hashicorp/go-multierror"
 
 	"istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/docker"
 	"istio.io/istio/pkg/test/echo/client"
 	"istio.io/istio/pkg/test/envoy"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
+	"istio.io/istio/pkg/test/framework/components/echo/common"
 	"istio.io/istio/pkg/test/framework/components/echo/docker/images"
 	"istio.io/istio/pkg/test/framework/components/environment/native"
 	"istio.io/istio/pkg/test/framework/components/pilot"
@@ -105,6 +110,9 @@ func newWorkload(e *native.Environment, cfg echo.Config, dumpDir string) (out *w
 	echoArgs := append([]string{
 		"--version", cfg.Version,
 	}, w.portMap.toEchoArgs()...)
+	if cfg.Locality != "" {
+		echoArgs = append(echoArgs, "--locality", cfg.Locality)
+	}
 
 	var image docker.Image
 	var cmd []string
@@ -231,14 +239,48 @@ func (w *workload) Close() (err error) {
 	return
 }
 
+func (w *workload) Name() string {
+	return w.container.Hostname
+}
+
 func (w *workload) Address() string {
 	return w.container.IPAddress
 }
 
+func (w *workload) HostAddress() string {
+	// Host ports are not supported.
+	return ""
+}
+
 func (w *workload) Sidecar() echo.Sidecar {
 	return w.sidecar
 }
 
+func (w *workload) CallDirect(address string, port int, opts echo.CallOptions,
+	checkers ...check.Checker) (client.ParsedResponses, error) {
+	out, err := common.CallEchoDirect(w.Instance, address, port, &opts, checkers...)
+	if err != nil {
+		call := fmt.Sprintf("%s->'%s://%s'", w.Name(), opts.Scheme, net.JoinHostPort(address, strconv.Itoa(port)))
+		if callErr, ok := err.(*echo.CallError); ok {
+			callErr.Call = call
+		} else {
+			err = fmt.Errorf("failed calling %s: %v", call, err)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func (w *workload) CallDirectOrFail(t test.Failer, address string, port int, opts echo.CallOptions,
+	checkers ...check.Checker) client.ParsedResponses {
+	t.Helper()
+	r, err := w.CallDirect(address, port, opts, checkers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
 func (w *workload) Dump() {
 	if w.container == nil {
 		return
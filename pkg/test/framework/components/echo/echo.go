@@ -16,6 +16,7 @@ package echo
 
 import (
 	"context"
+	"crypto/x509"
 
 	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
 
@@ -23,6 +24,7 @@ import (
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/echo/client"
 	"istio.io/istio/pkg/test/echo/proto"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
 	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/util/retry"
 )
@@ -46,6 +48,10 @@ type Builder interface {
 	// pointer will be updated to point at the new Instance.
 	With(i *Instance, cfg Config) Builder
 
+	// WithMaxConcurrency limits the number of Instances that are deployed and initialized at the same time.
+	// By default, all Instances are deployed in parallel.
+	WithMaxConcurrency(n int) Builder
+
 	// Build and initialize all Echo Instances. Upon returning, the Instance pointers
 	// are assigned and all Instances are ready to communicate with each other.
 	Build() error
@@ -74,9 +80,10 @@ type Instance interface {
 	Workloads() ([]Workload, error)
 	WorkloadsOrFail(t test.Failer) []Workload
 
-	// Call makes a call from this Instance to a target Instance.
-	Call(options CallOptions) (client.ParsedResponses, error)
-	CallOrFail(t test.Failer, options CallOptions) client.ParsedResponses
+	// Call makes a call from this Instance to a target Instance. If checkers are given, the call is retried
+	// until its result passes all of them.
+	Call(options CallOptions, checkers ...check.Checker) (client.ParsedResponses, error)
+	CallOrFail(t test.Failer, options CallOptions, checkers ...check.Checker) client.ParsedResponses
 }
 
 // Port exposed by an Echo Instance
@@ -95,18 +102,51 @@ type Port struct {
 	// InstancePort number where this instance is listening for connections.
 	// This need not be the same as the ServicePort where the service is accessed.
 	InstancePort int
+
+	// TLS (k8s only) indicates that the application terminates TLS on this port itself, using the
+	// certificate from Config.TLSSettings.
+	TLS bool
+
+	// Unnamed (k8s only) deploys the port of the Kubernetes Service with the name "port-<ServicePort>"
+	// instead of Name, so that its protocol isn't declared and has to be detected by the proxies. The port
+	// is still referred to by Name within the framework. AppProtocol is ignored for unnamed ports.
+	Unnamed bool
+
+	// AppProtocol (k8s only) is set as the appProtocol of the port of the Kubernetes Service, if not empty.
+	// It may differ from the protocol declared by Name, for testing which of the two takes precedence.
+	// Requires Kubernetes 1.19 or later in all clusters.
+	AppProtocol string
+
+	// HostPort (k8s only) exposes the InstancePort of the workloads on this port of the nodes they run on,
+	// where it can be called through Workload.HostAddress. Ignored with Config.HostNetwork, where the
+	// instance ports are already bound on the nodes.
+	HostPort int
 }
 
 // Workload provides an interface for a single deployed echo server.
 type Workload interface {
+	// Name of the workload, which is also its hostname (e.g. the pod name in Kubernetes).
+	Name() string
+
 	// Address returns the network address of the endpoint.
 	Address() string
 
+	// HostAddress (k8s only) returns the address of the node the workload runs on, where its host ports can
+	// be called. It's the same as Address for workloads on the host network.
+	HostAddress() string
+
 	// Sidecar if one was specified.
 	Sidecar() Sidecar
 
 	// ForwardEcho executes specific call from this workload.
 	ForwardEcho(context.Context, *proto.ForwardEchoRequest) (client.ParsedResponses, error)
+
+	// CallDirect makes a call from this workload to the given address and port (e.g. the IP of a pod),
+	// bypassing the VIP of the target service. Target, Port and PortName are ignored, and Scheme
+	// defaults to http. Checkers are handled as for Instance.Call.
+	CallDirect(address string, port int, options CallOptions, checkers ...check.Checker) (client.ParsedResponses, error)
+	CallDirectOrFail(t test.Failer, address string, port int, options CallOptions,
+		checkers ...check.Checker) client.ParsedResponses
 }
 
 // Sidecar provides an interface to execute queries against a single Envoy sidecar.
@@ -118,6 +158,14 @@ type Sidecar interface {
 	Info() (*envoyAdmin.ServerInfo, error)
 	InfoOrFail(t test.Failer) *envoyAdmin.ServerInfo
 
+	// Version of the Envoy build, parsed from its server info.
+	Version() (ProxyVersion, error)
+	VersionOrFail(t test.Failer) ProxyVersion
+
+	// Bootstrap configuration of the Envoy instance, including the node metadata sent to Pilot.
+	Bootstrap() (*envoyAdmin.BootstrapConfigDump, error)
+	BootstrapOrFail(t test.Failer) *envoyAdmin.BootstrapConfigDump
+
 	// Config of the Envoy instance.
 	Config() (*envoyAdmin.ConfigDump, error)
 	ConfigOrFail(t test.Failer) *envoyAdmin.ConfigDump
@@ -136,6 +184,23 @@ type Sidecar interface {
 	Listeners() (*envoyAdmin.Listeners, error)
 	ListenersOrFail(t test.Failer) *envoyAdmin.Listeners
 
+	// Certificates returns the workload certificate chain currently used by the Envoy instance, leaf first.
+	Certificates() ([]*x509.Certificate, error)
+	CertificatesOrFail(t test.Failer) []*x509.Certificate
+
+	// TrustBundle returns the root certificates currently used by the Envoy instance to validate peers.
+	TrustBundle() ([]*x509.Certificate, error)
+	TrustBundleOrFail(t test.Failer) []*x509.Certificate
+
+	// Stats returns the counters and gauges of the Envoy instance, by name.
+	Stats() (map[string]int64, error)
+	StatsOrFail(t test.Failer) map[string]int64
+
+	// SetLogLevel changes the log level of the Envoy instance, either for all loggers (e.g. "debug") or for a
+	// comma separated list of loggers (e.g. "rbac:debug,jwt:debug").
+	SetLogLevel(level string) error
+	SetLogLevelOrFail(t test.Failer, level string)
+
 	// Logs returns the logs for the sidecar container
 	Logs() (string, error)
 	// LogsOrFail returns the logs for the sidecar container, or aborts if an error is found
@@ -0,0 +1,133 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/test/echo/client"
+)
+
+// FailureKind classifies why an attempt of a call failed, so that tests can assert the failure mode rather
+// than matching the error text, which differs between protocols and between the echo client and the proxies.
+type FailureKind string
+
+const (
+	// NoFailure is the kind of attempts that succeeded.
+	NoFailure FailureKind = ""
+	// UnknownFailure is the kind of failures that match none of the other kinds.
+	UnknownFailure FailureKind = "Unknown"
+	// DNSFailure means that the host of the call could not be resolved.
+	DNSFailure FailureKind = "DNSFailure"
+	// ConnectTimeout means that the call timed out, while connecting or waiting for the response.
+	ConnectTimeout FailureKind = "ConnectTimeout"
+	// ConnectionRefused means that the connection was refused.
+	ConnectionRefused FailureKind = "ConnectionRefused"
+	// ConnectionReset means that the connection was reset or closed before a response was received, e.g. by
+	// a sidecar rejecting it.
+	ConnectionReset FailureKind = "ConnectionReset"
+	// TLSHandshakeError means that the TLS handshake failed, e.g. because a certificate was rejected.
+	TLSHandshakeError FailureKind = "TLSHandshakeError"
+	// HTTPStatusError means that the call completed, but the Validator rejected it and some of the responses
+	// had an unsuccessful status code.
+	HTTPStatusError FailureKind = "HTTPStatusError"
+	// ParseError means that the responses of the call could not be parsed, or that some of them are missing.
+	ParseError FailureKind = "ParseError"
+	// CheckFailure means that the call completed with successful responses, which the Validator rejected.
+	CheckFailure FailureKind = "CheckFailure"
+)
+
+// failurePatterns of the error messages of each kind, in the order they are matched. TLS errors are matched
+// before resets, since a handshake can fail with the connection being closed.
+var failurePatterns = []struct {
+	kind     FailureKind
+	patterns []string
+}{
+	{DNSFailure, []string{"no such host", "server misbehaving", "name resolution"}},
+	{TLSHandshakeError, []string{"tls:", "x509:", "handshake", "does not look like a TLS"}},
+	{ConnectTimeout, []string{"i/o timeout", "deadline exceeded", "Client.Timeout", "timed out", "timeout"}},
+	{ConnectionRefused, []string{"connection refused"}},
+	{ConnectionReset, []string{"connection reset", "broken pipe", "EOF", "connection closed", "transport is closing"}},
+	{ParseError, []string{"unexpected number of responses", "no responses received", "malformed", "failed parsing"}},
+}
+
+// ClassifyError returns the kind of failure of a call that failed with the given error. For a *CallError,
+// this is the kind of its last attempt.
+func ClassifyError(err error) FailureKind {
+	if err == nil {
+		return NoFailure
+	}
+	if callErr, ok := err.(*CallError); ok {
+		if len(callErr.Attempts) == 0 {
+			return UnknownFailure
+		}
+		return callErr.Attempts[len(callErr.Attempts)-1].Kind
+	}
+	msg := err.Error()
+	for _, p := range failurePatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(msg, pattern) {
+				return p.kind
+			}
+		}
+	}
+	return UnknownFailure
+}
+
+// ClassifyAttempt returns the kind of failure of an attempt, from the error the call failed with, if any,
+// and otherwise from the responses rejected by the Validator with validatorErr.
+func ClassifyAttempt(resp client.ParsedResponses, callErr, validatorErr error) FailureKind {
+	if callErr != nil {
+		return ClassifyError(callErr)
+	}
+	if validatorErr == nil {
+		return NoFailure
+	}
+	if len(resp) == 0 {
+		return ParseError
+	}
+	if hasErrorStatus(resp) {
+		return HTTPStatusError
+	}
+	return CheckFailure
+}
+
+func hasErrorStatus(resp client.ParsedResponses) bool {
+	for _, r := range resp {
+		if r.Code != "" && !r.IsOK() {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpectFailure returns a Validator that requires the call to fail with the given kind of failure. The
+// attempts of a call with this Validator succeed once the call fails as expected.
+func ExpectFailure(kind FailureKind) func(client.ParsedResponses, error) error {
+	return func(resp client.ParsedResponses, err error) error {
+		actual := ClassifyAttempt(resp, err, nil)
+		if err == nil && kind == HTTPStatusError && hasErrorStatus(resp) {
+			actual = HTTPStatusError
+		}
+		if actual != kind {
+			if err != nil {
+				return fmt.Errorf("expected the call to fail with %s, but it failed with %s: %v", kind, actual, err)
+			}
+			return fmt.Errorf("expected the call to fail with %s, but it succeeded", kind)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"errors"
+	"testing"
+
+	"istio.io/istio/pkg/test/echo/client"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err      string
+		expected FailureKind
+	}{
+		{"rpc error: code = Unknown desc = dial tcp: lookup b.ns.svc.cluster.local on 10.0.0.10:53: no such host", DNSFailure},
+		{"rpc error: code = Unknown desc = Get http://b:80: dial tcp 10.0.0.1:80: i/o timeout", ConnectTimeout},
+		{"rpc error: code = DeadlineExceeded desc = context deadline exceeded", ConnectTimeout},
+		{"rpc error: code = Unknown desc = dial tcp 10.0.0.1:80: connect: connection refused", ConnectionRefused},
+		{"rpc error: code = Unknown desc = read tcp 10.0.0.2:1234->10.0.0.1:80: read: connection reset by peer", ConnectionReset},
+		{"rpc error: code = Unknown desc = Get http://b:80: EOF", ConnectionReset},
+		{"rpc error: code = Unknown desc = Get https://b:443: remote error: tls: handshake failure", TLSHandshakeError},
+		{"rpc error: code = Unknown desc = Get https://b:443: x509: certificate signed by unknown authority", TLSHandshakeError},
+		{"unexpected number of responses: expected 5, received 4", ParseError},
+		{"something else", UnknownFailure},
+	}
+	for _, c := range cases {
+		if actual := ClassifyError(errors.New(c.err)); actual != c.expected {
+			t.Errorf("%q: expected %s, got %s", c.err, c.expected, actual)
+		}
+	}
+
+	callErr := &CallError{Attempts: []CallAttempt{{Kind: ConnectionReset}, {Kind: HTTPStatusError}}}
+	if actual := ClassifyError(callErr); actual != HTTPStatusError || callErr.Kind() != HTTPStatusError {
+		t.Errorf("expected the kind of the last attempt, got %s", actual)
+	}
+	if actual := ClassifyError(nil); actual != NoFailure {
+		t.Errorf("expected no failure, got %s", actual)
+	}
+}
+
+func TestClassifyAttempt(t *testing.T) {
+	ok := client.ParsedResponses{{Code: "200"}}
+	denied := client.ParsedResponses{{Code: "200"}, {Code: "403"}}
+	rejected := errors.New("rejected")
+
+	if actual := ClassifyAttempt(denied, nil, rejected); actual != HTTPStatusError {
+		t.Errorf("denied: got %s", actual)
+	}
+	if actual := ClassifyAttempt(ok, nil, rejected); actual != CheckFailure {
+		t.Errorf("rejected: got %s", actual)
+	}
+	if actual := ClassifyAttempt(nil, nil, rejected); actual != ParseError {
+		t.Errorf("no responses: got %s", actual)
+	}
+	if actual := ClassifyAttempt(ok, nil, nil); actual != NoFailure {
+		t.Errorf("accepted: got %s", actual)
+	}
+
+	if err := ExpectFailure(HTTPStatusError)(denied, nil); err != nil {
+		t.Errorf("ExpectFailure(HTTPStatusError): %v", err)
+	}
+	if err := ExpectFailure(ConnectionReset)(nil, errors.New("connection reset by peer")); err != nil {
+		t.Errorf("ExpectFailure(ConnectionReset): %v", err)
+	}
+	if err := ExpectFailure(ConnectionReset)(ok, nil); err == nil {
+		t.Errorf("ExpectFailure(ConnectionReset) accepted a successful call")
+	}
+}
@@ -15,24 +15,22 @@
 package kube
 
 import (
-	"sync"
-
-	"github.com/hashicorp/go-multierror"
+	"strings"
 
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/echo"
-	kubeEnv "istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/echo/common"
 	"istio.io/istio/pkg/test/framework/resource"
-
-	kubeCore "k8s.io/api/core/v1"
+	"istio.io/istio/pkg/test/framework/timing"
 )
 
 var _ echo.Builder = &builder{}
 
 type builder struct {
-	ctx        resource.Context
-	references []*echo.Instance
-	configs    []echo.Config
+	ctx            resource.Context
+	references     []*echo.Instance
+	configs        []echo.Config
+	maxConcurrency int
 }
 
 func NewBuilder(ctx resource.Context) echo.Builder {
@@ -47,7 +45,18 @@ func (b *builder) With(i *echo.Instance, cfg echo.Config) echo.Builder {
 	return b
 }
 
+func (b *builder) WithMaxConcurrency(n int) echo.Builder {
+	b.maxConcurrency = n
+	return b
+}
+
 func (b *builder) Build() error {
+	services := make([]string, 0, len(b.configs))
+	for _, cfg := range b.configs {
+		services = append(services, cfg.Service)
+	}
+	defer timing.Start(timing.Deploy, strings.Join(services, ","))()
+
 	instances, err := b.newInstances()
 	if err != nil {
 		return err
@@ -76,83 +85,55 @@ func (b *builder) BuildOrFail(t test.Failer) {
 }
 
 func (b *builder) newInstances() ([]echo.Instance, error) {
-	instances := make([]echo.Instance, 0, len(b.configs))
-	for _, cfg := range b.configs {
-		inst, err := newInstance(b.ctx, cfg)
+	// Deploy the instances in parallel.
+	instances := make([]echo.Instance, len(b.configs))
+	if err := common.ForEach(len(b.configs), b.maxConcurrency, func(index int) error {
+		inst, err := newInstance(b.ctx, b.configs[index])
 		if err != nil {
-			return nil, err
+			return err
+		}
+		instances[index] = inst
+		return nil
+	}); err != nil {
+		// Close any instances that were successfully created.
+		for _, inst := range instances {
+			if inst != nil {
+				_ = inst.(*instance).Close()
+			}
 		}
-		instances = append(instances, inst)
+		return nil, err
 	}
 	return instances, nil
 }
 
 func (b *builder) initializeInstances(instances []echo.Instance) error {
-	env := b.ctx.Environment().(*kubeEnv.Environment)
-
-	// Wait to receive the k8s Endpoints for each Echo Instance.
-	wg := sync.WaitGroup{}
-	instanceEndpoints := make([]*kubeCore.Endpoints, len(instances))
-	aggregateErrMux := &sync.Mutex{}
-	var aggregateErr error
-	for i, inst := range instances {
-		wg.Add(1)
-
-		instanceIndex := i
-		serviceName := inst.Config().Service
-		serviceNamespace := inst.Config().Namespace.Name()
-
-		// Run the waits in parallel.
-		go func() {
-			defer wg.Done()
-
-			// Wait until all the endpoints are ready for this service
-			_, endpoints, err := env.WaitUntilServiceEndpointsAreReady(serviceNamespace, serviceName)
+	// Wait to receive the k8s Endpoints for each Echo Instance, and initialize its workloads, in parallel.
+	return common.ForEach(len(instances), b.maxConcurrency, func(index int) error {
+		inst := instances[index].(*instance)
+		cfg := inst.Config()
+
+		if cfg.DeployAsVM {
+			// Mock VMs are not selected by their service, register them and wait until they are ready.
+			endpoints, err := inst.registerVM()
 			if err != nil {
-				aggregateErrMux.Lock()
-				aggregateErr = multierror.Append(aggregateErr, err)
-				aggregateErrMux.Unlock()
-				return
+				return inst.startupError(b.ctx, err)
 			}
-			instanceEndpoints[instanceIndex] = endpoints
-		}()
-	}
-
-	wg.Wait()
-
-	if aggregateErr != nil {
-		return aggregateErr
-	}
+			return inst.initialize(endpoints)
+		}
 
-	// Initialize the workloads for each instance.
-	for i, inst := range instances {
-		if err := inst.(*instance).initialize(instanceEndpoints[i]); err != nil {
-			return err
+		// Wait until all the endpoints are ready for this service
+		_, endpoints, err := inst.accessor.WaitUntilServiceEndpointsAreReady(cfg.Namespace.Name(), cfg.Service,
+			inst.startupRetryOptions()...)
+		if err != nil {
+			return inst.startupError(b.ctx, err)
 		}
-	}
-	return nil
+		return inst.initialize(endpoints)
+	})
 }
 
 func (b *builder) waitUntilAllCallable(instances []echo.Instance) error {
 	// Now wait for each endpoint to be callable from all others.
-	wg := sync.WaitGroup{}
-	aggregateErrMux := &sync.Mutex{}
-	var aggregateErr error
-	for _, inst := range instances {
-		wg.Add(1)
-
-		source := inst
-		go func() {
-			defer wg.Done()
-
-			if err := source.WaitUntilCallable(instances...); err != nil {
-				aggregateErrMux.Lock()
-				aggregateErr = multierror.Append(aggregateErr, err)
-				aggregateErrMux.Unlock()
-			}
-		}()
-	}
-	wg.Wait()
-
-	return aggregateErr
+	return common.ForEach(len(instances), b.maxConcurrency, func(index int) error {
+		return instances[index].WaitUntilCallable(instances...)
+	})
 }
@@ -0,0 +1,75 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	debugLogLevel   = "debug"
+	defaultLogLevel = "warning"
+)
+
+var _ resource.Debugger = &instance{}
+
+// EnableDebug implements resource.Debugger, raising the log level of the sidecars.
+func (c *instance) EnableDebug() error {
+	return c.setSidecarLogLevel(debugLogLevel)
+}
+
+// DisableDebug implements resource.Debugger, restoring the log level of the sidecars.
+func (c *instance) DisableDebug() error {
+	return c.setSidecarLogLevel(defaultLogLevel)
+}
+
+// Snapshot implements resource.Debugger, writing the config dumps of the sidecars.
+func (c *instance) Snapshot(dir string) (err error) {
+	m := jsonpb.Marshaler{Indent: "  "}
+	for i, w := range c.workloads {
+		if w.sidecar == nil {
+			continue
+		}
+		cfg, e := w.sidecar.Config()
+		if e != nil {
+			err = multierror.Append(err, e)
+			continue
+		}
+		out, e := m.MarshalToString(cfg)
+		if e != nil {
+			err = multierror.Append(err, e)
+			continue
+		}
+		file := path.Join(dir, fmt.Sprintf("%s.%s-%d-config_dump.json", c.cfg.Namespace.Name(), c.cfg.Service, i))
+		err = multierror.Append(err, ioutil.WriteFile(file, []byte(out), 0644)).ErrorOrNil()
+	}
+	return
+}
+
+func (c *instance) setSidecarLogLevel(level string) (err error) {
+	for _, w := range c.workloads {
+		if w.sidecar != nil {
+			err = multierror.Append(err, w.sidecar.SetLogLevel(level)).ErrorOrNil()
+		}
+	}
+	return
+}
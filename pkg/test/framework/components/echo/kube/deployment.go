@@ -15,9 +15,16 @@
 package kube
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/core/image"
 	"istio.io/istio/pkg/test/scopes"
@@ -25,14 +32,27 @@ import (
 )
 
 const (
-	deploymentYAML = `
-{{- if .ServiceAccount }}
-apiVersion: v1
-kind: ServiceAccount
-metadata:
-  name: {{ .Service }}
----
-{{- end }}
+	// tlsCertDir is where the certificate for ports with application TLS is mounted.
+	tlsCertDir = "/etc/echo/certs"
+
+	// drainExitGracePeriod is the time added to the drain timeout of the echo server for it to exit, before
+	// the pod is killed.
+	drainExitGracePeriod = 5 * time.Second
+
+	// restrictedUser is the non-root user the application container runs as in the restricted mode. It differs
+	// from the user of the sidecar, whose traffic is not redirected.
+	restrictedUser = 1338
+
+	// nodeOSLabel and nodeArchLabel of the nodes, for scheduling onto the operating system and architecture.
+	nodeOSLabel   = "kubernetes.io/os"
+	nodeArchLabel = "kubernetes.io/arch"
+
+	// userVolumeMountAnnotation mounts volumes of the pod into the injected sidecar container.
+	userVolumeMountAnnotation = "sidecar.istio.io/userVolumeMount"
+	// faketimeDir is where libfaketime is copied to in the pods of workloads with a skewed clock.
+	faketimeDir = "/faketime"
+
+	serviceYAML = `
 apiVersion: v1
 kind: Service
 metadata:
@@ -48,86 +68,370 @@ metadata:
 spec:
 {{- if .Headless }}
   clusterIP: None
+{{- end }}
+{{- if .IPFamilies }}
+{{- if gt (len .IPFamilies) 1 }}
+  ipFamilyPolicy: PreferDualStack
+{{- else }}
+  ipFamilyPolicy: SingleStack
+{{- end }}
+  ipFamilies:
+{{- range .IPFamilies }}
+  - {{ . }}
+{{- end }}
 {{- end }}
   ports:
 {{- range $i, $p := .Ports }}
+{{- if $p.Unnamed }}
+  - name: port-{{ $p.ServicePort }}
+{{- else }}
   - name: {{ $p.Name }}
+{{- if $p.AppProtocol }}
+    appProtocol: {{ $p.AppProtocol }}
+{{- end }}
+{{- end }}
     port: {{ $p.ServicePort }}
     targetPort: {{ $p.InstancePort }}
+{{- if eq $p.Protocol "UDP" }}
+    protocol: UDP
 {{- end }}
+{{- end }}
+{{- if not .DeployAsVM }}
   selector:
     app: {{ .Service }}
+{{- end }}
+`
+
+	deploymentYAML = `
+{{- range $i, $sa := .ServiceAccounts }}
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{ $sa }}
+{{- end }}
+{{- range $i, $subset := .Subsets }}
 ---
 apiVersion: apps/v1
+{{- if $.StatefulSet }}
+kind: StatefulSet
+{{- else }}
 kind: Deployment
+{{- end }}
 metadata:
-  name: {{ .Service }}-{{ .Version }}
+  name: {{ $.Service }}-{{ $subset.Version }}
 spec:
   replicas: 1
+{{- if $.StatefulSet }}
+  serviceName: {{ $.Service }}
+  podManagementPolicy: Parallel
+{{- end }}
   selector:
     matchLabels:
-      app: {{ .Service }}
-      version: {{ .Version }}
-{{- if ne .Locality "" }}
-      istio-locality: {{ .Locality }}
+      app: {{ $.Service }}
+      version: {{ $subset.Version }}
+{{- if ne $subset.Locality "" }}
+      istio-locality: {{ $subset.Locality }}
 {{- end }}
   template:
     metadata:
       labels:
-        app: {{ .Service }}
-        version: {{ .Version }}
-{{- if ne .Locality "" }}
-        istio-locality: {{ .Locality }}
+        app: {{ $.Service }}
+        version: {{ $subset.Version }}
+{{- if ne $subset.Locality "" }}
+        istio-locality: {{ $subset.Locality }}
+{{- end }}
+{{- range $name, $value := $subset.Labels }}
+        {{ $name }}: {{ printf "%q" $value }}
 {{- end }}
       annotations:
         foo: bar
-{{- if .WorkloadAnnotations }}
-{{- range $name, $value := .WorkloadAnnotations }}
+{{- if $.DeployAsVM }}
+        sidecar.istio.io/inject: "false"
+{{- end }}
+{{- if $.Restricted }}
+        seccomp.security.alpha.kubernetes.io/pod: runtime/default
+{{- end }}
+{{- if $subset.Annotations }}
+{{- range $name, $value := $subset.Annotations }}
         {{ $name }}: {{ printf "%q" $value }}
 {{- end }}
 {{- end }}
-{{- if .IncludeInboundPorts }}
-        traffic.sidecar.istio.io/includeInboundPorts: "{{ .IncludeInboundPorts }}"
+{{- if $.IncludeInboundPorts }}
+        traffic.sidecar.istio.io/includeInboundPorts: "{{ $.IncludeInboundPorts }}"
 {{- end }}
     spec:
-{{- if .ServiceAccount }}
-      serviceAccountName: {{ .Service }}
+{{- if $.HostNetwork }}
+      hostNetwork: true
+      dnsPolicy: ClusterFirstWithHostNet
+{{- end }}
+{{- if $.TerminationGracePeriodSeconds }}
+      terminationGracePeriodSeconds: {{ $.TerminationGracePeriodSeconds }}
+{{- end }}
+{{- if ne $subset.ServiceAccount "default" }}
+      serviceAccountName: {{ $subset.ServiceAccount }}
+{{- end }}
+{{- if $.NodeSelector }}
+      nodeSelector:
+{{- range $name, $value := $.NodeSelector }}
+        {{ $name }}: {{ printf "%q" $value }}
+{{- end }}
+{{- end }}
+{{- if $.Tolerations }}
+      tolerations:
+{{- range $i, $t := $.Tolerations }}
+      - operator: {{ if $t.Operator }}{{ $t.Operator }}{{ else }}Equal{{ end }}
+{{- if $t.Key }}
+        key: {{ printf "%q" $t.Key }}
+{{- end }}
+{{- if $t.Value }}
+        value: {{ printf "%q" $t.Value }}
+{{- end }}
+{{- if $t.Effect }}
+        effect: {{ $t.Effect }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if and $.LocalityNodeAffinity $subset.Region }}
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+            - matchExpressions:
+              - key: failure-domain.beta.kubernetes.io/region
+                operator: In
+                values:
+                - {{ $subset.Region }}
+{{- if $subset.Zone }}
+              - key: failure-domain.beta.kubernetes.io/zone
+                operator: In
+                values:
+                - {{ $subset.Zone }}
+{{- end }}
+{{- end }}
+{{- if $subset.ClockSkew }}
+      initContainers:
+      - name: faketime
+        image: {{ $.FaketimeImage }}
+        imagePullPolicy: {{ $.PullPolicy }}
+        command: ["cp", "{{ $.FaketimeLibrary }}", "{{ $.FaketimeDir }}/libfaketime.so.1"]
+        volumeMounts:
+        - name: faketime-lib
+          mountPath: {{ $.FaketimeDir }}
 {{- end }}
       containers:
       - name: app
-        image: {{ .Hub }}/app:{{ .Tag }}
-        imagePullPolicy: {{ .PullPolicy }}
-        args:
-{{- range $i, $p := .ContainerPorts }}
-{{- if eq .Protocol "GRPC" }}
-          - --grpc
+{{- if $.Image }}
+        image: {{ $.Image }}
+{{- else if $.DeployAsVM }}
+        image: {{ $.Hub }}/app_sidecar:{{ $.Tag }}
 {{- else }}
-          - --port
+        image: {{ $.Hub }}/app:{{ $.Tag }}
 {{- end }}
-          - "{{ $p.Port }}"
+        imagePullPolicy: {{ $.PullPolicy }}
+{{- if $.Restricted }}
+        securityContext:
+          runAsNonRoot: true
+          runAsUser: {{ $.RestrictedUser }}
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+            - ALL
+{{- end }}
+{{- if $.DeployAsVM }}
+        securityContext:
+          capabilities:
+            add:
+            - NET_ADMIN
+        env:
+        - name: ECHO_ARGS
+          value: "{{ $.VM.EchoArgs }} --version {{ $subset.Version }}{{ if $subset.Locality }} --locality {{ $subset.Locality }}{{ end }}"
+        - name: ISTIO_SERVICE
+          value: {{ $.Service }}
+        - name: ISTIO_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: ISTIO_SYSTEM_NAMESPACE
+          value: {{ $.VM.SystemNamespace }}
+        - name: POD_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.name
+        - name: ISTIO_CP_AUTH
+          value: MUTUAL_TLS
+        - name: ISTIO_PILOT_PORT
+          value: "15011"
+        - name: ENVOY_USER
+          value: istio-proxy
+        - name: ISTIO_AGENT_FLAGS
+          value: "--statusPort 15020 --applicationPorts {{ $.VM.InboundPorts }}"
+        - name: ISTIO_INBOUND_INTERCEPTION_MODE
+          value: REDIRECT
+        - name: ISTIO_INBOUND_PORTS
+          value: "{{ $.VM.InboundPorts }}"
+        - name: ISTIO_SERVICE_CIDR
+          value: "*"
+        - name: ISTIO_META_CONFIG_NAMESPACE
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.namespace
+        - name: ISTIO_METAJSON_LABELS
+          value: {{ printf "%q" $subset.MetaJSONLabels }}
+        - name: ISTIO_META_INTERCEPTION_MODE
+          value: REDIRECT
+{{- else }}
+        args:
+{{- range $i, $a := $.EchoArgs }}
+          - {{ printf "%q" $a }}
 {{- end }}
           - --version
-          - "{{ .Version }}"
+          - "{{ $subset.Version }}"
+{{- if $subset.Locality }}
+          - --locality
+          - "{{ $subset.Locality }}"
+{{- end }}
+{{- end }}
         ports:
-{{- range $i, $p := .ContainerPorts }}
+{{- range $i, $p := $.ContainerPorts }}
         - containerPort: {{ $p.Port }} 
+{{- if eq .Protocol "UDP" }}
+          protocol: UDP
+{{- end }}
+{{- with index $.HostPorts $p.Port }}
+          hostPort: {{ . }}
+{{- end }}
 {{- if eq .Port 3333 }}
           name: tcp-health-port
 {{- end }}
 {{- end }}
         readinessProbe:
           httpGet:
-            path: /
+            path: {{ $.Readiness.Path }}
             port: 8080
-          initialDelaySeconds: 10
-          periodSeconds: 10
-          failureThreshold: 10
+          initialDelaySeconds: {{ $.Readiness.InitialDelaySeconds }}
+          periodSeconds: {{ $.Readiness.PeriodSeconds }}
+          timeoutSeconds: {{ $.Readiness.TimeoutSeconds }}
+          failureThreshold: {{ $.Readiness.FailureThreshold }}
         livenessProbe:
           tcpSocket:
             port: tcp-health-port
           initialDelaySeconds: 10
           periodSeconds: 10
           failureThreshold: 10
+{{- if or $.TLSSettings $.DeployAsVM }}
+        volumeMounts:
+{{- if $.TLSSettings }}
+        - name: tls-certs
+          mountPath: {{ $.TLSCertDir }}
+          readOnly: true
+{{- end }}
+{{- if $.DeployAsVM }}
+        - name: istio-certs
+          mountPath: /etc/certs
+          readOnly: true
+{{- end }}
+{{- end }}
+{{- if or $.TLSSettings $.DeployAsVM $subset.ClockSkew }}
+      volumes:
+{{- if $.TLSSettings }}
+      - name: tls-certs
+        secret:
+          secretName: {{ $.Service }}-tls-certs
+{{- end }}
+{{- if $.DeployAsVM }}
+      - name: istio-certs
+        secret:
+          secretName: istio.{{ $subset.ServiceAccount }}
+{{- end }}
+{{- if $subset.ClockSkew }}
+      # Mounted into the sidecar through the userVolumeMount annotation, so that libfaketime is preloaded
+      # into Envoy.
+      - name: faketime-lib
+        emptyDir: {}
+      - name: faketime-preload
+        configMap:
+          name: {{ $.Service }}-{{ $subset.Version }}-faketime
+      - name: faketime-rc
+        configMap:
+          name: {{ $.Service }}-{{ $subset.Version }}-faketime
+{{- end }}
+{{- end }}
+{{- if $subset.ClockSkew }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ $.Service }}-{{ $subset.Version }}-faketime
+data:
+  ld.so.preload: |
+    {{ $.FaketimeDir }}/libfaketime.so.1
+  faketimerc: "{{ $subset.ClockSkew }}"
+{{- end }}
+{{- end }}
+{{- if .MetadataExchange }}
+---
+# Tampers with the peer metadata exchanged by the metadata exchange filter. The first Lua filter is inserted
+# at the front of the chain, before the metadata exchange filter, for the metadata received by inbound
+# requests and sent by inbound responses. The second one is inserted before the router, for the metadata sent
+# by outbound requests and received by outbound responses.
+apiVersion: networking.istio.io/v1alpha3
+kind: EnvoyFilter
+metadata:
+  name: {{ .Service }}-metadata-exchange
+spec:
+  workloadSelector:
+    labels:
+      app: {{ .Service }}
+  configPatches:
+{{- range $i, $sub := .MetadataExchangeBefore }}
+  - applyTo: HTTP_FILTER
+    match:
+      context: ANY
+      listener:
+        filterChain:
+          filter:
+            name: envoy.http_connection_manager
+{{- if $sub }}
+            subFilter:
+              name: {{ $sub }}
+{{- end }}
+    patch:
+      operation: INSERT_BEFORE
+      value:
+        name: envoy.lua
+        config:
+          inline_code: |
+            function tamper(headers)
+              for _, name in ipairs({"x-envoy-peer-metadata", "x-envoy-peer-metadata-id"}) do
+                if headers:get(name) ~= nil then
+{{- if eq $.MetadataExchange "corrupted" }}
+                  headers:replace(name, "!corrupted!")
+{{- else }}
+                  headers:remove(name)
+{{- end }}
+                end
+              end
+            end
+            function envoy_on_request(handle)
+              tamper(handle:headers())
+            end
+            function envoy_on_response(handle)
+              tamper(handle:headers())
+            end
+{{- end }}
+{{- end }}
+{{- if .TLSSettings }}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Service }}-tls-certs
+type: Opaque
+stringData:
+  cert.pem: {{ printf "%q" .TLSSettings.Cert }}
+  key.pem: {{ printf "%q" .TLSSettings.Key }}
+{{- end }}
 ---
 apiVersion: v1
 kind: Secret
@@ -151,26 +455,55 @@ iFxKGPAjB-g"
 )
 
 var (
+	serviceTemplate    *template.Template
 	deploymentTemplate *template.Template
 )
 
 func init() {
+	serviceTemplate = template.New("echo_service")
+	if _, err := serviceTemplate.Parse(serviceYAML); err != nil {
+		panic(fmt.Sprintf("unable to parse echo service template: %v", err))
+	}
+
 	deploymentTemplate = template.New("echo_deployment")
 	if _, err := deploymentTemplate.Parse(deploymentYAML); err != nil {
 		panic(fmt.Sprintf("unable to parse echo deployment template: %v", err))
 	}
 }
 
-func generateYAML(cfg echo.Config) (string, error) {
-	// Create the parameters for the YAML template.
-	settings, err := image.SettingsFromCommandLine()
-	if err != nil {
-		return "", err
-	}
+// vmParams are the template parameters for echo instances deployed as a mock VM.
+type vmParams struct {
+	// EchoArgs is the space separated argument list of the echo server.
+	EchoArgs string
+	// InboundPorts is the comma separated list of application ports captured by istio-proxy.
+	InboundPorts string
+	// SystemNamespace where the Istio control plane is running.
+	SystemNamespace string
+}
 
-	// Separate the annotations.
-	serviceAnnotations := make(map[string]string)
-	workloadAnnotations := make(map[string]string)
+// subsetParams are the template parameters for a single deployment of the service.
+type subsetParams struct {
+	Version     string
+	Labels      map[string]string
+	Annotations map[string]string
+	// MetaJSONLabels are the labels of the workload, encoded as JSON for the proxy metadata of mock VMs.
+	MetaJSONLabels string
+	// Locality of the workloads, with its region and zone for node affinity.
+	Locality string
+	Region   string
+	Zone     string
+	// ServiceAccount of the workloads, "default" if no service account is created. For mock VMs, istio-proxy
+	// uses the Citadel issued certificate of the service account.
+	ServiceAccount string
+	// ClockSkew of the sidecars in seconds, in the format of the libfaketime offsets (e.g. "+3600"). Empty if
+	// the clock isn't skewed.
+	ClockSkew string
+}
+
+// splitAnnotations separates the service and workload annotations of the Config.
+func splitAnnotations(cfg echo.Config) (serviceAnnotations, workloadAnnotations map[string]string) {
+	serviceAnnotations = make(map[string]string)
+	workloadAnnotations = make(map[string]string)
 	for k, v := range cfg.Annotations {
 		switch k.Type {
 		case echo.ServiceAnnotation:
@@ -181,23 +514,441 @@ func generateYAML(cfg echo.Config) (string, error) {
 			scopes.Framework.Warnf("annotation %s with unknown type %s", k.Name, k.Type)
 		}
 	}
+	return serviceAnnotations, workloadAnnotations
+}
 
+// generateServiceYAML generates the Service of the Config. In a multicluster topology it is also applied to
+// the clusters without workloads of the service, so that its name resolves there.
+func generateServiceYAML(cfg echo.Config) (string, error) {
+	serviceAnnotations, _ := splitAnnotations(cfg)
 	params := map[string]interface{}{
-		"Hub":                 settings.Hub,
-		"Tag":                 settings.Tag,
-		"PullPolicy":          settings.PullPolicy,
-		"Service":             cfg.Service,
-		"Version":             cfg.Version,
-		"Headless":            cfg.Headless,
-		"Locality":            cfg.Locality,
-		"ServiceAccount":      cfg.ServiceAccount,
-		"Ports":               cfg.Ports,
-		"ContainerPorts":      getContainerPorts(cfg.Ports),
-		"ServiceAnnotations":  serviceAnnotations,
-		"WorkloadAnnotations": workloadAnnotations,
-		"IncludeInboundPorts": cfg.IncludeInboundPorts,
+		"Service":            cfg.Service,
+		"Headless":           cfg.Headless,
+		"IPFamilies":         cfg.IPFamilies,
+		"Ports":              cfg.Ports,
+		"ServiceAnnotations": serviceAnnotations,
+		"DeployAsVM":         cfg.DeployAsVM,
+	}
+	return tmpl.Execute(serviceTemplate, params)
+}
+
+// generateYAML generates the Service and the workloads of the Config, deployed to the named cluster.
+func generateYAML(cfg echo.Config, systemNamespace, cluster string) (string, error) {
+	// Create the parameters for the YAML template.
+	settings, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return "", err
+	}
+
+	serviceYAML, err := generateServiceYAML(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cfg.Annotations.Validate(); err != nil {
+		return "", err
+	}
+	if cfg.NodeOS != "" && cfg.NodeOS != "linux" && !cfg.Naked {
+		return "", fmt.Errorf("the sidecar doesn't run on %s nodes, the workloads must be Naked", cfg.NodeOS)
+	}
+	if cfg.Restricted {
+		if cfg.DeployAsVM {
+			return "", errors.New("mock VMs require the NET_ADMIN capability, they cannot be restricted")
+		}
+		for _, p := range cfg.Ports {
+			if p.InstancePort > 0 && p.InstancePort < 1024 {
+				return "", fmt.Errorf("port %s: restricted workloads cannot listen on the privileged port %d",
+					p.Name, p.InstancePort)
+			}
+		}
+	}
+	if cfg.HostNetwork && cfg.DeployAsVM {
+		return "", errors.New("mock VMs cannot be deployed on the host network")
+	}
+	hostPorts, err := getHostPorts(cfg)
+	if err != nil {
+		return "", err
+	}
+	switch cfg.MetadataExchange {
+	case echo.MetadataExchangeEnabled:
+	case echo.MetadataExchangeDisabled, echo.MetadataExchangeCorrupted:
+		if cfg.Naked {
+			return "", fmt.Errorf("metadata exchange %s requires a sidecar, but the workloads are Naked", cfg.MetadataExchange)
+		}
+	default:
+		return "", fmt.Errorf("unknown metadata exchange mode %q", cfg.MetadataExchange)
+	}
+	_, workloadAnnotations := splitAnnotations(cfg)
+
+	subsets, err := getSubsets(cfg, workloadAnnotations)
+	if err != nil {
+		return "", err
+	}
+
+	if hasClockSkew(subsets) && settings.FaketimeImage == "" {
+		return "", errors.New("a clock skew requires an image with libfaketime, set with --istio.test.faketime.image")
+	}
+
+	// Collect the ports where the application terminates TLS.
+	var tlsPorts []int
+	for _, p := range cfg.Ports {
+		if !p.TLS {
+			continue
+		}
+		if cfg.TLSSettings == nil {
+			return "", fmt.Errorf("port %s has TLS enabled, but no TLSSettings were provided", p.Name)
+		}
+		if p.InstancePort == httpReadinessPort {
+			return "", fmt.Errorf("port %s: TLS is not supported on the readiness port %d", p.Name, httpReadinessPort)
+		}
+		tlsPorts = append(tlsPorts, p.InstancePort)
+	}
+	if cfg.TLSSettings != nil && (cfg.TLSSettings.Cert == "" || cfg.TLSSettings.Key == "") {
+		return "", errors.New("TLSSettings requires both a certificate and a key")
+	}
+
+	containerPorts := getContainerPorts(cfg.Ports)
+	echoArgs := getEchoArgs(cfg, containerPorts, tlsPorts, cluster)
+
+	var vm *vmParams
+	if cfg.DeployAsVM {
+		inboundPorts := make([]string, 0, len(containerPorts))
+		for _, p := range containerPorts {
+			inboundPorts = append(inboundPorts, strconv.Itoa(p.Port))
+		}
+		vm = &vmParams{
+			EchoArgs:        strings.Join(echoArgs, " "),
+			InboundPorts:    strings.Join(inboundPorts, ","),
+			SystemNamespace: systemNamespace,
+		}
+	}
+
+	params := map[string]interface{}{
+		"Hub":                           settings.Hub,
+		"Tag":                           settings.Tag,
+		"PullPolicy":                    settings.PullPolicy,
+		"Service":                       cfg.Service,
+		"Subsets":                       subsets,
+		"Headless":                      cfg.Headless,
+		"LocalityNodeAffinity":          cfg.LocalityNodeAffinity,
+		"StatefulSet":                   cfg.StatefulSet,
+		"TerminationGracePeriodSeconds": terminationGracePeriodSeconds(cfg.DrainTimeout),
+		"Readiness":                     getReadinessParams(cfg.ReadinessProbe),
+		"NodeSelector":                  getNodeSelector(cfg),
+		"Image":                         cfg.Image,
+		"Tolerations":                   cfg.Tolerations,
+		"ServiceAccounts":               getServiceAccounts(subsets),
+		"Ports":                         cfg.Ports,
+		"ContainerPorts":                containerPorts,
+		"EchoArgs":                      echoArgs,
+		"IncludeInboundPorts":           cfg.IncludeInboundPorts,
+		"TLSSettings":                   cfg.TLSSettings,
+		"TLSCertDir":                    tlsCertDir,
+		"DeployAsVM":                    cfg.DeployAsVM,
+		"VM":                            vm,
+		"Restricted":                    cfg.Restricted,
+		"HostNetwork":                   cfg.HostNetwork,
+		"HostPorts":                     hostPorts,
+		"RestrictedUser":                restrictedUser,
+		"FaketimeImage":                 settings.FaketimeImage,
+		"FaketimeLibrary":               settings.FaketimeLibrary,
+		"FaketimeDir":                   faketimeDir,
+		"MetadataExchange":              string(cfg.MetadataExchange),
+		// The Lua filters tampering with the metadata are inserted at the front of the chain and before the
+		// router, i.e. on both sides of the metadata exchange filter.
+		"MetadataExchangeBefore": []string{"", "envoy.router"},
 	}
 
 	// Generate the YAML content.
-	return tmpl.Execute(deploymentTemplate, params)
+	deploymentYAML, err := tmpl.Execute(deploymentTemplate, params)
+	if err != nil {
+		return "", err
+	}
+	return serviceYAML + deploymentYAML, nil
+}
+
+// getHostPorts returns the host ports of the workloads, by instance port.
+func getHostPorts(cfg echo.Config) (map[int]int, error) {
+	out := make(map[int]int)
+	if cfg.HostNetwork {
+		return out, nil
+	}
+	used := make(map[int]string)
+	for _, p := range cfg.Ports {
+		if p.HostPort == 0 {
+			continue
+		}
+		if other, ok := used[p.HostPort]; ok {
+			return nil, fmt.Errorf("ports %s and %s use the same host port %d", other, p.Name, p.HostPort)
+		}
+		used[p.HostPort] = p.Name
+		out[p.InstancePort] = p.HostPort
+	}
+	return out, nil
+}
+
+// terminationGracePeriodSeconds returns the termination grace period of pods whose echo server drains for the
+// given timeout, leaving time for the server to exit after the drain, or 0 for the default grace period.
+func terminationGracePeriodSeconds(drainTimeout time.Duration) int {
+	if drainTimeout <= 0 {
+		return 0
+	}
+	return seconds(drainTimeout + drainExitGracePeriod)
+}
+
+// getNodeSelector returns the node selector of the workloads, including the operating system and architecture
+// of the nodes.
+func getNodeSelector(cfg echo.Config) map[string]string {
+	if cfg.NodeOS == "" && cfg.NodeArch == "" {
+		return cfg.NodeSelector
+	}
+	out := make(map[string]string, len(cfg.NodeSelector)+2)
+	for k, v := range cfg.NodeSelector {
+		out[k] = v
+	}
+	if cfg.NodeOS != "" {
+		out[nodeOSLabel] = cfg.NodeOS
+	}
+	if cfg.NodeArch != "" {
+		out[nodeArchLabel] = cfg.NodeArch
+	}
+	return out
+}
+
+// readinessParams of the readiness probe of the application container.
+type readinessParams struct {
+	Path                string
+	InitialDelaySeconds int
+	PeriodSeconds       int
+	TimeoutSeconds      int
+	FailureThreshold    int
+}
+
+// getReadinessParams returns the parameters of the given readiness probe, filling in the defaults.
+func getReadinessParams(p *echo.ReadinessProbe) readinessParams {
+	out := readinessParams{
+		Path:                "/",
+		InitialDelaySeconds: 10,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      1,
+		FailureThreshold:    10,
+	}
+	if p == nil {
+		return out
+	}
+	if p.Path != "" {
+		out.Path = p.Path
+	}
+	if p.InitialDelay > 0 {
+		out.InitialDelaySeconds = seconds(p.InitialDelay)
+	}
+	if p.Period > 0 {
+		out.PeriodSeconds = seconds(p.Period)
+	}
+	if p.Timeout > 0 {
+		out.TimeoutSeconds = seconds(p.Timeout)
+	}
+	if p.FailureThreshold > 0 {
+		out.FailureThreshold = p.FailureThreshold
+	}
+	return out
+}
+
+// seconds rounds the given duration up to whole seconds.
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// getEchoArgs returns the arguments of the echo server for the given container ports.
+func getEchoArgs(cfg echo.Config, containerPorts model.PortList, tlsPorts []int, cluster string) []string {
+	var args []string
+	if cluster != "" {
+		args = append(args, "--cluster", cluster)
+	}
+	if cfg.DrainTimeout > 0 {
+		args = append(args, "--drain-timeout", cfg.DrainTimeout.String())
+	}
+	if cfg.TLSSettings != nil {
+		args = append(args,
+			"--crt", tlsCertDir+"/cert.pem",
+			"--key", tlsCertDir+"/key.pem")
+		for _, p := range tlsPorts {
+			args = append(args, "--tls", strconv.Itoa(p))
+		}
+	}
+	for _, p := range containerPorts {
+		var flag string
+		switch p.Protocol {
+		case protocol.GRPC:
+			flag = "--grpc"
+		case protocol.UDP:
+			flag = "--udp"
+		case protocol.MySQL:
+			flag = "--server-first"
+		case protocol.Thrift:
+			flag = "--thrift"
+		case protocol.Dubbo:
+			flag = "--dubbo"
+		default:
+			flag = "--port"
+		}
+		args = append(args, flag, strconv.Itoa(p.Port))
+	}
+	return args
+}
+
+// getSubsets returns the parameters for each deployment of the service. Subset annotations override the
+// workload annotations of the Config.
+func getSubsets(cfg echo.Config, workloadAnnotations map[string]string) ([]subsetParams, error) {
+	subsets := cfg.Subsets
+	if len(subsets) == 0 {
+		subsets = []echo.SubsetConfig{{Version: cfg.Version}}
+	}
+
+	out := make([]subsetParams, 0, len(subsets))
+	versions := make(map[string]bool)
+	for _, subset := range subsets {
+		if subset.Version == "" {
+			return nil, fmt.Errorf("subset of %s has no version", cfg.Service)
+		}
+		if versions[subset.Version] {
+			return nil, fmt.Errorf("duplicate subset %s for %s", subset.Version, cfg.Service)
+		}
+		versions[subset.Version] = true
+
+		if err := subset.Annotations.Validate(); err != nil {
+			return nil, fmt.Errorf("subset %s of %s: %v", subset.Version, cfg.Service, err)
+		}
+		annotations := make(map[string]string)
+		for k, v := range workloadAnnotations {
+			annotations[k] = v
+		}
+		for k, v := range subset.Annotations {
+			if k.Type != echo.WorkloadAnnotation {
+				return nil, fmt.Errorf("subset %s of %s: annotation %s is not a workload annotation",
+					subset.Version, cfg.Service, k.Name)
+			}
+			annotations[k.Name] = v.Value
+		}
+
+		// Labels of the subset override the labels of the Config.
+		extraLabels := make(map[string]string)
+		for k, v := range cfg.Labels {
+			extraLabels[k] = v
+		}
+		for k, v := range subset.Labels {
+			extraLabels[k] = v
+		}
+		labels := map[string]string{
+			"app":     cfg.Service,
+			"version": subset.Version,
+		}
+		for k, v := range extraLabels {
+			if _, ok := labels[k]; ok {
+				return nil, fmt.Errorf("subset %s of %s: label %s is reserved", subset.Version, cfg.Service, k)
+			}
+			labels[k] = v
+		}
+		metaJSONLabels, err := json.Marshal(labels)
+		if err != nil {
+			return nil, err
+		}
+
+		clockSkew, err := getClockSkew(cfg, subset, annotations)
+		if err != nil {
+			return nil, fmt.Errorf("subset %s of %s: %v", subset.Version, cfg.Service, err)
+		}
+
+		locality := subset.Locality
+		if locality == "" {
+			locality = cfg.Locality
+		}
+		var region, zone string
+		if locality != "" {
+			parts := strings.SplitN(locality, ".", 3)
+			region = parts[0]
+			if len(parts) > 1 {
+				zone = parts[1]
+			}
+		}
+
+		out = append(out, subsetParams{
+			Version:        subset.Version,
+			Labels:         extraLabels,
+			Annotations:    annotations,
+			MetaJSONLabels: string(metaJSONLabels),
+			Locality:       locality,
+			Region:         region,
+			Zone:           zone,
+			ServiceAccount: cfg.ServiceAccountFor(subset.Version),
+			ClockSkew:      clockSkew,
+		})
+	}
+	return out, nil
+}
+
+// getClockSkew returns the libfaketime offset of the sidecars of the subset, and adds the mounts of the
+// faketime volumes into the sidecar to its annotations.
+func getClockSkew(cfg echo.Config, subset echo.SubsetConfig, annotations map[string]string) (string, error) {
+	skew := subset.ClockSkew
+	if skew == 0 {
+		skew = cfg.ClockSkew
+	}
+	if skew == 0 {
+		return "", nil
+	}
+	if cfg.Naked || cfg.DeployAsVM {
+		return "", errors.New("a clock skew requires an injected sidecar")
+	}
+	if skew%time.Second != 0 {
+		return "", fmt.Errorf("clock skew %v is not a whole number of seconds", skew)
+	}
+
+	mounts := make(map[string]interface{})
+	if v, ok := annotations[userVolumeMountAnnotation]; ok {
+		if err := json.Unmarshal([]byte(v), &mounts); err != nil {
+			return "", fmt.Errorf("invalid annotation %s: %v", userVolumeMountAnnotation, err)
+		}
+	}
+	mounts["faketime-lib"] = map[string]interface{}{"mountPath": faketimeDir, "readOnly": true}
+	mounts["faketime-preload"] = map[string]interface{}{
+		"mountPath": "/etc/ld.so.preload",
+		"subPath":   "ld.so.preload",
+		"readOnly":  true,
+	}
+	mounts["faketime-rc"] = map[string]interface{}{
+		"mountPath": "/etc/faketimerc",
+		"subPath":   "faketimerc",
+		"readOnly":  true,
+	}
+	b, err := json.Marshal(mounts)
+	if err != nil {
+		return "", err
+	}
+	annotations[userVolumeMountAnnotation] = string(b)
+	return fmt.Sprintf("%+d", int64(skew/time.Second)), nil
+}
+
+// hasClockSkew indicates whether the sidecars of any of the subsets have a skewed clock.
+func hasClockSkew(subsets []subsetParams) bool {
+	for _, s := range subsets {
+		if s.ClockSkew != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getServiceAccounts returns the service accounts to create for the subsets, i.e. all except the default one.
+func getServiceAccounts(subsets []subsetParams) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, s := range subsets {
+		if s.ServiceAccount == "default" || seen[s.ServiceAccount] {
+			continue
+		}
+		seen[s.ServiceAccount] = true
+		out = append(out, s.ServiceAccount)
+	}
+	return out
 }
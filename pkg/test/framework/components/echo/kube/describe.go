@@ -0,0 +1,49 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"istio.io/istio/pkg/test/framework/topology"
+)
+
+var _ topology.Describer = &instance{}
+
+// DescribeTopology implements topology.Describer.
+func (c *instance) DescribeTopology(t *topology.Topology) error {
+	s := topology.Service{
+		Name:      c.cfg.Service,
+		Namespace: c.cfg.Namespace.Name(),
+		Cluster:   c.cfg.Cluster,
+		Sidecar:   !c.cfg.Naked,
+	}
+	if c.cfg.Version != "" {
+		s.Versions = append(s.Versions, c.cfg.Version)
+	}
+	for _, subset := range c.cfg.Subsets {
+		if subset.Version != "" && subset.Version != c.cfg.Version {
+			s.Versions = append(s.Versions, subset.Version)
+		}
+	}
+	for _, p := range c.cfg.Ports {
+		s.Ports = append(s.Ports, topology.Port{
+			Name:         p.Name,
+			Protocol:     string(p.Protocol),
+			ServicePort:  p.ServicePort,
+			InstancePort: p.InstancePort,
+		})
+	}
+	t.AddService(s)
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"strings"
+
+	kubeCore "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	// injectorSelector selects the pods of the sidecar injector.
+	injectorSelector = "istio=sidecar-injector"
+	// injectorLogLines is the number of trailing lines of the injector and CNI plugin logs included in the
+	// diagnostics.
+	injectorLogLines = 50
+)
+
+// startupRetryOptions returns the options for waiting until the workloads of the instance are ready.
+func (c *instance) startupRetryOptions() []retry.Option {
+	if c.cfg.StartupTimeout > 0 {
+		return []retry.Option{retry.Timeout(c.cfg.StartupTimeout)}
+	}
+	return nil
+}
+
+// startupError logs the diagnostics of the workloads of the instance, which failed to become ready with the
+// given error, and returns an error including a summary of the pod statuses. Without it a failed image pull or
+// a rejected injection only surfaces as a timeout.
+func (c *instance) startupError(ctx resource.Context, err error) error {
+	ns := c.cfg.Namespace.Name()
+	pods, e := c.accessor.GetPods(ns, "app="+c.cfg.Service)
+	if e != nil {
+		scopes.CI.Errorf("Error getting the pods of echo %s: %v", c.cfg.Service, e)
+	}
+
+	diag := &strings.Builder{}
+	statuses := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		status := podStatus(pod)
+		statuses = append(statuses, fmt.Sprintf("%s: %s", pod.Name, status))
+		fmt.Fprintf(diag, "=== Pod %s/%s: %s\n", ns, pod.Name, status)
+		events, e := c.accessor.GetEvents(ns, pod.Name)
+		if e != nil {
+			fmt.Fprintf(diag, "error getting events: %v\n", e)
+			continue
+		}
+		for _, event := range events {
+			fmt.Fprintf(diag, "%s\t%s\t%s\n", event.Type, event.Reason, event.Message)
+		}
+	}
+	if len(pods) == 0 {
+		// The pods were likely not created because the injection failed.
+		fmt.Fprintf(diag, "=== No pods found for echo %s/%s\n", ns, c.cfg.Service)
+		statuses = append(statuses, "no pods")
+	}
+
+	if cfg, e := istio.DefaultConfig(ctx); e != nil {
+		fmt.Fprintf(diag, "=== Error getting the Istio config: %v\n", e)
+	} else {
+		c.writeInjectorLogs(diag, cfg.SystemNamespace)
+		c.writeCNILogs(diag, cfg.CNINamespace, pods)
+	}
+
+	fmt.Fprintf(diag, "=== Generated deployment\n%s\n", c.generatedYAML)
+	scopes.CI.Errorf("Echo %s/%s failed to become ready: %v\n%s", ns, c.cfg.Service, err, diag.String())
+
+	return fmt.Errorf("echo %s/%s failed to become ready (%s): %v",
+		ns, c.cfg.Service, strings.Join(statuses, ", "), err)
+}
+
+// writeInjectorLogs writes the trailing logs of the sidecar injector pods in the given namespace.
+func (c *instance) writeInjectorLogs(diag *strings.Builder, systemNamespace string) {
+	pods, err := c.accessor.GetPods(systemNamespace, injectorSelector)
+	if err != nil {
+		fmt.Fprintf(diag, "=== Error getting the sidecar injector pods: %v\n", err)
+		return
+	}
+	for _, pod := range pods {
+		c.writeTrailingLogs(diag, pod)
+	}
+}
+
+// writeCNILogs writes the trailing logs of the pods of the Istio CNI plugin in the given namespace that run on
+// the nodes of the given echo pods, since the CNI plugin sets up the traffic redirection of the pods instead of
+// the istio-init container when it is installed. Nothing is written if the CNI plugin is not installed.
+func (c *instance) writeCNILogs(diag *strings.Builder, cniNamespace string, echoPods []kubeCore.Pod) {
+	nodes := make(map[string]bool, len(echoPods))
+	for _, pod := range echoPods {
+		if pod.Spec.NodeName != "" {
+			nodes[pod.Spec.NodeName] = true
+		}
+	}
+	if len(nodes) == 0 {
+		return
+	}
+	pods, err := c.accessor.GetPods(cniNamespace, istio.CNINodeSelector)
+	if err != nil {
+		fmt.Fprintf(diag, "=== Error getting the CNI plugin pods: %v\n", err)
+		return
+	}
+	for _, pod := range pods {
+		if nodes[pod.Spec.NodeName] {
+			c.writeTrailingLogs(diag, pod)
+		}
+	}
+}
+
+// writeTrailingLogs writes the trailing logs of all containers of the given pod.
+func (c *instance) writeTrailingLogs(diag *strings.Builder, pod kubeCore.Pod) {
+	for _, container := range pod.Spec.Containers {
+		l, err := c.accessor.Logs(pod.Namespace, pod.Name, container.Name, false /* previousLog */)
+		if err != nil {
+			fmt.Fprintf(diag, "=== Error getting the logs of %s/%s/%s: %v\n",
+				pod.Namespace, pod.Name, container.Name, err)
+			continue
+		}
+		lines := strings.Split(strings.TrimRight(l, "\n"), "\n")
+		if len(lines) > injectorLogLines {
+			lines = lines[len(lines)-injectorLogLines:]
+		}
+		fmt.Fprintf(diag, "=== Logs of %s/%s/%s\n%s\n",
+			pod.Namespace, pod.Name, container.Name, strings.Join(lines, "\n"))
+	}
+}
+
+// podStatus summarizes the status of the given pod: its phase, and the reason of any container that is not
+// ready, e.g. ImagePullBackOff.
+func podStatus(pod kubeCore.Pod) string {
+	out := string(pod.Status.Phase)
+	statuses := append(append([]kubeCore.ContainerStatus{}, pod.Status.InitContainerStatuses...),
+		pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		switch {
+		case cs.State.Waiting != nil:
+			out += fmt.Sprintf(", %s: %s", cs.Name, cs.State.Waiting.Reason)
+		case cs.State.Terminated != nil && cs.State.Terminated.ExitCode != 0:
+			out += fmt.Sprintf(", %s: %s (exit code %d)", cs.Name, cs.State.Terminated.Reason,
+				cs.State.Terminated.ExitCode)
+		case cs.State.Running != nil && !cs.Ready:
+			out += fmt.Sprintf(", %s: not ready", cs.Name)
+		}
+	}
+	return out
+}
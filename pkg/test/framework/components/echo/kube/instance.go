@@ -28,9 +28,12 @@ import (
 	"istio.io/istio/pkg/test"
 	appEcho "istio.io/istio/pkg/test/echo/client"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
 	"istio.io/istio/pkg/test/framework/components/echo/common"
 	kubeEnv "istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/kube"
 
 	kubeCore "k8s.io/api/core/v1"
 )
@@ -40,6 +43,12 @@ const (
 	httpReadinessPort     = 8080
 	defaultDomain         = "cluster.local"
 	noSidecarWaitDuration = 10 * time.Second
+
+	// appProtocolVersion is the first Kubernetes version enabling the appProtocol field of Service ports by
+	// default.
+	appProtocolVersion = "1.19"
+	// ipFamiliesVersion is the first Kubernetes version with the ipFamilyPolicy and ipFamilies fields of Services.
+	ipFamiliesVersion = "1.20"
 )
 
 var (
@@ -51,9 +60,24 @@ type instance struct {
 	id        resource.ID
 	cfg       echo.Config
 	clusterIP string
-	env       *kubeEnv.Environment
+	accessor  *kube.Accessor
 	workloads []*workload
 	grpcPort  uint16
+
+	// generatedYAML of the deployment, for diagnosing startup failures.
+	generatedYAML string
+
+	// remoteServices applied to the other clusters of a multicluster environment, deleted on Close.
+	remoteServices []remoteService
+
+	// vmServiceEntryYAML registering a mock VM with the mesh, deleted on Close.
+	vmServiceEntryYAML string
+}
+
+// remoteService is the Service of an instance, applied to a cluster other than its own.
+type remoteService struct {
+	accessor *kube.Accessor
+	yaml     string
 }
 
 func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err error) {
@@ -71,9 +95,16 @@ func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err erro
 	}
 
 	env := ctx.Environment().(*kubeEnv.Environment)
+	accessor, err := env.Cluster(cfg.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Cluster == "" {
+		cfg.Cluster = env.PrimaryClusterName()
+	}
 	c := &instance{
-		env: env,
-		cfg: cfg,
+		accessor: accessor,
+		cfg:      cfg,
 	}
 	c.id = ctx.TrackResource(c)
 
@@ -82,21 +113,63 @@ func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err erro
 	if grpcPort == nil {
 		return nil, errors.New("unable fo find GRPC command port")
 	}
+	if grpcPort.TLS {
+		return nil, fmt.Errorf("GRPC command port %s must not use TLS", grpcPort.Name)
+	}
 	c.grpcPort = uint16(grpcPort.InstancePort)
 
+	// Older API servers drop the Service fields they don't know, which would silently change the test.
+	if err := checkServiceFields(env, cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Restricted && !cfg.Naked {
+		if err := checkCNI(ctx, accessor); err != nil {
+			return nil, err
+		}
+	}
+
+	// Mock VMs connect to the control plane themselves.
+	systemNamespace := ""
+	if cfg.DeployAsVM {
+		istioCfg, err := istio.DefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		systemNamespace = istioCfg.SystemNamespace
+	}
+
 	// Generate the deployment YAML.
-	generatedYAML, err := generateYAML(cfg)
+	generatedYAML, err := generateYAML(cfg, systemNamespace, cfg.Cluster)
 	if err != nil {
 		return nil, err
 	}
+	c.generatedYAML = generatedYAML
 
 	// Deploy the YAML.
-	if err = env.ApplyContents(cfg.Namespace.Name(), generatedYAML); err != nil {
+	if _, err = accessor.ApplyContents(cfg.Namespace.Name(), generatedYAML); err != nil {
 		return nil, err
 	}
 
+	// Create the Service in the other clusters, so that it can be called from their workloads.
+	if env.IsMulticluster() {
+		serviceYAML, err := generateServiceYAML(cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range env.Accessors() {
+			if a == accessor {
+				continue
+			}
+			if _, err = a.ApplyContents(cfg.Namespace.Name(), serviceYAML); err != nil {
+				return nil, err
+			}
+			c.remoteServices = append(c.remoteServices, remoteService{accessor: a, yaml: serviceYAML})
+		}
+	}
+
 	// Now retrieve the service information to find the ClusterIP
-	s, err := env.GetService(cfg.Namespace.Name(), cfg.Service)
+	s, err := accessor.GetService(cfg.Namespace.Name(), cfg.Service)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +189,52 @@ func newInstance(ctx resource.Context, cfg echo.Config) (out *instance, err erro
 	return c, nil
 }
 
+// checkCNI verifies that the Istio CNI plugin is installed in the cluster of the given accessor, for workloads
+// whose injected pods cannot have the istio-init container.
+func checkCNI(ctx resource.Context, accessor *kube.Accessor) error {
+	istioCfg, err := istio.DefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	pods, err := accessor.GetPods(istioCfg.CNINamespace, istio.CNINodeSelector)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("restricted workloads with a sidecar require the Istio CNI plugin, which is not installed in %s",
+			istioCfg.CNINamespace)
+	}
+	return nil
+}
+
+// checkServiceFields verifies that all clusters of the environment support the optional fields of the
+// Service of the given configuration.
+func checkServiceFields(env *kubeEnv.Environment, cfg echo.Config) error {
+	if len(cfg.IPFamilies) > 0 {
+		if err := requireKubernetesVersion(env, cfg, ipFamiliesVersion, "ipFamilies"); err != nil {
+			return err
+		}
+	}
+	for _, p := range cfg.Ports {
+		if p.AppProtocol != "" && !p.Unnamed {
+			return requireKubernetesVersion(env, cfg, appProtocolVersion, fmt.Sprintf("appProtocol of port %s", p.Name))
+		}
+	}
+	return nil
+}
+
+func requireKubernetesVersion(env *kubeEnv.Environment, cfg echo.Config, minVersion, field string) error {
+	has, err := env.HasCapability(kubeEnv.KubernetesVersion(minVersion))
+	if err != nil {
+		return err
+	}
+	if !has {
+		return fmt.Errorf("%s of service %s/%s requires Kubernetes %s or later",
+			field, cfg.Namespace.Name(), cfg.Service, minVersion)
+	}
+	return nil
+}
+
 // getContainerPorts converts the ports to a port list of container ports.
 // Adds ports for health/readiness if necessary.
 func getContainerPorts(ports []echo.Port) model.PortList {
@@ -190,15 +309,18 @@ func (c *instance) WorkloadsOrFail(t test.Failer) []echo.Workload {
 
 func (c *instance) WaitUntilCallable(instances ...echo.Instance) error {
 	// Wait for the outbound config to be received by each workload from Pilot.
+	hasNoSidecar := false
 	for _, w := range c.workloads {
 		if w.sidecar != nil {
 			if err := w.sidecar.WaitForConfig(common.OutboundConfigAcceptFunc(instances...)); err != nil {
 				return err
 			}
+		} else {
+			hasNoSidecar = true
 		}
 	}
 
-	if !c.cfg.Annotations.GetBool(echo.SidecarInject) {
+	if hasNoSidecar {
 		time.Sleep(noSidecarWaitDuration)
 	}
 
@@ -221,7 +343,7 @@ func (c *instance) initialize(endpoints *kubeCore.Endpoints) error {
 	workloads := make([]*workload, 0)
 	for _, subset := range endpoints.Subsets {
 		for _, addr := range subset.Addresses {
-			workload, err := newWorkload(addr, c.cfg.Annotations, c.grpcPort, c.env.Accessor)
+			workload, err := newWorkload(addr, c.cfg.DeployAsVM, c.grpcPort, c.accessor)
 			if err != nil {
 				return err
 			}
@@ -242,6 +364,14 @@ func (c *instance) Close() (err error) {
 		err = multierror.Append(err, w.Close()).ErrorOrNil()
 	}
 	c.workloads = nil
+	for _, s := range c.remoteServices {
+		err = multierror.Append(err, s.accessor.DeleteContents(c.cfg.Namespace.Name(), s.yaml)).ErrorOrNil()
+	}
+	c.remoteServices = nil
+	if c.vmServiceEntryYAML != "" {
+		err = multierror.Append(err, c.cfg.Galley.DeleteConfig(c.cfg.Namespace, c.vmServiceEntryYAML)).ErrorOrNil()
+		c.vmServiceEntryYAML = ""
+	}
 	return
 }
 
@@ -249,26 +379,48 @@ func (c *instance) Config() echo.Config {
 	return c.cfg
 }
 
-func (c *instance) Call(opts echo.CallOptions) (appEcho.ParsedResponses, error) {
-	out, err := common.CallEcho(c.workloads[0].Instance, &opts, common.IdentityOutboundPortSelector)
+func (c *instance) Call(opts echo.CallOptions, checkers ...check.Checker) (appEcho.ParsedResponses, error) {
+	w, err := c.sourceWorkload(opts.FromWorkload)
+	if err != nil {
+		return nil, err
+	}
+	out, err := common.CallEcho(w.Instance, &opts, common.IdentityOutboundPortSelector, checkers...)
 	if err != nil {
 		if opts.Port != nil {
-			err = fmt.Errorf("failed calling %s->'%s://%s:%d/%s': %v",
+			call := fmt.Sprintf("%s->'%s://%s:%d/%s'",
 				c.Config().Service,
 				strings.ToLower(string(opts.Port.Protocol)),
 				opts.Target.Config().Service,
 				opts.Port.ServicePort,
-				opts.Path,
-				err)
+				opts.Path)
+			if callErr, ok := err.(*echo.CallError); ok {
+				callErr.Call = call
+			} else {
+				err = fmt.Errorf("failed calling %s: %v", call, err)
+			}
 		}
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *instance) CallOrFail(t test.Failer, opts echo.CallOptions) appEcho.ParsedResponses {
+// sourceWorkload returns the workload of this instance that makes calls from the given workload, or the
+// first workload if nil.
+func (c *instance) sourceWorkload(from echo.Workload) (*workload, error) {
+	if from == nil {
+		return c.workloads[0], nil
+	}
+	for _, w := range c.workloads {
+		if echo.Workload(w) == from {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("workload %s is not a workload of %s", from.Name(), c.Config().Service)
+}
+
+func (c *instance) CallOrFail(t test.Failer, opts echo.CallOptions, checkers ...check.Checker) appEcho.ParsedResponses {
 	t.Helper()
-	r, err := c.Call(opts)
+	r, err := c.Call(opts, checkers...)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -15,14 +15,13 @@
 package kube
 
 import (
-	"errors"
+	"crypto/x509"
 	"fmt"
 	"strings"
 
 	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
-	"github.com/golang/protobuf/ptypes"
 
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/echo"
@@ -36,6 +35,9 @@ import (
 const (
 	proxyContainerName = "istio-proxy"
 	proxyAdminPort     = 15000
+
+	// vmContainerName is the container running both the application and istio-proxy for mock VMs.
+	vmContainerName = "app"
 )
 
 var _ echo.Sidecar = &sidecar{}
@@ -44,30 +46,26 @@ type sidecar struct {
 	nodeID       string
 	podNamespace string
 	podName      string
+	container    string
 	accessor     *kube.Accessor
 }
 
-func newSidecar(pod kubeCore.Pod, accessor *kube.Accessor) (*sidecar, error) {
+func newSidecar(pod kubeCore.Pod, container string, accessor *kube.Accessor) (*sidecar, error) {
 	sidecar := &sidecar{
 		podNamespace: pod.Namespace,
 		podName:      pod.Name,
+		container:    container,
 		accessor:     accessor,
 	}
 
 	// Extract the node ID from Envoy.
 	if err := sidecar.WaitForConfig(func(cfg *envoyAdmin.ConfigDump) (bool, error) {
-		for _, c := range cfg.Configs {
-			if c.TypeUrl == "type.googleapis.com/envoy.admin.v2alpha.BootstrapConfigDump" {
-				cd := envoyAdmin.BootstrapConfigDump{}
-				if err := ptypes.UnmarshalAny(c, &cd); err != nil {
-					return false, err
-				}
-
-				sidecar.nodeID = cd.Bootstrap.Node.Id
-				return true, nil
-			}
+		cd, err := common.BootstrapFromConfigDump(cfg)
+		if err != nil {
+			return false, err
 		}
-		return false, errors.New("envoy Bootstrap not found in config dump")
+		sidecar.nodeID = cd.Bootstrap.Node.Id
+		return true, nil
 	}); err != nil {
 		return nil, err
 	}
@@ -97,6 +95,40 @@ func (s *sidecar) InfoOrFail(t test.Failer) *envoyAdmin.ServerInfo {
 	return info
 }
 
+func (s *sidecar) Version() (echo.ProxyVersion, error) {
+	info, err := s.Info()
+	if err != nil {
+		return echo.ProxyVersion{}, err
+	}
+	return echo.ParseProxyVersion(info.Version)
+}
+
+func (s *sidecar) VersionOrFail(t test.Failer) echo.ProxyVersion {
+	t.Helper()
+	v, err := s.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func (s *sidecar) Bootstrap() (*envoyAdmin.BootstrapConfigDump, error) {
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	return common.BootstrapFromConfigDump(cfg)
+}
+
+func (s *sidecar) BootstrapOrFail(t test.Failer) *envoyAdmin.BootstrapConfigDump {
+	t.Helper()
+	b, err := s.Bootstrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
 func (s *sidecar) Config() (*envoyAdmin.ConfigDump, error) {
 	msg := &envoyAdmin.ConfigDump{}
 	if err := s.adminRequest("config_dump", msg); err != nil {
@@ -162,14 +194,121 @@ func (s *sidecar) ListenersOrFail(t test.Failer) *envoyAdmin.Listeners {
 	return listeners
 }
 
-func (s *sidecar) adminRequest(path string, out proto.Message) error {
+func (s *sidecar) Certificates() ([]*x509.Certificate, error) {
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	certs, err := common.CertificatesFromConfigDump(cfg)
+	if err != nil || certs != nil {
+		return certs, err
+	}
+
+	// Not using SDS, read the mounted certificate chain.
+	command := "cat " + common.CertChainFile
+	response, err := s.accessor.Exec(s.podNamespace, s.podName, s.container, command)
+	if err != nil {
+		return nil, fmt.Errorf("failed exec on pod %s/%s: %v. Command: %s. Output:\n%s",
+			s.podNamespace, s.podName, err, command, response)
+	}
+	return common.ParseCertificates([]byte(response))
+}
+
+func (s *sidecar) CertificatesOrFail(t test.Failer) []*x509.Certificate {
+	t.Helper()
+	certs, err := s.Certificates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certs
+}
+
+func (s *sidecar) TrustBundle() ([]*x509.Certificate, error) {
+	cfg, err := s.Config()
+	if err != nil {
+		return nil, err
+	}
+	roots, err := common.TrustBundleFromConfigDump(cfg)
+	if err != nil || roots != nil {
+		return roots, err
+	}
+
+	// Not using SDS, read the mounted trust bundle.
+	command := "cat " + common.RootCertFile
+	response, err := s.accessor.Exec(s.podNamespace, s.podName, s.container, command)
+	if err != nil {
+		return nil, fmt.Errorf("failed exec on pod %s/%s: %v. Command: %s. Output:\n%s",
+			s.podNamespace, s.podName, err, command, response)
+	}
+	return common.ParseCertificates([]byte(response))
+}
+
+func (s *sidecar) TrustBundleOrFail(t test.Failer) []*x509.Certificate {
+	t.Helper()
+	roots, err := s.TrustBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return roots
+}
+
+func (s *sidecar) Stats() (map[string]int64, error) {
+	response, err := s.adminGet("stats")
+	if err != nil {
+		return nil, err
+	}
+	return common.ParseStats(response), nil
+}
+
+func (s *sidecar) StatsOrFail(t test.Failer) map[string]int64 {
+	t.Helper()
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func (s *sidecar) SetLogLevel(level string) error {
+	paths, err := common.LogLevelPaths(level)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if _, err := s.adminExec("-X POST", path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sidecar) SetLogLevelOrFail(t test.Failer, level string) {
+	t.Helper()
+	if err := s.SetLogLevel(level); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (s *sidecar) adminGet(path string) (string, error) {
+	return s.adminExec("", path)
+}
+
+func (s *sidecar) adminExec(curlArgs, path string) (string, error) {
 	// Exec onto the pod and make a curl request to the admin port, writing
-	command := fmt.Sprintf("curl http://127.0.0.1:%d/%s", proxyAdminPort, path)
-	response, err := s.accessor.Exec(s.podNamespace, s.podName, proxyContainerName, command)
+	command := fmt.Sprintf("curl %s http://127.0.0.1:%d/%s", curlArgs, proxyAdminPort, path)
+	response, err := s.accessor.Exec(s.podNamespace, s.podName, s.container, command)
 	if err != nil {
-		return fmt.Errorf("failed exec on pod %s/%s: %v. Command: %s. Output:\n%s",
+		return "", fmt.Errorf("failed exec on pod %s/%s: %v. Command: %s. Output:\n%s",
 			s.podNamespace, s.podName, err, command, response)
 	}
+	return response, nil
+}
+
+func (s *sidecar) adminRequest(path string, out proto.Message) error {
+	response, err := s.adminGet(path)
+	if err != nil {
+		return err
+	}
 
 	jspb := jsonpb.Unmarshaler{AllowUnknownFields: true}
 	if err := jspb.Unmarshal(strings.NewReader(response), out); err != nil {
@@ -179,7 +318,7 @@ func (s *sidecar) adminRequest(path string, out proto.Message) error {
 }
 
 func (s *sidecar) Logs() (string, error) {
-	return s.accessor.Logs(s.podNamespace, s.podName, proxyContainerName, false)
+	return s.accessor.Logs(s.podNamespace, s.podName, s.container, false)
 }
 
 func (s *sidecar) LogsOrFail(t test.Failer) string {
@@ -0,0 +1,170 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+
+	kubeCore "k8s.io/api/core/v1"
+)
+
+const (
+	vmServiceEntryTemplateYAML = `
+apiVersion: networking.istio.io/v1alpha3
+kind: ServiceEntry
+metadata:
+  name: {{ .Service }}
+  labels:
+    app: {{ .Service }}
+spec:
+  hosts:
+  - {{ .Service }}.{{ .Namespace }}.svc.{{ .Domain }}
+  ports:
+{{- range $i, $p := .Ports }}
+  - number: {{ $p.ServicePort }}
+    name: {{ $p.Name }}
+    protocol: {{ $p.Protocol }}
+{{- end }}
+  resolution: STATIC
+  location: MESH_INTERNAL
+  endpoints:
+{{- range $i, $ep := .Endpoints }}
+  - address: {{ $ep.Address }}
+{{- if ne $ep.Locality "" }}
+    locality: {{ $ep.Locality }}
+{{- end }}
+    labels:
+{{- range $name, $value := $ep.Labels }}
+      {{ $name }}: {{ printf "%q" $value }}
+{{- end }}
+    ports:
+{{- range $j, $p := $.Ports }}
+      {{ $p.Name }}: {{ $p.InstancePort }}
+{{- end }}
+{{- end }}
+`
+)
+
+var (
+	vmServiceEntryTemplate *template.Template
+)
+
+func init() {
+	var err error
+	if vmServiceEntryTemplate, err = tmpl.Parse(vmServiceEntryTemplateYAML); err != nil {
+		panic(fmt.Sprintf("unable to parse VM ServiceEntry template: %v", err))
+	}
+}
+
+// registerVM registers the pods of all subsets of a mock VM with the mesh through a single ServiceEntry, rather
+// than through the Kubernetes service registry. Each endpoint carries the version label of its subset. The pods only become ready once istio-proxy has received the inbound
+// configuration for the ServiceEntry, so the registration happens as soon as the pods have an IP.
+// Returns the endpoints of the ready pods.
+func (c *instance) registerVM() (*kubeCore.Endpoints, error) {
+	versions := make([]string, 0, len(c.cfg.Subsets))
+	for _, subset := range c.cfg.Subsets {
+		if subset.Version != "" {
+			versions = append(versions, subset.Version)
+		}
+	}
+	if len(versions) == 0 && c.cfg.Version != "" {
+		versions = append(versions, c.cfg.Version)
+	}
+	selectors := []string{"app=" + c.cfg.Service}
+	if len(versions) > 0 {
+		selectors = append(selectors, fmt.Sprintf("version in (%s)", strings.Join(versions, ",")))
+	}
+	fetch := c.accessor.NewPodFetch(c.cfg.Namespace.Name(), selectors...)
+
+	// Wait for the pods to be assigned an IP.
+	type endpoint struct {
+		Address  string
+		Locality string
+		Labels   map[string]string
+	}
+	var endpoints []endpoint
+	_, err := retry.Do(func() (interface{}, bool, error) {
+		pods, err := fetch()
+		if err != nil {
+			return nil, false, err
+		}
+		if len(pods) == 0 {
+			return nil, false, fmt.Errorf("no pods found for VM %s", c.cfg.FQDN())
+		}
+		endpoints = endpoints[:0]
+		for _, pod := range pods {
+			if pod.Status.PodIP == "" {
+				return nil, false, fmt.Errorf("pod %s/%s has not been assigned an IP", pod.Namespace, pod.Name)
+			}
+			labels := make(map[string]string)
+			for k, v := range pod.Labels {
+				if k != "pod-template-hash" {
+					labels[k] = v
+				}
+			}
+			// The locality of the subset is carried by the label of the pod.
+			endpoints = append(endpoints, endpoint{
+				Address:  pod.Status.PodIP,
+				Locality: pod.Labels["istio-locality"],
+				Labels:   labels,
+			})
+		}
+		return nil, true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seYAML, err := tmpl.Execute(vmServiceEntryTemplate, map[string]interface{}{
+		"Service":   c.cfg.Service,
+		"Namespace": c.cfg.Namespace.Name(),
+		"Domain":    c.cfg.Domain,
+		"Ports":     c.cfg.Ports,
+		"Endpoints": endpoints,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = c.cfg.Galley.ApplyConfig(c.cfg.Namespace, seYAML); err != nil {
+		return nil, fmt.Errorf("error applying ServiceEntry for VM %s: %v", c.cfg.FQDN(), err)
+	}
+	c.vmServiceEntryYAML = seYAML
+
+	// Now wait for the pods to become ready.
+	pods, err := c.accessor.WaitUntilPodsAreReady(fetch, c.startupRetryOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	subset := kubeCore.EndpointSubset{}
+	for _, pod := range pods {
+		subset.Addresses = append(subset.Addresses, kubeCore.EndpointAddress{
+			IP: pod.Status.PodIP,
+			TargetRef: &kubeCore.ObjectReference{
+				Kind:      "Pod",
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+			},
+		})
+	}
+	return &kubeCore.Endpoints{
+		Subsets: []kubeCore.EndpointSubset{subset},
+	}, nil
+}
@@ -16,11 +16,16 @@ package kube
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 
 	"github.com/hashicorp/go-multierror"
 
+	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/echo/client"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
+	"istio.io/istio/pkg/test/framework/components/echo/common"
 	"istio.io/istio/pkg/test/kube"
 
 	kubeCore "k8s.io/api/core/v1"
@@ -39,7 +44,9 @@ type workload struct {
 	sidecar   *sidecar
 }
 
-func newWorkload(addr kubeCore.EndpointAddress, annotations echo.Annotations, grpcPort uint16, accessor *kube.Accessor) (*workload, error) {
+// newWorkload creates a workload for the pod behind the given address. For mock VMs, istio-proxy runs in
+// the application container.
+func newWorkload(addr kubeCore.EndpointAddress, deployAsVM bool, grpcPort uint16, accessor *kube.Accessor) (*workload, error) {
 	if addr.TargetRef == nil || addr.TargetRef.Kind != "Pod" {
 		return nil, fmt.Errorf("invalid TargetRef for endpoint %s: %v", addr.IP, addr.TargetRef)
 	}
@@ -65,9 +72,21 @@ func newWorkload(addr kubeCore.EndpointAddress, annotations echo.Annotations, gr
 		return nil, err
 	}
 
+	// Subsets of a service may differ in whether they are injected, so check the pod itself.
+	sidecarContainer := ""
+	if deployAsVM {
+		sidecarContainer = vmContainerName
+	} else {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == proxyContainerName {
+				sidecarContainer = proxyContainerName
+			}
+		}
+	}
+
 	var s *sidecar
-	if annotations.GetBool(echo.SidecarInject) {
-		if s, err = newSidecar(pod, accessor); err != nil {
+	if sidecarContainer != "" {
+		if s, err = newSidecar(pod, sidecarContainer, accessor); err != nil {
 			return nil, err
 		}
 	}
@@ -91,10 +110,43 @@ func (w *workload) Close() (err error) {
 	return
 }
 
+func (w *workload) Name() string {
+	return w.pod.Name
+}
+
 func (w *workload) Address() string {
 	return w.addr.IP
 }
 
+func (w *workload) HostAddress() string {
+	return w.pod.Status.HostIP
+}
+
 func (w *workload) Sidecar() echo.Sidecar {
 	return w.sidecar
 }
+
+func (w *workload) CallDirect(address string, port int, opts echo.CallOptions,
+	checkers ...check.Checker) (client.ParsedResponses, error) {
+	out, err := common.CallEchoDirect(w.Instance, address, port, &opts, checkers...)
+	if err != nil {
+		call := fmt.Sprintf("%s->'%s://%s'", w.Name(), opts.Scheme, net.JoinHostPort(address, strconv.Itoa(port)))
+		if callErr, ok := err.(*echo.CallError); ok {
+			callErr.Call = call
+		} else {
+			err = fmt.Errorf("failed calling %s: %v", call, err)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func (w *workload) CallDirectOrFail(t test.Failer, address string, port int, opts echo.CallOptions,
+	checkers ...check.Checker) client.ParsedResponses {
+	t.Helper()
+	r, err := w.CallDirect(address, port, opts, checkers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
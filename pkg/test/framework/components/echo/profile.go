@@ -0,0 +1,128 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
+)
+
+// CallProfile bundles the options of a common kind of call, i.e. its scheme, port, number of requests,
+// validation and retries, so that tests don't repeat them and calls of the same kind behave the same across
+// suites. The port names match the ports of the echo deployments of the suites.
+type CallProfile struct {
+	Scheme     scheme.Instance
+	PortName   string
+	Count      int
+	Headers    http.Header
+	Timeout    time.Duration
+	GRPCStatus bool
+
+	RetryCount   int
+	RetryDelay   time.Duration
+	RetryBackoff float64
+	Validator    func(client.ParsedResponses, error) error
+}
+
+var (
+	// HTTPDefault makes 5 HTTP requests on the "http" port, retried until all of them succeed with a 200.
+	HTTPDefault = CallProfile{
+		Scheme:     scheme.HTTP,
+		PortName:   "http",
+		Count:      5,
+		RetryCount: 5,
+		Validator:  check.OK(),
+	}
+
+	// TCPShort makes a single TCP exchange on the "tcp" port with a short timeout, retried a few times until
+	// it succeeds.
+	TCPShort = CallProfile{
+		Scheme:     scheme.TCP,
+		PortName:   "tcp",
+		Count:      1,
+		Timeout:    5 * time.Second,
+		RetryCount: 3,
+		RetryDelay: 500 * time.Millisecond,
+		Validator:  check.NoError(),
+	}
+)
+
+// GRPCAuthed returns the profile of unary gRPC calls on the "grpc" port that carry the given token as a
+// bearer token in the authorization metadata. The status of each call is reported, and the calls are retried
+// until all of them succeed with OK.
+func GRPCAuthed(token string) CallProfile {
+	return CallProfile{
+		Scheme:     scheme.GRPC,
+		PortName:   "grpc",
+		Count:      5,
+		Headers:    http.Header{"Authorization": []string{"Bearer " + token}},
+		GRPCStatus: true,
+		RetryCount: 5,
+		Validator:  check.GRPCCode(codes.OK),
+	}
+}
+
+// To returns the options of the profile for calling the given target.
+func (p CallProfile) To(target Instance) CallOptions {
+	return p.Apply(CallOptions{Target: target})
+}
+
+// Apply returns a copy of opts, with the fields that it leaves unset filled in from the profile. Headers
+// of the profile are only added if opts doesn't set them, and the Validator of opts replaces the one of the
+// profile.
+func (p CallProfile) Apply(opts CallOptions) CallOptions {
+	if opts.Scheme == "" {
+		opts.Scheme = p.Scheme
+	}
+	if opts.PortName == "" && opts.Port == nil {
+		opts.PortName = p.PortName
+	}
+	if opts.Count == 0 {
+		opts.Count = p.Count
+	}
+	if len(p.Headers) > 0 {
+		headers := make(http.Header, len(opts.Headers)+len(p.Headers))
+		for k, v := range p.Headers {
+			headers[k] = v
+		}
+		for k, v := range opts.Headers {
+			headers[k] = v
+		}
+		opts.Headers = headers
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = p.Timeout
+	}
+	opts.GRPCStatus = opts.GRPCStatus || p.GRPCStatus
+	if opts.RetryCount == 0 {
+		opts.RetryCount = p.RetryCount
+	}
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = p.RetryDelay
+	}
+	if opts.RetryBackoff == 0 {
+		opts.RetryBackoff = p.RetryBackoff
+	}
+	if opts.Validator == nil {
+		opts.Validator = p.Validator
+	}
+	return opts
+}
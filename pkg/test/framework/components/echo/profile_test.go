@@ -0,0 +1,53 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+)
+
+func TestCallProfileApply(t *testing.T) {
+	opts := HTTPDefault.Apply(CallOptions{Path: "/path"})
+	if opts.Scheme != scheme.HTTP || opts.PortName != "http" || opts.Count != 5 || opts.RetryCount != 5 {
+		t.Fatalf("profile not applied: %+v", opts)
+	}
+	if opts.Path != "/path" || opts.Validator == nil {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	// Fields set on the options take precedence over the profile.
+	validator := func(client.ParsedResponses, error) error { return nil }
+	opts = TCPShort.Apply(CallOptions{Count: 10, Timeout: time.Minute, Port: &Port{Name: "other"}, Validator: validator})
+	if opts.Count != 10 || opts.Timeout != time.Minute || opts.PortName != "" || opts.RetryCount != 3 {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	opts = GRPCAuthed("token").Apply(CallOptions{Headers: http.Header{"X-Custom": []string{"value"}}})
+	if got := opts.Headers.Get("Authorization"); got != "Bearer token" {
+		t.Fatalf("authorization: got %q", got)
+	}
+	if got := opts.Headers.Get("X-Custom"); got != "value" || !opts.GRPCStatus {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	opts = GRPCAuthed("token").Apply(CallOptions{Headers: http.Header{"Authorization": []string{"Bearer other"}}})
+	if got := opts.Headers.Get("Authorization"); got != "Bearer other" {
+		t.Fatalf("authorization: got %q, want the header of the options", got)
+	}
+}
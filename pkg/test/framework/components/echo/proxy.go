@@ -0,0 +1,231 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/golang/protobuf/jsonpb"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+
+	"istio.io/istio/pkg/test"
+)
+
+// proxyVersionRegex matches the version of the Envoy build reported in its server info, e.g.
+// "73f240a29bece92a8882a36893ccce07b4a54664/1.12.0-dev/Clean/RELEASE/BoringSSL".
+var proxyVersionRegex = regexp.MustCompile(`^([0-9a-f]+)/(\d+)\.(\d+)\.(\d+)(?:-([^/]+))?/([^/]*)/([^/]*)`)
+
+// ProxyVersion is the version of the Envoy build of a sidecar.
+type ProxyVersion struct {
+	// Revision is the commit of the build.
+	Revision string
+	Major    int
+	Minor    int
+	Patch    int
+	// Label of development builds (e.g. "dev"), if any.
+	Label string
+	// Status of the source tree the build was made from (e.g. "Clean").
+	Status string
+	// BuildType is RELEASE or DEBUG.
+	BuildType string
+}
+
+// ParseProxyVersion parses the version reported in the server info of Envoy.
+func ParseProxyVersion(version string) (ProxyVersion, error) {
+	match := proxyVersionRegex.FindStringSubmatch(version)
+	if match == nil {
+		return ProxyVersion{}, fmt.Errorf("unrecognized Envoy version %q", version)
+	}
+	v := ProxyVersion{
+		Revision:  match[1],
+		Label:     match[5],
+		Status:    match[6],
+		BuildType: match[7],
+	}
+	// The numbers are known to be valid from the regex.
+	v.Major, _ = strconv.Atoi(match[2])
+	v.Minor, _ = strconv.Atoi(match[3])
+	v.Patch, _ = strconv.Atoi(match[4])
+	return v, nil
+}
+
+// AtLeast returns true if the version is the given one or a later one. Development builds of a version are
+// considered to be that version, since they are generally built from its release branch.
+func (v ProxyVersion) AtLeast(major, minor, patch int) bool {
+	if v.Major != major {
+		return v.Major > major
+	}
+	if v.Minor != minor {
+		return v.Minor > minor
+	}
+	return v.Patch >= patch
+}
+
+// Less returns true if the version is earlier than the given one.
+func (v ProxyVersion) Less(o ProxyVersion) bool {
+	return !v.AtLeast(o.Major, o.Minor, o.Patch)
+}
+
+// String returns the version as major.minor.patch, followed by the label if any.
+func (v ProxyVersion) String() string {
+	out := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Label != "" {
+		out += "-" + v.Label
+	}
+	return out
+}
+
+// MinProxyVersion returns the earliest Envoy version among the sidecars of all workloads of the given instances.
+// Tests targeting features gated on the proxy version should branch on it rather than on the version of any
+// single sidecar, so that the outcome doesn't depend on which workload handles a call.
+func MinProxyVersion(instances ...Instance) (ProxyVersion, error) {
+	var out *ProxyVersion
+	for _, i := range instances {
+		workloads, err := i.Workloads()
+		if err != nil {
+			return ProxyVersion{}, err
+		}
+		for _, w := range workloads {
+			if w.Sidecar() == nil {
+				continue
+			}
+			v, err := w.Sidecar().Version()
+			if err != nil {
+				return ProxyVersion{}, fmt.Errorf("workload %s: %v", w.Name(), err)
+			}
+			if out == nil || v.Less(*out) {
+				out = &v
+			}
+		}
+	}
+	if out == nil {
+		return ProxyVersion{}, fmt.Errorf("no sidecars found")
+	}
+	return *out, nil
+}
+
+// MinProxyVersionOrFail calls MinProxyVersion and fails t if an error occurs.
+func MinProxyVersionOrFail(t test.Failer, instances ...Instance) ProxyVersion {
+	t.Helper()
+	v, err := MinProxyVersion(instances...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// Skipper is a test that can be skipped, e.g. a testing.T or a framework.TestContext.
+type Skipper interface {
+	test.Failer
+	Skipf(format string, args ...interface{})
+}
+
+// SkipUnlessProxyVersion skips t unless the sidecars of all workloads of the given instances are at least of
+// the given version, and fails it if their versions can't be determined.
+func SkipUnlessProxyVersion(t Skipper, major, minor, patch int, instances ...Instance) {
+	t.Helper()
+	v := MinProxyVersionOrFail(t, instances...)
+	if !v.AtLeast(major, minor, patch) {
+		t.Skipf("Skipping: requires Envoy %d.%d.%d, found %s", major, minor, patch, v)
+	}
+}
+
+// CheckProxyVersion verifies that the sidecars of all workloads of the given instances are at least of the
+// given version.
+func CheckProxyVersion(major, minor, patch int, instances ...Instance) error {
+	v, err := MinProxyVersion(instances...)
+	if err != nil {
+		return err
+	}
+	if !v.AtLeast(major, minor, patch) {
+		return fmt.Errorf("expected Envoy %d.%d.%d or later, found %s", major, minor, patch, v)
+	}
+	return nil
+}
+
+// NodeMetadata returns the metadata that the sidecar sends to Pilot in its node, from its bootstrap
+// configuration. String values are returned as is, and other values in their JSON encoding.
+func NodeMetadata(s Sidecar) (map[string]string, error) {
+	bootstrap, err := s.Bootstrap()
+	if err != nil {
+		return nil, err
+	}
+	return flattenMetadata(bootstrap.GetBootstrap().GetNode().GetMetadata())
+}
+
+// NodeMetadataOrFail calls NodeMetadata and fails t if an error occurs.
+func NodeMetadataOrFail(t test.Failer, s Sidecar) map[string]string {
+	t.Helper()
+	md, err := NodeMetadata(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return md
+}
+
+// CheckNodeMetadata verifies that the node metadata of the sidecars of all workloads of the given instance
+// contains the expected values. An empty expected value only requires the key to be present.
+func CheckNodeMetadata(i Instance, expected map[string]string) error {
+	workloads, err := i.Workloads()
+	if err != nil {
+		return err
+	}
+	for _, w := range workloads {
+		if w.Sidecar() == nil {
+			return fmt.Errorf("workload %s has no sidecar", w.Name())
+		}
+		md, err := NodeMetadata(w.Sidecar())
+		if err != nil {
+			return err
+		}
+		for k, want := range expected {
+			got, ok := md[k]
+			if !ok {
+				return fmt.Errorf("workload %s: node metadata %s missing", w.Name(), k)
+			}
+			if want != "" && got != want {
+				return fmt.Errorf("workload %s: node metadata %s: expected %q, found %q", w.Name(), k, want, got)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckNodeMetadataOrFail calls CheckNodeMetadata and fails t if an error occurs.
+func CheckNodeMetadataOrFail(t test.Failer, i Instance, expected map[string]string) {
+	t.Helper()
+	if err := CheckNodeMetadata(i, expected); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func flattenMetadata(md *structpb.Struct) (map[string]string, error) {
+	out := make(map[string]string, len(md.GetFields()))
+	m := jsonpb.Marshaler{}
+	for k, v := range md.GetFields() {
+		if s, ok := v.GetKind().(*structpb.Value_StringValue); ok {
+			out[k] = s.StringValue
+			continue
+		}
+		js, err := m.MarshalToString(v)
+		if err != nil {
+			return nil, fmt.Errorf("node metadata %s: %v", k, err)
+		}
+		out[k] = js
+	}
+	return out, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"testing"
+
+	structpb "github.com/golang/protobuf/ptypes/struct"
+)
+
+func TestParseProxyVersion(t *testing.T) {
+	cases := []struct {
+		version  string
+		expected ProxyVersion
+		str      string
+	}{
+		{
+			version: "73f240a29bece92a8882a36893ccce07b4a54664/1.12.0-dev/Clean/RELEASE/BoringSSL",
+			expected: ProxyVersion{
+				Revision:  "73f240a29bece92a8882a36893ccce07b4a54664",
+				Major:     1,
+				Minor:     12,
+				Patch:     0,
+				Label:     "dev",
+				Status:    "Clean",
+				BuildType: "RELEASE",
+			},
+			str: "1.12.0-dev",
+		},
+		{
+			version: "abc123/1.11.2/Modified/DEBUG/BoringSSL",
+			expected: ProxyVersion{
+				Revision:  "abc123",
+				Major:     1,
+				Minor:     11,
+				Patch:     2,
+				Status:    "Modified",
+				BuildType: "DEBUG",
+			},
+			str: "1.11.2",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.str, func(t *testing.T) {
+			v, err := ParseProxyVersion(c.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != c.expected {
+				t.Fatalf("expected %+v, got %+v", c.expected, v)
+			}
+			if v.String() != c.str {
+				t.Fatalf("expected %q, got %q", c.str, v.String())
+			}
+		})
+	}
+
+	if _, err := ParseProxyVersion("unknown"); err == nil {
+		t.Fatal("expected an error for an unrecognized version")
+	}
+}
+
+func TestProxyVersionAtLeast(t *testing.T) {
+	v := ProxyVersion{Major: 1, Minor: 12, Patch: 3}
+	for _, c := range []struct {
+		major, minor, patch int
+		expected            bool
+	}{
+		{1, 12, 3, true},
+		{1, 12, 2, true},
+		{1, 11, 9, true},
+		{0, 20, 0, true},
+		{1, 12, 4, false},
+		{1, 13, 0, false},
+		{2, 0, 0, false},
+	} {
+		if got := v.AtLeast(c.major, c.minor, c.patch); got != c.expected {
+			t.Errorf("%s.AtLeast(%d, %d, %d): expected %v", v, c.major, c.minor, c.patch, c.expected)
+		}
+	}
+}
+
+func TestFlattenMetadata(t *testing.T) {
+	md := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"ISTIO_VERSION": {Kind: &structpb.Value_StringValue{StringValue: "1.3.0"}},
+		"SDS":           {Kind: &structpb.Value_BoolValue{BoolValue: true}},
+	}}
+	out, err := flattenMetadata(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["ISTIO_VERSION"] != "1.3.0" || out["SDS"] != "true" || len(out) != 2 {
+		t.Fatalf("unexpected metadata: %v", out)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerResponse instructs the echo server how to respond to HTTP calls, through query parameters of the
+// path of the call. This allows testing behaviors that depend on the response, e.g. retries on 503 over mTLS,
+// buffer limits of the proxies, or authorization of responses.
+type ServerResponse struct {
+	// Codes returned by the server, with their relative weights, e.g. {503: 1, 200: 1} to fail half of the
+	// requests. Defaults to 200.
+	Codes map[int]int
+
+	// Delay before the server responds.
+	Delay time.Duration
+
+	// Headers set on the response. Neither names nor values may contain commas. The responses report the
+	// headers received by the client in ParsedResponse.ResponseHeaders.
+	Headers map[string]string
+
+	// Size the body of the response is padded to, in bytes. The responses report the size of the body
+	// received by the client in ParsedResponse.BodySize, rather than the padding.
+	Size int
+}
+
+// Path returns the given path of a call with the query parameters of the response.
+func (r ServerResponse) Path(path string) string {
+	query := url.Values{}
+	if len(r.Codes) > 0 {
+		codes := make([]int, 0, len(r.Codes))
+		for code := range r.Codes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		values := make([]string, 0, len(codes))
+		for _, code := range codes {
+			values = append(values, fmt.Sprintf("%d:%d", code, r.Codes[code]))
+		}
+		query.Set("codes", strings.Join(values, ","))
+	}
+	if r.Delay > 0 {
+		query.Set("delay", r.Delay.String())
+	}
+	if len(r.Headers) > 0 {
+		names := make([]string, 0, len(r.Headers))
+		for name := range r.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		values := make([]string, 0, len(names))
+		for _, name := range names {
+			values = append(values, name+":"+r.Headers[name])
+		}
+		query.Set("headers", strings.Join(values, ","))
+	}
+	if r.Size > 0 {
+		query.Set("size", strconv.Itoa(r.Size))
+	}
+	if len(query) == 0 {
+		return path
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + query.Encode()
+}
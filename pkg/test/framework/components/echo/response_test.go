@@ -0,0 +1,42 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServerResponsePath(t *testing.T) {
+	cases := []struct {
+		name     string
+		response ServerResponse
+		path     string
+		expected string
+	}{
+		{"empty", ServerResponse{}, "/path", "/path"},
+		{"codes", ServerResponse{Codes: map[int]int{503: 1, 200: 3}}, "/path", "/path?codes=200%3A3%2C503%3A1"},
+		{"delay", ServerResponse{Delay: time.Second}, "/", "/?delay=1s"},
+		{"headers", ServerResponse{Headers: map[string]string{"b": "2", "a": "1"}}, "/", "/?headers=a%3A1%2Cb%3A2"},
+		{"size", ServerResponse{Size: 1024}, "/path?x=y", "/path?x=y&size=1024"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.response.Path(c.path); got != c.expected {
+				t.Fatalf("got %q, want %q", got, c.expected)
+			}
+		})
+	}
+}
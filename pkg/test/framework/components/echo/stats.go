@@ -0,0 +1,176 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+)
+
+// StatsSnapshot of the stats of the sidecars of a set of workloads, for asserting how the stats changed
+// afterwards, e.g. that a call was denied by the RBAC filter or made a TLS handshake.
+type StatsSnapshot struct {
+	workloads []Workload
+	before    map[string]int64
+}
+
+// SnapshotStats takes a snapshot of the stats of the sidecars of the given workloads, which must all have one.
+func SnapshotStats(workloads ...Workload) (*StatsSnapshot, error) {
+	s := &StatsSnapshot{workloads: workloads}
+	var err error
+	if s.before, err = s.current(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SnapshotStatsOrFail calls SnapshotStats and fails t if an error occurs.
+func SnapshotStatsOrFail(t test.Failer, workloads ...Workload) *StatsSnapshot {
+	t.Helper()
+	s, err := SnapshotStats(workloads...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// SnapshotInstanceStats takes a snapshot of the stats of the sidecars of all workloads of the given instances.
+func SnapshotInstanceStats(instances ...Instance) (*StatsSnapshot, error) {
+	var workloads []Workload
+	for _, i := range instances {
+		w, err := i.Workloads()
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, w...)
+	}
+	return SnapshotStats(workloads...)
+}
+
+// SnapshotInstanceStatsOrFail calls SnapshotInstanceStats and fails t if an error occurs.
+func SnapshotInstanceStatsOrFail(t test.Failer, instances ...Instance) *StatsSnapshot {
+	t.Helper()
+	s, err := SnapshotInstanceStats(instances...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// Delta returns how the stats changed since the snapshot, summed over the sidecars.
+func (s *StatsSnapshot) Delta() (StatsDelta, error) {
+	after, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return NewStatsDelta(s.before, after), nil
+}
+
+// DeltaOrFail calls Delta and fails t if an error occurs.
+func (s *StatsSnapshot) DeltaOrFail(t test.Failer) StatsDelta {
+	t.Helper()
+	d, err := s.Delta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func (s *StatsSnapshot) current() (map[string]int64, error) {
+	out := make(map[string]int64)
+	for _, w := range s.workloads {
+		if w.Sidecar() == nil {
+			return nil, fmt.Errorf("workload %s has no sidecar", w.Name())
+		}
+		stats, err := w.Sidecar().Stats()
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range stats {
+			out[name] += value
+		}
+	}
+	return out, nil
+}
+
+// StatsDelta is the change of the stats of sidecars between two snapshots, by name. Only the stats that changed
+// are included.
+type StatsDelta map[string]int64
+
+// NewStatsDelta returns the change of the stats from before to after.
+func NewStatsDelta(before, after map[string]int64) StatsDelta {
+	out := make(StatsDelta)
+	for name, value := range after {
+		if d := value - before[name]; d != 0 {
+			out[name] = d
+		}
+	}
+	for name, value := range before {
+		if _, ok := after[name]; !ok && value != 0 {
+			out[name] = -value
+		}
+	}
+	return out
+}
+
+// Sum returns the total change of the stats whose name contains the given string, e.g. "ssl.handshake" or
+// "rbac.denied".
+func (d StatsDelta) Sum(stat string) int64 {
+	var out int64
+	for name, value := range d {
+		if strings.Contains(name, stat) {
+			out += value
+		}
+	}
+	return out
+}
+
+// CheckIncreased verifies that the stats whose name contains the given string increased.
+func (d StatsDelta) CheckIncreased(stat string) error {
+	if d.Sum(stat) <= 0 {
+		return fmt.Errorf("expected %s to increase, changed stats:\n%s", stat, d)
+	}
+	return nil
+}
+
+// CheckIncreasedBy verifies that the stats whose name contains the given string increased by exactly n.
+func (d StatsDelta) CheckIncreasedBy(stat string, n int64) error {
+	if got := d.Sum(stat); got != n {
+		return fmt.Errorf("expected %s to increase by %d, got %d. Changed stats:\n%s", stat, n, got, d)
+	}
+	return nil
+}
+
+// CheckUnchanged verifies that the stats whose name contains the given string didn't change.
+func (d StatsDelta) CheckUnchanged(stat string) error {
+	return d.CheckIncreasedBy(stat, 0)
+}
+
+// String returns the changed stats, one per line, sorted by name.
+func (d StatsDelta) String() string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sb := &strings.Builder{}
+	for _, name := range names {
+		_, _ = fmt.Fprintf(sb, "  %s: %+d\n", name, d[name])
+	}
+	return sb.String()
+}
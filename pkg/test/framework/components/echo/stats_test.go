@@ -0,0 +1,61 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatsDelta(t *testing.T) {
+	before := map[string]int64{
+		"listener.10.0.0.1_8080.ssl.handshake":             3,
+		"http.10.0.0.1_8080.rbac.denied":                   1,
+		"cluster.outbound|80||b.default.svc.cluster.local": 5,
+		"server.live": 1,
+	}
+	after := map[string]int64{
+		"listener.10.0.0.1_8080.ssl.handshake":             5,
+		"listener.10.0.0.1_9090.ssl.handshake":             1,
+		"http.10.0.0.1_8080.rbac.denied":                   1,
+		"cluster.outbound|80||b.default.svc.cluster.local": 4,
+	}
+	d := NewStatsDelta(before, after)
+
+	expected := StatsDelta{
+		"listener.10.0.0.1_8080.ssl.handshake":             2,
+		"listener.10.0.0.1_9090.ssl.handshake":             1,
+		"cluster.outbound|80||b.default.svc.cluster.local": -1,
+		"server.live": -1,
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Fatalf("expected %v, got %v", expected, d)
+	}
+	if err := d.CheckIncreasedBy("ssl.handshake", 3); err != nil {
+		t.Error(err)
+	}
+	if err := d.CheckIncreased("ssl.handshake"); err != nil {
+		t.Error(err)
+	}
+	if err := d.CheckUnchanged("rbac.denied"); err != nil {
+		t.Error(err)
+	}
+	if err := d.CheckIncreased("cluster.outbound"); err == nil {
+		t.Error("expected an error for a decreased stat")
+	}
+	if err := d.CheckIncreasedBy("ssl.handshake", 2); err == nil {
+		t.Error("expected an error for a wrong increase")
+	}
+}
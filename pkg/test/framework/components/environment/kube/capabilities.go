@@ -0,0 +1,158 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	kubeApiCore "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+// Capability of the clusters of the environment, which tests can require.
+type Capability string
+
+const (
+	// LoadBalancer indicates that Services of type LoadBalancer are assigned an external address.
+	LoadBalancer Capability = "LoadBalancer"
+	// IPv6 indicates that the cluster Services are assigned IPv6 addresses.
+	IPv6 Capability = "IPv6"
+	// CNI indicates that the Istio CNI plugin is installed.
+	CNI Capability = "CNI"
+	// PodSecurityAdmission indicates that the Pod Security admission controller, and thus the
+	// pod-security.kubernetes.io namespace labels, are available.
+	PodSecurityAdmission Capability = "PodSecurityAdmission"
+
+	// CNINodeSelector selects the pods of the DaemonSet installing the Istio CNI plugin on the nodes.
+	CNINodeSelector = "k8s-app=istio-cni-node"
+
+	kubernetesVersionPrefix = "KubernetesVersion>="
+
+	// podSecurityAdmissionVersion is the first Kubernetes version enabling Pod Security admission by default.
+	podSecurityAdmissionVersion = "1.23"
+)
+
+// KubernetesVersion returns the capability of running at least the given Kubernetes version, in the
+// <major>.<minor> form.
+func KubernetesVersion(minVersion string) Capability {
+	return Capability(kubernetesVersionPrefix + minVersion)
+}
+
+// HasCapability returns whether all clusters of the environment have the given capability. The clusters are
+// only probed the first time a capability is queried.
+func (e *Environment) HasCapability(c Capability) (bool, error) {
+	e.capabilitiesMu.Lock()
+	defer e.capabilitiesMu.Unlock()
+
+	if has, ok := e.capabilities[c]; ok {
+		return has, nil
+	}
+
+	has := true
+	for i, a := range e.Accessors() {
+		h, err := probeCapability(a, c)
+		if err != nil {
+			return false, fmt.Errorf("failed probing capability %s of cluster %s: %v", c, e.ClusterNames()[i], err)
+		}
+		has = has && h
+	}
+
+	scopes.Framework.Infof("Cluster capability %s: %v", c, has)
+	if e.capabilities == nil {
+		e.capabilities = make(map[Capability]bool)
+	}
+	e.capabilities[c] = has
+	return has, nil
+}
+
+func probeCapability(a *kube.Accessor, c Capability) (bool, error) {
+	switch c {
+	case LoadBalancer:
+		services, err := a.GetServices("")
+		if err != nil {
+			return false, err
+		}
+		for _, s := range services {
+			if s.Spec.Type == kubeApiCore.ServiceTypeLoadBalancer && len(s.Status.LoadBalancer.Ingress) > 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case IPv6:
+		s, err := a.GetService("default", "kubernetes")
+		if err != nil {
+			return false, err
+		}
+		ip := net.ParseIP(s.Spec.ClusterIP)
+		return ip != nil && ip.To4() == nil, nil
+	case CNI:
+		pods, err := a.GetPods("", CNINodeSelector)
+		if err != nil {
+			return false, err
+		}
+		return len(pods) > 0, nil
+	case PodSecurityAdmission:
+		return hasKubernetesVersion(a, podSecurityAdmissionVersion)
+	}
+
+	if strings.HasPrefix(string(c), kubernetesVersionPrefix) {
+		return hasKubernetesVersion(a, strings.TrimPrefix(string(c), kubernetesVersionPrefix))
+	}
+	return false, fmt.Errorf("unknown capability %s", c)
+}
+
+func hasKubernetesVersion(a *kube.Accessor, minVersion string) (bool, error) {
+	ver, err := a.GetKubernetesVersion()
+	if err != nil {
+		return false, err
+	}
+	return versionAtLeast(ver.Major, ver.Minor, minVersion)
+}
+
+// versionAtLeast compares the given server version with the <major>.<minor> minimum version. Providers
+// may add a suffix to the minor version of the server (e.g. "16+"), which is ignored.
+func versionAtLeast(major, minor, minVersion string) (bool, error) {
+	parts := strings.SplitN(minVersion, ".", 2)
+	if len(parts) != 2 {
+		return false, fmt.Errorf("invalid Kubernetes version %q, expected <major>.<minor>", minVersion)
+	}
+	minMajor, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false, fmt.Errorf("invalid Kubernetes version %q: %v", minVersion, err)
+	}
+	minMinor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false, fmt.Errorf("invalid Kubernetes version %q: %v", minVersion, err)
+	}
+
+	serverMajor, err := strconv.Atoi(strings.TrimRight(major, "+"))
+	if err != nil {
+		return false, fmt.Errorf("invalid server major version %q: %v", major, err)
+	}
+	serverMinor, err := strconv.Atoi(strings.TrimRight(minor, "+"))
+	if err != nil {
+		return false, fmt.Errorf("invalid server minor version %q: %v", minor, err)
+	}
+
+	if serverMajor != minMajor {
+		return serverMajor > minMajor, nil
+	}
+	return serverMinor >= minMinor, nil
+}
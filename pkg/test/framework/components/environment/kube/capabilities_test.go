@@ -0,0 +1,44 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import "testing"
+
+func TestVersionAtLeast(t *testing.T) {
+	cases := []struct {
+		major, minor, min string
+		want              bool
+	}{
+		{"1", "15", "1.15", true},
+		{"1", "16", "1.9", true},
+		{"1", "9", "1.16", false},
+		{"1", "16+", "1.16", true},
+		{"1", "14+", "1.15", false},
+		{"2", "0", "1.23", true},
+	}
+	for _, c := range cases {
+		got, err := versionAtLeast(c.major, c.minor, c.min)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("versionAtLeast(%s, %s, %s): got %v, want %v", c.major, c.minor, c.min, got, c.want)
+		}
+	}
+
+	if _, err := versionAtLeast("1", "15", "1"); err == nil {
+		t.Error("expected an error for an invalid minimum version")
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/test/framework/topology"
+	"istio.io/istio/pkg/test/kube"
+)
+
+// policyGroups are the API groups of the Istio configuration described as policies of the topology. The Mixer
+// configuration is left out, as most of it is installed along with Istio.
+var policyGroups = map[string]bool{
+	"networking.istio.io":     true,
+	"authentication.istio.io": true,
+	"rbac.istio.io":           true,
+	"security.istio.io":       true,
+}
+
+var _ topology.Describer = &Environment{}
+
+// DescribeTopology implements topology.Describer, adding the Istio networking and security configuration of all
+// clusters as policies.
+func (e *Environment) DescribeTopology(t *topology.Topology) error {
+	for _, name := range e.ClusterNames() {
+		a, err := e.Cluster(name)
+		if err != nil {
+			return err
+		}
+		cluster := ""
+		if e.IsMulticluster() {
+			cluster = name
+		}
+		if err := describePolicies(a, cluster, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func describePolicies(a *kube.Accessor, cluster string, t *topology.Topology) error {
+	crds, err := a.GetCustomResourceDefinitions()
+	if err != nil {
+		return err
+	}
+	var types []string
+	for _, crd := range crds {
+		if policyGroups[crd.Spec.Group] {
+			types = append(types, crd.Spec.Names.Plural+"."+crd.Spec.Group)
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	namespaces, err := a.GetNamespaces()
+	if err != nil {
+		return err
+	}
+	seen := make(map[topology.Policy]bool)
+	for _, ns := range namespaces {
+		if systemNamespaces[ns.Name] {
+			continue
+		}
+		out, err := a.GetResourcesYAML(ns.Name, strings.Join(types, ","))
+		if err != nil {
+			return err
+		}
+		list := struct {
+			Items []struct {
+				Kind     string `json:"kind"`
+				Metadata struct {
+					Name      string `json:"name"`
+					Namespace string `json:"namespace"`
+				} `json:"metadata"`
+			} `json:"items"`
+		}{}
+		if err := yaml.Unmarshal([]byte(out), &list); err != nil {
+			return err
+		}
+		for _, item := range list.Items {
+			// Cluster scoped resources, such as MeshPolicy, are listed for every namespace.
+			p := topology.Policy{
+				Kind:      item.Kind,
+				Name:      item.Metadata.Name,
+				Namespace: item.Metadata.Namespace,
+				Cluster:   cluster,
+			}
+			if !seen[p] {
+				seen[p] = true
+				t.AddPolicy(p)
+			}
+		}
+	}
+	return nil
+}
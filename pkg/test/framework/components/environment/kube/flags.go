@@ -16,7 +16,9 @@ package kube
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/mitchellh/go-homedir"
 
@@ -26,8 +28,15 @@ import (
 var (
 	// Settings we will collect from the command-line.
 	settingsFromCommandLine = &Settings{
-		KubeConfig: env.ISTIO_TEST_KUBE_CONFIG.Value(),
+		KubeConfig:  env.ISTIO_TEST_KUBE_CONFIG.Value(),
+		ClusterName: DefaultClusterName,
 	}
+
+	// remoteKubeConfigs is the comma separated list of name=path pairs for the remote clusters.
+	remoteKubeConfigs string
+
+	// topologyFile is the path of the file with the Topology of the clusters.
+	topologyFile string
 )
 
 // newSettingsFromCommandline returns Settings obtained from command-line flags. flag.Parse must be called before calling this function.
@@ -38,15 +47,55 @@ func newSettingsFromCommandline() (*Settings, error) {
 
 	s := settingsFromCommandLine.clone()
 
+	if topologyFile != "" {
+		if remoteKubeConfigs != "" {
+			return nil, fmt.Errorf("istio.test.kube.topology and istio.test.kube.remoteConfigs are mutually exclusive")
+		}
+		if err := normalizeFile(&topologyFile); err != nil {
+			return nil, err
+		}
+		if err := s.applyTopology(topologyFile); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+
 	if s.KubeConfig != "" {
 		if err := normalizeFile(&s.KubeConfig); err != nil {
 			return nil, err
 		}
 	}
 
+	var err error
+	if s.RemoteKubeConfigs, err = parseRemoteKubeConfigs(remoteKubeConfigs, s.ClusterName); err != nil {
+		return nil, err
+	}
+
 	return s, nil
 }
 
+func parseRemoteKubeConfigs(value string, primary string) (map[string]string, error) {
+	out := make(map[string]string)
+	if value == "" {
+		return out, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid remote cluster %q (want name=path)", entry)
+		}
+		name, path := parts[0], parts[1]
+		if _, ok := out[name]; ok || name == primary {
+			return nil, fmt.Errorf("duplicate cluster name %q", name)
+		}
+		if err := normalizeFile(&path); err != nil {
+			return nil, err
+		}
+		out[name] = path
+	}
+	return out, nil
+}
+
 func normalizeFile(path *string) error {
 	// If the path uses the homedir ~, expand the path.
 	var err error
@@ -71,4 +120,12 @@ func init() {
 		"The path to the kube config file for cluster environments")
 	flag.BoolVar(&settingsFromCommandLine.Minikube, "istio.test.kube.minikube", settingsFromCommandLine.Minikube,
 		"Indicates that the target environment is Minikube. Used by Ingress component to obtain the right IP address..")
+	flag.StringVar(&settingsFromCommandLine.ClusterName, "istio.test.kube.clusterName", settingsFromCommandLine.ClusterName,
+		"The name of the cluster configured by istio.test.kube.config in a multicluster topology")
+	flag.StringVar(&remoteKubeConfigs, "istio.test.kube.remoteConfigs", remoteKubeConfigs,
+		"Comma separated list of name=path pairs with the kube config files of the remote clusters in a multicluster topology")
+	flag.StringVar(&topologyFile, "istio.test.kube.topology", topologyFile,
+		"The path to a file with the kube configs, contexts and roles of the clusters, overriding istio.test.kube.config")
+	flag.BoolVar(&settingsFromCommandLine.ReuseNamespaces, "istio.test.kube.reuseNamespaces", settingsFromCommandLine.ReuseNamespaces,
+		"Reuse the namespaces created by tests in later tests of the suite, deleting only the resources in them between tests")
 }
@@ -15,6 +15,11 @@
 package kube
 
 import (
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
 	"istio.io/istio/pkg/test/deployment"
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/environment/api"
@@ -31,9 +36,25 @@ type Environment struct {
 	ctx api.Context
 	*kube.Accessor
 	s *Settings
+
+	// remotes are the accessors for the remote clusters, by cluster name.
+	remotes map[string]*kube.Accessor
+
+	namespaces namespacePool
+
+	capabilitiesMu sync.Mutex
+	capabilities   map[Capability]bool
 }
 
 var _ resource.Environment = &Environment{}
+var _ resource.EnvironmentDumper = &Environment{}
+
+// systemNamespaces are not dumped by DumpState.
+var systemNamespaces = map[string]bool{
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
 
 // New returns a new Kubernetes environment
 func New(ctx api.Context) (resource.Environment, error) {
@@ -50,18 +71,84 @@ func New(ctx api.Context) (resource.Environment, error) {
 	}
 
 	e := &Environment{
-		ctx: ctx,
-		s:   s,
+		ctx:     ctx,
+		s:       s,
+		remotes: make(map[string]*kube.Accessor),
 	}
 	e.id = ctx.TrackResource(e)
 
+	if err := s.resolveContexts(workDir); err != nil {
+		return nil, err
+	}
+
 	if e.Accessor, err = kube.NewAccessor(s.KubeConfig, workDir); err != nil {
 		return nil, err
 	}
 
+	for name, kubeConfig := range s.RemoteKubeConfigs {
+		if e.remotes[name], err = kube.NewAccessor(kubeConfig, workDir); err != nil {
+			return nil, err
+		}
+	}
+
 	return e, nil
 }
 
+// ClusterNames returns the names of all clusters, starting with the primary cluster.
+func (e *Environment) ClusterNames() []string {
+	return append([]string{e.s.ClusterName}, e.s.RemoteClusterNames()...)
+}
+
+// PrimaryClusterName returns the name of the cluster configured by the kube config of the environment.
+func (e *Environment) PrimaryClusterName() string {
+	return e.s.ClusterName
+}
+
+// ConfigClusterName returns the name of the cluster the Istio configuration is applied to.
+func (e *Environment) ConfigClusterName() string {
+	if e.s.ConfigClusterName != "" {
+		return e.s.ConfigClusterName
+	}
+	return e.s.ClusterName
+}
+
+// IsMulticluster indicates whether the environment has remote clusters.
+func (e *Environment) IsMulticluster() bool {
+	return len(e.remotes) > 0
+}
+
+// Cluster returns the accessor for the named cluster. An empty name selects the primary cluster.
+func (e *Environment) Cluster(name string) (*kube.Accessor, error) {
+	if name == "" || name == e.s.ClusterName {
+		return e.Accessor, nil
+	}
+	a, ok := e.remotes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster %q (available: %v)", name, e.ClusterNames())
+	}
+	return a, nil
+}
+
+// setCluster replaces the accessor and the kube config file of the named cluster.
+func (e *Environment) setCluster(name string, a *kube.Accessor, kubeConfig string) {
+	if name == e.s.ClusterName {
+		e.Accessor = a
+		e.s.KubeConfig = kubeConfig
+		return
+	}
+	e.remotes[name] = a
+	e.s.RemoteKubeConfigs[name] = kubeConfig
+}
+
+// Accessors returns the accessors for all clusters, starting with the primary cluster.
+func (e *Environment) Accessors() []*kube.Accessor {
+	out := []*kube.Accessor{e.Accessor}
+	for _, name := range e.s.RemoteClusterNames() {
+		out = append(out, e.remotes[name])
+	}
+	return out
+}
+
 // EnvironmentName implements environment.Instance
 func (e *Environment) EnvironmentName() environment.Name {
 	return environment.Kube
@@ -83,6 +170,35 @@ func (e *Environment) Settings() *Settings {
 	return e.s.clone()
 }
 
+// DumpState implements resource.EnvironmentDumper. It dumps the pods, events, logs, proxy state and
+// Istio configuration of all non-system namespaces of every cluster into workDir/<cluster>/<namespace>.
+func (e *Environment) DumpState(workDir string) {
+	for _, name := range e.ClusterNames() {
+		a, err := e.Cluster(name)
+		if err != nil {
+			scopes.CI.Errorf("Unable to dump cluster %s: %v", name, err)
+			continue
+		}
+		namespaces, err := a.GetNamespaces()
+		if err != nil {
+			scopes.CI.Errorf("Unable to list namespaces of cluster %s: %v", name, err)
+			continue
+		}
+		for _, ns := range namespaces {
+			if systemNamespaces[ns.Name] {
+				continue
+			}
+			d := path.Join(workDir, name, ns.Name)
+			if err := os.MkdirAll(d, os.ModePerm); err != nil {
+				scopes.CI.Errorf("Unable to create dump directory %s: %v", d, err)
+				continue
+			}
+			deployment.DumpNamespace(d, ns.Name, a)
+		}
+	}
+	scopes.CI.Infof("Dumped environment state to %s", workDir)
+}
+
 // ApplyContents applies the given yaml contents to the namespace.
 func (e *Environment) ApplyContents(namespace, yml string) error {
 	_, err := e.Accessor.ApplyContents(namespace, yml)
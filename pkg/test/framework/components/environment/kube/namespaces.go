@@ -0,0 +1,79 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"io"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test/scopes"
+)
+
+var _ io.Closer = &Environment{}
+
+// namespacePool holds the namespaces released by tests for reuse, when Settings.ReuseNamespaces is enabled.
+type namespacePool struct {
+	mu sync.Mutex
+	// released namespaces, by the key of the configuration they were created with.
+	released map[string][]string
+}
+
+// AcquireNamespace returns a namespace that was released for reuse with the given configuration key, if there
+// is one. The namespace is removed from the pool until it is released again.
+func (e *Environment) AcquireNamespace(key string) (string, bool) {
+	e.namespaces.mu.Lock()
+	defer e.namespaces.mu.Unlock()
+
+	names := e.namespaces.released[key]
+	if len(names) == 0 {
+		return "", false
+	}
+	name := names[len(names)-1]
+	e.namespaces.released[key] = names[:len(names)-1]
+	scopes.Framework.Debugf("reusing namespace %s", name)
+	return name, true
+}
+
+// ReleaseNamespace returns the namespace to the pool, for reuse by the next test asking for a namespace with
+// the same configuration key. The pooled namespaces are deleted when the environment is closed.
+func (e *Environment) ReleaseNamespace(key, name string) {
+	e.namespaces.mu.Lock()
+	defer e.namespaces.mu.Unlock()
+
+	if e.namespaces.released == nil {
+		e.namespaces.released = make(map[string][]string)
+	}
+	e.namespaces.released[key] = append(e.namespaces.released[key], name)
+	scopes.Framework.Debugf("released namespace %s for reuse", name)
+}
+
+// Close implements io.Closer. It deletes the namespaces that were released for reuse.
+func (e *Environment) Close() (err error) {
+	e.namespaces.mu.Lock()
+	released := e.namespaces.released
+	e.namespaces.released = nil
+	e.namespaces.mu.Unlock()
+
+	for _, names := range released {
+		for _, name := range names {
+			for _, a := range e.Accessors() {
+				err = multierror.Append(err, a.DeleteNamespace(name)).ErrorOrNil()
+			}
+		}
+	}
+	return
+}
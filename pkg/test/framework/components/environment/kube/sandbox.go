@@ -0,0 +1,257 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+
+	"github.com/hashicorp/go-multierror"
+	kubeApiRbac "k8s.io/api/rbac/v1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// SandboxName is the name of the namespace, ServiceAccount, ClusterRole and ClusterRoleBinding created for
+	// the RBAC sandbox.
+	SandboxName = "istio-test-rbac-sandbox"
+)
+
+// DefaultSandboxRules allow the operations the framework and its components perform on behalf of the tests, once
+// Istio is installed: managing test namespaces and the workloads and Istio configuration in them, and reading the
+// state of the cluster for diagnostics.
+var DefaultSandboxRules = []kubeApiRbac.PolicyRule{
+	{
+		APIGroups: []string{""},
+		Resources: []string{"namespaces"},
+		Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{
+			"pods", "pods/log", "pods/exec", "pods/portforward", "services", "endpoints", "configmaps",
+			"secrets", "serviceaccounts", "events",
+		},
+		Verbs: []string{"*"},
+	},
+	{
+		APIGroups: []string{"apps", "extensions"},
+		Resources: []string{"deployments", "statefulsets", "daemonsets", "replicasets"},
+		Verbs:     []string{"*"},
+	},
+	{
+		APIGroups: []string{"batch"},
+		Resources: []string{"jobs"},
+		Verbs:     []string{"*"},
+	},
+	{
+		APIGroups: []string{"networking.k8s.io"},
+		Resources: []string{"networkpolicies"},
+		Verbs:     []string{"*"},
+	},
+	{
+		APIGroups: []string{
+			"networking.istio.io", "security.istio.io", "authentication.istio.io", "rbac.istio.io",
+			"config.istio.io",
+		},
+		Resources: []string{"*"},
+		Verbs:     []string{"*"},
+	},
+	{
+		APIGroups: []string{"apiextensions.k8s.io"},
+		Resources: []string{"customresourcedefinitions"},
+		Verbs:     []string{"get", "list"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"nodes"},
+		Verbs:     []string{"get", "list"},
+	},
+	{
+		APIGroups: []string{"metrics.k8s.io"},
+		Resources: []string{"pods"},
+		Verbs:     []string{"get"},
+	},
+}
+
+// RBACSandbox returns a setup function that switches the environment, in all clusters, from the credentials of
+// its kube configs to those of a ServiceAccount bound to a ClusterRole with the given rules, or with
+// DefaultSandboxRules if none are given. All of the Kubernetes API operations of the tests, components and tools
+// that run after it are then limited to those rules, which both tests least-privilege operation and catches tests
+// accidentally depending on cluster-admin rights. The original credentials are restored when the suite is done.
+//
+// Since installing Istio requires cluster-admin rights, it must run after the istio setup function.
+//
+// Example:
+//
+//     framework.NewSuite("authz_rbac", m).
+//         Setup(istio.Setup(nil, nil)).
+//         Setup(kube.RBACSandbox()).
+//         Run()
+func RBACSandbox(rules ...kubeApiRbac.PolicyRule) resource.SetupFn {
+	if len(rules) == 0 {
+		rules = DefaultSandboxRules
+	}
+	return func(ctx resource.Context) error {
+		env, ok := ctx.Environment().(*Environment)
+		if !ok {
+			return fmt.Errorf("the RBAC sandbox requires the kube environment")
+		}
+		workDir, err := ctx.CreateTmpDirectory("rbac-sandbox")
+		if err != nil {
+			return err
+		}
+
+		yml, err := sandboxYAML(rules)
+		if err != nil {
+			return err
+		}
+		s := &sandbox{
+			env:      env,
+			settings: env.s.clone(),
+			yml:      yml,
+		}
+		// Restore the original credentials even if only some of the clusters were switched.
+		s.id = ctx.TrackResource(s)
+		for _, name := range env.ClusterNames() {
+			if err := s.enter(name, workDir); err != nil {
+				return fmt.Errorf("failed entering the RBAC sandbox on cluster %s: %v", name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// sandbox tracks the original accessors of the clusters of an environment in an RBAC sandbox.
+type sandbox struct {
+	id       resource.ID
+	env      *Environment
+	settings *Settings
+	yml      string
+
+	// admins are the original accessors of the clusters that entered the sandbox, by cluster name.
+	admins map[string]*kube.Accessor
+}
+
+var _ resource.Resource = &sandbox{}
+var _ io.Closer = &sandbox{}
+
+func (s *sandbox) ID() resource.ID {
+	return s.id
+}
+
+func (s *sandbox) enter(cluster string, workDir string) error {
+	admin, err := s.env.Cluster(cluster)
+	if err != nil {
+		return err
+	}
+
+	if !admin.NamespaceExists(SandboxName) {
+		if err := admin.CreateNamespace(SandboxName, "istio-test"); err != nil {
+			return err
+		}
+	}
+	if _, err := admin.ApplyContents(SandboxName, s.yml); err != nil {
+		return err
+	}
+
+	kubeConfig, err := admin.ServiceAccountKubeConfig(SandboxName, SandboxName)
+	if err != nil {
+		return err
+	}
+	kubeConfigFile := path.Join(workDir, fmt.Sprintf("kubeconfig-%s", cluster))
+	if err := ioutil.WriteFile(kubeConfigFile, []byte(kubeConfig), 0600); err != nil {
+		return err
+	}
+	accessor, err := kube.NewAccessor(kubeConfigFile, workDir)
+	if err != nil {
+		return err
+	}
+
+	if s.admins == nil {
+		s.admins = make(map[string]*kube.Accessor)
+	}
+	s.admins[cluster] = admin
+	s.env.setCluster(cluster, accessor, kubeConfigFile)
+	scopes.Framework.Infof("Cluster %s entered the RBAC sandbox, using the credentials of %s/%s",
+		cluster, SandboxName, SandboxName)
+	return nil
+}
+
+// Close restores the original credentials of the clusters and deletes the sandbox.
+func (s *sandbox) Close() (err error) {
+	for cluster, admin := range s.admins {
+		kubeConfig := s.settings.KubeConfig
+		if cluster != s.settings.ClusterName {
+			kubeConfig = s.settings.RemoteKubeConfigs[cluster]
+		}
+		s.env.setCluster(cluster, admin, kubeConfig)
+
+		err = multierror.Append(err,
+			admin.DeleteContents(SandboxName, s.yml),
+			admin.DeleteNamespace(SandboxName),
+		).ErrorOrNil()
+	}
+	s.admins = nil
+	return
+}
+
+// sandboxYAML returns the ServiceAccount, ClusterRole and ClusterRoleBinding of the sandbox.
+func sandboxYAML(rules []kubeApiRbac.PolicyRule) (string, error) {
+	meta := kubeApiMeta.ObjectMeta{
+		Name:   SandboxName,
+		Labels: map[string]string{"istio-testing": "istio-test"},
+	}
+	role := &kubeApiRbac.ClusterRole{
+		TypeMeta:   kubeApiMeta.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+		ObjectMeta: meta,
+		Rules:      rules,
+	}
+	binding := &kubeApiRbac.ClusterRoleBinding{
+		TypeMeta:   kubeApiMeta.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+		ObjectMeta: meta,
+		RoleRef: kubeApiRbac.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     SandboxName,
+		},
+		Subjects: []kubeApiRbac.Subject{{
+			Kind:      kubeApiRbac.ServiceAccountKind,
+			Name:      SandboxName,
+			Namespace: SandboxName,
+		}},
+	}
+
+	out := fmt.Sprintf(`apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: %s
+`, SandboxName)
+	for _, obj := range []interface{}{role, binding} {
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		out += "---\n" + string(b)
+	}
+	return out, nil
+}
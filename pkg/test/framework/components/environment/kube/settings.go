@@ -16,6 +16,12 @@ package kube
 
 import (
 	"fmt"
+	"sort"
+)
+
+const (
+	// DefaultClusterName is the name of the primary cluster, unless configured otherwise.
+	DefaultClusterName = "primary"
 )
 
 // Settings provide kube-specific Settings from flags.
@@ -23,22 +29,75 @@ type Settings struct {
 	// Path to kube config file. Required if the environment is kubernetes.
 	KubeConfig string
 
+	// ClusterName is the name of the primary cluster, configured by KubeConfig.
+	ClusterName string
+
+	// RemoteKubeConfigs maps the names of additional clusters in a multicluster topology to the paths of
+	// their kube config files.
+	RemoteKubeConfigs map[string]string
+
+	// TopologyFile is the path of the file the clusters were read from, if any. See Topology.
+	TopologyFile string
+
+	// ConfigClusterName is the name of the cluster the Istio configuration is applied to. Defaults to the
+	// primary cluster.
+	ConfigClusterName string
+
+	// contexts maps the names of the clusters using a context other than the current one of their kube
+	// config to the context. They are resolved when the environment is created.
+	contexts map[string]string
+
 	// Indicates that the Ingress Gateway is not available. This typically happens in Minikube. The Ingress
 	// component will fall back to node-port in this case.
 	Minikube bool
+
+	// ReuseNamespaces keeps the namespaces created by a test after it is done, and hands them out to the next
+	// tests asking for a namespace with the same configuration, after deleting the resources deployed into them.
+	ReuseNamespaces bool
 }
 
 func (s *Settings) clone() *Settings {
 	c := *s
+	c.RemoteKubeConfigs = make(map[string]string, len(s.RemoteKubeConfigs))
+	for name, path := range s.RemoteKubeConfigs {
+		c.RemoteKubeConfigs[name] = path
+	}
+	if s.contexts != nil {
+		c.contexts = make(map[string]string, len(s.contexts))
+		for name, context := range s.contexts {
+			c.contexts[name] = context
+		}
+	}
 	return &c
 }
 
+// RemoteClusterNames returns the sorted names of the remote clusters.
+func (s *Settings) RemoteClusterNames() []string {
+	names := make([]string, 0, len(s.RemoteKubeConfigs))
+	for name := range s.RemoteKubeConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // String implements fmt.Stringer
 func (s *Settings) String() string {
 	result := ""
 
+	if s.TopologyFile != "" {
+		result += fmt.Sprintf("TopologyFile:    %s\n", s.TopologyFile)
+	}
 	result += fmt.Sprintf("KubeConfig:      %s\n", s.KubeConfig)
+	result += fmt.Sprintf("ClusterName:     %s\n", s.ClusterName)
+	for _, name := range s.RemoteClusterNames() {
+		result += fmt.Sprintf("RemoteCluster:   %s=%s\n", name, s.RemoteKubeConfigs[name])
+	}
+	if s.ConfigClusterName != "" {
+		result += fmt.Sprintf("ConfigCluster:   %s\n", s.ConfigClusterName)
+	}
 	result += fmt.Sprintf("MiniKubeIngress: %v\n", s.Minikube)
+	result += fmt.Sprintf("ReuseNamespaces: %v\n", s.ReuseNamespaces)
 
 	return result
 }
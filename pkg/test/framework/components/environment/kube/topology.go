@@ -0,0 +1,198 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pkg/test/shell"
+)
+
+// ClusterRole is the role of a cluster in a multicluster topology.
+type ClusterRole string
+
+const (
+	// RolePrimary is the role of the cluster running the Istio control plane. Exactly one cluster of a topology
+	// has this role.
+	RolePrimary ClusterRole = "primary"
+	// RoleRemote is the role of the clusters whose workloads are managed by the control plane of the primary
+	// cluster.
+	RoleRemote ClusterRole = "remote"
+	// RoleConfig is the role of the cluster the Istio configuration is applied to. Defaults to the primary
+	// cluster. Galley only reads the configuration of the cluster it runs in, so this must be the primary
+	// cluster for now.
+	RoleConfig ClusterRole = "config"
+)
+
+// Topology of the clusters of the environment, as read from the file given with istio.test.kube.topology.
+//
+// Example:
+//
+//     clusters:
+//     - name: primary
+//       kubeconfig: ~/.kube/config
+//       context: gke_my-project_us-central1-a_primary
+//       roles: [primary, config]
+//     - name: remote
+//       kubeconfig: ~/.kube/remote.yaml
+//       roles: [remote]
+type Topology struct {
+	Clusters []ClusterTopology `json:"clusters"`
+}
+
+// ClusterTopology describes a single cluster of a Topology.
+type ClusterTopology struct {
+	// Name of the cluster, which must be unique in the topology.
+	Name string `json:"name"`
+	// KubeConfig is the path of the kube config file of the cluster.
+	KubeConfig string `json:"kubeconfig"`
+	// Context in the kube config file to use. Defaults to the current context of the file.
+	Context string `json:"context,omitempty"`
+	// Roles of the cluster.
+	Roles []ClusterRole `json:"roles"`
+}
+
+func (c ClusterTopology) hasRole(role ClusterRole) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTopology parses and validates the given topology file contents.
+func parseTopology(data []byte) (*Topology, error) {
+	t := &Topology{}
+	if err := yaml.UnmarshalStrict(data, t); err != nil {
+		return nil, fmt.Errorf("invalid topology: %v", err)
+	}
+
+	if len(t.Clusters) == 0 {
+		return nil, fmt.Errorf("invalid topology: no clusters")
+	}
+	names := make(map[string]bool)
+	primary := ""
+	config := ""
+	for _, c := range t.Clusters {
+		if c.Name == "" || c.KubeConfig == "" {
+			return nil, fmt.Errorf("invalid topology: clusters require a name and a kubeconfig")
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("invalid topology: duplicate cluster name %q", c.Name)
+		}
+		names[c.Name] = true
+
+		if len(c.Roles) == 0 {
+			return nil, fmt.Errorf("invalid topology: cluster %s has no roles", c.Name)
+		}
+		for _, r := range c.Roles {
+			switch r {
+			case RolePrimary:
+				if primary != "" {
+					return nil, fmt.Errorf("invalid topology: clusters %s and %s are both primary", primary, c.Name)
+				}
+				primary = c.Name
+			case RoleConfig:
+				if config != "" {
+					return nil, fmt.Errorf("invalid topology: clusters %s and %s both hold the config", config, c.Name)
+				}
+				config = c.Name
+			case RoleRemote:
+			default:
+				return nil, fmt.Errorf("invalid topology: unknown role %q of cluster %s", r, c.Name)
+			}
+		}
+		if c.hasRole(RoleRemote) && (c.hasRole(RolePrimary) || c.hasRole(RoleConfig)) {
+			return nil, fmt.Errorf("invalid topology: remote cluster %s cannot be primary or hold the config", c.Name)
+		}
+	}
+	if primary == "" {
+		return nil, fmt.Errorf("invalid topology: no primary cluster")
+	}
+	if config != "" && config != primary {
+		return nil, fmt.Errorf("invalid topology: the config cluster %s must be the primary cluster %s", config, primary)
+	}
+	return t, nil
+}
+
+// applyTopology configures the clusters of the settings from the topology in the given file.
+func (s *Settings) applyTopology(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	t, err := parseTopology(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+
+	s.TopologyFile = filename
+	s.RemoteKubeConfigs = make(map[string]string)
+	s.contexts = make(map[string]string)
+	for _, c := range t.Clusters {
+		kubeConfig := c.KubeConfig
+		if err := normalizeFile(&kubeConfig); err != nil {
+			return err
+		}
+		if c.hasRole(RoleConfig) {
+			s.ConfigClusterName = c.Name
+		}
+		if c.hasRole(RolePrimary) {
+			s.ClusterName = c.Name
+			s.KubeConfig = kubeConfig
+		} else {
+			s.RemoteKubeConfigs[c.Name] = kubeConfig
+		}
+		if c.Context != "" {
+			s.contexts[c.Name] = c.Context
+		}
+	}
+	return nil
+}
+
+// resolveContexts replaces the kube config files of the clusters using a context other than the current one with
+// self-contained kube config files in workDir, whose current context is the configured one. This way the rest of
+// the framework, and the tools it runs, only need the path of the kube config file of a cluster.
+func (s *Settings) resolveContexts(workDir string) error {
+	for name, context := range s.contexts {
+		kubeConfig := s.KubeConfig
+		if name != s.ClusterName {
+			kubeConfig = s.RemoteKubeConfigs[name]
+		}
+		out, err := shell.Execute(false, "kubectl config view --minify --flatten --kubeconfig=%s --context=%s",
+			kubeConfig, context)
+		if err != nil {
+			return fmt.Errorf("unable to select context %s of %s for cluster %s: %v: %s",
+				context, kubeConfig, name, err, strings.TrimSpace(out))
+		}
+		resolved := path.Join(workDir, fmt.Sprintf("kubeconfig-%s", name))
+		if err := ioutil.WriteFile(resolved, []byte(out), 0600); err != nil {
+			return err
+		}
+		if name == s.ClusterName {
+			s.KubeConfig = resolved
+		} else {
+			s.RemoteKubeConfigs[name] = resolved
+		}
+	}
+	s.contexts = nil
+	return nil
+}
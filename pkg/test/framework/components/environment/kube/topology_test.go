@@ -0,0 +1,133 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTopology(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "primary and remote",
+			data: `
+clusters:
+- name: primary
+  kubeconfig: /tmp/primary
+  context: ctx
+  roles: [primary, config]
+- name: remote
+  kubeconfig: /tmp/remote
+  roles: [remote]
+`,
+		},
+		{
+			name:    "no clusters",
+			data:    `clusters: []`,
+			wantErr: "no clusters",
+		},
+		{
+			name: "no primary",
+			data: `
+clusters:
+- name: remote
+  kubeconfig: /tmp/remote
+  roles: [remote]
+`,
+			wantErr: "no primary cluster",
+		},
+		{
+			name: "two primaries",
+			data: `
+clusters:
+- name: a
+  kubeconfig: /tmp/a
+  roles: [primary]
+- name: b
+  kubeconfig: /tmp/b
+  roles: [primary]
+`,
+			wantErr: "both primary",
+		},
+		{
+			name: "duplicate name",
+			data: `
+clusters:
+- name: a
+  kubeconfig: /tmp/a
+  roles: [primary]
+- name: a
+  kubeconfig: /tmp/b
+  roles: [remote]
+`,
+			wantErr: "duplicate cluster name",
+		},
+		{
+			name: "config on remote",
+			data: `
+clusters:
+- name: a
+  kubeconfig: /tmp/a
+  roles: [primary]
+- name: b
+  kubeconfig: /tmp/b
+  roles: [remote, config]
+`,
+			wantErr: "cannot be primary or hold the config",
+		},
+		{
+			name: "unknown role",
+			data: `
+clusters:
+- name: a
+  kubeconfig: /tmp/a
+  roles: [leader]
+`,
+			wantErr: "unknown role",
+		},
+		{
+			name: "unknown field",
+			data: `
+clusters:
+- name: a
+  kubeconfig: /tmp/a
+  role: primary
+`,
+			wantErr: "invalid topology",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			topology, err := parseTopology([]byte(c.data))
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("got error %v, want %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(topology.Clusters) != 2 || topology.Clusters[0].Context != "ctx" {
+				t.Fatalf("unexpected topology: %+v", topology)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalca
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/fakes/externalca"
+)
+
+const (
+	adminTimeout = 10 * time.Second
+)
+
+// client for the admin API of the fake external CA.
+type client struct {
+	// address of the admin API, in host:port form.
+	address string
+}
+
+func (c *client) RootCert() ([]byte, error) {
+	return c.do(http.MethodGet, externalca.RootCertPath, nil)
+}
+
+func (c *client) RootCertOrFail(t test.Failer) []byte {
+	t.Helper()
+	root, err := c.RootCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func (c *client) SetFault(fault Fault) error {
+	body, err := json.Marshal(fault)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(http.MethodPut, externalca.FaultPath, body)
+	return err
+}
+
+func (c *client) SetFaultOrFail(t test.Failer, fault Fault) {
+	t.Helper()
+	if err := c.SetFault(fault); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *client) SetOutage() error {
+	return c.SetError(codes.Unavailable)
+}
+
+func (c *client) SetOutageOrFail(t test.Failer) {
+	t.Helper()
+	if err := c.SetOutage(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *client) SetError(code codes.Code) error {
+	return c.SetFault(Fault{Code: code})
+}
+
+func (c *client) SetErrorOrFail(t test.Failer, code codes.Code) {
+	t.Helper()
+	if err := c.SetError(code); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *client) SetDelay(delay time.Duration) error {
+	return c.SetFault(Fault{Delay: delay})
+}
+
+func (c *client) SetDelayOrFail(t test.Failer, delay time.Duration) {
+	t.Helper()
+	if err := c.SetDelay(delay); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *client) ClearFault() error {
+	_, err := c.do(http.MethodDelete, externalca.FaultPath, nil)
+	return err
+}
+
+func (c *client) ClearFaultOrFail(t test.Failer) {
+	t.Helper()
+	if err := c.ClearFault(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *client) Stats() (Stats, error) {
+	stats := Stats{}
+	body, err := c.do(http.MethodGet, externalca.StatsPath, nil)
+	if err != nil {
+		return stats, err
+	}
+	err = json.Unmarshal(body, &stats)
+	return stats, err
+}
+
+func (c *client) StatsOrFail(t test.Failer) Stats {
+	t.Helper()
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func (c *client) do(method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", c.address, path), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpClient := http.Client{
+		Timeout: adminTimeout,
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("external CA admin %s %s returned %d: %s", method, path, resp.StatusCode, string(out))
+	}
+	return out, nil
+}
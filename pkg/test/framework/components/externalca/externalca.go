@@ -0,0 +1,109 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalca
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/fakes/externalca"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Fault injected on the signing path of the external CA.
+type Fault = externalca.Fault
+
+// Stats about the signing requests served by the external CA.
+type Stats = externalca.Stats
+
+// Config for the external CA.
+type Config struct {
+	// Namespace to deploy the CA to. If not set, a new namespace is created. Only used in the
+	// Kubernetes environment.
+	Namespace namespace.Instance
+
+	// SigningCert and SigningKey are the PEM encoded CA certificate and key used for signing workload
+	// certificates. If not set, the CA generates a self-signed root.
+	SigningCert []byte
+	SigningKey  []byte
+
+	// CertChain is the PEM encoded chain from the signing certificate's issuer up to and including the root.
+	// Only needed if the signing certificate is an intermediate.
+	CertChain []byte
+}
+
+// Instance represents a deployed external CA, implementing the Istio CA gRPC API. Its address can be used as the
+// CA endpoint of the node agent (CA_ADDR, with the Citadel CA provider).
+type Instance interface {
+	resource.Resource
+
+	// Address of the Istio CA API, in host:port form, as seen from within the cluster. In the Kubernetes
+	// environment the API is served over TLS, with a certificate issued by the CA itself.
+	Address() string
+
+	// RootCert returns the PEM encoded root certificate of the CA.
+	RootCert() ([]byte, error)
+	RootCertOrFail(t test.Failer) []byte
+
+	// SetFault injects a delay and/or an error code into the signing path.
+	SetFault(fault Fault) error
+	SetFaultOrFail(t test.Failer, fault Fault)
+
+	// SetOutage makes the CA fail all signing requests with UNAVAILABLE.
+	SetOutage() error
+	SetOutageOrFail(t test.Failer)
+
+	// SetError makes the CA fail all signing requests with the given code.
+	SetError(code codes.Code) error
+	SetErrorOrFail(t test.Failer, code codes.Code)
+
+	// SetDelay makes the CA wait for the given duration before signing.
+	SetDelay(delay time.Duration) error
+	SetDelayOrFail(t test.Failer, delay time.Duration)
+
+	// ClearFault restores the normal behavior of the CA.
+	ClearFault() error
+	ClearFaultOrFail(t test.Failer)
+
+	// Stats returns statistics about the signing requests received by the CA.
+	Stats() (Stats, error)
+	StatsOrFail(t test.Failer) Stats
+}
+
+// New returns a new instance of the external CA.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Native, func() {
+		i, err = newNative(ctx, cfg)
+	})
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("externalca.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,235 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalca
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"istio.io/istio/pkg/test/deployment"
+	"istio.io/istio/pkg/test/fakes/externalca"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/core/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	serviceName = "external-ca"
+
+	signingMountPath = "/etc/external-ca"
+
+	template = `
+{{- if .signingCert }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.app}}-signing
+type: Opaque
+data:
+  signing-cert.pem: "{{.signingCert}}"
+  signing-key.pem: "{{.signingKey}}"
+  cert-chain.pem: "{{.certChain}}"
+---
+{{- end }}
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{.app}}
+  labels:
+    app: {{.app}}
+spec:
+  ports:
+  - port: {{.grpcPort}}
+    targetPort: {{.grpcPort}}
+    name: grpc
+  selector:
+    app: {{.app}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.app}}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: {{.app}}
+  template:
+    metadata:
+      labels:
+        app: {{.app}}
+      annotations:
+        sidecar.istio.io/inject: "false"
+    spec:
+      containers:
+      - name: app
+        image: "{{.Hub}}/test_externalca:{{.Tag}}"
+        imagePullPolicy: {{.ImagePullPolicy}}
+        args:
+        - --grpcPort={{.grpcPort}}
+        - --adminPort={{.adminPort}}
+        - --hosts={{.hosts}}
+{{- if .signingCert }}
+        - --signingCert={{.mountPath}}/signing-cert.pem
+        - --signingKey={{.mountPath}}/signing-key.pem
+        - --certChain={{.mountPath}}/cert-chain.pem
+{{- end }}
+        ports:
+        - name: grpc
+          containerPort: {{.grpcPort}}
+        - name: admin
+          containerPort: {{.adminPort}}
+        readinessProbe:
+          httpGet:
+            path: {{.path}}
+            port: admin
+          initialDelaySeconds: 1
+{{- if .signingCert }}
+        volumeMounts:
+        - name: signing
+          mountPath: {{.mountPath}}
+          readOnly: true
+      volumes:
+      - name: signing
+        secret:
+          secretName: {{.app}}-signing
+{{- end }}
+---
+`
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id resource.ID
+
+	*client
+
+	namespace  namespace.Instance
+	forwarder  testKube.PortForwarder
+	deployment *deployment.Instance
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	env := ctx.Environment().(*kube.Environment)
+	c := &kubeComponent{
+		client:    &client{},
+		namespace: cfg.Namespace,
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	scopes.CI.Infof("=== BEGIN: external CA Deployment ===")
+	defer func() {
+		if err != nil {
+			scopes.CI.Infof("=== FAILED: external CA Deployment ===")
+			_ = c.Close()
+		} else {
+			scopes.CI.Infof("=== SUCCEEDED: external CA Deployment ===")
+		}
+	}()
+
+	if len(cfg.SigningCert) > 0 && len(cfg.SigningKey) == 0 {
+		err = fmt.Errorf("external CA: signing key is required with the signing certificate")
+		return nil, err
+	}
+
+	if c.namespace == nil {
+		if c.namespace, err = namespace.New(ctx, namespace.Config{
+			Prefix: "external-ca",
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+
+	ns := c.namespace.Name()
+	hosts := []string{
+		serviceName,
+		fmt.Sprintf("%s.%s", serviceName, ns),
+		fmt.Sprintf("%s.%s.svc", serviceName, ns),
+		fmt.Sprintf("%s.%s.svc.cluster.local", serviceName, ns),
+	}
+	yamlContent, err := tmpl.Evaluate(template, map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.Tag,
+		"ImagePullPolicy": s.PullPolicy,
+		"app":             serviceName,
+		"grpcPort":        externalca.DefaultGRPCPort,
+		"adminPort":       externalca.DefaultAdminPort,
+		"path":            externalca.RootCertPath,
+		"hosts":           strings.Join(hosts, ","),
+		"mountPath":       signingMountPath,
+		"signingCert":     base64.StdEncoding.EncodeToString(cfg.SigningCert),
+		"signingKey":      base64.StdEncoding.EncodeToString(cfg.SigningKey),
+		"certChain":       base64.StdEncoding.EncodeToString(cfg.CertChain),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.deployment = deployment.NewYamlContentDeployment(ns, yamlContent)
+	if err = c.deployment.Deploy(env.Accessor, false); err != nil {
+		return nil, err
+	}
+
+	fetchFn := env.NewSinglePodFetch(ns, "app="+serviceName)
+	pods, err := env.WaitUntilPodsAreReady(fetchFn)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err = env.WaitUntilServiceEndpointsAreReady(ns, serviceName); err != nil {
+		return nil, err
+	}
+
+	if c.forwarder, err = env.NewPortForwarder(pods[0], 0, externalca.DefaultAdminPort); err != nil {
+		return nil, err
+	}
+	if err = c.forwarder.Start(); err != nil {
+		return nil, err
+	}
+	c.client.address = c.forwarder.Address()
+	scopes.Framework.Debugf("initialized external CA admin port forwarder: %v", c.forwarder.Address())
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Address() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", serviceName, c.namespace.Name(), externalca.DefaultGRPCPort)
+}
+
+func (c *kubeComponent) Close() (err error) {
+	if c.forwarder != nil {
+		err = c.forwarder.Close()
+		c.forwarder = nil
+	}
+	return err
+}
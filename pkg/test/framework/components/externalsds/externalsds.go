@@ -0,0 +1,75 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package externalsds provides a component that stands in for a third-party identity provider (e.g. SPIRE),
+// serving workload certificates to the sidecars over the SDS Unix domain socket of each node.
+package externalsds
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/fakes/sds"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Stats about the SDS requests served by the external SDS servers.
+type Stats = sds.Stats
+
+// Config for the external SDS servers.
+type Config struct {
+	// Namespace to deploy the servers to. If not set, a new namespace is created.
+	Namespace namespace.Instance
+
+	// TrustDomain of the issued identities. Defaults to cluster.local.
+	TrustDomain string
+
+	// HostPath is the directory of the nodes the socket is created in. It must be the directory that the
+	// injected sidecars mount for SDS, i.e. Istio must be deployed with global.sds.enabled=true, and with
+	// nodeagent.enabled=false so that the node agent doesn't serve the same socket. Defaults to
+	// /var/run/sds.
+	HostPath string
+}
+
+// Instance represents the external SDS servers, one on every node of the cluster. All of them issue
+// certificates from the same CA.
+type Instance interface {
+	resource.Resource
+
+	// RootCert returns the PEM encoded root certificate of the CA issuing the workload certificates.
+	RootCert() []byte
+
+	// Stats returns the statistics about the SDS requests, summed over all servers.
+	Stats() (Stats, error)
+	StatsOrFail(t test.Failer) Stats
+}
+
+// New deploys the external SDS servers and waits until all of them are ready.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("externalsds.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,271 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalsds
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/deployment"
+	"istio.io/istio/pkg/test/fakes/sds"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/core/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	testKube "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/tmpl"
+)
+
+const (
+	appName = "external-sds"
+
+	defaultHostPath  = "/var/run/sds"
+	signingMountPath = "/etc/external-sds"
+	udsFileName      = "uds_path"
+
+	adminTimeout = 10 * time.Second
+
+	template = `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.app}}-signing
+type: Opaque
+data:
+  signing-cert.pem: "{{.signingCert}}"
+  signing-key.pem: "{{.signingKey}}"
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: {{.app}}
+spec:
+  selector:
+    matchLabels:
+      app: {{.app}}
+  template:
+    metadata:
+      labels:
+        app: {{.app}}
+      annotations:
+        sidecar.istio.io/inject: "false"
+    spec:
+      containers:
+      - name: app
+        image: "{{.Hub}}/test_sds:{{.Tag}}"
+        imagePullPolicy: {{.ImagePullPolicy}}
+        args:
+        - --udsPath={{.socketPath}}
+        - --adminPort={{.adminPort}}
+        - --trustDomain={{.trustDomain}}
+        - --signingCert={{.mountPath}}/signing-cert.pem
+        - --signingKey={{.mountPath}}/signing-key.pem
+        ports:
+        - name: admin
+          containerPort: {{.adminPort}}
+        readinessProbe:
+          httpGet:
+            path: {{.path}}
+            port: admin
+          initialDelaySeconds: 1
+        volumeMounts:
+        - name: sds-uds-path
+          mountPath: {{.hostPath}}
+        - name: signing
+          mountPath: {{.mountPath}}
+          readOnly: true
+      volumes:
+      - name: sds-uds-path
+        hostPath:
+          path: {{.hostPath}}
+          type: DirectoryOrCreate
+      - name: signing
+        secret:
+          secretName: {{.app}}-signing
+---
+`
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id resource.ID
+
+	namespace  namespace.Instance
+	rootCert   []byte
+	forwarders []testKube.PortForwarder
+	deployment *deployment.Instance
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	env := ctx.Environment().(*kube.Environment)
+	c := &kubeComponent{
+		namespace: cfg.Namespace,
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	scopes.CI.Infof("=== BEGIN: external SDS Deployment ===")
+	defer func() {
+		if err != nil {
+			scopes.CI.Infof("=== FAILED: external SDS Deployment ===")
+			_ = c.Close()
+		} else {
+			scopes.CI.Infof("=== SUCCEEDED: external SDS Deployment ===")
+		}
+	}()
+
+	if cfg.TrustDomain == "" {
+		cfg.TrustDomain = sds.DefaultTrustDomain
+	}
+	if cfg.HostPath == "" {
+		cfg.HostPath = defaultHostPath
+	}
+
+	if c.namespace == nil {
+		if c.namespace, err = namespace.New(ctx, namespace.Config{
+			Prefix: "external-sds",
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// All servers share the CA, so that their workloads trust each other.
+	var signingKey []byte
+	if c.rootCert, signingKey, err = sds.GenerateCA(); err != nil {
+		return nil, err
+	}
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+
+	ns := c.namespace.Name()
+	yamlContent, err := tmpl.Evaluate(template, map[string]interface{}{
+		"Hub":             s.Hub,
+		"Tag":             s.Tag,
+		"ImagePullPolicy": s.PullPolicy,
+		"app":             appName,
+		"adminPort":       sds.DefaultAdminPort,
+		"path":            sds.RootCertPath,
+		"trustDomain":     cfg.TrustDomain,
+		"hostPath":        cfg.HostPath,
+		"socketPath":      path.Join(cfg.HostPath, udsFileName),
+		"mountPath":       signingMountPath,
+		"signingCert":     base64.StdEncoding.EncodeToString(c.rootCert),
+		"signingKey":      base64.StdEncoding.EncodeToString(signingKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.deployment = deployment.NewYamlContentDeployment(ns, yamlContent)
+	if err = c.deployment.Deploy(env.Accessor, false); err != nil {
+		return nil, err
+	}
+
+	pods, err := env.WaitUntilPodsAreReady(env.NewPodFetch(ns, "app="+appName))
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		var forwarder testKube.PortForwarder
+		if forwarder, err = env.NewPortForwarder(pod, 0, sds.DefaultAdminPort); err != nil {
+			return nil, err
+		}
+		c.forwarders = append(c.forwarders, forwarder)
+		if err = forwarder.Start(); err != nil {
+			return nil, err
+		}
+		scopes.Framework.Debugf("initialized external SDS admin port forwarder for %s: %v",
+			pod.Name, forwarder.Address())
+	}
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) RootCert() []byte {
+	return c.rootCert
+}
+
+func (c *kubeComponent) Stats() (Stats, error) {
+	total := Stats{}
+	for _, f := range c.forwarders {
+		stats, err := getStats(f.Address())
+		if err != nil {
+			return total, err
+		}
+		total.Requests += stats.Requests
+		total.Failures += stats.Failures
+		total.Identities = append(total.Identities, stats.Identities...)
+	}
+	return total, nil
+}
+
+func (c *kubeComponent) StatsOrFail(t test.Failer) Stats {
+	t.Helper()
+	stats, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func getStats(address string) (Stats, error) {
+	stats := Stats{}
+	httpClient := http.Client{
+		Timeout: adminTimeout,
+	}
+	resp, err := httpClient.Get(fmt.Sprintf("http://%s%s", address, sds.StatsPath))
+	if err != nil {
+		return stats, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return stats, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return stats, fmt.Errorf("external SDS admin %s returned %d: %s", sds.StatsPath, resp.StatusCode, string(body))
+	}
+	err = json.Unmarshal(body, &stats)
+	return stats, err
+}
+
+func (c *kubeComponent) Close() (err error) {
+	for _, f := range c.forwarders {
+		err = multierror.Append(err, f.Close()).ErrorOrNil()
+	}
+	c.forwarders = nil
+	return err
+}
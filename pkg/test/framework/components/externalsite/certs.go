@@ -0,0 +1,87 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalsite
+
+import (
+	"fmt"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	rootTTL = 7 * 24 * time.Hour
+	certTTL = 24 * time.Hour
+	keySize = 2048
+)
+
+// serverCert is the PEM encoded certificate and key presented by a host.
+type serverCert struct {
+	cert []byte
+	key  []byte
+}
+
+// generateCerts returns a new self-signed root certificate and, for each of the given hosts, a server
+// certificate signed by the root according to its mode.
+func generateCerts(hosts map[CertMode]string) ([]byte, map[CertMode]serverCert, error) {
+	rootCert, rootKey, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          "Istio Test External Site",
+		NotBefore:    time.Now().Add(-time.Hour),
+		TTL:          rootTTL,
+		RSAKeySize:   keySize,
+		IsCA:         true,
+		IsSelfSigned: true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	signerCert, err := util.ParsePemEncodedCertificate(rootCert)
+	if err != nil {
+		return nil, nil, err
+	}
+	signerKey, err := util.ParsePemEncodedKey(rootKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certs := make(map[CertMode]serverCert, len(hosts))
+	for mode, host := range hosts {
+		opts := util.CertOptions{
+			Host:       host,
+			NotBefore:  time.Now().Add(-time.Hour),
+			TTL:        certTTL,
+			SignerCert: signerCert,
+			SignerPriv: signerKey,
+			Org:        "Istio Test External Site",
+			RSAKeySize: keySize,
+			IsServer:   true,
+		}
+		switch mode {
+		case Valid:
+		case Expired:
+			opts.NotBefore = time.Now().Add(-2 * certTTL)
+		case WrongSAN:
+			opts.Host = WrongHost
+		default:
+			return nil, nil, fmt.Errorf("unsupported certificate mode %q", mode)
+		}
+		cert, key, err := util.GenCertKeyFromOptions(opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("generating the %s certificate: %v", mode, err)
+		}
+		certs[mode] = serverCert{cert: cert, key: key}
+	}
+	return rootCert, certs, nil
+}
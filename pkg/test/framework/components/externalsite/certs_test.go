@@ -0,0 +1,74 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalsite
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestGenerateCerts(t *testing.T) {
+	const host = "external-valid.ns.svc.cluster.local"
+	root, certs, err := generateCerts(map[CertMode]string{
+		Valid:    host,
+		Expired:  host,
+		WrongSAN: host,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(root) {
+		t.Fatal("invalid root certificate")
+	}
+
+	cases := []struct {
+		mode    CertMode
+		wantErr string
+	}{
+		{Valid, ""},
+		{Expired, "expired"},
+		{WrongSAN, "not " + host},
+	}
+	for _, c := range cases {
+		t.Run(string(c.mode), func(t *testing.T) {
+			cert, err := util.ParsePemEncodedCertificate(certs[c.mode].cert)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = cert.Verify(x509.VerifyOptions{
+				DNSName:     host,
+				Roots:       roots,
+				CurrentTime: time.Now(),
+			})
+			switch {
+			case c.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)):
+				t.Fatalf("expected error containing %q, got %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateCertsUnsupportedMode(t *testing.T) {
+	if _, _, err := generateCerts(map[CertMode]string{"bogus": "host"}); err == nil {
+		t.Fatal("expected an error for an unsupported mode")
+	}
+}
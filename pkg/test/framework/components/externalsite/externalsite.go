@@ -0,0 +1,92 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package externalsite provides an HTTPS site outside of the mesh, whose server certificates can be made
+// valid, expired or issued for the wrong host. It allows tests of egress TLS origination and certificate
+// validation to run without depending on endpoints on the internet.
+package externalsite
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// CertMode selects the server certificate presented by a host of the site.
+type CertMode string
+
+const (
+	// Valid certificates are signed by the root of the site, unexpired and issued for the host.
+	Valid CertMode = "valid"
+	// Expired certificates are signed by the root of the site and issued for the host, but expired.
+	Expired CertMode = "expired"
+	// WrongSAN certificates are signed by the root of the site and unexpired, but issued for WrongHost.
+	WrongSAN CertMode = "wrong-san"
+
+	// WrongHost is the only subject alternative name of the WrongSAN certificates.
+	WrongHost = "wrong.example.com"
+)
+
+// AllModes lists all of the supported certificate modes.
+var AllModes = []CertMode{Valid, Expired, WrongSAN}
+
+// Config for the external site.
+type Config struct {
+	// Namespace to deploy the site to. If not set, a new namespace without sidecar injection is created.
+	// The namespace must not have sidecar injection enabled.
+	Namespace namespace.Instance
+
+	// Modes of the hosts to deploy. Defaults to AllModes.
+	Modes []CertMode
+}
+
+// Instance represents a deployed external HTTPS site. Each certificate mode is served by a separate host,
+// which only terminates TLS on Port.
+type Instance interface {
+	resource.Resource
+
+	// Host returns the hostname that serves a certificate of the given mode.
+	Host(mode CertMode) string
+
+	// Port the hosts serve HTTPS on.
+	Port() int
+
+	// RootCert returns the PEM encoded root certificate that signed the certificates of all hosts.
+	RootCert() []byte
+
+	// Echo returns the echo instance that backs the host of the given mode, or nil if the mode wasn't
+	// deployed.
+	Echo(mode CertMode) echo.Instance
+}
+
+// New returns a new instance of the external site.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("externalsite.NewOrFail: %v", err)
+	}
+	return i
+}
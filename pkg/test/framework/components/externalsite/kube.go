@@ -0,0 +1,141 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalsite
+
+import (
+	"fmt"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// servicePort is the port of the Kubernetes Service of each host.
+	servicePort = 443
+	// instancePort is the port the echo application terminates TLS on.
+	instancePort = 8443
+)
+
+var _ Instance = &kubeComponent{}
+
+type kubeComponent struct {
+	id resource.ID
+
+	namespace namespace.Instance
+	rootCert  []byte
+	echos     map[CertMode]echo.Instance
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	c := &kubeComponent{
+		namespace: cfg.Namespace,
+		echos:     make(map[CertMode]echo.Instance),
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	scopes.CI.Infof("=== BEGIN: External site Deployment ===")
+	defer func() {
+		if err != nil {
+			scopes.CI.Infof("=== FAILED: External site Deployment ===")
+		} else {
+			scopes.CI.Infof("=== SUCCEEDED: External site Deployment ===")
+		}
+	}()
+
+	modes := cfg.Modes
+	if len(modes) == 0 {
+		modes = AllModes
+	}
+
+	if c.namespace == nil {
+		// Namespaces are created with sidecar injection disabled, so the hosts are outside of the mesh.
+		if c.namespace, err = namespace.New(ctx, namespace.Config{
+			Prefix: "external",
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	hosts := make(map[CertMode]string, len(modes))
+	for _, mode := range modes {
+		hosts[mode] = c.Host(mode)
+	}
+	var certs map[CertMode]serverCert
+	if c.rootCert, certs, err = generateCerts(hosts); err != nil {
+		return nil, err
+	}
+
+	builder, err := echoboot.NewBuilder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]echo.Instance, len(modes))
+	for i, mode := range modes {
+		builder = builder.With(&instances[i], echo.Config{
+			Service:   serviceName(mode),
+			Namespace: c.namespace,
+			Naked:     true,
+			Ports: []echo.Port{
+				{
+					Name:         "https",
+					Protocol:     protocol.HTTPS,
+					ServicePort:  servicePort,
+					InstancePort: instancePort,
+					TLS:          true,
+				},
+			},
+			TLSSettings: &echo.TLSSettings{
+				Cert: string(certs[mode].cert),
+				Key:  string(certs[mode].key),
+			},
+		})
+	}
+	if err = builder.Build(); err != nil {
+		return nil, err
+	}
+	for i, mode := range modes {
+		c.echos[mode] = instances[i]
+	}
+	return c, nil
+}
+
+func serviceName(mode CertMode) string {
+	return fmt.Sprintf("external-%s", mode)
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Host(mode CertMode) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", serviceName(mode), c.namespace.Name())
+}
+
+func (c *kubeComponent) Port() int {
+	return servicePort
+}
+
+func (c *kubeComponent) RootCert() []byte {
+	return c.rootCert
+}
+
+func (c *kubeComponent) Echo(mode CertMode) echo.Instance {
+	return c.echos[mode]
+}
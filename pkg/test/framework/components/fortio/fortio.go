@@ -0,0 +1,201 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fortio generates load with fortio, for measuring the latency and throughput of the mesh in
+// integration tests.
+package fortio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	defaultQPS         = 100
+	defaultConnections = 8
+	defaultDuration    = 10 * time.Second
+)
+
+var defaultPercentiles = []float64{50, 90, 99}
+
+// Config for the load generator.
+type Config struct {
+	// Namespace to deploy the load generator to. The load generator runs with a sidecar if the namespace has
+	// injection enabled. If not set, a new namespace with injection is created. Only used in the Kubernetes
+	// environment.
+	Namespace namespace.Instance
+}
+
+// LoadOptions for a run of the load generator.
+type LoadOptions struct {
+	// URL to send the requests to, for example http://b.apps:80/ or the address of a gateway.
+	URL string
+
+	// QPS is the total number of requests per second over all connections. Negative values send the
+	// requests as fast as possible. Defaults to 100.
+	QPS float64
+
+	// Connections is the number of parallel connections. Defaults to 8.
+	Connections int
+
+	// Duration of the run. Defaults to 10 seconds.
+	Duration time.Duration
+
+	// Headers added to all requests.
+	Headers map[string]string
+
+	// Percentiles of the latency reported in the Result. Defaults to 50, 90 and 99.
+	Percentiles []float64
+}
+
+func (o *LoadOptions) fillDefaults() error {
+	if o.URL == "" {
+		return fmt.Errorf("fortio: URL is required")
+	}
+	if o.QPS == 0 {
+		o.QPS = defaultQPS
+	}
+	if o.Connections == 0 {
+		o.Connections = defaultConnections
+	}
+	if o.Duration == 0 {
+		o.Duration = defaultDuration
+	}
+	if len(o.Percentiles) == 0 {
+		o.Percentiles = defaultPercentiles
+	}
+	return nil
+}
+
+// Histogram of the request latencies.
+type Histogram struct {
+	Count  int64
+	Min    time.Duration
+	Max    time.Duration
+	Avg    time.Duration
+	StdDev time.Duration
+	// Percentiles of the latency, by percentile.
+	Percentiles map[float64]time.Duration
+}
+
+// Result of a run of the load generator.
+type Result struct {
+	// ActualQPS achieved over all connections.
+	ActualQPS float64
+	// ActualDuration of the run.
+	ActualDuration time.Duration
+	// Connections used.
+	Connections int
+	// ResponseCodes counts the responses by HTTP status code. Requests that failed without a response are
+	// counted with code -1.
+	ResponseCodes map[int]int64
+	// Latency of the requests.
+	Latency Histogram
+}
+
+// Errors returns the number of requests that did not receive a 200 response.
+func (r Result) Errors() int64 {
+	var errors int64
+	for code, count := range r.ResponseCodes {
+		if code != 200 {
+			errors += count
+		}
+	}
+	return errors
+}
+
+// String implements fmt.Stringer
+func (r Result) String() string {
+	percentiles := make([]float64, 0, len(r.Latency.Percentiles))
+	for p := range r.Latency.Percentiles {
+		percentiles = append(percentiles, p)
+	}
+	sort.Float64s(percentiles)
+	out := make([]string, 0, len(percentiles))
+	for _, p := range percentiles {
+		out = append(out, fmt.Sprintf("p%v=%v", p, r.Latency.Percentiles[p]))
+	}
+	return fmt.Sprintf("qps=%.1f connections=%d requests=%d codes=%v avg=%v %s",
+		r.ActualQPS, r.Connections, r.Latency.Count, r.ResponseCodes, r.Latency.Avg, strings.Join(out, " "))
+}
+
+func toResult(res *fhttp.HTTPRunnerResults) Result {
+	r := Result{
+		ActualQPS:      res.ActualQPS,
+		ActualDuration: res.ActualDuration,
+		Connections:    res.NumThreads,
+		ResponseCodes:  make(map[int]int64, len(res.RetCodes)),
+		Latency: Histogram{
+			Percentiles: make(map[float64]time.Duration),
+		},
+	}
+	for code, count := range res.RetCodes {
+		r.ResponseCodes[code] = count
+	}
+	if h := res.DurationHistogram; h != nil {
+		r.Latency.Count = h.Count
+		r.Latency.Min = seconds(h.Min)
+		r.Latency.Max = seconds(h.Max)
+		r.Latency.Avg = seconds(h.Avg)
+		r.Latency.StdDev = seconds(h.StdDev)
+		for _, p := range h.Percentiles {
+			r.Latency.Percentiles[p.Percentile] = seconds(p.Value)
+		}
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Instance of the load generator.
+type Instance interface {
+	resource.Resource
+
+	// Load sends requests with the given options and returns the result, once the run is complete.
+	Load(opts LoadOptions) (Result, error)
+	LoadOrFail(t test.Failer, opts LoadOptions) Result
+}
+
+// New returns a new instance of the load generator.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Native, func() {
+		i, err = newNative(ctx)
+	})
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("fortio.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fortio
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+)
+
+const fortioJSON = `{
+  "RunType": "HTTP",
+  "ActualQPS": 99.5,
+  "ActualDuration": 10000000000,
+  "NumThreads": 4,
+  "DurationHistogram": {
+    "Count": 995,
+    "Min": 0.001,
+    "Max": 0.02,
+    "Avg": 0.0025,
+    "StdDev": 0.0005,
+    "Percentiles": [
+      {"Percentile": 50, "Value": 0.002},
+      {"Percentile": 99, "Value": 0.015}
+    ]
+  },
+  "RetCodes": {"200": 990, "403": 5}
+}`
+
+func TestToResult(t *testing.T) {
+	res := &fhttp.HTTPRunnerResults{}
+	if err := json.Unmarshal([]byte(fortioJSON), res); err != nil {
+		t.Fatal(err)
+	}
+	r := toResult(res)
+
+	if r.ActualQPS != 99.5 || r.Connections != 4 || r.ActualDuration != 10*time.Second {
+		t.Fatalf("unexpected result: %s", r)
+	}
+	if r.Latency.Count != 995 || r.Latency.Avg != 2500*time.Microsecond {
+		t.Fatalf("unexpected latency: %+v", r.Latency)
+	}
+	if got := r.Latency.Percentiles[99]; got != 15*time.Millisecond {
+		t.Fatalf("p99: expected 15ms, got %v", got)
+	}
+	if got := r.Errors(); got != 5 {
+		t.Fatalf("errors: expected 5, got %d", got)
+	}
+}
+
+func TestFillDefaults(t *testing.T) {
+	if err := (&LoadOptions{}).fillDefaults(); err == nil {
+		t.Fatal("expected an error without URL")
+	}
+	opts := LoadOptions{URL: "http://b:80"}
+	if err := opts.fillDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if opts.QPS != defaultQPS || opts.Connections != defaultConnections || opts.Duration != defaultDuration ||
+		len(opts.Percentiles) != len(defaultPercentiles) {
+		t.Fatalf("unexpected defaults: %+v", opts)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fortio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"fortio.org/fortio/fhttp"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/deployment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/tmpl"
+
+	kubeCore "k8s.io/api/core/v1"
+)
+
+const (
+	appName       = "fortio"
+	containerName = "fortio"
+
+	template = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{.app}}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: {{.app}}
+  template:
+    metadata:
+      labels:
+        app: {{.app}}
+    spec:
+      containers:
+      - name: {{.container}}
+        image: {{.image}}
+        args:
+        - server
+        ports:
+        - containerPort: 8080
+          name: http-fortio
+---
+`
+	// image of fortio, matching the release used by the samples.
+	image = "docker.io/fortio/fortio:latest_release"
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+// kubeComponent runs fortio in a pod, so that the load passes through the sidecars like the traffic of
+// the other workloads in the mesh.
+type kubeComponent struct {
+	id resource.ID
+
+	env        *kube.Environment
+	namespace  namespace.Instance
+	pod        kubeCore.Pod
+	deployment *deployment.Instance
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	env := ctx.Environment().(*kube.Environment)
+	c := &kubeComponent{
+		env:       env,
+		namespace: cfg.Namespace,
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	scopes.CI.Infof("=== BEGIN: Fortio Deployment ===")
+	defer func() {
+		if err != nil {
+			scopes.CI.Infof("=== FAILED: Fortio Deployment ===")
+			_ = c.Close()
+		} else {
+			scopes.CI.Infof("=== SUCCEEDED: Fortio Deployment ===")
+		}
+	}()
+
+	if c.namespace == nil {
+		if c.namespace, err = namespace.New(ctx, namespace.Config{
+			Prefix: "fortio",
+			Inject: true,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	yamlContent, err := tmpl.Evaluate(template, map[string]interface{}{
+		"app":       appName,
+		"container": containerName,
+		"image":     image,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.deployment = deployment.NewYamlContentDeployment(c.namespace.Name(), yamlContent)
+	if err = c.deployment.Deploy(env.Accessor, false); err != nil {
+		return nil, err
+	}
+
+	fetchFn := env.NewSinglePodFetch(c.namespace.Name(), "app="+appName)
+	pods, err := env.WaitUntilPodsAreReady(fetchFn)
+	if err != nil {
+		return nil, err
+	}
+	c.pod = pods[0]
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Load(opts LoadOptions) (Result, error) {
+	if err := opts.fillDefaults(); err != nil {
+		return Result{}, err
+	}
+
+	percentiles := make([]string, 0, len(opts.Percentiles))
+	for _, p := range opts.Percentiles {
+		percentiles = append(percentiles, fmt.Sprintf("%v", p))
+	}
+	args := []string{
+		"fortio", "load", "-quiet", "-json", "-",
+		"-qps", fmt.Sprintf("%v", opts.QPS),
+		"-c", fmt.Sprintf("%d", opts.Connections),
+		"-t", opts.Duration.String(),
+		"-p", strings.Join(percentiles, ","),
+	}
+	for k, v := range opts.Headers {
+		args = append(args, "-H", fmt.Sprintf("%q", fmt.Sprintf("%s: %s", k, v)))
+	}
+	args = append(args, opts.URL)
+
+	command := strings.Join(args, " ")
+	scopes.Framework.Debugf("Running load: %s", command)
+	out, err := c.env.Exec(c.pod.Namespace, c.pod.Name, containerName, command)
+	if err != nil {
+		return Result{}, fmt.Errorf("fortio load failed: %v. Output:\n%s", err, out)
+	}
+
+	res := &fhttp.HTTPRunnerResults{}
+	if err := json.Unmarshal([]byte(out), res); err != nil {
+		return Result{}, fmt.Errorf("unable to parse fortio result: %v. Output:\n%s", err, out)
+	}
+	return toResult(res), nil
+}
+
+func (c *kubeComponent) LoadOrFail(t test.Failer, opts LoadOptions) Result {
+	t.Helper()
+	r, err := c.Load(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func (c *kubeComponent) Close() (err error) {
+	if c.deployment != nil {
+		err = c.deployment.Delete(c.env.Accessor, false)
+		c.deployment = nil
+	}
+	return
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fortio
+
+import (
+	"fmt"
+	"os"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+var _ Instance = &nativeComponent{}
+
+// nativeComponent runs fortio in the test process.
+type nativeComponent struct {
+	id resource.ID
+}
+
+func newNative(ctx resource.Context) (Instance, error) {
+	c := &nativeComponent{}
+	c.id = ctx.TrackResource(c)
+	return c, nil
+}
+
+func (c *nativeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *nativeComponent) Load(opts LoadOptions) (Result, error) {
+	if err := opts.fillDefaults(); err != nil {
+		return Result{}, err
+	}
+
+	runnerOpts := fhttp.HTTPRunnerOptions{
+		RunnerOptions: periodic.RunnerOptions{
+			QPS:         opts.QPS,
+			Duration:    opts.Duration,
+			NumThreads:  opts.Connections,
+			Percentiles: opts.Percentiles,
+			Out:         os.Stderr,
+		},
+		HTTPOptions: fhttp.HTTPOptions{
+			URL: opts.URL,
+		},
+	}
+	for k, v := range opts.Headers {
+		if err := runnerOpts.HTTPOptions.AddAndValidateExtraHeader(fmt.Sprintf("%s: %s", k, v)); err != nil {
+			return Result{}, err
+		}
+	}
+
+	res, err := fhttp.RunHTTPTest(&runnerOpts)
+	if err != nil {
+		return Result{}, err
+	}
+	return toResult(res), nil
+}
+
+func (c *nativeComponent) LoadOrFail(t test.Failer, opts LoadOptions) Result {
+	t.Helper()
+	r, err := c.Load(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 
 	"istio.io/istio/pkg/test"
@@ -64,6 +65,9 @@ func newKube(ctx resource.Context, cfg Config) (Instance, error) {
 	}
 	ns := c.ConfigNamespace
 
+	// The config of an existing installation outlives the tests, so remove what they applied.
+	n.clearOnClose = !c.DeployIstio
+
 	fetchFn := n.environment.NewSinglePodFetch(ns, "istio=galley")
 	pods, err := n.environment.WaitUntilPodsAreReady(fetchFn)
 	if err != nil {
@@ -110,6 +114,9 @@ type kubeComponent struct {
 
 	cache     *yml.Cache
 	forwarder kube2.PortForwarder
+
+	// clearOnClose removes the config applied through the component when it is closed.
+	clearOnClose bool
 }
 
 var _ Instance = &kubeComponent{}
@@ -143,7 +150,10 @@ func (c *kubeComponent) ApplyConfig(ns namespace.Instance, yamlText ...string) e
 		nsName = ns.Name()
 	}
 
+	// Apply all of the resources with a single kubectl command, which is much faster than one command per
+	// resource.
 	var err error
+	var files []string
 	for _, y := range yamlText {
 		if nsName != "" {
 			if y, err = yml.ApplyNamespace(y, nsName); err != nil {
@@ -155,15 +165,18 @@ func (c *kubeComponent) ApplyConfig(ns namespace.Instance, yamlText ...string) e
 		if err != nil {
 			return err
 		}
-
 		for _, k := range keys {
-			if err = c.environment.Accessor.Apply(nsName, c.cache.GetFileFor(k)); err != nil {
-				return err
-			}
+			files = append(files, c.cache.GetFileFor(k))
 		}
-		scopes.Framework.Debugf("Applied config: ns: %s\n%s\n", nsName, y)
+	}
+	if len(files) == 0 {
+		return nil
 	}
 
+	if err = c.environment.Accessor.ApplyFiles(nsName, files...); err != nil {
+		return err
+	}
+	scopes.Framework.Debugf("Applied config: ns: %s\n%s\n", nsName, yml.JoinString(yamlText...))
 	return nil
 }
 
@@ -251,9 +264,14 @@ func (c *kubeComponent) WaitForSnapshotOrFail(t test.Failer, collection string,
 
 // Close implements io.Closer.
 func (c *kubeComponent) Close() (err error) {
+	if c.clearOnClose {
+		scopes.Framework.Debugf("%s clearing config", c.id)
+		err = c.ClearConfig()
+	}
+
 	if c.client != nil {
 		scopes.Framework.Debugf("%s closing client", c.id)
-		err = c.client.Close()
+		err = multierror.Append(err, c.client.Close()).ErrorOrNil()
 		c.client = nil
 	}
 
@@ -0,0 +1,82 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatewaysecret
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/ingress"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Credential of a gateway, stored in a Kubernetes secret and served over SDS.
+type Credential struct {
+	// Cert is the PEM encoded server certificate chain.
+	Cert []byte
+	// Key is the PEM encoded private key of the server certificate.
+	Key []byte
+	// CACert is the PEM encoded CA certificate used for verifying client certificates. If set, the credential is
+	// stored in a generic secret for MUTUAL gateways, otherwise in a TLS secret for SIMPLE gateways.
+	CACert []byte
+}
+
+// Config for the gateway secret component.
+type Config struct {
+	Istio istio.Instance
+
+	// Ingress gateway used for probing the served certificates.
+	Ingress ingress.Instance
+}
+
+// Instance manages the Kubernetes secrets of the gateway credentials, and waits until the ingress gateway serves
+// the changes. Secrets created through the instance are deleted when it is closed.
+type Instance interface {
+	resource.Resource
+
+	// Set creates or updates the secret of the given credential name, and waits until the ingress gateway serves
+	// the new certificate for the given host (SNI).
+	Set(credentialName, host string, cred Credential) error
+	SetOrFail(t test.Failer, credentialName, host string, cred Credential)
+
+	// Delete the secret of the given credential name, and waits until the ingress gateway no longer serves its
+	// certificate for the given host (SNI).
+	Delete(credentialName, host string) error
+	DeleteOrFail(t test.Failer, credentialName, host string)
+
+	// WaitForCertificate waits until the ingress gateway serves the given PEM encoded certificate for the given
+	// host (SNI). Only the leaf certificate is compared.
+	WaitForCertificate(host string, cert []byte) error
+	WaitForCertificateOrFail(t test.Failer, host string, cert []byte)
+}
+
+// New returns a new instance of the gateway secret component.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("gatewaysecret.NewOrFail: %v", err)
+	}
+	return i
+}
@@ -0,0 +1,251 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gatewaysecret
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/ingress"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	// Keys of the TLS secrets of SIMPLE gateways.
+	tlsSecretCert = "tls.crt"
+	tlsSecretKey  = "tls.key"
+	// Keys of the generic secrets of MUTUAL gateways.
+	genericSecretCert   = "cert"
+	genericSecretKey    = "key"
+	genericSecretCACert = "cacert"
+
+	dialTimeout = 5 * time.Second
+)
+
+var (
+	retryTimeout = retry.Timeout(2 * time.Minute)
+	retryDelay   = retry.Delay(time.Second)
+
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id resource.ID
+
+	env       *kube.Environment
+	namespace string
+	ingress   ingress.Instance
+
+	// created are the names of the secrets created by this component.
+	created map[string]struct{}
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	if cfg.Istio == nil || cfg.Ingress == nil {
+		return nil, fmt.Errorf("gatewaysecret: Istio and Ingress must be set")
+	}
+	c := &kubeComponent{
+		env:       ctx.Environment().(*kube.Environment),
+		namespace: cfg.Istio.Settings().IngressNamespace,
+		ingress:   cfg.Ingress,
+		created:   make(map[string]struct{}),
+	}
+	c.id = ctx.TrackResource(c)
+	return c, nil
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Set(credentialName, host string, cred Credential) error {
+	secret := newSecret(credentialName, c.namespace, cred)
+	secrets := c.env.GetSecret(c.namespace)
+	if _, err := secrets.Get(credentialName, kubeApiMeta.GetOptions{}); err == nil {
+		if _, err := secrets.Update(secret); err != nil {
+			return fmt.Errorf("failed updating secret %s/%s: %v", c.namespace, credentialName, err)
+		}
+	} else if errors.IsNotFound(err) {
+		if _, err := secrets.Create(secret); err != nil {
+			return fmt.Errorf("failed creating secret %s/%s: %v", c.namespace, credentialName, err)
+		}
+		c.created[credentialName] = struct{}{}
+	} else {
+		return err
+	}
+	scopes.Framework.Infof("Gateway secret %s/%s set, waiting for it to be served for %s",
+		c.namespace, credentialName, host)
+	return c.WaitForCertificate(host, cred.Cert)
+}
+
+func (c *kubeComponent) SetOrFail(t test.Failer, credentialName, host string, cred Credential) {
+	t.Helper()
+	if err := c.Set(credentialName, host, cred); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *kubeComponent) Delete(credentialName, host string) error {
+	secret, err := c.env.GetSecret(c.namespace).Get(credentialName, kubeApiMeta.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := c.env.DeleteSecret(c.namespace, credentialName); err != nil {
+		return err
+	}
+	delete(c.created, credentialName)
+
+	leaf, err := leafCertificate(secretCert(secret))
+	if err != nil {
+		return err
+	}
+	_, err = retry.Do(func() (interface{}, bool, error) {
+		served, err := c.probe(host)
+		if err != nil {
+			// The gateway may reject the handshake once the credential is gone.
+			return nil, true, nil
+		}
+		if bytes.Equal(served, leaf) {
+			return nil, false, fmt.Errorf("ingress gateway still serves the certificate of %s for %s",
+				credentialName, host)
+		}
+		return nil, true, nil
+	}, retryTimeout, retryDelay)
+	return err
+}
+
+func (c *kubeComponent) DeleteOrFail(t test.Failer, credentialName, host string) {
+	t.Helper()
+	if err := c.Delete(credentialName, host); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *kubeComponent) WaitForCertificate(host string, cert []byte) error {
+	leaf, err := leafCertificate(cert)
+	if err != nil {
+		return err
+	}
+	_, err = retry.Do(func() (interface{}, bool, error) {
+		served, err := c.probe(host)
+		if err != nil {
+			return nil, false, err
+		}
+		if !bytes.Equal(served, leaf) {
+			return nil, false, fmt.Errorf("ingress gateway doesn't serve the expected certificate for %s yet", host)
+		}
+		return nil, true, nil
+	}, retryTimeout, retryDelay)
+	return err
+}
+
+func (c *kubeComponent) WaitForCertificateOrFail(t test.Failer, host string, cert []byte) {
+	t.Helper()
+	if err := c.WaitForCertificate(host, cert); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// probe performs a TLS handshake with the ingress gateway for the given SNI, and returns the DER encoded leaf
+// certificate it presented. The handshake may fail after the certificate was received, e.g. when a MUTUAL
+// gateway requires a client certificate; only the server certificate matters here.
+func (c *kubeComponent) probe(host string) ([]byte, error) {
+	var served []byte
+	address := c.ingress.HTTPSAddress()
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", address.String(), &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) > 0 {
+				served = rawCerts[0]
+			}
+			return nil
+		},
+	})
+	if conn != nil {
+		_ = conn.Close()
+	}
+	if served == nil {
+		if err == nil {
+			err = fmt.Errorf("no certificate presented")
+		}
+		return nil, fmt.Errorf("TLS handshake with ingress gateway %s for %s failed: %v", address.String(), host, err)
+	}
+	return served, nil
+}
+
+func (c *kubeComponent) Close() (err error) {
+	for name := range c.created {
+		if e := c.env.DeleteSecret(c.namespace, name); e != nil && !errors.IsNotFound(e) {
+			err = e
+		}
+	}
+	c.created = make(map[string]struct{})
+	return
+}
+
+func newSecret(name, namespace string, cred Credential) *kubeApiCore.Secret {
+	secret := &kubeApiCore.Secret{
+		ObjectMeta: kubeApiMeta.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	if len(cred.CACert) > 0 {
+		secret.Data = map[string][]byte{
+			genericSecretCert:   cred.Cert,
+			genericSecretKey:    cred.Key,
+			genericSecretCACert: cred.CACert,
+		}
+		return secret
+	}
+	secret.Type = kubeApiCore.SecretTypeTLS
+	secret.Data = map[string][]byte{
+		tlsSecretCert: cred.Cert,
+		tlsSecretKey:  cred.Key,
+	}
+	return secret
+}
+
+func secretCert(secret *kubeApiCore.Secret) []byte {
+	if cert, ok := secret.Data[tlsSecretCert]; ok {
+		return cert
+	}
+	return secret.Data[genericSecretCert]
+}
+
+// leafCertificate returns the DER encoded first certificate of the given PEM data.
+func leafCertificate(data []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("invalid PEM encoded certificate")
+	}
+	return block.Bytes, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpfilter provides a component that inserts custom HTTP filters, e.g. Lua filters
+// implementing authorization or telemetry extensions, into the sidecars of echo instances.
+package httpfilter
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+const (
+	// LuaFilterName is the name of the Envoy Lua HTTP filter.
+	LuaFilterName = "envoy.lua"
+	// RouterFilterName is the name of the last HTTP filter of every connection manager.
+	RouterFilterName = "envoy.router"
+)
+
+// Config for the HTTP filter component.
+type Config struct {
+	// Config is used to apply the EnvoyFilters that insert the filter.
+	Config config.Instance
+
+	// Name of the HTTP filter, e.g. LuaFilterName.
+	Name string
+
+	// FilterConfig is the configuration of the filter, e.g. as returned by Lua.
+	FilterConfig map[string]interface{}
+
+	// Before is the name of the HTTP filter that the filter is inserted before. Defaults to
+	// RouterFilterName. For authorization extensions, this can be the RBAC filter, so that the filter
+	// runs ahead of the Istio authorization policies.
+	Before string
+
+	// Inbound and Outbound select the listeners the filter is inserted into. If neither is set, the filter
+	// is only inserted into the inbound listeners.
+	Inbound  bool
+	Outbound bool
+
+	// Instances whose sidecars the filter is inserted into.
+	Instances []echo.Instance
+}
+
+// Lua returns the configuration of a Lua filter running the given code.
+func Lua(code string) map[string]interface{} {
+	return map[string]interface{}{
+		"inlineCode": code,
+	}
+}
+
+// Instance of the HTTP filter component. The filter is removed again when the instance is closed.
+type Instance interface {
+	resource.Resource
+}
+
+// New inserts the filter into the sidecars of all instances, and waits until it was loaded by all of them.
+func New(ctx resource.Context, cfg Config) (Instance, error) {
+	return newHTTPFilter(ctx, cfg)
+}
+
+// NewOrFail calls New and fails t if an error occurs.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("httpfilter.NewOrFail: %v", err)
+	}
+	return i
+}
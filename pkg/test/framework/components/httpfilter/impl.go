@@ -0,0 +1,197 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpfilter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
+	"github.com/ghodss/yaml"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-multierror"
+
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+var (
+	_ Instance  = &httpFilterImpl{}
+	_ io.Closer = &httpFilterImpl{}
+)
+
+type httpFilterImpl struct {
+	id  resource.ID
+	cfg Config
+
+	mutex sync.Mutex
+	// applied EnvoyFilter for each of the instances.
+	applied map[echo.Instance]string
+}
+
+func newHTTPFilter(ctx resource.Context, cfg Config) (Instance, error) {
+	if cfg.Config == nil {
+		return nil, fmt.Errorf("httpfilter: Config is required")
+	}
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("httpfilter: Name is required")
+	}
+	if cfg.Before == "" {
+		cfg.Before = RouterFilterName
+	}
+	if !cfg.Inbound && !cfg.Outbound {
+		cfg.Inbound = true
+	}
+
+	c := &httpFilterImpl{
+		cfg:     cfg,
+		applied: make(map[echo.Instance]string),
+	}
+	c.id = ctx.TrackResource(c)
+
+	for _, i := range cfg.Instances {
+		if err := c.insert(i); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *httpFilterImpl) ID() resource.ID {
+	return c.id
+}
+
+// envoyFilter returns the EnvoyFilter inserting the filter into the sidecars of the given instance.
+func (c *httpFilterImpl) envoyFilter(i echo.Instance) (string, error) {
+	var contexts []string
+	if c.cfg.Inbound {
+		contexts = append(contexts, "SIDECAR_INBOUND")
+	}
+	if c.cfg.Outbound {
+		contexts = append(contexts, "SIDECAR_OUTBOUND")
+	}
+
+	patches := make([]interface{}, 0, len(contexts))
+	for _, listenerContext := range contexts {
+		patches = append(patches, map[string]interface{}{
+			"applyTo": "HTTP_FILTER",
+			"match": map[string]interface{}{
+				"context": listenerContext,
+				"listener": map[string]interface{}{
+					"filterChain": map[string]interface{}{
+						"filter": map[string]interface{}{
+							"name": "envoy.http_connection_manager",
+							"subFilter": map[string]interface{}{
+								"name": c.cfg.Before,
+							},
+						},
+					},
+				},
+			},
+			"patch": map[string]interface{}{
+				"operation": "INSERT_BEFORE",
+				"value": map[string]interface{}{
+					"name":   c.cfg.Name,
+					"config": c.cfg.FilterConfig,
+				},
+			},
+		})
+	}
+
+	service := i.Config().Service
+	out, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       "EnvoyFilter",
+		"metadata": map[string]string{
+			"name": fmt.Sprintf("httpfilter-%s-%s", service, strings.Replace(c.cfg.Name, ".", "-", -1)),
+		},
+		"spec": map[string]interface{}{
+			"workloadSelector": map[string]interface{}{
+				"labels": map[string]string{
+					"app": service,
+				},
+			},
+			"configPatches": patches,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (c *httpFilterImpl) insert(i echo.Instance) error {
+	filter, err := c.envoyFilter(i)
+	if err != nil {
+		return err
+	}
+
+	if err := c.cfg.Config.Apply(i.Config().Namespace, filter); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	c.applied[i] = filter
+	c.mutex.Unlock()
+
+	// Wait for the filter to show up in the listeners of all sidecars of the instance.
+	workloads, err := i.Workloads()
+	if err != nil {
+		return err
+	}
+	for _, w := range workloads {
+		s := w.Sidecar()
+		if s == nil {
+			return fmt.Errorf("httpfilter: workload %s of %s has no sidecar", w.Name(), i.Config().Service)
+		}
+		if err := s.WaitForConfig(func(cfg *envoyAdmin.ConfigDump) (bool, error) {
+			if !containsFilter(cfg, c.cfg.Name) {
+				return false, fmt.Errorf("filter %s not loaded yet by %s", c.cfg.Name, s.NodeID())
+			}
+			return true, nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsFilter(cfg *envoyAdmin.ConfigDump, name string) bool {
+	for _, c := range cfg.Configs {
+		if c.TypeUrl != "type.googleapis.com/envoy.admin.v2alpha.ListenersConfigDump" {
+			continue
+		}
+		listeners := &envoyAdmin.ListenersConfigDump{}
+		if err := ptypes.UnmarshalAny(c, listeners); err != nil {
+			return false
+		}
+		return strings.Contains(proto.MarshalTextString(listeners), fmt.Sprintf("%q", name))
+	}
+	return false
+}
+
+func (c *httpFilterImpl) Close() (err error) {
+	c.mutex.Lock()
+	applied := c.applied
+	c.applied = make(map[echo.Instance]string)
+	c.mutex.Unlock()
+
+	for i, filter := range applied {
+		err = multierror.Append(err, c.cfg.Config.Delete(i.Config().Namespace, filter)).ErrorOrNil()
+	}
+	return
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package imageprepull provides a component that pulls the test images on all nodes of the cluster before the
+// tests start, so that the first deployments of a suite don't fail on the latency of pulling the images.
+package imageprepull
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Config for pulling the images.
+type Config struct {
+	// Images to pull. Defaults to the echo app, the mock VM app and the proxy images of the hub and tag of the
+	// test settings. The images must provide sh.
+	Images []string
+}
+
+// Instance represents the images pulled on all nodes of the cluster.
+type Instance interface {
+	resource.Resource
+
+	// Images returns the pulled images.
+	Images() []string
+
+	// Pinned returns the reference by digest of the given image, as pulled on the nodes, so that all
+	// deployments of the suite run the same image even if its tag is moved while the suite runs. If the
+	// digest of the image is not known, the image is returned unchanged.
+	Pinned(image string) string
+}
+
+// New pulls the images on all nodes and waits until all of them are pulled.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("imageprepull.NewOrFail: %v", err)
+	}
+	return i
+}
+
+// Setup is a setup function that pulls the images of the given config on all nodes. It does nothing outside
+// of the Kubernetes environment.
+func Setup(i *Instance, cfg Config) resource.SetupFn {
+	return func(ctx resource.Context) error {
+		if ctx.Environment().EnvironmentName() != environment.Kube {
+			return nil
+		}
+		ins, err := New(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		if i != nil {
+			*i = ins
+		}
+		return nil
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imageprepull
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test/deployment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/core/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+
+	kubeApiCore "k8s.io/api/core/v1"
+)
+
+const (
+	appName = "image-prepull"
+
+	// pullTimeout is how long to wait for the images to be pulled on all nodes.
+	pullTimeout = 15 * time.Minute
+
+	// digestSeparator separates the repository from the digest in image references.
+	digestSeparator = "@sha256:"
+
+	template = `
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: {{.app}}
+spec:
+  selector:
+    matchLabels:
+      app: {{.app}}
+  template:
+    metadata:
+      labels:
+        app: {{.app}}
+      annotations:
+        sidecar.istio.io/inject: "false"
+    spec:
+      tolerations:
+      - operator: Exists
+      initContainers:
+{{- range $i, $image := .images }}
+      - name: image-{{ $i }}
+        image: "{{ $image }}"
+        imagePullPolicy: {{ $.ImagePullPolicy }}
+        command: ["sh", "-c", "exit 0"]
+{{- end }}
+      containers:
+      - name: pause
+        image: k8s.gcr.io/pause:3.1
+`
+)
+
+var (
+	_ Instance  = &kubeComponent{}
+	_ io.Closer = &kubeComponent{}
+)
+
+type kubeComponent struct {
+	id resource.ID
+
+	namespace  namespace.Instance
+	images     []string
+	pinned     map[string]string
+	deployment *deployment.Instance
+}
+
+func newKube(ctx resource.Context, cfg Config) (Instance, error) {
+	env := ctx.Environment().(*kube.Environment)
+	c := &kubeComponent{
+		pinned: make(map[string]string),
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	scopes.CI.Infof("=== BEGIN: Image pre-pull ===")
+	defer func() {
+		if err != nil {
+			scopes.CI.Infof("=== FAILED: Image pre-pull ===")
+			_ = c.Close()
+		} else {
+			scopes.CI.Infof("=== SUCCEEDED: Image pre-pull ===")
+		}
+	}()
+
+	s, err := image.SettingsFromCommandLine()
+	if err != nil {
+		return nil, err
+	}
+	c.images = cfg.Images
+	if len(c.images) == 0 {
+		c.images = []string{
+			fmt.Sprintf("%s/app:%s", s.Hub, s.Tag),
+			fmt.Sprintf("%s/app_sidecar:%s", s.Hub, s.Tag),
+			fmt.Sprintf("%s/proxyv2:%s", s.Hub, s.Tag),
+		}
+	}
+
+	if c.namespace, err = namespace.New(ctx, namespace.Config{
+		Prefix: appName,
+	}); err != nil {
+		return nil, err
+	}
+
+	ns := c.namespace.Name()
+	yamlContent, err := tmpl.Evaluate(template, map[string]interface{}{
+		"ImagePullPolicy": s.PullPolicy,
+		"app":             appName,
+		"images":          c.images,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.deployment = deployment.NewYamlContentDeployment(ns, yamlContent)
+	if err = c.deployment.Deploy(env.Accessor, false); err != nil {
+		return nil, err
+	}
+
+	// The DaemonSet reports as ready before its pods are scheduled, wait for the pods first.
+	fetch := func() ([]kubeApiCore.Pod, error) {
+		pods, err := env.GetPods(ns, "app="+appName)
+		if err == nil && len(pods) == 0 {
+			err = fmt.Errorf("no pods of %s/%s scheduled", ns, appName)
+		}
+		return pods, err
+	}
+	if _, err = env.WaitUntilPodsAreReady(fetch, retry.Timeout(pullTimeout)); err != nil {
+		return nil, fmt.Errorf("failed pulling images %v: %v", c.images, err)
+	}
+	if err = env.WaitUntilDaemonSetIsReady(ns, appName, retry.Timeout(pullTimeout)); err != nil {
+		return nil, fmt.Errorf("failed pulling images %v: %v", c.images, err)
+	}
+
+	// Record the digests the images resolved to.
+	pods, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		for _, status := range pod.Status.InitContainerStatuses {
+			var i int
+			if _, e := fmt.Sscanf(status.Name, "image-%d", &i); e != nil || i >= len(c.images) {
+				continue
+			}
+			digest := status.ImageID
+			idx := strings.Index(digest, digestSeparator)
+			if idx < 0 {
+				continue
+			}
+			img := c.images[i]
+			pinned := repository(img) + digest[idx:]
+			if prev, ok := c.pinned[img]; ok && prev != pinned {
+				scopes.CI.Warnf("Image %s resolved to different digests on the nodes: %s and %s", img, prev, pinned)
+				continue
+			}
+			c.pinned[img] = pinned
+		}
+	}
+	for _, img := range c.images {
+		scopes.CI.Infof("Pre-pulled image %s: %s", img, c.Pinned(img))
+	}
+	return c, nil
+}
+
+// repository returns the given image reference without its tag or digest.
+func repository(img string) string {
+	if idx := strings.Index(img, "@"); idx >= 0 {
+		return img[:idx]
+	}
+	// A colon after the last slash separates the tag, others separate the port of the registry.
+	if idx := strings.LastIndex(img, ":"); idx > strings.LastIndex(img, "/") {
+		return img[:idx]
+	}
+	return img
+}
+
+func (c *kubeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *kubeComponent) Images() []string {
+	return c.images
+}
+
+func (c *kubeComponent) Pinned(img string) string {
+	if pinned, ok := c.pinned[img]; ok {
+		return pinned
+	}
+	return img
+}
+
+func (c *kubeComponent) Close() error {
+	// The images stay on the nodes, deleting the namespace removes the DaemonSet.
+	return nil
+}
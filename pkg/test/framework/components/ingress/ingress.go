@@ -15,6 +15,7 @@
 package ingress
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"strings"
@@ -61,6 +62,23 @@ type CallOptions struct {
 
 	// CallType specifies what type of call to make (PlainText, TLS, mTLS).
 	CallType CallType
+
+	// ServerName overrides the SNI sent in the TLS handshake, and the name the server certificate is verified
+	// against. Defaults to Host.
+	ServerName string
+
+	// MinTLSVersion and MaxTLSVersion bound the TLS version negotiated with the gateway (e.g. tls.VersionTLS12).
+	// Zero values use the defaults of crypto/tls.
+	MinTLSVersion uint16
+	MaxTLSVersion uint16
+
+	// ALPN protocols offered in the TLS handshake, in order of preference. The request itself is always sent
+	// over HTTP/1.1, so offering only "h2" is useful for asserting the negotiation rather than for the request.
+	ALPN []string
+
+	// InsecureSkipVerify disables the verification of the server certificate, e.g. for probing the certificate
+	// served by a PASSTHROUGH gateway. CaCert is not required if set.
+	InsecureSkipVerify bool
 }
 
 // sanitize checks and fills fields in CallOptions. Returns error on failures, and nil otherwise.
@@ -109,6 +127,9 @@ type CallResponse struct {
 
 	// Response body
 	Body string
+
+	// TLS is the state of the connection negotiated with the gateway. Nil for plain text calls.
+	TLS *tls.ConnectionState
 }
 
 // Deploy returns a new instance of echo.
@@ -214,14 +214,24 @@ func (c *kubeComponent) createClient(options CallOptions) (*http.Client, error)
 	}
 	if options.CallType != PlainText {
 		scopes.Framework.Debug("Prepare root cert for client")
-		roots := x509.NewCertPool()
-		ok := roots.AppendCertsFromPEM([]byte(options.CaCert))
-		if !ok {
-			return nil, fmt.Errorf("failed to parse root certificate")
+		serverName := options.ServerName
+		if serverName == "" {
+			serverName = options.Host
 		}
 		tlsConfig := &tls.Config{
-			RootCAs:    roots,
-			ServerName: options.Host,
+			ServerName:         serverName,
+			MinVersion:         options.MinTLSVersion,
+			MaxVersion:         options.MaxTLSVersion,
+			NextProtos:         options.ALPN,
+			InsecureSkipVerify: options.InsecureSkipVerify,
+		}
+		if options.CaCert != "" || !options.InsecureSkipVerify {
+			roots := x509.NewCertPool()
+			ok := roots.AppendCertsFromPEM([]byte(options.CaCert))
+			if !ok {
+				return nil, fmt.Errorf("failed to parse root certificate")
+			}
+			tlsConfig.RootCAs = roots
 		}
 		if options.CallType == Mtls {
 			cer, err := tls.X509KeyPair([]byte(options.Cert), []byte(options.PrivateKey))
@@ -306,6 +316,7 @@ func (c *kubeComponent) Call(options CallOptions) (CallResponse, error) {
 	response := CallResponse{
 		Code: status,
 		Body: contents,
+		TLS:  resp.TLS,
 	}
 
 	return response, nil
@@ -0,0 +1,112 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/core/image"
+	"istio.io/istio/pkg/test/helm"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+
+	kubeCore "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultCNINamespace is the namespace the Istio CNI plugin is installed to.
+	DefaultCNINamespace = "kube-system"
+
+	// CNINodeSelector selects the pods of the DaemonSet installing the Istio CNI plugin on the nodes.
+	CNINodeSelector = kube.CNINodeSelector
+
+	cniValuesKey = "istio_cni.enabled"
+)
+
+// CNI returns a SetupConfigFn that installs the Istio CNI plugin from the istio-cni Helm chart in the given
+// directory, instead of adding the istio-init container to the injected pods. If chartDir is empty, the chart
+// dir of the command line is used.
+func CNI(chartDir string) SetupConfigFn {
+	return func(cfg *Config) {
+		cfg.CNI = true
+		if chartDir != "" {
+			cfg.CNIChartDir = chartDir
+		}
+	}
+}
+
+// cniValues returns the values of the Config with the injection of the istio-init container disabled, if the
+// CNI plugin is used.
+func cniValues(cfg Config) map[string]string {
+	if !cfg.CNI {
+		return cfg.Values
+	}
+	values := make(map[string]string, len(cfg.Values)+1)
+	for k, v := range cfg.Values {
+		values[k] = v
+	}
+	values[cniValuesKey] = "true"
+	return values
+}
+
+// deployCNI installs the Istio CNI plugin, and waits until it runs on all of the nodes. It returns the file of
+// the installed resources.
+func deployCNI(env *kube.Environment, workDir, helmWorkDir string, cfg Config) (string, error) {
+	if cfg.CNIChartDir == "" {
+		return "", fmt.Errorf("installing the Istio CNI plugin requires the istio-cni Helm chart dir")
+	}
+	if err := normalizeFile(&cfg.CNIChartDir); err != nil {
+		return "", err
+	}
+
+	values := map[string]string{
+		"hub":        cfg.Values[image.HubValuesKey],
+		"tag":        cfg.Values[image.TagValuesKey],
+		"pullPolicy": cfg.Values[image.ImagePullPolicyValuesKey],
+		// The pods of the control plane are never injected.
+		"excludeNamespaces": fmt.Sprintf("{%s,%s}", cfg.SystemNamespace, cfg.CNINamespace),
+	}
+	cniYaml, err := helm.Template(helmWorkDir, cfg.CNIChartDir, "istio-cni", cfg.CNINamespace, "", values)
+	if err != nil {
+		return "", err
+	}
+
+	cniFile := path.Join(workDir, "istio-cni.yaml")
+	if err = ioutil.WriteFile(cniFile, []byte(cniYaml), os.ModePerm); err != nil {
+		return "", fmt.Errorf("unable to write %q: %v", cniFile, err)
+	}
+	scopes.CI.Infof("Installing the Istio CNI plugin with: %s", cniFile)
+
+	if err = env.Accessor.Apply(cfg.CNINamespace, cniFile); err != nil {
+		return "", err
+	}
+
+	// The DaemonSet reports as ready before its pods are scheduled, wait for the pods first.
+	fetch := func() ([]kubeCore.Pod, error) {
+		pods, err := env.GetPods(cfg.CNINamespace, CNINodeSelector)
+		if err == nil && len(pods) == 0 {
+			err = fmt.Errorf("no pods of the Istio CNI plugin scheduled in %s", cfg.CNINamespace)
+		}
+		return pods, err
+	}
+	if _, err = env.WaitUntilPodsAreReady(fetch, retry.Timeout(cfg.DeployTimeout)); err != nil {
+		return "", fmt.Errorf("failed installing the Istio CNI plugin: %v", err)
+	}
+	return cniFile, nil
+}
@@ -41,6 +41,9 @@ const (
 	// ValuesMcpFile for Istio Helm deployment.
 	E2EValuesFile = "test-values/values-e2e.yaml"
 
+	// RemoteValuesFile for configuring the remote clusters of a multicluster environment.
+	RemoteValuesFile = "values-istio-remote.yaml"
+
 	// DefaultDeployTimeout for Istio
 	DefaultDeployTimeout = time.Second * 300
 
@@ -60,7 +63,8 @@ const (
 )
 
 var (
-	helmValues string
+	helmValues       string
+	remoteHelmValues string
 
 	settingsFromCommandline = &Config{
 		ChartRepo:                      DefaultIstioChartRepo,
@@ -77,7 +81,9 @@ var (
 		ChartDir:                       env.IstioChartDir,
 		CrdsFilesDir:                   env.CrdsFilesDir,
 		ValuesFile:                     E2EValuesFile,
+		RemoteValuesFile:               RemoteValuesFile,
 		CustomSidecarInjectorNamespace: "",
+		CNINamespace:                   DefaultCNINamespace,
 	}
 )
 
@@ -124,6 +130,17 @@ type Config struct {
 	// Overrides for the Helm values file.
 	Values map[string]string
 
+	// ValuesOverlays are Helm values documents in YAML, merged in order over the values file. Unlike Values,
+	// they can hold structured settings, such as lists and maps. Values override them.
+	ValuesOverlays []string
+
+	// The Helm values file used for the remote clusters of a multicluster environment. The remote clusters run
+	// no control plane of their own, and are configured to use the one deployed to the primary cluster.
+	RemoteValuesFile string
+
+	// Overrides for the remote Helm values file.
+	RemoteValues map[string]string
+
 	// Indicates that the test should deploy Istio into the target Kubernetes cluster before running tests.
 	DeployIstio bool
 
@@ -134,6 +151,21 @@ type Config struct {
 	// CustomSidecarInjectorNamespace allows injecting the sidecar from the specified namespace.
 	// if the value is "", use the default sidecar injection instead.
 	CustomSidecarInjectorNamespace string
+
+	// Revision of the control plane. The sidecars of the namespaces labeled with istio.io/rev=<Revision> are
+	// injected by this control plane. If empty, the namespaces labeled with istio-injection=enabled are.
+	Revision string
+
+	// CNI indicates that the traffic of the injected pods is redirected to the sidecar by the Istio CNI
+	// plugin, rather than by the istio-init container, so that the pods don't require the NET_ADMIN
+	// capability. When deploying Istio, the plugin is installed from the CNIChartDir.
+	CNI bool
+
+	// CNIChartDir is the istio-cni Helm chart dir, for installing the CNI plugin.
+	CNIChartDir string
+
+	// CNINamespace is the namespace the CNI plugin is installed to (default: "kube-system").
+	CNINamespace string
 }
 
 // Is mtls enabled. Check in Values flag and Values file.
@@ -164,6 +196,79 @@ func (c *Config) IsMtlsEnabled() bool {
 	return false
 }
 
+// AddValuesOverlay adds the given Helm values to the ValuesOverlays of the Config. The values are either a
+// nested map or a struct with yaml tags, following the structure of the values file, e.g.
+// map[string]interface{}{"global": map[string]interface{}{"trustDomain": "example.com"}}.
+func (c *Config) AddValuesOverlay(values interface{}) error {
+	out, err := yaml2.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed marshaling Helm values overlay: %v", err)
+	}
+	c.ValuesOverlays = append(c.ValuesOverlays, string(out))
+	return nil
+}
+
+// AddValuesOverlayFile adds the Helm values of the given YAML file to the ValuesOverlays of the Config.
+func (c *Config) AddValuesOverlayFile(path string) error {
+	if err := normalizeFile(&path); err != nil {
+		return err
+	}
+	out, err := file.AsString(path)
+	if err != nil {
+		return err
+	}
+	c.ValuesOverlays = append(c.ValuesOverlays, out)
+	return nil
+}
+
+// ValuesOverlay returns a SetupConfigFn that adds the given Helm values YAML to the ValuesOverlays of the Config.
+func ValuesOverlay(valuesYAML string) SetupConfigFn {
+	return func(cfg *Config) {
+		cfg.ValuesOverlays = append(cfg.ValuesOverlays, valuesYAML)
+	}
+}
+
+// Revision returns a SetupConfigFn that deploys the given revision of the control plane to the namespace
+// istio-<revision>, next to the default control plane. The revision relies on the default control plane for
+// the validation of the configuration, the workload certificates and the gateways.
+func Revision(revision string) SetupConfigFn {
+	return func(cfg *Config) {
+		ns := "istio-" + revision
+		cfg.SystemNamespace = ns
+		cfg.IstioNamespace = ns
+		cfg.ConfigNamespace = ns
+		cfg.TelemetryNamespace = ns
+		cfg.PolicyNamespace = ns
+		cfg.Revision = revision
+		cfg.SkipWaitForValidationWebhook = true
+
+		values := make(map[string]string, len(cfg.Values))
+		for k, v := range cfg.Values {
+			values[k] = v
+		}
+		values["global.revision"] = revision
+		values["global.configValidation"] = "false"
+		values["security.enabled"] = "false"
+		values["gateways.enabled"] = "false"
+		cfg.Values = values
+	}
+}
+
+// WorkloadCertTTL returns a SetupConfigFn that makes Citadel issue workload certificates with the given TTL,
+// and rotate them at half of their lifetime, so that rotations can be observed within a test.
+func WorkloadCertTTL(ttl time.Duration) SetupConfigFn {
+	return func(cfg *Config) {
+		values := make(map[string]string, len(cfg.Values))
+		for k, v := range cfg.Values {
+			values[k] = v
+		}
+		values["security.workloadCertTtl"] = ttl.String()
+		values["security.workloadCertGracePeriodRatio"] = "0.5"
+		values["security.workloadCertMinGracePeriod"] = (ttl / 2).String()
+		cfg.Values = values
+	}
+}
+
 // DefaultConfig creates a new Config from defaults, environments variables, and command-line parameters.
 func DefaultConfig(ctx resource.Context) (Config, error) {
 	// Make a local copy.
@@ -177,6 +282,10 @@ func DefaultConfig(ctx resource.Context) (Config, error) {
 		return Config{}, err
 	}
 
+	if err := checkFileExists(filepath.Join(s.ChartDir, s.RemoteValuesFile)); err != nil {
+		return Config{}, err
+	}
+
 	if err := normalizeFile(&s.CrdsFilesDir); err != nil {
 		return Config{}, err
 	}
@@ -186,7 +295,11 @@ func DefaultConfig(ctx resource.Context) (Config, error) {
 		return Config{}, err
 	}
 
-	if s.Values, err = newHelmValues(deps); err != nil {
+	if s.Values, err = newHelmValues(deps, helmValues); err != nil {
+		return Config{}, err
+	}
+
+	if s.RemoteValues, err = newHelmValues(deps, remoteHelmValues); err != nil {
 		return Config{}, err
 	}
 
@@ -228,8 +341,8 @@ func checkFileExists(path string) error {
 	return nil
 }
 
-func newHelmValues(s *image.Settings) (map[string]string, error) {
-	userValues, err := parseHelmValues()
+func newHelmValues(s *image.Settings, overrides string) (map[string]string, error) {
+	userValues, err := parseHelmValues(overrides)
 	if err != nil {
 		return nil, err
 	}
@@ -254,17 +367,17 @@ func newHelmValues(s *image.Settings) (map[string]string, error) {
 	return values, nil
 }
 
-func parseHelmValues() (map[string]string, error) {
+func parseHelmValues(overrides string) (map[string]string, error) {
 	out := make(map[string]string)
-	if helmValues == "" {
+	if overrides == "" {
 		return out, nil
 	}
 
-	values := strings.Split(helmValues, ",")
+	values := strings.Split(overrides, ",")
 	for _, v := range values {
 		parts := strings.Split(v, "=")
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("failed parsing helm values: %s", helmValues)
+			return nil, fmt.Errorf("failed parsing helm values: %s", overrides)
 		}
 		out[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
 	}
@@ -286,12 +399,19 @@ func (c *Config) String() string {
 	result += fmt.Sprintf("DeployTimeout:                  %s\n", c.DeployTimeout.String())
 	result += fmt.Sprintf("UndeployTimeout:                %s\n", c.UndeployTimeout.String())
 	result += fmt.Sprintf("Values:                         %v\n", c.Values)
+	result += fmt.Sprintf("ValuesOverlays:                 %d\n", len(c.ValuesOverlays))
 	result += fmt.Sprintf("ChartRepo:                      %s\n", c.ChartRepo)
 	result += fmt.Sprintf("ChartDir:                       %s\n", c.ChartDir)
 	result += fmt.Sprintf("CrdsFilesDir:                   %s\n", c.CrdsFilesDir)
 	result += fmt.Sprintf("ValuesFile:                     %s\n", c.ValuesFile)
+	result += fmt.Sprintf("RemoteValues:                   %v\n", c.RemoteValues)
+	result += fmt.Sprintf("RemoteValuesFile:               %s\n", c.RemoteValuesFile)
 	result += fmt.Sprintf("SkipWaitForValidationWebhook:   %v\n", c.SkipWaitForValidationWebhook)
 	result += fmt.Sprintf("CustomSidecarInjectorNamespace: %s\n", c.CustomSidecarInjectorNamespace)
+	result += fmt.Sprintf("Revision:                       %s\n", c.Revision)
+	result += fmt.Sprintf("CNI:                            %v\n", c.CNI)
+	result += fmt.Sprintf("CNIChartDir:                    %s\n", c.CNIChartDir)
+	result += fmt.Sprintf("CNINamespace:                   %s\n", c.CNINamespace)
 
 	return result
 }
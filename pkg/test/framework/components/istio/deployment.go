@@ -17,6 +17,7 @@ package istio
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
 	"regexp"
 	"sort"
@@ -57,8 +58,17 @@ func renderIstioTemplate(helmDir string, cfg Config) (string, error) {
 		return "", err
 	}
 
-	renderedYaml, err := helm.Template(
-		helmDir, cfg.ChartDir, "istio", cfg.SystemNamespace, path.Join(env.IstioChartDir, cfg.ValuesFile), cfg.Values)
+	overlayFiles := make([]string, 0, len(cfg.ValuesOverlays))
+	for i, overlay := range cfg.ValuesOverlays {
+		f := path.Join(helmDir, fmt.Sprintf("values-overlay-%d.yaml", i))
+		if err := ioutil.WriteFile(f, []byte(overlay), os.ModePerm); err != nil {
+			return "", fmt.Errorf("failed writing Helm values overlay: %v", err)
+		}
+		overlayFiles = append(overlayFiles, f)
+	}
+
+	renderedYaml, err := helm.Template(helmDir, cfg.ChartDir, "istio", cfg.SystemNamespace,
+		path.Join(env.IstioChartDir, cfg.ValuesFile), cniValues(cfg), overlayFiles...)
 	if err != nil {
 		return "", err
 	}
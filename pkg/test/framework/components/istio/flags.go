@@ -35,7 +35,8 @@ func init() {
 	flag.StringVar(&settingsFromCommandline.EgressNamespace, "istio.test.kube.egressNamespace", settingsFromCommandline.EgressNamespace,
 		"Specifies the namespace in which istio egressgateway is deployed.")
 	flag.BoolVar(&settingsFromCommandline.DeployIstio, "istio.test.kube.deploy", settingsFromCommandline.DeployIstio,
-		"Deploy Istio into the target Kubernetes environment.")
+		"Deploy Istio into the target Kubernetes environment. If false, the tests attach to the Istio installation already "+
+			"in the environment, which is neither modified nor removed. The configuration applied by the tests is still removed.")
 	flag.DurationVar(&settingsFromCommandline.DeployTimeout, "istio.test.kube.deployTimeout", 0,
 		"Timeout applied to deploying Istio into the target Kubernetes environment. Only applies if DeployIstio=true.")
 	flag.DurationVar(&settingsFromCommandline.UndeployTimeout, "istio.test.kube.undeployTimeout", 0,
@@ -46,7 +47,26 @@ func init() {
 		"Helm values file. This can be an absolute path or relative to chartDir. Only valid when deploying Istio.")
 	flag.StringVar(&helmValues, "istio.test.kube.helm.values", helmValues,
 		"Manual overrides for Helm values file. Only valid when deploying Istio.")
+	flag.StringVar(&settingsFromCommandline.RemoteValuesFile, "istio.test.kube.helm.remoteValuesFile", settingsFromCommandline.RemoteValuesFile,
+		"Helm values file for the remote clusters of a multicluster environment. This can be an absolute path or relative to chartDir. Only valid when deploying Istio.")
+	flag.StringVar(&remoteHelmValues, "istio.test.kube.helm.remoteValues", remoteHelmValues,
+		"Manual overrides for the remote Helm values file. Only valid when deploying Istio.")
 	flag.StringVar(&settingsFromCommandline.CustomSidecarInjectorNamespace, "istio.test.kube.customSidecarInjectorNamespace",
 		settingsFromCommandline.CustomSidecarInjectorNamespace, "Inject the sidecar from the specified namespace")
+	flag.BoolVar(&settingsFromCommandline.CNI, "istio.test.kube.cni", settingsFromCommandline.CNI,
+		"Redirect the traffic of the injected pods with the Istio CNI plugin instead of the istio-init container. "+
+			"When deploying Istio, the plugin is installed from the istio-cni Helm chart dir.")
+	flag.StringVar(&settingsFromCommandline.CNIChartDir, "istio.test.kube.helm.cniChartDir", settingsFromCommandline.CNIChartDir,
+		"Helm chart dir for the Istio CNI plugin. Only valid when deploying Istio with the CNI plugin.")
+	flag.StringVar(&settingsFromCommandline.CNINamespace, "istio.test.kube.cniNamespace", settingsFromCommandline.CNINamespace,
+		"Specifies the namespace in which the Istio CNI plugin is installed.")
+	flag.StringVar(&previousFromCommandline.ChartDir, "istio.test.kube.previous.chartDir", previousFromCommandline.ChartDir,
+		"Helm chart dir of the previous version of Istio, deployed by upgrade tests before upgrading to the version under test.")
+	flag.StringVar(&previousFromCommandline.CrdsFilesDir, "istio.test.kube.previous.crdsFilesDir", previousFromCommandline.CrdsFilesDir,
+		"Helm CRDs files dir of the previous version of Istio. Defaults to the one of the version under test.")
+	flag.StringVar(&previousFromCommandline.Hub, "istio.test.kube.previous.hub", previousFromCommandline.Hub,
+		"Hub of the images of the previous version of Istio. Defaults to the hub of the version under test.")
+	flag.StringVar(&previousFromCommandline.Tag, "istio.test.kube.previous.tag", previousFromCommandline.Tag,
+		"Tag of the images of the previous version of Istio.")
 
 }
@@ -18,6 +18,7 @@ import (
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/framework/timing"
 	"istio.io/istio/pkg/test/scopes"
 )
 
@@ -71,6 +72,7 @@ func Deploy(ctx resource.Context, cfg *Config) (Instance, error) {
 
 	var err error
 	scopes.CI.Info("=== BEGIN: Deploy Istio (via Helm Template) ===")
+	defer timing.Start(timing.Install, "istio")()
 	defer func() {
 		if err != nil {
 			scopes.CI.Infof("=== FAILED: Deploy Istio ===")
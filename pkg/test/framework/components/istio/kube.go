@@ -35,6 +35,8 @@ type kubeComponent struct {
 	ctx         resource.Context
 	environment *kube.Environment
 	deployment  *deployment.Instance
+	// cniFile of the resources of the Istio CNI plugin, if it was installed.
+	cniFile string
 }
 
 var _ io.Closer = &kubeComponent{}
@@ -54,60 +56,30 @@ func deploy(ctx resource.Context, env *kube.Environment, cfg Config) (Instance,
 	i.id = ctx.TrackResource(i)
 
 	if !cfg.DeployIstio {
-		scopes.Framework.Info("skipping deployment due to Config")
+		scopes.Framework.Info("skipping deployment due to Config, attaching to the existing installation")
+		if err := waitForExistingInstall(env.Accessor, cfg); err != nil {
+			return nil, err
+		}
 		return i, nil
 	}
 
-	// Top-level work dir for Istio deployment.
-	workDir, err := ctx.CreateTmpDirectory("istio-deployment")
+	workDir, helmWorkDir, files, err := writeDeploymentFiles(ctx, "istio-deployment", cfg)
 	if err != nil {
 		return nil, err
 	}
+	istioInstallFile := files.install
+	istioConfigFile := files.config
 
-	// Create helm working dir
-	helmWorkDir := path.Join(workDir, "helm")
-	if err := os.MkdirAll(helmWorkDir, os.ModePerm); err != nil {
-		return nil, err
-	}
-
-	// First, generate CRDs.
-	crdYaml, err := generateCRDYaml(cfg.CrdsFilesDir)
-	if err != nil {
-		return nil, err
-	}
-
-	// Generate rendered yaml file for Istio, including namespace.
-	istioYaml, err := generateIstioYaml(helmWorkDir, cfg)
-	if err != nil {
+	// Apply CRDs first.
+	if err = env.Accessor.Apply("", files.crd); err != nil {
 		return nil, err
 	}
 
-	// split installation & configuration into two distinct steps, so that we can submit configuration before waiting
-	// for Galley to come online.
-	installYaml, configureYaml := splitIstioYaml(istioYaml)
-
-	// Write out as files for deployment and debugging purposes.
-	crdFile := path.Join(workDir, "crd.yaml")
-	if err = ioutil.WriteFile(crdFile, []byte(crdYaml), os.ModePerm); err != nil {
-		return nil, fmt.Errorf("unable to write %q: %v", crdFile, err)
-	}
-	istioFile := path.Join(workDir, "istio.yaml")
-	if err = ioutil.WriteFile(istioFile, []byte(istioYaml), os.ModePerm); err != nil {
-		return nil, fmt.Errorf("unable to write %q: %v", istioFile, err)
-	}
-	istioInstallFile := path.Join(workDir, "istio-install-only.yaml")
-	if err = ioutil.WriteFile(istioInstallFile, []byte(installYaml), os.ModePerm); err != nil {
-		return nil, fmt.Errorf("unable to write %q: %v", istioInstallFile, err)
-	}
-	istioConfigFile := path.Join(workDir, "istio-config-only.yaml")
-	if err = ioutil.WriteFile(istioConfigFile, []byte(configureYaml), os.ModePerm); err != nil {
-		return nil, fmt.Errorf("unable to write %q: %v", istioConfigFile, err)
-	}
-	scopes.CI.Infof("Wrote out istio deployment files at: %s", workDir)
-
-	// Apply CRDs first.
-	if err = env.Accessor.Apply("", crdFile); err != nil {
-		return nil, err
+	// The CNI plugin must be running on the nodes before any pods are injected.
+	if cfg.CNI {
+		if i.cniFile, err = deployCNI(env, workDir, helmWorkDir, cfg); err != nil {
+			return nil, err
+		}
 	}
 
 	// Deploy Istio.
@@ -137,9 +109,76 @@ func deploy(ctx resource.Context, env *kube.Environment, cfg Config) (Instance,
 		return nil, err
 	}
 
+	// Finally, configure the remote clusters to use the control plane of the primary cluster.
+	if env.IsMulticluster() {
+		if err = deployRemotes(env, workDir, helmWorkDir, cfg); err != nil {
+			return nil, err
+		}
+	}
+
 	return i, nil
 }
 
+// deploymentFiles are the YAML files generated for deploying Istio.
+type deploymentFiles struct {
+	crd string
+	// install has the resources of the components, and config the Istio configuration, which can only be
+	// applied once the validation webhook is running.
+	install string
+	config  string
+}
+
+// writeDeploymentFiles renders the Istio deployment for the given config by Helm, and writes it out to a new
+// work dir with the given prefix, for deployment and debugging purposes.
+func writeDeploymentFiles(ctx resource.Context, prefix string, cfg Config) (workDir, helmWorkDir string, files deploymentFiles, err error) {
+	// Top-level work dir for Istio deployment.
+	workDir, err = ctx.CreateTmpDirectory(prefix)
+	if err != nil {
+		return
+	}
+
+	// Create helm working dir
+	helmWorkDir = path.Join(workDir, "helm")
+	if err = os.MkdirAll(helmWorkDir, os.ModePerm); err != nil {
+		return
+	}
+
+	// First, generate CRDs.
+	crdYaml, err := generateCRDYaml(cfg.CrdsFilesDir)
+	if err != nil {
+		return
+	}
+
+	// Generate rendered yaml file for Istio, including namespace.
+	istioYaml, err := generateIstioYaml(helmWorkDir, cfg)
+	if err != nil {
+		return
+	}
+
+	// split installation & configuration into two distinct steps, so that we can submit configuration before waiting
+	// for Galley to come online.
+	installYaml, configureYaml := splitIstioYaml(istioYaml)
+
+	files = deploymentFiles{
+		crd:     path.Join(workDir, "crd.yaml"),
+		install: path.Join(workDir, "istio-install-only.yaml"),
+		config:  path.Join(workDir, "istio-config-only.yaml"),
+	}
+	for f, content := range map[string]string{
+		files.crd:                        crdYaml,
+		path.Join(workDir, "istio.yaml"): istioYaml,
+		files.install:                    installYaml,
+		files.config:                     configureYaml,
+	} {
+		if err = ioutil.WriteFile(f, []byte(content), os.ModePerm); err != nil {
+			err = fmt.Errorf("unable to write %q: %v", f, err)
+			return
+		}
+	}
+	scopes.CI.Infof("Wrote out istio deployment files at: %s", workDir)
+	return
+}
+
 // ID implements resource.Instance
 func (i *kubeComponent) ID() resource.ID {
 	return i.id
@@ -158,6 +197,21 @@ func (i *kubeComponent) Close() (err error) {
 		if err == nil {
 			err = i.environment.Accessor.WaitForNamespaceDeletion(i.settings.SystemNamespace)
 		}
+		if e := deleteRemotes(i.environment, i.settings); e != nil && err == nil {
+			err = e
+		}
+		// The CNI plugin is installed to a namespace shared with other components.
+		if i.cniFile != "" {
+			if e := i.environment.Accessor.Delete(i.settings.CNINamespace, i.cniFile); e != nil && err == nil {
+				err = e
+			}
+		}
+		// The injector webhook of a revision is not deleted with its namespace.
+		if i.settings.Revision != "" {
+			if e := i.environment.Accessor.DeleteMutatingWebhook("istio-sidecar-injector-" + i.settings.Revision); e != nil && err == nil {
+				err = e
+			}
+		}
 	}
 
 	return
@@ -174,25 +228,5 @@ func (i *kubeComponent) Dump() {
 
 	deployment.DumpPodState(d, i.settings.SystemNamespace, i.environment.Accessor)
 	deployment.DumpPodEvents(d, i.settings.SystemNamespace, i.environment.Accessor)
-
-	pods, err := i.environment.Accessor.GetPods(i.settings.SystemNamespace)
-	if err != nil {
-		scopes.CI.Errorf("Unable to get pods from the system namespace: %v", err)
-		return
-	}
-
-	for _, pod := range pods {
-		for _, container := range pod.Spec.Containers {
-			l, err := i.environment.Logs(pod.Namespace, pod.Name, container.Name, false /* previousLog */)
-			if err != nil {
-				scopes.CI.Errorf("Unable to get logs for pod/container: %s/%s/%s", pod.Namespace, pod.Name, container.Name)
-				continue
-			}
-
-			fname := path.Join(d, fmt.Sprintf("%s-%s.log", pod.Name, container.Name))
-			if err = ioutil.WriteFile(fname, []byte(l), os.ModePerm); err != nil {
-				scopes.CI.Errorf("Unable to write logs for pod/container: %s/%s/%s", pod.Namespace, pod.Name, container.Name)
-			}
-		}
-	}
+	deployment.DumpPodLogs(d, i.settings.SystemNamespace, i.environment.Accessor)
 }
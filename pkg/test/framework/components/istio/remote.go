@@ -0,0 +1,163 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"istio.io/istio/pkg/kube/secretcontroller"
+	"istio.io/istio/pkg/test/deployment"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/helm"
+	k "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/yml"
+
+	kubeCore "k8s.io/api/core/v1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	remoteSecretPrefix = "istio-remote-secret-"
+)
+
+// controlPlaneValues returns the Helm values that point the remote clusters at the control plane of the primary
+// cluster. The pod IPs are used, which requires a flat network across the clusters.
+func controlPlaneValues(accessor *k.Accessor, cfg Config) (map[string]string, error) {
+	pods, err := accessor.WaitUntilPodsAreReady(accessor.NewSinglePodFetch(cfg.ConfigNamespace, "istio=pilot"))
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for pilot in the primary cluster: %v", err)
+	}
+	values := map[string]string{
+		"global.remotePilotAddress":           pods[0].Status.PodIP,
+		"global.remotePilotCreateSvcEndpoint": "true",
+	}
+
+	// Mixer is optional.
+	for _, mixer := range []struct {
+		key       string
+		namespace string
+		selector  string
+	}{
+		{"global.remotePolicyAddress", cfg.PolicyNamespace, "istio-mixer-type=policy"},
+		{"global.remoteTelemetryAddress", cfg.TelemetryNamespace, "istio-mixer-type=telemetry"},
+	} {
+		pods, err := accessor.GetPods(mixer.namespace, mixer.selector)
+		if err != nil {
+			return nil, err
+		}
+		if len(pods) > 0 && pods[0].Status.PodIP != "" {
+			values[mixer.key] = pods[0].Status.PodIP
+			values["global.createRemoteSvcEndpoints"] = "true"
+		}
+	}
+	return values, nil
+}
+
+// deployRemotes configures the remote clusters of the environment to use the control plane of the primary
+// cluster, and registers them with it.
+func deployRemotes(environment *kube.Environment, workDir, helmWorkDir string, cfg Config) error {
+	controlPlane, err := controlPlaneValues(environment.Accessor, cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range environment.Settings().RemoteClusterNames() {
+		accessor, err := environment.Cluster(name)
+		if err != nil {
+			return err
+		}
+
+		values := make(map[string]string)
+		for key, value := range controlPlane {
+			values[key] = value
+		}
+		values["global.multiCluster.clusterName"] = name
+		for key, value := range cfg.RemoteValues {
+			values[key] = value
+		}
+
+		remoteYaml, err := helm.Template(helmWorkDir, cfg.ChartDir, "istio-remote", cfg.SystemNamespace,
+			path.Join(env.IstioChartDir, cfg.RemoteValuesFile), values)
+		if err != nil {
+			return err
+		}
+		remoteYaml = yml.JoinString(fmt.Sprintf(namespaceTemplate, cfg.SystemNamespace), remoteYaml)
+
+		remoteFile := path.Join(workDir, fmt.Sprintf("istio-remote-%s.yaml", name))
+		if err = ioutil.WriteFile(remoteFile, []byte(remoteYaml), os.ModePerm); err != nil {
+			return fmt.Errorf("unable to write %q: %v", remoteFile, err)
+		}
+		scopes.CI.Infof("Configuring remote cluster %s with: %s", name, remoteFile)
+
+		d := deployment.NewYamlDeployment(cfg.SystemNamespace, remoteFile)
+		if err = d.Deploy(accessor, true, retry.Timeout(cfg.DeployTimeout)); err != nil {
+			return fmt.Errorf("failed configuring remote cluster %s: %v", name, err)
+		}
+
+		if err = registerRemote(environment, name, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerRemote stores the kube config of the named remote cluster in the secret watched by Pilot, for
+// discovering the services of the cluster.
+func registerRemote(environment *kube.Environment, name string, cfg Config) error {
+	kubeConfig, err := ioutil.ReadFile(environment.Settings().RemoteKubeConfigs[name])
+	if err != nil {
+		return err
+	}
+	secret := &kubeCore.Secret{
+		ObjectMeta: kubeApiMeta.ObjectMeta{
+			Name:      remoteSecretPrefix + name,
+			Namespace: cfg.SystemNamespace,
+			Labels: map[string]string{
+				secretcontroller.MultiClusterSecretLabel: "true",
+			},
+		},
+		Data: map[string][]byte{
+			name: kubeConfig,
+		},
+	}
+	if err = environment.Accessor.CreateSecret(cfg.SystemNamespace, secret); err != nil {
+		return fmt.Errorf("failed registering remote cluster %s: %v", name, err)
+	}
+	return nil
+}
+
+// deleteRemotes removes the configuration of the remote clusters. The secrets of the remote clusters are
+// deleted together with the system namespace of the primary cluster.
+func deleteRemotes(environment *kube.Environment, cfg Config) (err error) {
+	for _, name := range environment.Settings().RemoteClusterNames() {
+		accessor, e := environment.Cluster(name)
+		if e != nil {
+			return e
+		}
+		if e = accessor.DeleteNamespace(cfg.SystemNamespace); e == nil {
+			e = accessor.WaitForNamespaceDeletion(cfg.SystemNamespace)
+		}
+		if e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
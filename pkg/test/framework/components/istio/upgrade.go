@@ -0,0 +1,163 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package istio
+
+import (
+	"errors"
+	"fmt"
+
+	"istio.io/istio/pkg/test/deployment"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/core/image"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/framework/timing"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// PreviousVersion of Istio, which upgrade tests deploy before upgrading in place to the version under test.
+type PreviousVersion struct {
+	// ChartDir is the top-level Helm chart dir of the previous version.
+	ChartDir string
+
+	// CrdsFilesDir is the Helm CRDs files dir of the previous version. Defaults to the one of the version under
+	// test.
+	CrdsFilesDir string
+
+	// Hub and Tag of the images of the previous version.
+	Hub string
+	Tag string
+}
+
+var previousFromCommandline = &PreviousVersion{}
+
+// PreviousVersionSettings returns the settings of the previous version of Istio from the command-line.
+func PreviousVersionSettings() PreviousVersion {
+	return *previousFromCommandline
+}
+
+// Apply the previous version to the given config, replacing the charts and images of the version under test.
+func (p PreviousVersion) Apply(cfg *Config) error {
+	if p.ChartDir == "" || p.Tag == "" {
+		return errors.New("the chart dir and the tag of the previous version of Istio are required")
+	}
+	cfg.ChartDir = p.ChartDir
+	if err := normalizeFile(&cfg.ChartDir); err != nil {
+		return err
+	}
+	if p.CrdsFilesDir != "" {
+		cfg.CrdsFilesDir = p.CrdsFilesDir
+		if err := normalizeFile(&cfg.CrdsFilesDir); err != nil {
+			return err
+		}
+	}
+
+	values := make(map[string]string, len(cfg.Values))
+	for k, v := range cfg.Values {
+		values[k] = v
+	}
+	if p.Hub != "" {
+		values[image.HubValuesKey] = p.Hub
+	}
+	values[image.TagValuesKey] = p.Tag
+	cfg.Values = values
+	return nil
+}
+
+// SetupPrevious is a setup function that deploys the previous version of Istio, as configured from the
+// command-line, on the Kubernetes environment. The given function overrides the rest of the configuration, and
+// must make the same overrides as the one passed to Upgrade. Fails if no previous version is configured, so
+// suites using it are expected to only run in jobs that provide one.
+func SetupPrevious(i *Instance, cfn SetupConfigFn) resource.SetupFn {
+	return func(ctx resource.Context) error {
+		if ctx.Environment().EnvironmentName() != environment.Kube {
+			return resource.UnsupportedEnvironment(ctx.Environment())
+		}
+		cfg, err := DefaultConfig(ctx)
+		if err != nil {
+			return err
+		}
+		if !cfg.DeployIstio {
+			return errors.New("upgrade tests must deploy Istio")
+		}
+		if err := PreviousVersionSettings().Apply(&cfg); err != nil {
+			return err
+		}
+		if cfn != nil {
+			cfn(&cfg)
+		}
+		ins, err := Deploy(ctx, &cfg)
+		if err != nil {
+			return err
+		}
+		if i != nil {
+			*i = ins
+		}
+		return nil
+	}
+}
+
+// Upgrade the given deployment of Istio in place, to the version under test. The given function overrides the
+// configuration, as the one passed to SetupPrevious. The Istio configuration resources are re-applied once the
+// upgraded components are ready. The deployed sidecars are not restarted, so they keep running the previous
+// version until their workloads are re-deployed.
+func Upgrade(ctx resource.Context, i Instance, cfn SetupConfigFn) (err error) {
+	c, ok := i.(*kubeComponent)
+	if !ok {
+		return fmt.Errorf("upgrade of %T is not supported", i)
+	}
+	cfg, err := DefaultConfig(ctx)
+	if err != nil {
+		return err
+	}
+	if cfn != nil {
+		cfn(&cfg)
+	}
+	if cfg.SystemNamespace != c.settings.SystemNamespace {
+		return fmt.Errorf("upgrade from namespace %s to %s is not supported", c.settings.SystemNamespace, cfg.SystemNamespace)
+	}
+
+	scopes.CI.Info("=== BEGIN: Upgrade Istio (via Helm Template) ===")
+	defer timing.Start(timing.Install, "istio-upgrade")()
+	defer func() {
+		if err != nil {
+			scopes.CI.Infof("=== FAILED: Upgrade Istio ===")
+		} else {
+			scopes.CI.Infof("=== SUCCEEDED: Upgrade Istio ===")
+		}
+	}()
+
+	_, _, files, err := writeDeploymentFiles(ctx, "istio-upgrade", cfg)
+	if err != nil {
+		return err
+	}
+	if err = c.environment.Accessor.Apply("", files.crd); err != nil {
+		return err
+	}
+	c.deployment = deployment.NewYamlDeployment(cfg.SystemNamespace, files.install)
+	if err = c.deployment.Deploy(c.environment.Accessor, true, retry.Timeout(cfg.DeployTimeout)); err != nil {
+		return err
+	}
+	if !cfg.SkipWaitForValidationWebhook {
+		if err = waitForValidationWebhook(c.environment.Accessor); err != nil {
+			return err
+		}
+	}
+	if err = c.environment.Accessor.Apply("", files.config); err != nil {
+		return err
+	}
+	c.settings = cfg
+	return nil
+}
@@ -39,6 +39,20 @@ spec:
 `
 )
 
+// waitForExistingInstall waits until the control plane of an Istio installation that was not deployed by the
+// test framework serves, so that tests attaching to it fail early if it is missing.
+func waitForExistingInstall(accessor *kube.Accessor, cfg Config) error {
+	if !accessor.NamespaceExists(cfg.SystemNamespace) {
+		return fmt.Errorf("no existing Istio installation found: namespace %s does not exist", cfg.SystemNamespace)
+	}
+	for _, svc := range []string{"istio-pilot", "istio-galley"} {
+		if _, _, err := accessor.WaitUntilServiceEndpointsAreReady(cfg.ConfigNamespace, svc); err != nil {
+			return fmt.Errorf("error waiting for %s/%s of the existing Istio installation: %v", cfg.ConfigNamespace, svc, err)
+		}
+	}
+	return nil
+}
+
 func waitForValidationWebhook(accessor *kube.Accessor) error {
 
 	defer func() {
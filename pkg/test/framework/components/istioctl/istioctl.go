@@ -15,44 +15,155 @@
 package istioctl
 
 import (
-	"testing"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
 
+	"istio.io/istio/istioctl/cmd"
+	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
 )
 
+// Output of an istioctl command.
+type Output struct {
+	// Stdout of the command. Commands run in-process write both their output and their errors to Stdout.
+	Stdout string
+	// Stderr of the command. Always empty for commands run in-process.
+	Stderr string
+}
+
 type Instance interface {
+	resource.Resource
+
 	// Invoke invokes an istioctl command and returns the output and exception.
 	// Cobra commands don't make it easy to separate stdout and stderr and the string parameter
 	// will receive both.
 	Invoke(args []string) (string, error)
+	InvokeOrFail(t test.Failer, args []string) string
+
+	// Run an istioctl command. Stdout and stderr are only captured separately if istioctl runs as a binary.
+	Run(args ...string) (Output, error)
+	RunOrFail(t test.Failer, args ...string) Output
+
+	// RunJSON runs an istioctl command and unmarshals its stdout into out. The arguments must select the JSON
+	// output of the command, e.g. "-o", "json".
+	RunJSON(out interface{}, args ...string) error
+	RunJSONOrFail(t test.Failer, out interface{}, args ...string)
 }
 
 // Structured config for the istioctl component
 type Config struct {
-	// currently nothing, we might add stuff like OS env settings later
+	// Binary of istioctl to run the commands with. If empty, the commands are run in-process. Defaults to the
+	// value of the --istio.test.istioctl.binary flag.
+	Binary string
+
+	// Cluster (kube only) whose kubeconfig is passed to the commands. Defaults to the primary cluster.
+	Cluster string
+
+	// Context (kube only) of the kubeconfig passed to the commands. Defaults to the current context.
+	Context string
 }
 
 // New returns a new instance of "istioctl".
 func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	if cfg.Binary == "" {
+		cfg.Binary = binaryFromCommandline
+	}
+
 	err = resource.UnsupportedEnvironment(ctx.Environment())
 	ctx.Environment().Case(environment.Native, func() {
-		i = newNative(ctx, cfg)
-		err = nil
+		i, err = newNative(ctx, cfg)
 	})
 	ctx.Environment().Case(environment.Kube, func() {
-		i = newKube(ctx, cfg)
-		err = nil
+		i, err = newKube(ctx, cfg)
 	})
 
 	return
 }
 
 // NewOrFail returns a new instance of "istioctl".
-func NewOrFail(t *testing.T, c resource.Context, config Config) Instance {
+func NewOrFail(t test.Failer, c resource.Context, config Config) Instance {
+	t.Helper()
 	i, err := New(c, config)
 	if err != nil {
 		t.Fatalf("istioctl.NewOrFail:: %v", err)
 	}
 	return i
 }
+
+// runner runs the commands of an Instance with the given environment specific arguments prepended.
+type runner struct {
+	binary  string
+	envArgs []string
+}
+
+// Invoke implements Instance.
+func (r *runner) Invoke(args []string) (string, error) {
+	out, err := r.Run(args...)
+	return out.Stdout + out.Stderr, err
+}
+
+// InvokeOrFail implements Instance.
+func (r *runner) InvokeOrFail(t test.Failer, args []string) string {
+	t.Helper()
+	out, err := r.Invoke(args)
+	if err != nil {
+		t.Fatalf("istioctl %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// Run implements Instance.
+func (r *runner) Run(args ...string) (Output, error) {
+	args = append(append([]string{}, r.envArgs...), args...)
+	scopes.Framework.Debugf("Running istioctl %s", strings.Join(args, " "))
+
+	if r.binary == "" {
+		var out bytes.Buffer
+		rootCmd := cmd.GetRootCmd(args)
+		rootCmd.SetOutput(&out)
+		err := rootCmd.Execute()
+		return Output{Stdout: out.String()}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	c := exec.Command(r.binary, args...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	err := c.Run()
+	return Output{Stdout: stdout.String(), Stderr: stderr.String()}, err
+}
+
+// RunOrFail implements Instance.
+func (r *runner) RunOrFail(t test.Failer, args ...string) Output {
+	t.Helper()
+	out, err := r.Run(args...)
+	if err != nil {
+		t.Fatalf("istioctl %s: %v\nstdout:\n%s\nstderr:\n%s", strings.Join(args, " "), err, out.Stdout, out.Stderr)
+	}
+	return out
+}
+
+// RunJSON implements Instance.
+func (r *runner) RunJSON(out interface{}, args ...string) error {
+	o, err := r.Run(args...)
+	if err != nil {
+		return fmt.Errorf("istioctl %s: %v\nstdout:\n%s\nstderr:\n%s", strings.Join(args, " "), err, o.Stdout, o.Stderr)
+	}
+	if err := json.Unmarshal([]byte(o.Stdout), out); err != nil {
+		return fmt.Errorf("istioctl %s: failed parsing JSON output: %v\n%s", strings.Join(args, " "), err, o.Stdout)
+	}
+	return nil
+}
+
+// RunJSONOrFail implements Instance.
+func (r *runner) RunJSONOrFail(t test.Failer, out interface{}, args ...string) {
+	t.Helper()
+	if err := r.RunJSON(out, args...); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -15,46 +15,54 @@
 package istioctl
 
 import (
-	"bytes"
-
-	"istio.io/istio/istioctl/cmd"
+	"fmt"
 
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
 	"istio.io/istio/pkg/test/framework/resource"
 )
 
 type kubeComponent struct {
+	*runner
+
 	config Config
 	id     resource.ID
 	ctx    resource.Context
 	env    *kube.Environment
 }
 
-func newKube(ctx resource.Context, config Config) Instance {
+func newKube(ctx resource.Context, config Config) (Instance, error) {
+	env := ctx.Environment().(*kube.Environment)
+
+	kubeConfig := env.Settings().KubeConfig
+	if config.Cluster != "" && config.Cluster != env.PrimaryClusterName() {
+		var ok bool
+		if kubeConfig, ok = env.Settings().RemoteKubeConfigs[config.Cluster]; !ok {
+			return nil, fmt.Errorf("istioctl: unknown cluster %q", config.Cluster)
+		}
+	}
+	envArgs := []string{
+		"--kubeconfig",
+		kubeConfig,
+	}
+	if config.Context != "" {
+		envArgs = append(envArgs, "--context", config.Context)
+	}
+
 	n := &kubeComponent{
+		runner: &runner{
+			binary:  config.Binary,
+			envArgs: envArgs,
+		},
 		ctx:    ctx,
 		config: config,
-		env:    ctx.Environment().(*kube.Environment),
+		env:    env,
 	}
 	n.id = ctx.TrackResource(n)
 
-	return n
+	return n, nil
 }
 
 // ID implements resource.Instance
 func (c *kubeComponent) ID() resource.ID {
 	return c.id
 }
-
-// Invoke gets the discovery address for pilot.
-func (c *kubeComponent) Invoke(args []string) (string, error) {
-	var envArgs = []string{
-		"--kubeconfig",
-		c.env.Settings().KubeConfig,
-	}
-	var out bytes.Buffer
-	rootCmd := cmd.GetRootCmd(append(envArgs, args...))
-	rootCmd.SetOutput(&out)
-	fErr := rootCmd.Execute()
-	return out.String(), fErr
-}
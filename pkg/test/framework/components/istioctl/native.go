@@ -15,40 +15,30 @@
 package istioctl
 
 import (
-	"bytes"
-
-	"istio.io/istio/istioctl/cmd"
-
 	"istio.io/istio/pkg/test/framework/resource"
 )
 
 // Note: The native tests don't talk to a K8s API server, so most istioctl commands won't work
 type nativeComponent struct {
+	*runner
+
 	config Config
 	id     resource.ID
 	ctx    resource.Context
 }
 
-func newNative(ctx resource.Context, config Config) Instance {
+func newNative(ctx resource.Context, config Config) (Instance, error) {
 	n := &nativeComponent{
+		runner: &runner{binary: config.Binary},
 		ctx:    ctx,
 		config: config,
 	}
 	n.id = ctx.TrackResource(n)
 
-	return n
+	return n, nil
 }
 
 // ID implements resource.Instance
 func (c *nativeComponent) ID() resource.ID {
 	return c.id
 }
-
-// Invoke gets the discovery address for pilot.
-func (c *nativeComponent) Invoke(args []string) (string, error) {
-	var out bytes.Buffer
-	rootCmd := cmd.GetRootCmd(args)
-	rootCmd.SetOutput(&out)
-	fErr := rootCmd.Execute()
-	return out.String(), fErr
-}
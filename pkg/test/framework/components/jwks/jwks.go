@@ -0,0 +1,99 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwks
+
+import (
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/fakes/jwks"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Fault injected into the responses of the JWKS server.
+type Fault = jwks.Fault
+
+// Stats about the requests served by the JWKS server.
+type Stats = jwks.Stats
+
+// Config for the JWKS server.
+type Config struct {
+	// Namespace to deploy the server to. If not set, a new namespace is created. Only used in the
+	// Kubernetes environment.
+	Namespace namespace.Instance
+
+	// JWKS initially served. Defaults to an empty key set.
+	JWKS string
+}
+
+// Instance represents a deployed fake JWKS server, for use as the jwksUri of authentication policies.
+type Instance interface {
+	resource.Resource
+
+	// URI of the key set over plaintext HTTP, as seen from within the cluster.
+	URI() string
+
+	// SecureURI of the key set over HTTPS, as seen from within the cluster. The server uses a self-signed
+	// certificate.
+	SecureURI() string
+
+	// SetJWKS replaces the served key set.
+	SetJWKS(jwks string) error
+	SetJWKSOrFail(t test.Failer, jwks string)
+
+	// SetFault injects a delay and/or an error status code into the responses of the server.
+	SetFault(fault Fault) error
+	SetFaultOrFail(t test.Failer, fault Fault)
+
+	// SetOutage makes the server fail all requests with 503 (Service Unavailable).
+	SetOutage() error
+	SetOutageOrFail(t test.Failer)
+
+	// SetDelay makes the server wait for the given duration before responding.
+	SetDelay(delay time.Duration) error
+	SetDelayOrFail(t test.Failer, delay time.Duration)
+
+	// ClearFault restores the normal behavior of the server.
+	ClearFault() error
+	ClearFaultOrFail(t test.Failer)
+
+	// Stats returns statistics about the requests for the key set received by the server.
+	Stats() (Stats, error)
+	StatsOrFail(t test.Failer) Stats
+}
+
+// New returns a new instance of the JWKS server.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Native, func() {
+		i, err = newNative(ctx, cfg)
+	})
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("jwks.NewOrFail: %v", err)
+	}
+	return i
+}
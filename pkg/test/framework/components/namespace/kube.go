@@ -18,9 +18,13 @@ import (
 	"fmt"
 	"io"
 	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
+
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
 	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/resource"
@@ -34,11 +38,16 @@ var (
 	mu    sync.Mutex
 )
 
-// kubeNamespace represents a Kubernetes namespace. It is tracked as a resource.
+// kubeNamespace represents a Kubernetes namespace. It is tracked as a resource. In a multicluster
+// environment, the namespace is created in all clusters.
 type kubeNamespace struct {
-	id   resource.ID
-	name string
-	a    *k.Accessor
+	id        resource.ID
+	name      string
+	accessors []*k.Accessor
+
+	// env and reuseKey are set if the namespace is released for reuse instead of deleted when closed.
+	env      *kube.Environment
+	reuseKey string
 }
 
 var _ Instance = &kubeNamespace{}
@@ -55,11 +64,25 @@ func (n *kubeNamespace) ID() resource.ID {
 
 // Close implements io.Closer
 func (n *kubeNamespace) Close() (err error) {
+	if n.name != "" && n.reuseKey != "" {
+		ns := n.name
+		if err = resetKube(n.accessors, ns); err == nil {
+			n.name = ""
+			n.env.ReleaseNamespace(n.reuseKey, ns)
+			scopes.Framework.Debugf("%s close complete, namespace released for reuse", n.id)
+			return
+		}
+		scopes.Framework.Warnf("%s unable to reset namespace %s for reuse, deleting it: %v", n.id, ns, err)
+		err = nil
+	}
+
 	if n.name != "" {
 		scopes.Framework.Debugf("%s deleting namespace", n.id)
 		ns := n.name
 		n.name = ""
-		err = n.a.DeleteNamespace(ns)
+		for _, a := range n.accessors {
+			err = multierror.Append(err, a.DeleteNamespace(ns)).ErrorOrNil()
+		}
 	}
 
 	scopes.Framework.Debugf("%s close complete (err:%v)", n.id, err)
@@ -73,51 +96,142 @@ func claimKube(ctx resource.Context, name string) (Instance, error) {
 		return nil, err
 	}
 
-	if !env.Accessor.NamespaceExists(name) {
-		nsConfig := Config{
-			Inject:                  true,
-			CustomInjectorNamespace: cfg.CustomSidecarInjectorNamespace,
-		}
-		nsLabels := createNamespaceLabels(&nsConfig)
-		if err := env.CreateNamespaceWithLabels(name, "istio-test", nsLabels); err != nil {
-			return nil, err
+	for _, a := range env.Accessors() {
+		if !a.NamespaceExists(name) {
+			nsConfig := Config{
+				Inject:                  true,
+				CustomInjectorNamespace: cfg.CustomSidecarInjectorNamespace,
+			}
+			nsLabels := createNamespaceLabels(&nsConfig)
+			if err := a.CreateNamespaceWithLabels(name, "istio-test", nsLabels); err != nil {
+				return nil, err
+			}
 		}
-
 	}
 	return &kubeNamespace{name: name}, nil
 }
 
 // NewNamespace allocates a new testing namespace.
 func newKube(ctx resource.Context, nsConfig *Config) (Instance, error) {
+	env := ctx.Environment().(*kube.Environment)
+
+	key := ""
+	if env.Settings().ReuseNamespaces {
+		key = reuseKey(nsConfig)
+		if ns, ok := env.AcquireNamespace(key); ok {
+			n := &kubeNamespace{
+				name:      ns,
+				accessors: env.Accessors(),
+				env:       env,
+				reuseKey:  key,
+			}
+			n.id = ctx.TrackResource(n)
+			return n, nil
+		}
+	}
+
 	mu.Lock()
 	idctr++
 	nsid := idctr
 	r := rnd.Intn(99999)
 	mu.Unlock()
 
-	env := ctx.Environment().(*kube.Environment)
 	ns := fmt.Sprintf("%s-%d-%d", nsConfig.Prefix, nsid, r)
 
 	nsLabels := createNamespaceLabels(nsConfig)
-	if err := env.CreateNamespaceWithLabels(ns, "istio-test", nsLabels); err != nil {
-		return nil, err
+	n := &kubeNamespace{name: ns}
+	for _, a := range env.Accessors() {
+		if err := a.CreateNamespaceWithMetadata(ns, "istio-test", copyMap(nsLabels), nsConfig.Annotations); err != nil {
+			_ = n.Close()
+			return nil, err
+		}
+		n.accessors = append(n.accessors, a)
+	}
+
+	if key != "" {
+		n.env = env
+		n.reuseKey = key
 	}
 
-	n := &kubeNamespace{name: ns, a: env.Accessor}
 	id := ctx.TrackResource(n)
 	n.id = id
 
 	return n, nil
 }
 
+// reuseKey identifies the namespaces that can be reused for the given configuration.
+func reuseKey(cfg *Config) string {
+	return cfg.Prefix + "/" + joinMap(createNamespaceLabels(cfg)) + "/" + joinMap(cfg.Annotations)
+}
+
+func joinMap(m map[string]string) string {
+	parts := make([]string, 0, len(m))
+	for k, v := range m {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func copyMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func resetKubeNamespace(ctx resource.Context, ns Instance) error {
+	env := ctx.Environment().(*kube.Environment)
+	return resetKube(env.Accessors(), ns.Name())
+}
+
+// resetKube deletes the Istio configuration and the workloads deployed to the namespace in all clusters,
+// leaving an empty namespace behind.
+func resetKube(accessors []*k.Accessor, ns string) (err error) {
+	scopes.Framework.Debugf("resetting namespace %s", ns)
+	for _, a := range accessors {
+		crds, e := a.GetCustomResourceDefinitions()
+		if e != nil {
+			return e
+		}
+		var istioTypes []string
+		for _, crd := range crds {
+			if strings.HasSuffix(crd.Spec.Group, "istio.io") {
+				istioTypes = append(istioTypes, crd.Spec.Names.Plural+"."+crd.Spec.Group)
+			}
+		}
+		if len(istioTypes) > 0 {
+			err = multierror.Append(err, a.DeleteAll(ns, strings.Join(istioTypes, ","), "")).ErrorOrNil()
+		}
+
+		err = multierror.Append(err,
+			a.DeleteAll(ns, "deployments,statefulsets,daemonsets,replicasets,jobs,services,pods,configmaps", ""),
+			// Keep the default service account and the tokens, so that new pods can be admitted right away.
+			a.DeleteAll(ns, "secrets", "type!=kubernetes.io/service-account-token"),
+			a.DeleteAll(ns, "serviceaccounts", "metadata.name!=default"),
+		).ErrorOrNil()
+	}
+	return
+}
+
 // createNamespaceLabels will take a namespace config and generate the proper k8s labels
 func createNamespaceLabels(cfg *Config) map[string]string {
 	l := make(map[string]string)
-	if cfg.Inject {
-		l["istio-injection"] = "enabled"
+	switch {
+	case cfg.SkipInjectionLabels:
+	case cfg.Inject:
+		if cfg.Revision != "" {
+			l[RevisionLabel] = cfg.Revision
+		} else {
+			l[InjectionLabel] = "enabled"
+		}
 		if cfg.CustomInjectorNamespace != "" {
 			l["istio-env"] = cfg.CustomInjectorNamespace
 		}
+	default:
+		// Opt out explicitly, in case the injector injects namespaces by default.
+		l[InjectionLabel] = "disabled"
 	}
 
 	// bring over supplied labels
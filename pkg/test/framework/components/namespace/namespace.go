@@ -22,12 +22,25 @@ import (
 	"istio.io/istio/pkg/test/framework/resource"
 )
 
+const (
+	// RevisionLabel selects the revision of the control plane that injects the sidecars of a namespace.
+	RevisionLabel = "istio.io/rev"
+	// InjectionLabel enables or disables sidecar injection for a namespace.
+	InjectionLabel = "istio-injection"
+)
+
 // Config contains configuration information about the namespace instance
 type Config struct {
 	Prefix                  string            // prefix to use for autogenerated namespace name
 	Inject                  bool              // whether to add sidecar injection label to this namespace
 	CustomInjectorNamespace string            // namespace of custom injector instance
+	Revision                string            // revision of the control plane that injects the sidecars
 	Labels                  map[string]string // arbitrary labels to be applied to namespace
+	Annotations             map[string]string // arbitrary annotations to be applied to namespace
+
+	// SkipInjectionLabels leaves out all of the injection labels, even the one disabling injection when Inject
+	// is false, so that injection is decided by the defaults of the injector and any of the given Labels.
+	SkipInjectionLabels bool
 }
 
 // Instance represents an allocated namespace that can be used to create config, or deploy components in.
@@ -81,6 +94,28 @@ func NewOrFail(t test.Failer, ctx resource.Context, nsConfig Config) Instance {
 	return i
 }
 
+// Reset deletes the Istio configuration and the workloads deployed to the namespace, without deleting the
+// namespace itself. This is much faster than creating a new namespace for each test.
+func Reset(ctx resource.Context, ns Instance) (err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Native, func() {
+		// Native namespaces are imaginary, and have nothing to delete.
+		err = nil
+	})
+	ctx.Environment().Case(environment.Kube, func() {
+		err = resetKubeNamespace(ctx, ns)
+	})
+	return
+}
+
+// ResetOrFail calls Reset and fails test if it returns error
+func ResetOrFail(t test.Failer, ctx resource.Context, ns Instance) {
+	t.Helper()
+	if err := Reset(ctx, ns); err != nil {
+		t.Fatalf("namespace.ResetOrFail: %v", err)
+	}
+}
+
 // ClaimSystemNamespace retrieves the namespace for the Istio system components from the environment.
 func ClaimSystemNamespace(ctx resource.Context) (Instance, error) {
 	switch ctx.Environment().EnvironmentName() {
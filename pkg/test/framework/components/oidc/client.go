@@ -0,0 +1,89 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/fakes/oidc"
+)
+
+const (
+	tokenTimeout = 10 * time.Second
+)
+
+// client for the token endpoint of the fake OIDC provider.
+type client struct {
+	// address of the provider, in host:port form, as seen from the test.
+	address string
+}
+
+func (c *client) Token(opts TokenOptions) (string, error) {
+	form := url.Values{}
+	if opts.Subject != "" {
+		form.Set(oidc.SubjectParam, opts.Subject)
+	}
+	for _, aud := range opts.Audiences {
+		form.Add(oidc.AudienceParam, aud)
+	}
+	if opts.ExpiresIn != 0 {
+		form.Set(oidc.ExpiresInParam, strconv.Itoa(int(opts.ExpiresIn.Seconds())))
+	}
+	if len(opts.Claims) > 0 {
+		claims, err := json.Marshal(opts.Claims)
+		if err != nil {
+			return "", err
+		}
+		form.Set(oidc.ClaimsParam, string(claims))
+	}
+
+	httpClient := http.Client{
+		Timeout: tokenTimeout,
+	}
+	resp, err := httpClient.PostForm(fmt.Sprintf("http://%s%s", c.address, oidc.TokenPath), form)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc token endpoint returned %d: %s", resp.StatusCode, string(body))
+	}
+	token := oidc.TokenResponse{}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+func (c *client) TokenOrFail(t test.Failer, opts TokenOptions) string {
+	t.Helper()
+	token, err := c.Token(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"fmt"
+	"io"
+
+	"istio.io/istio/pkg/test/fakes/oidc"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+var (
+	_ Instance  = &nativeComponent{}
+	_ io.Closer = &nativeComponent{}
+)
+
+type nativeComponent struct {
+	id resource.ID
+
+	*client
+	server *oidc.Server
+}
+
+func newNative(ctx resource.Context, _ Config) (Instance, error) {
+	c := &nativeComponent{
+		client: &client{},
+	}
+	c.id = ctx.TrackResource(c)
+
+	var err error
+	scopes.CI.Infof("=== BEGIN: Start local OIDC provider ===")
+	defer func() {
+		if err != nil {
+			scopes.CI.Infof("=== FAILED: Start local OIDC provider ===")
+			_ = c.Close()
+		} else {
+			scopes.CI.Infof("=== SUCCEEDED: Start local OIDC provider ===")
+		}
+	}()
+
+	// Auto-allocate the port, and use the local address as the issuer.
+	if c.server, err = oidc.NewServer(0, ""); err != nil {
+		return nil, err
+	}
+	if err = c.server.Start(); err != nil {
+		return nil, err
+	}
+	c.client.address = fmt.Sprintf("127.0.0.1:%d", c.server.Port())
+	return c, nil
+}
+
+func (c *nativeComponent) ID() resource.ID {
+	return c.id
+}
+
+func (c *nativeComponent) Issuer() string {
+	return c.server.Issuer()
+}
+
+func (c *nativeComponent) DiscoveryURI() string {
+	return c.Issuer() + oidc.DiscoveryPath
+}
+
+func (c *nativeComponent) JWKSURI() string {
+	return c.Issuer() + oidc.JWKSPath
+}
+
+func (c *nativeComponent) Close() (err error) {
+	if c.server != nil {
+		err = c.server.Close()
+		c.server = nil
+	}
+	return
+}
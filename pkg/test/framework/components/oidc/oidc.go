@@ -0,0 +1,84 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Config for the OIDC provider.
+type Config struct {
+	// Namespace to deploy the provider to. If not set, a new namespace is created. Only used in the
+	// Kubernetes environment.
+	Namespace namespace.Instance
+}
+
+// TokenOptions for minting a token.
+type TokenOptions struct {
+	// Subject ("sub" claim) of the token.
+	Subject string
+	// Audiences ("aud" claim) of the token.
+	Audiences []string
+	// ExpiresIn is the lifetime of the token. Defaults to one hour. A negative value mints an expired token.
+	ExpiresIn time.Duration
+	// Claims added to the token. The standard claims set by the provider take precedence.
+	Claims map[string]interface{}
+}
+
+// Instance represents a deployed mock OpenID Connect provider. Its issuer can be used in the origins of
+// authentication policies without a jwksUri, in which case the key set is resolved through the discovery document.
+type Instance interface {
+	resource.Resource
+
+	// Issuer of the minted tokens. This is the URL of the provider as seen from within the cluster.
+	Issuer() string
+
+	// DiscoveryURI of the OpenID Connect discovery document, as seen from within the cluster.
+	DiscoveryURI() string
+
+	// JWKSURI of the key set, as seen from within the cluster.
+	JWKSURI() string
+
+	// Token mints a new signed token through the token endpoint of the provider.
+	Token(opts TokenOptions) (string, error)
+	TokenOrFail(t test.Failer, opts TokenOptions) string
+}
+
+// New returns a new instance of the OIDC provider.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Native, func() {
+		i, err = newNative(ctx, cfg)
+	})
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("oidc.NewOrFail: %v", err)
+	}
+	return i
+}
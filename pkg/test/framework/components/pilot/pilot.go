@@ -26,6 +26,7 @@ import (
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/galley"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
 )
 
 // TypeURL for making discovery requests.
@@ -50,6 +51,16 @@ type Instance interface {
 	StartDiscoveryOrFail(t test.Failer, req *xdsapi.DiscoveryRequest)
 	WatchDiscovery(duration time.Duration, accept func(*xdsapi.DiscoveryResponse) (bool, error)) error
 	WatchDiscoveryOrFail(t test.Failer, duration time.Duration, accept func(*xdsapi.DiscoveryResponse) (bool, error))
+
+	// GetXdsSnapshot returns the clusters, listeners, routes and endpoints served to the proxy with the given node ID.
+	GetXdsSnapshot(nodeID string) (*XdsSnapshot, error)
+	GetXdsSnapshotOrFail(t test.Failer, nodeID string) *XdsSnapshot
+
+	// WaitForXdsSnapshot fetches the snapshot of the proxy with the given node ID until it is accepted by the
+	// given handler.
+	WaitForXdsSnapshot(nodeID string, accept func(*XdsSnapshot) (bool, error), options ...retry.Option) error
+	WaitForXdsSnapshotOrFail(t test.Failer, nodeID string, accept func(*XdsSnapshot) (bool, error),
+		options ...retry.Option)
 }
 
 // Structured config for the Pilot component
@@ -0,0 +1,360 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	xdslistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	rbacHTTP "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rbac/v2"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	rbacTCP "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/rbac/v2"
+	adsapi "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/envoyproxy/go-control-plane/pkg/conversion"
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+
+	authzModel "istio.io/istio/pilot/pkg/security/authz/model"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	xdsFetchTimeout = 10 * time.Second
+
+	defaultXdsTimeout = 30 * time.Second
+	defaultXdsDelay   = time.Second
+)
+
+// XdsSnapshot is the configuration served by Pilot to a single proxy.
+type XdsSnapshot struct {
+	NodeID    string
+	Clusters  []*xdsapi.Cluster
+	Listeners []*xdsapi.Listener
+	Routes    []*xdsapi.RouteConfiguration
+	Endpoints []*xdsapi.ClusterLoadAssignment
+}
+
+// InboundListenerName returns the name of the inbound listener of the proxy with the given address, for the given
+// instance port.
+func InboundListenerName(address string, port int) string {
+	return fmt.Sprintf("%s_%d", address, port)
+}
+
+// Cluster returns the cluster with the given name, or nil if not found.
+func (s *XdsSnapshot) Cluster(name string) *xdsapi.Cluster {
+	for _, c := range s.Clusters {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Listener returns the listener with the given name, or nil if not found.
+func (s *XdsSnapshot) Listener(name string) *xdsapi.Listener {
+	for _, l := range s.Listeners {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// Route returns the route configuration with the given name, or nil if not found.
+func (s *XdsSnapshot) Route(name string) *xdsapi.RouteConfiguration {
+	for _, r := range s.Routes {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// ClusterLoadAssignment returns the endpoints of the cluster with the given name, or nil if not found.
+func (s *XdsSnapshot) ClusterLoadAssignment(clusterName string) *xdsapi.ClusterLoadAssignment {
+	for _, e := range s.Endpoints {
+		if e.ClusterName == clusterName {
+			return e
+		}
+	}
+	return nil
+}
+
+// HTTPFilters returns the names of the HTTP filters of all filter chains of the listener with the given name.
+func (s *XdsSnapshot) HTTPFilters(listenerName string) ([]string, error) {
+	l := s.Listener(listenerName)
+	if l == nil {
+		return nil, fmt.Errorf("listener %s not found for %s", listenerName, s.NodeID)
+	}
+
+	var out []string
+	for _, chain := range l.FilterChains {
+		for _, f := range chain.Filters {
+			if f.Name != xdsutil.HTTPConnectionManager {
+				continue
+			}
+			m := &hcm.HttpConnectionManager{}
+			if err := filterConfig(f, m); err != nil {
+				return nil, err
+			}
+			for _, hf := range m.HttpFilters {
+				out = append(out, hf.Name)
+			}
+		}
+	}
+	return out, nil
+}
+
+// CheckHTTPFilter returns an error unless the listener with the given name has the given HTTP filter.
+func (s *XdsSnapshot) CheckHTTPFilter(listenerName, filterName string) error {
+	filters, err := s.HTTPFilters(listenerName)
+	if err != nil {
+		return err
+	}
+	for _, f := range filters {
+		if f == filterName {
+			return nil
+		}
+	}
+	return fmt.Errorf("listener %s of %s: HTTP filter %s not found in %v", listenerName, s.NodeID, filterName, filters)
+}
+
+// RBACPolicies returns the names of the policies of the HTTP and network RBAC filters of the listener with the
+// given name.
+func (s *XdsSnapshot) RBACPolicies(listenerName string) ([]string, error) {
+	l := s.Listener(listenerName)
+	if l == nil {
+		return nil, fmt.Errorf("listener %s not found for %s", listenerName, s.NodeID)
+	}
+
+	var out []string
+	for _, chain := range l.FilterChains {
+		for _, f := range chain.Filters {
+			switch f.Name {
+			case authzModel.RBACTCPFilterName:
+				m := &rbacTCP.RBAC{}
+				if err := filterConfig(f, m); err != nil {
+					return nil, err
+				}
+				for name := range m.GetRules().GetPolicies() {
+					out = append(out, name)
+				}
+			case xdsutil.HTTPConnectionManager:
+				m := &hcm.HttpConnectionManager{}
+				if err := filterConfig(f, m); err != nil {
+					return nil, err
+				}
+				for _, hf := range m.HttpFilters {
+					if hf.Name != authzModel.RBACHTTPFilterName {
+						continue
+					}
+					rbac := &rbacHTTP.RBAC{}
+					if err := httpFilterConfig(hf, rbac); err != nil {
+						return nil, err
+					}
+					for name := range rbac.GetRules().GetPolicies() {
+						out = append(out, name)
+					}
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// CheckRBACPolicy returns an error unless the listener with the given name has an RBAC filter with the given
+// policy.
+func (s *XdsSnapshot) CheckRBACPolicy(listenerName, policy string) error {
+	policies, err := s.RBACPolicies(listenerName)
+	if err != nil {
+		return err
+	}
+	for _, p := range policies {
+		if p == policy {
+			return nil
+		}
+	}
+	return fmt.Errorf("listener %s of %s: RBAC policy %s not found in %v", listenerName, s.NodeID, policy, policies)
+}
+
+func filterConfig(f *xdslistener.Filter, out proto.Message) error {
+	if f.GetTypedConfig() != nil {
+		return ptypes.UnmarshalAny(f.GetTypedConfig(), out)
+	}
+	return conversion.StructToMessage(f.GetConfig(), out)
+}
+
+func httpFilterConfig(f *hcm.HttpFilter, out proto.Message) error {
+	if f.GetTypedConfig() != nil {
+		return ptypes.UnmarshalAny(f.GetTypedConfig(), out)
+	}
+	return conversion.StructToMessage(f.GetConfig(), out)
+}
+
+// GetXdsSnapshot returns the clusters, listeners, routes and endpoints Pilot serves to the proxy with the given
+// node ID. A separate stream is used, so the snapshot does not interfere with the discovery calls of the client.
+func (c *client) GetXdsSnapshot(nodeID string) (*XdsSnapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), xdsFetchTimeout)
+	defer cancel()
+
+	stream, err := adsapi.NewAggregatedDiscoveryServiceClient(c.conn).StreamAggregatedResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stream.CloseSend() }()
+
+	s := &XdsSnapshot{NodeID: nodeID}
+
+	resources, err := fetchXds(stream, nodeID, Cluster)
+	if err != nil {
+		return nil, err
+	}
+	var edsClusters []string
+	for _, r := range resources {
+		cluster := &xdsapi.Cluster{}
+		if err := ptypes.UnmarshalAny(r, cluster); err != nil {
+			return nil, err
+		}
+		s.Clusters = append(s.Clusters, cluster)
+		if cluster.GetType() == xdsapi.Cluster_EDS {
+			name := cluster.Name
+			if cluster.GetEdsClusterConfig().GetServiceName() != "" {
+				name = cluster.GetEdsClusterConfig().GetServiceName()
+			}
+			edsClusters = append(edsClusters, name)
+		}
+	}
+
+	if resources, err = fetchXds(stream, nodeID, Listener); err != nil {
+		return nil, err
+	}
+	var routeNames []string
+	for _, r := range resources {
+		l := &xdsapi.Listener{}
+		if err := ptypes.UnmarshalAny(r, l); err != nil {
+			return nil, err
+		}
+		s.Listeners = append(s.Listeners, l)
+		for _, chain := range l.FilterChains {
+			for _, f := range chain.Filters {
+				if f.Name != xdsutil.HTTPConnectionManager {
+					continue
+				}
+				m := &hcm.HttpConnectionManager{}
+				if err := filterConfig(f, m); err != nil {
+					return nil, err
+				}
+				if name := m.GetRds().GetRouteConfigName(); name != "" {
+					routeNames = append(routeNames, name)
+				}
+			}
+		}
+	}
+
+	if len(routeNames) > 0 {
+		if resources, err = fetchXds(stream, nodeID, Route, routeNames...); err != nil {
+			return nil, err
+		}
+		for _, r := range resources {
+			route := &xdsapi.RouteConfiguration{}
+			if err := ptypes.UnmarshalAny(r, route); err != nil {
+				return nil, err
+			}
+			s.Routes = append(s.Routes, route)
+		}
+	}
+
+	if len(edsClusters) > 0 {
+		if resources, err = fetchXds(stream, nodeID, ClusterLoadAssignment, edsClusters...); err != nil {
+			return nil, err
+		}
+		for _, r := range resources {
+			cla := &xdsapi.ClusterLoadAssignment{}
+			if err := ptypes.UnmarshalAny(r, cla); err != nil {
+				return nil, err
+			}
+			s.Endpoints = append(s.Endpoints, cla)
+		}
+	}
+
+	return s, nil
+}
+
+// GetXdsSnapshotOrFail calls GetXdsSnapshot and fails t if an error occurs.
+func (c *client) GetXdsSnapshotOrFail(t test.Failer, nodeID string) *XdsSnapshot {
+	t.Helper()
+	s, err := c.GetXdsSnapshot(nodeID)
+	if err != nil {
+		t.Fatalf("pilot.GetXdsSnapshotOrFail: %v", err)
+	}
+	return s
+}
+
+// WaitForXdsSnapshot fetches the snapshot of the proxy with the given node ID until it is accepted by the given
+// handler. An error returned by the handler causes a retry, a rejection stops the retries immediately.
+func (c *client) WaitForXdsSnapshot(nodeID string, accept func(*XdsSnapshot) (bool, error),
+	options ...retry.Option) error {
+	options = append([]retry.Option{retry.Delay(defaultXdsDelay), retry.Timeout(defaultXdsTimeout)}, options...)
+
+	_, err := retry.Do(func() (interface{}, bool, error) {
+		s, err := c.GetXdsSnapshot(nodeID)
+		if err != nil {
+			return nil, false, err
+		}
+		accepted, err := accept(s)
+		if err != nil {
+			return nil, false, err
+		}
+		if !accepted {
+			return nil, true, fmt.Errorf("xDS snapshot of %s rejected", nodeID)
+		}
+		return nil, true, nil
+	}, options...)
+	return err
+}
+
+// WaitForXdsSnapshotOrFail calls WaitForXdsSnapshot and fails t if an error occurs.
+func (c *client) WaitForXdsSnapshotOrFail(t test.Failer, nodeID string, accept func(*XdsSnapshot) (bool, error),
+	options ...retry.Option) {
+	t.Helper()
+	if err := c.WaitForXdsSnapshot(nodeID, accept, options...); err != nil {
+		t.Fatalf("pilot.WaitForXdsSnapshotOrFail: %v", err)
+	}
+}
+
+func fetchXds(stream adsapi.AggregatedDiscoveryService_StreamAggregatedResourcesClient, nodeID string,
+	typeURL TypeURL, resourceNames ...string) ([]*any.Any, error) {
+	req := NewDiscoveryRequest(nodeID, typeURL)
+	req.ResourceNames = resourceNames
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, fmt.Errorf("failed fetching %s for %s: %v", typeURL, nodeID, err)
+		}
+		// Skip the pushes of the other types, which Pilot may send at any time.
+		if resp.TypeUrl == string(typeURL) {
+			return resp.Resources, nil
+		}
+	}
+}
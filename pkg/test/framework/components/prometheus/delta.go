@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Delta measures how much the value of a query changes after a baseline was taken, for example
+// the increase of the request count during a test.
+type Delta struct {
+	prom     Instance
+	query    Query
+	baseline float64
+}
+
+// Query of the Delta.
+func (d *Delta) Query() Query {
+	return d.query
+}
+
+// Baseline value of the query, taken when the Delta was created.
+func (d *Delta) Baseline() float64 {
+	return d.baseline
+}
+
+// Value returns the current change of the value of the query since the baseline was taken.
+func (d *Delta) Value() (float64, error) {
+	v, err := d.prom.Value(d.query)
+	if err != nil {
+		return 0, err
+	}
+	return v - d.baseline, nil
+}
+
+// Eventually waits until the change of the value of the query is accepted by the given Check, and returns it.
+func (d *Delta) Eventually(check Check, opts ...retry.Option) (float64, error) {
+	v, err := retry.Do(func() (interface{}, bool, error) {
+		v, err := d.Value()
+		if err != nil {
+			return nil, false, err
+		}
+		if err := check(v); err != nil {
+			return nil, false, fmt.Errorf("delta of %s (baseline %v): %v", d.query, d.baseline, err)
+		}
+		return v, true, nil
+	}, append([]retry.Option{retryTimeout, eventuallyDelay}, opts...)...)
+	if err != nil {
+		return 0, err
+	}
+	return v.(float64), nil
+}
+
+// EventuallyOrFail calls Eventually and fails t if an error occurs.
+func (d *Delta) EventuallyOrFail(t test.Failer, check Check, opts ...retry.Option) float64 {
+	t.Helper()
+	v, err := d.Eventually(check, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -43,6 +43,9 @@ var (
 	retryTimeout = retry.Timeout(time.Second * 120)
 	retryDelay   = retry.Delay(time.Second * 20)
 
+	// eventuallyDelay is shorter than retryDelay, as the checks do not wait for the values to quiesce.
+	eventuallyDelay = retry.Delay(time.Second * 2)
+
 	_ Instance  = &kubeComponent{}
 	_ io.Closer = &kubeComponent{}
 )
@@ -68,12 +71,11 @@ func newKube(ctx resource.Context) (Instance, error) {
 		return nil, err
 	}
 
-	fetchFn := env.Accessor.NewSinglePodFetch(cfg.TelemetryNamespace, fmt.Sprintf("app=%s", appName))
-	pods, err := env.Accessor.WaitUntilPodsAreReady(fetchFn)
-	if err != nil {
+	selector := fmt.Sprintf("app=%s", appName)
+	fetchFn := env.Accessor.NewSinglePodFetch(cfg.TelemetryNamespace, selector)
+	if _, err := env.Accessor.WaitUntilPodsAreReady(fetchFn); err != nil {
 		return nil, err
 	}
-	pod := pods[0]
 
 	svc, err := env.Accessor.GetService(cfg.TelemetryNamespace, serviceName)
 	if err != nil {
@@ -81,11 +83,8 @@ func newKube(ctx resource.Context) (Instance, error) {
 	}
 	port := uint16(svc.Spec.Ports[0].Port)
 
-	forwarder, err := env.Accessor.NewPortForwarder(pod, 0, port)
-	if err != nil {
-		return nil, err
-	}
-
+	// Prometheus is queried throughout long suites, keep forwarding if the connection to the pod is lost.
+	forwarder := env.Accessor.NewManagedPortForwarder(cfg.TelemetryNamespace, port, selector)
 	if err := forwarder.Start(); err != nil {
 		return nil, err
 	}
@@ -255,6 +254,82 @@ func (c *kubeComponent) SumOrFail(t test.Failer, val model.Value, labels map[str
 	return v
 }
 
+func (c *kubeComponent) Value(q Query) (float64, error) {
+	query := fmt.Sprintf("sum(%s)", q)
+	scopes.Framework.Debugf("Value running: %q", query)
+
+	v, err := c.api.Query(context.Background(), query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("error querying Prometheus: %v", err)
+	}
+	scopes.Framework.Debugf("Value received: %v", v)
+
+	if v.Type() != model.ValVector {
+		return 0, fmt.Errorf("value not a model.Vector; was %s", v.Type().String())
+	}
+	sum := 0.0
+	for _, sample := range v.(model.Vector) {
+		sum += float64(sample.Value)
+	}
+	return sum, nil
+}
+
+func (c *kubeComponent) ValueOrFail(t test.Failer, q Query) float64 {
+	t.Helper()
+	v, err := c.Value(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func (c *kubeComponent) EventuallyValue(q Query, check Check, opts ...retry.Option) (float64, error) {
+	v, err := retry.Do(func() (interface{}, bool, error) {
+		v, err := c.Value(q)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := check(v); err != nil {
+			return nil, false, fmt.Errorf("value of %s: %v", q, err)
+		}
+		return v, true, nil
+	}, append([]retry.Option{retryTimeout, eventuallyDelay}, opts...)...)
+	if err != nil {
+		return 0, err
+	}
+	return v.(float64), nil
+}
+
+func (c *kubeComponent) EventuallyValueOrFail(t test.Failer, q Query, check Check, opts ...retry.Option) float64 {
+	t.Helper()
+	v, err := c.EventuallyValue(q, check, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func (c *kubeComponent) Delta(q Query) (*Delta, error) {
+	baseline, err := c.Value(q)
+	if err != nil {
+		return nil, err
+	}
+	return &Delta{
+		prom:     c,
+		query:    q,
+		baseline: baseline,
+	}, nil
+}
+
+func (c *kubeComponent) DeltaOrFail(t test.Failer, q Query) *Delta {
+	t.Helper()
+	d, err := c.Delta(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
 // Close implements io.Closer.
 func (c *kubeComponent) Close() error {
 	return c.forwarder.Close()
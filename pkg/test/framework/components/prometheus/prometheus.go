@@ -21,6 +21,7 @@ import (
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
 )
 
 type Instance interface {
@@ -40,6 +41,18 @@ type Instance interface {
 	// Sum all the samples that has the given labels in the given vector value.
 	Sum(val prom.Value, labels map[string]string) (float64, error)
 	SumOrFail(t test.Failer, val prom.Value, labels map[string]string) float64
+
+	// Value returns the sum of the samples of the given query, or zero if there are none.
+	Value(q Query) (float64, error)
+	ValueOrFail(t test.Failer, q Query) float64
+
+	// EventuallyValue waits until the value of the given query is accepted by the given Check, and returns it.
+	EventuallyValue(q Query, check Check, opts ...retry.Option) (float64, error)
+	EventuallyValueOrFail(t test.Failer, q Query, check Check, opts ...retry.Option) float64
+
+	// Delta takes the current value of the given query as the baseline for measuring its change.
+	Delta(q Query) (*Delta, error)
+	DeltaOrFail(t test.Failer, q Query) *Delta
 }
 
 // New returns a new instance of echo.
@@ -0,0 +1,124 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// RequestsTotal is the Istio metric counting the HTTP and gRPC requests.
+	RequestsTotal = "istio_requests_total"
+	// TCPConnectionsOpenedTotal is the Istio metric counting the opened TCP connections.
+	TCPConnectionsOpenedTotal = "istio_tcp_connections_opened_total"
+
+	// MutualTLS and None are the values of the connection_security_policy label of the Istio metrics.
+	MutualTLS = "mutual_tls"
+	None      = "none"
+
+	// ReporterSource and ReporterDestination are the values of the reporter label of the Istio metrics.
+	ReporterSource      = "source"
+	ReporterDestination = "destination"
+)
+
+// Query for a metric, selecting the samples with the given labels.
+type Query struct {
+	Metric string
+	Labels map[string]string
+}
+
+// NewQuery returns a Query for the given metric without labels.
+func NewQuery(metric string) Query {
+	return Query{
+		Metric: metric,
+		Labels: make(map[string]string),
+	}
+}
+
+// With returns a copy of the Query that also selects the given label value.
+func (q Query) With(label, value string) Query {
+	out := NewQuery(q.Metric)
+	for k, v := range q.Labels {
+		out.Labels[k] = v
+	}
+	out.Labels[label] = value
+	return out
+}
+
+// Source returns a copy of the Query that selects the samples of the given source workload.
+func (q Query) Source(workload, namespace string) Query {
+	return q.With("source_workload", workload).With("source_workload_namespace", namespace)
+}
+
+// Destination returns a copy of the Query that selects the samples of the given destination workload.
+func (q Query) Destination(workload, namespace string) Query {
+	return q.With("destination_workload", workload).With("destination_workload_namespace", namespace)
+}
+
+// ConnectionSecurityPolicy returns a copy of the Query that selects the samples with the given connection
+// security policy, either MutualTLS or None.
+func (q Query) ConnectionSecurityPolicy(policy string) Query {
+	return q.With("connection_security_policy", policy)
+}
+
+// ResponseCode returns a copy of the Query that selects the requests with the given response code.
+func (q Query) ResponseCode(code int) Query {
+	return q.With("response_code", fmt.Sprintf("%d", code))
+}
+
+// Reporter returns a copy of the Query that selects the samples of the given reporter, either
+// ReporterSource or ReporterDestination.
+func (q Query) Reporter(reporter string) Query {
+	return q.With("reporter", reporter)
+}
+
+// String returns the PromQL of the query, for example
+// istio_requests_total{connection_security_policy="mutual_tls",reporter="destination"}.
+func (q Query) String() string {
+	if len(q.Labels) == 0 {
+		return q.Metric
+	}
+	labels := make([]string, 0, len(q.Labels))
+	for k, v := range q.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%q", k, v))
+	}
+	sort.Strings(labels)
+	return fmt.Sprintf("%s{%s}", q.Metric, strings.Join(labels, ","))
+}
+
+// Check of the value of a query.
+type Check func(value float64) error
+
+// Equals returns a Check that accepts the given value.
+func Equals(want float64) Check {
+	return func(value float64) error {
+		if value != want {
+			return fmt.Errorf("expected %v, got %v", want, value)
+		}
+		return nil
+	}
+}
+
+// AtLeast returns a Check that accepts the values greater than or equal to the given minimum.
+func AtLeast(min float64) Check {
+	return func(value float64) error {
+		if value < min {
+			return fmt.Errorf("expected at least %v, got %v", min, value)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	base := NewQuery(RequestsTotal)
+	q := base.
+		ConnectionSecurityPolicy(MutualTLS).
+		Destination("b-v1", "apps").
+		ResponseCode(200)
+
+	if got := base.String(); got != RequestsTotal {
+		t.Fatalf("base query was modified: %s", got)
+	}
+	want := `istio_requests_total{connection_security_policy="mutual_tls",destination_workload="b-v1",` +
+		`destination_workload_namespace="apps",response_code="200"}`
+	if got := q.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestChecks(t *testing.T) {
+	if err := Equals(2)(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := Equals(2)(3); err == nil {
+		t.Fatal("expected Equals to fail")
+	}
+	if err := AtLeast(2)(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := AtLeast(2)(1); err == nil {
+		t.Fatal("expected AtLeast to fail")
+	}
+}
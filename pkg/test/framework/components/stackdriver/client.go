@@ -0,0 +1,199 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stackdriver
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	loggingpb "google.golang.org/genproto/googleapis/logging/v2"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/fakes/stackdriver"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	adminTimeout = 10 * time.Second
+)
+
+var (
+	retryTimeout = retry.Timeout(2 * time.Minute)
+	retryDelay   = retry.Delay(2 * time.Second)
+)
+
+// client for the admin API of the fake Stackdriver server.
+type client struct {
+	// address of the admin API, in host:port form.
+	address string
+}
+
+func (c *client) ListTimeSeries() ([]*monitoringpb.TimeSeries, error) {
+	resp := &monitoringpb.ListTimeSeriesResponse{}
+	if err := c.get(stackdriver.TimeSeriesPath, resp); err != nil {
+		return nil, err
+	}
+	return resp.TimeSeries, nil
+}
+
+func (c *client) ListTimeSeriesOrFail(t test.Failer) []*monitoringpb.TimeSeries {
+	t.Helper()
+	ts, err := c.ListTimeSeries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func (c *client) ListLogEntries() ([]*loggingpb.LogEntry, error) {
+	resp := &loggingpb.ListLogEntriesResponse{}
+	if err := c.get(stackdriver.LogEntriesPath, resp); err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
+}
+
+func (c *client) ListLogEntriesOrFail(t test.Failer) []*loggingpb.LogEntry {
+	t.Helper()
+	entries, err := c.ListLogEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func (c *client) WaitForTimeSeries(metricType string, labels map[string]string, opts ...retry.Option) (*monitoringpb.TimeSeries, error) {
+	ts, err := retry.Do(func() (interface{}, bool, error) {
+		all, err := c.ListTimeSeries()
+		if err != nil {
+			return nil, false, err
+		}
+		for _, ts := range all {
+			if ts.Metric != nil && ts.Metric.Type == metricType && containsLabels(ts.Metric.Labels, labels) {
+				return ts, true, nil
+			}
+		}
+		return nil, false, fmt.Errorf("no time series of %s with labels %v in %d received time series",
+			metricType, labels, len(all))
+	}, append([]retry.Option{retryTimeout, retryDelay}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return ts.(*monitoringpb.TimeSeries), nil
+}
+
+func (c *client) WaitForTimeSeriesOrFail(t test.Failer, metricType string, labels map[string]string,
+	opts ...retry.Option) *monitoringpb.TimeSeries {
+	t.Helper()
+	ts, err := c.WaitForTimeSeries(metricType, labels, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func (c *client) WaitForLogEntry(logName string, labels map[string]string, opts ...retry.Option) (*loggingpb.LogEntry, error) {
+	e, err := retry.Do(func() (interface{}, bool, error) {
+		all, err := c.ListLogEntries()
+		if err != nil {
+			return nil, false, err
+		}
+		for _, e := range all {
+			if matchesLogName(e.LogName, logName) && containsLabels(e.Labels, labels) {
+				return e, true, nil
+			}
+		}
+		return nil, false, fmt.Errorf("no log entry of %s with labels %v in %d received log entries",
+			logName, labels, len(all))
+	}, append([]retry.Option{retryTimeout, retryDelay}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return e.(*loggingpb.LogEntry), nil
+}
+
+func (c *client) WaitForLogEntryOrFail(t test.Failer, logName string, labels map[string]string,
+	opts ...retry.Option) *loggingpb.LogEntry {
+	t.Helper()
+	e, err := c.WaitForLogEntry(logName, labels, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func (c *client) Reset() error {
+	_, err := c.do(http.MethodPost, stackdriver.ResetPath)
+	return err
+}
+
+func (c *client) ResetOrFail(t test.Failer) {
+	t.Helper()
+	if err := c.Reset(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func containsLabels(actual, expected map[string]string) bool {
+	for k, v := range expected {
+		if actual[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesLogName(actual, expected string) bool {
+	return actual == expected || strings.HasSuffix(actual, "/logs/"+expected)
+}
+
+func (c *client) get(path string, out proto.Message) error {
+	body, err := c.do(http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+	return (&jsonpb.Unmarshaler{AllowUnknownFields: true}).Unmarshal(bytes.NewReader(body), out)
+}
+
+func (c *client) do(method, path string) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://%s%s", c.address, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	httpClient := http.Client{
+		Timeout: adminTimeout,
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stackdriver admin %s %s returned %d: %s", method, path, resp.StatusCode, string(out))
+	}
+	return out, nil
+}
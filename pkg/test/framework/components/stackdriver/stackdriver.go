@@ -0,0 +1,88 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stackdriver deploys a fake Stackdriver server, for running the telemetry tests of the Stackdriver
+// extensions of the proxies hermetically.
+package stackdriver
+
+import (
+	loggingpb "google.golang.org/genproto/googleapis/logging/v2"
+	monitoringpb "google.golang.org/genproto/googleapis/monitoring/v3"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Config for the fake Stackdriver server.
+type Config struct {
+	// Namespace to deploy the server to. If not set, a new namespace is created. Only used in the
+	// Kubernetes environment.
+	Namespace namespace.Instance
+}
+
+// Instance represents a deployed fake Stackdriver server, receiving the metrics and log entries reported
+// through the Cloud Monitoring and Cloud Logging APIs.
+type Instance interface {
+	resource.Resource
+
+	// Address of the gRPC endpoint of the Stackdriver APIs, in host:port form, as seen from the workloads.
+	Address() string
+
+	// ListTimeSeries returns all time series received so far.
+	ListTimeSeries() ([]*monitoringpb.TimeSeries, error)
+	ListTimeSeriesOrFail(t test.Failer) []*monitoringpb.TimeSeries
+
+	// ListLogEntries returns all log entries received so far.
+	ListLogEntries() ([]*loggingpb.LogEntry, error)
+	ListLogEntriesOrFail(t test.Failer) []*loggingpb.LogEntry
+
+	// WaitForTimeSeries waits until a time series of the given metric type, whose metric labels include the
+	// given labels, is received and returns it.
+	WaitForTimeSeries(metricType string, labels map[string]string, opts ...retry.Option) (*monitoringpb.TimeSeries, error)
+	WaitForTimeSeriesOrFail(t test.Failer, metricType string, labels map[string]string, opts ...retry.Option) *monitoringpb.TimeSeries
+
+	// WaitForLogEntry waits until a log entry of the given log, whose labels include the given labels, is
+	// received and returns it. The log name matches the full name or its suffix after "/logs/".
+	WaitForLogEntry(logName string, labels map[string]string, opts ...retry.Option) (*loggingpb.LogEntry, error)
+	WaitForLogEntryOrFail(t test.Failer, logName string, labels map[string]string, opts ...retry.Option) *loggingpb.LogEntry
+
+	// Reset discards the time series and log entries received so far.
+	Reset() error
+	ResetOrFail(t test.Failer)
+}
+
+// New returns a new instance of the fake Stackdriver server.
+func New(ctx resource.Context, cfg Config) (i Instance, err error) {
+	err = resource.UnsupportedEnvironment(ctx.Environment())
+	ctx.Environment().Case(environment.Native, func() {
+		i, err = newNative(ctx)
+	})
+	ctx.Environment().Case(environment.Kube, func() {
+		i, err = newKube(ctx, cfg)
+	})
+	return
+}
+
+// NewOrFail calls New and fails test if it returns an error.
+func NewOrFail(t test.Failer, ctx resource.Context, cfg Config) Instance {
+	t.Helper()
+	i, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatalf("stackdriver.NewOrFail: %v", err)
+	}
+	return i
+}
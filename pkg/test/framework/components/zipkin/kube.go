@@ -21,20 +21,27 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"sort"
 	"time"
 
+	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
 	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/resource"
 	testKube "istio.io/istio/pkg/test/kube"
 	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
 )
 
 const (
 	appName    = "zipkin"
 	tracesAPI  = "/api/v2/traces?limit=%d&spanName=%s&annotationQuery=%s"
+	serviceAPI = "/api/v2/traces?limit=%d&serviceName=%s"
 	zipkinPort = 9411
+
+	// defaultLimit of the number of traces queried by WaitForTrace.
+	defaultLimit = 100
 )
 
 var (
@@ -89,12 +96,59 @@ func (c *kubeComponent) ID() resource.ID {
 }
 
 func (c *kubeComponent) QueryTraces(limit int, spanName, annotationQuery string) ([]Trace, error) {
-	// Get 100 most recent traces
+	return c.queryTraces(fmt.Sprintf(tracesAPI, limit, spanName, annotationQuery))
+}
+
+func (c *kubeComponent) QueryTracesByService(serviceName string, limit int) ([]Trace, error) {
+	return c.queryTraces(fmt.Sprintf(serviceAPI, limit, url.QueryEscape(serviceName)))
+}
+
+func (c *kubeComponent) QueryTracesByServiceOrFail(t test.Failer, serviceName string, limit int) []Trace {
+	t.Helper()
+	traces, err := c.QueryTracesByService(serviceName, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return traces
+}
+
+func (c *kubeComponent) WaitForTrace(serviceName string, check func(Trace) error, opts ...retry.Option) (Trace, error) {
+	trace, err := retry.Do(func() (interface{}, bool, error) {
+		traces, err := c.QueryTracesByService(serviceName, defaultLimit)
+		if err != nil {
+			return nil, false, err
+		}
+		var errs []string
+		for _, t := range traces {
+			if err := check(t); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			return t, true, nil
+		}
+		return nil, false, fmt.Errorf("no matching trace of %s in %d traces: %v", serviceName, len(traces), errs)
+	}, append([]retry.Option{retry.Delay(3 * time.Second), retry.Timeout(80 * time.Second)}, opts...)...)
+	if err != nil {
+		return Trace{}, err
+	}
+	return trace.(Trace), nil
+}
+
+func (c *kubeComponent) WaitForTraceOrFail(t test.Failer, serviceName string, check func(Trace) error, opts ...retry.Option) Trace {
+	t.Helper()
+	trace, err := c.WaitForTrace(serviceName, check, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trace
+}
+
+func (c *kubeComponent) queryTraces(query string) ([]Trace, error) {
 	client := http.Client{
 		Timeout: 5 * time.Second,
 	}
-	scopes.Framework.Debugf("make get call to zipkin api %v", c.address+fmt.Sprintf(tracesAPI, limit, spanName, annotationQuery))
-	resp, err := client.Get(c.address + fmt.Sprintf(tracesAPI, limit, spanName, annotationQuery))
+	scopes.Framework.Debugf("make get call to zipkin api %v", c.address+query)
+	resp, err := client.Get(c.address + query)
 	if err != nil {
 		scopes.Framework.Debugf("zipking err %v", err)
 		return nil, err
@@ -159,6 +213,9 @@ func extractTraces(resp []byte) ([]Trace, error) {
 func buildSpan(obj interface{}) Span {
 	var s Span
 	spanSpec := obj.(map[string]interface{})
+	if traceID, ok := spanSpec["traceId"]; ok {
+		s.TraceID = traceID.(string)
+	}
 	if spanID, ok := spanSpec["id"]; ok {
 		s.SpanID = spanID.(string)
 	}
@@ -173,5 +230,16 @@ func buildSpan(obj interface{}) Span {
 	if name, ok := spanSpec["name"]; ok {
 		s.Name = name.(string)
 	}
+	if kind, ok := spanSpec["kind"]; ok {
+		s.Kind = kind.(string)
+	}
+	if tagsObj, ok := spanSpec["tags"]; ok {
+		if tags, ok := tagsObj.(map[string]interface{}); ok {
+			s.Tags = make(map[string]string, len(tags))
+			for k, v := range tags {
+				s.Tags[k] = fmt.Sprint(v)
+			}
+		}
+	}
 	return s
 }
@@ -0,0 +1,93 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"fmt"
+)
+
+const (
+	// KindClient and KindServer are the kinds of the spans reported by the sidecars for outbound and
+	// inbound requests.
+	KindClient = "CLIENT"
+	KindServer = "SERVER"
+)
+
+// Find returns the spans of the given service and kind. An empty kind matches all spans of the service.
+func (t Trace) Find(serviceName, kind string) []*Span {
+	var out []*Span
+	for i := range t.Spans {
+		s := &t.Spans[i]
+		if s.ServiceName == serviceName && (kind == "" || s.Kind == kind) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Parent returns the parent of the given span, or nil if the span is a root span or its parent was not
+// reported.
+func (t Trace) Parent(s *Span) *Span {
+	if s.ParentSpanID == "" {
+		return nil
+	}
+	for i := range t.Spans {
+		if t.Spans[i].SpanID == s.ParentSpanID {
+			return &t.Spans[i]
+		}
+	}
+	return nil
+}
+
+// CheckParentage verifies that the trace contains a span of the child service whose parent is a span of the
+// parent service, for example a server span of the destination of a request that is a child of the client
+// span of its source. This shows that the trace context was propagated along with the request.
+func (t Trace) CheckParentage(childService, parentService string) error {
+	children := t.Find(childService, "")
+	if len(children) == 0 {
+		return fmt.Errorf("no span of %s found in trace %s", childService, t)
+	}
+	for _, c := range children {
+		if p := t.Parent(c); p != nil && p.ServiceName == parentService {
+			return nil
+		}
+	}
+	return fmt.Errorf("no span of %s has a parent span of %s in trace %s", childService, parentService, t)
+}
+
+// CheckTag verifies that the span has the given tag value.
+func (s Span) CheckTag(key, value string) error {
+	got, ok := s.Tags[key]
+	if !ok {
+		return fmt.Errorf("span %s of %s has no tag %q", s.Name, s.ServiceName, key)
+	}
+	if got != value {
+		return fmt.Errorf("span %s of %s tag %q: expected %q, got %q", s.Name, s.ServiceName, key, value, got)
+	}
+	return nil
+}
+
+// String implements fmt.Stringer
+func (t Trace) String() string {
+	id := ""
+	if len(t.Spans) > 0 {
+		id = t.Spans[0].TraceID
+	}
+	out := fmt.Sprintf("%s:", id)
+	for _, s := range t.Spans {
+		out += fmt.Sprintf(" [%s %s %s id=%s parent=%s]", s.ServiceName, s.Kind, s.Name, s.SpanID, s.ParentSpanID)
+	}
+	return out
+}
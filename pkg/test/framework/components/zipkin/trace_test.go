@@ -0,0 +1,58 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"testing"
+)
+
+const tracesJSON = `[[
+  {"traceId":"t1","id":"1","kind":"CLIENT","name":"b.apps.svc.cluster.local:80/*",
+   "localEndpoint":{"serviceName":"a.apps"},"tags":{"http.status_code":"200","upstream_cluster":"outbound|80||b.apps.svc.cluster.local"}},
+  {"traceId":"t1","id":"2","parentId":"1","kind":"SERVER","name":"b.apps.svc.cluster.local:80/*",
+   "localEndpoint":{"serviceName":"b.apps"},"tags":{"http.status_code":"200"}}
+]]`
+
+func TestTraceAssertions(t *testing.T) {
+	traces, err := extractTraces([]byte(tracesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 1 {
+		t.Fatalf("expected 1 trace, got %d", len(traces))
+	}
+	trace := traces[0]
+
+	if err := trace.CheckParentage("b.apps", "a.apps"); err != nil {
+		t.Fatal(err)
+	}
+	if err := trace.CheckParentage("a.apps", "b.apps"); err == nil {
+		t.Fatal("expected the client span to have no parent")
+	}
+
+	servers := trace.Find("b.apps", KindServer)
+	if len(servers) != 1 {
+		t.Fatalf("expected 1 server span of b, got %d", len(servers))
+	}
+	if err := servers[0].CheckTag("http.status_code", "200"); err != nil {
+		t.Fatal(err)
+	}
+	if err := servers[0].CheckTag("http.status_code", "403"); err == nil {
+		t.Fatal("expected CheckTag to fail")
+	}
+	if len(servers[0].ChildSpans) != 0 || len(trace.Find("a.apps", KindClient)[0].ChildSpans) != 1 {
+		t.Fatalf("unexpected child spans in trace %s", trace)
+	}
+}
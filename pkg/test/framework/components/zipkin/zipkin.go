@@ -15,10 +15,11 @@
 package zipkin
 
 import (
-	"testing"
-
+	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
 )
 
 // Instance represents a zipkin deployment on kube
@@ -28,16 +29,30 @@ type Instance interface {
 	// QueryTraces gets at most number of limit most recent available traces from zipkin.
 	// spanName filters that only trace with the given span name will be included.
 	QueryTraces(limit int, spanName, annotationQuery string) ([]Trace, error)
+
+	// QueryTracesByService gets at most number of limit most recent traces that include a span of the given
+	// service, for example "b.apps" for the sidecar of the echo service b in namespace apps.
+	QueryTracesByService(serviceName string, limit int) ([]Trace, error)
+	QueryTracesByServiceOrFail(t test.Failer, serviceName string, limit int) []Trace
+
+	// WaitForTrace waits until one of the recent traces of the given service is accepted by the given check,
+	// and returns it. Envoy reports spans periodically, so traces may show up with a delay of a few seconds.
+	WaitForTrace(serviceName string, check func(Trace) error, opts ...retry.Option) (Trace, error)
+	WaitForTraceOrFail(t test.Failer, serviceName string, check func(Trace) error, opts ...retry.Option) Trace
 }
 
 // Span represents a single span, which includes span attributes for verification
 // TODO(bianpengyuan) consider using zipkin proto api https://github.com/istio/istio/issues/13926
 type Span struct {
+	TraceID      string
 	SpanID       string
 	ParentSpanID string
 	ServiceName  string
 	Name         string
-	ChildSpans   []*Span
+	// Kind of the span, either "CLIENT" or "SERVER" for the spans reported by Envoy.
+	Kind       string
+	Tags       map[string]string
+	ChildSpans []*Span
 }
 
 // Trace represents a trace by a collection of spans which all belong to that trace
@@ -45,6 +60,18 @@ type Trace struct {
 	Spans []Span
 }
 
+// SetupConfig is an istio.SetupConfigFn that installs zipkin along with Istio and enables the tracing of
+// all requests by the sidecars and gateways.
+func SetupConfig(cfg *istio.Config) {
+	if cfg == nil {
+		return
+	}
+	cfg.Values["tracing.enabled"] = "true"
+	cfg.Values["tracing.provider"] = "zipkin"
+	cfg.Values["global.enableTracing"] = "true"
+	cfg.Values["pilot.traceSampling"] = "100.0"
+}
+
 // New returns a new instance of zipkin.
 func New(ctx resource.Context) (i Instance, err error) {
 	err = resource.UnsupportedEnvironment(ctx.Environment())
@@ -55,7 +82,7 @@ func New(ctx resource.Context) (i Instance, err error) {
 }
 
 // NewOrFail returns a new zipkin instance or fails test.
-func NewOrFail(t *testing.T, ctx resource.Context) Instance {
+func NewOrFail(t test.Failer, ctx resource.Context) Instance {
 	t.Helper()
 	i, err := New(ctx)
 	if err != nil {
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 
+	"istio.io/istio/pkg/test/framework/features"
 	"istio.io/istio/pkg/test/framework/label"
 
 	"istio.io/istio/pkg/test/framework/components/environment"
@@ -44,6 +45,12 @@ func SettingsFromCommandLine(testID string) (*Settings, error) {
 	}
 	s.Selector = f
 
+	fs, err := features.ParseSelector(s.FeatureSelectorString)
+	if err != nil {
+		return nil, err
+	}
+	s.FeatureSelector = fs
+
 	return s, nil
 }
 
@@ -58,9 +65,21 @@ func init() {
 	flag.BoolVar(&settingsFromCommandLine.NoCleanup, "istio.test.nocleanup", settingsFromCommandLine.NoCleanup,
 		"Do not cleanup resources after test completion")
 
+	flag.BoolVar(&settingsFromCommandLine.NoCleanupOnFailure, "istio.test.nocleanup-on-failure",
+		settingsFromCommandLine.NoCleanupOnFailure,
+		"Do not cleanup the resources of failed tests, and of the suite if any test failed, for investigating failures")
+
+	flag.BoolVar(&settingsFromCommandLine.RetryFlaky, "istio.test.retry-flaky", settingsFromCommandLine.RetryFlaky,
+		"Retry failed subtests once with more diagnostics, reporting the ones that pass on retry as flaky")
+
 	flag.BoolVar(&settingsFromCommandLine.CIMode, "istio.test.ci", settingsFromCommandLine.CIMode,
 		"Enable CI Mode. Additional logging and state dumping will be enabled.")
 
 	flag.StringVar(&settingsFromCommandLine.SelectorString, "istio.test.select", settingsFromCommandLine.SelectorString,
 		"Comma separated list of labels for selecting tests to run (e.g. 'foo,+bar-baz').")
+
+	flag.StringVar(&settingsFromCommandLine.FeatureSelectorString, "istio.test.features",
+		settingsFromCommandLine.FeatureSelectorString,
+		"Comma separated list of features for selecting tests to run. Features prefixed with '-' are skipped, "+
+			"and each feature covers the features below it (e.g. 'security.authn,-security.authn.vm').")
 }
@@ -27,6 +27,8 @@ var (
 		Hub:        env.HUB.Value(),
 		Tag:        env.TAG.Value(),
 		PullPolicy: env.PULL_POLICY.Value(),
+
+		FaketimeLibrary: DefaultFaketimeLibrary,
 	}
 )
 
@@ -57,4 +59,8 @@ func init() {
 		"Common Container tag to use when deploying container images")
 	flag.StringVar(&settingsFromCommandLine.PullPolicy, "istio.test.pullpolicy", settingsFromCommandLine.PullPolicy,
 		"Common image pull policy to use when deploying container images")
+	flag.StringVar(&settingsFromCommandLine.FaketimeImage, "istio.test.faketime.image", settingsFromCommandLine.FaketimeImage,
+		"Image containing libfaketime, required for echo workloads with a skewed clock")
+	flag.StringVar(&settingsFromCommandLine.FaketimeLibrary, "istio.test.faketime.library", settingsFromCommandLine.FaketimeLibrary,
+		"Location of libfaketime in the image of --istio.test.faketime.image")
 }
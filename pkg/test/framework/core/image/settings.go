@@ -28,6 +28,9 @@ const (
 
 	// LatestTag value
 	LatestTag = "latest"
+
+	// DefaultFaketimeLibrary is the location of libfaketime in images with the Debian faketime package.
+	DefaultFaketimeLibrary = "/usr/lib/x86_64-linux-gnu/faketime/libfaketime.so.1"
 )
 
 // Settings provide kube-specific Settings from flags.
@@ -40,6 +43,12 @@ type Settings struct {
 
 	// Image pull policy to use for deployments. If not specified, the defaults of each deployment will be used.
 	PullPolicy string
+
+	// FaketimeImage containing libfaketime, which is copied into the pods of workloads with a skewed clock.
+	FaketimeImage string
+
+	// FaketimeLibrary is the location of libfaketime in the FaketimeImage.
+	FaketimeLibrary string
 }
 
 func (s *Settings) clone() *Settings {
@@ -54,6 +63,8 @@ func (s *Settings) String() string {
 	result += fmt.Sprintf("Hub:             %s\n", s.Hub)
 	result += fmt.Sprintf("Tag:             %s\n", s.Tag)
 	result += fmt.Sprintf("PullPolicy:      %s\n", s.PullPolicy)
+	result += fmt.Sprintf("FaketimeImage:   %s\n", s.FaketimeImage)
+	result += fmt.Sprintf("FaketimeLibrary: %s\n", s.FaketimeLibrary)
 
 	return result
 }
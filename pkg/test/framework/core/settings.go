@@ -19,6 +19,7 @@ import (
 	"path"
 	"strings"
 
+	"istio.io/istio/pkg/test/framework/features"
 	"istio.io/istio/pkg/test/framework/label"
 
 	"istio.io/istio/pkg/test/framework/components/environment"
@@ -44,6 +45,14 @@ type Settings struct {
 	// Do not cleanup the resources after the test run.
 	NoCleanup bool
 
+	// Do not cleanup the resources of failed tests, nor the suite resources if any test failed, so that the
+	// failure can be investigated on the live system.
+	NoCleanupOnFailure bool
+
+	// RetryFlaky retries failed subtests once, with more diagnostics, and reports the ones passing on retry as
+	// flaky instead of failed.
+	RetryFlaky bool
+
 	// Indicates that the tests are running in CI Mode
 	CIMode bool
 
@@ -56,6 +65,12 @@ type Settings struct {
 
 	// The label selector, in parsed form.
 	Selector label.Selector
+
+	// The feature selector that the user has specified.
+	FeatureSelectorString string
+
+	// The feature selector, in parsed form.
+	FeatureSelector features.Selector
 }
 
 // RunDir is the name of the dir to output, for this particular run.
@@ -94,7 +109,10 @@ func (s *Settings) String() string {
 	result += fmt.Sprintf("TestID:       %s\n", s.TestID)
 	result += fmt.Sprintf("RunID:        %s\n", s.RunID.String())
 	result += fmt.Sprintf("NoCleanup:    %v\n", s.NoCleanup)
+	result += fmt.Sprintf("NoCleanupOnFailure: %v\n", s.NoCleanupOnFailure)
+	result += fmt.Sprintf("RetryFlaky:   %v\n", s.RetryFlaky)
 	result += fmt.Sprintf("BaseDir:      %s\n", s.BaseDir)
 	result += fmt.Sprintf("Selector:     %v\n", s.Selector)
+	result += fmt.Sprintf("Features:     %v\n", s.FeatureSelector)
 	return result
 }
@@ -0,0 +1,125 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package features labels tests with the hierarchical names of the features they cover, such as
+// "security.authz.deny", so that runs can be restricted to the tests of some features.
+package features
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Feature covered by a test. Features are dot separated hierarchical names, where each name covers all of the
+// names below it, e.g. "security.authz" covers "security.authz.deny".
+type Feature string
+
+var featureRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*(\.[a-zA-Z0-9_-]+)*$`)
+
+// Covers returns true if the feature is the given feature or one of its parents.
+func (f Feature) Covers(other Feature) bool {
+	return f == other || strings.HasPrefix(string(other), string(f)+".")
+}
+
+// Selector of tests by the features they cover.
+type Selector struct {
+	// Tests must cover at least one of the included features, if any.
+	included []Feature
+	// Tests must not cover any of the excluded features.
+	excluded []Feature
+}
+
+var _ fmt.Stringer = Selector{}
+
+// ParseSelector parses a comma separated list of features. Features prefixed with '-' are excluded, all others
+// (optionally prefixed with '+') are included. For example, "security.authn,-security.authn.vm" selects the
+// tests of authentication features, except the ones covering VMs.
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if len(p) == 0 {
+			continue
+		}
+
+		excluded := false
+		switch p[0] {
+		case '-':
+			excluded = true
+			p = p[1:]
+		case '+':
+			p = p[1:]
+		}
+
+		if !featureRegex.MatchString(p) {
+			return Selector{}, fmt.Errorf("invalid feature name: %q", p)
+		}
+		if excluded {
+			sel.excluded = append(sel.excluded, Feature(p))
+		} else {
+			sel.included = append(sel.included, Feature(p))
+		}
+	}
+
+	for _, i := range sel.included {
+		for _, e := range sel.excluded {
+			if i == e {
+				return Selector{}, fmt.Errorf("conflicting feature selector specification: %q", s)
+			}
+		}
+	}
+	return sel, nil
+}
+
+// Selects returns true if a test covering the given features should run.
+func (s Selector) Selects(features []Feature) bool {
+	if s.Excludes(features) {
+		return false
+	}
+	if len(s.included) == 0 {
+		return true
+	}
+	return coversAny(s.included, features)
+}
+
+// Excludes returns true if tests covering the given features never run, regardless of the features
+// they cover in addition.
+func (s Selector) Excludes(features []Feature) bool {
+	return coversAny(s.excluded, features)
+}
+
+func coversAny(selected []Feature, features []Feature) bool {
+	for _, s := range selected {
+		for _, f := range features {
+			if s.Covers(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s Selector) String() string {
+	var parts []string
+	for _, f := range s.included {
+		parts = append(parts, "+"+string(f))
+	}
+	for _, f := range s.excluded {
+		parts = append(parts, "-"+string(f))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package features
+
+import (
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	cases := []struct {
+		selector string
+		features []Feature
+		selects  bool
+		excludes bool
+	}{
+		{"", nil, true, false},
+		{"", []Feature{"security.authz.deny"}, true, false},
+		{"security.authn", nil, false, false},
+		{"security.authn", []Feature{"security.authn.jwt"}, true, false},
+		{"security.authn", []Feature{"security.authz.deny"}, false, false},
+		{"security.authn", []Feature{"security.authnx"}, false, false},
+		{"+security.authn,+security.authz", []Feature{"security.authz.deny"}, true, false},
+		{"-security.authn.vm", []Feature{"security.authn.vm"}, false, true},
+		{"security.authn,-security.authn.vm", []Feature{"security.authn.jwt", "security.authn.vm.mtls"}, false, true},
+		{"security.authn,-security.authn.vm", []Feature{"security.authn.jwt"}, true, false},
+	}
+	for _, c := range cases {
+		t.Run(c.selector, func(t *testing.T) {
+			s, err := ParseSelector(c.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Selects(c.features); got != c.selects {
+				t.Errorf("Selects(%v): got %v, want %v", c.features, got, c.selects)
+			}
+			if got := s.Excludes(c.features); got != c.excludes {
+				t.Errorf("Excludes(%v): got %v, want %v", c.features, got, c.excludes)
+			}
+		})
+	}
+}
+
+func TestParseSelector_Invalid(t *testing.T) {
+	for _, s := range []string{"security..authn", "-", "1security", "security.authn,-security.authn"} {
+		if _, err := ParseSelector(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
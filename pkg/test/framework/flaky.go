@@ -0,0 +1,244 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// flakyReportFile lists the tests of a run that passed on retry, one per line, in the run directory.
+	flakyReportFile = "flaky.txt"
+)
+
+// attempt records the failures of the first attempt of a test that is retried if it fails.
+type attempt struct {
+	mu       sync.Mutex
+	errors   []string
+	isFailed bool
+	isNested bool
+}
+
+func (a *attempt) fail(msg string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.isFailed = true
+	if msg != "" {
+		a.errors = append(a.errors, msg)
+	}
+}
+
+// attemptFailed is the panic value that unwinds an attempt that called FailNow, up to runAttempt.
+type attemptFailed struct{}
+
+// failNow records the failure and stops the attempt, like testing.T.FailNow does for tests. As for
+// testing.T.FailNow, it must be called on the goroutine running the test.
+func (a *attempt) failNow(msg string) {
+	a.fail(msg)
+	panic(attemptFailed{})
+}
+
+func (a *attempt) failed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.isFailed
+}
+
+// setNested records that the attempt ran subtests. Their failures are reported by Go right away, so the attempt
+// can't be retried.
+func (a *attempt) setNested() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.isNested = true
+}
+
+func (a *attempt) String() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return strings.Join(a.errors, "\n")
+}
+
+// runRetryingFlaky runs fn in a separate context, which records the failures instead of failing the test. If it
+// fails, the state of the debuggers in scope is snapshotted, their logging is raised, and fn is run again in ctx.
+// If the retry passes, the test is reported as flaky.
+//
+// Both attempts run on the test goroutine. The failures of the first attempt are only intercepted if they are
+// reported through its context: fn must not fail the underlying *testing.T directly, and must call FailNow,
+// Fatal and Fatalf of the context on the test goroutine only, as for testing.T.
+//
+// Only the debuggers outside of the test, e.g. the ones of the suite or of the parent tests, have their logging
+// raised for the retry, as the ones created by the first attempt are cleaned up before retrying.
+func (t *Test) runRetryingFlaky(ctx *testContext, fn func(ctx TestContext)) {
+	first := &attempt{}
+	firstCtx := &testContext{
+		id:      ctx.id,
+		test:    t,
+		T:       ctx.T,
+		suite:   ctx.suite,
+		scope:   newScope(ctx.id+"(attempt-1)", ctx.scope),
+		workDir: ctx.workDir,
+		attempt: first,
+	}
+
+	exited := true
+	defer func() {
+		if exited {
+			// The first attempt ended the test, e.g. by skipping it. Clean up its scope before unwinding.
+			if err := firstCtx.scope.done(ctx.suite.settings.NoCleanup); err != nil {
+				ctx.Logf("error scope cleanup: %v", err)
+			}
+		}
+	}()
+	runAttempt(firstCtx, fn)
+	exited = false
+
+	if !first.failed() {
+		if err := firstCtx.scope.done(ctx.suite.settings.NoCleanup); err != nil {
+			ctx.Logf("error scope cleanup: %v", err)
+		}
+		return
+	}
+
+	if first.isNested {
+		// The failures can't be retried, report them as they are.
+		ctx.Errorf("%s", first.String())
+		nocleanup := ctx.suite.settings.NoCleanup || ctx.suite.settings.NoCleanupOnFailure
+		if err := firstCtx.scope.done(nocleanup); err != nil {
+			ctx.Logf("error scope cleanup: %v", err)
+		}
+		return
+	}
+
+	ctx.Logf("Attempt 1 failed, retrying with more diagnostics:\n%s", first.String())
+	firstDir := path.Join(ctx.workDir, "attempt-1")
+	snapshotDebuggers(firstCtx.scope.debuggers(), firstDir)
+	if err := firstCtx.scope.done(ctx.suite.settings.NoCleanup); err != nil {
+		ctx.Logf("error scope cleanup: %v", err)
+	}
+
+	debuggers := ctx.scope.debuggers()
+	for _, d := range debuggers {
+		if err := d.EnableDebug(); err != nil {
+			scopes.Framework.Warnf("Unable to enable debugging of %v: %v", debuggerID(d), err)
+		}
+	}
+
+	// Run as deferred, so that it also runs if the retry calls FailNow.
+	defer func() {
+		secondDir := path.Join(ctx.workDir, "attempt-2")
+		snapshotDebuggers(ctx.scope.debuggers(), secondDir)
+		if err := diffSnapshots(firstDir, secondDir, path.Join(ctx.workDir, "attempt-diff")); err != nil {
+			scopes.Framework.Warnf("Unable to compare the snapshots of the attempts: %v", err)
+		}
+
+		for _, d := range debuggers {
+			if err := d.DisableDebug(); err != nil {
+				scopes.Framework.Warnf("Unable to disable debugging of %v: %v", debuggerID(d), err)
+			}
+		}
+
+		if !ctx.Failed() {
+			ctx.suite.markFlaky(t.goTest.Name())
+			scopes.CI.Warnf("=== FLAKY: Test: '%s[%s]' passed on retry ===", ctx.Settings().TestID, t.goTest.Name())
+		}
+	}()
+
+	fn(ctx)
+}
+
+// runAttempt runs fn in the context of an attempt, stopping it if it calls FailNow. Other panics are propagated.
+func runAttempt(ctx *testContext, fn func(ctx TestContext)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(attemptFailed); !ok {
+				panic(r)
+			}
+		}
+	}()
+	fn(ctx)
+}
+
+// debuggerID returns the ID of the debugger, for logging.
+func debuggerID(d resource.Debugger) interface{} {
+	if r, ok := d.(resource.Resource); ok {
+		return r.ID()
+	}
+	return d
+}
+
+func snapshotDebuggers(debuggers []resource.Debugger, dir string) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		scopes.Framework.Warnf("Unable to create snapshot directory %s: %v", dir, err)
+		return
+	}
+	for _, d := range debuggers {
+		if err := d.Snapshot(dir); err != nil {
+			scopes.Framework.Warnf("Unable to snapshot %v: %v", debuggerID(d), err)
+		}
+	}
+}
+
+// diffSnapshots writes the unified diffs of the files in both of the given snapshot directories into out.
+func diffSnapshots(first, second, out string) error {
+	files, err := ioutil.ReadDir(first)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		a, err := ioutil.ReadFile(path.Join(first, f.Name()))
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(path.Join(second, f.Name()))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			FromFile: "attempt-1/" + f.Name(),
+			A:        difflib.SplitLines(string(a)),
+			ToFile:   "attempt-2/" + f.Name(),
+			B:        difflib.SplitLines(string(b)),
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			continue
+		}
+		if err := os.MkdirAll(out, os.ModePerm); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path.Join(out, f.Name()+".diff"), []byte(text), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -19,3 +19,21 @@ package resource
 type Dumper interface {
 	Dump()
 }
+
+// Debugger is implemented by components that can raise their logging and snapshot their state, for retrying
+// the failed attempts of flaky tests with more diagnostics.
+type Debugger interface {
+	// EnableDebug raises the logging of the component, until DisableDebug is called.
+	EnableDebug() error
+	DisableDebug() error
+
+	// Snapshot writes the current state of the component, e.g. its proxy configuration, into files in dir. The
+	// file names must be stable, so that the snapshots of different attempts can be compared.
+	Snapshot(dir string) error
+}
+
+// EnvironmentDumper is implemented by environments that can dump the state of all of their workloads
+// (logs, proxy state, configuration) into a directory when a test fails.
+type EnvironmentDumper interface {
+	DumpState(workDir string)
+}
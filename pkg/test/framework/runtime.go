@@ -21,6 +21,7 @@ import (
 	"istio.io/istio/pkg/test/framework/core"
 	"istio.io/istio/pkg/test/framework/label"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
 )
 
 var _ resource.Dumper = &runtime{}
@@ -58,5 +59,10 @@ func (i *runtime) newRootContext(test *Test, goTest *testing.T, labels label.Set
 
 // Close implements io.Closer
 func (i *runtime) Close() error {
-	return i.context.globalScope.done(i.context.settings.NoCleanup)
+	nocleanup := i.context.skipCleanup()
+	if nocleanup && !i.context.settings.NoCleanup {
+		scopes.CI.Infof("Tests of the suite failed, keeping the suite resources for investigation:\n%s",
+			i.context.globalScope.summary())
+	}
+	return i.context.globalScope.done(nocleanup)
 }
@@ -22,6 +22,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/framework/topology"
 	"istio.io/istio/pkg/test/scopes"
 )
 
@@ -52,7 +53,10 @@ func newScope(id string, p *scope) *scope {
 	}
 
 	if p != nil {
+		// Parallel tests create their scopes concurrently.
+		p.mu.Lock()
 		p.children = append(p.children, s)
+		p.mu.Unlock()
 	}
 
 	return s
@@ -77,7 +81,10 @@ func (s *scope) done(nocleanup bool) error {
 	scopes.Framework.Debugf("Begin cleaning up scope: %v", s.id)
 
 	// First, wait for all of the children to be done.
-	for _, c := range s.children {
+	s.mu.Lock()
+	children := append([]*scope{}, s.children...)
+	s.mu.Unlock()
+	for _, c := range children {
 		c.waitForDone()
 	}
 
@@ -115,6 +122,54 @@ func (s *scope) done(nocleanup bool) error {
 	return err
 }
 
+// summary lists the resources of the scope and its children, one per line. Resources with a name, such as
+// namespaces, are listed along with their name.
+func (s *scope) summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := ""
+	for _, r := range s.resources {
+		if n, ok := r.(interface{ Name() string }); ok {
+			out += fmt.Sprintf("  %v (%s)\n", r.ID(), n.Name())
+		} else {
+			out += fmt.Sprintf("  %v\n", r.ID())
+		}
+	}
+	for _, c := range s.children {
+		out += c.summary()
+	}
+	return out
+}
+
+// describers returns the resources of the scope that implement topology.Describer.
+func (s *scope) describers() []topology.Describer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []topology.Describer
+	for _, r := range s.resources {
+		if d, ok := r.(topology.Describer); ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// debuggers returns the resources of the scope and its parents that implement resource.Debugger.
+func (s *scope) debuggers() []resource.Debugger {
+	var out []resource.Debugger
+	for c := s; c != nil; c = c.parent {
+		c.mu.Lock()
+		for _, r := range c.resources {
+			if d, ok := r.(resource.Debugger); ok {
+				out = append(out, d)
+			}
+		}
+		c.mu.Unlock()
+	}
+	return out
+}
+
 func (s *scope) waitForDone() {
 	<-s.closeChan
 }
@@ -28,8 +28,11 @@ import (
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
 	"istio.io/istio/pkg/test/framework/components/environment/native"
 	"istio.io/istio/pkg/test/framework/core"
+	"istio.io/istio/pkg/test/framework/features"
 	"istio.io/istio/pkg/test/framework/label"
 	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/framework/timing"
+	"istio.io/istio/pkg/test/framework/topology"
 	"istio.io/istio/pkg/test/scopes"
 )
 
@@ -57,6 +60,8 @@ type Suite struct {
 	osExit func(int)
 	labels label.Set
 
+	features []features.Feature
+
 	setupFns []resource.SetupFn
 
 	getSettingsFn func(string) (*core.Settings, error)
@@ -85,6 +90,12 @@ func (s *Suite) Label(labels ...label.Instance) *Suite {
 	return s
 }
 
+// Features declares the features covered by all the tests in the suite.
+func (s *Suite) Features(feats ...features.Feature) *Suite {
+	s.features = append(s.features, feats...)
+	return s
+}
+
 // RequireEnvironment ensures that the current environment matches what the suite expects. Otherwise it
 // stops test execution. This also applies the appropriate label to the suite implicitly.
 func (s *Suite) RequireEnvironment(name environment.Name) *Suite {
@@ -186,6 +197,14 @@ func (s *Suite) run() (errLevel int) {
 			ctx.Settings().Selector)
 		return 0
 	}
+	if ctx.Settings().FeatureSelector.Excludes(s.features) {
+		scopes.Framework.Infof("Skipping suite %q due to feature mismatch: features=%v, selector=%v",
+			ctx.Settings().TestID,
+			s.features,
+			ctx.Settings().FeatureSelector)
+		return 0
+	}
+	ctx.suiteFeatures = s.features
 
 	start := time.Now()
 
@@ -194,16 +213,21 @@ func (s *Suite) run() (errLevel int) {
 			rt.Dump()
 		}
 
+		endCleanup := timing.Start(timing.Cleanup, ctx.Settings().TestID)
 		if err := rt.Close(); err != nil {
 			scopes.Framework.Errorf("Error during close: %v", err)
 		}
+		endCleanup()
 		rt = nil
+
+		writeTimingReport(ctx.Settings())
 	}()
 
 	if err := s.runSetupFns(ctx); err != nil {
 		scopes.Framework.Errorf("Exiting due to setup failure: %v", err)
 		return exitCodeSetupError
 	}
+	writeTopology(ctx)
 
 	defer func() {
 		end := time.Now()
@@ -225,6 +249,8 @@ func (s *Suite) run() (errLevel int) {
 func (s *Suite) runSetupFns(ctx SuiteContext) (err error) {
 	scopes.CI.Infof("=== BEGIN: Setup: '%s' ===", ctx.Settings().TestID)
 
+	defer timing.Start(timing.Setup, ctx.Settings().TestID)()
+
 	for _, fn := range s.setupFns {
 		err := s.runSetupFn(fn, ctx)
 		if err != nil {
@@ -237,6 +263,31 @@ func (s *Suite) runSetupFns(ctx SuiteContext) (err error) {
 	return nil
 }
 
+func writeTimingReport(s *core.Settings) {
+	report := timing.NewReport(s.TestID)
+	timing.Reset()
+	scopes.CI.Infof("=== Suite %q timing ===\n%s", s.TestID, report)
+	if err := report.WriteArtifacts(s.RunDir()); err != nil {
+		scopes.Framework.Errorf("Error writing timing report: %v", err)
+	}
+}
+
+// writeTopology writes the topology of the components deployed by the setup functions of the suite.
+func writeTopology(ctx *suiteContext) {
+	t := topology.New(ctx.Settings().TestID)
+	for _, d := range ctx.globalScope.describers() {
+		if err := d.DescribeTopology(t); err != nil {
+			scopes.Framework.Warnf("Unable to describe the topology of the suite: %v", err)
+		}
+	}
+	if len(t.Services) == 0 && len(t.Policies) == 0 {
+		return
+	}
+	if err := t.WriteArtifacts(ctx.Settings().RunDir()); err != nil {
+		scopes.Framework.Errorf("Error writing topology: %v", err)
+	}
+}
+
 func initRuntime(testID string, labels label.Set, getSettingsFn func(string) (*core.Settings, error)) error {
 	rtMu.Lock()
 	defer rtMu.Unlock()
@@ -24,6 +24,7 @@ import (
 
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/core"
+	"istio.io/istio/pkg/test/framework/features"
 	"istio.io/istio/pkg/test/framework/label"
 	"istio.io/istio/pkg/test/framework/resource"
 )
@@ -117,6 +118,51 @@ func TestSuite_Label_SuiteAllow(t *testing.T) {
 	g.Expect(runCalled).To(BeTrue())
 }
 
+func TestSuite_Features_SuiteFilter(t *testing.T) {
+	defer cleanupRT()
+	g := NewGomegaWithT(t)
+
+	var runCalled bool
+	runFn := func() int {
+		runCalled = true
+		return 0
+	}
+
+	sel, err := features.ParseSelector("-security.authn.vm")
+	g.Expect(err).To(BeNil())
+	settings := core.DefaultSettings()
+	settings.FeatureSelector = sel
+
+	s := newSuite("tid", runFn, defaultExitFn, settingsFn(settings))
+	s.Features("security.authn.vm.mtls")
+	s.Run()
+
+	g.Expect(runCalled).To(BeFalse())
+}
+
+func TestSuite_Features_SuiteAllow(t *testing.T) {
+	defer cleanupRT()
+	g := NewGomegaWithT(t)
+
+	var runCalled bool
+	runFn := func() int {
+		runCalled = true
+		return 0
+	}
+
+	sel, err := features.ParseSelector("security.authz")
+	g.Expect(err).To(BeNil())
+	settings := core.DefaultSettings()
+	settings.FeatureSelector = sel
+
+	s := newSuite("tid", runFn, defaultExitFn, settingsFn(settings))
+	s.Features("security.authn")
+	s.Run()
+
+	// Tests of the suite may still cover selected features.
+	g.Expect(runCalled).To(BeTrue())
+}
+
 func TestSuite_RequireEnvironment(t *testing.T) {
 	defer cleanupRT()
 	g := NewGomegaWithT(t)
@@ -22,9 +22,11 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"istio.io/istio/pkg/test/framework/components/environment/api"
 	"istio.io/istio/pkg/test/framework/core"
+	"istio.io/istio/pkg/test/framework/features"
 	"istio.io/istio/pkg/test/framework/label"
 	"istio.io/istio/pkg/test/framework/resource"
 	"istio.io/istio/pkg/test/scopes"
@@ -51,6 +53,15 @@ type suiteContext struct {
 	contextNames map[string]struct{}
 
 	suiteLabels label.Set
+
+	// suiteFeatures are the features covered by all tests of the suite.
+	suiteFeatures []features.Feature
+
+	// failed is set if any test of the suite failed.
+	failed int32
+
+	// flakyMu serializes the writes to the flaky test report.
+	flakyMu sync.Mutex
 }
 
 func newSuiteContext(s *core.Settings, envFn api.FactoryFn, labels label.Set) (*suiteContext, error) {
@@ -78,6 +89,33 @@ func newSuiteContext(s *core.Settings, envFn api.FactoryFn, labels label.Set) (*
 	return c, nil
 }
 
+// markFailed records that a test of the suite failed.
+func (s *suiteContext) markFailed() {
+	atomic.StoreInt32(&s.failed, 1)
+}
+
+// markFlaky records that the named test of the suite passed on retry, in the flaky test report of the run.
+func (s *suiteContext) markFlaky(name string) {
+	s.flakyMu.Lock()
+	defer s.flakyMu.Unlock()
+
+	report := path.Join(s.settings.RunDir(), flakyReportFile)
+	f, err := os.OpenFile(report, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		scopes.Framework.Warnf("Unable to open the flaky test report %s: %v", report, err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := fmt.Fprintln(f, name); err != nil {
+		scopes.Framework.Warnf("Unable to write the flaky test report %s: %v", report, err)
+	}
+}
+
+// skipCleanup indicates whether the resources of the suite should be kept after it is done.
+func (s *suiteContext) skipCleanup() bool {
+	return s.settings.NoCleanup || (s.settings.NoCleanupOnFailure && atomic.LoadInt32(&s.failed) == 1)
+}
+
 // allocateContextID allocates a unique context id for TestContexts. Useful for creating unique names to help with
 // debugging
 func (s *suiteContext) allocateContextID(prefix string) string {
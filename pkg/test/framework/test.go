@@ -20,19 +20,24 @@ import (
 	"time"
 
 	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/features"
 	"istio.io/istio/pkg/test/framework/label"
+	"istio.io/istio/pkg/test/framework/timing"
 	"istio.io/istio/pkg/test/scopes"
 )
 
 // Test allows the test author to specify test-related metadata in a fluent-style, before commencing execution.
 type Test struct {
 	// name to be used when creating a Golang test. Only used for subtests.
-	name        string
-	parent      *Test
-	goTest      *testing.T
-	labels      []label.Instance
-	s           *suiteContext
-	requiredEnv environment.Name
+	name         string
+	parent       *Test
+	goTest       *testing.T
+	labels       []label.Instance
+	features     []features.Feature
+	s            *suiteContext
+	requiredEnv  environment.Name
+	requiredCaps []kube.Capability
 
 	ctx *testContext
 
@@ -67,6 +72,27 @@ func (t *Test) Label(labels ...label.Instance) *Test {
 	return t
 }
 
+// Features declares the features covered by this test. Subtests cover the features of their parents.
+//
+// Example:
+//
+//     framework.NewTest(t).
+//         Features("security.authz.deny").
+//         Run(func(ctx framework.TestContext) { ... })
+func (t *Test) Features(feats ...features.Feature) *Test {
+	t.features = append(t.features, feats...)
+	return t
+}
+
+// allFeatures returns the features covered by this test, its parents and its suite.
+func (t *Test) allFeatures() []features.Feature {
+	all := append([]features.Feature{}, t.s.suiteFeatures...)
+	for c := t; c != nil; c = c.parent {
+		all = append(all, c.features...)
+	}
+	return all
+}
+
 // RequiresEnvironment ensures that the current environment matches what the suite expects. Otherwise it stops test
 // execution and skips the test.
 func (t *Test) RequiresEnvironment(name environment.Name) *Test {
@@ -74,6 +100,20 @@ func (t *Test) RequiresEnvironment(name environment.Name) *Test {
 	return t
 }
 
+// RequiresCapability skips the test, unless all clusters of the environment have the given capabilities.
+// Implies RequiresEnvironment(environment.Kube).
+//
+// Example:
+//
+//     framework.NewTest(t).
+//         RequiresCapability(kube.LoadBalancer, kube.KubernetesVersion("1.16")).
+//         Run(func(ctx framework.TestContext) { ... })
+func (t *Test) RequiresCapability(caps ...kube.Capability) *Test {
+	t.requiredEnv = environment.Kube
+	t.requiredCaps = append(t.requiredCaps, caps...)
+	return t
+}
+
 // Run the test, supplied as a lambda.
 func (t *Test) Run(fn func(ctx TestContext)) {
 	t.runInternal(fn, false)
@@ -174,17 +214,42 @@ func (t *Test) doRun(ctx *testContext, fn func(ctx TestContext), parallel bool)
 		return
 	}
 
+	for _, c := range t.requiredCaps {
+		has, err := t.s.Environment().(*kube.Environment).HasCapability(c)
+		if err != nil {
+			ctx.Done()
+			t.goTest.Fatalf("Unable to determine the capabilities required by %q: %v", t.goTest.Name(), err)
+			return
+		}
+		if !has {
+			ctx.Done()
+			t.goTest.Skipf("Skipping %q: required cluster capability not found: %s", t.goTest.Name(), c)
+			return
+		}
+	}
+
+	if feats := t.allFeatures(); !t.s.settings.FeatureSelector.Selects(feats) {
+		ctx.Done()
+		t.goTest.Skipf("Skipping %q: feature mismatch: features=%v, selector=%v",
+			t.goTest.Name(), feats, t.s.settings.FeatureSelector)
+		return
+	}
+
 	start := time.Now()
+	endTest := timing.Start(timing.Test, t.goTest.Name())
 
 	scopes.CI.Infof("=== BEGIN: Test: '%s[%s]' ===", rt.suiteContext().Settings().TestID, t.goTest.Name())
 	defer func() {
 		doneFn := func() {
 			end := time.Now()
+			endTest()
 			scopes.CI.Infof("=== DONE:  Test: '%s[%s] (%v)' ===",
 				rt.suiteContext().Settings().TestID,
 				t.goTest.Name(),
 				end.Sub(start))
+			endCleanup := timing.Start(timing.Cleanup, t.goTest.Name())
 			ctx.Done()
+			endCleanup()
 		}
 		if t.hasParallelChildren {
 			// If a child is running in parallel, it won't continue until this test returns.
@@ -196,5 +261,9 @@ func (t *Test) doRun(ctx *testContext, fn func(ctx TestContext), parallel bool)
 		}
 	}()
 
+	if t.parent != nil && t.s.settings.RetryFlaky {
+		t.runRetryingFlaky(ctx, fn)
+		return
+	}
 	fn(ctx)
 }
@@ -96,6 +96,10 @@ type testContext struct {
 
 	// The workDir for this particular context
 	workDir string
+
+	// attempt records the failures of the context, instead of failing the test, for the first attempt of a test
+	// that is retried if flaky.
+	attempt *attempt
 }
 
 func newTestContext(test *Test, goTest *testing.T, s *suiteContext, parentScope *scope, labels label.Set) *testContext {
@@ -206,6 +210,10 @@ func (c *testContext) NewSubTest(name string) *Test {
 		panic(fmt.Sprintf("Attempting to create subtest %s before running parent", name))
 	}
 
+	if c.attempt != nil {
+		c.attempt.setNested()
+	}
+
 	return &Test{
 		name:   name,
 		parent: c.test,
@@ -221,11 +229,23 @@ func (c *testContext) Done() {
 	if c.Failed() {
 		scopes.Framework.Debugf("Begin dumping testContext: %q", c.id)
 		c.scope.dump()
+		if d, ok := c.suite.environment.(resource.EnvironmentDumper); ok {
+			d.DumpState(path.Join(c.workDir, "dump"))
+		}
 		scopes.Framework.Debugf("Completed dumping testContext: %q", c.id)
 	}
 
+	nocleanup := c.suite.settings.NoCleanup
+	if c.Failed() {
+		c.suite.markFailed()
+		if c.suite.settings.NoCleanupOnFailure && !nocleanup {
+			nocleanup = true
+			scopes.CI.Infof("Test %q failed, keeping its resources for investigation:\n%s", c.id, c.scope.summary())
+		}
+	}
+
 	scopes.Framework.Debugf("Begin cleaning up testContext: %q", c.id)
-	if err := c.scope.done(c.suite.settings.NoCleanup); err != nil {
+	if err := c.scope.done(nocleanup); err != nil {
 		c.Logf("error scope cleanup: %v", err)
 	}
 	scopes.Framework.Debugf("Completed cleaning up testContext: %q", c.id)
@@ -233,36 +253,60 @@ func (c *testContext) Done() {
 
 func (c *testContext) Error(args ...interface{}) {
 	c.Helper()
+	if c.attempt != nil {
+		c.attempt.fail(fmt.Sprint(args...))
+		return
+	}
 	c.T.Error(args...)
 }
 
 func (c *testContext) Errorf(format string, args ...interface{}) {
 	c.Helper()
+	if c.attempt != nil {
+		c.attempt.fail(fmt.Sprintf(format, args...))
+		return
+	}
 	c.T.Errorf(format, args...)
 }
 
 func (c *testContext) Fail() {
 	c.Helper()
+	if c.attempt != nil {
+		c.attempt.fail("")
+		return
+	}
 	c.T.Fail()
 }
 
 func (c *testContext) FailNow() {
 	c.Helper()
+	if c.attempt != nil {
+		c.attempt.failNow("")
+	}
 	c.T.FailNow()
 }
 
 func (c *testContext) Failed() bool {
 	c.Helper()
+	if c.attempt != nil {
+		return c.attempt.failed()
+	}
 	return c.T.Failed()
 }
 
 func (c *testContext) Fatal(args ...interface{}) {
 	c.Helper()
+	if c.attempt != nil {
+		c.attempt.failNow(fmt.Sprint(args...))
+	}
 	c.T.Fatal(args...)
 }
 
 func (c *testContext) Fatalf(format string, args ...interface{}) {
 	c.Helper()
+	if c.attempt != nil {
+		c.attempt.failNow(fmt.Sprintf(format, args...))
+	}
 	c.T.Fatalf(format, args...)
 }
 
@@ -0,0 +1,179 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timing records how long the phases of a test suite take, and writes them as artifacts so that
+// the time spent in setup, component installation, echo deployment, tests and cleanup can be tracked.
+package timing
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phase of a test suite.
+type Phase string
+
+const (
+	// Setup is the execution of a setup function of the suite.
+	Setup Phase = "setup"
+	// Install is the installation of a component, such as Istio.
+	Install Phase = "install"
+	// Deploy is the deployment of echo instances.
+	Deploy Phase = "deploy"
+	// Test is the execution of a test, including its subtests.
+	Test Phase = "test"
+	// Cleanup is the cleanup of the resources of a test or of the suite.
+	Cleanup Phase = "cleanup"
+
+	// JSONFile is the name of the JSON artifact.
+	JSONFile = "timing.json"
+	// JUnitFile is the name of the JUnit artifact, which has the durations as properties of the suite.
+	JUnitFile = "timing.xml"
+)
+
+// Record of a single phase.
+type Record struct {
+	Phase   Phase     `json:"phase"`
+	Name    string    `json:"name"`
+	Start   time.Time `json:"start"`
+	Seconds float64   `json:"seconds"`
+}
+
+// Report of all recorded phases of a suite.
+type Report struct {
+	Suite   string   `json:"suite"`
+	Records []Record `json:"records"`
+	Totals  []Total  `json:"totals"`
+}
+
+// Total duration of all records of a phase.
+type Total struct {
+	Phase   Phase   `json:"phase"`
+	Seconds float64 `json:"seconds"`
+}
+
+var (
+	mu      sync.Mutex
+	records []Record
+)
+
+// Start recording the given phase. The returned function ends the phase and records it.
+func Start(phase Phase, name string) func() {
+	start := time.Now()
+	return func() {
+		r := Record{
+			Phase:   phase,
+			Name:    name,
+			Start:   start,
+			Seconds: time.Since(start).Seconds(),
+		}
+		mu.Lock()
+		records = append(records, r)
+		mu.Unlock()
+	}
+}
+
+// Records returns all records, ordered by start time.
+func Records() []Record {
+	mu.Lock()
+	out := append([]Record{}, records...)
+	mu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Start.Before(out[j].Start)
+	})
+	return out
+}
+
+// Reset clears all records.
+func Reset() {
+	mu.Lock()
+	records = nil
+	mu.Unlock()
+}
+
+// NewReport returns the report of all records of the given suite.
+func NewReport(suite string) *Report {
+	r := &Report{
+		Suite:   suite,
+		Records: Records(),
+	}
+	totals := make(map[Phase]float64)
+	var phases []Phase
+	for _, rec := range r.Records {
+		if _, ok := totals[rec.Phase]; !ok {
+			phases = append(phases, rec.Phase)
+		}
+		totals[rec.Phase] += rec.Seconds
+	}
+	for _, p := range phases {
+		r.Totals = append(r.Totals, Total{Phase: p, Seconds: totals[p]})
+	}
+	return r
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestSuite struct {
+	XMLName    xml.Name        `xml:"testsuite"`
+	Name       string          `xml:"name,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+}
+
+// WriteArtifacts writes the report as JSONFile and JUnitFile to the given directory.
+func (r *Report) WriteArtifacts(dir string) error {
+	js, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(dir, JSONFile), js, 0644); err != nil {
+		return err
+	}
+
+	suite := junitTestSuite{Name: r.Suite}
+	for _, t := range r.Totals {
+		suite.Properties = append(suite.Properties, junitProperty{
+			Name:  fmt.Sprintf("timing.%s", t.Phase),
+			Value: fmt.Sprintf("%.3f", t.Seconds),
+		})
+	}
+	for _, rec := range r.Records {
+		suite.Properties = append(suite.Properties, junitProperty{
+			Name:  fmt.Sprintf("timing.%s.%s", rec.Phase, rec.Name),
+			Value: fmt.Sprintf("%.3f", rec.Seconds),
+		})
+	}
+	out, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(dir, JUnitFile), append([]byte(xml.Header), out...), 0644)
+}
+
+// String returns the totals of the report, one phase per line.
+func (r *Report) String() string {
+	out := ""
+	for _, t := range r.Totals {
+		out += fmt.Sprintf("%-8s %v\n", t.Phase, time.Duration(t.Seconds*float64(time.Second)).Round(time.Millisecond))
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	Reset()
+	defer Reset()
+
+	Start(Setup, "suite")()
+	Start(Test, "TestA")()
+	Start(Test, "TestB")()
+
+	r := NewReport("suite")
+	if len(r.Records) != 3 {
+		t.Fatalf("expected 3 records, got %v", r.Records)
+	}
+	if len(r.Totals) != 2 || r.Totals[0].Phase != Setup || r.Totals[1].Phase != Test {
+		t.Fatalf("unexpected totals %v", r.Totals)
+	}
+
+	dir, err := ioutil.TempDir("", "timing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	if err := r.WriteArtifacts(dir); err != nil {
+		t.Fatal(err)
+	}
+	js, err := ioutil.ReadFile(path.Join(dir, JSONFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := Report{}
+	if err := json.Unmarshal(js, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Suite != "suite" || len(parsed.Records) != 3 {
+		t.Fatalf("unexpected report %+v", parsed)
+	}
+	if _, err := os.Stat(path.Join(dir, JUnitFile)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package topology describes the services and policies deployed by the setup of a test suite, and writes them as
+// artifacts so that reviewers of failing runs can understand the test topology without reading the setup code.
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// JSONFile is the name of the JSON artifact.
+	JSONFile = "topology.json"
+	// DOTFile is the name of the graphviz artifact.
+	DOTFile = "topology.dot"
+)
+
+// Describer is implemented by the components that are part of the test topology.
+type Describer interface {
+	// DescribeTopology adds the component to the given topology.
+	DescribeTopology(t *Topology) error
+}
+
+// Port of a service.
+type Port struct {
+	Name         string `json:"name"`
+	Protocol     string `json:"protocol"`
+	ServicePort  int    `json:"servicePort,omitempty"`
+	InstancePort int    `json:"instancePort,omitempty"`
+}
+
+// Service deployed for the tests, such as an echo instance.
+type Service struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Cluster   string   `json:"cluster,omitempty"`
+	Versions  []string `json:"versions,omitempty"`
+	Ports     []Port   `json:"ports,omitempty"`
+	Sidecar   bool     `json:"sidecar"`
+}
+
+// Policy is an Istio configuration resource applied for the tests.
+type Policy struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster,omitempty"`
+}
+
+// Topology of a suite.
+type Topology struct {
+	Suite    string    `json:"suite"`
+	Services []Service `json:"services"`
+	Policies []Policy  `json:"policies"`
+}
+
+// New returns an empty topology for the given suite.
+func New(suite string) *Topology {
+	return &Topology{Suite: suite}
+}
+
+// AddService adds the given service to the topology.
+func (t *Topology) AddService(s Service) {
+	t.Services = append(t.Services, s)
+}
+
+// AddPolicy adds the given policy to the topology.
+func (t *Topology) AddPolicy(p Policy) {
+	t.Policies = append(t.Policies, p)
+}
+
+// sort the services and policies by cluster, namespace and name, for stable artifacts.
+func (t *Topology) sort() {
+	sort.SliceStable(t.Services, func(i, j int) bool {
+		a, b := t.Services[i], t.Services[j]
+		return a.Cluster+"/"+a.Namespace+"/"+a.Name < b.Cluster+"/"+b.Namespace+"/"+b.Name
+	})
+	sort.SliceStable(t.Policies, func(i, j int) bool {
+		a, b := t.Policies[i], t.Policies[j]
+		return a.Cluster+"/"+a.Namespace+"/"+a.Kind+"/"+a.Name < b.Cluster+"/"+b.Namespace+"/"+b.Kind+"/"+b.Name
+	})
+}
+
+// WriteArtifacts writes the topology as JSONFile and DOTFile to the given directory.
+func (t *Topology) WriteArtifacts(dir string) error {
+	t.sort()
+	js, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(dir, JSONFile), js, 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(dir, DOTFile), []byte(t.DOT()), 0644)
+}
+
+// DOT returns the graphviz description of the topology, with a cluster per namespace holding its services and
+// policies.
+func (t *Topology) DOT() string {
+	t.sort()
+
+	type namespace struct {
+		services []Service
+		policies []Policy
+	}
+	namespaces := make(map[string]*namespace)
+	var keys []string
+	get := func(cluster, ns string) *namespace {
+		key := ns
+		if cluster != "" {
+			key = cluster + "/" + ns
+		}
+		if _, ok := namespaces[key]; !ok {
+			namespaces[key] = &namespace{}
+			keys = append(keys, key)
+		}
+		return namespaces[key]
+	}
+	for _, s := range t.Services {
+		n := get(s.Cluster, s.Namespace)
+		n.services = append(n.services, s)
+	}
+	for _, p := range t.Policies {
+		n := get(p.Cluster, p.Namespace)
+		n.policies = append(n.policies, p)
+	}
+	sort.Strings(keys)
+
+	out := fmt.Sprintf("digraph %q {\n", t.Suite)
+	out += "  rankdir=LR;\n"
+	out += "  node [fontname=\"Helvetica\", fontsize=10];\n"
+	for i, key := range keys {
+		n := namespaces[key]
+		out += fmt.Sprintf("  subgraph cluster_%d {\n", i)
+		out += fmt.Sprintf("    label=%q;\n", key)
+		for _, s := range n.services {
+			style := "solid"
+			if !s.Sidecar {
+				style = "dashed"
+			}
+			out += fmt.Sprintf("    %q [shape=box, style=%s, label=%q];\n", key+"/"+s.Name, style, serviceLabel(s))
+		}
+		for _, p := range n.policies {
+			out += fmt.Sprintf("    %q [shape=note, label=%q];\n", key+"/"+p.Kind+"/"+p.Name, p.Kind+"\n"+p.Name)
+		}
+		out += "  }\n"
+	}
+	out += "}\n"
+	return out
+}
+
+func serviceLabel(s Service) string {
+	lines := []string{s.Name}
+	if len(s.Versions) > 0 {
+		lines = append(lines, "versions: "+strings.Join(s.Versions, ","))
+	}
+	for _, p := range s.Ports {
+		lines = append(lines, fmt.Sprintf("%s %s %d->%d", p.Name, p.Protocol, p.ServicePort, p.InstancePort))
+	}
+	if !s.Sidecar {
+		lines = append(lines, "(no sidecar)")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestTopology(t *testing.T) {
+	topo := New("suite")
+	topo.AddService(Service{
+		Name:      "b",
+		Namespace: "ns1",
+		Ports:     []Port{{Name: "http", Protocol: "HTTP", ServicePort: 80, InstancePort: 8090}},
+		Sidecar:   true,
+	})
+	topo.AddService(Service{Name: "a", Namespace: "ns1"})
+	topo.AddPolicy(Policy{Kind: "AuthorizationPolicy", Name: "deny-all", Namespace: "ns1"})
+
+	dot := topo.DOT()
+	for _, want := range []string{
+		`digraph "suite"`,
+		`label="ns1"`,
+		`"ns1/a" [shape=box, style=dashed`,
+		`"ns1/b" [shape=box, style=solid, label="b\nhttp HTTP 80->8090"]`,
+		`"ns1/AuthorizationPolicy/deny-all" [shape=note`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("expected %q in:\n%s", want, dot)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "topology")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	if err := topo.WriteArtifacts(dir); err != nil {
+		t.Fatal(err)
+	}
+	js, err := ioutil.ReadFile(path.Join(dir, JSONFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := Topology{}
+	if err := json.Unmarshal(js, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Services) != 2 || parsed.Services[0].Name != "a" || len(parsed.Policies) != 1 {
+		t.Fatalf("unexpected topology %+v", parsed)
+	}
+	if _, err := os.Stat(path.Join(dir, DOTFile)); err != nil {
+		t.Fatal(err)
+	}
+}
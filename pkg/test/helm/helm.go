@@ -39,11 +39,17 @@ func Init(homeDir string, clientOnly bool) error {
 	return err
 }
 
-func Template(homeDir, template, name, namespace string, valuesFile string, values map[string]string) (string, error) {
+// Template calls "helm template". The overlay files are merged over the values file in order, and the values
+// override both.
+func Template(homeDir, template, name, namespace string, valuesFile string, values map[string]string,
+	overlayFiles ...string) (string, error) {
 	p := []string{"helm", "--home", homeDir, "template", template, "--name", name, "--namespace", namespace}
 	if valuesFile != "" {
 		p = append(p, "--values", valuesFile)
 	}
+	for _, f := range overlayFiles {
+		p = append(p, "--values", f)
+	}
 
 	// Override the values in the helm value file.
 	for k, v := range values {
@@ -27,6 +27,7 @@ import (
 	"istio.io/istio/pkg/test/util/retry"
 
 	kubeApiAdmissions "k8s.io/api/admissionregistration/v1beta1"
+	kubeApiApps "k8s.io/api/apps/v1"
 	kubeApiCore "k8s.io/api/core/v1"
 	kubeApiExt "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	kubeExtClient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -38,6 +39,8 @@ import (
 	kubeClientCore "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // Needed for auth
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdApi "k8s.io/client-go/tools/clientcmd/api"
 )
 
 const (
@@ -91,7 +94,18 @@ func NewAccessor(kubeConfig string, baseWorkDir string) (*Accessor, error) {
 
 // NewPortForwarder creates a new port forwarder.
 func (a *Accessor) NewPortForwarder(pod kubeApiCore.Pod, localPort, remotePort uint16) (PortForwarder, error) {
-	return newPortForwarder(a.restConfig, pod, localPort, remotePort)
+	f, err := newPortForwarder(a.restConfig, pod, localPort, remotePort)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// NewManagedPortForwarder creates a new port forwarder to the given remote port of a ready pod matching the
+// selectors. Unlike the forwarders created by NewPortForwarder, it re-establishes the forwarding, to the same
+// local address, when the connection to the pod is lost or the pod is replaced.
+func (a *Accessor) NewManagedPortForwarder(namespace string, remotePort uint16, selectors ...string) PortForwarder {
+	return newManagedPortForwarder(a, namespace, remotePort, selectors)
 }
 
 // GetPods returns pods in the given namespace, based on the selectors. If no selectors are given, then
@@ -129,6 +143,18 @@ func (a *Accessor) GetPod(namespace, name string) (kubeApiCore.Pod, error) {
 	return *v, nil
 }
 
+// GetPodMetrics returns the current resource usage of the containers of the given pod, as reported by the
+// metrics API. The cluster must run a metrics server.
+func (a *Accessor) GetPodMetrics(namespace, name string) (*PodMetrics, error) {
+	raw, err := a.set.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods", name).
+		DoRaw()
+	if err != nil {
+		return nil, fmt.Errorf("failed getting metrics of pod %s/%s: %v", namespace, name, err)
+	}
+	return parsePodMetrics(raw)
+}
+
 // DeletePod deletes the given pod.
 func (a *Accessor) DeletePod(namespace, name string) error {
 	return a.set.CoreV1().Pods(namespace).Delete(name, &kubeApiMeta.DeleteOptions{})
@@ -238,6 +264,40 @@ func (a *Accessor) WaitUntilPodsAreDeleted(fetchFunc PodFetchFunc, opts ...retry
 	return err
 }
 
+// GetDeployment returns the deployment with the given name/namespace.
+func (a *Accessor) GetDeployment(ns string, name string) (*kubeApiApps.Deployment, error) {
+	return a.set.AppsV1().Deployments(ns).Get(name, kubeApiMeta.GetOptions{})
+}
+
+// UpdateDeployment updates the given deployment.
+func (a *Accessor) UpdateDeployment(deployment *kubeApiApps.Deployment) (*kubeApiApps.Deployment, error) {
+	return a.set.AppsV1().Deployments(deployment.Namespace).Update(deployment)
+}
+
+// WaitUntilDeploymentIsRolledOut waits until all replicas of the deployment with the name/namespace run the
+// latest revision of its spec and are available.
+func (a *Accessor) WaitUntilDeploymentIsRolledOut(ns string, name string, opts ...retry.Option) error {
+	_, err := retry.Do(func() (interface{}, bool, error) {
+		deployment, err := a.set.AppsV1().Deployments(ns).Get(name, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return nil, false, err
+		}
+
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		status := deployment.Status
+		done := status.ObservedGeneration >= deployment.Generation &&
+			status.UpdatedReplicas == replicas &&
+			status.Replicas == replicas &&
+			status.AvailableReplicas == replicas
+		return nil, done, nil
+	}, newRetryOptions(opts...)...)
+
+	return err
+}
+
 // DeleteDeployment deletes the given deployment.
 func (a *Accessor) DeleteDeployment(ns string, name string) error {
 	return a.set.AppsV1().Deployments(ns).Delete(name, deleteOptionsForeground())
@@ -323,6 +383,11 @@ func (a *Accessor) DeleteValidatingWebhook(name string) error {
 	return a.set.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Delete(name, deleteOptionsForeground())
 }
 
+// DeleteMutatingWebhook deletes the mutating webhook with the given name.
+func (a *Accessor) DeleteMutatingWebhook(name string) error {
+	return a.set.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Delete(name, deleteOptionsForeground())
+}
+
 // WaitForValidatingWebhookDeletion waits for the validating webhook with the given name to be garbage collected by kubernetes.
 func (a *Accessor) WaitForValidatingWebhookDeletion(name string, opts ...retry.Option) error {
 	_, err := retry.Do(func() (interface{}, bool, error) {
@@ -352,6 +417,27 @@ func (a *Accessor) GetValidatingWebhookConfiguration(name string) (*kubeApiAdmis
 	return whc, nil
 }
 
+// UpdateValidatingWebhookConfiguration updates the given ValidatingWebhookConfiguration.
+func (a *Accessor) UpdateValidatingWebhookConfiguration(
+	whc *kubeApiAdmissions.ValidatingWebhookConfiguration) (*kubeApiAdmissions.ValidatingWebhookConfiguration, error) {
+	return a.set.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Update(whc)
+}
+
+// GetMutatingWebhookConfiguration returns the specified MutatingWebhookConfiguration.
+func (a *Accessor) GetMutatingWebhookConfiguration(name string) (*kubeApiAdmissions.MutatingWebhookConfiguration, error) {
+	whc, err := a.set.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get(name, kubeApiMeta.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not get mutating webhook config: %s", name)
+	}
+	return whc, nil
+}
+
+// UpdateMutatingWebhookConfiguration updates the given MutatingWebhookConfiguration.
+func (a *Accessor) UpdateMutatingWebhookConfiguration(
+	whc *kubeApiAdmissions.MutatingWebhookConfiguration) (*kubeApiAdmissions.MutatingWebhookConfiguration, error) {
+	return a.set.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Update(whc)
+}
+
 // GetCustomResourceDefinitions gets the CRDs
 func (a *Accessor) GetCustomResourceDefinitions() ([]kubeApiExt.CustomResourceDefinition, error) {
 	crd, err := a.extSet.ApiextensionsV1beta1().CustomResourceDefinitions().List(kubeApiMeta.ListOptions{})
@@ -371,6 +457,15 @@ func (a *Accessor) GetService(ns string, name string) (*kubeApiCore.Service, err
 	return a.set.CoreV1().Services(ns).Get(name, kubeApiMeta.GetOptions{})
 }
 
+// GetServices returns the services in the given namespace. An empty namespace selects all namespaces.
+func (a *Accessor) GetServices(ns string) ([]kubeApiCore.Service, error) {
+	list, err := a.set.CoreV1().Services(ns).List(kubeApiMeta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
 // GetSecret returns secret resource with the given namespace.
 func (a *Accessor) GetSecret(ns string) kubeClientCore.SecretInterface {
 	return a.set.CoreV1().Secrets(ns)
@@ -390,10 +485,61 @@ func (a *Accessor) DeleteSecret(namespace, name string) (err error) {
 	return err
 }
 
+// GetConfigMap returns the config map interface of the given namespace.
+func (a *Accessor) GetConfigMap(ns string) kubeClientCore.ConfigMapInterface {
+	return a.set.CoreV1().ConfigMaps(ns)
+}
+
 func (a *Accessor) GetServiceAccount(namespace string) kubeClientCore.ServiceAccountInterface {
 	return a.set.CoreV1().ServiceAccounts(namespace)
 }
 
+// ServiceAccountKubeConfig returns the contents of a kube config file for the same cluster as the accessor, which
+// authenticates with the token of the given service account. It waits for the token to be issued.
+func (a *Accessor) ServiceAccountKubeConfig(namespace, name string) (string, error) {
+	secret, err := retry.Do(func() (interface{}, bool, error) {
+		sa, err := a.GetServiceAccount(namespace).Get(name, kubeApiMeta.GetOptions{})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, ref := range sa.Secrets {
+			s, err := a.GetSecret(namespace).Get(ref.Name, kubeApiMeta.GetOptions{})
+			if err != nil {
+				return nil, false, err
+			}
+			if s.Type == kubeApiCore.SecretTypeServiceAccountToken && len(s.Data[kubeApiCore.ServiceAccountTokenKey]) > 0 {
+				return s, true, nil
+			}
+		}
+		return nil, false, fmt.Errorf("no token issued for service account %s/%s", namespace, name)
+	}, retry.Timeout(time.Minute))
+	if err != nil {
+		return "", err
+	}
+	data := secret.(*kubeApiCore.Secret).Data
+
+	cfg := clientcmdApi.NewConfig()
+	cfg.Clusters["cluster"] = &clientcmdApi.Cluster{
+		Server:                   a.restConfig.Host,
+		CertificateAuthorityData: data[kubeApiCore.ServiceAccountRootCAKey],
+	}
+	cfg.AuthInfos[name] = &clientcmdApi.AuthInfo{
+		Token: string(data[kubeApiCore.ServiceAccountTokenKey]),
+	}
+	cfg.Contexts[name] = &clientcmdApi.Context{
+		Cluster:   "cluster",
+		AuthInfo:  name,
+		Namespace: namespace,
+	}
+	cfg.CurrentContext = name
+
+	out, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
 // GetKubernetesVersion returns the Kubernetes server version
 func (a *Accessor) GetKubernetesVersion() (*version.Info, error) {
 	return a.extSet.ServerVersion()
@@ -416,9 +562,16 @@ func (a *Accessor) CreateNamespace(ns string, istioTestingAnnotation string) err
 
 // CreateNamespaceWithLabels with the specified name, sidecar-injection behavior, and labels
 func (a *Accessor) CreateNamespaceWithLabels(ns string, istioTestingAnnotation string, labels map[string]string) error {
-	scopes.Framework.Debugf("Creating namespace %s ns with labels %v", ns, labels)
+	return a.CreateNamespaceWithMetadata(ns, istioTestingAnnotation, labels, nil)
+}
+
+// CreateNamespaceWithMetadata with the specified name, sidecar-injection behavior, labels and annotations
+func (a *Accessor) CreateNamespaceWithMetadata(ns string, istioTestingAnnotation string,
+	labels map[string]string, annotations map[string]string) error {
+	scopes.Framework.Debugf("Creating namespace %s ns with labels %v and annotations %v", ns, labels, annotations)
 
 	n := a.newNamespaceWithLabels(ns, istioTestingAnnotation, labels)
+	n.ObjectMeta.Annotations = annotations
 	_, err := a.set.CoreV1().Namespaces().Create(&n)
 	return err
 }
@@ -479,6 +632,16 @@ func (a *Accessor) WaitForNamespaceDeletion(ns string, opts ...retry.Option) err
 	return err
 }
 
+// GetNamespaces returns all of the K8s namespaces.
+func (a *Accessor) GetNamespaces() ([]kubeApiCore.Namespace, error) {
+	l, err := a.set.CoreV1().Namespaces().List(kubeApiMeta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Items, nil
+}
+
 // GetNamespace returns the K8s namespaceresource with the given name.
 func (a *Accessor) GetNamespace(ns string) (*kubeApiCore.Namespace, error) {
 	n, err := a.set.CoreV1().Namespaces().Get(ns, kubeApiMeta.GetOptions{})
@@ -489,6 +652,22 @@ func (a *Accessor) GetNamespace(ns string) (*kubeApiCore.Namespace, error) {
 	return n, nil
 }
 
+// SetNamespaceLabels sets the given labels on the K8s namespace with the given name, keeping its other labels.
+func (a *Accessor) SetNamespaceLabels(ns string, labels map[string]string) error {
+	n, err := a.set.CoreV1().Namespaces().Get(ns, kubeApiMeta.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if n.Labels == nil {
+		n.Labels = make(map[string]string, len(labels))
+	}
+	for k, v := range labels {
+		n.Labels[k] = v
+	}
+	_, err = a.set.CoreV1().Namespaces().Update(n)
+	return err
+}
+
 // ApplyContents applies the given config contents using kubectl.
 func (a *Accessor) ApplyContents(namespace string, contents string) ([]string, error) {
 	return a.ctl.applyContents(namespace, contents)
@@ -499,6 +678,12 @@ func (a *Accessor) Apply(namespace string, filename string) error {
 	return a.ctl.apply(namespace, filename)
 }
 
+// ApplyFiles applies the config in all of the given files with a single kubectl command. Unlike Apply, the
+// files are not split, so they must not contain CRDs along with resources of their kinds.
+func (a *Accessor) ApplyFiles(namespace string, filenames ...string) error {
+	return a.ctl.applyFiles(namespace, filenames)
+}
+
 // DeleteContents deletes the given config contents using kubectl.
 func (a *Accessor) DeleteContents(namespace string, contents string) error {
 	return a.ctl.deleteContents(namespace, contents)
@@ -509,11 +694,23 @@ func (a *Accessor) Delete(namespace string, filename string) error {
 	return a.ctl.delete(namespace, filename)
 }
 
+// DeleteAll deletes all resources of the given comma separated types (e.g. "deployments,services") in the
+// namespace using kubectl. If fieldSelector is not empty, only the matching resources are deleted.
+func (a *Accessor) DeleteAll(namespace, resourceTypes, fieldSelector string) error {
+	return a.ctl.deleteAll(namespace, resourceTypes, fieldSelector)
+}
+
 // Logs calls the logs command for the specified pod, with -c, if container is specified.
 func (a *Accessor) Logs(namespace string, pod string, container string, previousLog bool) (string, error) {
 	return a.ctl.logs(namespace, pod, container, previousLog)
 }
 
+// GetResourcesYAML returns the resources of the given type (e.g. "virtualservices.networking.istio.io") in the
+// given namespace as YAML, using kubectl.
+func (a *Accessor) GetResourcesYAML(namespace, resourceType string) (string, error) {
+	return a.ctl.get(namespace, resourceType)
+}
+
 // Exec executes the provided command on the specified pod/container.
 func (a *Accessor) Exec(namespace, pod, container, command string) (string, error) {
 	return a.ctl.exec(namespace, pod, container, command)
@@ -94,6 +94,29 @@ func (c *kubectl) applyInternal(namespace string, files []string) error {
 	return nil
 }
 
+// applyFiles applies all of the given files with a single kubectl command, for configuration that doesn't
+// depend on CRDs being created by the same files.
+func (c *kubectl) applyFiles(namespace string, files []string) error {
+	args := ""
+	for _, f := range files {
+		if !isEmpty(f) {
+			args += " -f " + f
+		}
+	}
+	if args == "" {
+		return nil
+	}
+
+	command := fmt.Sprintf("kubectl apply %s %s%s", c.configArg(), namespaceArg(namespace), args)
+	scopes.CI.Infof("Applying YAML: %s", command)
+	s, err := shell.Execute(true, command)
+	if err != nil {
+		scopes.CI.Infof("(FAILED) Executing kubectl: %s (err: %v): %s", command, err, s)
+		return fmt.Errorf("%v: %s", err, s)
+	}
+	return nil
+}
+
 func isEmpty(f string) bool {
 	fileInfo, err := os.Stat(f)
 	if err != nil {
@@ -150,6 +173,21 @@ func (c *kubectl) deleteInternal(namespace string, files []string) (err error) {
 	return
 }
 
+// deleteAll deletes all resources of the given types in the namespace, optionally only the ones matching
+// the given field selector.
+func (c *kubectl) deleteAll(namespace, resourceTypes, fieldSelector string) error {
+	selector := ""
+	if fieldSelector != "" {
+		selector = "--field-selector " + fieldSelector
+	}
+	s, err := shell.Execute(true, "kubectl delete %s --all --ignore-not-found %s %s %s",
+		resourceTypes, selector, namespaceArg(namespace), c.configArg())
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, s)
+	}
+	return nil
+}
+
 // logs calls the logs command for the specified pod, with -c, if container is specified.
 func (c *kubectl) logs(namespace string, pod string, container string, previousLog bool) (string, error) {
 	cmd := fmt.Sprintf("kubectl logs %s %s %s %s %s",
@@ -164,6 +202,17 @@ func (c *kubectl) logs(namespace string, pod string, container string, previousL
 	return "", fmt.Errorf("%v: %s", err, s)
 }
 
+// get calls the get command for the specified resource type, and returns the resources as YAML.
+func (c *kubectl) get(namespace, resourceType string) (string, error) {
+	// Don't use combined output, so that warnings don't corrupt the YAML output.
+	s, err := shell.Execute(false, "kubectl get %s %s %s -o yaml", resourceType, namespaceArg(namespace), c.configArg())
+	if err == nil {
+		return s, nil
+	}
+
+	return "", fmt.Errorf("%v: %s", err, s)
+}
+
 func (c *kubectl) exec(namespace, pod, container, command string) (string, error) {
 	// Don't use combined output. The stderr and stdout streams are updated asynchronously and stderr can
 	// corrupt the JSON output.
@@ -0,0 +1,189 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	kubeApiCore "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	reconnectTimeout  = time.Minute * 2
+	reconnectDelay    = time.Second
+	reconnectMaxDelay = time.Second * 10
+)
+
+var _ PortForwarder = &managedPortForwarder{}
+
+// managedPortForwarder forwards a local port to a pod selected by labels, and reconnects when the forwarding
+// stops without being closed.
+type managedPortForwarder struct {
+	accessor   *Accessor
+	namespace  string
+	selectors  []string
+	remotePort uint16
+
+	// ctx is canceled when the forwarder is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	current   *defaultPortForwarder
+	localPort uint16
+	address   string
+}
+
+func newManagedPortForwarder(a *Accessor, namespace string, remotePort uint16, selectors []string) *managedPortForwarder {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &managedPortForwarder{
+		accessor:   a,
+		namespace:  namespace,
+		selectors:  selectors,
+		remotePort: remotePort,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+func (f *managedPortForwarder) Start() error {
+	if err := f.connect(); err != nil {
+		return err
+	}
+	go f.monitor()
+	return nil
+}
+
+func (f *managedPortForwarder) Address() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.address
+}
+
+func (f *managedPortForwarder) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ctx.Err() != nil {
+		return nil
+	}
+	f.cancel()
+	if f.current != nil {
+		current := f.current
+		f.current = nil
+		return current.Close()
+	}
+	return nil
+}
+
+// connect starts forwarding to a ready pod. After the first connection, the same local port is reused so
+// that the address of the forwarder doesn't change.
+func (f *managedPortForwarder) connect() error {
+	pod, err := f.readyPod()
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	localPort := f.localPort
+	f.mu.Unlock()
+
+	fw, err := newPortForwarder(f.accessor.restConfig, pod, localPort, f.remotePort)
+	if err != nil {
+		return err
+	}
+	if err := fw.Start(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ctx.Err() != nil {
+		return fw.Close()
+	}
+	if f.localPort == 0 {
+		_, port, err := net.SplitHostPort(fw.Address())
+		if err != nil {
+			_ = fw.Close()
+			return err
+		}
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			_ = fw.Close()
+			return err
+		}
+		f.localPort = uint16(p)
+	}
+	f.current = fw
+	f.address = fw.Address()
+	scopes.Framework.Debugf("Forwarding %s to %s/%s:%d", f.address, pod.Namespace, pod.Name, f.remotePort)
+	return nil
+}
+
+func (f *managedPortForwarder) readyPod() (kubeApiCore.Pod, error) {
+	pods, err := f.accessor.GetPods(f.namespace, f.selectors...)
+	if err != nil {
+		return kubeApiCore.Pod{}, err
+	}
+	for _, p := range pods {
+		p := p
+		if p.DeletionTimestamp == nil && CheckPodReady(&p) == nil {
+			return p, nil
+		}
+	}
+	return kubeApiCore.Pod{}, fmt.Errorf("no ready pod found in namespace %s matching selectors %v", f.namespace, f.selectors)
+}
+
+// monitor waits for the current forwarding to stop, and reconnects unless the forwarder was closed.
+func (f *managedPortForwarder) monitor() {
+	for {
+		f.mu.Lock()
+		current := f.current
+		f.mu.Unlock()
+
+		var err error
+		select {
+		case <-f.ctx.Done():
+			return
+		case err = <-current.errCh:
+		}
+
+		f.mu.Lock()
+		if f.ctx.Err() != nil {
+			f.mu.Unlock()
+			return
+		}
+		f.current = nil
+		f.mu.Unlock()
+
+		scopes.Framework.Warnf("Port forward %s to %s:%d stopped (%v), reconnecting",
+			f.Address(), f.namespace, f.remotePort, err)
+		_ = current.Close()
+		if err := retry.UntilSuccess(f.connect, retry.Context(f.ctx), retry.Timeout(reconnectTimeout),
+			retry.Delay(reconnectDelay), retry.Backoff(reconnectMaxDelay)); err != nil {
+			if f.ctx.Err() == nil {
+				scopes.Framework.Errorf("Failed re-establishing port forward to %s:%d: %v", f.namespace, f.remotePort, err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ContainerMetrics is the resource usage of a container, as reported by the metrics API.
+type ContainerMetrics struct {
+	Name   string
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// PodMetrics is the resource usage of the containers of a pod, as reported by the metrics API.
+type PodMetrics struct {
+	// Timestamp of the end of the window the usage was measured over.
+	Timestamp time.Time
+	// Window the usage was measured over.
+	Window     time.Duration
+	Containers []ContainerMetrics
+}
+
+// Container returns the metrics of the container with the given name, if present.
+func (m *PodMetrics) Container(name string) (ContainerMetrics, bool) {
+	for _, c := range m.Containers {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ContainerMetrics{}, false
+}
+
+// podMetricsJSON is the subset of the metrics.k8s.io/v1beta1 PodMetrics resource that is used.
+type podMetricsJSON struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Window     string    `json:"window"`
+	Containers []struct {
+		Name  string `json:"name"`
+		Usage struct {
+			CPU    resource.Quantity `json:"cpu"`
+			Memory resource.Quantity `json:"memory"`
+		} `json:"usage"`
+	} `json:"containers"`
+}
+
+func parsePodMetrics(raw []byte) (*PodMetrics, error) {
+	in := podMetricsJSON{}
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("failed parsing pod metrics: %v", err)
+	}
+	out := &PodMetrics{Timestamp: in.Timestamp}
+	if in.Window != "" {
+		w, err := time.ParseDuration(in.Window)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing pod metrics window: %v", err)
+		}
+		out.Window = w
+	}
+	for _, c := range in.Containers {
+		out.Containers = append(out.Containers, ContainerMetrics{
+			Name:   c.Name,
+			CPU:    c.Usage.CPU,
+			Memory: c.Usage.Memory,
+		})
+	}
+	return out, nil
+}
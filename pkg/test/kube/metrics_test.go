@@ -0,0 +1,51 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePodMetrics(t *testing.T) {
+	raw := `{
+  "kind": "PodMetrics",
+  "apiVersion": "metrics.k8s.io/v1beta1",
+  "metadata": {"name": "a-v1-1234", "namespace": "test"},
+  "timestamp": "2019-09-01T10:00:00Z",
+  "window": "30s",
+  "containers": [
+    {"name": "app", "usage": {"cpu": "1m", "memory": "8Mi"}},
+    {"name": "istio-proxy", "usage": {"cpu": "12345678n", "memory": "24Mi"}}
+  ]
+}`
+	m, err := parsePodMetrics([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Window != 30*time.Second || len(m.Containers) != 2 {
+		t.Fatalf("unexpected metrics: %+v", m)
+	}
+	proxy, ok := m.Container("istio-proxy")
+	if !ok {
+		t.Fatal("istio-proxy container not found")
+	}
+	if got := proxy.CPU.MilliValue(); got != 13 {
+		t.Errorf("cpu: got %dm, want 13m", got)
+	}
+	if got := proxy.Memory.Value(); got != 24*1024*1024 {
+		t.Errorf("memory: got %d, want %d", got, 24*1024*1024)
+	}
+}
@@ -52,16 +52,19 @@ type defaultPortForwarder struct {
 	readyCh   <-chan struct{}
 	address   string
 	output    *bytes.Buffer
+
+	// errCh receives the result of the forwarding once it stops, either because the forwarder is closed or
+	// because the connection to the pod is lost.
+	errCh chan error
 }
 
 func (f *defaultPortForwarder) Start() error {
-	errCh := make(chan error, 1)
 	go func() {
-		errCh <- f.forwarder.ForwardPorts()
+		f.errCh <- f.forwarder.ForwardPorts()
 	}()
 
 	select {
-	case err := <-errCh:
+	case err := <-f.errCh:
 		return fmt.Errorf("failure running port forward process: %v", err)
 	case <-f.readyCh:
 		address, err := parseAddress(f.output.String())
@@ -83,7 +86,7 @@ func (f *defaultPortForwarder) Close() error {
 	return nil
 }
 
-func newPortForwarder(restConfig *rest.Config, pod v1.Pod, localPort, remotePort uint16) (PortForwarder, error) {
+func newPortForwarder(restConfig *rest.Config, pod v1.Pod, localPort, remotePort uint16) (*defaultPortForwarder, error) {
 	restClient, err := rest.RESTClientFor(restConfig)
 	if err != nil {
 		return nil, err
@@ -112,6 +115,7 @@ func newPortForwarder(restConfig *rest.Config, pod v1.Pod, localPort, remotePort
 		stopCh:    stopCh,
 		readyCh:   readyCh,
 		output:    output,
+		errCh:     make(chan error, 1),
 	}, nil
 }
 
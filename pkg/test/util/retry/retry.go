@@ -15,7 +15,9 @@
 package retry
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"istio.io/istio/pkg/test"
@@ -37,8 +39,12 @@ var (
 )
 
 type config struct {
-	timeout time.Duration
-	delay   time.Duration
+	timeout  time.Duration
+	delay    time.Duration
+	maxDelay time.Duration
+	jitter   float64
+	ctx      context.Context
+	history  *History
 }
 
 // Option for a retry opteration.
@@ -58,6 +64,106 @@ func Delay(delay time.Duration) Option {
 	}
 }
 
+// Backoff doubles the delay after each failed attempt, up to the given maximum delay.
+func Backoff(maxDelay time.Duration) Option {
+	return func(cfg *config) {
+		cfg.maxDelay = maxDelay
+	}
+}
+
+// Jitter randomizes each delay by up to the given fraction of it (e.g. 0.2 for +/-20%), so that concurrent
+// retries don't hit the target in lockstep.
+func Jitter(fraction float64) Option {
+	return func(cfg *config) {
+		cfg.jitter = fraction
+	}
+}
+
+// Context stops the retry operation once the given context is done, even if the timeout is not reached yet.
+func Context(ctx context.Context) Option {
+	return func(cfg *config) {
+		cfg.ctx = ctx
+	}
+}
+
+// RecordHistory records all attempts of the retry operation in the given history, including the ones of
+// operations that succeed. The history is reset when the operation starts.
+func RecordHistory(h *History) Option {
+	return func(cfg *config) {
+		cfg.history = h
+	}
+}
+
+// Attempt of a retry operation.
+type Attempt struct {
+	// Start of the attempt.
+	Start time.Time
+	// Duration of the call to the retried function.
+	Duration time.Duration
+	// Err returned by the retried function, if any.
+	Err error
+}
+
+// History of the attempts of a retry operation.
+type History struct {
+	Attempts []Attempt
+	// Elapsed time since the start of the operation.
+	Elapsed time.Duration
+}
+
+// LastError returns the error of the last failed attempt, if any.
+func (h *History) LastError() error {
+	for i := len(h.Attempts) - 1; i >= 0; i-- {
+		if h.Attempts[i].Err != nil {
+			return h.Attempts[i].Err
+		}
+	}
+	return nil
+}
+
+// String returns the attempts, one per line. Consecutive attempts with the same outcome are listed as a range.
+func (h *History) String() string {
+	outcome := func(a Attempt) string {
+		if a.Err == nil {
+			return "not completed"
+		}
+		return a.Err.Error()
+	}
+
+	out := fmt.Sprintf("%d attempts in %v:\n", len(h.Attempts), h.Elapsed)
+	var begin time.Time
+	if len(h.Attempts) > 0 {
+		begin = h.Attempts[0].Start
+	}
+	for i := 0; i < len(h.Attempts); {
+		j := i
+		for j+1 < len(h.Attempts) && outcome(h.Attempts[j+1]) == outcome(h.Attempts[i]) {
+			j++
+		}
+		at := h.Attempts[i].Start.Sub(begin).Round(time.Millisecond)
+		if i == j {
+			out += fmt.Sprintf("  attempt %d (+%v): %s\n", i+1, at, outcome(h.Attempts[i]))
+		} else {
+			out += fmt.Sprintf("  attempts %d-%d (+%v): %s\n", i+1, j+1, at, outcome(h.Attempts[i]))
+		}
+		i = j + 1
+	}
+	return out
+}
+
+// Error returned when a retry operation times out or its context is done before it completes.
+type Error struct {
+	History
+
+	// Reason the operation stopped, e.g. "timeout".
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s while waiting after %d attempts in %v (last error: %v)",
+		e.Reason, len(e.Attempts), e.Elapsed.Round(time.Millisecond), e.LastError())
+}
+
 // RetriableFunc a function that can be retried.
 type RetriableFunc func() (result interface{}, completed bool, err error)
 
@@ -75,39 +181,79 @@ func UntilSuccess(fn func() error, options ...Option) error {
 	return e
 }
 
-// UntilSuccessOrFail calls UntilSuccess, and fails t with Fatalf if it ends up returning an error
+// UntilSuccessOrFail calls UntilSuccess, and fails t with Fatalf if it ends up returning an error. The history
+// of the attempts is included in the failure.
 func UntilSuccessOrFail(t test.Failer, fn func() error, options ...Option) {
 	t.Helper()
 	err := UntilSuccess(fn, options...)
 	if err != nil {
+		if e, ok := err.(*Error); ok {
+			t.Fatalf("retry.UntilSuccessOrFail: %v\n%s", err, e.History.String())
+		}
 		t.Fatalf("retry.UntilSuccessOrFail: %v", err)
 	}
 }
 
 // Do retries the given function, until there is a timeout, or until the function indicates that it has completed.
+// If the operation doesn't complete, the returned error is an *Error with the history of the attempts.
 func Do(fn RetriableFunc, options ...Option) (interface{}, error) {
 	cfg := defaultConfig
 	for _, option := range options {
 		option(&cfg)
 	}
+	ctx := cfg.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	history := cfg.history
+	if history == nil {
+		history = &History{}
+	}
+	*history = History{}
+
+	start := time.Now()
+	stop := func(reason string) error {
+		history.Elapsed = time.Since(start)
+		return &Error{History: *history, Reason: reason}
+	}
 
-	var lasterr error
+	delay := cfg.delay
 	to := time.After(cfg.timeout)
 	for {
 		select {
 		case <-to:
-			return nil, fmt.Errorf("timeout while waiting (last error: %v)", lasterr)
+			return nil, stop("timeout")
+		case <-ctx.Done():
+			return nil, stop(ctx.Err().Error())
 		default:
 		}
 
+		attempt := Attempt{Start: time.Now()}
 		result, completed, err := fn()
+		attempt.Duration = time.Since(attempt.Start)
+		attempt.Err = err
+		history.Attempts = append(history.Attempts, attempt)
 		if completed {
+			history.Elapsed = time.Since(start)
 			return result, err
 		}
-		if err != nil {
-			lasterr = err
+
+		select {
+		case <-time.After(jittered(delay, cfg.jitter)):
+		case <-ctx.Done():
 		}
+		if cfg.maxDelay > 0 {
+			delay *= 2
+			if delay > cfg.maxDelay {
+				delay = cfg.maxDelay
+			}
+		}
+	}
+}
 
-		<-time.After(cfg.delay)
+func jittered(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
 	}
+	return d + time.Duration(float64(d)*fraction*(2*rand.Float64()-1))
 }
@@ -0,0 +1,74 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUntilSuccess_History(t *testing.T) {
+	calls := 0
+	h := &History{}
+	err := UntilSuccess(func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, Delay(time.Millisecond), RecordHistory(h))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Attempts) != 3 || h.Attempts[2].Err != nil || h.LastError() == nil {
+		t.Fatalf("unexpected history: %v", h)
+	}
+	if !strings.Contains(h.String(), "attempts 1-2 (+0s): not yet") {
+		t.Fatalf("unexpected history:\n%s", h)
+	}
+}
+
+func TestUntilSuccess_Timeout(t *testing.T) {
+	err := UntilSuccess(func() error {
+		return errors.New("failing")
+	}, Timeout(50*time.Millisecond), Delay(time.Millisecond), Backoff(10*time.Millisecond), Jitter(0.5))
+	e, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if e.Reason != "timeout" || len(e.Attempts) < 2 || e.LastError().Error() != "failing" {
+		t.Fatalf("unexpected error: %v", e)
+	}
+}
+
+func TestUntilSuccess_Context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	start := time.Now()
+	err := UntilSuccess(func() error {
+		calls++
+		cancel()
+		return errors.New("failing")
+	}, Context(ctx), Delay(time.Minute))
+	if _, ok := err.(*Error); !ok {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if calls != 1 || time.Since(start) > 10*time.Second {
+		t.Fatalf("expected the retry to stop once the context was canceled: %v", err)
+	}
+}
@@ -59,3 +59,49 @@ func TestNamespace(t *testing.T) {
 		}
 	}
 }
+
+func TestNamespaceMetadata(t *testing.T) {
+	framework.NewTest(t).
+		RequiresEnvironment(environment.Kube).
+		Run(func(ctx framework.TestContext) {
+			env := ctx.Environment().(*kube.Environment)
+
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix:      "testns-rev",
+				Inject:      true,
+				Revision:    "canary",
+				Labels:      map[string]string{"team": "security"},
+				Annotations: map[string]string{"example.com/owner": "istio-test"},
+			})
+			n, err := env.Accessor.GetNamespace(ns.Name())
+			if err != nil {
+				t.Fatalf("Error getting the namespace(%q): %v", ns.Name(), err)
+			}
+			if _, found := n.Labels[namespace.InjectionLabel]; found {
+				t.Fatalf("unexpected injection label: ns: %s", ns.Name())
+			}
+			if got := n.Labels[namespace.RevisionLabel]; got != "canary" {
+				t.Fatalf("revision label: got %q, want %q", got, "canary")
+			}
+			if got := n.Labels["team"]; got != "security" {
+				t.Fatalf("team label: got %q, want %q", got, "security")
+			}
+			if got := n.Annotations["example.com/owner"]; got != "istio-test" {
+				t.Fatalf("owner annotation: got %q, want %q", got, "istio-test")
+			}
+
+			ns = namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix:              "testns-skip",
+				SkipInjectionLabels: true,
+			})
+			n, err = env.Accessor.GetNamespace(ns.Name())
+			if err != nil {
+				t.Fatalf("Error getting the namespace(%q): %v", ns.Name(), err)
+			}
+			for _, l := range []string{namespace.InjectionLabel, namespace.RevisionLabel} {
+				if _, found := n.Labels[l]; found {
+					t.Fatalf("unexpected label %s: ns: %s", l, ns.Name())
+				}
+			}
+		})
+}
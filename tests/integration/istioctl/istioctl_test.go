@@ -15,16 +15,21 @@
 package istioctl
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"testing"
 
+	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
 	"istio.io/istio/pkg/test/framework/components/galley"
 	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/components/istioctl"
+	"istio.io/istio/pkg/test/framework/components/namespace"
 	"istio.io/istio/pkg/test/framework/components/pilot"
 	"istio.io/istio/pkg/test/framework/resource"
 )
@@ -95,3 +100,53 @@ func TestVersion(t *testing.T) {
 			}
 		})
 }
+
+// TestProxyConfig does "istioctl proxy-config clusters <pod> -o json" to verify the CLI reads the configuration of a sidecar
+func TestProxyConfig(t *testing.T) {
+	framework.
+		NewTest(t).
+		Run(func(ctx framework.TestContext) {
+			g := galley.NewOrFail(t, ctx, galley.Config{})
+			p := pilot.NewOrFail(t, ctx, pilot.Config{Galley: g})
+
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "istioctl-proxy-config",
+				Inject: true,
+			})
+			var a echo.Instance
+			echoboot.NewBuilderOrFail(t, ctx).
+				With(&a, echo.Config{
+					Service:   "a",
+					Namespace: ns,
+					Ports: []echo.Port{
+						{
+							Name:        "http",
+							Protocol:    protocol.HTTP,
+							ServicePort: 80,
+						},
+					},
+					Galley: g,
+					Pilot:  p,
+				}).
+				BuildOrFail(t)
+
+			// The node ID is of the form "sidecar~<ip>~<pod>.<namespace>~<domain>".
+			nodeID := a.WorkloadsOrFail(t)[0].Sidecar().NodeID()
+			parts := strings.Split(nodeID, "~")
+			if len(parts) != 4 {
+				t.Fatalf("unexpected node ID %q", nodeID)
+			}
+
+			istioCtl := istioctl.NewOrFail(t, ctx, istioctl.Config{})
+			var clusters []map[string]interface{}
+			istioCtl.RunJSONOrFail(t, &clusters, "proxy-config", "clusters", parts[2], "-o", "json")
+
+			expected := fmt.Sprintf("outbound|80||%s", a.Config().FQDN())
+			for _, c := range clusters {
+				if c["name"] == expected {
+					return
+				}
+			}
+			t.Fatalf("cluster %s not found in the output of istioctl proxy-config for %s", expected, parts[2])
+		})
+}
@@ -28,12 +28,11 @@ import (
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
 	"istio.io/istio/pkg/test/framework/components/namespace"
-	"istio.io/istio/pkg/test/util/file"
 	"istio.io/istio/pkg/test/util/retry"
-	"istio.io/istio/pkg/test/util/tmpl"
 	"istio.io/istio/tests/common/jwt"
 	"istio.io/istio/tests/integration/security/util"
 	"istio.io/istio/tests/integration/security/util/connection"
+	"istio.io/istio/tests/integration/security/util/policy"
 )
 
 const (
@@ -53,9 +52,9 @@ func TestAuthnJwt(t *testing.T) {
 
 			var a, b, c echo.Instance
 			echoboot.NewBuilderOrFail(ctx, ctx).
-				With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
-				With(&b, util.EchoConfig("b", ns, false, nil, g, p)).
-				With(&c, util.EchoConfig("c", ns, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&b, util.EchoConfig("b", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&c, util.EchoConfig("c", ns, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 
 			testCases := []struct {
@@ -318,11 +317,11 @@ func TestAuthnJwt(t *testing.T) {
 				t.Run(testName, func(t *testing.T) {
 
 					// Apply the policy.
-					namespaceTmpl := map[string]string{
+					namespaceTmpl := policy.Params{
 						"Namespace": ns.Name(),
 					}
-					deploymentYAML := tmpl.EvaluateAllOrFail(t, namespaceTmpl,
-						file.AsStringOrFail(t, filepath.Join("testdata", c.configFile)))
+					deploymentYAML := policy.RenderFilesOrFail(t, namespaceTmpl,
+						filepath.Join("testdata", c.configFile))
 					g.ApplyConfigOrFail(t, ns, deploymentYAML...)
 					defer g.DeleteConfigOrFail(t, ns, deploymentYAML...)
 
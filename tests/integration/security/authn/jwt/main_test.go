@@ -43,6 +43,7 @@ func setupConfig(cfg *istio.Config) {
 func TestMain(m *testing.M) {
 	framework.
 		NewSuite("authn_jwt", m).
+		Features("security.authn.jwt").
 		RequireEnvironment(environment.Kube).
 		Label(label.CustomSetup).
 		SetupOnEnv(environment.Kube, istio.Setup(&inst, setupConfig)).
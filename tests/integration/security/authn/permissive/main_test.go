@@ -31,6 +31,7 @@ var (
 
 func TestMain(m *testing.M) {
 	framework.NewSuite("authn_permissive_test", m).
+		Features("security.authn.permissive").
 		RequireEnvironment(environment.Native).
 		Setup(func(ctx resource.Context) (err error) {
 			if g, err = galley.New(ctx, galley.Config{}); err != nil {
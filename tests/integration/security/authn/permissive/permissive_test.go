@@ -25,6 +25,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 
 	"istio.io/istio/tests/integration/security/util"
+	"istio.io/istio/tests/integration/security/util/policy"
 
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	xdsutil "github.com/envoyproxy/go-control-plane/pkg/wellknown"
@@ -90,21 +91,14 @@ func TestAuthnPermissive(t *testing.T) {
 			env := ctx.Environment().(*native.Environment)
 			ns := namespace.ClaimOrFail(t, ctx, env.SystemNamespace)
 
-			policy := `
-apiVersion: authentication.istio.io/v1alpha1
-kind: Policy
-metadata:
-  name: default
-spec:
-  peers:
-    - mtls:
-        mode: PERMISSIVE
-`
-			g.ApplyConfigOrFail(t, ns, policy)
+			g.ApplyConfigOrFail(t, ns, policy.AuthnPolicy.RenderOrFail(t, policy.Params{
+				"Name": "default",
+				"Mode": "PERMISSIVE",
+			}))
 
 			var a echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
-				With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 			t.Logf("echo boot warmed")
 			nodeID := a.WorkloadsOrFail(t)[0].Sidecar().NodeID()
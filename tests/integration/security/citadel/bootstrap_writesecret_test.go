@@ -27,9 +27,8 @@ import (
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/environment/kube"
 	"istio.io/istio/pkg/test/framework/components/namespace"
-	"istio.io/istio/pkg/test/util/file"
-	"istio.io/istio/pkg/test/util/tmpl"
 	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/tests/integration/security/util/policy"
 )
 
 const (
@@ -48,13 +47,13 @@ func TestCitadelBootstrapKubernetes(t *testing.T) {
 		Label(label.CustomSetup). // test depends on the clusterrole name being "istio-citadel-istio-system"
 		Run(func(ctx framework.TestContext) {
 			ns := namespace.ClaimOrFail(t, ctx, ist.Settings().IstioNamespace)
-			namespaceTmpl := map[string]string{
+			namespaceTmpl := policy.Params{
 				"Namespace": ns.Name(),
 			}
 
 			// Apply the RBAC policy to prevent Citadel from reading the CA-root secret.
-			policies := tmpl.EvaluateAllOrFail(t, namespaceTmpl,
-				file.AsStringOrFail(t, noReadRBACConfigTmpl))
+			policies := policy.RenderFilesOrFail(t, namespaceTmpl,
+				noReadRBACConfigTmpl)
 
 			g.ApplyConfigOrFail(t, ns, policies...)
 
@@ -106,8 +105,8 @@ func TestCitadelBootstrapKubernetes(t *testing.T) {
 
 			// Recover the normal setup for other tests.
 			// Recover the normal RBAC policy.
-			policies = tmpl.EvaluateAllOrFail(t, namespaceTmpl,
-				file.AsStringOrFail(t, normalRBACConfigTmpl))
+			policies = policy.RenderFilesOrFail(t, namespaceTmpl,
+				normalRBACConfigTmpl)
 
 			g.ApplyConfigOrFail(t, ns, policies...)
 
@@ -31,6 +31,7 @@ var (
 
 func TestMain(m *testing.M) {
 	framework.NewSuite("citadel_test", m).
+		Features("security.citadel").
 		RequireEnvironment(environment.Kube).
 		SetupOnEnv(environment.Kube, istio.Setup(&ist, nil)).
 		Setup(func(ctx resource.Context) (err error) {
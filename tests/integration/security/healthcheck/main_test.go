@@ -34,6 +34,7 @@ var (
 
 func TestMain(m *testing.M) {
 	framework.NewSuite("mtls_healthcheck", m).
+		Features("security.authn.mtls.healthcheck").
 		RequireEnvironment(environment.Kube).
 		Label(label.CustomSetup).
 		SetupOnEnv(environment.Kube, istio.Setup(&ist, setupConfig)).
@@ -20,10 +20,12 @@ import (
 	"testing"
 
 	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/tests/integration/security/util/policy"
 )
 
 // TestMtlsHealthCheck verifies Kubernetes HTTP health check can work when mTLS
@@ -38,19 +40,12 @@ func TestMtlsHealthCheck(t *testing.T) {
 			ns := namespace.ClaimOrFail(t, ctx, "default")
 
 			// Apply the policy.
-			policyYAML := `apiVersion: "authentication.istio.io/v1alpha1"
-kind: "Policy"
-metadata:
-  name: "mtls-strict-for-healthcheck"
-spec:
-  targets:
-  - name: "healthcheck"
-  peers:
-    - mtls:
-        mode: STRICT
-`
-			g.ApplyConfigOrFail(t, ns, policyYAML)
-			defer g.DeleteConfigOrFail(t, ns, policyYAML)
+			policyYAML := policy.AuthnPolicy.RenderOrFail(t, policy.Params{
+				"Name":    "mtls-strict-for-healthcheck",
+				"Service": "healthcheck",
+				"Mode":    "STRICT",
+			})
+			config.NewOrFail(t, ctx, config.Config{Galley: g}).ApplyOrFail(t, ns, policyYAML)
 
 			var healthcheck echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
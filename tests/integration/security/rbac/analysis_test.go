@@ -0,0 +1,73 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"testing"
+
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/analysis"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+const (
+	analysisBinding = `apiVersion: "rbac.istio.io/v1alpha1"
+kind: ServiceRoleBinding
+metadata:
+  name: bind-viewer
+spec:
+  subjects:
+  - user: "*"
+  roleRef:
+    kind: ServiceRole
+    name: viewer
+`
+	analysisRole = `apiVersion: "rbac.istio.io/v1alpha1"
+kind: ServiceRole
+metadata:
+  name: viewer
+spec:
+  rules:
+  - services: ["*"]
+    methods: ["GET"]
+`
+)
+
+// TestAnalysis_MissingServiceRole verifies that the analyzers report a ServiceRoleBinding referring to a
+// ServiceRole that doesn't exist, until the ServiceRole is created.
+func TestAnalysis_MissingServiceRole(t *testing.T) {
+	framework.NewTest(t).
+		RequiresEnvironment(environment.Kube).
+		Run(func(ctx framework.TestContext) {
+			ns := namespace.NewOrFail(t, ctx, namespace.Config{
+				Prefix: "rbac-analysis",
+			})
+			cfg := config.NewOrFail(t, ctx, config.Config{Galley: g})
+			code := msg.ReferencedResourceNotFound.Code()
+
+			cfg.ApplyOrFail(t, ns, analysisBinding)
+			analysis.RunOrFail(t, ctx, ns).
+				ForResource("ServiceRoleBinding", ns, "bind-viewer").
+				CheckContainsOrFail(t, code)
+
+			cfg.ApplyOrFail(t, ns, analysisRole)
+			analysis.RunOrFail(t, ctx, ns).
+				ForResource("ServiceRoleBinding", ns, "bind-viewer").
+				CheckNotContainsOrFail(t, code)
+		})
+}
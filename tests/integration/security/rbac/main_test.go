@@ -41,6 +41,7 @@ const (
 func TestMain(m *testing.M) {
 	framework.
 		NewSuite("rbac", m).
+		Features("security.authz.rbac").
 		RequireEnvironment(environment.Kube).
 		Label(label.CustomSetup).
 		SetupOnEnv(environment.Kube, istio.Setup(&inst, setupConfig)).
@@ -20,15 +20,15 @@ import (
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/test/echo/common/scheme"
 	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/namespace"
-	"istio.io/istio/pkg/test/util/file"
-	"istio.io/istio/pkg/test/util/tmpl"
 	"istio.io/istio/tests/common/jwt"
 	"istio.io/istio/tests/integration/security/util"
 	"istio.io/istio/tests/integration/security/util/connection"
+	"istio.io/istio/tests/integration/security/util/policy"
 )
 
 func TestV1_OptionalJWT(t *testing.T) {
@@ -43,8 +43,8 @@ func TestV1_OptionalJWT(t *testing.T) {
 
 			var a, b echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
-				With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
-				With(&b, util.EchoConfig("b", ns, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&b, util.EchoConfig("b", ns, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 
 			cases := []TestCase{
@@ -75,15 +75,17 @@ func TestV1_OptionalJWT(t *testing.T) {
 				},
 			}
 
-			args := map[string]string{
+			args := policy.Params{
 				"Namespace": ns.Name(),
 			}
-			policies := tmpl.EvaluateAllOrFail(t, args,
-				file.AsStringOrFail(t, rbacClusterConfigTmpl),
-				file.AsStringOrFail(t, "testdata/v1-policy-optional-jwt.yaml.tmpl"))
+			policies := policy.RenderFilesOrFail(t, args,
+				rbacClusterConfigTmpl,
+				"testdata/v1-policy-optional-jwt.yaml.tmpl")
 
-			g.ApplyConfigOrFail(t, ns, policies...)
-			defer g.DeleteConfigOrFail(t, ns, policies...)
+			config.NewOrFail(t, ctx, config.Config{
+				Galley:  g,
+				WaitFor: []echo.Instance{a, b},
+			}).ApplyOrFail(t, ns, policies...)
 
 			RunRBACTest(t, cases)
 		})
@@ -104,9 +106,9 @@ func TestV1_Group(t *testing.T) {
 
 			var a, b, c echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
-				With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
-				With(&b, util.EchoConfig("b", ns, false, nil, g, p)).
-				With(&c, util.EchoConfig("c", ns, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&b, util.EchoConfig("b", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&c, util.EchoConfig("c", ns, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 
 			cases := []TestCase{
@@ -164,15 +166,17 @@ func TestV1_Group(t *testing.T) {
 				},
 			}
 
-			args := map[string]string{
+			args := policy.Params{
 				"Namespace": ns.Name(),
 			}
-			policies := tmpl.EvaluateAllOrFail(t, args,
-				file.AsStringOrFail(t, rbacClusterConfigTmpl),
-				file.AsStringOrFail(t, "testdata/v1-policy-group.yaml.tmpl"))
+			policies := policy.RenderFilesOrFail(t, args,
+				rbacClusterConfigTmpl,
+				"testdata/v1-policy-group.yaml.tmpl")
 
-			g.ApplyConfigOrFail(t, ns, policies...)
-			defer g.DeleteConfigOrFail(t, ns, policies...)
+			config.NewOrFail(t, ctx, config.Config{
+				Galley:  g,
+				WaitFor: []echo.Instance{a, b, c},
+			}).ApplyOrFail(t, ns, policies...)
 
 			RunRBACTest(t, cases)
 		})
@@ -188,10 +192,10 @@ func TestV1_GRPC(t *testing.T) {
 			})
 			var a, b, c, d echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
-				With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
-				With(&b, util.EchoConfig("b", ns, false, nil, g, p)).
-				With(&c, util.EchoConfig("c", ns, false, nil, g, p)).
-				With(&d, util.EchoConfig("d", ns, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&b, util.EchoConfig("b", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&c, util.EchoConfig("c", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&d, util.EchoConfig("d", ns, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 
 			cases := []TestCase{
@@ -230,15 +234,17 @@ func TestV1_GRPC(t *testing.T) {
 				},
 			}
 
-			namespaceTmpl := map[string]string{
+			namespaceTmpl := policy.Params{
 				"Namespace": ns.Name(),
 			}
 
-			policies := tmpl.EvaluateAllOrFail(t, namespaceTmpl,
-				file.AsStringOrFail(t, rbacClusterConfigTmpl),
-				file.AsStringOrFail(t, "testdata/v1-policy-grpc.yaml.tmpl"))
-			g.ApplyConfigOrFail(t, ns, policies...)
-			defer g.DeleteConfigOrFail(t, ns, policies...)
+			policies := policy.RenderFilesOrFail(t, namespaceTmpl,
+				rbacClusterConfigTmpl,
+				"testdata/v1-policy-grpc.yaml.tmpl")
+			config.NewOrFail(t, ctx, config.Config{
+				Galley:  g,
+				WaitFor: []echo.Instance{a, b, c, d},
+			}).ApplyOrFail(t, ns, policies...)
 
 			RunRBACTest(t, cases)
 		})
@@ -308,14 +314,16 @@ func TestV1_Path(t *testing.T) {
 				newTestCase("/public/%2e%2e/%2e/private", false),
 			}
 
-			args := map[string]string{
+			args := policy.Params{
 				"Namespace": ns.Name(),
 			}
-			policies := tmpl.EvaluateAllOrFail(t, args,
-				file.AsStringOrFail(t, rbacClusterConfigTmpl),
-				file.AsStringOrFail(t, "testdata/v1-policy-path.yaml.tmpl"))
-			g.ApplyConfigOrFail(t, ns, policies...)
-			defer g.DeleteConfigOrFail(t, ns, policies...)
+			policies := policy.RenderFilesOrFail(t, args,
+				rbacClusterConfigTmpl,
+				"testdata/v1-policy-path.yaml.tmpl")
+			config.NewOrFail(t, ctx, config.Config{
+				Galley:  g,
+				WaitFor: []echo.Instance{a, b},
+			}).ApplyOrFail(t, ns, policies...)
 
 			RunRBACTest(t, cases)
 		})
@@ -19,14 +19,14 @@ import (
 
 	"istio.io/istio/pkg/test/echo/common/scheme"
 	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/namespace"
-	"istio.io/istio/pkg/test/util/file"
-	"istio.io/istio/pkg/test/util/tmpl"
 	"istio.io/istio/tests/integration/security/util"
 	"istio.io/istio/tests/integration/security/util/connection"
+	"istio.io/istio/tests/integration/security/util/policy"
 )
 
 // TestV1beta1_OverrideV1alpha1 tests v1beta1 authorization overrides the v1alpha1 RBAC policy for
@@ -42,9 +42,9 @@ func TestV1beta1_OverrideV1alpha1(t *testing.T) {
 
 			var a, b, c echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
-				With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
-				With(&b, util.EchoConfig("b", ns, false, nil, g, p)).
-				With(&c, util.EchoConfig("c", ns, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&b, util.EchoConfig("b", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&c, util.EchoConfig("c", ns, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 
 			newTestCase := func(target echo.Instance, path string, expectAllowed bool) TestCase {
@@ -68,13 +68,15 @@ func TestV1beta1_OverrideV1alpha1(t *testing.T) {
 				newTestCase(c, "/path-v1beta1", false),
 			}
 
-			args := map[string]string{
+			args := policy.Params{
 				"Namespace": ns.Name(),
 			}
-			policies := tmpl.EvaluateAllOrFail(t, args,
-				file.AsStringOrFail(t, "testdata/v1beta1-override-v1alpha1.yaml.tmpl"))
-			g.ApplyConfigOrFail(t, ns, policies...)
-			defer g.DeleteConfigOrFail(t, ns, policies...)
+			policies := policy.RenderFilesOrFail(t, args,
+				"testdata/v1beta1-override-v1alpha1.yaml.tmpl")
+			config.NewOrFail(t, ctx, config.Config{
+				Galley:  g,
+				WaitFor: []echo.Instance{a, b, c},
+			}).ApplyOrFail(t, ns, policies...)
 
 			RunRBACTest(t, cases)
 		})
@@ -102,10 +104,10 @@ func TestV1beta1_WorkloadSelector(t *testing.T) {
 
 			var a, bInNS1, cInNS1, cInNS2 echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
-				With(&a, util.EchoConfig("a", ns1, false, nil, g, p)).
-				With(&bInNS1, util.EchoConfig("b", ns1, false, nil, g, p)).
-				With(&cInNS1, util.EchoConfig("c", ns1, false, nil, g, p)).
-				With(&cInNS2, util.EchoConfig("c", ns2, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns1, util.WithGalley(g), util.WithPilot(p))).
+				With(&bInNS1, util.EchoConfig("b", ns1, util.WithGalley(g), util.WithPilot(p))).
+				With(&cInNS1, util.EchoConfig("c", ns1, util.WithGalley(g), util.WithPilot(p))).
+				With(&cInNS2, util.EchoConfig("c", ns2, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 
 			newTestCase := func(namePrefix string, target echo.Instance, path string, expectAllowed bool) TestCase {
@@ -149,24 +151,24 @@ func TestV1beta1_WorkloadSelector(t *testing.T) {
 				newTestCase("[cInNS2]", cInNS2, "/policy-ns-root-c", true),
 			}
 
-			args := map[string]string{
+			args := policy.Params{
 				"Namespace1":    ns1.Name(),
 				"Namespace2":    ns2.Name(),
 				"RootNamespace": rootNamespace,
 			}
 
-			applyPolicy := func(filename string, ns namespace.Instance) []string {
-				policy := tmpl.EvaluateAllOrFail(t, args, file.AsStringOrFail(t, filename))
-				g.ApplyConfigOrFail(t, ns, policy...)
-				return policy
+			cfg := config.NewOrFail(t, ctx, config.Config{
+				Galley:  g,
+				WaitFor: []echo.Instance{a, bInNS1, cInNS1, cInNS2},
+			})
+			applyPolicy := func(filename string, ns namespace.Instance) {
+				policies := policy.RenderFilesOrFail(t, args, filename)
+				cfg.ApplyOrFail(t, ns, policies...)
 			}
 
-			policyNS1 := applyPolicy("testdata/v1beta1-workload-ns1.yaml.tmpl", ns1)
-			defer g.DeleteConfigOrFail(t, ns1, policyNS1...)
-			policyNS2 := applyPolicy("testdata/v1beta1-workload-ns2.yaml.tmpl", ns2)
-			defer g.DeleteConfigOrFail(t, ns2, policyNS2...)
-			policyNSRoot := applyPolicy("testdata/v1beta1-workload-ns-root.yaml.tmpl", rootNS{})
-			defer g.DeleteConfigOrFail(t, rootNS{}, policyNSRoot...)
+			applyPolicy("testdata/v1beta1-workload-ns1.yaml.tmpl", ns1)
+			applyPolicy("testdata/v1beta1-workload-ns2.yaml.tmpl", ns2)
+			applyPolicy("testdata/v1beta1-workload-ns-root.yaml.tmpl", rootNS{})
 
 			RunRBACTest(t, cases)
 		})
@@ -18,10 +18,10 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"istio.io/istio/pkg/test/echo/common/scheme"
 	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
 	"istio.io/istio/pkg/test/framework/components/echo"
 	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
 	"istio.io/istio/pkg/test/framework/components/environment"
@@ -29,8 +29,10 @@ import (
 	"istio.io/istio/pkg/test/framework/components/namespace"
 	"istio.io/istio/pkg/test/framework/components/pilot"
 	"istio.io/istio/pkg/test/util/file"
+	"istio.io/istio/pkg/test/util/retry"
 	"istio.io/istio/tests/integration/security/util"
 	"istio.io/istio/tests/integration/security/util/connection"
+	"istio.io/istio/tests/integration/security/util/report"
 )
 
 type TestCase struct {
@@ -56,6 +58,7 @@ type TestCase struct {
 type Context struct {
 	ctx                   framework.TestContext
 	g                     galley.Instance
+	config                config.Instance
 	p                     pilot.Instance
 	Namespace             namespace.Instance
 	A, B, Headless, Naked echo.Instance
@@ -70,16 +73,21 @@ func CreateContext(ctx framework.TestContext, g galley.Instance, p pilot.Instanc
 
 	var a, b, headless, naked echo.Instance
 	echoboot.NewBuilderOrFail(ctx, ctx).
-		With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
-		With(&b, util.EchoConfig("b", ns, false, nil, g, p)).
-		With(&headless, util.EchoConfig("headless", ns, false, nil, g, p)).
-		With(&naked, util.EchoConfig("naked", ns, false, echo.NewAnnotations().
-			SetBool(echo.SidecarInject, false), g, p)).
+		With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
+		With(&b, util.EchoConfig("b", ns, util.WithGalley(g), util.WithPilot(p))).
+		With(&headless, util.EchoConfig("headless", ns, util.WithGalley(g), util.WithPilot(p))).
+		With(&naked, util.EchoConfig("naked", ns, util.WithGalley(g), util.WithPilot(p),
+			util.WithAnnotations(echo.NewAnnotations().SetBool(echo.SidecarInject, false)))).
 		BuildOrFail(ctx)
+	cfg := config.NewOrFail(ctx, ctx, config.Config{
+		Galley:  g,
+		WaitFor: []echo.Instance{a, b, headless},
+	})
 
 	return Context{
 		ctx:       ctx,
 		g:         g,
+		config:    cfg,
 		p:         p,
 		Namespace: ns,
 		A:         a,
@@ -89,7 +97,8 @@ func CreateContext(ctx framework.TestContext, g galley.Instance, p pilot.Instanc
 	}
 }
 
-// Run runs the given reachability test cases with the context.
+// Run runs the given reachability test cases with the context. The results of the calls of each test case are
+// exported as a JSON artifact named after its config file.
 func (rc *Context) Run(testCases []TestCase) {
 	callOptions := []echo.CallOptions{
 		{
@@ -115,23 +124,20 @@ func (rc *Context) Run(testCases []TestCase) {
 		c := c
 		testName := strings.TrimSuffix(c.ConfigFile, filepath.Ext(c.ConfigFile))
 		test := rc.ctx.NewSubTest(testName)
+		recorder := &report.Recorder{}
 
 		if c.RequiredEnvironment != "" {
 			test.RequiresEnvironment(c.RequiredEnvironment)
 		}
 
 		test.Run(func(ctx framework.TestContext) {
-			// Apply the policy.
+			// Apply the policy, and wait until the sidecars receive it.
 			policyYAML := file.AsStringOrFail(ctx, filepath.Join("../testdata", c.ConfigFile))
-			rc.g.ApplyConfigOrFail(ctx, c.Namespace, policyYAML)
+			rc.config.ApplyOrFail(ctx, c.Namespace, policyYAML)
 			ctx.WhenDone(func() error {
-				return rc.g.DeleteConfig(c.Namespace, policyYAML)
+				return rc.config.Delete(c.Namespace, policyYAML)
 			})
 
-			// Give some time for the policy propagate.
-			// TODO: query pilot or app to know instead of sleep.
-			time.Sleep(10 * time.Second)
-
 			for _, src := range []echo.Instance{rc.A, rc.B, rc.Headless, rc.Naked} {
 				for _, dest := range []echo.Instance{rc.A, rc.B, rc.Headless, rc.Naked} {
 					for _, opts := range callOptions {
@@ -164,12 +170,24 @@ func (rc *Context) Run(testCases []TestCase) {
 										Options:       opts,
 										ExpectSuccess: expectSuccess,
 									}
-									checker.CheckOrFail(ctx)
+									history := retry.History{}
+									err := retry.UntilSuccess(checker.Check, retry.RecordHistory(&history))
+									recorder.Add(report.NewCall(src, dest, opts, report.ExpectationOf(expectSuccess), history, err))
+									if err != nil {
+										ctx.Fatal(err)
+									}
 								})
 						}
 					}
 				}
 			}
 		})
+
+		// The parallel sub-tests are done once the test returns. Skipped tests have no results to export.
+		if calls := recorder.Calls(); len(calls) > 0 {
+			if _, err := report.Write(rc.ctx, testName, calls); err != nil {
+				rc.ctx.Error(err)
+			}
+		}
 	}
 }
@@ -55,13 +55,14 @@ func TestReachability(t *testing.T) {
 						return src != rctx.Headless || opts.Target != rctx.Headless
 					},
 					ExpectSuccess: func(src echo.Instance, opts echo.CallOptions) bool {
-						if src == rctx.Naked && opts.Target == rctx.Naked {
+						srcNaked, dstNaked := src.Config().Naked, opts.Target.Config().Naked
+						if srcNaked && dstNaked {
 							// naked->naked should always succeed.
 							return true
 						}
 
 						// If one of the two endpoints is naked, expect failure.
-						return src != rctx.Naked && opts.Target != rctx.Naked
+						return !srcNaked && !dstNaked
 					},
 				},
 				{
@@ -46,8 +46,8 @@ func TestSdsCitadelCaControlPlaneAuthDisabled(t *testing.T) {
 
 			var a, b echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
-				With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
-				With(&b, util.EchoConfig("b", ns, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&b, util.EchoConfig("b", ns, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 
 			checkers := []connection.Checker{
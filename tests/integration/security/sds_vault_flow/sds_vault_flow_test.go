@@ -27,10 +27,9 @@ import (
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/istio"
 	"istio.io/istio/pkg/test/framework/components/namespace"
-	"istio.io/istio/pkg/test/util/file"
 	"istio.io/istio/pkg/test/util/retry"
-	"istio.io/istio/pkg/test/util/tmpl"
 	"istio.io/istio/tests/integration/security/util/connection"
+	"istio.io/istio/tests/integration/security/util/policy"
 )
 
 func TestSdsVaultCaFlow(t *testing.T) {
@@ -48,8 +47,8 @@ func TestSdsVaultCaFlow(t *testing.T) {
 
 			var a, b echo.Instance
 			echoboot.NewBuilderOrFail(t, ctx).
-				With(&a, util.EchoConfig("a", ns, false, nil, g, p)).
-				With(&b, util.EchoConfig("b", ns, false, nil, g, p)).
+				With(&a, util.EchoConfig("a", ns, util.WithGalley(g), util.WithPilot(p))).
+				With(&b, util.EchoConfig("b", ns, util.WithGalley(g), util.WithPilot(p))).
 				BuildOrFail(t)
 
 			checkers := []connection.Checker{
@@ -65,13 +64,12 @@ func TestSdsVaultCaFlow(t *testing.T) {
 			}
 
 			// Apply the policy
-			deployment := tmpl.EvaluateOrFail(t, file.AsStringOrFail(t, "testdata/config.yaml"),
-				map[string]string{
-					"Namespace": ns.Name(),
-				})
+			deployment := policy.RenderFilesOrFail(t, policy.Params{
+				"Namespace": ns.Name(),
+			}, "testdata/config.yaml")
 
-			g.ApplyConfigOrFail(t, ns, deployment)
-			defer g.DeleteConfigOrFail(t, ns, deployment)
+			g.ApplyConfigOrFail(t, ns, deployment...)
+			defer g.DeleteConfigOrFail(t, ns, deployment...)
 
 			// Sleep 10 seconds for the policy to take effect.
 			time.Sleep(10 * time.Second)
@@ -0,0 +1,104 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+)
+
+// Apps is the canonical set of echo applications used by the security tests.
+type Apps struct {
+	Namespace namespace.Instance
+
+	A        echo.Instance
+	B        echo.Instance
+	C        echo.Instance
+	Headless echo.Instance
+	Naked    echo.Instance
+
+	// VM is a mock VM workload. It is only deployed in the Kubernetes environment and is nil otherwise.
+	VM echo.Instance
+}
+
+// All returns all of the deployed applications.
+func (a *Apps) All() []echo.Instance {
+	out := []echo.Instance{a.A, a.B, a.C, a.Headless, a.Naked}
+	if a.VM != nil {
+		out = append(out, a.VM)
+	}
+	return out
+}
+
+// SetupApps deploys the canonical set of security test applications ("a", "b", "c", "headless",
+// "naked" and, in the Kubernetes environment, "vm") into the given namespace. The given options are
+// applied to every application. The applications are tracked by the context and are cleaned up when
+// the context is done.
+func SetupApps(ctx resource.Context, ns namespace.Instance, opts ...EchoOption) (*Apps, error) {
+	newConfig := func(name string, extra ...EchoOption) echo.Config {
+		return EchoConfig(name, ns, append(append([]EchoOption{}, opts...), extra...)...)
+	}
+
+	builder, err := echoboot.NewBuilder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apps := &Apps{
+		Namespace: ns,
+	}
+	builder = builder.
+		With(&apps.A, newConfig("a")).
+		With(&apps.B, newConfig("b")).
+		With(&apps.C, newConfig("c")).
+		With(&apps.Headless, newConfig("headless", WithHeadless())).
+		With(&apps.Naked, newConfig("naked", WithNaked()))
+	if ctx.Environment().EnvironmentName() == environment.Kube {
+		builder = builder.With(&apps.VM, newConfig("vm", WithDeployAsVM()))
+	}
+	if err = builder.Build(); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// SetupAppsOrFail calls SetupApps and fails t if an error occurs.
+func SetupAppsOrFail(t test.Failer, ctx resource.Context, ns namespace.Instance, opts ...EchoOption) *Apps {
+	t.Helper()
+	apps, err := SetupApps(ctx, ns, opts...)
+	if err != nil {
+		t.Fatalf("util.SetupAppsOrFail: %v", err)
+	}
+	return apps
+}
+
+// SetupIsolatedApps deploys the canonical set of security test applications into a new namespace with the
+// given prefix, which is only used by the given test. Tests using isolated applications can run in parallel
+// with each other (see framework.Test.RunParallel), while sharing the control plane installed by the suite, as
+// long as they only apply namespaced configuration. The namespace and applications are removed when the test
+// is done.
+func SetupIsolatedApps(ctx framework.TestContext, prefix string, opts ...EchoOption) *Apps {
+	ctx.Helper()
+	ns := namespace.NewOrFail(ctx, ctx, namespace.Config{
+		Prefix: prefix,
+		Inject: true,
+	})
+	return SetupAppsOrFail(ctx, ctx, ns, opts...)
+}
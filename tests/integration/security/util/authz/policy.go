@@ -0,0 +1,271 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package authz generates AuthorizationPolicy resources for tests from Go types, instead of templated YAML.
+package authz
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+const (
+	defaultPolicyName = "policy"
+)
+
+// Source of a request, matches if any of the non-empty fields match.
+type Source struct {
+	Principals        []string `json:"principals,omitempty"`
+	RequestPrincipals []string `json:"requestPrincipals,omitempty"`
+	Namespaces        []string `json:"namespaces,omitempty"`
+	IPBlocks          []string `json:"ipBlocks,omitempty"`
+}
+
+// From returns a Source matching the identities of the given echo instances.
+func From(instances ...echo.Instance) Source {
+	s := Source{}
+	for _, i := range instances {
+		s.Principals = append(s.Principals, strings.TrimPrefix(util.Principal(i), "spiffe://"))
+	}
+	return s
+}
+
+// FromNamespaces returns a Source matching all workloads of the given namespaces.
+func FromNamespaces(namespaces ...namespace.Instance) Source {
+	s := Source{}
+	for _, ns := range namespaces {
+		s.Namespaces = append(s.Namespaces, ns.Name())
+	}
+	return s
+}
+
+// FromRequestPrincipals returns a Source matching the given JWT principals ("<iss>/<sub>").
+func FromRequestPrincipals(principals ...string) Source {
+	return Source{RequestPrincipals: principals}
+}
+
+// AnySource returns a Source matching all requests.
+func AnySource() Source {
+	return Source{}
+}
+
+func (s Source) isEmpty() bool {
+	return len(s.Principals)+len(s.RequestPrincipals)+len(s.Namespaces)+len(s.IPBlocks) == 0
+}
+
+// Operation of a request, matches if all of the non-empty fields match.
+type Operation struct {
+	Hosts   []string `json:"hosts,omitempty"`
+	Ports   []string `json:"ports,omitempty"`
+	Methods []string `json:"methods,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+}
+
+func (o Operation) isEmpty() bool {
+	return len(o.Hosts)+len(o.Ports)+len(o.Methods)+len(o.Paths) == 0
+}
+
+// Target of a rule: the workload the policy applies to and the operations that are allowed on it.
+type Target struct {
+	workload  echo.Instance
+	operation Operation
+}
+
+// To returns a Target selecting the given workload. If nil, the policy applies to all workloads in
+// the namespace.
+func To(workload echo.Instance) Target {
+	return Target{workload: workload}
+}
+
+// Paths restricts the target to the given paths.
+func (t Target) Paths(paths ...string) Target {
+	t.operation.Paths = append(append([]string{}, t.operation.Paths...), paths...)
+	return t
+}
+
+// Methods restricts the target to the given methods.
+func (t Target) Methods(methods ...string) Target {
+	t.operation.Methods = append(append([]string{}, t.operation.Methods...), methods...)
+	return t
+}
+
+// Ports restricts the target to the given ports.
+func (t Target) Ports(ports ...string) Target {
+	t.operation.Ports = append(append([]string{}, t.operation.Ports...), ports...)
+	return t
+}
+
+// Hosts restricts the target to the given hosts.
+func (t Target) Hosts(hosts ...string) Target {
+	t.operation.Hosts = append(append([]string{}, t.operation.Hosts...), hosts...)
+	return t
+}
+
+func (t Target) key() string {
+	if t.workload == nil {
+		return ""
+	}
+	return t.workload.Config().Service
+}
+
+// Condition on request attributes that must be met for the rule to match.
+type Condition struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+// When returns a Condition requiring the given attribute (e.g. "request.headers[x-token]") to have one
+// of the given values.
+func When(key string, values ...string) Condition {
+	return Condition{Key: key, Values: values}
+}
+
+type rule struct {
+	From []map[string]Source    `json:"from,omitempty"`
+	To   []map[string]Operation `json:"to,omitempty"`
+	When []Condition            `json:"when,omitempty"`
+}
+
+type binding struct {
+	target Target
+	rules  []rule
+}
+
+// Policy is a builder of AuthorizationPolicy resources. A separate resource is generated for each
+// target workload of the rules.
+type Policy struct {
+	ns       namespace.Instance
+	name     string
+	bindings []*binding
+}
+
+// NewPolicy returns a new, empty Policy in the given namespace.
+func NewPolicy(ns namespace.Instance) *Policy {
+	return &Policy{
+		ns:   ns,
+		name: defaultPolicyName,
+	}
+}
+
+// Named sets the name of the policy. Resource names are derived from it.
+func (p *Policy) Named(name string) *Policy {
+	p.name = name
+	return p
+}
+
+// Allow requests from the given source to the given target, if all of the conditions are met.
+func (p *Policy) Allow(from Source, to Target, when ...Condition) *Policy {
+	r := rule{
+		When: when,
+	}
+	if !from.isEmpty() {
+		r.From = []map[string]Source{{"source": from}}
+	}
+	if !to.operation.isEmpty() {
+		r.To = []map[string]Operation{{"operation": to.operation}}
+	}
+
+	for _, b := range p.bindings {
+		if b.target.key() == to.key() {
+			b.rules = append(b.rules, r)
+			return p
+		}
+	}
+	p.bindings = append(p.bindings, &binding{
+		target: to,
+		rules:  []rule{r},
+	})
+	return p
+}
+
+// YAML returns the generated AuthorizationPolicy resources.
+func (p *Policy) YAML() (string, error) {
+	docs := make([]string, 0, len(p.bindings))
+	for _, b := range p.bindings {
+		name := p.name
+		spec := map[string]interface{}{
+			"rules": b.rules,
+		}
+		if b.target.workload != nil {
+			name = fmt.Sprintf("%s-%s", p.name, b.target.key())
+			spec["selector"] = map[string]interface{}{
+				"matchLabels": map[string]string{
+					"app": b.target.key(),
+				},
+			}
+		}
+
+		out, err := yaml.Marshal(map[string]interface{}{
+			"apiVersion": "security.istio.io/v1beta1",
+			"kind":       "AuthorizationPolicy",
+			"metadata": map[string]string{
+				"name":      name,
+				"namespace": p.ns.Name(),
+			},
+			"spec": spec,
+		})
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(out))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
+
+// YAMLOrFail calls YAML and fails t if an error occurs.
+func (p *Policy) YAMLOrFail(t test.Failer) string {
+	t.Helper()
+	out, err := p.YAML()
+	if err != nil {
+		t.Fatalf("authz.Policy.YAMLOrFail: %v", err)
+	}
+	return out
+}
+
+// Apply the policy, and wait until it is distributed.
+func (p *Policy) Apply(c config.Instance) error {
+	out, err := p.YAML()
+	if err != nil {
+		return err
+	}
+	return c.Apply(p.ns, out)
+}
+
+// Delete the policy, and wait until the deletion is distributed.
+func (p *Policy) Delete(c config.Instance) error {
+	out, err := p.YAML()
+	if err != nil {
+		return err
+	}
+	return c.Delete(p.ns, out)
+}
+
+// ApplyOrFail applies the policy, waits until it is distributed, and deletes it when the given context is done.
+func (p *Policy) ApplyOrFail(ctx framework.TestContext, c config.Instance) {
+	ctx.Helper()
+	out := p.YAMLOrFail(ctx)
+	c.ApplyOrFail(ctx, p.ns, out)
+	ctx.WhenDone(func() error {
+		return c.Delete(p.ns, out)
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util/report"
+)
+
+const (
+	// nonMatchingPrincipal is allowed by the policies that don't match the request.
+	nonMatchingPrincipal = "cluster.local/ns/nonexistent/sa/nonexistent"
+
+	// defaultArtifact is the default name of the JSON artifact of the results of a PrecedenceTest.
+	defaultArtifact = "authz-precedence"
+)
+
+// Scope of a policy: the workloads it applies to.
+type Scope int
+
+const (
+	// MeshScope policies are in the root namespace without a selector, and apply to all workloads of the mesh.
+	MeshScope Scope = iota
+	// NamespaceScope policies are in the namespace of the workload without a selector.
+	NamespaceScope
+	// WorkloadScope policies are in the namespace of the workload and select it.
+	WorkloadScope
+
+	numScopes = 3
+)
+
+func (s Scope) String() string {
+	switch s {
+	case MeshScope:
+		return "mesh"
+	case NamespaceScope:
+		return "namespace"
+	case WorkloadScope:
+		return "workload"
+	default:
+		return fmt.Sprintf("scope(%d)", int(s))
+	}
+}
+
+// Effect of the policy of a scope on the request.
+type Effect int
+
+const (
+	// NoPolicy at the scope.
+	NoPolicy Effect = iota
+	// AllowRequest is a policy allowing the request.
+	AllowRequest
+	// AllowOther is a policy allowing other requests only.
+	AllowOther
+)
+
+func (e Effect) String() string {
+	switch e {
+	case NoPolicy:
+		return "none"
+	case AllowRequest:
+		return "allow"
+	case AllowOther:
+		return "allow-other"
+	default:
+		return fmt.Sprintf("effect(%d)", int(e))
+	}
+}
+
+// PrecedenceCase is a combination of the policies at each scope.
+//
+// Only ALLOW policies are supported by this version of AuthorizationPolicy, for which the policies of all
+// scopes are combined: a workload without policies allows all requests, otherwise a request is allowed if
+// any of the policies that apply to the workload allows it.
+type PrecedenceCase [numScopes]Effect
+
+// PrecedenceCases returns all combinations of the policies at each scope.
+func PrecedenceCases() []PrecedenceCase {
+	effects := []Effect{NoPolicy, AllowRequest, AllowOther}
+	out := []PrecedenceCase{{}}
+	for s := 0; s < numScopes; s++ {
+		next := make([]PrecedenceCase, 0, len(out)*len(effects))
+		for _, c := range out {
+			for _, e := range effects {
+				c[s] = e
+				next = append(next, c)
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+// Allowed returns whether the request is expected to be allowed.
+func (c PrecedenceCase) Allowed() bool {
+	applied := false
+	for _, e := range c {
+		switch e {
+		case AllowRequest:
+			return true
+		case AllowOther:
+			applied = true
+		}
+	}
+	return !applied
+}
+
+// Name of the case, e.g. "mesh=none,namespace=allow,workload=allow-other".
+func (c PrecedenceCase) Name() string {
+	parts := make([]string, 0, numScopes)
+	for s, e := range c {
+		parts = append(parts, fmt.Sprintf("%s=%s", Scope(s), e))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Policies returns the policies of the case for requests from the given source to the given target, with
+// the mesh scoped policy in the given root namespace.
+func (c PrecedenceCase) Policies(root namespace.Instance, from, to echo.Instance) []*Policy {
+	var out []*Policy
+	for s, e := range c {
+		if e == NoPolicy {
+			continue
+		}
+		src := From(from)
+		if e == AllowOther {
+			src = Source{Principals: []string{nonMatchingPrincipal}}
+		}
+		scope := Scope(s)
+		name := "precedence-" + scope.String()
+		switch scope {
+		case MeshScope:
+			out = append(out, NewPolicy(root).Named(name).Allow(src, To(nil)))
+		case NamespaceScope:
+			out = append(out, NewPolicy(to.Config().Namespace).Named(name).Allow(src, To(nil)))
+		case WorkloadScope:
+			out = append(out, NewPolicy(to.Config().Namespace).Named(name).Allow(src, To(to)))
+		}
+	}
+	return out
+}
+
+// PrecedenceTest runs a sub-test for each of the cases, applying the policies of the case and verifying that
+// requests from the given source to the given target are allowed or denied as expected. The mesh scoped
+// policies affect all workloads, so the other tests must not run in parallel.
+type PrecedenceTest struct {
+	// Config used for applying the policies.
+	Config config.Instance
+	// RootNamespace of the mesh, where the mesh scoped policies are applied.
+	RootNamespace namespace.Instance
+	// From is the source of the requests.
+	From echo.Instance
+	// Options of the calls. The target must be set.
+	Options echo.CallOptions
+	// Cases to run. Defaults to all PrecedenceCases.
+	Cases []PrecedenceCase
+	// Artifact is the name of the JSON artifact the results of the cases are exported as, in the work
+	// directory of the test. Defaults to "authz-precedence".
+	Artifact string
+}
+
+// Run the test.
+func (t PrecedenceTest) Run(ctx framework.TestContext) {
+	ctx.Helper()
+	cases := t.Cases
+	if len(cases) == 0 {
+		cases = PrecedenceCases()
+	}
+	artifact := t.Artifact
+	if artifact == "" {
+		artifact = defaultArtifact
+	}
+	recorder := &report.Recorder{}
+	for _, c := range cases {
+		c := c
+		ctx.NewSubTest(c.Name()).Run(func(ctx framework.TestContext) {
+			for _, p := range c.Policies(t.RootNamespace, t.From, t.Options.Target) {
+				p.ApplyOrFail(ctx, t.Config)
+			}
+			checker := check.Denied()
+			if c.Allowed() {
+				checker = check.OK()
+			}
+			history := retry.History{}
+			err := retry.UntilSuccess(func() error {
+				_, err := t.From.Call(t.Options, checker)
+				return err
+			}, retry.RecordHistory(&history))
+
+			call := report.NewCall(t.From, t.Options.Target, t.Options, report.ExpectationOf(c.Allowed()), history, err)
+			call.Case = c.Name()
+			recorder.Add(call)
+			if err != nil {
+				ctx.Fatalf("%v\n%s", err, history.String())
+			}
+		})
+	}
+	if _, err := recorder.Write(ctx, artifact); err != nil {
+		ctx.Error(err)
+	}
+}
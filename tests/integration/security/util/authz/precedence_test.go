@@ -0,0 +1,55 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"testing"
+)
+
+func TestPrecedenceCases(t *testing.T) {
+	cases := PrecedenceCases()
+	if len(cases) != 27 {
+		t.Fatalf("expected 27 cases, got %d", len(cases))
+	}
+	names := make(map[string]bool)
+	for _, c := range cases {
+		if names[c.Name()] {
+			t.Fatalf("duplicate case %s", c.Name())
+		}
+		names[c.Name()] = true
+	}
+}
+
+func TestPrecedenceCaseAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		c       PrecedenceCase
+		allowed bool
+	}{
+		{PrecedenceCase{NoPolicy, NoPolicy, NoPolicy}, true},
+		{PrecedenceCase{AllowOther, NoPolicy, NoPolicy}, false},
+		{PrecedenceCase{NoPolicy, AllowOther, NoPolicy}, false},
+		{PrecedenceCase{NoPolicy, NoPolicy, AllowOther}, false},
+		{PrecedenceCase{AllowOther, AllowOther, AllowOther}, false},
+		{PrecedenceCase{AllowRequest, AllowOther, AllowOther}, true},
+		{PrecedenceCase{AllowOther, NoPolicy, AllowRequest}, true},
+		{PrecedenceCase{NoPolicy, AllowRequest, NoPolicy}, true},
+	} {
+		t.Run(tc.c.Name(), func(t *testing.T) {
+			if got := tc.c.Allowed(); got != tc.allowed {
+				t.Fatalf("Allowed: got %v, want %v", got, tc.allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+// ReceivedRequests returns the last n requests received by the echo server of the given workload of target,
+// oldest first, as the server received them from its sidecar. This allows verifying what the sidecar
+// forwarded, e.g. that it stripped the Authorization header. If n is zero, all recorded requests are returned.
+func ReceivedRequests(target echo.Instance, w echo.Workload, n int) ([]response.Request, error) {
+	path := common.RequestsPath
+	if n > 0 {
+		path = fmt.Sprintf("%s?count=%d", path, n)
+	}
+	resp, err := loopbackRequest(target, w, path)
+	if err != nil {
+		return nil, err
+	}
+	return resp.CapturedRequests, nil
+}
+
+// ReceivedRequestsOrFail calls ReceivedRequests and fails t if an error occurs.
+func ReceivedRequestsOrFail(t test.Failer, target echo.Instance, w echo.Workload, n int) []response.Request {
+	t.Helper()
+	requests, err := ReceivedRequests(target, w, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return requests
+}
+
+// ReceivedRequestWithID returns the request with the given X-Request-Id received by the echo server of the
+// given workload of target, among the recorded requests.
+func ReceivedRequestWithID(target echo.Instance, w echo.Workload, requestID string) (*response.Request, error) {
+	requests, err := ReceivedRequests(target, w, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(requests) - 1; i >= 0; i-- {
+		for k, values := range requests[i].Headers {
+			if !strings.EqualFold(k, string(response.RequestIDField)) {
+				continue
+			}
+			for _, v := range values {
+				if v == requestID {
+					return &requests[i], nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("no request with ID %s received by %s", requestID, w.Name())
+}
@@ -0,0 +1,322 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cert mints root CAs, intermediate CAs, workload and server certificates for security tests, so
+// that certificate chains, key types and lifetimes don't need to be expressed as static fixtures.
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"time"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test"
+)
+
+const (
+	// CACertsSecretName is the name of the secret holding the plugged-in signing CA of Citadel.
+	CACertsSecretName = "cacerts"
+
+	// Keys of the cacerts secret.
+	CACertID    = "ca-cert.pem"
+	CAKeyID     = "ca-key.pem"
+	RootCertID  = "root-cert.pem"
+	CertChainID = "cert-chain.pem"
+
+	defaultOrg = "Istio Test"
+	defaultTTL = 24 * time.Hour
+)
+
+// KeyType of the generated private keys.
+type KeyType int
+
+const (
+	// RSA2048 keys. The default.
+	RSA2048 KeyType = iota
+	// RSA4096 keys.
+	RSA4096
+	// ECDSAP256 keys.
+	ECDSAP256
+	// ECDSAP384 keys.
+	ECDSAP384
+)
+
+func (k KeyType) generate() (crypto.Signer, error) {
+	switch k {
+	case RSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case RSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case ECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case ECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key type %d", k)
+	}
+}
+
+// Options for generating a certificate.
+type Options struct {
+	// CommonName of the subject.
+	CommonName string
+	// Organization of the subject. Defaults to "Istio Test".
+	Organization string
+
+	// DNSNames, IPs and URIs added as subject alternative names.
+	DNSNames []string
+	IPs      []net.IP
+	URIs     []string
+
+	// KeyType of the private key.
+	KeyType KeyType
+
+	// NotBefore of the certificate. Defaults to now. Can be set in the future, or in the past together
+	// with TTL to create expired certificates.
+	NotBefore time.Time
+	// TTL of the certificate. Defaults to 24h.
+	TTL time.Duration
+
+	// MaxPathLen of a CA certificate. Zero means no limit.
+	MaxPathLen int
+}
+
+// Bundle is a generated certificate together with its private key and issuer chain.
+type Bundle struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+
+	// issuer of the certificate, nil for self-signed roots.
+	issuer *Bundle
+}
+
+// NewRoot generates a self-signed root CA.
+func NewRoot(opts Options) (*Bundle, error) {
+	return newBundle(nil, opts, true, nil)
+}
+
+// NewRootOrFail calls NewRoot and fails t if an error occurs.
+func NewRootOrFail(t test.Failer, opts Options) *Bundle {
+	t.Helper()
+	return orFail(t, "NewRoot")(NewRoot(opts))
+}
+
+// NewIntermediate generates an intermediate CA signed by b.
+func (b *Bundle) NewIntermediate(opts Options) (*Bundle, error) {
+	return newBundle(b, opts, true, nil)
+}
+
+// NewIntermediateOrFail calls NewIntermediate and fails t if an error occurs.
+func (b *Bundle) NewIntermediateOrFail(t test.Failer, opts Options) *Bundle {
+	t.Helper()
+	return orFail(t, "NewIntermediate")(b.NewIntermediate(opts))
+}
+
+// NewWorkload generates a workload certificate for mutual TLS, signed by b. The given SPIFFE identity is
+// added to the URI SANs.
+func (b *Bundle) NewWorkload(spiffeID string, opts Options) (*Bundle, error) {
+	opts.URIs = append([]string{spiffeID}, opts.URIs...)
+	return newBundle(b, opts, false, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth})
+}
+
+// NewWorkloadOrFail calls NewWorkload and fails t if an error occurs.
+func (b *Bundle) NewWorkloadOrFail(t test.Failer, spiffeID string, opts Options) *Bundle {
+	t.Helper()
+	return orFail(t, "NewWorkload")(b.NewWorkload(spiffeID, opts))
+}
+
+// NewServer generates a server certificate (e.g. for a gateway) signed by b.
+func (b *Bundle) NewServer(opts Options) (*Bundle, error) {
+	return newBundle(b, opts, false, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth})
+}
+
+// NewServerOrFail calls NewServer and fails t if an error occurs.
+func (b *Bundle) NewServerOrFail(t test.Failer, opts Options) *Bundle {
+	t.Helper()
+	return orFail(t, "NewServer")(b.NewServer(opts))
+}
+
+// NewClient generates a client certificate signed by b.
+func (b *Bundle) NewClient(opts Options) (*Bundle, error) {
+	return newBundle(b, opts, false, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth})
+}
+
+// NewClientOrFail calls NewClient and fails t if an error occurs.
+func (b *Bundle) NewClientOrFail(t test.Failer, opts Options) *Bundle {
+	t.Helper()
+	return orFail(t, "NewClient")(b.NewClient(opts))
+}
+
+// Root returns the root CA of the chain of b.
+func (b *Bundle) Root() *Bundle {
+	r := b
+	for r.issuer != nil {
+		r = r.issuer
+	}
+	return r
+}
+
+// CertPEM returns the PEM encoded certificate.
+func (b *Bundle) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b.Cert.Raw})
+}
+
+// KeyPEM returns the PEM encoded private key, in PKCS#1 form for RSA keys and in SEC 1 form for ECDSA keys.
+func (b *Bundle) KeyPEM() []byte {
+	switch k := b.Key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)})
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			// Only fails for unsupported curves, which KeyType doesn't generate.
+			panic(err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	default:
+		panic(fmt.Sprintf("unsupported key %T", b.Key))
+	}
+}
+
+// ChainPEM returns the PEM encoded chain from the certificate up to and including the root.
+func (b *Bundle) ChainPEM() []byte {
+	out := bytes.Buffer{}
+	for c := b; c != nil; c = c.issuer {
+		out.Write(c.CertPEM())
+	}
+	return out.Bytes()
+}
+
+// RootPEM returns the PEM encoded root of the chain.
+func (b *Bundle) RootPEM() []byte {
+	return b.Root().CertPEM()
+}
+
+// CACertsSecret returns the "cacerts" secret that plugs b in as the signing CA of Citadel.
+func (b *Bundle) CACertsSecret(namespace string) *kubeApiCore.Secret {
+	return &kubeApiCore.Secret{
+		ObjectMeta: kubeApiMeta.ObjectMeta{
+			Name:      CACertsSecretName,
+			Namespace: namespace,
+		},
+		Data: b.CACertsData(),
+	}
+}
+
+// CACertsData returns the file layout of the "cacerts" secret for b.
+func (b *Bundle) CACertsData() map[string][]byte {
+	return map[string][]byte{
+		CACertID:    b.CertPEM(),
+		CAKeyID:     b.KeyPEM(),
+		RootCertID:  b.RootPEM(),
+		CertChainID: b.ChainPEM(),
+	}
+}
+
+func newBundle(issuer *Bundle, opts Options, isCA bool, extKeyUsage []x509.ExtKeyUsage) (*Bundle, error) {
+	key, err := opts.KeyType.generate()
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := opts.NotBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now().Add(-time.Minute)
+	}
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+	org := opts.Organization
+	if org == "" {
+		org = defaultOrg
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   opts.CommonName,
+			Organization: []string{org},
+		},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(ttl),
+		DNSNames:              opts.DNSNames,
+		IPAddresses:           opts.IPs,
+		ExtKeyUsage:           extKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	for _, u := range opts.URIs {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI SAN %q: %v", u, err)
+		}
+		template.URIs = append(template.URIs, parsed)
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+		template.MaxPathLen = opts.MaxPathLen
+		template.MaxPathLenZero = false
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	}
+
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.Cert, issuer.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bundle{
+		Cert:   cert,
+		Key:    key,
+		issuer: issuer,
+	}, nil
+}
+
+func orFail(t test.Failer, name string) func(*Bundle, error) *Bundle {
+	return func(b *Bundle, err error) *Bundle {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("cert.%sOrFail: %v", name, err)
+		}
+		return b
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	root := NewRootOrFail(t, Options{CommonName: "root"})
+	intermediate := root.NewIntermediateOrFail(t, Options{CommonName: "intermediate", KeyType: ECDSAP256})
+	workload := intermediate.NewWorkloadOrFail(t, "spiffe://cluster.local/ns/default/sa/a", Options{TTL: time.Hour})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.Cert)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intermediate.Cert)
+	if _, err := workload.Cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Fatalf("failed to verify workload certificate: %v", err)
+	}
+
+	if got := workload.Cert.URIs[0].String(); got != "spiffe://cluster.local/ns/default/sa/a" {
+		t.Fatalf("unexpected URI SAN: %s", got)
+	}
+	if got := workload.Cert.NotAfter.Sub(workload.Cert.NotBefore); got != time.Hour {
+		t.Fatalf("unexpected TTL: %v", got)
+	}
+	if !bytes.Equal(workload.RootPEM(), root.CertPEM()) {
+		t.Fatal("unexpected root")
+	}
+
+	chain := workload.ChainPEM()
+	count := 0
+	for block, rest := pem.Decode(chain); block != nil; block, rest = pem.Decode(rest) {
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 certificates in chain, got %d", count)
+	}
+}
+
+func TestCACertsData(t *testing.T) {
+	root := NewRootOrFail(t, Options{CommonName: "root"})
+	ca := root.NewIntermediateOrFail(t, Options{CommonName: "ca"})
+
+	data := ca.CACertsData()
+	for _, key := range []string{CACertID, CAKeyID, RootCertID, CertChainID} {
+		if len(data[key]) == 0 {
+			t.Fatalf("missing %s", key)
+		}
+	}
+	if block, _ := pem.Decode(data[CAKeyID]); block == nil || block.Type != "RSA PRIVATE KEY" {
+		t.Fatalf("unexpected key encoding")
+	}
+}
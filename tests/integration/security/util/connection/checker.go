@@ -19,6 +19,7 @@ import (
 
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
 	"istio.io/istio/pkg/test/util/retry"
 )
 
@@ -32,10 +33,8 @@ type Checker struct {
 // Check whether the target endpoint is reachable from the source.
 func (c *Checker) Check() error {
 	results, err := c.From.Call(c.Options)
+	err = check.OK().Check(results, err)
 	if c.ExpectSuccess {
-		if err == nil {
-			err = results.CheckOK()
-		}
 		if err != nil {
 			return fmt.Errorf("%s to %s:%s using %s: expected success but failed: %v",
 				c.From.Config().Service, c.Options.Target.Config().Service, c.Options.PortName, c.Options.Scheme, err)
@@ -44,7 +43,7 @@ func (c *Checker) Check() error {
 	}
 
 	// Expect failure...
-	if err == nil && results.CheckOK() == nil {
+	if err == nil {
 		return fmt.Errorf("%s to %s:%s using %s: expected failed, actually success",
 			c.From.Config().Service, c.Options.Target.Config().Service, c.Options.PortName, c.Options.Scheme)
 	}
@@ -0,0 +1,188 @@
+//  Copyright 2019 Istio Authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+const (
+	requestIDHeader = "X-Request-Id"
+
+	// lifetimeTimeoutSlack is added to the duration of the stream for the default timeout of the call.
+	lifetimeTimeoutSlack = 30 * time.Second
+	// receivedPollInterval is the interval at which the target is polled until it received the request.
+	receivedPollInterval = 200 * time.Millisecond
+)
+
+// Outcome of a long-lived connection that was established before a change of configuration.
+type Outcome string
+
+const (
+	// Continued connections completed all of their messages.
+	Continued Outcome = "continued"
+	// Drained connections were closed gracefully before completing their messages.
+	Drained Outcome = "drained"
+	// Reset connections failed before completing their messages.
+	Reset Outcome = "reset"
+)
+
+// OutcomeOf returns the outcome of the given response of a streaming call that was made with the given number
+// of stream messages. gRPC streams that end early always fail with a status, so they are never Drained: the
+// StreamCode of the response tells why they were Reset.
+func OutcomeOf(r *client.ParsedResponse, messages int) Outcome {
+	switch {
+	case r.StreamError != "":
+		return Reset
+	case r.TCPResult != "" && r.TCPResult != response.TCPResultOK && r.TCPResult != response.TCPResultClosed:
+		return Reset
+	case r.StreamCode != codes.OK:
+		return Reset
+	case len(r.StreamMessages) < messages:
+		return Drained
+	}
+	return Continued
+}
+
+// Lifetime is a test utility for testing what happens to a connection through the sidecars that is already
+// established when the configuration changes, e.g. when a policy denying the connection is applied or the
+// mTLS mode is switched. Unlike Checker, which makes new connections after the change, Lifetime opens a
+// single long-lived connection, applies the change once the target received the request over it, and
+// verifies whether the connection continued, was drained or was reset.
+type Lifetime struct {
+	From echo.Instance
+	// Options of the call. StreamMessages and StreamInterval are required and must keep the connection open
+	// long enough for the change to take effect. The http and tcp schemes stream server-sent events over the
+	// connection, and the grpc scheme messages over an HTTP/2 stream. Count is ignored: a single connection
+	// is opened. If the Timeout is zero, it defaults to the duration of the stream plus 30 seconds.
+	Options echo.CallOptions
+	// Change of configuration applied while the connection is open.
+	Change func() error
+	// Expected outcome of the connection.
+	Expected Outcome
+}
+
+// Run opens the connection, applies the change once the target received the request over the connection, and
+// returns the response once the connection completed.
+func (l *Lifetime) Run() (*client.ParsedResponse, error) {
+	if l.Options.StreamMessages <= 0 || l.Options.StreamInterval <= 0 {
+		return nil, errors.New("connection lifetime: StreamMessages and StreamInterval are required")
+	}
+	opts := l.Options
+	opts.Count = 1
+	if opts.Timeout == 0 {
+		opts.Timeout = time.Duration(opts.StreamMessages)*opts.StreamInterval + lifetimeTimeoutSlack
+	}
+	opts.Headers = make(http.Header, len(l.Options.Headers)+1)
+	for k, v := range l.Options.Headers {
+		opts.Headers[k] = v
+	}
+	id := uuid.New().String()
+	opts.Headers.Set(requestIDHeader, id)
+
+	type result struct {
+		resp client.ParsedResponses
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := l.From.Call(opts)
+		done <- result{resp, err}
+	}()
+
+	if err := waitUntilReceived(opts.Target, id, opts.Timeout, func() bool { return len(done) > 0 }); err != nil {
+		// Wait for the call, so that it does not outlive the test.
+		r := <-done
+		if r.err != nil {
+			return nil, fmt.Errorf("connection lifetime: %v (call failed: %v)", err, r.err)
+		}
+		return nil, fmt.Errorf("connection lifetime: %v", err)
+	}
+
+	changeErr := l.Change()
+	r := <-done
+	if changeErr != nil {
+		return nil, fmt.Errorf("connection lifetime: failed applying the change: %v", changeErr)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.resp) != 1 {
+		return nil, fmt.Errorf("connection lifetime: expected 1 response, received %d", len(r.resp))
+	}
+	return r.resp[0], nil
+}
+
+// Check runs the connection and verifies that it had the expected outcome.
+func (l *Lifetime) Check() error {
+	resp, err := l.Run()
+	if err != nil {
+		return err
+	}
+	if got := OutcomeOf(resp, l.Options.StreamMessages); got != l.Expected {
+		return fmt.Errorf("%s to %s:%s using %s: expected the connection to be %s, but it was %s after %d of %d messages"+
+			" (stream error: %q, tcp result: %q)",
+			l.From.Config().Service, l.Options.Target.Config().Service, l.Options.PortName, l.Options.Scheme,
+			l.Expected, got, len(resp.StreamMessages), l.Options.StreamMessages, resp.StreamError, resp.TCPResult)
+	}
+	return nil
+}
+
+// CheckOrFail calls Check and fails t if an error occurs. Unlike Checker.CheckOrFail, it is not retried,
+// since the change is only applied once.
+func (l *Lifetime) CheckOrFail(t test.Failer) {
+	t.Helper()
+	if err := l.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitUntilReceived waits until one of the workloads of target received the request with the given ID. It
+// fails if the timeout expires, or if completed reports that the call completed first, e.g. because the
+// stream was too short for the change to be applied while it is open.
+func waitUntilReceived(target echo.Instance, requestID string, timeout time.Duration, completed func() bool) error {
+	workloads, err := target.Workloads()
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		for _, w := range workloads {
+			if _, err := util.ReceivedRequestWithID(target, w, requestID); err == nil {
+				return nil
+			}
+		}
+		if completed() {
+			return fmt.Errorf("the connection completed before request %s was received by %s; "+
+				"increase StreamMessages or StreamInterval", requestID, target.Config().Service)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("request %s was not received by %s within %v", requestID, target.Config().Service, timeout)
+		}
+		time.Sleep(receivedPollInterval)
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package controlplane scales the deployments of the control plane up and down, or restarts their pods, while
+// traffic flows between echo workloads. After every step it verifies that Citadel still issues workload
+// certificates and that Pilot still distributes configuration to the sidecars.
+package controlplane
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+	"istio.io/istio/tests/integration/security/util/traffic"
+)
+
+const (
+	// Pilot is the deployment of Pilot, which distributes the configuration.
+	Pilot = "istio-pilot"
+	// Citadel is the deployment of Citadel, which signs the workload certificates.
+	Citadel = "istio-citadel"
+
+	defaultSettle = 10 * time.Second
+)
+
+// Step changing the control plane.
+type Step struct {
+	// Deployment of the control plane changed by the step, e.g. Pilot or Citadel.
+	Deployment string
+
+	// Replicas the deployment is scaled to. Ignored if Restart is set.
+	Replicas int
+
+	// Restart deletes the pods of the deployment one at a time instead, waiting for each replacement to be
+	// ready, as happens during a rolling upgrade or the failure of a node.
+	Restart bool
+}
+
+// String implements fmt.Stringer
+func (s Step) String() string {
+	if s.Restart {
+		return fmt.Sprintf("restart %s", s.Deployment)
+	}
+	return fmt.Sprintf("scale %s to %d", s.Deployment, s.Replicas)
+}
+
+// Config of a run.
+type Config struct {
+	// IstioNamespace the control plane is deployed to.
+	IstioNamespace string
+
+	// Steps applied in order.
+	Steps []Step
+
+	// Traffic that is sent continuously during the run.
+	Traffic []traffic.Config
+
+	// WorkloadNamespaces whose workload certificates are re-issued after every step, to verify that they are
+	// still signed.
+	WorkloadNamespaces []namespace.Instance
+
+	// Config used for verifying the distribution of configuration after every step. If not set, only the
+	// certificates are verified.
+	Config config.Instance
+
+	// Probe returns the configuration applied, in ProbeNamespace, after the given step, and deleted again
+	// once it reached the sidecars. It's required with Config.
+	Probe func(step int) string
+
+	// ProbeNamespace the probe configuration is applied to.
+	ProbeNamespace namespace.Instance
+
+	// Settle is the duration traffic is sent for after every step, before verifying it. Defaults to 10s.
+	Settle time.Duration
+}
+
+// StepReport is the outcome of a step.
+type StepReport struct {
+	Step Step
+	// Time the step started.
+	Time time.Time
+	// Duration until the changed deployment was rolled out.
+	Duration time.Duration
+	// Propagation of the probe configuration, if any.
+	Propagation config.Propagation
+	// Err is the first failure of the step, or of verifying the control plane after it.
+	Err error
+}
+
+// Report of a run.
+type Report struct {
+	Steps   []StepReport
+	Traffic []traffic.Result
+}
+
+// Check verifies that all of the steps succeeded, and that at most maxErrors calls of each traffic
+// generator failed.
+func (r *Report) Check(maxErrors int) error {
+	for _, s := range r.Steps {
+		if s.Err != nil {
+			return fmt.Errorf("step %q failed: %v", s.Step, s.Err)
+		}
+	}
+	for _, t := range r.Traffic {
+		if err := t.CheckMaxErrors(maxErrors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String implements fmt.Stringer
+func (r *Report) String() string {
+	sb := &strings.Builder{}
+	for _, s := range r.Steps {
+		_, _ = fmt.Fprintf(sb, "%s %s: rolled out in %v", s.Time.Format(time.RFC3339Nano), s.Step, s.Duration)
+		if len(s.Propagation.Latencies) > 0 {
+			_, _ = fmt.Fprintf(sb, ", config propagated in %v", s.Propagation.Max())
+		}
+		if s.Err != nil {
+			_, _ = fmt.Fprintf(sb, ", failed: %v", s.Err)
+		}
+		sb.WriteString("\n")
+	}
+	for _, t := range r.Traffic {
+		_, _ = fmt.Fprintf(sb, "%s\n", t)
+	}
+	return sb.String()
+}
+
+// Run applies the steps while sending traffic, and returns the report. The deployments are scaled back to
+// their original number of replicas afterwards. Only supported in the Kubernetes environment.
+func Run(ctx resource.Context, cfg Config) (*Report, error) {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return nil, fmt.Errorf("controlplane: only supported in the %s environment", environment.Kube)
+	}
+	if cfg.Config != nil && (cfg.Probe == nil || cfg.ProbeNamespace == nil) {
+		return nil, fmt.Errorf("controlplane: Probe and ProbeNamespace are required with Config")
+	}
+	if cfg.Settle == 0 {
+		cfg.Settle = defaultSettle
+	}
+
+	original := make(map[string]int)
+	for _, s := range cfg.Steps {
+		if _, ok := original[s.Deployment]; ok {
+			continue
+		}
+		d, err := env.GetDeployment(cfg.IstioNamespace, s.Deployment)
+		if err != nil {
+			return nil, err
+		}
+		original[s.Deployment] = 1
+		if d.Spec.Replicas != nil {
+			original[s.Deployment] = int(*d.Spec.Replicas)
+		}
+	}
+
+	generators := make([]traffic.Generator, 0, len(cfg.Traffic))
+	for _, t := range cfg.Traffic {
+		generators = append(generators, traffic.NewGenerator(t).Start())
+	}
+
+	report := &Report{}
+	for i, s := range cfg.Steps {
+		scopes.Framework.Infof("controlplane: step %d: %s", i, s)
+		sr := StepReport{Step: s, Time: time.Now()}
+		sr.Err = apply(env, cfg.IstioNamespace, s)
+		sr.Duration = time.Since(sr.Time)
+		if sr.Err == nil {
+			time.Sleep(cfg.Settle)
+			sr.Propagation, sr.Err = verify(env, cfg, i)
+		}
+		report.Steps = append(report.Steps, sr)
+		if sr.Err != nil {
+			break
+		}
+	}
+
+	for _, g := range generators {
+		report.Traffic = append(report.Traffic, g.Stop())
+	}
+
+	var err error
+	for name, replicas := range original {
+		if e := scale(env, cfg.IstioNamespace, name, replicas); e != nil && err == nil {
+			err = fmt.Errorf("failed restoring the replicas of %s: %v", name, e)
+		}
+	}
+	scopes.Framework.Infof("controlplane: run complete:\n%s", report)
+	return report, err
+}
+
+// RunOrFail calls Run and fails t if an error occurs, or if the report has more than maxErrors failed calls
+// for any of the traffic generators.
+func RunOrFail(t test.Failer, ctx resource.Context, cfg Config, maxErrors int) *Report {
+	t.Helper()
+	report, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatalf("controlplane.RunOrFail: %v", err)
+	}
+	if err := report.Check(maxErrors); err != nil {
+		t.Fatalf("controlplane.RunOrFail: %v\n%s", err, report)
+	}
+	return report
+}
+
+func apply(env *kube.Environment, ns string, s Step) error {
+	if !s.Restart {
+		return scale(env, ns, s.Deployment, s.Replicas)
+	}
+	d, err := env.GetDeployment(ns, s.Deployment)
+	if err != nil {
+		return err
+	}
+	selector := kubeApiMeta.FormatLabelSelector(d.Spec.Selector)
+	pods, err := env.GetPods(ns, selector)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if err := env.DeletePod(ns, pod.Name); err != nil {
+			return err
+		}
+		if err := env.WaitUntilDeploymentIsRolledOut(ns, s.Deployment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scale(env *kube.Environment, ns, deployment string, replicas int) error {
+	if err := env.ScaleDeployment(ns, deployment, replicas); err != nil {
+		return err
+	}
+	return env.WaitUntilDeploymentIsRolledOut(ns, deployment)
+}
+
+// verify that the workload certificates are re-issued, and that the probe configuration reaches the sidecars.
+func verify(env *kube.Environment, cfg Config, step int) (config.Propagation, error) {
+	for _, ns := range cfg.WorkloadNamespaces {
+		if err := reissue(env, ns); err != nil {
+			return config.Propagation{}, err
+		}
+	}
+	if cfg.Config == nil {
+		return config.Propagation{}, nil
+	}
+	probe := cfg.Probe(step)
+	p, err := cfg.Config.ApplyAndMeasure(cfg.ProbeNamespace, probe)
+	if err != nil {
+		return p, fmt.Errorf("probe configuration was not distributed: %v", err)
+	}
+	return p, cfg.Config.Delete(cfg.ProbeNamespace, probe)
+}
+
+// reissue the workload certificates of the namespace, and wait until all of them were issued again.
+func reissue(env *kube.Environment, ns namespace.Instance) error {
+	names, err := workloadSecrets(env, ns.Name())
+	if err != nil {
+		return err
+	}
+	if err := util.ReissueWorkloadCerts(env, ns); err != nil {
+		return err
+	}
+	return retry.UntilSuccess(func() error {
+		issued, err := workloadSecrets(env, ns.Name())
+		if err != nil {
+			return err
+		}
+		for name := range names {
+			if !issued[name] {
+				return fmt.Errorf("workload certificate secret %s/%s was not re-issued", ns.Name(), name)
+			}
+		}
+		return nil
+	})
+}
+
+func workloadSecrets(env *kube.Environment, ns string) (map[string]bool, error) {
+	list, err := env.GetSecret(ns).List(kubeApiMeta.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool)
+	for _, s := range list.Items {
+		if strings.HasPrefix(s.Name, util.IstioSecretPrefix) {
+			out[s.Name] = true
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+// Datapath taken by an outbound call of an echo workload.
+type Datapath string
+
+const (
+	// ThroughSidecar means that the traffic of the call was captured and proxied by the sidecar.
+	ThroughSidecar Datapath = "through sidecar"
+	// BypassedSidecar means that the traffic went straight from the application to the network, e.g. because
+	// its port or IP range is excluded from capture.
+	BypassedSidecar Datapath = "bypassed sidecar"
+
+	upstreamRequestsStat    = ".upstream_rq_total"
+	upstreamConnectionsStat = ".upstream_cx_total"
+)
+
+// outboundClusterPrefixes are the prefixes of the stats of the clusters that captured outbound traffic is sent
+// to. The other clusters of the sidecar are used by the proxy itself (e.g. for configuration), or for inbound
+// traffic.
+var outboundClusterPrefixes = []string{"cluster.outbound|", "cluster.PassthroughCluster.", "cluster.BlackHoleCluster."}
+
+// OutboundActivity returns the number of requests and connections the sidecars of all workloads of the given
+// instance sent to outbound clusters. Requests count the HTTP traffic, whose connections may be reused, and
+// connections the TCP traffic.
+func OutboundActivity(i echo.Instance) (int64, error) {
+	workloads, err := i.Workloads()
+	if err != nil {
+		return 0, err
+	}
+	var out int64
+	for _, w := range workloads {
+		if w.Sidecar() == nil {
+			return 0, fmt.Errorf("workload %s has no sidecar", w.Name())
+		}
+		stats, err := w.Sidecar().Stats()
+		if err != nil {
+			return 0, err
+		}
+		for name, value := range stats {
+			if isOutboundActivityStat(name) {
+				out += value
+			}
+		}
+	}
+	return out, nil
+}
+
+func isOutboundActivityStat(name string) bool {
+	if !strings.HasSuffix(name, upstreamRequestsStat) && !strings.HasSuffix(name, upstreamConnectionsStat) {
+		return false
+	}
+	for _, p := range outboundClusterPrefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// DatapathResult of a call.
+type DatapathResult struct {
+	Datapath Datapath
+	// Activity is the increase of the OutboundActivity of the source during the call.
+	Activity int64
+	// Responses and error of the call, which don't affect the datapath.
+	Responses client.ParsedResponses
+	CallErr   error
+}
+
+// TraceDatapath makes the call, which is expected to be sent by a workload of src, and determines the datapath
+// it took from the outbound activity of the sidecars of src during the call. The call may fail either way,
+// e.g. a call that bypasses the sidecar is rejected by a target requiring mTLS. The sidecars of src must not
+// send any other traffic during the call.
+func TraceDatapath(src echo.Instance, call func() (client.ParsedResponses, error)) (*DatapathResult, error) {
+	before, err := OutboundActivity(src)
+	if err != nil {
+		return nil, err
+	}
+	out := &DatapathResult{}
+	out.Responses, out.CallErr = call()
+	after, err := OutboundActivity(src)
+	if err != nil {
+		return nil, err
+	}
+	out.Activity = after - before
+	out.Datapath = BypassedSidecar
+	if out.Activity > 0 {
+		out.Datapath = ThroughSidecar
+	}
+	return out, nil
+}
+
+// CheckDatapath makes the call from src with the given options, and verifies that it took the expected
+// datapath. The call itself may fail.
+func CheckDatapath(src echo.Instance, opts echo.CallOptions, expected Datapath) error {
+	result, err := TraceDatapath(src, func() (client.ParsedResponses, error) {
+		return src.Call(opts)
+	})
+	if err != nil {
+		return err
+	}
+	if result.Datapath != expected {
+		target := opts.Host
+		if opts.Target != nil {
+			target = opts.Target.Config().Service
+		}
+		return fmt.Errorf("call %s->%s:%s: expected datapath %s, got %s (outbound activity %d, call error: %v)",
+			src.Config().Service, target, opts.PortName, expected, result.Datapath, result.Activity, result.CallErr)
+	}
+	return nil
+}
+
+// CheckDatapathOrFail calls CheckDatapath and fails t if an error occurs.
+func CheckDatapathOrFail(t test.Failer, src echo.Instance, opts echo.CallOptions, expected Datapath) {
+	t.Helper()
+	if err := CheckDatapath(src, opts, expected); err != nil {
+		t.Fatal(err)
+	}
+}
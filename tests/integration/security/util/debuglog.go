@@ -0,0 +1,117 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	// DebugLogLevel is the log level of the sidecars for the attempts retried by RetryWithDebugLogs, raising the
+	// logging of the authorization and JWT authentication filters.
+	DebugLogLevel = "rbac:debug,jwt:debug"
+
+	// defaultLogLevel restores the default log level of the loggers raised by DebugLogLevel.
+	defaultLogLevel = "rbac:warning,jwt:warning"
+)
+
+// RetryWithDebugLogs retries fn like retry.UntilSuccess. If the first attempt fails, the log level of the sidecars
+// of the given instances is raised to DebugLogLevel for the remaining attempts and, if fn still doesn't succeed,
+// their logs are written into the work directory of ctx. The log level is restored before returning.
+func RetryWithDebugLogs(ctx resource.Context, instances []echo.Instance, fn func() error, options ...retry.Option) error {
+	err := fn()
+	if err == nil {
+		return nil
+	}
+	scopes.Framework.Infof("First attempt failed, retrying with %s logging: %v", DebugLogLevel, err)
+
+	sidecars := sidecarsOf(instances)
+	for name, s := range sidecars {
+		if err := s.SetLogLevel(DebugLogLevel); err != nil {
+			return fmt.Errorf("failed raising the log level of %s: %v", name, err)
+		}
+	}
+	defer func() {
+		for name, s := range sidecars {
+			if err := s.SetLogLevel(defaultLogLevel); err != nil {
+				scopes.Framework.Warnf("Failed restoring the log level of %s: %v", name, err)
+			}
+		}
+	}()
+
+	if err = retry.UntilSuccess(fn, options...); err != nil {
+		if dir, e := writeSidecarLogs(ctx, sidecars); e != nil {
+			scopes.Framework.Warnf("Failed writing the debug logs of the sidecars: %v", e)
+		} else {
+			scopes.Framework.Infof("Wrote the debug logs of the sidecars to %s", dir)
+		}
+	}
+	return err
+}
+
+// RetryWithDebugLogsOrFail calls RetryWithDebugLogs and fails t if an error occurs.
+func RetryWithDebugLogsOrFail(t test.Failer, ctx resource.Context, instances []echo.Instance, fn func() error,
+	options ...retry.Option) {
+	t.Helper()
+	if err := RetryWithDebugLogs(ctx, instances, fn, options...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sidecarsOf returns the sidecars of the workloads of the given instances, by workload name.
+func sidecarsOf(instances []echo.Instance) map[string]echo.Sidecar {
+	out := make(map[string]echo.Sidecar)
+	for _, i := range instances {
+		if i.Config().Naked {
+			continue
+		}
+		workloads, err := i.Workloads()
+		if err != nil {
+			scopes.Framework.Warnf("Unable to get the workloads of %s: %v", i.Config().Service, err)
+			continue
+		}
+		for _, w := range workloads {
+			if s := w.Sidecar(); s != nil {
+				out[w.Name()] = s
+			}
+		}
+	}
+	return out
+}
+
+func writeSidecarLogs(ctx resource.Context, sidecars map[string]echo.Sidecar) (string, error) {
+	dir, err := ctx.CreateDirectory("sidecar-debug-logs")
+	if err != nil {
+		return "", err
+	}
+	for name, s := range sidecars {
+		logs, err := s.Logs()
+		if err != nil {
+			return "", fmt.Errorf("failed getting the logs of %s: %v", name, err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, name+".log"), []byte(logs), 0644); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
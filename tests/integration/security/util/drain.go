@@ -0,0 +1,84 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+// StartDrain starts draining the echo server of the given workload of target: readiness checks and new HTTP
+// requests fail, and the server waits up to the given timeout for the requests in flight to complete before
+// it shuts down. The request is sent by the workload to itself, bypassing the sidecar. The returned response
+// reports the number of requests in flight when draining started.
+func StartDrain(target echo.Instance, w echo.Workload, timeout time.Duration) (*client.ParsedResponse, error) {
+	return loopbackRequest(target, w, fmt.Sprintf("%s?start&timeout=%v", common.DrainPath, timeout))
+}
+
+// StartDrainOrFail calls StartDrain and fails t if an error occurs.
+func StartDrainOrFail(t test.Failer, target echo.Instance, w echo.Workload, timeout time.Duration) *client.ParsedResponse {
+	t.Helper()
+	resp, err := StartDrain(target, w, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// DrainStatus returns the drain status of the echo server of the given workload of target, i.e. whether it
+// is draining and the number of requests in flight.
+func DrainStatus(target echo.Instance, w echo.Workload) (*client.ParsedResponse, error) {
+	return loopbackRequest(target, w, common.DrainPath)
+}
+
+// DrainStatusOrFail calls DrainStatus and fails t if an error occurs.
+func DrainStatusOrFail(t test.Failer, target echo.Instance, w echo.Workload) *client.ParsedResponse {
+	t.Helper()
+	resp, err := DrainStatus(target, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// loopbackRequest sends a request for the given path to the first HTTP port of the echo server of the given
+// workload of target, from the workload itself so that it bypasses the sidecar.
+func loopbackRequest(target echo.Instance, w echo.Workload, path string) (*client.ParsedResponse, error) {
+	var port *echo.Port
+	for i, p := range target.Config().Ports {
+		if p.Protocol == protocol.HTTP {
+			port = &target.Config().Ports[i]
+			break
+		}
+	}
+	if port == nil {
+		return nil, fmt.Errorf("service %s has no HTTP port", target.Config().Service)
+	}
+
+	resp, err := w.CallDirect("127.0.0.1", port.InstancePort, echo.CallOptions{Path: path})
+	if err != nil {
+		return nil, fmt.Errorf("request %s to %s failed: %v", path, w.Name(), err)
+	}
+	if len(resp) != 1 {
+		return nil, fmt.Errorf("request %s to %s: expected 1 response, received %d", path, w.Name(), len(resp))
+	}
+	return resp[0], nil
+}
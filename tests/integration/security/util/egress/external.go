@@ -0,0 +1,95 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+const (
+	// DefaultExternalName is the service name of the external application.
+	DefaultExternalName = "external"
+)
+
+// External is an echo application running outside of the mesh, standing in for a service on the
+// internet.
+type External struct {
+	// Namespace of the external application. Sidecar injection is disabled for it.
+	Namespace namespace.Instance
+	// Instance of the external application.
+	Instance echo.Instance
+}
+
+// DeployExternal deploys an echo application with the given name (DefaultExternalName if empty) into a
+// new namespace without sidecar injection. The given options are applied to the application config.
+func DeployExternal(ctx resource.Context, name string, opts ...util.EchoOption) (*External, error) {
+	if name == "" {
+		name = DefaultExternalName
+	}
+
+	ns, err := namespace.New(ctx, namespace.Config{
+		Prefix: name,
+		Inject: false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(append([]util.EchoOption{}, opts...),
+		util.WithNaked())
+
+	builder, err := echoboot.NewBuilder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e := &External{
+		Namespace: ns,
+	}
+	if err := builder.With(&e.Instance, util.EchoConfig(name, ns, opts...)).Build(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// DeployExternalOrFail calls DeployExternal and fails t if an error occurs.
+func DeployExternalOrFail(t test.Failer, ctx resource.Context, name string, opts ...util.EchoOption) *External {
+	t.Helper()
+	e, err := DeployExternal(ctx, name, opts...)
+	if err != nil {
+		t.Fatalf("egress.DeployExternalOrFail: %v", err)
+	}
+	return e
+}
+
+// Host returns the host name the external application is reachable on.
+func (e *External) Host() string {
+	return e.Instance.Config().FQDN()
+}
+
+// ServicePort returns the service port of the external application with the given name, or 0 if there is
+// no such port.
+func (e *External) ServicePort(name string) int {
+	for _, p := range e.Instance.Config().Ports {
+		if p.Name == name {
+			return p.ServicePort
+		}
+	}
+	return 0
+}
@@ -0,0 +1,335 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package egress provides helpers for tests that route traffic to services outside of the mesh through
+// the egress gateway: an "external" application deployed without a sidecar, and a generator for the
+// ServiceEntry, Gateway, DestinationRule and VirtualService resources wiring it up.
+package egress
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+const (
+	// DefaultGatewayPort is the port of the egress gateway service the traffic is sent to.
+	DefaultGatewayPort = 80
+	// DefaultPort is the port of the external host the application calls.
+	DefaultPort = 80
+
+	defaultIstioNamespace = "istio-system"
+	gatewayService        = "istio-egressgateway"
+	gatewaySelector       = "egressgateway"
+	gatewaySubset         = "external"
+)
+
+// TLSMode of the connection between the sidecars and the egress gateway.
+type TLSMode string
+
+const (
+	// IstioMutual uses mutual TLS with the Istio workload certificates.
+	IstioMutual TLSMode = "ISTIO_MUTUAL"
+	// Simple terminates TLS at the gateway with the certificate mounted at /etc/certs, without
+	// requiring a client certificate. The sidecars don't originate TLS in this mode, which makes it
+	// useful for verifying that misconfigured gateways reject the traffic.
+	Simple TLSMode = "SIMPLE"
+	// Disable sends plaintext to the gateway.
+	Disable TLSMode = "DISABLE"
+)
+
+// Route is a builder of the configuration that sends the traffic of the mesh for an external host
+// through the egress gateway, and from there to the host, optionally originating TLS at the gateway.
+type Route struct {
+	ns             namespace.Instance
+	name           string
+	host           string
+	port           int
+	endpoint       string
+	serviceEntry   bool
+	istioNamespace string
+	gatewayPort    int
+	gatewayTLS     TLSMode
+	originatePort  int
+}
+
+// NewRoute returns a Route for the given external host, with configuration in the given namespace. By
+// default, plaintext requests to port DefaultPort of the host are sent to the gateway over ISTIO_MUTUAL
+// and forwarded to the same port of the host.
+func NewRoute(ns namespace.Instance, host string) *Route {
+	return &Route{
+		ns:             ns,
+		name:           strings.Replace(host, ".", "-", -1),
+		host:           host,
+		port:           DefaultPort,
+		serviceEntry:   true,
+		istioNamespace: defaultIstioNamespace,
+		gatewayPort:    DefaultGatewayPort,
+		gatewayTLS:     IstioMutual,
+	}
+}
+
+// NewRouteToExternal returns a Route for the given external application, calling its "http" port. No
+// ServiceEntry is generated, since the application is already known to the mesh through the service
+// registry.
+func NewRouteToExternal(ns namespace.Instance, e *External) *Route {
+	r := NewRoute(ns, e.Host()).Named(e.Instance.Config().Service)
+	r.serviceEntry = false
+	if port := e.ServicePort("http"); port != 0 {
+		r.port = port
+	}
+	return r
+}
+
+// Named sets the name of the route. Resource names are derived from it.
+func (r *Route) Named(name string) *Route {
+	r.name = name
+	return r
+}
+
+// Port sets the port of the external host the application calls.
+func (r *Route) Port(port int) *Route {
+	r.port = port
+	return r
+}
+
+// Endpoint resolves the host to the given address in the generated ServiceEntry, so that a made-up host
+// can be backed by the external application.
+func (r *Route) Endpoint(address string) *Route {
+	r.endpoint = address
+	return r
+}
+
+// IstioNamespace sets the namespace the egress gateway is deployed in.
+func (r *Route) IstioNamespace(ns string) *Route {
+	r.istioNamespace = ns
+	return r
+}
+
+// GatewayPort sets the port of the egress gateway the traffic is sent to.
+func (r *Route) GatewayPort(port int) *Route {
+	r.gatewayPort = port
+	return r
+}
+
+// GatewayTLS sets the TLS mode of the connection between the sidecars and the egress gateway.
+func (r *Route) GatewayTLS(mode TLSMode) *Route {
+	r.gatewayTLS = mode
+	return r
+}
+
+// OriginateTLS makes the gateway originate TLS to the given port of the external host, instead of
+// forwarding plaintext to the port the application calls.
+func (r *Route) OriginateTLS(port int) *Route {
+	r.originatePort = port
+	return r
+}
+
+func (r *Route) gatewayHost() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", gatewayService, r.istioNamespace)
+}
+
+func (r *Route) gatewayName() string {
+	return r.name + "-egressgateway"
+}
+
+func (r *Route) upstreamPort() int {
+	if r.originatePort != 0 {
+		return r.originatePort
+	}
+	return r.port
+}
+
+func (r *Route) resource(kind, name string, spec map[string]interface{}) map[string]interface{} {
+	return newResource(r.ns, kind, name, spec)
+}
+
+func newResource(ns namespace.Instance, kind, name string, spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1alpha3",
+		"kind":       kind,
+		"metadata": map[string]string{
+			"name":      name,
+			"namespace": ns.Name(),
+		},
+		"spec": spec,
+	}
+}
+
+func toYAML(resources []map[string]interface{}) (string, error) {
+	docs := make([]string, 0, len(resources))
+	for _, res := range resources {
+		out, err := yaml.Marshal(res)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(out))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
+
+func (r *Route) resources() []map[string]interface{} {
+	var out []map[string]interface{}
+
+	if r.serviceEntry {
+		ports := []map[string]interface{}{
+			{"number": r.port, "name": fmt.Sprintf("http-%d", r.port), "protocol": "HTTP"},
+		}
+		if r.originatePort != 0 && r.originatePort != r.port {
+			ports = append(ports, map[string]interface{}{
+				"number": r.originatePort, "name": fmt.Sprintf("https-%d", r.originatePort), "protocol": "HTTPS",
+			})
+		}
+		spec := map[string]interface{}{
+			"hosts":      []string{r.host},
+			"ports":      ports,
+			"location":   "MESH_EXTERNAL",
+			"resolution": "DNS",
+		}
+		if r.endpoint != "" {
+			spec["endpoints"] = []map[string]string{{"address": r.endpoint}}
+		}
+		out = append(out, r.resource("ServiceEntry", r.name, spec))
+	}
+
+	server := map[string]interface{}{
+		"hosts": []string{r.host},
+	}
+	switch r.gatewayTLS {
+	case Disable:
+		server["port"] = map[string]interface{}{"number": r.gatewayPort, "name": "http", "protocol": "HTTP"}
+	case Simple:
+		server["port"] = map[string]interface{}{"number": r.gatewayPort, "name": "https", "protocol": "HTTPS"}
+		server["tls"] = map[string]string{
+			"mode":              string(Simple),
+			"serverCertificate": "/etc/certs/server.pem",
+			"privateKey":        "/etc/certs/privatekey.pem",
+		}
+	default:
+		server["port"] = map[string]interface{}{"number": r.gatewayPort, "name": "https", "protocol": "HTTPS"}
+		server["tls"] = map[string]string{"mode": string(IstioMutual)}
+	}
+	out = append(out, r.resource("Gateway", r.gatewayName(), map[string]interface{}{
+		"selector": map[string]string{"istio": gatewaySelector},
+		"servers":  []interface{}{server},
+	}))
+
+	// The TLS settings of the sidecars towards the gateway. In SIMPLE mode the sidecars deliberately
+	// keep sending plaintext.
+	gatewayPolicy := map[string]interface{}{
+		"loadBalancer": map[string]string{"simple": "ROUND_ROBIN"},
+	}
+	if r.gatewayTLS == IstioMutual {
+		gatewayPolicy["portLevelSettings"] = []map[string]interface{}{{
+			"port": map[string]int{"number": r.gatewayPort},
+			"tls":  map[string]string{"mode": string(IstioMutual), "sni": r.host},
+		}}
+	}
+	out = append(out, r.resource("DestinationRule", r.gatewayName(), map[string]interface{}{
+		"host": r.gatewayHost(),
+		"subsets": []map[string]interface{}{{
+			"name":          gatewaySubset,
+			"trafficPolicy": gatewayPolicy,
+		}},
+	}))
+
+	if r.originatePort != 0 {
+		out = append(out, r.resource("DestinationRule", r.name+"-originate-tls", map[string]interface{}{
+			"host": r.host,
+			"trafficPolicy": map[string]interface{}{
+				"loadBalancer": map[string]string{"simple": "ROUND_ROBIN"},
+				"portLevelSettings": []map[string]interface{}{{
+					"port": map[string]int{"number": r.originatePort},
+					"tls":  map[string]string{"mode": "SIMPLE", "sni": r.host},
+				}},
+			},
+		}))
+	}
+
+	out = append(out, r.resource("VirtualService", r.name, map[string]interface{}{
+		"hosts":    []string{r.host},
+		"gateways": []string{r.gatewayName(), "mesh"},
+		"http": []map[string]interface{}{
+			{
+				"match": []map[string]interface{}{{"gateways": []string{"mesh"}, "port": r.port}},
+				"route": []map[string]interface{}{{
+					"destination": map[string]interface{}{
+						"host":   r.gatewayHost(),
+						"subset": gatewaySubset,
+						"port":   map[string]int{"number": r.gatewayPort},
+					},
+				}},
+			},
+			{
+				"match": []map[string]interface{}{{"gateways": []string{r.gatewayName()}, "port": r.gatewayPort}},
+				"route": []map[string]interface{}{{
+					"destination": map[string]interface{}{
+						"host": r.host,
+						"port": map[string]int{"number": r.upstreamPort()},
+					},
+				}},
+			},
+		},
+	}))
+	return out
+}
+
+// YAML returns the generated resources.
+func (r *Route) YAML() (string, error) {
+	return toYAML(r.resources())
+}
+
+// YAMLOrFail calls YAML and fails t if an error occurs.
+func (r *Route) YAMLOrFail(t test.Failer) string {
+	t.Helper()
+	out, err := r.YAML()
+	if err != nil {
+		t.Fatalf("egress.Route.YAMLOrFail: %v", err)
+	}
+	return out
+}
+
+// Apply the route, and wait until it is distributed.
+func (r *Route) Apply(c config.Instance) error {
+	out, err := r.YAML()
+	if err != nil {
+		return err
+	}
+	return c.Apply(r.ns, out)
+}
+
+// Delete the route, and wait until the deletion is distributed.
+func (r *Route) Delete(c config.Instance) error {
+	out, err := r.YAML()
+	if err != nil {
+		return err
+	}
+	return c.Delete(r.ns, out)
+}
+
+// ApplyOrFail applies the route, waits until it is distributed, and deletes it when the given context is done.
+func (r *Route) ApplyOrFail(ctx framework.TestContext, c config.Instance) {
+	ctx.Helper()
+	out := r.YAMLOrFail(ctx)
+	c.ApplyOrFail(ctx, r.ns, out)
+	ctx.WhenDone(func() error {
+		return c.Delete(r.ns, out)
+	})
+}
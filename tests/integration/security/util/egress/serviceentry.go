@@ -0,0 +1,221 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"fmt"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+// Resolution of the endpoints of a generated ServiceEntry.
+type Resolution string
+
+const (
+	// ResolutionDNS resolves the address of the external application through DNS.
+	ResolutionDNS Resolution = "DNS"
+	// ResolutionStatic uses the addresses of the workloads of the external application as endpoints.
+	ResolutionStatic Resolution = "STATIC"
+	// ResolutionNone forwards the connections to the original destination, so the host must resolve to the
+	// external application on its own.
+	ResolutionNone Resolution = "NONE"
+)
+
+// ServiceEntry is a builder of the configuration that registers an external application with the mesh as a
+// MESH_EXTERNAL service, so that egress tests have an external dependency that doesn't rely on the internet.
+type ServiceEntry struct {
+	ns         namespace.Instance
+	e          *External
+	name       string
+	host       string
+	resolution Resolution
+	tls        TLSMode
+}
+
+// ServiceEntry returns a ServiceEntry builder for the application, with configuration in the given namespace.
+// By default the host of the application is resolved through DNS, and the sidecars send plaintext to it.
+func (e *External) ServiceEntry(ns namespace.Instance) *ServiceEntry {
+	return &ServiceEntry{
+		ns:         ns,
+		e:          e,
+		name:       e.Instance.Config().Service,
+		host:       e.Host(),
+		resolution: ResolutionDNS,
+		tls:        Disable,
+	}
+}
+
+// Named sets the name of the ServiceEntry. Resource names are derived from it.
+func (s *ServiceEntry) Named(name string) *ServiceEntry {
+	s.name = name
+	return s
+}
+
+// Host sets the host the external application is registered as, e.g. a made-up public host name. With DNS
+// resolution the host is backed by the address of the application.
+func (s *ServiceEntry) Host(host string) *ServiceEntry {
+	s.host = host
+	return s
+}
+
+// Resolution sets the resolution of the endpoints of the ServiceEntry.
+func (s *ServiceEntry) Resolution(resolution Resolution) *ServiceEntry {
+	s.resolution = resolution
+	return s
+}
+
+// TLS sets the TLS mode of the connections of the sidecars to the external application. With Simple, the
+// sidecars originate TLS to the ports the application terminates TLS on, so that callers can send plaintext.
+// Other modes than Disable and Simple are not supported, since the application has no sidecar.
+func (s *ServiceEntry) TLS(mode TLSMode) *ServiceEntry {
+	s.tls = mode
+	return s
+}
+
+func (s *ServiceEntry) endpoints() ([]map[string]string, error) {
+	switch s.resolution {
+	case ResolutionDNS:
+		if s.host == s.e.Host() {
+			return nil, nil
+		}
+		return []map[string]string{{"address": s.e.Host()}}, nil
+	case ResolutionStatic:
+		workloads, err := s.e.Instance.Workloads()
+		if err != nil {
+			return nil, err
+		}
+		out := make([]map[string]string, 0, len(workloads))
+		for _, w := range workloads {
+			out = append(out, map[string]string{"address": w.Address()})
+		}
+		return out, nil
+	case ResolutionNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("egress: unsupported resolution %q", s.resolution)
+	}
+}
+
+func (s *ServiceEntry) resources() ([]map[string]interface{}, error) {
+	if s.tls != Disable && s.tls != Simple {
+		return nil, fmt.Errorf("egress: unsupported TLS mode %q for an external application", s.tls)
+	}
+	endpoints, err := s.endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []map[string]interface{}
+	var tlsPorts []int
+	for _, p := range s.e.Instance.Config().Ports {
+		if p.ServicePort == 0 {
+			continue
+		}
+		proto := p.Protocol
+		if p.TLS {
+			if s.tls == Simple {
+				tlsPorts = append(tlsPorts, p.ServicePort)
+			} else {
+				// The callers talk TLS to the application themselves.
+				proto = protocol.TLS
+			}
+		}
+		ports = append(ports, map[string]interface{}{
+			"number":   p.ServicePort,
+			"name":     p.Name,
+			"protocol": string(proto),
+		})
+	}
+
+	spec := map[string]interface{}{
+		"hosts":      []string{s.host},
+		"ports":      ports,
+		"location":   "MESH_EXTERNAL",
+		"resolution": string(s.resolution),
+	}
+	if len(endpoints) > 0 {
+		spec["endpoints"] = endpoints
+	}
+	out := []map[string]interface{}{newResource(s.ns, "ServiceEntry", s.name, spec)}
+
+	if len(tlsPorts) > 0 {
+		settings := make([]map[string]interface{}, 0, len(tlsPorts))
+		for _, port := range tlsPorts {
+			settings = append(settings, map[string]interface{}{
+				"port": map[string]int{"number": port},
+				"tls":  map[string]string{"mode": string(Simple), "sni": s.host},
+			})
+		}
+		out = append(out, newResource(s.ns, "DestinationRule", s.name+"-tls", map[string]interface{}{
+			"host": s.host,
+			"trafficPolicy": map[string]interface{}{
+				"portLevelSettings": settings,
+			},
+		}))
+	}
+	return out, nil
+}
+
+// YAML returns the generated resources.
+func (s *ServiceEntry) YAML() (string, error) {
+	resources, err := s.resources()
+	if err != nil {
+		return "", err
+	}
+	return toYAML(resources)
+}
+
+// YAMLOrFail calls YAML and fails t if an error occurs.
+func (s *ServiceEntry) YAMLOrFail(t test.Failer) string {
+	t.Helper()
+	out, err := s.YAML()
+	if err != nil {
+		t.Fatalf("egress.ServiceEntry.YAMLOrFail: %v", err)
+	}
+	return out
+}
+
+// Apply the ServiceEntry, and wait until it is distributed.
+func (s *ServiceEntry) Apply(c config.Instance) error {
+	out, err := s.YAML()
+	if err != nil {
+		return err
+	}
+	return c.Apply(s.ns, out)
+}
+
+// Delete the ServiceEntry, and wait until the deletion is distributed.
+func (s *ServiceEntry) Delete(c config.Instance) error {
+	out, err := s.YAML()
+	if err != nil {
+		return err
+	}
+	return c.Delete(s.ns, out)
+}
+
+// ApplyOrFail applies the ServiceEntry, waits until it is distributed, and deletes it when the given context
+// is done.
+func (s *ServiceEntry) ApplyOrFail(ctx framework.TestContext, c config.Instance) {
+	ctx.Helper()
+	out := s.YAMLOrFail(ctx)
+	c.ApplyOrFail(ctx, s.ns, out)
+	ctx.WhenDone(func() error {
+		return c.Delete(s.ns, out)
+	})
+}
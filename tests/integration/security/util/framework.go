@@ -22,13 +22,102 @@ import (
 	"istio.io/istio/pkg/test/framework/components/pilot"
 )
 
-func EchoConfig(name string, ns namespace.Instance, headless bool, annos echo.Annotations, g galley.Instance, p pilot.Instance) echo.Config {
-	return echo.Config{
+// EchoOption customizes the echo.Config returned by EchoConfig.
+type EchoOption func(cfg *echo.Config)
+
+// WithGalley sets the Galley instance used by the echo instance.
+func WithGalley(g galley.Instance) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Galley = g
+	}
+}
+
+// WithPilot sets the Pilot instance used by the echo instance.
+func WithPilot(p pilot.Instance) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Pilot = p
+	}
+}
+
+// WithHeadless deploys the echo service without a ClusterIP.
+func WithHeadless() EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Headless = true
+	}
+}
+
+// WithAnnotations sets the annotations used for deploying the echo instance.
+func WithAnnotations(annos echo.Annotations) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Annotations = annos
+	}
+}
+
+// WithNaked deploys the echo instance without a sidecar.
+func WithNaked() EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Naked = true
+	}
+}
+
+// WithPorts appends the given ports to the default http, tcp and grpc ports.
+func WithPorts(ports ...echo.Port) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Ports = append(cfg.Ports, ports...)
+	}
+}
+
+// WithVersion sets the version (i.e. the subset) of the echo deployment.
+func WithVersion(version string) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Version = version
+	}
+}
+
+// WithServiceAccount indicates whether a dedicated service account should be created for the
+// echo deployment. Defaults to true.
+func WithServiceAccount(enabled bool) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.ServiceAccount = enabled
+	}
+}
+
+// WithServiceAccountName creates a service account with the given name, rather than the name of the service,
+// for the echo deployment.
+func WithServiceAccountName(name string) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.ServiceAccountName = name
+	}
+}
+
+// WithSubsets deploys a separate workload for each of the given subsets behind the echo service.
+func WithSubsets(subsets ...echo.SubsetConfig) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Subsets = append(cfg.Subsets, subsets...)
+	}
+}
+
+// WithCluster deploys the workloads of the echo instance to the named cluster of a multicluster environment.
+func WithCluster(name string) EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.Cluster = name
+	}
+}
+
+// WithDeployAsVM deploys the echo instance as a mock VM, registered with the mesh through a ServiceEntry.
+func WithDeployAsVM() EchoOption {
+	return func(cfg *echo.Config) {
+		cfg.DeployAsVM = true
+	}
+}
+
+// EchoConfig returns the echo.Config commonly used by the security tests: a service with http, tcp and
+// grpc ports and a dedicated service account. The defaults can be customized with the given options.
+func EchoConfig(name string, ns namespace.Instance, opts ...EchoOption) echo.Config {
+	cfg := echo.Config{
 		Service:        name,
 		Namespace:      ns,
 		ServiceAccount: true,
-		Headless:       headless,
-		Annotations:    annos,
 		Ports: []echo.Port{
 			{
 				Name:     "http",
@@ -43,7 +132,9 @@ func EchoConfig(name string, ns namespace.Instance, headless bool, annos echo.An
 				Protocol: protocol.GRPC,
 			},
 		},
-		Galley: g,
-		Pilot:  p,
 	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
 }
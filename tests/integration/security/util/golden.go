@@ -0,0 +1,209 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
+
+	pilotutil "istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+const (
+	// RBACHTTPFilter is the name of the Envoy HTTP filter enforcing authorization policies.
+	RBACHTTPFilter = "envoy.filters.http.rbac"
+	// RBACNetworkFilter is the name of the Envoy network filter enforcing authorization policies on TCP ports.
+	RBACNetworkFilter = "envoy.filters.network.rbac"
+	// AuthnHTTPFilter is the name of the Envoy HTTP filter enforcing authentication policies.
+	AuthnHTTPFilter = "istio_authn"
+	// JWTHTTPFilter is the name of the Envoy HTTP filter validating JWT tokens.
+	JWTHTTPFilter = "jwt-auth"
+
+	httpConnectionManager = "envoy.http_connection_manager"
+)
+
+// FilterSlice selects the configuration of a filter of the inbound listener of a workload port, to be compared
+// against a golden file.
+type FilterSlice struct {
+	// Workload whose sidecar configuration is inspected.
+	Workload echo.Workload
+	// Port of the inbound listener.
+	Port echo.Port
+	// Filter name. HTTP filters are looked up in the HTTP connection manager of the listener, all other
+	// filters in its network filters.
+	Filter string
+	// HTTP is true if Filter is an HTTP filter.
+	HTTP bool
+	// Replace occurrences of the keys with the values in the extracted configuration, to remove values that
+	// differ between runs, such as generated namespace names.
+	Replace map[string]string
+}
+
+// Extract returns the normalized configuration of the selected filter, as indented JSON with sorted keys.
+// The configurations of all filter chains are returned as a list, with duplicates removed, so the output
+// doesn't depend on which mTLS mode created the chains.
+func (s FilterSlice) Extract() ([]byte, error) {
+	l, err := inboundListener(s.Workload, s.Port)
+	if err != nil {
+		return nil, err
+	}
+	js, err := (&jsonpb.Marshaler{OrigName: true}).MarshalToString(l)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.Replace {
+		js = strings.Replace(js, k, v, -1)
+	}
+	listener := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(js), &listener); err != nil {
+		return nil, err
+	}
+
+	configs := make([]interface{}, 0)
+	seen := make(map[string]bool)
+	for _, chain := range list(listener["filter_chains"]) {
+		for _, f := range s.filters(chain) {
+			key, err := json.Marshal(f)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[string(key)] {
+				seen[string(key)] = true
+				configs = append(configs, f)
+			}
+		}
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("filter %s not found in inbound listener %s", s.Filter, l.Name)
+	}
+	return json.MarshalIndent(configs, "", "  ")
+}
+
+// ExtractOrFail calls Extract and fails t if an error occurs.
+func (s FilterSlice) ExtractOrFail(t test.Failer) []byte {
+	t.Helper()
+	out, err := s.Extract()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// Compare the extracted configuration against the given golden file, returning an error with a diff if they
+// differ. If the REFRESH_GOLDEN environment variable is set, the golden file is updated instead.
+func (s FilterSlice) Compare(goldenFile string) error {
+	actual, err := s.Extract()
+	if err != nil {
+		return err
+	}
+	if pilotutil.Refresh() {
+		return ioutil.WriteFile(goldenFile, actual, 0644)
+	}
+	golden, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		return err
+	}
+	if err := pilotutil.Compare(actual, golden); err != nil {
+		return fmt.Errorf("filter %s of %s_%d doesn't match golden file %s:\n%v",
+			s.Filter, s.Workload.Address(), s.Port.InstancePort, goldenFile, err)
+	}
+	return nil
+}
+
+// CompareOrFail calls Compare and fails t if an error occurs.
+func (s FilterSlice) CompareOrFail(t test.Failer, goldenFile string) {
+	t.Helper()
+	if err := s.Compare(goldenFile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (s FilterSlice) filters(chain interface{}) []interface{} {
+	var out []interface{}
+	for _, f := range list(field(chain, "filters")) {
+		name := field(f, "name")
+		if s.HTTP {
+			if name != httpConnectionManager {
+				continue
+			}
+			for _, hf := range list(field(filterConfig(f), "http_filters")) {
+				if field(hf, "name") == s.Filter {
+					out = append(out, filterConfig(hf))
+				}
+			}
+		} else if name == s.Filter {
+			out = append(out, filterConfig(f))
+		}
+	}
+	return out
+}
+
+// filterConfig returns the config of a filter, which is set either as a struct or as typed config.
+func filterConfig(f interface{}) interface{} {
+	if c := field(f, "config"); c != nil {
+		return c
+	}
+	return field(f, "typed_config")
+}
+
+func field(v interface{}, name string) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return m[name]
+}
+
+func list(v interface{}) []interface{} {
+	l, _ := v.([]interface{})
+	return l
+}
+
+// inboundListener returns the inbound listener of the given port of the workload.
+func inboundListener(w echo.Workload, port echo.Port) (*xdsapi.Listener, error) {
+	if w.Sidecar() == nil {
+		return nil, fmt.Errorf("workload %s has no sidecar", w.Name())
+	}
+	cfg, err := w.Sidecar().Config()
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("%s_%d", w.Address(), port.InstancePort)
+	for _, c := range cfg.Configs {
+		if c.TypeUrl != listenersConfigDumpType {
+			continue
+		}
+		dump := envoyAdmin.ListenersConfigDump{}
+		if err := ptypes.UnmarshalAny(c, &dump); err != nil {
+			return nil, err
+		}
+		for _, l := range dump.DynamicActiveListeners {
+			if l.GetListener().GetName() == name {
+				return l.GetListener(), nil
+			}
+		}
+		return nil, fmt.Errorf("inbound listener %s not found", name)
+	}
+	return nil, fmt.Errorf("envoy listeners not found in config dump")
+}
@@ -0,0 +1,142 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/echo/proto"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/check"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// PodDNSName returns the DNS record of the given workload of a headless service, which is the name of the pod
+// for a StatefulSet, and otherwise the address of the workload with dashes, prefixed to the name of the service.
+func PodDNSName(target echo.Instance, w echo.Workload) string {
+	if target.Config().StatefulSet {
+		return w.Name() + "." + target.Config().FQDN()
+	}
+	return strings.NewReplacer(".", "-", ":", "-").Replace(w.Address()) + "." + target.Config().FQDN()
+}
+
+// ResolveFrom resolves host with the resolver of the given workload and returns the sorted addresses.
+func ResolveFrom(from echo.Workload, host string) ([]string, error) {
+	resp, err := from.ForwardEcho(context.Background(), &proto.ForwardEchoRequest{
+		Url:   fmt.Sprintf("%s://%s", scheme.DNS, host),
+		Count: 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed resolving %s from %s: %v", host, from.Name(), err)
+	}
+	if len(resp) != 1 {
+		return nil, fmt.Errorf("failed resolving %s from %s: expected 1 response, received %d",
+			host, from.Name(), len(resp))
+	}
+	return resp[0].ResolvedAddresses, nil
+}
+
+// ResolveFromOrFail calls ResolveFrom and fails t if an error occurs.
+func ResolveFromOrFail(t test.Failer, from echo.Workload, host string) []string {
+	t.Helper()
+	addresses, err := ResolveFrom(from, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addresses
+}
+
+// CheckPodDNS verifies that, from the given workload, the name of the headless target resolves to the addresses
+// of all of its workloads, and the DNS record of each workload resolves to its own address only. The records
+// are retried until they are published.
+func CheckPodDNS(from echo.Workload, target echo.Instance) error {
+	if !target.Config().Headless {
+		return fmt.Errorf("service %s is not headless", target.Config().Service)
+	}
+	workloads, err := target.Workloads()
+	if err != nil {
+		return err
+	}
+
+	expected := make([]string, 0, len(workloads))
+	for _, w := range workloads {
+		expected = append(expected, w.Address())
+	}
+	sort.Strings(expected)
+
+	return retry.UntilSuccess(func() error {
+		addresses, err := ResolveFrom(from, target.Config().FQDN())
+		if err != nil {
+			return err
+		}
+		if strings.Join(addresses, ",") != strings.Join(expected, ",") {
+			return fmt.Errorf("%s resolved to %v, expected %v", target.Config().FQDN(), addresses, expected)
+		}
+
+		for _, w := range workloads {
+			name := PodDNSName(target, w)
+			addresses, err := ResolveFrom(from, name)
+			if err != nil {
+				return err
+			}
+			if len(addresses) != 1 || addresses[0] != w.Address() {
+				return fmt.Errorf("%s of workload %s resolved to %v, expected %s", name, w.Name(), addresses, w.Address())
+			}
+		}
+		return nil
+	})
+}
+
+// CheckPodDNSOrFail calls CheckPodDNS and fails t if an error occurs.
+func CheckPodDNSOrFail(t test.Failer, from echo.Workload, target echo.Instance) {
+	t.Helper()
+	if err := CheckPodDNS(from, target); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CallPod calls the given workload of a headless target through its DNS record, on the instance port of the
+// given port. With a headless service the request is passed through to the selected pod, rather than load
+// balanced across the service, so the call also requires every response to be served by that workload.
+func CallPod(from echo.Workload, target echo.Instance, w echo.Workload, port echo.Port, opts echo.CallOptions,
+	checkers ...check.Checker) (client.ParsedResponses, error) {
+	if !target.Config().Headless {
+		return nil, fmt.Errorf("service %s is not headless", target.Config().Service)
+	}
+	servedBy := check.Each(func(i int, r *client.ParsedResponse) error {
+		if r.Hostname != w.Name() {
+			return fmt.Errorf("response[%d]: expected to be served by %s, served by %s", i, w.Name(), r.Hostname)
+		}
+		return nil
+	})
+	return from.CallDirect(PodDNSName(target, w), port.InstancePort, opts, append(checkers, servedBy)...)
+}
+
+// CallPodOrFail calls CallPod and fails t if an error occurs.
+func CallPodOrFail(t test.Failer, from echo.Workload, target echo.Instance, w echo.Workload, port echo.Port,
+	opts echo.CallOptions, checkers ...check.Checker) client.ParsedResponses {
+	t.Helper()
+	resp, err := CallPod(from, target, w, port, opts, checkers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwt mints JWT tokens with arbitrary claims for use in security tests.
+package jwt
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	josejwt "gopkg.in/square/go-jose.v2/jwt"
+
+	"istio.io/istio/pkg/test"
+)
+
+// Builder of signed JWT tokens.
+type Builder struct {
+	key    *Key
+	claims map[string]interface{}
+}
+
+// NewBuilder returns a Builder that signs tokens with the given key.
+func NewBuilder(key *Key) *Builder {
+	return &Builder{
+		key:    key,
+		claims: make(map[string]interface{}),
+	}
+}
+
+// Issuer sets the "iss" claim.
+func (b *Builder) Issuer(iss string) *Builder {
+	return b.Claim("iss", iss)
+}
+
+// Subject sets the "sub" claim.
+func (b *Builder) Subject(sub string) *Builder {
+	return b.Claim("sub", sub)
+}
+
+// Audience sets the "aud" claim. A single audience is encoded as a string, multiple as a list.
+func (b *Builder) Audience(aud ...string) *Builder {
+	if len(aud) == 1 {
+		return b.Claim("aud", aud[0])
+	}
+	return b.Claim("aud", aud)
+}
+
+// IssuedAt sets the "iat" claim.
+func (b *Builder) IssuedAt(t time.Time) *Builder {
+	return b.Claim("iat", t.Unix())
+}
+
+// NotBefore sets the "nbf" claim.
+func (b *Builder) NotBefore(t time.Time) *Builder {
+	return b.Claim("nbf", t.Unix())
+}
+
+// ExpiresAt sets the "exp" claim.
+func (b *Builder) ExpiresAt(t time.Time) *Builder {
+	return b.Claim("exp", t.Unix())
+}
+
+// ExpiresIn sets the "iat" claim to now and the "exp" claim relative to it. A negative duration
+// creates an already expired token.
+func (b *Builder) ExpiresIn(d time.Duration) *Builder {
+	now := time.Now()
+	return b.IssuedAt(now).ExpiresAt(now.Add(d))
+}
+
+// Claim sets an arbitrary claim. The value may be any JSON serializable value, including nested
+// maps and lists.
+func (b *Builder) Claim(name string, value interface{}) *Builder {
+	b.claims[name] = value
+	return b
+}
+
+// Build signs and serializes the token.
+func (b *Builder) Build() (string, error) {
+	if b.key == nil {
+		return "", fmt.Errorf("jwt: no signing key")
+	}
+
+	opts := (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", b.key.ID)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: b.key.Algorithm, Key: b.key.private}, opts)
+	if err != nil {
+		return "", err
+	}
+	return josejwt.Signed(signer).Claims(b.claims).CompactSerialize()
+}
+
+// BuildOrFail calls Build and fails t if an error occurs.
+func (b *Builder) BuildOrFail(t test.Failer) string {
+	t.Helper()
+	token, err := b.Build()
+	if err != nil {
+		t.Fatalf("jwt.BuildOrFail: %v", err)
+	}
+	return token
+}
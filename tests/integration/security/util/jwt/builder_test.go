@@ -0,0 +1,74 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	josejwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+func TestBuilder(t *testing.T) {
+	for _, newKey := range []func(t *testing.T) *Key{
+		func(t *testing.T) *Key { return NewRSAKeyOrFail(t) },
+		func(t *testing.T) *Key { return NewECKeyOrFail(t) },
+	} {
+		key := newKey(t)
+		t.Run(string(key.Algorithm), func(t *testing.T) {
+			token := NewBuilder(key).
+				Issuer("test-issuer@istio.io").
+				Subject("sub-1").
+				Audience("aud-1", "aud-2").
+				ExpiresIn(time.Hour).
+				Claim("nested", map[string]interface{}{"key": "value"}).
+				BuildOrFail(t)
+
+			set := jose.JSONWebKeySet{}
+			if err := json.Unmarshal([]byte(JWKSOrFail(t, key)), &set); err != nil {
+				t.Fatal(err)
+			}
+
+			parsed, err := josejwt.ParseSigned(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := parsed.Headers[0].KeyID; got != key.ID {
+				t.Fatalf("kid: got %q, want %q", got, key.ID)
+			}
+			jwks := set.Key(key.ID)
+			if len(jwks) != 1 {
+				t.Fatalf("expected key %q in JWKS", key.ID)
+			}
+
+			claims := map[string]interface{}{}
+			if err := parsed.Claims(jwks[0].Key, &claims); err != nil {
+				t.Fatal(err)
+			}
+			if claims["iss"] != "test-issuer@istio.io" || claims["sub"] != "sub-1" {
+				t.Fatalf("unexpected claims: %v", claims)
+			}
+			if !reflect.DeepEqual(claims["aud"], []interface{}{"aud-1", "aud-2"}) {
+				t.Fatalf("unexpected aud: %v", claims["aud"])
+			}
+			if !reflect.DeepEqual(claims["nested"], map[string]interface{}{"key": "value"}) {
+				t.Fatalf("unexpected nested claim: %v", claims["nested"])
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+
+	"gopkg.in/square/go-jose.v2"
+
+	"istio.io/istio/pkg/test"
+)
+
+// Key is a signing key for JWT tokens.
+type Key struct {
+	// ID of the key, used as the "kid" header of the tokens and in the JWKS.
+	ID string
+	// Algorithm used for signing.
+	Algorithm jose.SignatureAlgorithm
+
+	private crypto.Signer
+}
+
+// NewRSAKey generates a new 2048 bit RSA key for RS256 signatures.
+func NewRSAKey() (*Key, error) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return newKey(private, jose.RS256)
+}
+
+// NewRSAKeyOrFail calls NewRSAKey and fails t if an error occurs.
+func NewRSAKeyOrFail(t test.Failer) *Key {
+	t.Helper()
+	k, err := NewRSAKey()
+	if err != nil {
+		t.Fatalf("jwt.NewRSAKeyOrFail: %v", err)
+	}
+	return k
+}
+
+// NewECKey generates a new P-256 key for ES256 signatures.
+func NewECKey() (*Key, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newKey(private, jose.ES256)
+}
+
+// NewECKeyOrFail calls NewECKey and fails t if an error occurs.
+func NewECKeyOrFail(t test.Failer) *Key {
+	t.Helper()
+	k, err := NewECKey()
+	if err != nil {
+		t.Fatalf("jwt.NewECKeyOrFail: %v", err)
+	}
+	return k
+}
+
+func newKey(private crypto.Signer, alg jose.SignatureAlgorithm) (*Key, error) {
+	k := &Key{
+		Algorithm: alg,
+		private:   private,
+	}
+
+	// Use the RFC 7638 thumbprint of the public key as the key ID.
+	thumbprint, err := k.publicJWK().Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	k.ID = base64.RawURLEncoding.EncodeToString(thumbprint)
+	return k, nil
+}
+
+func (k *Key) publicJWK() jose.JSONWebKey {
+	return jose.JSONWebKey{
+		Key:       k.private.Public(),
+		KeyID:     k.ID,
+		Algorithm: string(k.Algorithm),
+		Use:       "sig",
+	}
+}
+
+// JWKS returns the JSON Web Key Set containing the public parts of the given keys, suitable for the
+// jwks field of an authentication policy or for serving from a jwksUri.
+func JWKS(keys ...*Key) (string, error) {
+	set := jose.JSONWebKeySet{}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, k.publicJWK())
+	}
+	out, err := json.Marshal(set)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// JWKSOrFail calls JWKS and fails t if an error occurs.
+func JWKSOrFail(t test.Failer, keys ...*Key) string {
+	t.Helper()
+	out, err := JWKS(keys...)
+	if err != nil {
+		t.Fatalf("jwt.JWKSOrFail: %v", err)
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+const (
+	// AuthorizationHeader is the default location of tokens, with the BearerPrefix.
+	AuthorizationHeader = "Authorization"
+	// BearerPrefix of tokens in the AuthorizationHeader.
+	BearerPrefix = "Bearer "
+)
+
+// Location of a token in a request, matching the locations a JWT policy extracts tokens from: by default the
+// AuthorizationHeader, otherwise the headers listed in jwtHeaders and the query parameters listed in
+// jwtParams.
+type Location struct {
+	header string
+	prefix string
+	param  string
+	cookie string
+}
+
+// InAuthorizationHeader returns the default Location, as a bearer token in the Authorization header.
+func InAuthorizationHeader() Location {
+	return InHeader(AuthorizationHeader, BearerPrefix)
+}
+
+// InHeader returns a Location in the given header, with the given prefix before the token. Headers listed in
+// jwtHeaders carry the raw token, without prefix.
+func InHeader(name, prefix string) Location {
+	return Location{header: name, prefix: prefix}
+}
+
+// InQueryParam returns a Location in the given query parameter, as listed in jwtParams.
+func InQueryParam(name string) Location {
+	return Location{param: name}
+}
+
+// InCookie returns a Location in the cookie with the given name. JWT policies of this version don't extract
+// tokens from cookies, so this is for verifying that such tokens are ignored.
+func InCookie(name string) Location {
+	return Location{cookie: name}
+}
+
+// Apply returns a copy of the given call options that sends the token in the location. The headers of the
+// options are copied rather than modified, and query parameters are added to the path.
+func (l Location) Apply(opts echo.CallOptions, token string) echo.CallOptions {
+	headers := make(http.Header, len(opts.Headers)+1)
+	for k, v := range opts.Headers {
+		headers[k] = append([]string{}, v...)
+	}
+	switch {
+	case l.header != "":
+		headers.Set(l.header, l.prefix+token)
+	case l.cookie != "":
+		cookie := (&http.Cookie{Name: l.cookie, Value: token}).String()
+		if existing := headers.Get("Cookie"); existing != "" {
+			cookie = existing + "; " + cookie
+		}
+		headers.Set("Cookie", cookie)
+	case l.param != "":
+		sep := "?"
+		if strings.Contains(opts.Path, "?") {
+			sep = "&"
+		}
+		opts.Path += sep + url.Values{l.param: []string{token}}.Encode()
+	}
+	opts.Headers = headers
+	return opts
+}
+
+// String describes the location, e.g. for naming sub-tests.
+func (l Location) String() string {
+	switch {
+	case l.header != "":
+		return "header " + l.header
+	case l.cookie != "":
+		return "cookie " + l.cookie
+	case l.param != "":
+		return "query parameter " + l.param
+	default:
+		return "none"
+	}
+}
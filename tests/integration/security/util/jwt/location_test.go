@@ -0,0 +1,62 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"net/http"
+	"testing"
+
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+func TestLocationApply(t *testing.T) {
+	base := echo.CallOptions{
+		Path:    "/path",
+		Headers: http.Header{"X-Custom": {"value"}},
+	}
+	cases := []struct {
+		location Location
+		path     string
+		header   string
+		value    string
+	}{
+		{InAuthorizationHeader(), "/path", "Authorization", "Bearer token"},
+		{InHeader("X-Jwt", ""), "/path", "X-Jwt", "token"},
+		{InQueryParam("access_token"), "/path?access_token=token", "", ""},
+		{InCookie("session"), "/path", "Cookie", "session=token"},
+	}
+	for _, c := range cases {
+		t.Run(c.location.String(), func(t *testing.T) {
+			opts := c.location.Apply(base, "token")
+			if opts.Path != c.path {
+				t.Fatalf("path: got %q, want %q", opts.Path, c.path)
+			}
+			if c.header != "" && opts.Headers.Get(c.header) != c.value {
+				t.Fatalf("header %s: got %q, want %q", c.header, opts.Headers.Get(c.header), c.value)
+			}
+			if opts.Headers.Get("X-Custom") != "value" {
+				t.Fatalf("existing header lost: %v", opts.Headers)
+			}
+			if len(base.Headers) != 1 || base.Path != "/path" {
+				t.Fatalf("base options modified: %+v", base)
+			}
+		})
+	}
+
+	opts := InQueryParam("token").Apply(echo.CallOptions{Path: "/path?a=b"}, "x y")
+	if opts.Path != "/path?a=b&token=x+y" {
+		t.Fatalf("path with query: got %q", opts.Path)
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package manifest loads the topology of a security test suite from a YAML manifest: the namespaces, the echo
+// applications deployed to them and the baseline policies applied at setup. Scenarios can then be added by
+// editing data files, rather than the setup code of the suite.
+//
+// An example manifest:
+//
+//	namespaces:
+//	- name: apps
+//	  inject: true
+//	apps:
+//	- name: a
+//	  namespace: apps
+//	- name: b
+//	  namespace: apps
+//	  subsets:
+//	  - version: v1
+//	  - version: v2
+//	policies:
+//	- template: authn-policy
+//	  namespace: apps
+//	  params:
+//	    Name: default
+//	    Mode: STRICT
+//	- file: testdata/allow-a.yaml.tmpl
+//	  namespace: apps
+//	  params:
+//	    Principal: '{{ principal "a" }}'
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/tests/integration/security/util"
+	"istio.io/istio/tests/integration/security/util/policy"
+)
+
+// Manifest of the topology of a suite.
+type Manifest struct {
+	// Namespaces created at setup.
+	Namespaces []Namespace `yaml:"namespaces"`
+
+	// Apps deployed at setup, after the namespaces.
+	Apps []App `yaml:"apps"`
+
+	// Policies applied at setup, once the apps are deployed.
+	Policies []Policy `yaml:"policies"`
+
+	// dir of the manifest file, which the files of the policies are relative to.
+	dir string
+}
+
+// Namespace created for the suite.
+type Namespace struct {
+	// Name the namespace is referred to by in the manifest. It is the prefix of the name of the created
+	// namespace, to which a unique suffix is added.
+	Name string `yaml:"name"`
+
+	// Inject enables sidecar injection for the namespace.
+	Inject bool `yaml:"inject"`
+
+	// Labels of the namespace.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// App is an echo application, based on the configuration of util.EchoConfig.
+type App struct {
+	// Name of the service of the application, unique in the manifest.
+	Name string `yaml:"name"`
+
+	// Namespace of the manifest the application is deployed to.
+	Namespace string `yaml:"namespace"`
+
+	// Version of the workload, if it has no Subsets.
+	Version string `yaml:"version"`
+
+	// Headless deploys the service without a ClusterIP.
+	Headless bool `yaml:"headless"`
+
+	// Naked deploys the workloads without sidecars.
+	Naked bool `yaml:"naked"`
+
+	// VM deploys the application as a mock VM. VM applications are only deployed in the Kubernetes
+	// environment, and skipped in the others.
+	VM bool `yaml:"vm"`
+
+	// ServiceAccount creates a service account for the application. Defaults to true.
+	ServiceAccount *bool `yaml:"serviceAccount"`
+
+	// Ports of the service. If empty, the http, tcp and grpc ports of util.EchoConfig are used.
+	Ports []Port `yaml:"ports"`
+
+	// Subsets of the application, each deployed as a workload of its own.
+	Subsets []Subset `yaml:"subsets"`
+
+	// Labels of the workloads.
+	Labels map[string]string `yaml:"labels"`
+
+	// Cluster of a multicluster environment the workloads are deployed to. If empty, the primary cluster.
+	Cluster string `yaml:"cluster"`
+}
+
+// Port of an App.
+type Port struct {
+	// Name of the port.
+	Name string `yaml:"name"`
+
+	// Protocol of the port, e.g. HTTP, TCP or GRPC.
+	Protocol string `yaml:"protocol"`
+
+	// ServicePort of the service. If zero, one is generated.
+	ServicePort int `yaml:"servicePort"`
+
+	// InstancePort the echo server listens on. If zero, one is generated.
+	InstancePort int `yaml:"instancePort"`
+}
+
+// Subset of an App.
+type Subset struct {
+	// Version of the subset.
+	Version string `yaml:"version"`
+
+	// Labels of the workloads of the subset.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// Policy applied at setup. It is either a template of the policy library, or a template file. The values of
+// the parameters are templates themselves, which can refer to the deployment with the functions:
+//
+//	namespace "<name>": the name of the created namespace of the manifest.
+//	principal "<app>": the principal of the app in the form of authorization policies, i.e. without the
+//	  spiffe:// prefix.
+//
+// The Namespace parameter is set to the name of the created namespace the policy is applied to, unless it is
+// given.
+type Policy struct {
+	// Template of the policy library, e.g. "authn-policy".
+	Template string `yaml:"template"`
+
+	// File of the template, relative to the manifest, whose parameters are all required.
+	File string `yaml:"file"`
+
+	// Namespace of the manifest the policy is applied to.
+	Namespace string `yaml:"namespace"`
+
+	// Params of the template.
+	Params map[string]string `yaml:"params"`
+}
+
+// String implements fmt.Stringer
+func (p Policy) String() string {
+	if p.Template != "" {
+		return fmt.Sprintf("template %s in %s", p.Template, p.Namespace)
+	}
+	return fmt.Sprintf("file %s in %s", p.File, p.Namespace)
+}
+
+// Load the manifest in the given file.
+func Load(filename string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("manifest %s: %v", filename, err)
+	}
+	m.dir = filepath.Dir(filename)
+	return m, nil
+}
+
+// Parse and validate a manifest. Unknown fields are rejected. The files of its policies are relative to the
+// working directory.
+func Parse(b []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := yaml.UnmarshalStrict(b, m); err != nil {
+		return nil, err
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Manifest) validate() error {
+	namespaces := make(map[string]bool)
+	for _, ns := range m.Namespaces {
+		if ns.Name == "" {
+			return errors.New("namespace without a name")
+		}
+		if namespaces[ns.Name] {
+			return fmt.Errorf("duplicate namespace %s", ns.Name)
+		}
+		namespaces[ns.Name] = true
+	}
+
+	apps := make(map[string]bool)
+	for _, a := range m.Apps {
+		if a.Name == "" {
+			return errors.New("app without a name")
+		}
+		if apps[a.Name] {
+			return fmt.Errorf("duplicate app %s", a.Name)
+		}
+		apps[a.Name] = true
+		if !namespaces[a.Namespace] {
+			return fmt.Errorf("app %s: unknown namespace %q", a.Name, a.Namespace)
+		}
+		for _, p := range a.Ports {
+			if protocol.Parse(p.Protocol) == protocol.Unsupported {
+				return fmt.Errorf("app %s: port %s has an unsupported protocol %q", a.Name, p.Name, p.Protocol)
+			}
+		}
+	}
+
+	for i, p := range m.Policies {
+		switch {
+		case p.Template != "" && p.File != "":
+			return fmt.Errorf("policy %d: only one of template and file can be set", i)
+		case p.Template != "":
+			if _, ok := policy.Lookup(p.Template); !ok {
+				return fmt.Errorf("policy %d: unknown template %q", i, p.Template)
+			}
+		case p.File == "":
+			return fmt.Errorf("policy %d: either template or file must be set", i)
+		}
+		if !namespaces[p.Namespace] {
+			return fmt.Errorf("policy %d: unknown namespace %q", i, p.Namespace)
+		}
+		for name, value := range p.Params {
+			if _, err := parseParam(name, value, nil); err != nil {
+				return fmt.Errorf("policy %d: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// echoConfig returns the configuration of the app, deployed to the given namespace. The options are applied
+// before the settings of the app.
+func (a App) echoConfig(ns namespace.Instance, opts ...util.EchoOption) echo.Config {
+	cfg := util.EchoConfig(a.Name, ns, opts...)
+	if a.Version != "" {
+		cfg.Version = a.Version
+	}
+	cfg.Headless = cfg.Headless || a.Headless
+	cfg.Naked = cfg.Naked || a.Naked
+	cfg.DeployAsVM = cfg.DeployAsVM || a.VM
+	if a.ServiceAccount != nil {
+		cfg.ServiceAccount = *a.ServiceAccount
+	}
+	if len(a.Ports) > 0 {
+		cfg.Ports = nil
+		for _, p := range a.Ports {
+			cfg.Ports = append(cfg.Ports, echo.Port{
+				Name:         p.Name,
+				Protocol:     protocol.Parse(p.Protocol),
+				ServicePort:  p.ServicePort,
+				InstancePort: p.InstancePort,
+			})
+		}
+	}
+	for _, s := range a.Subsets {
+		cfg.Subsets = append(cfg.Subsets, echo.SubsetConfig{
+			Version: s.Version,
+			Labels:  s.Labels,
+		})
+	}
+	if len(a.Labels) > 0 {
+		labels := make(map[string]string, len(cfg.Labels)+len(a.Labels))
+		for k, v := range cfg.Labels {
+			labels[k] = v
+		}
+		for k, v := range a.Labels {
+			labels[k] = v
+		}
+		cfg.Labels = labels
+	}
+	if a.Cluster != "" {
+		cfg.Cluster = a.Cluster
+	}
+	return cfg
+}
+
+// parseParam parses the value of a parameter as a template, with the functions of the deployment. With a nil
+// deployment, the functions fail if called.
+func parseParam(name, value string, d *Deployment) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"namespace": func(name string) (string, error) {
+			if d == nil {
+				return "", errors.New("no deployment")
+			}
+			ns := d.Namespace(name)
+			if ns == nil {
+				return "", fmt.Errorf("unknown namespace %q", name)
+			}
+			return ns.Name(), nil
+		},
+		"principal": func(app string) (string, error) {
+			if d == nil {
+				return "", errors.New("no deployment")
+			}
+			i := d.App(app)
+			if i == nil {
+				return "", fmt.Errorf("unknown or undeployed app %q", app)
+			}
+			return strings.TrimPrefix(util.Principal(i), "spiffe://"), nil
+		},
+	}
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("param %s: %v", name, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+type fakeNamespace string
+
+func (n fakeNamespace) Name() string {
+	return string(n)
+}
+
+func TestLoad(t *testing.T) {
+	m, err := Load("testdata/manifest.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Namespaces) != 2 || len(m.Apps) != 3 || len(m.Policies) != 2 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+
+	cfg := m.Apps[1].echoConfig(fakeNamespace("apps-1"))
+	if cfg.Service != "b" || cfg.Namespace.Name() != "apps-1" || cfg.ServiceAccount {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if len(cfg.Ports) != 1 || cfg.Ports[0].Protocol != protocol.HTTP || cfg.Ports[0].ServicePort != 80 {
+		t.Fatalf("unexpected ports: %+v", cfg.Ports)
+	}
+	if len(cfg.Subsets) != 2 || cfg.Subsets[1].Labels["canary"] != "true" {
+		t.Fatalf("unexpected subsets: %+v", cfg.Subsets)
+	}
+
+	// The defaults of util.EchoConfig are kept.
+	cfg = m.Apps[0].echoConfig(fakeNamespace("apps-1"))
+	if !cfg.ServiceAccount || len(cfg.Ports) != 3 {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+	if !m.Apps[2].echoConfig(fakeNamespace("other-1")).DeployAsVM {
+		t.Fatal("expected a mock VM")
+	}
+}
+
+func TestRender(t *testing.T) {
+	m, err := Load("testdata/manifest.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Deployment{
+		manifest: m,
+		namespaces: map[string]namespace.Instance{
+			"apps":  fakeNamespace("apps-1"),
+			"other": fakeNamespace("other-2"),
+		},
+	}
+
+	out, err := d.render(m.Policies[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "mode: STRICT") {
+		t.Fatalf("unexpected policy:\n%s", out)
+	}
+
+	out, err = d.render(m.Policies[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "namespace: apps-1") || !strings.Contains(out, `namespaces: ["other-2"]`) {
+		t.Fatalf("unexpected policy:\n%s", out)
+	}
+
+	if _, err := d.render(Policy{
+		Template:  "authn-policy",
+		Namespace: "apps",
+		Params:    map[string]string{"Name": `{{ principal "a" }}`},
+	}); err == nil {
+		t.Fatal("expected an error for an undeployed app")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		manifest string
+		err      string
+	}{
+		{
+			name:     "unknown field",
+			manifest: "namespaces:\n- name: apps\n  injected: true\n",
+			err:      "injected",
+		},
+		{
+			name:     "duplicate namespace",
+			manifest: "namespaces:\n- name: apps\n- name: apps\n",
+			err:      "duplicate namespace apps",
+		},
+		{
+			name:     "unknown namespace",
+			manifest: "apps:\n- name: a\n  namespace: apps\n",
+			err:      `unknown namespace "apps"`,
+		},
+		{
+			name:     "duplicate app",
+			manifest: "namespaces:\n- name: apps\napps:\n- name: a\n  namespace: apps\n- name: a\n  namespace: apps\n",
+			err:      "duplicate app a",
+		},
+		{
+			name:     "unsupported protocol",
+			manifest: "namespaces:\n- name: apps\napps:\n- name: a\n  namespace: apps\n  ports:\n  - name: p\n    protocol: SMTP\n",
+			err:      "unsupported protocol",
+		},
+		{
+			name:     "template and file",
+			manifest: "namespaces:\n- name: apps\npolicies:\n- template: authn-policy\n  file: p.yaml\n  namespace: apps\n",
+			err:      "only one of template and file",
+		},
+		{
+			name:     "unknown template",
+			manifest: "namespaces:\n- name: apps\npolicies:\n- template: unknown\n  namespace: apps\n",
+			err:      `unknown template "unknown"`,
+		},
+		{
+			name:     "invalid param",
+			manifest: "namespaces:\n- name: apps\npolicies:\n- template: authn-policy\n  namespace: apps\n  params:\n    Name: '{{ namespace'\n",
+			err:      "param Name",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Parse([]byte(c.manifest))
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("expected an error containing %q, got %v", c.err, err)
+			}
+		})
+	}
+}
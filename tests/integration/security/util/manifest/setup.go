@@ -0,0 +1,190 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/galley"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/tests/integration/security/util"
+	"istio.io/istio/tests/integration/security/util/policy"
+)
+
+// Deployment of a manifest.
+type Deployment struct {
+	manifest   *Manifest
+	namespaces map[string]namespace.Instance
+	apps       map[string]echo.Instance
+	// order of the deployed apps in the manifest.
+	order []string
+}
+
+// Namespace returns the created namespace with the given name in the manifest, or nil if there is none.
+func (d *Deployment) Namespace(name string) namespace.Instance {
+	return d.namespaces[name]
+}
+
+// App returns the deployed app with the given name, or nil if there is none or it wasn't deployed in the
+// environment.
+func (d *Deployment) App(name string) echo.Instance {
+	return d.apps[name]
+}
+
+// Apps returns all of the deployed apps, in the order of the manifest.
+func (d *Deployment) Apps() []echo.Instance {
+	out := make([]echo.Instance, 0, len(d.order))
+	for _, name := range d.order {
+		out = append(out, d.apps[name])
+	}
+	return out
+}
+
+// Setup creates the namespaces of the manifest, deploys its apps and applies its policies through Galley,
+// waiting until they are distributed to the apps. The given options are applied to every app, before its
+// settings in the manifest, e.g. util.WithGalley and util.WithPilot. Everything is tracked by the context and
+// cleaned up when the context is done.
+func Setup(ctx resource.Context, m *Manifest, g galley.Instance, opts ...util.EchoOption) (*Deployment, error) {
+	d := &Deployment{
+		manifest:   m,
+		namespaces: make(map[string]namespace.Instance),
+		apps:       make(map[string]echo.Instance),
+	}
+
+	for _, ns := range m.Namespaces {
+		i, err := namespace.New(ctx, namespace.Config{
+			Prefix: ns.Name,
+			Inject: ns.Inject,
+			Labels: ns.Labels,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed creating namespace %s: %v", ns.Name, err)
+		}
+		d.namespaces[ns.Name] = i
+	}
+
+	builder, err := echoboot.NewBuilder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]echo.Instance, len(m.Apps))
+	for i, a := range m.Apps {
+		if a.VM && ctx.Environment().EnvironmentName() != environment.Kube {
+			continue
+		}
+		builder = builder.With(&instances[i], a.echoConfig(d.namespaces[a.Namespace], opts...))
+		d.order = append(d.order, a.Name)
+	}
+	if err := builder.Build(); err != nil {
+		return nil, err
+	}
+	for i, a := range m.Apps {
+		if instances[i] != nil {
+			d.apps[a.Name] = instances[i]
+		}
+	}
+
+	if len(m.Policies) == 0 {
+		return d, nil
+	}
+	resources := make([]config.Resources, 0, len(m.Policies))
+	for _, p := range m.Policies {
+		yamlText, err := d.render(p)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %v", p, err)
+		}
+		resources = append(resources, config.Resources{
+			Namespace: d.namespaces[p.Namespace],
+			YAML:      []string{yamlText},
+		})
+	}
+	cfg, err := config.New(ctx, config.Config{
+		Galley:  g,
+		WaitFor: d.Apps(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ApplyAll(resources...); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetupOrFail calls Setup and fails t if an error occurs.
+func SetupOrFail(t test.Failer, ctx resource.Context, m *Manifest, g galley.Instance,
+	opts ...util.EchoOption) *Deployment {
+	t.Helper()
+	d, err := Setup(ctx, m, g, opts...)
+	if err != nil {
+		t.Fatalf("manifest.SetupOrFail: %v", err)
+	}
+	return d
+}
+
+// SetupFn returns a resource.SetupFn that loads the manifest in the given file and sets up the deployment,
+// for use with framework.Suite.Setup. The Galley instance is read through the pointer when the function runs,
+// so that it may be set by an earlier setup function.
+func SetupFn(d **Deployment, filename string, g *galley.Instance, opts ...util.EchoOption) resource.SetupFn {
+	return func(ctx resource.Context) error {
+		m, err := Load(filename)
+		if err != nil {
+			return err
+		}
+		*d, err = Setup(ctx, m, *g, opts...)
+		return err
+	}
+}
+
+// render the policy, with the values of its parameters expanded against the deployment.
+func (d *Deployment) render(p Policy) (string, error) {
+	var t *policy.Template
+	if p.Template != "" {
+		t, _ = policy.Lookup(p.Template)
+	} else {
+		filename := p.File
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(d.manifest.dir, filename)
+		}
+		var err error
+		if t, err = policy.Load(filename); err != nil {
+			return "", err
+		}
+	}
+
+	params := policy.Params{
+		"Namespace": d.namespaces[p.Namespace].Name(),
+	}
+	for name, value := range p.Params {
+		v, err := parseParam(name, value, d)
+		if err != nil {
+			return "", err
+		}
+		sb := &strings.Builder{}
+		if err := v.Execute(sb, nil); err != nil {
+			return "", fmt.Errorf("param %s: %v", name, err)
+		}
+		params[name] = sb.String()
+	}
+	return t.Render(params)
+}
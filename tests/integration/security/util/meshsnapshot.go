@@ -0,0 +1,202 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+)
+
+const (
+	meshConfigMapName = "istio"
+	meshConfigKey     = "mesh"
+)
+
+var (
+	// clusterMeshResourceTypes are the cluster scoped Istio resources, which apply to the whole mesh.
+	clusterMeshResourceTypes = []string{
+		"meshpolicies.authentication.istio.io",
+		"clusterrbacconfigs.rbac.istio.io",
+	}
+
+	// rootMeshResourceTypes are the Istio resources that apply to the whole mesh when created in the root
+	// (system) namespace.
+	rootMeshResourceTypes = []string{
+		"policies.authentication.istio.io",
+		"destinationrules.networking.istio.io",
+		"authorizationpolicies.security.istio.io",
+		"serviceroles.rbac.istio.io",
+		"servicerolebindings.rbac.istio.io",
+		"sidecars.networking.istio.io",
+		"envoyfilters.networking.istio.io",
+	}
+)
+
+// MeshSnapshot of the mesh wide configuration: the mesh config, the cluster scoped Istio resources (e.g. the
+// default MeshPolicy, which enables mTLS mesh wide) and the Istio resources in the root namespace.
+type MeshSnapshot struct {
+	env             *kube.Environment
+	systemNamespace string
+
+	meshConfig string
+	// resources by namespace (empty for cluster scoped ones) and type.
+	resources map[resourceKind]map[string]map[string]interface{}
+}
+
+type resourceKind struct {
+	namespace    string
+	resourceType string
+}
+
+// TakeMeshSnapshot returns a snapshot of the current mesh wide configuration of the Istio deployment in the
+// given system namespace.
+func TakeMeshSnapshot(env *kube.Environment, systemNamespace string) (*MeshSnapshot, error) {
+	cm, err := env.GetConfigMap(systemNamespace).Get(meshConfigMapName, kubeApiMeta.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed reading config map %s/%s: %v", systemNamespace, meshConfigMapName, err)
+	}
+	s := &MeshSnapshot{
+		env:             env,
+		systemNamespace: systemNamespace,
+		meshConfig:      cm.Data[meshConfigKey],
+		resources:       make(map[resourceKind]map[string]map[string]interface{}),
+	}
+	for _, kind := range s.kinds() {
+		objects, err := s.get(kind)
+		if err != nil {
+			return nil, err
+		}
+		s.resources[kind] = objects
+	}
+	return s, nil
+}
+
+// Restore the mesh wide configuration to the snapshot: the mesh config is reset, resources created since are
+// deleted, and deleted or modified ones are applied again. The restored configuration takes a while to
+// propagate to the proxies.
+func (s *MeshSnapshot) Restore() (err error) {
+	configMaps := s.env.GetConfigMap(s.systemNamespace)
+	cm, e := configMaps.Get(meshConfigMapName, kubeApiMeta.GetOptions{})
+	if e != nil {
+		return fmt.Errorf("failed reading config map %s/%s: %v", s.systemNamespace, meshConfigMapName, e)
+	}
+	if cm.Data[meshConfigKey] != s.meshConfig {
+		cm.Data[meshConfigKey] = s.meshConfig
+		if _, e := configMaps.Update(cm); e != nil {
+			err = multierror.Append(err, fmt.Errorf("failed restoring config map %s/%s: %v",
+				s.systemNamespace, meshConfigMapName, e))
+		}
+	}
+
+	for _, kind := range s.kinds() {
+		current, e := s.get(kind)
+		if e != nil {
+			err = multierror.Append(err, e)
+			continue
+		}
+		snapshot := s.resources[kind]
+		for name, obj := range snapshot {
+			if cur, ok := current[name]; ok && reflect.DeepEqual(cur, obj) {
+				continue
+			}
+			err = multierror.Append(err, s.apply(kind, obj)).ErrorOrNil()
+		}
+		for name, obj := range current {
+			if _, ok := snapshot[name]; !ok {
+				err = multierror.Append(err, s.delete(kind, obj)).ErrorOrNil()
+			}
+		}
+	}
+	return
+}
+
+// PreserveMeshOrFail takes a snapshot of the mesh wide configuration, and restores it when the given context is
+// done, so that changes of the mesh config or of mesh wide policies made by the test don't affect the following
+// tests. The configuration is restored even if the test panics.
+func PreserveMeshOrFail(ctx framework.TestContext, env *kube.Environment, systemNamespace string) {
+	ctx.Helper()
+	s, err := TakeMeshSnapshot(env, systemNamespace)
+	if err != nil {
+		ctx.Fatalf("failed taking snapshot of the mesh config: %v", err)
+	}
+	ctx.WhenDone(s.Restore)
+}
+
+func (s *MeshSnapshot) kinds() []resourceKind {
+	kinds := make([]resourceKind, 0, len(clusterMeshResourceTypes)+len(rootMeshResourceTypes))
+	for _, t := range clusterMeshResourceTypes {
+		kinds = append(kinds, resourceKind{resourceType: t})
+	}
+	for _, t := range rootMeshResourceTypes {
+		kinds = append(kinds, resourceKind{namespace: s.systemNamespace, resourceType: t})
+	}
+	return kinds
+}
+
+// get returns the resources of the given kind by name, without their server populated metadata.
+func (s *MeshSnapshot) get(kind resourceKind) (map[string]map[string]interface{}, error) {
+	out, err := s.env.GetResourcesYAML(kind.namespace, kind.resourceType)
+	if err != nil {
+		return nil, err
+	}
+	list := struct {
+		Items []map[string]interface{} `json:"items"`
+	}{}
+	if err := yaml.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %v", kind.resourceType, err)
+	}
+
+	objects := make(map[string]map[string]interface{}, len(list.Items))
+	for _, obj := range list.Items {
+		meta, _ := obj["metadata"].(map[string]interface{})
+		name, _ := meta["name"].(string)
+		stripped := map[string]interface{}{
+			"name": name,
+		}
+		if kind.namespace != "" {
+			stripped["namespace"] = kind.namespace
+		}
+		if labels, ok := meta["labels"]; ok {
+			stripped["labels"] = labels
+		}
+		obj["metadata"] = stripped
+		delete(obj, "status")
+		objects[name] = obj
+	}
+	return objects, nil
+}
+
+func (s *MeshSnapshot) apply(kind resourceKind, obj map[string]interface{}) error {
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return s.env.ApplyContents(kind.namespace, string(out))
+}
+
+func (s *MeshSnapshot) delete(kind resourceKind, obj map[string]interface{}) error {
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return s.env.DeleteContents(kind.namespace, string(out))
+}
@@ -0,0 +1,118 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/common"
+	"istio.io/istio/pkg/test/echo/common/response"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// ReceivedCounts returns the number of requests received by the echo servers of all workloads of target since
+// they started, and how many of them were mirrored. Only the requests whose path has the given prefix are
+// counted, so that a unique path per test isolates its requests.
+func ReceivedCounts(target echo.Instance, pathPrefix string) (response.RequestCounts, error) {
+	out := response.RequestCounts{}
+	workloads, err := target.Workloads()
+	if err != nil {
+		return out, err
+	}
+	path := common.CountsPath + "?path=" + url.QueryEscape(pathPrefix)
+	for _, w := range workloads {
+		resp, err := loopbackRequest(target, w, path)
+		if err != nil {
+			return out, err
+		}
+		out.Total += resp.RequestCounts.Total
+		out.Mirrored += resp.RequestCounts.Mirrored
+	}
+	return out, nil
+}
+
+// MirroredRequests returns the mirrored requests with the given path prefix among the requests recorded by the
+// echo server of the given workload of target, oldest first. Their headers show how the mirror was reached,
+// e.g. the X-Forwarded-Client-Cert of requests mirrored over mutual TLS.
+func MirroredRequests(target echo.Instance, w echo.Workload, pathPrefix string) ([]response.Request, error) {
+	requests, err := ReceivedRequests(target, w, 0)
+	if err != nil {
+		return nil, err
+	}
+	var out []response.Request
+	for _, r := range requests {
+		u, err := url.Parse(r.URL)
+		if err != nil || !strings.HasPrefix(u.Path, pathPrefix) {
+			continue
+		}
+		if strings.Contains(r.Host, common.ShadowHostSuffix) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// CheckMirroring makes the call from src, which must succeed, and verifies that the given percentage of its
+// requests was mirrored to mirror, within the given tolerance in percentage points. The call must use a path
+// that is unique to it, e.g. with a test ID, since all of the requests received with the path are counted.
+// The target itself must not receive mirrored requests.
+func CheckMirroring(src echo.Instance, opts echo.CallOptions, mirror echo.Instance, percentage, tolerance float64) error {
+	if opts.Path == "" || opts.Path == "/" {
+		return fmt.Errorf("mirroring must be checked with a unique path")
+	}
+	path := strings.SplitN(opts.Path, "?", 2)[0]
+	resp, err := src.Call(opts)
+	if err != nil {
+		return err
+	}
+	if err := resp.CheckOK(); err != nil {
+		return err
+	}
+
+	// Mirrored requests are sent asynchronously, and may arrive after the response to the caller.
+	return retry.UntilSuccess(func() error {
+		primary, err := ReceivedCounts(opts.Target, path)
+		if err != nil {
+			return err
+		}
+		if primary.Mirrored > 0 {
+			return fmt.Errorf("%s received %d mirrored requests", opts.Target.Config().Service, primary.Mirrored)
+		}
+		mirrored, err := ReceivedCounts(mirror, path)
+		if err != nil {
+			return err
+		}
+		actual := 100 * float64(mirrored.Mirrored) / float64(len(resp))
+		if math.Abs(actual-percentage) > tolerance {
+			return fmt.Errorf("expected %g%% (±%g) of %d requests mirrored to %s, got %.1f%%",
+				percentage, tolerance, len(resp), mirror.Config().Service, actual)
+		}
+		return nil
+	})
+}
+
+// CheckMirroringOrFail calls CheckMirroring and fails t if an error occurs.
+func CheckMirroringOrFail(t test.Failer, src echo.Instance, opts echo.CallOptions, mirror echo.Instance,
+	percentage, tolerance float64) {
+	t.Helper()
+	if err := CheckMirroring(src, opts, mirror, percentage, tolerance); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+const (
+	// DefaultTrustDomain is the trust domain used by the test deployments of Istio.
+	DefaultTrustDomain = "cluster.local"
+)
+
+// Principal returns the SPIFFE identity of the given echo instance in the default trust domain.
+func Principal(i echo.Instance) string {
+	return PrincipalForTrustDomain(i, DefaultTrustDomain)
+}
+
+// PrincipalForTrustDomain returns the SPIFFE identity of the given echo instance in the given trust domain.
+func PrincipalForTrustDomain(i echo.Instance, trustDomain string) string {
+	return SubsetPrincipalForTrustDomain(i, i.Config().Version, trustDomain)
+}
+
+// SubsetPrincipal returns the SPIFFE identity of the workloads of the given subset of the echo instance in the
+// default trust domain.
+func SubsetPrincipal(i echo.Instance, version string) string {
+	return SubsetPrincipalForTrustDomain(i, version, DefaultTrustDomain)
+}
+
+// SubsetPrincipalForTrustDomain returns the SPIFFE identity of the workloads of the given subset of the echo
+// instance in the given trust domain.
+func SubsetPrincipalForTrustDomain(i echo.Instance, version, trustDomain string) string {
+	cfg := i.Config()
+	return fmt.Sprintf("spiffe://%s/ns/%s/sa/%s", trustDomain, cfg.Namespace.Name(), cfg.ServiceAccountFor(version))
+}
+
+// CheckMTLS verifies that all of the responses were received over mutual TLS with the expected principals.
+// An empty expected principal only requires that the corresponding principal is present.
+func CheckMTLS(resp client.ParsedResponses, expectedSrcPrincipal, expectedDstPrincipal string) error {
+	return resp.Check(func(i int, r *client.ParsedResponse) error {
+		if r.SourcePrincipal == "" {
+			return fmt.Errorf("response[%d]: no client certificate was verified, request was not mTLS", i)
+		}
+		if expectedSrcPrincipal != "" && r.SourcePrincipal != expectedSrcPrincipal {
+			return fmt.Errorf("response[%d] source principal: expected %s, received %s",
+				i, expectedSrcPrincipal, r.SourcePrincipal)
+		}
+		if r.DestinationPrincipal == "" {
+			return fmt.Errorf("response[%d]: destination principal missing", i)
+		}
+		if expectedDstPrincipal != "" && r.DestinationPrincipal != expectedDstPrincipal {
+			return fmt.Errorf("response[%d] destination principal: expected %s, received %s",
+				i, expectedDstPrincipal, r.DestinationPrincipal)
+		}
+		return nil
+	})
+}
+
+// CheckMTLSOrFail calls CheckMTLS and fails t if an error occurs.
+func CheckMTLSOrFail(t test.Failer, resp client.ParsedResponses, expectedSrcPrincipal, expectedDstPrincipal string) {
+	t.Helper()
+	if err := CheckMTLS(resp, expectedSrcPrincipal, expectedDstPrincipal); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CheckPlaintext verifies that none of the responses were received over mutual TLS.
+func CheckPlaintext(resp client.ParsedResponses) error {
+	return resp.Check(func(i int, r *client.ParsedResponse) error {
+		if r.SourcePrincipal != "" {
+			return fmt.Errorf("response[%d]: expected plaintext, but received client principal %s",
+				i, r.SourcePrincipal)
+		}
+		return nil
+	})
+}
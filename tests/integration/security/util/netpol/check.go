@@ -0,0 +1,101 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netpol
+
+import (
+	"fmt"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Result of a request subject to both NetworkPolicies and Istio authorization.
+type Result string
+
+const (
+	// Allowed requests were served by the target.
+	Allowed Result = "allowed"
+	// DeniedByMesh requests reached the sidecar of the target, which denied them.
+	DeniedByMesh Result = "denied by authorization policy"
+	// DeniedByNetwork requests never reached the target pod.
+	DeniedByNetwork Result = "denied by network policy"
+)
+
+// ResultOf returns the result of the given HTTP call. Requests denied by Istio authorization are rejected
+// with a 403 by the sidecar of the target. Connections denied by a NetworkPolicy never reach the sidecar of
+// the target: the sidecar of the client responds with a 503 when it fails to connect, while clients without
+// a sidecar fail to connect, e.g. with a timeout. All of the responses must have the same result.
+func ResultOf(resp client.ParsedResponses, err error) (Result, error) {
+	if err != nil {
+		return DeniedByNetwork, nil
+	}
+	if len(resp) == 0 {
+		return "", fmt.Errorf("no responses received")
+	}
+	var result Result
+	for i, r := range resp {
+		var got Result
+		switch r.Code {
+		case "200":
+			got = Allowed
+		case "403":
+			got = DeniedByMesh
+		case "503":
+			got = DeniedByNetwork
+		default:
+			return "", fmt.Errorf("response[%d]: unexpected response code %s", i, r.Code)
+		}
+		if i > 0 && got != result {
+			return "", fmt.Errorf("response[%d] was %s, but response[0] was %s", i, got, result)
+		}
+		result = got
+	}
+	return result, nil
+}
+
+// Checker verifies the result of requests between echo instances, when both NetworkPolicies and Istio
+// authorization apply to them, e.g. that a request allowed by the mesh is still denied by the network.
+type Checker struct {
+	From    echo.Instance
+	Options echo.CallOptions
+	// Expected result of the requests. Calls from instances without a sidecar that are DeniedByNetwork only
+	// fail after the Timeout of the Options, so it should be short.
+	Expected Result
+}
+
+// Check whether the requests from the source have the expected result.
+func (c *Checker) Check() error {
+	got, err := ResultOf(c.From.Call(c.Options))
+	if err != nil {
+		return fmt.Errorf("%s to %s:%s: %v", c.From.Config().Service, c.Options.Target.Config().Service,
+			c.Options.PortName, err)
+	}
+	if got != c.Expected {
+		return fmt.Errorf("%s to %s:%s: expected the request to be %s, but it was %s",
+			c.From.Config().Service, c.Options.Target.Config().Service, c.Options.PortName, c.Expected, got)
+	}
+	return nil
+}
+
+// CheckOrFail calls Check until it succeeds, since both kinds of policies are enforced asynchronously, and
+// fails t if it times out.
+func (c *Checker) CheckOrFail(t test.Failer) {
+	t.Helper()
+	if err := retry.UntilSuccess(c.Check); err != nil {
+		t.Fatal(err)
+	}
+}
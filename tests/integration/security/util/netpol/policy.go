@@ -0,0 +1,234 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package netpol generates Kubernetes NetworkPolicy resources restricting the traffic between echo
+// namespaces, and checks the outcome of requests subject to both NetworkPolicies and Istio authorization.
+package netpol
+
+import (
+	"github.com/ghodss/yaml"
+
+	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+const (
+	defaultPolicyName = "netpol"
+
+	// NamespaceLabel is set on the namespaces of the peers of a policy when it is applied, with the name of
+	// the namespace as value, so that the policy can select them.
+	NamespaceLabel = "istio-testing-netpol"
+)
+
+// Peer allowed to connect to the pods selected by a Policy.
+type Peer struct {
+	namespace namespace.Instance
+	app       string
+	ipBlock   string
+}
+
+// FromNamespace returns a Peer matching all pods of the given namespace.
+func FromNamespace(ns namespace.Instance) Peer {
+	return Peer{namespace: ns}
+}
+
+// FromWorkloads returns a Peer matching the pods of the given echo instance.
+func FromWorkloads(i echo.Instance) Peer {
+	return Peer{namespace: i.Config().Namespace, app: i.Config().Service}
+}
+
+// FromIPBlock returns a Peer matching the addresses of the given CIDR, e.g. the pod CIDR of a node.
+func FromIPBlock(cidr string) Peer {
+	return Peer{ipBlock: cidr}
+}
+
+func (p Peer) spec() map[string]interface{} {
+	if p.ipBlock != "" {
+		return map[string]interface{}{
+			"ipBlock": map[string]string{"cidr": p.ipBlock},
+		}
+	}
+	out := map[string]interface{}{
+		"namespaceSelector": matchLabels(NamespaceLabel, p.namespace.Name()),
+	}
+	if p.app != "" {
+		out["podSelector"] = matchLabels("app", p.app)
+	}
+	return out
+}
+
+type port struct {
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port"`
+}
+
+type ingressRule struct {
+	From  []map[string]interface{} `json:"from"`
+	Ports []port                   `json:"ports,omitempty"`
+}
+
+type rule struct {
+	from  Peer
+	ports []port
+}
+
+// Policy is a builder of NetworkPolicy resources. The selected pods only accept the connections that are
+// allowed by the rules of the policy: without rules, all of their ingress traffic is denied.
+//
+// NetworkPolicies are enforced by the CNI plugin before the traffic reaches the sidecar, so they apply to
+// the instance ports of the pods, rather than to the service ports.
+type Policy struct {
+	ns     namespace.Instance
+	name   string
+	target echo.Instance
+	rules  []rule
+}
+
+// NewPolicy returns a new Policy in the given namespace, selecting all of its pods and denying all of their
+// ingress traffic.
+func NewPolicy(ns namespace.Instance) *Policy {
+	return &Policy{
+		ns:   ns,
+		name: defaultPolicyName,
+	}
+}
+
+// Named sets the name of the policy.
+func (p *Policy) Named(name string) *Policy {
+	p.name = name
+	return p
+}
+
+// Selecting restricts the policy to the pods of the given echo instance. If nil, the policy applies to all
+// pods in the namespace.
+func (p *Policy) Selecting(target echo.Instance) *Policy {
+	p.target = target
+	return p
+}
+
+// Allow connections from the given peer to the instance ports of the given ports. If no ports are given,
+// connections to all ports are allowed.
+func (p *Policy) Allow(from Peer, ports ...echo.Port) *Policy {
+	r := rule{from: from}
+	for _, pt := range ports {
+		proto := "TCP"
+		if pt.Protocol == protocol.UDP {
+			proto = "UDP"
+		}
+		r.ports = append(r.ports, port{Protocol: proto, Port: pt.InstancePort})
+	}
+	p.rules = append(p.rules, r)
+	return p
+}
+
+// YAML returns the generated NetworkPolicy resource.
+func (p *Policy) YAML() (string, error) {
+	podSelector := map[string]interface{}{}
+	if p.target != nil {
+		podSelector = matchLabels("app", p.target.Config().Service)
+	}
+	// A policy without rules must have an empty list of rules, rather than none, to deny all traffic.
+	rules := make([]ingressRule, 0, len(p.rules))
+	for _, r := range p.rules {
+		rules = append(rules, ingressRule{
+			From:  []map[string]interface{}{r.from.spec()},
+			Ports: r.ports,
+		})
+	}
+	out, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "networking.k8s.io/v1",
+		"kind":       "NetworkPolicy",
+		"metadata": map[string]string{
+			"name":      p.name,
+			"namespace": p.ns.Name(),
+		},
+		"spec": map[string]interface{}{
+			"podSelector": podSelector,
+			"policyTypes": []string{"Ingress"},
+			"ingress":     rules,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// YAMLOrFail calls YAML and fails t if an error occurs.
+func (p *Policy) YAMLOrFail(t test.Failer) string {
+	t.Helper()
+	out, err := p.YAML()
+	if err != nil {
+		t.Fatalf("netpol.Policy.YAMLOrFail: %v", err)
+	}
+	return out
+}
+
+// Apply the policy, after labeling the namespaces of its peers with the NamespaceLabel. NetworkPolicies
+// are enforced asynchronously, so the outcome of requests should be checked with retries.
+func (p *Policy) Apply(env *kube.Environment) error {
+	out, err := p.YAML()
+	if err != nil {
+		return err
+	}
+	for _, ns := range p.peerNamespaces() {
+		if err := env.SetNamespaceLabels(ns, map[string]string{NamespaceLabel: ns}); err != nil {
+			return err
+		}
+	}
+	return env.ApplyContents(p.ns.Name(), out)
+}
+
+// Delete the policy. The labels of the namespaces are kept, since they may be used by other policies.
+func (p *Policy) Delete(env *kube.Environment) error {
+	out, err := p.YAML()
+	if err != nil {
+		return err
+	}
+	return env.DeleteContents(p.ns.Name(), out)
+}
+
+// ApplyOrFail applies the policy, and deletes it when the given context is done.
+func (p *Policy) ApplyOrFail(ctx framework.TestContext, env *kube.Environment) {
+	ctx.Helper()
+	if err := p.Apply(env); err != nil {
+		ctx.Fatalf("netpol.Policy.ApplyOrFail: %v", err)
+	}
+	ctx.WhenDone(func() error {
+		return p.Delete(env)
+	})
+}
+
+func (p *Policy) peerNamespaces() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, r := range p.rules {
+		if r.from.namespace == nil || seen[r.from.namespace.Name()] {
+			continue
+		}
+		seen[r.from.namespace.Name()] = true
+		out = append(out, r.from.namespace.Name())
+	}
+	return out
+}
+
+func matchLabels(key, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"matchLabels": map[string]string{key: value},
+	}
+}
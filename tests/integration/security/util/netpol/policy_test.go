@@ -0,0 +1,101 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netpol
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+type fakeNamespace string
+
+func (n fakeNamespace) Name() string {
+	return string(n)
+}
+
+func TestPolicyYAML(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy *Policy
+		want   string
+	}{
+		{
+			name:   "deny all",
+			policy: NewPolicy(fakeNamespace("b")),
+			want: `
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: netpol
+  namespace: b
+spec:
+  podSelector: {}
+  policyTypes: [Ingress]
+  ingress: []
+`,
+		},
+		{
+			name: "allow namespace and ip block",
+			policy: NewPolicy(fakeNamespace("b")).Named("allow").
+				Allow(FromNamespace(fakeNamespace("a"))).
+				Allow(FromIPBlock("10.0.0.0/8")),
+			want: `
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow
+  namespace: b
+spec:
+  podSelector: {}
+  policyTypes: [Ingress]
+  ingress:
+  - from:
+    - namespaceSelector:
+        matchLabels:
+          istio-testing-netpol: a
+  - from:
+    - ipBlock:
+        cidr: 10.0.0.0/8
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got, want interface{}
+			if err := yaml.Unmarshal([]byte(c.policy.YAMLOrFail(t)), &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := yaml.Unmarshal([]byte(c.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got:\n%v\nwant:\n%v", got, want)
+			}
+		})
+	}
+
+}
+
+func TestPeerNamespaces(t *testing.T) {
+	p := NewPolicy(fakeNamespace("b")).
+		Allow(FromNamespace(fakeNamespace("a"))).
+		Allow(FromNamespace(fakeNamespace("a"))).
+		Allow(FromIPBlock("10.0.0.0/8"))
+	if got := p.peerNamespaces(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("got %v, want [a]", got)
+	}
+}
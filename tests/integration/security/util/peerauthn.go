@@ -0,0 +1,203 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	envoyAdmin "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
+	"github.com/golang/protobuf/ptypes"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util/policy"
+)
+
+// MTLSMode is the mutual TLS mode accepted by the inbound ports of a workload.
+type MTLSMode string
+
+const (
+	// MTLSStrict only accepts mutual TLS traffic.
+	MTLSStrict MTLSMode = "STRICT"
+	// MTLSPermissive accepts both mutual TLS and plaintext traffic.
+	MTLSPermissive MTLSMode = "PERMISSIVE"
+	// MTLSDisable only accepts plaintext traffic.
+	MTLSDisable MTLSMode = "DISABLE"
+
+	listenersConfigDumpType = "type.googleapis.com/envoy.admin.v2alpha.ListenersConfigDump"
+)
+
+// PeerAuthentication configures the mutual TLS mode of an echo instance, with optional per-port overrides.
+// It is converted to authentication Policy resources, along with a DestinationRule that makes clients
+// use the matching TLS mode for each port.
+type PeerAuthentication struct {
+	// Name used as a prefix of the generated resources. Defaults to the service name of the target.
+	Name string
+
+	// Target echo instance.
+	Target echo.Instance
+
+	// Mode for all ports of the target that don't have an override. Defaults to MTLSPermissive.
+	Mode MTLSMode
+
+	// Ports overrides the mode for the ports with the given names.
+	Ports map[string]MTLSMode
+}
+
+func (p PeerAuthentication) name() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Target.Config().Service
+}
+
+func (p PeerAuthentication) mode() MTLSMode {
+	if p.Mode != "" {
+		return p.Mode
+	}
+	return MTLSPermissive
+}
+
+// ModeForPort returns the mode applied to the given port of the target.
+func (p PeerAuthentication) ModeForPort(port echo.Port) MTLSMode {
+	if mode, ok := p.Ports[port.Name]; ok {
+		return mode
+	}
+	return p.mode()
+}
+
+// YAML returns the authentication Policy and DestinationRule resources for the configuration.
+func (p PeerAuthentication) YAML() string {
+	cfg := p.Target.Config()
+	docs := []string{authnPolicy(p.name(), cfg.Service, "", p.mode())}
+
+	portSettings := make([]string, 0, len(cfg.Ports))
+	for _, port := range cfg.Ports {
+		mode, ok := p.Ports[port.Name]
+		if ok {
+			docs = append(docs, authnPolicy(fmt.Sprintf("%s-%s", p.name(), port.Name), cfg.Service, port.Name, mode))
+		}
+		tlsMode := "ISTIO_MUTUAL"
+		if p.ModeForPort(port) == MTLSDisable {
+			tlsMode = "DISABLE"
+		}
+		portSettings = append(portSettings, fmt.Sprintf(`
+    - port:
+        number: %d
+      tls:
+        mode: %s`, port.ServicePort, tlsMode))
+	}
+
+	docs = append(docs, fmt.Sprintf(`apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: %s
+spec:
+  host: %s
+  trafficPolicy:
+    portLevelSettings:%s
+`, p.name(), cfg.FQDN(), strings.Join(portSettings, "")))
+
+	return strings.Join(docs, "---\n")
+}
+
+func authnPolicy(name, service, portName string, mode MTLSMode) string {
+	p := policy.Params{
+		"Name":     name,
+		"Service":  service,
+		"PortName": portName,
+	}
+	if mode != MTLSDisable {
+		p["Mode"] = string(mode)
+	}
+	return policy.AuthnPolicy.MustRender(p)
+}
+
+// ApplyPeerAuthentication applies the given configuration, and waits until the inbound
+// listeners of all workloads of the target are configured with the expected mTLS modes. The configuration
+// is deleted when the context is done.
+func ApplyPeerAuthentication(ctx framework.TestContext, c config.Instance, p PeerAuthentication, opts ...retry.Option) {
+	ctx.Helper()
+	ns := p.Target.Config().Namespace
+	yaml := p.YAML()
+	c.ApplyOrFail(ctx, ns, yaml)
+	ctx.WhenDone(func() error {
+		return c.Delete(ns, yaml)
+	})
+
+	for _, w := range p.Target.WorkloadsOrFail(ctx) {
+		if w.Sidecar() == nil {
+			continue
+		}
+		w.Sidecar().WaitForConfigOrFail(ctx, InboundMTLSAcceptFunc(w.Address(), p), opts...)
+	}
+}
+
+// InboundMTLSAcceptFunc returns a function that accepts Envoy configuration once the inbound listeners
+// of the workload with the given address match the modes of the given configuration.
+func InboundMTLSAcceptFunc(workloadAddress string, p PeerAuthentication) func(*envoyAdmin.ConfigDump) (bool, error) {
+	return func(cfg *envoyAdmin.ConfigDump) (bool, error) {
+		for _, c := range cfg.Configs {
+			if c.TypeUrl != listenersConfigDumpType {
+				continue
+			}
+			dump := envoyAdmin.ListenersConfigDump{}
+			if err := ptypes.UnmarshalAny(c, &dump); err != nil {
+				return false, err
+			}
+
+			for _, port := range p.Target.Config().Ports {
+				expected := p.ModeForPort(port)
+				name := fmt.Sprintf("%s_%d", workloadAddress, port.InstancePort)
+				actual, err := inboundMTLSMode(&dump, name)
+				if err != nil {
+					return false, err
+				}
+				if actual != expected {
+					return false, fmt.Errorf("inbound listener %s: expected mTLS mode %s, found %s", name, expected, actual)
+				}
+			}
+			return true, nil
+		}
+		return false, fmt.Errorf("envoy listeners not found in config dump")
+	}
+}
+
+func inboundMTLSMode(dump *envoyAdmin.ListenersConfigDump, name string) (MTLSMode, error) {
+	for _, l := range dump.DynamicActiveListeners {
+		if l.GetListener().GetName() != name {
+			continue
+		}
+		tls := 0
+		chains := l.GetListener().GetFilterChains()
+		for _, chain := range chains {
+			if chain.GetTlsContext() != nil {
+				tls++
+			}
+		}
+		switch {
+		case tls == 0:
+			return MTLSDisable, nil
+		case tls == len(chains):
+			return MTLSStrict, nil
+		default:
+			return MTLSPermissive, nil
+		}
+	}
+	return "", fmt.Errorf("inbound listener %s not found", name)
+}
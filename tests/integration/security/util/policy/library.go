@@ -0,0 +1,84 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+var (
+	// AuthnPolicy is an authentication Policy configuring peer authentication.
+	//
+	// Parameters:
+	//   Name: of the policy. It must be "default" for a namespace-wide policy.
+	//   Service: (optional) targeted by the policy. The policy applies to the whole namespace if empty.
+	//   PortName: (optional) of the service targeted by the policy. The policy applies to all ports if empty.
+	//   Mode: (optional) of mutual TLS, e.g. STRICT or PERMISSIVE. Mutual TLS is disabled if empty.
+	AuthnPolicy = MustNew("authn-policy", `apiVersion: authentication.istio.io/v1alpha1
+kind: Policy
+metadata:
+  name: {{.Name}}
+spec:
+{{- if .Service}}
+  targets:
+  - name: {{.Service}}
+{{- if .PortName}}
+    ports:
+    - name: {{.PortName}}
+{{- end}}
+{{- end}}
+{{- if .Mode}}
+  peers:
+  - mtls:
+      mode: {{.Mode}}
+{{- end}}
+`, "Service", "PortName", "Mode")
+
+	// AllowPrincipalToPath is an AuthorizationPolicy allowing a single principal to call a single path of the
+	// workloads with the given app label.
+	//
+	// Parameters:
+	//   Name, Namespace: of the policy.
+	//   App: label of the workloads the policy applies to.
+	//   Principal: allowed, without the spiffe:// prefix.
+	//   Path: allowed.
+	AllowPrincipalToPath = MustNew("allow-principal-to-path", `apiVersion: security.istio.io/v1beta1
+kind: AuthorizationPolicy
+metadata:
+  name: {{.Name}}
+  namespace: {{.Namespace}}
+spec:
+  selector:
+    matchLabels:
+      app: {{.App}}
+  rules:
+  - from:
+    - source:
+        principals:
+        - {{.Principal}}
+    to:
+    - operation:
+        paths:
+        - {{.Path}}
+`)
+)
+
+// library of the templates above, by name.
+var library = map[string]*Template{
+	AuthnPolicy.Name():          AuthnPolicy,
+	AllowPrincipalToPath.Name(): AllowPrincipalToPath,
+}
+
+// Lookup returns the template of the library with the given name, e.g. "authn-policy".
+func Lookup(name string) (*Template, bool) {
+	t, ok := library[name]
+	return t, ok
+}
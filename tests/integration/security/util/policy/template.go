@@ -0,0 +1,236 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy provides templates of the configuration used by the security tests, with strict checking of
+// their parameters: rendering a template fails if any of the parameters it references is missing, instead of
+// silently producing "<no value>" in the YAML.
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"istio.io/istio/pkg/test"
+)
+
+// Params of a template, by name.
+type Params map[string]string
+
+// Template of configuration resources, whose parameters are referenced as fields of dot (e.g. {{.Namespace}}).
+type Template struct {
+	name     string
+	tpl      *template.Template
+	required []string
+	optional []string
+}
+
+// New parses a template. All of the parameters it references are required to be non-empty when rendering, except
+// for the given optional ones, which default to empty.
+func New(name, text string, optional ...string) (*Template, error) {
+	tpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	isOptional := make(map[string]bool, len(optional))
+	for _, o := range optional {
+		isOptional[o] = true
+	}
+	refs := make(map[string]bool)
+	collectFields(tpl.Root, refs)
+	for o := range isOptional {
+		if !refs[o] {
+			return nil, fmt.Errorf("template %s: optional parameter %s is not used", name, o)
+		}
+	}
+
+	t := &Template{
+		name:     name,
+		tpl:      tpl,
+		optional: optional,
+	}
+	for r := range refs {
+		if !isOptional[r] {
+			t.required = append(t.required, r)
+		}
+	}
+	sort.Strings(t.required)
+	return t, nil
+}
+
+// MustNew calls New and panics if an error occurs. It is meant for templates defined in package variables.
+func MustNew(name, text string, optional ...string) *Template {
+	t, err := New(name, text, optional...)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Load parses the template in the given file. See New.
+func Load(filename string, optional ...string) (*Template, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return New(filename, string(content), optional...)
+}
+
+// Name of the template.
+func (t *Template) Name() string {
+	return t.name
+}
+
+// Required returns the names of the parameters that must be set to render the template, sorted.
+func (t *Template) Required() []string {
+	return append([]string(nil), t.required...)
+}
+
+// Render the template with the given parameters. Fails if a required parameter is missing or empty. Parameters
+// that the template doesn't use are ignored, so that the same parameters can be used for several templates.
+func (t *Template) Render(p Params) (string, error) {
+	var missing []string
+	for _, r := range t.required {
+		if p[r] == "" {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("template %s: missing parameters: %s", t.name, strings.Join(missing, ", "))
+	}
+
+	data := make(map[string]string, len(p)+len(t.optional))
+	for _, o := range t.optional {
+		data[o] = ""
+	}
+	for k, v := range p {
+		data[k] = v
+	}
+	var b bytes.Buffer
+	if err := t.tpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("template %s: %v", t.name, err)
+	}
+	return b.String(), nil
+}
+
+// RenderOrFail calls Render and fails t if an error occurs.
+func (t *Template) RenderOrFail(f test.Failer, p Params) string {
+	f.Helper()
+	out, err := t.Render(p)
+	if err != nil {
+		f.Fatal(err)
+	}
+	return out
+}
+
+// MustRender calls Render and panics if an error occurs. It is meant for parameters that are always set by the
+// caller.
+func (t *Template) MustRender(p Params) string {
+	out, err := t.Render(p)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// RenderAll renders each of the templates with the same parameters.
+func RenderAll(p Params, templates ...*Template) ([]string, error) {
+	out := make([]string, 0, len(templates))
+	for _, t := range templates {
+		content, err := t.Render(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, content)
+	}
+	return out, nil
+}
+
+// RenderAllOrFail calls RenderAll and fails t if an error occurs.
+func RenderAllOrFail(t test.Failer, p Params, templates ...*Template) []string {
+	t.Helper()
+	out, err := RenderAll(p, templates...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// RenderFiles loads the templates in the given files, all of whose parameters are required, and renders each of
+// them with the same parameters.
+func RenderFiles(p Params, filenames ...string) ([]string, error) {
+	templates := make([]*Template, 0, len(filenames))
+	for _, f := range filenames {
+		t, err := Load(f)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return RenderAll(p, templates...)
+}
+
+// RenderFilesOrFail calls RenderFiles and fails t if an error occurs.
+func RenderFilesOrFail(t test.Failer, p Params, filenames ...string) []string {
+	t.Helper()
+	out, err := RenderFiles(p, filenames...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// collectFields adds the names of the fields of dot referenced by the given node to refs. The bodies of range and
+// with actions are skipped, since dot is something else there.
+func collectFields(node parse.Node, refs map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			collectFields(c, refs)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, refs)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			collectFields(c, refs)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			collectFields(a, refs)
+		}
+	case *parse.FieldNode:
+		refs[n.Ident[0]] = true
+	case *parse.IfNode:
+		collectFields(n.Pipe, refs)
+		collectFields(n.List, refs)
+		collectFields(n.ElseList, refs)
+	case *parse.RangeNode:
+		collectFields(n.Pipe, refs)
+	case *parse.WithNode:
+		collectFields(n.Pipe, refs)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, refs)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderMissingParams(t *testing.T) {
+	tpl := MustNew("test", "a: {{.A}}\nb: {{.B}}\n{{if .C}}c: {{.C}}\n{{end}}{{with .D}}d: {{.}}\n{{end}}", "C")
+	if !reflect.DeepEqual(tpl.Required(), []string{"A", "B", "D"}) {
+		t.Fatalf("unexpected required parameters: %v", tpl.Required())
+	}
+
+	_, err := tpl.Render(Params{"A": "1", "B": ""})
+	if err == nil || !strings.Contains(err.Error(), "missing parameters: B, D") {
+		t.Fatalf("expected missing B and D, got %v", err)
+	}
+
+	out, err := tpl.Render(Params{"A": "1", "B": "2", "D": "x", "Unused": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "a: 1\nb: 2\nd: x\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestNewUnusedOptional(t *testing.T) {
+	if _, err := New("test", "a: {{.A}}", "B"); err == nil {
+		t.Fatal("expected an error for an unused optional parameter")
+	}
+}
+
+func TestAuthnPolicy(t *testing.T) {
+	cases := []struct {
+		name     string
+		params   Params
+		expected string
+	}{
+		{
+			name:   "namespace",
+			params: Params{"Name": "default", "Mode": "PERMISSIVE"},
+			expected: `apiVersion: authentication.istio.io/v1alpha1
+kind: Policy
+metadata:
+  name: default
+spec:
+  peers:
+  - mtls:
+      mode: PERMISSIVE
+`,
+		},
+		{
+			name:   "port",
+			params: Params{"Name": "b-http", "Service": "b", "PortName": "http"},
+			expected: `apiVersion: authentication.istio.io/v1alpha1
+kind: Policy
+metadata:
+  name: b-http
+spec:
+  targets:
+  - name: b
+    ports:
+    - name: http
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if out := AuthnPolicy.RenderOrFail(t, c.params); out != c.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", c.expected, out)
+			}
+		})
+	}
+}
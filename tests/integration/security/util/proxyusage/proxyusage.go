@@ -0,0 +1,283 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package proxyusage samples the CPU and memory usage of the proxies of echo instances while a test runs, so
+// that expensive proxy configurations, such as large authorization policies, are caught as regressions.
+package proxyusage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+	k "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// ArtifactName is the name of the file the report is written to by WriteArtifact.
+	ArtifactName = "proxy-usage.json"
+
+	// DefaultInterval between samples. The metrics API typically refreshes usage every 15 to 60 seconds.
+	DefaultInterval = 15 * time.Second
+
+	proxyContainerName = "istio-proxy"
+)
+
+// Sample of the usage of a single proxy.
+type Sample struct {
+	Time      time.Time `json:"time"`
+	Workload  string    `json:"workload"`
+	Namespace string    `json:"namespace"`
+	// MilliCPU used by the proxy, averaged over the window of the metrics API.
+	MilliCPU int64 `json:"milliCPU"`
+	// MemoryBytes is the working set of the proxy.
+	MemoryBytes int64 `json:"memoryBytes"`
+}
+
+// Thresholds of the usage of each proxy. Zero values are not checked.
+type Thresholds struct {
+	MilliCPU    int64
+	MemoryBytes int64
+}
+
+// Report of the samples of a set of proxies.
+type Report struct {
+	Samples []Sample `json:"samples"`
+}
+
+type target struct {
+	accessor  *k.Accessor
+	namespace string
+	workload  string
+}
+
+// Sampler samples the usage of proxies in the background until it is stopped.
+type Sampler struct {
+	targets  []target
+	interval time.Duration
+
+	mu      sync.Mutex
+	samples []Sample
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+	once   sync.Once
+}
+
+// Start sampling the usage of the proxies of the workloads of the given instances, every interval. The usage
+// is read from the metrics API, so the cluster must run a metrics server.
+func Start(ctx resource.Context, interval time.Duration, instances ...echo.Instance) (*Sampler, error) {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return nil, fmt.Errorf("proxy usage is only supported in the %s environment", environment.Kube)
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	s := &Sampler{
+		interval: interval,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	for _, i := range instances {
+		cfg := i.Config()
+		accessor, err := env.Cluster(cfg.Cluster)
+		if err != nil {
+			return nil, err
+		}
+		workloads, err := i.Workloads()
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range workloads {
+			if w.Sidecar() == nil {
+				continue
+			}
+			s.targets = append(s.targets, target{
+				accessor:  accessor,
+				namespace: cfg.Namespace.Name(),
+				workload:  w.Name(),
+			})
+		}
+	}
+
+	// Take the first sample right away, so that the metrics API is known to work.
+	if err := s.sample(); err != nil {
+		return nil, err
+	}
+	go s.run()
+	return s, nil
+}
+
+// StartOrFail calls Start and fails the test if an error occurs. The sampler is stopped when the test is done,
+// if it wasn't already.
+func StartOrFail(ctx framework.TestContext, interval time.Duration, instances ...echo.Instance) *Sampler {
+	ctx.Helper()
+	s, err := Start(ctx, interval, instances...)
+	if err != nil {
+		ctx.Fatalf("proxyusage.StartOrFail: %v", err)
+	}
+	ctx.WhenDone(func() error {
+		s.Stop()
+		return nil
+	})
+	return s
+}
+
+func (s *Sampler) run() {
+	defer close(s.doneCh)
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-t.C:
+			if err := s.sample(); err != nil {
+				scopes.Framework.Warnf("Failed sampling proxy usage: %v", err)
+			}
+		}
+	}
+}
+
+func (s *Sampler) sample() error {
+	for _, t := range s.targets {
+		m, err := t.accessor.GetPodMetrics(t.namespace, t.workload)
+		if err != nil {
+			return err
+		}
+		c, ok := m.Container(proxyContainerName)
+		if !ok {
+			return fmt.Errorf("no metrics for the proxy of %s/%s", t.namespace, t.workload)
+		}
+		s.mu.Lock()
+		s.samples = append(s.samples, Sample{
+			Time:        m.Timestamp,
+			Workload:    t.workload,
+			Namespace:   t.namespace,
+			MilliCPU:    c.CPU.MilliValue(),
+			MemoryBytes: c.Memory.Value(),
+		})
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop sampling, and return the report of all samples.
+func (s *Sampler) Stop() *Report {
+	s.once.Do(func() {
+		close(s.stopCh)
+		<-s.doneCh
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Report{Samples: append([]Sample{}, s.samples...)}
+}
+
+// StopOrFail stops sampling, writes the report as an artifact to the work dir of the test, and fails the test
+// if any proxy exceeded the thresholds.
+func (s *Sampler) StopOrFail(ctx framework.TestContext, thresholds Thresholds) *Report {
+	ctx.Helper()
+	report := s.Stop()
+	scopes.Framework.Infof("Proxy usage: %s", report)
+	if _, err := report.WriteArtifact(ctx.WorkDir()); err != nil {
+		ctx.Fatalf("proxyusage.StopOrFail: %v", err)
+	}
+	if err := report.Check(thresholds); err != nil {
+		ctx.Fatalf("proxyusage.StopOrFail: %v", err)
+	}
+	return report
+}
+
+// Max returns the largest sample of each proxy, keyed by "<namespace>/<workload>". The CPU and memory maxima
+// may come from different samples.
+func (r *Report) Max() map[string]Sample {
+	out := make(map[string]Sample)
+	for _, s := range r.Samples {
+		key := s.Namespace + "/" + s.Workload
+		m, ok := out[key]
+		if !ok {
+			out[key] = s
+			continue
+		}
+		if s.MilliCPU > m.MilliCPU {
+			m.MilliCPU = s.MilliCPU
+		}
+		if s.MemoryBytes > m.MemoryBytes {
+			m.MemoryBytes = s.MemoryBytes
+		}
+		out[key] = m
+	}
+	return out
+}
+
+// Check verifies that no proxy exceeded the thresholds.
+func (r *Report) Check(thresholds Thresholds) error {
+	var exceeded []string
+	for key, m := range r.Max() {
+		if thresholds.MilliCPU > 0 && m.MilliCPU > thresholds.MilliCPU {
+			exceeded = append(exceeded, fmt.Sprintf("%s: %dm CPU", key, m.MilliCPU))
+		}
+		if thresholds.MemoryBytes > 0 && m.MemoryBytes > thresholds.MemoryBytes {
+			exceeded = append(exceeded, fmt.Sprintf("%s: %d bytes memory", key, m.MemoryBytes))
+		}
+	}
+	if len(exceeded) > 0 {
+		sort.Strings(exceeded)
+		return fmt.Errorf("proxies exceeded the usage thresholds (%dm CPU, %d bytes memory): %s",
+			thresholds.MilliCPU, thresholds.MemoryBytes, strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+// WriteArtifact writes the report as JSON to ArtifactName in the given directory, and returns the path of
+// the file.
+func (r *Report) WriteArtifact(dir string) (string, error) {
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, ArtifactName)
+	if err := ioutil.WriteFile(path, out, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// String implements fmt.Stringer
+func (r *Report) String() string {
+	max := r.Max()
+	keys := make([]string, 0, len(max))
+	for key := range max {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s: max %dm CPU, %dMi memory", key, max[key].MilliCPU, max[key].MemoryBytes/(1024*1024)))
+	}
+	return fmt.Sprintf("%d samples; %s", len(r.Samples), strings.Join(parts, "; "))
+}
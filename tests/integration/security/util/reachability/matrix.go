@@ -0,0 +1,281 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reachability
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/common/scheme"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+	"istio.io/istio/tests/integration/security/util/connection"
+	"istio.io/istio/tests/integration/security/util/report"
+)
+
+const (
+	// DefaultConcurrency is the default number of rows of a Matrix that are checked at the same time.
+	DefaultConcurrency = 8
+)
+
+var (
+	defaultRetryOptions = []retry.Option{retry.Delay(time.Second), retry.Timeout(time.Second * 30)}
+)
+
+// Row is a single expectation of a reachability Matrix.
+type Row struct {
+	// From is the source of the call.
+	From echo.Instance
+
+	// To is the target of the call.
+	To echo.Instance
+
+	// Port is the name of the port on the target.
+	Port string
+
+	// Scheme used for the call. If not provided, a default for the port is selected.
+	Scheme scheme.Instance
+
+	// Path of the request.
+	Path string
+
+	// ExpectSuccess indicates whether the call is expected to succeed.
+	ExpectSuccess bool
+
+	// ExpectMTLS indicates that a successful call must have been made over mutual TLS between the
+	// principals of From and To. Ignored if ExpectSuccess is false.
+	ExpectMTLS bool
+}
+
+// String implements fmt.Stringer
+func (r Row) String() string {
+	return fmt.Sprintf("%s->%s://%s:%s%s", r.From.Config().Service, r.Scheme, r.To.Config().Service, r.Port, r.Path)
+}
+
+func (r Row) callOptions() echo.CallOptions {
+	return echo.CallOptions{
+		Target:   r.To,
+		PortName: r.Port,
+		Scheme:   r.Scheme,
+		Path:     r.Path,
+	}
+}
+
+func (r Row) check() error {
+	checker := connection.Checker{
+		From:          r.From,
+		Options:       r.callOptions(),
+		ExpectSuccess: r.ExpectSuccess,
+	}
+	if err := checker.Check(); err != nil {
+		return err
+	}
+
+	if !r.ExpectSuccess || !r.ExpectMTLS {
+		return nil
+	}
+
+	results, err := r.From.Call(r.callOptions())
+	if err != nil {
+		return err
+	}
+	return util.CheckMTLS(results, util.Principal(r.From), util.Principal(r.To))
+}
+
+func (r Row) expectation() report.Expectation {
+	if r.ExpectSuccess && r.ExpectMTLS {
+		return report.AllowMTLS
+	}
+	return report.ExpectationOf(r.ExpectSuccess)
+}
+
+// Result of checking a single Row.
+type Result struct {
+	Row     Row
+	Err     error
+	Elapsed time.Duration
+	// History of the attempts made until the row matched its expectation or timed out.
+	History retry.History
+}
+
+// Call returns the result in the form exported by the report package.
+func (r Result) Call() report.Call {
+	return report.NewCall(r.Row.From, r.Row.To, r.Row.callOptions(), r.Row.expectation(), r.History, r.Err)
+}
+
+// Results of checking a Matrix.
+type Results []Result
+
+// Failed returns the results that did not match the expectation.
+func (r Results) Failed() Results {
+	out := make(Results, 0)
+	for _, result := range r {
+		if result.Err != nil {
+			out = append(out, result)
+		}
+	}
+	return out
+}
+
+// Calls returns the results in the form exported by the report package.
+func (r Results) Calls() []report.Call {
+	out := make([]report.Call, 0, len(r))
+	for _, result := range r {
+		out = append(out, result.Call())
+	}
+	return out
+}
+
+// Export the results as a JSON artifact with the given name into the work directory of the test.
+func (r Results) Export(ctx framework.TestContext, name string) (string, error) {
+	return report.Write(ctx, name, r.Calls())
+}
+
+// ExportOrFail calls Export and fails the test if an error occurs.
+func (r Results) ExportOrFail(ctx framework.TestContext, name string) string {
+	ctx.Helper()
+	return report.WriteOrFail(ctx, name, r.Calls())
+}
+
+// Report returns a human readable table of the results.
+func (r Results) Report() string {
+	sb := &strings.Builder{}
+	w := tabwriter.NewWriter(sb, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SOURCE\tDESTINATION\tPORT\tSCHEME\tEXPECT\tRESULT\tATTEMPTS\tELAPSED")
+	for _, result := range r {
+		outcome := "PASS"
+		if result.Err != nil {
+			outcome = "FAIL: " + result.Err.Error()
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%v\n",
+			result.Row.From.Config().Service,
+			result.Row.To.Config().Service,
+			result.Row.Port,
+			result.Row.Scheme,
+			result.Row.expectation(),
+			outcome,
+			len(result.History.Attempts),
+			result.Elapsed.Round(time.Millisecond))
+	}
+	_ = w.Flush()
+	return sb.String()
+}
+
+// Matrix is a declarative set of reachability expectations.
+type Matrix struct {
+	Rows []Row
+
+	// Concurrency is the maximum number of rows that are checked at the same time. If <= 0,
+	// DefaultConcurrency is used.
+	Concurrency int
+
+	// RetryOptions applied while waiting for each row to match its expectation.
+	RetryOptions []retry.Option
+}
+
+// Check all rows of the matrix, retrying each until it matches its expectation or times out.
+func (m Matrix) Check() Results {
+	concurrency := m.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	retryOptions := m.RetryOptions
+	if len(retryOptions) == 0 {
+		retryOptions = defaultRetryOptions
+	}
+
+	results := make(Results, len(m.Rows))
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, row := range m.Rows {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		i, row := i, row
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			history := retry.History{}
+			start := time.Now()
+			err := retry.UntilSuccess(row.check, withOptions(retryOptions, retry.RecordHistory(&history))...)
+			results[i] = Result{
+				Row:     row,
+				Err:     err,
+				Elapsed: time.Since(start),
+				History: history,
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// CheckOrFail checks all rows of the matrix and fails t with a report if any of them did not match
+// its expectation.
+func (m Matrix) CheckOrFail(t test.Failer) Results {
+	t.Helper()
+	results := m.Check()
+	if failed := results.Failed(); len(failed) > 0 {
+		t.Fatalf("%d/%d reachability checks failed:\n%s", len(failed), len(results), results.Report())
+	}
+	return results
+}
+
+// CheckAndExportOrFail checks all rows of the matrix, exports the results as a JSON artifact with the given
+// name, and fails the test with a report if any of them did not match its expectation.
+func (m Matrix) CheckAndExportOrFail(ctx framework.TestContext, name string) Results {
+	ctx.Helper()
+	results := m.Check()
+	results.ExportOrFail(ctx, name)
+	if failed := results.Failed(); len(failed) > 0 {
+		ctx.Fatalf("%d/%d reachability checks failed:\n%s", len(failed), len(results), results.Report())
+	}
+	return results
+}
+
+// withOptions returns a new slice with the extra options appended to the base ones, so that the base slice
+// can be shared by concurrent checks.
+func withOptions(base []retry.Option, extra ...retry.Option) []retry.Option {
+	return append(append(make([]retry.Option, 0, len(base)+len(extra)), base...), extra...)
+}
+
+// Cross returns a row for every combination of the given sources, destinations and ports. The
+// expectation for each row is filled in by the expect function.
+func Cross(from, to []echo.Instance, ports []Row, expect func(row *Row)) []Row {
+	rows := make([]Row, 0, len(from)*len(to)*len(ports))
+	for _, src := range from {
+		for _, dst := range to {
+			for _, p := range ports {
+				row := p
+				row.From = src
+				row.To = dst
+				if expect != nil {
+					expect(&row)
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows
+}
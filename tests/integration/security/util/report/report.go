@@ -0,0 +1,214 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report exports the outcomes of the calls made by the security tests as JSON artifacts in the work
+// directory of the test, for consumption by flake tracking and coverage dashboards.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	// FileSuffix of the artifacts written into the work directory of the tests.
+	FileSuffix = ".results.json"
+
+	// Version of the format of the artifacts, incremented on incompatible changes.
+	Version = 1
+)
+
+// Expectation of a call.
+type Expectation string
+
+const (
+	// Allow expects the call to succeed.
+	Allow Expectation = "allow"
+	// AllowMTLS expects the call to succeed over mutual TLS.
+	AllowMTLS Expectation = "allow-mtls"
+	// Deny expects the call to fail.
+	Deny Expectation = "deny"
+)
+
+// ExpectationOf returns the expectation of a call that is expected to succeed or fail.
+func ExpectationOf(expectSuccess bool) Expectation {
+	if expectSuccess {
+		return Allow
+	}
+	return Deny
+}
+
+// Outcome of a call, whether it matched its expectation.
+type Outcome string
+
+const (
+	// Pass is the outcome of calls matching their expectation.
+	Pass Outcome = "pass"
+	// Fail is the outcome of calls that did not match their expectation before timing out.
+	Fail Outcome = "fail"
+)
+
+// Call is the result of a call, retried until it matched its expectation or timed out.
+type Call struct {
+	// Case the call belongs to, if the test checks the same call in several configurations.
+	Case        string      `json:"case,omitempty"`
+	Source      string      `json:"source"`
+	Destination string      `json:"destination"`
+	Port        string      `json:"port"`
+	Scheme      string      `json:"scheme,omitempty"`
+	Path        string      `json:"path,omitempty"`
+	Expectation Expectation `json:"expectation"`
+	Outcome     Outcome     `json:"outcome"`
+	// Error of the last failed attempt. Set for passing calls too, if they passed after a retry.
+	Error string `json:"error,omitempty"`
+	// LatencyMillis is the duration of the last attempt, in milliseconds.
+	LatencyMillis int64 `json:"latencyMillis"`
+	// ElapsedMillis is the duration of all attempts, including the delays between them, in milliseconds.
+	ElapsedMillis int64 `json:"elapsedMillis"`
+	Attempts      int   `json:"attempts"`
+}
+
+// NewCall returns the result of a call from the given source to the given destination, from the history of
+// the attempts and the error returned by the retry operation.
+func NewCall(from, to echo.Instance, opts echo.CallOptions, expectation Expectation, h retry.History, err error) Call {
+	c := Call{
+		Source:      from.Config().Service,
+		Destination: to.Config().Service,
+		Port:        opts.PortName,
+		Scheme:      string(opts.Scheme),
+		Path:        opts.Path,
+		Expectation: expectation,
+	}
+	c.setOutcome(h, err)
+	return c
+}
+
+func (c *Call) setOutcome(h retry.History, err error) {
+	c.Outcome = Pass
+	if err != nil {
+		c.Outcome = Fail
+	}
+	if last := h.LastError(); last != nil {
+		c.Error = last.Error()
+	} else if err != nil {
+		c.Error = err.Error()
+	}
+	c.Attempts = len(h.Attempts)
+	if c.Attempts > 0 {
+		c.LatencyMillis = millis(h.Attempts[c.Attempts-1].Duration)
+	}
+	c.ElapsedMillis = millis(h.Elapsed)
+}
+
+func millis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+// Artifact is the content of an exported file.
+type Artifact struct {
+	Version int    `json:"version"`
+	Test    string `json:"test"`
+	// Name of the artifact within the test.
+	Name  string    `json:"name"`
+	Time  time.Time `json:"time"`
+	Calls []Call    `json:"calls"`
+}
+
+// Write the calls as an artifact with the given name into the work directory of the test, returning the
+// path of the file.
+func Write(ctx framework.TestContext, name string, calls []Call) (string, error) {
+	a := Artifact{
+		Version: Version,
+		Test:    ctx.Name(),
+		Name:    name,
+		Time:    time.Now(),
+		Calls:   calls,
+	}
+	if a.Calls == nil {
+		a.Calls = []Call{}
+	}
+	out, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	file := path.Join(ctx.WorkDir(), name+FileSuffix)
+	if err := ioutil.WriteFile(file, out, 0644); err != nil {
+		return "", fmt.Errorf("failed writing the results of %s: %v", name, err)
+	}
+	scopes.Framework.Infof("Wrote %d call results to %s", len(calls), file)
+	return file, nil
+}
+
+// WriteOrFail calls Write and fails the test if an error occurs.
+func WriteOrFail(ctx framework.TestContext, name string, calls []Call) string {
+	ctx.Helper()
+	file, err := Write(ctx, name, calls)
+	if err != nil {
+		ctx.Fatal(err)
+	}
+	return file
+}
+
+// Recorder collects the results of calls made concurrently, e.g. by parallel sub-tests.
+type Recorder struct {
+	mutex sync.Mutex
+	calls []Call
+}
+
+// Add the result of a call.
+func (r *Recorder) Add(c Call) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls = append(r.calls, c)
+}
+
+// Calls returns the recorded results, ordered by case, source, destination, port and scheme, so that
+// artifacts of different runs are comparable.
+func (r *Recorder) Calls() []Call {
+	r.mutex.Lock()
+	out := append([]Call{}, r.calls...)
+	r.mutex.Unlock()
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		switch {
+		case a.Case != b.Case:
+			return a.Case < b.Case
+		case a.Source != b.Source:
+			return a.Source < b.Source
+		case a.Destination != b.Destination:
+			return a.Destination < b.Destination
+		case a.Port != b.Port:
+			return a.Port < b.Port
+		default:
+			return a.Scheme < b.Scheme
+		}
+	})
+	return out
+}
+
+// Write the recorded results as an artifact with the given name into the work directory of the test.
+func (r *Recorder) Write(ctx framework.TestContext, name string) (string, error) {
+	return Write(ctx, name, r.Calls())
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+func TestSetOutcome(t *testing.T) {
+	retried := retry.History{
+		Attempts: []retry.Attempt{
+			{Duration: 40 * time.Millisecond, Err: errors.New("connection refused")},
+			{Duration: 15 * time.Millisecond},
+		},
+		Elapsed: 1055 * time.Millisecond,
+	}
+	cases := []struct {
+		name     string
+		history  retry.History
+		err      error
+		expected Call
+	}{
+		{
+			name:     "first attempt",
+			history:  retry.History{Attempts: []retry.Attempt{{Duration: 5 * time.Millisecond}}, Elapsed: 5 * time.Millisecond},
+			expected: Call{Outcome: Pass, LatencyMillis: 5, ElapsedMillis: 5, Attempts: 1},
+		},
+		{
+			name:     "retried",
+			history:  retried,
+			expected: Call{Outcome: Pass, Error: "connection refused", LatencyMillis: 15, ElapsedMillis: 1055, Attempts: 2},
+		},
+		{
+			name: "timeout",
+			history: retry.History{
+				Attempts: []retry.Attempt{{Duration: 30 * time.Millisecond, Err: errors.New("403 Forbidden")}},
+				Elapsed:  30 * time.Second,
+			},
+			err:      errors.New("timeout while waiting"),
+			expected: Call{Outcome: Fail, Error: "403 Forbidden", LatencyMillis: 30, ElapsedMillis: 30000, Attempts: 1},
+		},
+		{
+			name:     "no attempts",
+			err:      errors.New("context canceled"),
+			expected: Call{Outcome: Fail, Error: "context canceled"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := Call{}
+			actual.setOutcome(c.history, c.err)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Fatalf("expected %+v, got %+v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecorderCalls(t *testing.T) {
+	r := &Recorder{}
+	r.Add(Call{Source: "b", Destination: "a", Port: "http"})
+	r.Add(Call{Source: "a", Destination: "b", Port: "tcp"})
+	r.Add(Call{Source: "a", Destination: "b", Port: "http", Scheme: "websocket"})
+	r.Add(Call{Source: "a", Destination: "b", Port: "http", Scheme: "http"})
+
+	expected := []Call{
+		{Source: "a", Destination: "b", Port: "http", Scheme: "http"},
+		{Source: "a", Destination: "b", Port: "http", Scheme: "websocket"},
+		{Source: "a", Destination: "b", Port: "tcp"},
+		{Source: "b", Destination: "a", Port: "http"},
+	}
+	if actual := r.Calls(); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rotation rotates the signing CA of Citadel, or watches the rotation of the workload certificates,
+// while traffic flows between echo workloads, and reports any requests that failed during the rotation.
+package rotation
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	kubeutil "istio.io/istio/pkg/test/kube"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+	"istio.io/istio/tests/integration/security/util/cert"
+	"istio.io/istio/tests/integration/security/util/traffic"
+
+	kubeApiCore "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	citadelSelector = "istio=citadel"
+
+	defaultBaseline = 10 * time.Second
+	defaultSettle   = 60 * time.Second
+
+	restartTimeout = 5 * time.Minute
+	restartDelay   = 2 * time.Second
+)
+
+// Config for a rotation.
+type Config struct {
+	// IstioNamespace is the namespace Citadel is running in. Istio must be deployed with a plugged-in CA
+	// (i.e. security.selfSigned=false), so that Citadel mounts the cacerts secret.
+	IstioNamespace string
+
+	// CA that Citadel signs workload certificates with after the rotation.
+	CA *cert.Bundle
+
+	// WorkloadNamespaces whose Istio secrets are deleted after the rotation, to have them re-issued by the
+	// new CA.
+	WorkloadNamespaces []namespace.Instance
+
+	// Traffic that is sent continuously during the rotation, by a traffic.Generator for each config.
+	Traffic []traffic.Config
+
+	// Baseline is the duration traffic is sent for before rotating. Defaults to 10s.
+	Baseline time.Duration
+
+	// Settle is the duration traffic is sent for after rotating. Defaults to 60s.
+	Settle time.Duration
+}
+
+// Report of a rotation.
+type Report struct {
+	// Start and End of the traffic.
+	Start time.Time
+	End   time.Time
+	// Rotated is the time the new CA was plugged in.
+	Rotated time.Time
+	// Traffic sent during the rotation, in the order of the configs.
+	Traffic []traffic.Result
+}
+
+// Failures returns the number of failed requests.
+func (r *Report) Failures() int {
+	return failures(r.Traffic)
+}
+
+// String implements fmt.Stringer
+func (r *Report) String() string {
+	sb := &strings.Builder{}
+	_, _ = fmt.Fprintf(sb, "%d/%d requests failed (rotated at %s)\n",
+		r.Failures(), requests(r.Traffic), r.Rotated.Format(time.RFC3339Nano))
+	for _, t := range r.Traffic {
+		for _, f := range t.Failures() {
+			_, _ = fmt.Fprintf(sb, "  %s (%+v after rotation) %s: %v\n",
+				f.Time.Format(time.RFC3339Nano), f.Time.Sub(r.Rotated), t.Name, f.Err)
+		}
+	}
+	return sb.String()
+}
+
+// Rotate plugs in the configured CA while sending traffic, and returns the report of all of the requests. The
+// original CA of Citadel is restored when ctx is done.
+func Rotate(ctx resource.Context, cfg Config) (*Report, error) {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return nil, fmt.Errorf("rotation is only supported in the %s environment", environment.Kube)
+	}
+	if cfg.CA == nil {
+		return nil, fmt.Errorf("no CA to rotate to")
+	}
+	fillInDefaults(&cfg)
+	if err := saveCA(ctx, env, cfg); err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	report.Start = time.Now()
+	generators := startTraffic(cfg.Traffic)
+
+	time.Sleep(cfg.Baseline)
+
+	report.Rotated = time.Now()
+	err := plugCA(env, cfg)
+	if err == nil {
+		time.Sleep(cfg.Settle)
+	}
+
+	report.Traffic = stopTraffic(generators)
+	report.End = time.Now()
+
+	if err != nil {
+		return report, err
+	}
+	scopes.Framework.Infof("CA rotation complete: %s", report)
+	return report, nil
+}
+
+// RotateOrFail calls Rotate and fails t if an error occurs, or if any of the requests failed.
+func RotateOrFail(t test.Failer, ctx resource.Context, cfg Config) *Report {
+	t.Helper()
+	report, err := Rotate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("rotation.RotateOrFail: %v", err)
+	}
+	if report.Failures() > 0 {
+		t.Fatalf("rotation.RotateOrFail: %s", report)
+	}
+	return report
+}
+
+// startTraffic starts a generator for each of the configs.
+func startTraffic(configs []traffic.Config) []traffic.Generator {
+	out := make([]traffic.Generator, 0, len(configs))
+	for _, c := range configs {
+		out = append(out, traffic.NewGenerator(c).Start())
+	}
+	return out
+}
+
+// stopTraffic stops the generators, and returns their results in the same order.
+func stopTraffic(generators []traffic.Generator) []traffic.Result {
+	out := make([]traffic.Result, 0, len(generators))
+	for _, g := range generators {
+		out = append(out, g.Stop())
+	}
+	return out
+}
+
+func requests(results []traffic.Result) int {
+	n := 0
+	for _, r := range results {
+		n += len(r.Records)
+	}
+	return n
+}
+
+func failures(results []traffic.Result) int {
+	n := 0
+	for _, r := range results {
+		n += len(r.Failures())
+	}
+	return n
+}
+
+func fillInDefaults(cfg *Config) {
+	if cfg.Baseline == 0 {
+		cfg.Baseline = defaultBaseline
+	}
+	if cfg.Settle == 0 {
+		cfg.Settle = defaultSettle
+	}
+}
+
+// plugCA replaces the cacerts secret with the configured CA, restarts Citadel to load it and triggers the
+// re-issuance of the workload secrets.
+func plugCA(env *kube.Environment, cfg Config) error {
+	return setCA(env, cfg.IstioNamespace, cfg.CA.CACertsSecret(cfg.IstioNamespace), cfg.WorkloadNamespaces)
+}
+
+// setCA replaces the cacerts secret with the given one, or only deletes it if nil, restarts Citadel to load it
+// and triggers the re-issuance of the workload secrets of the given namespaces.
+func setCA(env *kube.Environment, istioNamespace string, secret *kubeApiCore.Secret,
+	workloadNamespaces []namespace.Instance) error {
+	if err := env.DeleteSecret(istioNamespace, cert.CACertsSecretName); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed deleting %s secret: %v", cert.CACertsSecretName, err)
+	}
+	if secret != nil {
+		if _, err := env.GetSecret(istioNamespace).Create(secret); err != nil {
+			return fmt.Errorf("failed creating %s secret: %v", cert.CACertsSecretName, err)
+		}
+	}
+
+	pods, err := env.GetPods(istioNamespace, citadelSelector)
+	if err != nil {
+		return err
+	}
+	deleted := make(map[string]bool)
+	for _, pod := range pods {
+		deleted[string(pod.UID)] = true
+		if err := env.DeletePod(pod.Namespace, pod.Name); err != nil {
+			return fmt.Errorf("failed restarting Citadel: %v", err)
+		}
+	}
+	if err := waitForRestart(env, istioNamespace, deleted); err != nil {
+		return fmt.Errorf("citadel did not become ready after restart: %v", err)
+	}
+
+	return util.ReissueWorkloadCerts(env, workloadNamespaces...)
+}
+
+// originalCA restores the cacerts secret Citadel was using before a rotation, when the context it is tracked
+// by is done.
+type originalCA struct {
+	id  resource.ID
+	env *kube.Environment
+	cfg Config
+	// secret is the original cacerts secret, or nil if there was none and Citadel was self-signed.
+	secret *kubeApiCore.Secret
+}
+
+var _ io.Closer = &originalCA{}
+
+// saveCA records the cacerts secret of Citadel, and tracks its restoration with ctx.
+func saveCA(ctx resource.Context, env *kube.Environment, cfg Config) error {
+	o := &originalCA{
+		env: env,
+		cfg: cfg,
+	}
+	secret, err := env.GetSecret(cfg.IstioNamespace).Get(cert.CACertsSecretName, kubeApiMeta.GetOptions{})
+	switch {
+	case err == nil:
+		o.secret = &kubeApiCore.Secret{
+			ObjectMeta: kubeApiMeta.ObjectMeta{
+				Name:        secret.Name,
+				Namespace:   secret.Namespace,
+				Labels:      secret.Labels,
+				Annotations: secret.Annotations,
+			},
+			Type: secret.Type,
+			Data: secret.Data,
+		}
+	case errors.IsNotFound(err):
+	default:
+		return fmt.Errorf("failed reading %s secret: %v", cert.CACertsSecretName, err)
+	}
+	o.id = ctx.TrackResource(o)
+	return nil
+}
+
+// ID implements resource.Resource.
+func (o *originalCA) ID() resource.ID {
+	return o.id
+}
+
+// Close restores the original CA, restarts Citadel and re-issues the workload secrets signed by the rotated CA.
+func (o *originalCA) Close() error {
+	scopes.Framework.Infof("Restoring the original CA of Citadel in %s", o.cfg.IstioNamespace)
+	return setCA(o.env, o.cfg.IstioNamespace, o.secret, o.cfg.WorkloadNamespaces)
+}
+
+// waitForRestart waits until none of the deleted Citadel pods, by UID, exist anymore and a new one is ready.
+// The deleted pods stay ready while they are terminating, so waiting for any ready pod may find one of them
+// before the new pod loaded the plugged-in CA.
+func waitForRestart(env *kube.Environment, namespace string, deleted map[string]bool) error {
+	return retry.UntilSuccess(func() error {
+		pods, err := env.GetPods(namespace, citadelSelector)
+		if err != nil {
+			return err
+		}
+		ready := false
+		for i := range pods {
+			pod := &pods[i]
+			if deleted[string(pod.UID)] {
+				return fmt.Errorf("pod %s is still terminating", pod.Name)
+			}
+			if kubeutil.CheckPodReady(pod) == nil {
+				ready = true
+			}
+		}
+		if !ready {
+			return fmt.Errorf("no new Citadel pod is ready")
+		}
+		return nil
+	}, retry.Timeout(restartTimeout), retry.Delay(restartDelay))
+}
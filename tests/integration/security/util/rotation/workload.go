@@ -0,0 +1,253 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rotation
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/tests/integration/security/util/traffic"
+)
+
+const (
+	defaultRotations    = 2
+	defaultPollInterval = 5 * time.Second
+
+	// clockSkew tolerated between Citadel and the test when checking the lifetime of the certificates.
+	clockSkew = time.Minute
+)
+
+// WorkloadConfig for watching the rotation of the workload certificates of echo instances. Istio must be
+// deployed with a short workload certificate TTL, e.g. with istio.WorkloadCertTTL.
+type WorkloadConfig struct {
+	// Instances whose workload certificates are watched. The workloads must have sidecars.
+	Instances []echo.Instance
+
+	// TTL of the workload certificates, as configured for Citadel.
+	TTL time.Duration
+
+	// Rotations is the number of rotations to wait for on each workload. Defaults to 2.
+	Rotations int
+
+	// Timeout for all of the rotations. Defaults to Rotations+1 times the TTL.
+	Timeout time.Duration
+
+	// PollInterval between reads of the certificates of the workloads. Defaults to 5s.
+	PollInterval time.Duration
+
+	// Traffic that is sent continuously while watching, by a traffic.Generator for each config.
+	Traffic []traffic.Config
+}
+
+// Certificate observed in use by a workload.
+type Certificate struct {
+	// Serial number of the certificate.
+	Serial string
+	// NotBefore and NotAfter of the certificate.
+	NotBefore time.Time
+	NotAfter  time.Time
+	// Observed is the time the certificate was first read from the workload.
+	Observed time.Time
+}
+
+// WorkloadReport of the rotations of the certificates of the workloads.
+type WorkloadReport struct {
+	// Start and End of the watch.
+	Start time.Time
+	End   time.Time
+	// TTL the certificates were checked against.
+	TTL time.Duration
+	// Certificates used by each workload, by workload name, in the order they were observed.
+	Certificates map[string][]Certificate
+	// Traffic sent while watching, in the order of the configs.
+	Traffic []traffic.Result
+}
+
+// Rotations returns the number of rotations observed for the given workload.
+func (r *WorkloadReport) Rotations(workload string) int {
+	if n := len(r.Certificates[workload]); n > 0 {
+		return n - 1
+	}
+	return 0
+}
+
+// Failures returns the number of failed requests.
+func (r *WorkloadReport) Failures() int {
+	return failures(r.Traffic)
+}
+
+// Check verifies that every workload rotated its certificate at least the given number of times, that each
+// certificate had the expected lifetime and was replaced before it expired, and that no request failed.
+func (r *WorkloadReport) Check(rotations int) error {
+	var errs []string
+	for workload, certs := range r.Certificates {
+		if n := r.Rotations(workload); n < rotations {
+			errs = append(errs, fmt.Sprintf("%s: %d rotations observed, expected at least %d", workload, n, rotations))
+		}
+		for i, c := range certs {
+			if lifetime := c.NotAfter.Sub(c.NotBefore); lifetime > r.TTL+clockSkew {
+				errs = append(errs, fmt.Sprintf("%s: certificate %s is valid for %v, expected at most %v",
+					workload, c.Serial, lifetime, r.TTL))
+			}
+			if i > 0 && certs[i].Observed.After(certs[i-1].NotAfter.Add(clockSkew)) {
+				errs = append(errs, fmt.Sprintf("%s: certificate %s expired at %s before it was replaced at %s",
+					workload, certs[i-1].Serial, certs[i-1].NotAfter.Format(time.RFC3339), c.Observed.Format(time.RFC3339)))
+			}
+		}
+	}
+	if r.Failures() > 0 {
+		for _, t := range r.Traffic {
+			if len(t.Failures()) > 0 {
+				errs = append(errs, strings.TrimSuffix(t.String(), "\n"))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("workload certificate rotation failed:\n%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// String implements fmt.Stringer
+func (r *WorkloadReport) String() string {
+	sb := &strings.Builder{}
+	_, _ = fmt.Fprintf(sb, "%d/%d requests failed during %v\n", r.Failures(), requests(r.Traffic), r.End.Sub(r.Start))
+	for workload, certs := range r.Certificates {
+		_, _ = fmt.Fprintf(sb, "  %s: %d rotations\n", workload, r.Rotations(workload))
+		for _, c := range certs {
+			_, _ = fmt.Fprintf(sb, "    %s valid %s - %s, observed %s\n", c.Serial,
+				c.NotBefore.Format(time.RFC3339), c.NotAfter.Format(time.RFC3339), c.Observed.Format(time.RFC3339))
+		}
+	}
+	return sb.String()
+}
+
+// WatchWorkloadCerts reads the certificates of the workloads of the configured instances until each of them
+// rotated the configured number of times, or the timeout expires, while sending traffic. The returned report
+// holds the observed certificates and all of the requests; use Check to verify it.
+func WatchWorkloadCerts(cfg WorkloadConfig) (*WorkloadReport, error) {
+	if cfg.TTL <= 0 {
+		return nil, fmt.Errorf("no workload certificate TTL")
+	}
+	fillInWorkloadDefaults(&cfg)
+
+	var workloads []echo.Workload
+	for _, i := range cfg.Instances {
+		w, err := i.Workloads()
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, w...)
+	}
+
+	report := &WorkloadReport{
+		TTL:          cfg.TTL,
+		Certificates: make(map[string][]Certificate),
+	}
+	report.Start = time.Now()
+	generators := startTraffic(cfg.Traffic)
+
+	deadline := report.Start.Add(cfg.Timeout)
+	var err error
+	for {
+		if err = observeCerts(workloads, report); err != nil {
+			break
+		}
+		done := true
+		for _, w := range workloads {
+			if report.Rotations(w.Name()) < cfg.Rotations {
+				done = false
+			}
+		}
+		if done || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(cfg.PollInterval)
+	}
+
+	report.Traffic = stopTraffic(generators)
+	report.End = time.Now()
+	if err != nil {
+		return report, err
+	}
+	scopes.Framework.Infof("Workload certificate rotation watch complete: %s", report)
+	return report, nil
+}
+
+// WatchWorkloadCertsOrFail calls WatchWorkloadCerts and fails t if an error occurs, or if the check of the
+// report fails.
+func WatchWorkloadCertsOrFail(t test.Failer, cfg WorkloadConfig) *WorkloadReport {
+	t.Helper()
+	report, err := WatchWorkloadCerts(cfg)
+	if err != nil {
+		t.Fatalf("rotation.WatchWorkloadCertsOrFail: %v", err)
+	}
+	rotations := cfg.Rotations
+	if rotations == 0 {
+		rotations = defaultRotations
+	}
+	if err := report.Check(rotations); err != nil {
+		t.Fatalf("rotation.WatchWorkloadCertsOrFail: %v\n%s", err, report)
+	}
+	return report
+}
+
+func fillInWorkloadDefaults(cfg *WorkloadConfig) {
+	if cfg.Rotations == 0 {
+		cfg.Rotations = defaultRotations
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = time.Duration(cfg.Rotations+1) * cfg.TTL
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+}
+
+// observeCerts records the leaf certificates of the workloads that differ from the last ones observed.
+func observeCerts(workloads []echo.Workload, report *WorkloadReport) error {
+	now := time.Now()
+	for _, w := range workloads {
+		if w.Sidecar() == nil {
+			return fmt.Errorf("workload %s has no sidecar", w.Name())
+		}
+		chain, err := w.Sidecar().Certificates()
+		if err != nil || len(chain) == 0 {
+			// The certificates may be unavailable for a moment while they are replaced.
+			scopes.Framework.Warnf("Failed reading the certificates of %s: %v", w.Name(), err)
+			continue
+		}
+		leaf := toCertificate(chain[0], now)
+		certs := report.Certificates[w.Name()]
+		if len(certs) == 0 || certs[len(certs)-1].Serial != leaf.Serial {
+			report.Certificates[w.Name()] = append(certs, leaf)
+		}
+	}
+	return nil
+}
+
+func toCertificate(c *x509.Certificate, observed time.Time) Certificate {
+	return Certificate{
+		Serial:    c.SerialNumber.Text(16),
+		NotBefore: c.NotBefore,
+		NotAfter:  c.NotAfter,
+		Observed:  observed,
+	}
+}
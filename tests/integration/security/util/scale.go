@@ -0,0 +1,187 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/config"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/echo/echoboot"
+	"istio.io/istio/pkg/test/framework/components/galley"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/tests/integration/security/util/policy"
+)
+
+const (
+	defaultScalePrefix    = "scale"
+	defaultScaleBatchSize = 50
+)
+
+// ScaleOptions for ScaleSetup.
+type ScaleOptions struct {
+	// Namespace the services and their configuration are deployed to.
+	Namespace namespace.Instance
+
+	// Galley used for applying the generated configuration.
+	Galley galley.Instance
+
+	// Prefix of the service names, which are "<prefix>-<index>". Defaults to "scale".
+	Prefix string
+
+	// EchoOptions applied to every service.
+	EchoOptions []EchoOption
+
+	// AuthorizationPolicies generates an AuthorizationPolicy for every service, allowing requests on
+	// its own path from the previous service of the ring (i.e. service i allows service i-1, and the first
+	// allows the last).
+	AuthorizationPolicies bool
+
+	// MTLSMode generates a PeerAuthentication with the given mode for every service, if not empty.
+	MTLSMode MTLSMode
+
+	// BatchSize is the maximum number of resources applied, and waited for, at once. Defaults to 50.
+	BatchSize int
+
+	// MaxConcurrency limits the number of services that are deployed at the same time. Unlimited if zero.
+	MaxConcurrency int
+}
+
+// Scale is a topology of generated services and configuration, deployed by ScaleSetup.
+type Scale struct {
+	Namespace namespace.Instance
+
+	// Services in the order of their index.
+	Services []echo.Instance
+
+	// Config used for applying the generated configuration. It waits for the sidecars of all the Services,
+	// so it can be used for measuring the propagation of additional configuration to them.
+	Config config.Instance
+
+	// Resources are the generated configuration YAML documents, in the order they were applied.
+	Resources []string
+}
+
+// ScaleSetup deploys n echo services and the configuration generated for them according to the given
+// options. The configuration is applied in batches, each of which is waited for until it reaches all of the
+// sidecars. Everything is tracked by the context and cleaned up when the context is done.
+func ScaleSetup(ctx resource.Context, n int, opts ScaleOptions) (*Scale, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("util.ScaleSetup: invalid number of services %d", n)
+	}
+	if opts.Namespace == nil || opts.Galley == nil {
+		return nil, fmt.Errorf("util.ScaleSetup: Namespace and Galley are required")
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = defaultScalePrefix
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultScaleBatchSize
+	}
+
+	builder, err := echoboot.NewBuilder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scale{
+		Namespace: opts.Namespace,
+		Services:  make([]echo.Instance, n),
+	}
+	for i := range s.Services {
+		builder = builder.With(&s.Services[i], EchoConfig(fmt.Sprintf("%s-%d", opts.Prefix, i), opts.Namespace,
+			opts.EchoOptions...))
+	}
+	if opts.MaxConcurrency > 0 {
+		builder = builder.WithMaxConcurrency(opts.MaxConcurrency)
+	}
+	scopes.CI.Infof("=== BEGIN: Deploy %d scale services in %s ===", n, opts.Namespace.Name())
+	if err = builder.Build(); err != nil {
+		scopes.CI.Infof("=== FAILED: Deploy %d scale services in %s ===", n, opts.Namespace.Name())
+		return nil, err
+	}
+	scopes.CI.Infof("=== SUCCEEDED: Deploy %d scale services in %s ===", n, opts.Namespace.Name())
+
+	if s.Config, err = config.New(ctx, config.Config{
+		Galley:  opts.Galley,
+		WaitFor: s.Services,
+	}); err != nil {
+		return nil, err
+	}
+
+	for i, svc := range s.Services {
+		if opts.MTLSMode != "" {
+			s.Resources = append(s.Resources, PeerAuthentication{
+				Target: svc,
+				Mode:   opts.MTLSMode,
+			}.YAML())
+		}
+		if opts.AuthorizationPolicies {
+			from := s.Services[(i+n-1)%n]
+			s.Resources = append(s.Resources, scaleAuthorizationPolicy(svc, from))
+		}
+	}
+
+	for start := 0; start < len(s.Resources); start += opts.BatchSize {
+		end := start + opts.BatchSize
+		if end > len(s.Resources) {
+			end = len(s.Resources)
+		}
+		scopes.Framework.Infof("util.ScaleSetup: applying resources %d-%d of %d", start, end, len(s.Resources))
+		if err := s.Config.Apply(opts.Namespace, s.Resources[start:end]...); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// ScaleSetupOrFail calls ScaleSetup and fails t if an error occurs.
+func ScaleSetupOrFail(t test.Failer, ctx resource.Context, n int, opts ScaleOptions) *Scale {
+	t.Helper()
+	s, err := ScaleSetup(ctx, n, opts)
+	if err != nil {
+		t.Fatalf("util.ScaleSetupOrFail: %v", err)
+	}
+	return s
+}
+
+// Path returns the path allowed by the generated AuthorizationPolicy of the given service.
+func (s *Scale) Path(svc echo.Instance) string {
+	return "/" + svc.Config().Service
+}
+
+// Previous returns the service allowed to call the given one by the generated AuthorizationPolicies.
+func (s *Scale) Previous(svc echo.Instance) echo.Instance {
+	for i, candidate := range s.Services {
+		if candidate == svc {
+			return s.Services[(i+len(s.Services)-1)%len(s.Services)]
+		}
+	}
+	return nil
+}
+
+func scaleAuthorizationPolicy(svc, from echo.Instance) string {
+	cfg := svc.Config()
+	return policy.AllowPrincipalToPath.MustRender(policy.Params{
+		"Name":      cfg.Service,
+		"Namespace": cfg.Namespace.Name(),
+		"App":       cfg.Service,
+		"Principal": strings.TrimPrefix(Principal(from), "spiffe://"),
+		"Path":      "/" + cfg.Service,
+	})
+}
@@ -0,0 +1,194 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sdstiming measures how long new echo proxies take from starting until their first workload
+// certificate is issued through SDS, so that regressions in the certificate issuance latency are caught.
+package sdstiming
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+)
+
+const (
+	// ArtifactName is the name of the file the report is written to by WriteArtifact.
+	ArtifactName = "sds-timing.json"
+
+	proxyContainerName = "istio-proxy"
+)
+
+// Timing of the first certificate of a single workload.
+type Timing struct {
+	Workload  string `json:"workload"`
+	Namespace string `json:"namespace"`
+	// ProxyStarted is the time the proxy container of the workload started.
+	ProxyStarted time.Time `json:"proxyStarted"`
+	// CertIssued is the time the certificate used by the proxy was issued, i.e. its NotBefore.
+	CertIssued time.Time `json:"certIssued"`
+	// Latency from the start of the proxy until the certificate was issued.
+	Latency time.Duration `json:"latency"`
+}
+
+// Report of the timings of a set of workloads.
+type Report struct {
+	Timings []Timing `json:"timings"`
+}
+
+// Measure returns the timings of the workloads of the given instances. The workloads must have just been
+// deployed: once a certificate is rotated, the timing measures the rotation rather than the first issuance.
+// Istio must be deployed with SDS enabled, so that the certificates are issued on the first request of the
+// proxies.
+func Measure(ctx resource.Context, instances ...echo.Instance) (*Report, error) {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return nil, fmt.Errorf("SDS timing is only supported in the %s environment", environment.Kube)
+	}
+
+	report := &Report{}
+	for _, i := range instances {
+		cfg := i.Config()
+		accessor, err := env.Cluster(cfg.Cluster)
+		if err != nil {
+			return nil, err
+		}
+		workloads, err := i.Workloads()
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range workloads {
+			if w.Sidecar() == nil {
+				return nil, fmt.Errorf("workload %s has no sidecar", w.Name())
+			}
+			pod, err := accessor.GetPod(cfg.Namespace.Name(), w.Name())
+			if err != nil {
+				return nil, err
+			}
+			var started time.Time
+			for _, s := range pod.Status.ContainerStatuses {
+				if s.Name == proxyContainerName && s.State.Running != nil {
+					started = s.State.Running.StartedAt.Time
+				}
+			}
+			if started.IsZero() {
+				return nil, fmt.Errorf("proxy of workload %s is not running", w.Name())
+			}
+
+			chain, err := w.Sidecar().Certificates()
+			if err != nil {
+				return nil, fmt.Errorf("failed reading the certificates of %s: %v", w.Name(), err)
+			}
+			if len(chain) == 0 {
+				return nil, fmt.Errorf("workload %s has no certificate", w.Name())
+			}
+			issued := chain[0].NotBefore
+			report.Timings = append(report.Timings, Timing{
+				Workload:     w.Name(),
+				Namespace:    cfg.Namespace.Name(),
+				ProxyStarted: started,
+				CertIssued:   issued,
+				Latency:      issued.Sub(started),
+			})
+		}
+	}
+	sort.Slice(report.Timings, func(i, j int) bool {
+		return report.Timings[i].Latency < report.Timings[j].Latency
+	})
+	scopes.Framework.Infof("SDS timing: %s", report)
+	return report, nil
+}
+
+// MeasureOrFail calls Measure, writes the report as an artifact to the work dir of the test, and fails the
+// test if an error occurs or any workload took longer than max to receive its certificate.
+func MeasureOrFail(ctx framework.TestContext, max time.Duration, instances ...echo.Instance) *Report {
+	ctx.Helper()
+	report, err := Measure(ctx, instances...)
+	if err != nil {
+		ctx.Fatalf("sdstiming.MeasureOrFail: %v", err)
+	}
+	if _, err := report.WriteArtifact(ctx.WorkDir()); err != nil {
+		ctx.Fatalf("sdstiming.MeasureOrFail: %v", err)
+	}
+	if err := report.Check(max); err != nil {
+		ctx.Fatalf("sdstiming.MeasureOrFail: %v", err)
+	}
+	return report
+}
+
+// Max returns the largest latency of the report.
+func (r *Report) Max() time.Duration {
+	var max time.Duration
+	for _, t := range r.Timings {
+		if t.Latency > max {
+			max = t.Latency
+		}
+	}
+	return max
+}
+
+// Mean returns the mean latency of the report.
+func (r *Report) Mean() time.Duration {
+	if len(r.Timings) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, t := range r.Timings {
+		sum += t.Latency
+	}
+	return sum / time.Duration(len(r.Timings))
+}
+
+// Check verifies that no workload took longer than max to receive its certificate.
+func (r *Report) Check(max time.Duration) error {
+	var slow []string
+	for _, t := range r.Timings {
+		if t.Latency > max {
+			slow = append(slow, fmt.Sprintf("%s/%s: %v", t.Namespace, t.Workload, t.Latency))
+		}
+	}
+	if len(slow) > 0 {
+		return fmt.Errorf("certificate issuance took longer than %v: %s", max, strings.Join(slow, ", "))
+	}
+	return nil
+}
+
+// WriteArtifact writes the report as JSON to ArtifactName in the given directory, and returns the path of
+// the file.
+func (r *Report) WriteArtifact(dir string) (string, error) {
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, ArtifactName)
+	if err := ioutil.WriteFile(path, out, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// String implements fmt.Stringer
+func (r *Report) String() string {
+	return fmt.Sprintf("%d workloads, mean %v, max %v", len(r.Timings), r.Mean(), r.Max())
+}
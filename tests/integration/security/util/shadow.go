@@ -0,0 +1,120 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/accesslog"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+const (
+	shadowAllowedStat = ".rbac.shadow_allowed"
+	shadowDeniedStat  = ".rbac.shadow_denied"
+)
+
+// ShadowStats are the results of the RBAC shadow rules counted by the sidecars of a workload. Shadow rules
+// are generated for the ServiceRoleBindings in PERMISSIVE mode, or for all of them if the ClusterRbacConfig
+// enforcement mode is PERMISSIVE. They are evaluated, but don't affect the traffic; their results are only
+// observable through these stats and the shadow fields of the access log (see accesslog.ShadowResult).
+type ShadowStats struct {
+	Allowed int64
+	Denied  int64
+}
+
+// InboundShadowStats returns the results of the shadow rules of the RBAC filters of all inbound listeners,
+// summed over the workloads of the given instance.
+func InboundShadowStats(i echo.Instance) (*ShadowStats, error) {
+	workloads, err := i.Workloads()
+	if err != nil {
+		return nil, err
+	}
+	stats := &ShadowStats{}
+	for _, w := range workloads {
+		if w.Sidecar() == nil {
+			return nil, fmt.Errorf("workload %s has no sidecar", w.Name())
+		}
+		all, err := w.Sidecar().Stats()
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range all {
+			switch {
+			case strings.HasSuffix(name, shadowAllowedStat):
+				stats.Allowed += value
+			case strings.HasSuffix(name, shadowDeniedStat):
+				stats.Denied += value
+			}
+		}
+	}
+	return stats, nil
+}
+
+// Since returns the results counted since the given earlier stats of the same instance.
+func (s *ShadowStats) Since(before *ShadowStats) *ShadowStats {
+	return &ShadowStats{
+		Allowed: s.Allowed - before.Allowed,
+		Denied:  s.Denied - before.Denied,
+	}
+}
+
+func (s *ShadowStats) String() string {
+	return fmt.Sprintf("shadow_allowed=%d shadow_denied=%d", s.Allowed, s.Denied)
+}
+
+// CheckShadowCall makes the call from src, and verifies that all of its requests succeed while the shadow
+// rules of opts.Target evaluated each of them with the expected result, either accesslog.ShadowAllowed or
+// accesslog.ShadowDenied.
+func CheckShadowCall(src echo.Instance, opts echo.CallOptions, expected string) error {
+	if expected != accesslog.ShadowAllowed && expected != accesslog.ShadowDenied {
+		return fmt.Errorf("unknown shadow result %q", expected)
+	}
+	before, err := InboundShadowStats(opts.Target)
+	if err != nil {
+		return err
+	}
+	resp, err := src.Call(opts)
+	if err != nil {
+		return err
+	}
+	if err := resp.CheckOK(); err != nil {
+		return fmt.Errorf("requests must not be affected by shadow rules: %v", err)
+	}
+	after, err := InboundShadowStats(opts.Target)
+	if err != nil {
+		return err
+	}
+
+	delta := after.Since(before)
+	matched, other := delta.Allowed, delta.Denied
+	if expected == accesslog.ShadowDenied {
+		matched, other = delta.Denied, delta.Allowed
+	}
+	if matched < int64(len(resp)) || other > 0 {
+		return fmt.Errorf("expected %d requests shadow %s, counted %s", len(resp), expected, delta)
+	}
+	return nil
+}
+
+// CheckShadowCallOrFail calls CheckShadowCall and fails t if an error occurs.
+func CheckShadowCallOrFail(t test.Failer, src echo.Instance, opts echo.CallOptions, expected string) {
+	t.Helper()
+	if err := CheckShadowCall(src, opts, expected); err != nil {
+		t.Fatal(err)
+	}
+}
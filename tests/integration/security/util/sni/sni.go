@@ -0,0 +1,165 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sni provides helpers for tests of SNI based routing and authorization, which make TLS connections
+// with arbitrary server names and determine where the TLS sessions were terminated.
+package sni
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+)
+
+const dialTimeout = 10 * time.Second
+
+// Termination is where a TLS session was terminated.
+type Termination string
+
+const (
+	// AtGateway means that the gateway terminated TLS, e.g. with the credential of a SIMPLE server.
+	AtGateway Termination = "gateway"
+	// PassedThrough means that the gateway forwarded the TLS session and the backend terminated it, e.g. for
+	// PASSTHROUGH and AUTO_PASSTHROUGH servers.
+	PassedThrough Termination = "passthrough"
+)
+
+// Roots that issue the certificates of the gateway and of the backend. Either may be empty, in which case no
+// certificate is attributed to it.
+type Roots struct {
+	// Gateway is the PEM encoded root certificate of the credential of the gateway.
+	Gateway []byte
+	// Backend is the PEM encoded root certificate of the certificate served by the backend.
+	Backend []byte
+}
+
+// Dial opens a TLS connection to the given address with the given SNI, and returns the certificate chain
+// presented by the server, leaf first. The server certificate is not verified. This is meant for connections
+// from the test process, e.g. to the address of an ingress gateway.
+func Dial(address, serverName string) ([]*x509.Certificate, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", address, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("TLS connection to %s with SNI %q failed: %v", address, serverName, err)
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.ConnectionState().PeerCertificates, nil
+}
+
+// DialOrFail calls Dial and fails t if an error occurs.
+func DialOrFail(t test.Failer, address, serverName string) []*x509.Certificate {
+	t.Helper()
+	certs, err := Dial(address, serverName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certs
+}
+
+// TerminationOf determines where the TLS session that presented the given certificate chain was terminated,
+// from the roots that issued it.
+func TerminationOf(certs []*x509.Certificate, roots Roots) (Termination, error) {
+	if len(certs) == 0 {
+		return "", errors.New("no server certificate was presented")
+	}
+	gateway, err := issuedBy(certs, roots.Gateway)
+	if err != nil {
+		return "", fmt.Errorf("gateway root: %v", err)
+	}
+	backend, err := issuedBy(certs, roots.Backend)
+	if err != nil {
+		return "", fmt.Errorf("backend root: %v", err)
+	}
+	switch {
+	case gateway && backend:
+		return "", fmt.Errorf("certificate %q was issued by both the gateway and the backend roots",
+			certs[0].Subject)
+	case gateway:
+		return AtGateway, nil
+	case backend:
+		return PassedThrough, nil
+	default:
+		return "", fmt.Errorf("certificate %q issued by %q is from neither the gateway nor the backend",
+			certs[0].Subject, certs[0].Issuer)
+	}
+}
+
+// CheckTermination verifies that the TLS sessions of all of the responses were terminated as expected. The
+// responses must be of requests made over TLS, e.g. with echo.CallOptions.SNI set.
+func CheckTermination(resp client.ParsedResponses, roots Roots, expected Termination) error {
+	return resp.Check(func(i int, r *client.ParsedResponse) error {
+		actual, err := TerminationOf(r.ServerCertificates, roots)
+		if err != nil {
+			return fmt.Errorf("response[%d]: %v", i, err)
+		}
+		if actual != expected {
+			return fmt.Errorf("response[%d]: expected TLS termination %s, found %s", i, expected, actual)
+		}
+		return nil
+	})
+}
+
+// CheckTerminationOrFail calls CheckTermination and fails t if an error occurs.
+func CheckTerminationOrFail(t test.Failer, resp client.ParsedResponses, roots Roots, expected Termination) {
+	t.Helper()
+	if err := CheckTermination(resp, roots, expected); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// issuedBy returns whether any certificate of the chain is one of the given roots or is signed by one.
+// Validity periods and names aren't checked, so that sessions can be attributed even to misconfigured
+// servers.
+func issuedBy(certs []*x509.Certificate, rootsPEM []byte) (bool, error) {
+	roots, err := parseCertificates(rootsPEM)
+	if err != nil {
+		return false, err
+	}
+	for _, root := range roots {
+		for _, c := range certs {
+			if c.Equal(root) || c.CheckSignatureFrom(root) == nil {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var out []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return out, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, cert)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sni
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (ca *testCA) issue(t *testing.T, host string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestTerminationOf(t *testing.T) {
+	gateway := newCA(t, "gateway-root")
+	backend := newCA(t, "backend-root")
+	other := newCA(t, "other-root")
+	roots := Roots{Gateway: gateway.pem, Backend: backend.pem}
+
+	cases := []struct {
+		name    string
+		certs   []*x509.Certificate
+		roots   Roots
+		want    Termination
+		wantErr bool
+	}{
+		{name: "gateway", certs: []*x509.Certificate{gateway.issue(t, "gw")}, roots: roots, want: AtGateway},
+		{name: "backend", certs: []*x509.Certificate{backend.issue(t, "be")}, roots: roots, want: PassedThrough},
+		{name: "chain", certs: []*x509.Certificate{other.issue(t, "be"), backend.cert}, roots: roots,
+			want: PassedThrough},
+		{name: "unknown", certs: []*x509.Certificate{other.issue(t, "x")}, roots: roots, wantErr: true},
+		{name: "no certificates", roots: roots, wantErr: true},
+		{name: "same root", certs: []*x509.Certificate{gateway.issue(t, "gw")},
+			roots: Roots{Gateway: gateway.pem, Backend: gateway.pem}, wantErr: true},
+		{name: "backend only", certs: []*x509.Certificate{backend.issue(t, "be")},
+			roots: Roots{Backend: backend.pem}, want: PassedThrough},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := TerminationOf(c.certs, c.roots)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Fatalf("got %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDial(t *testing.T) {
+	received := make(chan string, 1)
+	s := httptest.NewUnstartedServer(http.NotFoundHandler())
+	s.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			received <- hello.ServerName
+			return nil, nil
+		},
+	}
+	s.StartTLS()
+	defer s.Close()
+
+	certs, err := Dial(s.Listener.Addr().String(), "foo.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) == 0 || !certs[0].Equal(s.Certificate()) {
+		t.Fatalf("unexpected certificates: %v", certs)
+	}
+	if sni := <-received; sni != "foo.example.com" {
+		t.Fatalf("server received SNI %q, want foo.example.com", sni)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+const (
+	sslVersionsStat = ".ssl.versions."
+	sslCiphersStat  = ".ssl.ciphers."
+)
+
+// TLSParams configured for the connections of a proxy. Zero values mean that the Envoy defaults apply.
+type TLSParams struct {
+	MinVersion   auth.TlsParameters_TlsProtocol
+	MaxVersion   auth.TlsParameters_TlsProtocol
+	CipherSuites []string
+}
+
+// InboundTLSParams returns the TLS parameters of the inbound listener of the given port of the workload, as
+// configured for the mTLS filter chains. It fails if the listener doesn't accept TLS.
+func InboundTLSParams(w echo.Workload, port echo.Port) (*TLSParams, error) {
+	l, err := inboundListener(w, port)
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range l.GetFilterChains() {
+		if chain.GetTlsContext() == nil {
+			continue
+		}
+		params := chain.GetTlsContext().GetCommonTlsContext().GetTlsParams()
+		return &TLSParams{
+			MinVersion:   params.GetTlsMinimumProtocolVersion(),
+			MaxVersion:   params.GetTlsMaximumProtocolVersion(),
+			CipherSuites: params.GetCipherSuites(),
+		}, nil
+	}
+	return nil, fmt.Errorf("inbound listener %s doesn't accept TLS", l.GetName())
+}
+
+// CheckInboundTLSParams verifies that the inbound listener of the given port of the workload is configured
+// with the expected TLS parameters.
+func CheckInboundTLSParams(w echo.Workload, port echo.Port, expected TLSParams) error {
+	actual, err := InboundTLSParams(w, port)
+	if err != nil {
+		return err
+	}
+	if actual.MinVersion != expected.MinVersion || actual.MaxVersion != expected.MaxVersion ||
+		strings.Join(actual.CipherSuites, ":") != strings.Join(expected.CipherSuites, ":") {
+		return fmt.Errorf("inbound listener %s_%d: expected TLS parameters %+v, found %+v",
+			w.Address(), port.InstancePort, expected, *actual)
+	}
+	return nil
+}
+
+// CheckInboundTLSParamsOrFail calls CheckInboundTLSParams and fails t if an error occurs.
+func CheckInboundTLSParamsOrFail(t test.Failer, w echo.Workload, port echo.Port, expected TLSParams) {
+	t.Helper()
+	if err := CheckInboundTLSParams(w, port, expected); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TLSStats are the number of TLS connections of a proxy by negotiated version (e.g. "TLSv1.2") and cipher
+// suite (e.g. "ECDHE-RSA-AES128-GCM-SHA256"), as counted by Envoy.
+type TLSStats struct {
+	Versions map[string]int64
+	Ciphers  map[string]int64
+}
+
+// InboundTLSStats returns the TLS stats of the connections accepted by the inbound listener of the given port
+// of the workload.
+func InboundTLSStats(w echo.Workload, port echo.Port) (*TLSStats, error) {
+	return tlsStats(w, fmt.Sprintf("listener.%s_%d", w.Address(), port.InstancePort))
+}
+
+// OutboundTLSStats returns the TLS stats of the connections opened by the workload to the given port of the
+// target.
+func OutboundTLSStats(w echo.Workload, target echo.Instance, port echo.Port) (*TLSStats, error) {
+	return tlsStats(w, fmt.Sprintf("cluster.outbound|%d||%s", port.ServicePort, target.Config().FQDN()))
+}
+
+func tlsStats(w echo.Workload, prefix string) (*TLSStats, error) {
+	if w.Sidecar() == nil {
+		return nil, fmt.Errorf("workload %s has no sidecar", w.Name())
+	}
+	all, err := w.Sidecar().Stats()
+	if err != nil {
+		return nil, err
+	}
+	stats := &TLSStats{
+		Versions: make(map[string]int64),
+		Ciphers:  make(map[string]int64),
+	}
+	for name, value := range all {
+		switch {
+		case strings.HasPrefix(name, prefix+sslVersionsStat):
+			stats.Versions[strings.TrimPrefix(name, prefix+sslVersionsStat)] = value
+		case strings.HasPrefix(name, prefix+sslCiphersStat):
+			stats.Ciphers[strings.TrimPrefix(name, prefix+sslCiphersStat)] = value
+		}
+	}
+	return stats, nil
+}
+
+// Since returns the connections counted since the given earlier stats of the same proxy.
+func (s *TLSStats) Since(before *TLSStats) *TLSStats {
+	delta := func(now, then map[string]int64) map[string]int64 {
+		out := make(map[string]int64)
+		for k, v := range now {
+			if d := v - then[k]; d > 0 {
+				out[k] = d
+			}
+		}
+		return out
+	}
+	return &TLSStats{
+		Versions: delta(s.Versions, before.Versions),
+		Ciphers:  delta(s.Ciphers, before.Ciphers),
+	}
+}
+
+// Check verifies that at least one connection was counted, and that all of the connections negotiated one of
+// the given versions and, if any are given, one of the given cipher suites.
+func (s *TLSStats) Check(versions []string, ciphers []string) error {
+	var total int64
+	for _, n := range s.Versions {
+		total += n
+	}
+	if total == 0 {
+		return fmt.Errorf("no TLS connections counted")
+	}
+	if unexpected := unexpectedKeys(s.Versions, versions); len(unexpected) > 0 {
+		return fmt.Errorf("connections negotiated TLS versions %v, expected only %v", unexpected, versions)
+	}
+	if len(ciphers) > 0 {
+		if unexpected := unexpectedKeys(s.Ciphers, ciphers); len(unexpected) > 0 {
+			return fmt.Errorf("connections negotiated cipher suites %v, expected only %v", unexpected, ciphers)
+		}
+	}
+	return nil
+}
+
+// CheckOrFail calls Check and fails t if an error occurs.
+func (s *TLSStats) CheckOrFail(t test.Failer, versions []string, ciphers []string) {
+	t.Helper()
+	if err := s.Check(versions, ciphers); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func unexpectedKeys(counts map[string]int64, allowed []string) []string {
+	var out []string
+	for k, n := range counts {
+		if n == 0 {
+			continue
+		}
+		found := false
+		for _, a := range allowed {
+			if k == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
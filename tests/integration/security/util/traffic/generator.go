@@ -0,0 +1,205 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package traffic sends a steady stream of echo calls in the background, so tests can prove that a
+// configuration change (e.g. an mTLS migration or a CA rotation) didn't cause downtime.
+package traffic
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+const (
+	defaultInterval = 500 * time.Millisecond
+)
+
+// Config for a Generator.
+type Config struct {
+	// Source of the calls.
+	Source echo.Instance
+
+	// Options of each call.
+	Options echo.CallOptions
+
+	// Interval between the start of successive calls. Defaults to 500ms.
+	Interval time.Duration
+}
+
+// Generator of background traffic.
+type Generator interface {
+	// Start sending traffic. Must only be called once.
+	Start() Generator
+
+	// Stop sending traffic and return the results. Blocks until the current call completes.
+	Stop() Result
+}
+
+// Record of a single call.
+type Record struct {
+	Time     time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Result of the traffic sent by a Generator.
+type Result struct {
+	// Name describing the traffic, i.e. source, target and port.
+	Name    string
+	Records []Record
+}
+
+// Failures returns the records of the failed calls.
+func (r Result) Failures() []Record {
+	out := make([]Record, 0)
+	for _, record := range r.Records {
+		if record.Err != nil {
+			out = append(out, record)
+		}
+	}
+	return out
+}
+
+// SuccessRate returns the fraction of successful calls, in [0, 1].
+func (r Result) SuccessRate() float64 {
+	if len(r.Records) == 0 {
+		return 0
+	}
+	return float64(len(r.Records)-len(r.Failures())) / float64(len(r.Records))
+}
+
+// String implements fmt.Stringer
+func (r Result) String() string {
+	failures := r.Failures()
+	sb := &strings.Builder{}
+	_, _ = fmt.Fprintf(sb, "%s: %d/%d calls failed\n", r.Name, len(failures), len(r.Records))
+	for _, f := range failures {
+		_, _ = fmt.Fprintf(sb, "  %s: %v\n", f.Time.Format(time.RFC3339Nano), f.Err)
+	}
+	return sb.String()
+}
+
+// CheckMaxErrors returns an error if more than maxErrors calls failed, or if no calls were made.
+func (r Result) CheckMaxErrors(maxErrors int) error {
+	if len(r.Records) == 0 {
+		return fmt.Errorf("%s: no calls were made", r.Name)
+	}
+	if len(r.Failures()) > maxErrors {
+		return fmt.Errorf("error budget of %d exceeded. %s", maxErrors, r)
+	}
+	return nil
+}
+
+// CheckMaxErrorsOrFail calls CheckMaxErrors and fails t if an error occurs.
+func (r Result) CheckMaxErrorsOrFail(t test.Failer, maxErrors int) {
+	t.Helper()
+	if err := r.CheckMaxErrors(maxErrors); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CheckSuccessRate returns an error if the fraction of successful calls is below the given rate.
+func (r Result) CheckSuccessRate(minRate float64) error {
+	if len(r.Records) == 0 {
+		return fmt.Errorf("%s: no calls were made", r.Name)
+	}
+	if rate := r.SuccessRate(); rate < minRate {
+		return fmt.Errorf("success rate %.3f below %.3f. %s", rate, minRate, r)
+	}
+	return nil
+}
+
+// CheckSuccessRateOrFail calls CheckSuccessRate and fails t if an error occurs.
+func (r Result) CheckSuccessRateOrFail(t test.Failer, minRate float64) {
+	t.Helper()
+	if err := r.CheckSuccessRate(minRate); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var _ Generator = &generator{}
+
+type generator struct {
+	Config
+
+	stop    chan struct{}
+	stopped chan struct{}
+
+	mutex  sync.Mutex
+	result Result
+}
+
+// NewGenerator returns a new Generator with the given configuration.
+func NewGenerator(cfg Config) Generator {
+	if cfg.Interval == 0 {
+		cfg.Interval = defaultInterval
+	}
+	return &generator{
+		Config:  cfg,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		result: Result{
+			Name: fmt.Sprintf("%s->%s:%s",
+				cfg.Source.Config().Service, cfg.Options.Target.Config().Service, cfg.Options.PortName),
+		},
+	}
+}
+
+func (g *generator) Start() Generator {
+	go func() {
+		defer close(g.stopped)
+
+		ticker := time.NewTicker(g.Interval)
+		defer ticker.Stop()
+		for {
+			g.call()
+			select {
+			case <-g.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return g
+}
+
+func (g *generator) call() {
+	start := time.Now()
+	responses, err := g.Source.Call(g.Options)
+	if err == nil {
+		err = responses.CheckOK()
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.result.Records = append(g.result.Records, Record{
+		Time:     start,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+}
+
+func (g *generator) Stop() Result {
+	close(g.stop)
+	<-g.stopped
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.result
+}
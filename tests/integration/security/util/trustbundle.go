@@ -0,0 +1,149 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/echo"
+)
+
+// TrustBundles returns the root certificates that the sidecar of each workload of the given instances uses to
+// validate its peers, by workload name.
+func TrustBundles(instances ...echo.Instance) (map[string][]*x509.Certificate, error) {
+	bundles := make(map[string][]*x509.Certificate)
+	for _, i := range instances {
+		workloads, err := i.Workloads()
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range workloads {
+			if w.Sidecar() == nil {
+				return nil, fmt.Errorf("workload %s has no sidecar", w.Name())
+			}
+			roots, err := w.Sidecar().TrustBundle()
+			if err != nil {
+				return nil, fmt.Errorf("failed reading the trust bundle of %s: %v", w.Name(), err)
+			}
+			bundles[w.Name()] = roots
+		}
+	}
+	return bundles, nil
+}
+
+// CheckTrustBundle verifies that the trust bundles of all workloads of the given instances contain each of
+// the expected roots, e.g. a secondary root added to the cacerts secret, or the root of a federated cluster.
+// Roots are compared by their DER encoding. The bundles are distributed to the sidecars asynchronously, so
+// callers usually retry the check.
+func CheckTrustBundle(instances []echo.Instance, expected ...*x509.Certificate) error {
+	return checkTrustBundles(instances, expected, false)
+}
+
+// CheckTrustBundleOrFail calls CheckTrustBundle and fails t if an error occurs.
+func CheckTrustBundleOrFail(t test.Failer, instances []echo.Instance, expected ...*x509.Certificate) {
+	t.Helper()
+	if err := CheckTrustBundle(instances, expected...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CheckTrustBundleExactly is like CheckTrustBundle, but also verifies that the trust bundles contain no other
+// roots, e.g. to verify that a retired root was removed.
+func CheckTrustBundleExactly(instances []echo.Instance, expected ...*x509.Certificate) error {
+	return checkTrustBundles(instances, expected, true)
+}
+
+// CheckTrustBundleExactlyOrFail calls CheckTrustBundleExactly and fails t if an error occurs.
+func CheckTrustBundleExactlyOrFail(t test.Failer, instances []echo.Instance, expected ...*x509.Certificate) {
+	t.Helper()
+	if err := CheckTrustBundleExactly(instances, expected...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkTrustBundles(instances []echo.Instance, expected []*x509.Certificate, exact bool) error {
+	bundles, err := TrustBundles(instances...)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(bundles))
+	for name := range bundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failures []string
+	for _, name := range names {
+		if err := compareRoots(bundles[name], expected, exact); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("unexpected trust bundles:\n%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// compareRoots returns an error listing the expected roots missing from actual, and with exact the roots of
+// actual that aren't expected.
+func compareRoots(actual, expected []*x509.Certificate, exact bool) error {
+	var missing, extra []string
+	for _, e := range expected {
+		if !containsRoot(actual, e) {
+			missing = append(missing, describeRoot(e))
+		}
+	}
+	if exact {
+		for _, a := range actual {
+			if !containsRoot(expected, a) {
+				extra = append(extra, describeRoot(a))
+			}
+		}
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		problems = append(problems, "unexpected "+strings.Join(extra, ", "))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s (has %d roots)", strings.Join(problems, "; "), len(actual))
+	}
+	return nil
+}
+
+func containsRoot(roots []*x509.Certificate, root *x509.Certificate) bool {
+	for _, r := range roots {
+		if bytes.Equal(r.Raw, root.Raw) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeRoot identifies a root by its subject and the prefix of its SHA-256 fingerprint, since test roots
+// often share the same subject.
+func describeRoot(c *x509.Certificate) string {
+	sum := sha256.Sum256(c.Raw)
+	return fmt.Sprintf("%q [%x]", c.Subject.String(), sum[:8])
+}
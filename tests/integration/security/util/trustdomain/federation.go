@@ -0,0 +1,289 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trustdomain
+
+import (
+	"fmt"
+	"io"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/test/util/tmpl"
+	"istio.io/istio/security/pkg/k8s/controller"
+	"istio.io/istio/tests/integration/security/util/cert"
+)
+
+const (
+	citadelContainer = "citadel"
+
+	// citadelTemplate deploys a Citadel that only manages the namespaces labeled for it, with the plugged-in
+	// CA of the cacerts secret of its namespace. It reuses the ClusterRole of the default Citadel.
+	citadelTemplate = `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: istio-citadel-service-account
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: istio-citadel-{{.Namespace}}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: istio-citadel-{{.IstioNamespace}}
+subjects:
+- kind: ServiceAccount
+  name: istio-citadel-service-account
+  namespace: {{.Namespace}}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: istio-citadel
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      istio: citadel
+  template:
+    metadata:
+      labels:
+        istio: citadel
+      annotations:
+        sidecar.istio.io/inject: "false"
+    spec:
+      serviceAccountName: istio-citadel-service-account
+      containers:
+      - name: citadel
+        image: "{{.Image}}"
+        imagePullPolicy: {{.ImagePullPolicy}}
+        args:
+        - --append-dns-names=true
+        - --grpc-port=8060
+        - --citadel-storage-namespace={{.Namespace}}
+        - --self-signed-ca=false
+        - --signing-cert=/etc/cacerts/ca-cert.pem
+        - --signing-key=/etc/cacerts/ca-key.pem
+        - --root-cert=/etc/cacerts/root-cert.pem
+        - --cert-chain=/etc/cacerts/cert-chain.pem
+        - --trust-domain={{.TrustDomain}}
+        env:
+        - name: CITADEL_ENABLE_NAMESPACES_BY_DEFAULT
+          value: "false"
+        volumeMounts:
+        - name: cacerts
+          mountPath: /etc/cacerts
+          readOnly: true
+      volumes:
+      - name: cacerts
+        secret:
+          secretName: cacerts
+`
+)
+
+// DomainConfig of an additional trust domain within the mesh.
+type DomainConfig struct {
+	// IstioNamespace the default Citadel is deployed to. Its image and ClusterRole are reused. Defaults to the
+	// IstioNamespace of the Istio deployment of the suite.
+	IstioNamespace string
+
+	// TrustDomain of the workloads of the domain.
+	TrustDomain string
+
+	// CA that signs the workload certificates of the domain. To federate the domain with the rest of the mesh,
+	// use an intermediate of the root plugged into the default Citadel. If nil, a new self-signed root is
+	// used, so that the workloads of the domain and of the rest of the mesh don't trust each other.
+	CA *cert.Bundle
+}
+
+// Domain is an additional trust domain within the mesh. The workload certificates of the namespaces created
+// with NewNamespace are issued in the trust domain by a dedicated Citadel, while the rest of the mesh keeps
+// the trust domain of the default Citadel. Workloads of all domains share the control plane.
+type Domain struct {
+	id        resource.ID
+	ctx       resource.Context
+	env       *kube.Environment
+	cfg       DomainConfig
+	namespace namespace.Instance
+	yaml      string
+}
+
+var _ io.Closer = &Domain{}
+
+// NewDomain deploys the Citadel of a new trust domain. Only supported in the Kubernetes environment.
+func NewDomain(ctx resource.Context, cfg DomainConfig) (d *Domain, err error) {
+	if ctx.Environment().EnvironmentName() != environment.Kube {
+		return nil, fmt.Errorf("trustdomain: unsupported environment %s", ctx.Environment().EnvironmentName())
+	}
+	if cfg.TrustDomain == "" {
+		return nil, fmt.Errorf("trustdomain: no trust domain given")
+	}
+	if cfg.IstioNamespace == "" {
+		istioCfg, err := istio.DefaultConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cfg.IstioNamespace = istioCfg.IstioNamespace
+	}
+	if cfg.CA == nil {
+		if cfg.CA, err = cert.NewRoot(cert.Options{CommonName: cfg.TrustDomain}); err != nil {
+			return nil, err
+		}
+	}
+	d = &Domain{
+		ctx: ctx,
+		env: ctx.Environment().(*kube.Environment),
+		cfg: cfg,
+	}
+	d.id = ctx.TrackResource(d)
+
+	scopes.Framework.Infof("Deploying a Citadel for trust domain %q", cfg.TrustDomain)
+	if d.namespace, err = namespace.New(ctx, namespace.Config{Prefix: "istio-td"}); err != nil {
+		return nil, err
+	}
+
+	def, err := d.env.GetDeployment(cfg.IstioNamespace, citadelDeployment)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading the default Citadel: %v", err)
+	}
+	var image, pullPolicy string
+	for _, c := range def.Spec.Template.Spec.Containers {
+		if c.Name == citadelContainer {
+			image, pullPolicy = c.Image, string(c.ImagePullPolicy)
+		}
+	}
+	if image == "" {
+		return nil, fmt.Errorf("deployment %s/%s has no %s container",
+			cfg.IstioNamespace, citadelDeployment, citadelContainer)
+	}
+
+	if err = d.env.CreateSecret(d.namespace.Name(), cfg.CA.CACertsSecret(d.namespace.Name())); err != nil {
+		return nil, err
+	}
+	if d.yaml, err = tmpl.Evaluate(citadelTemplate, map[string]string{
+		"Namespace":       d.namespace.Name(),
+		"IstioNamespace":  cfg.IstioNamespace,
+		"TrustDomain":     cfg.TrustDomain,
+		"Image":           image,
+		"ImagePullPolicy": pullPolicy,
+	}); err != nil {
+		return nil, err
+	}
+	if err = d.env.ApplyContents(d.namespace.Name(), d.yaml); err != nil {
+		return nil, err
+	}
+	if err = d.env.WaitUntilDeploymentIsRolledOut(d.namespace.Name(), citadelDeployment); err != nil {
+		return nil, fmt.Errorf("citadel of trust domain %q was not rolled out: %v", cfg.TrustDomain, err)
+	}
+	return d, nil
+}
+
+// NewDomainOrFail calls NewDomain and fails t if an error occurs.
+func NewDomainOrFail(t test.Failer, ctx resource.Context, cfg DomainConfig) *Domain {
+	t.Helper()
+	d, err := NewDomain(ctx, cfg)
+	if err != nil {
+		t.Fatalf("trustdomain.NewDomainOrFail: %v", err)
+	}
+	return d
+}
+
+// ID implements resource.Resource.
+func (d *Domain) ID() resource.ID {
+	return d.id
+}
+
+// TrustDomain of the workloads of the domain.
+func (d *Domain) TrustDomain() string {
+	return d.cfg.TrustDomain
+}
+
+// CA that signs the workload certificates of the domain.
+func (d *Domain) CA() *cert.Bundle {
+	return d.cfg.CA
+}
+
+// NewNamespace creates a namespace with sidecar injection enabled, whose workload certificates are issued in
+// the trust domain.
+func (d *Domain) NewNamespace(prefix string) (namespace.Instance, error) {
+	return namespace.New(d.ctx, namespace.Config{
+		Prefix: prefix,
+		Inject: true,
+		Labels: map[string]string{
+			controller.NamespaceManagedLabel: d.namespace.Name(),
+		},
+	})
+}
+
+// NewNamespaceOrFail calls NewNamespace and fails t if an error occurs.
+func (d *Domain) NewNamespaceOrFail(t test.Failer, prefix string) namespace.Instance {
+	t.Helper()
+	ns, err := d.NewNamespace(prefix)
+	if err != nil {
+		t.Fatalf("trustdomain.NewNamespaceOrFail: %v", err)
+	}
+	return ns
+}
+
+// Close removes the cluster scoped resources of the domain. The namespace of the Citadel is deleted with the
+// other namespaces of the context.
+func (d *Domain) Close() error {
+	if d.yaml == "" {
+		return nil
+	}
+	err := d.env.DeleteContents(d.namespace.Name(), d.yaml)
+	d.yaml = ""
+	return err
+}
+
+// CheckRejected calls the target from the given source until the call fails, which is expected when the
+// source and the target are in trust domains that don't trust each other.
+func CheckRejected(from echo.Instance, opts echo.CallOptions) error {
+	return retry.UntilSuccess(func() error {
+		resp, err := from.Call(opts)
+		if err != nil {
+			return nil
+		}
+		if resp.CheckOK() != nil {
+			return nil
+		}
+		return fmt.Errorf("call was accepted: %s", principals(resp))
+	})
+}
+
+// CheckRejectedOrFail calls CheckRejected and fails t if an error occurs.
+func CheckRejectedOrFail(t test.Failer, from echo.Instance, opts echo.CallOptions) {
+	t.Helper()
+	if err := CheckRejected(from, opts); err != nil {
+		t.Fatalf("%s to %s:%s: %v", from.Config().Service, opts.Target.Config().Service, opts.PortName, err)
+	}
+}
+
+func principals(resp client.ParsedResponses) string {
+	if len(resp) == 0 {
+		return "no responses"
+	}
+	return fmt.Sprintf("source principal %q, destination principal %q",
+		resp[0].SourcePrincipal, resp[0].DestinationPrincipal)
+}
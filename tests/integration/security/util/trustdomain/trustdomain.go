@@ -0,0 +1,243 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trustdomain reconfigures the trust domain of a running Istio deployment, and verifies which trust
+// domains are accepted while workloads migrate from one to the other. It also adds trust domains to a mesh
+// with NewDomain, each with a dedicated Citadel, for testing calls between workloads of different domains.
+//
+// This release doesn't support trustDomainAliases in MeshConfig. Instead, peers from other trust domains are
+// accepted during a migration by disabling the trust domain validation of the server sidecars
+// (PILOT_SKIP_VALIDATE_TRUST_DOMAIN).
+package trustdomain
+
+import (
+	"fmt"
+	"strings"
+
+	kubeApiApps "k8s.io/api/apps/v1"
+	kubeApiCore "k8s.io/api/core/v1"
+
+	"istio.io/istio/pkg/test/echo/client"
+	"istio.io/istio/pkg/test/framework"
+	"istio.io/istio/pkg/test/framework/components/echo"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util"
+)
+
+const (
+	pilotDeployment   = "istio-pilot"
+	citadelDeployment = "istio-citadel"
+
+	trustDomainFlag = "--trust-domain="
+	skipValidateEnv = "PILOT_SKIP_VALIDATE_TRUST_DOMAIN"
+)
+
+// containers of each deployment that take the trust domain flag.
+var trustDomainContainers = map[string][]string{
+	pilotDeployment:   {"discovery", "istio-proxy"},
+	citadelDeployment: {"citadel"},
+}
+
+// Config of a trust domain change.
+type Config struct {
+	// IstioNamespace Pilot and Citadel are deployed to.
+	IstioNamespace string
+
+	// TrustDomain to switch to.
+	TrustDomain string
+
+	// AcceptAnyTrustDomain disables the trust domain validation of the server sidecars, so that workloads that
+	// still have certificates of the previous trust domain are accepted during the migration.
+	AcceptAnyTrustDomain bool
+
+	// WorkloadNamespaces whose workload certificates are re-issued in the new trust domain.
+	WorkloadNamespaces []namespace.Instance
+}
+
+// Change of the trust domain, which can be reverted.
+type Change struct {
+	env *kube.Environment
+	cfg Config
+
+	// original pod templates of the changed deployments, by name.
+	original map[string]kubeApiCore.PodTemplateSpec
+}
+
+// Set the trust domain of Pilot and Citadel as configured, waits until both are rolled out, and re-issues the
+// workload certificates. Only supported in the Kubernetes environment.
+func Set(ctx resource.Context, cfg Config) (*Change, error) {
+	if ctx.Environment().EnvironmentName() != environment.Kube {
+		return nil, fmt.Errorf("trustdomain: unsupported environment %s", ctx.Environment().EnvironmentName())
+	}
+	c := &Change{
+		env:      ctx.Environment().(*kube.Environment),
+		cfg:      cfg,
+		original: make(map[string]kubeApiCore.PodTemplateSpec),
+	}
+
+	scopes.Framework.Infof("Setting trust domain to %q (accept any: %v)", cfg.TrustDomain, cfg.AcceptAnyTrustDomain)
+	for _, name := range []string{pilotDeployment, citadelDeployment} {
+		if err := c.update(name, func(d *kubeApiApps.Deployment) {
+			c.original[name] = *d.Spec.Template.DeepCopy()
+			setTrustDomain(d, trustDomainContainers[name], cfg.TrustDomain)
+			if name == pilotDeployment {
+				setEnv(d, "discovery", skipValidateEnv, fmt.Sprintf("%v", cfg.AcceptAnyTrustDomain))
+			}
+		}); err != nil {
+			return c, err
+		}
+	}
+	return c, util.ReissueWorkloadCerts(c.env, cfg.WorkloadNamespaces...)
+}
+
+// SetOrFail calls Set and fails the test if an error occurs. The change is reverted when the test context is done.
+func SetOrFail(ctx framework.TestContext, cfg Config) *Change {
+	ctx.Helper()
+	c, err := Set(ctx, cfg)
+	if c != nil {
+		ctx.WhenDone(c.Revert)
+	}
+	if err != nil {
+		ctx.Fatalf("trustdomain.SetOrFail: %v", err)
+	}
+	return c
+}
+
+// Revert Pilot and Citadel to their original configuration, and re-issue the workload certificates.
+func (c *Change) Revert() error {
+	if len(c.original) == 0 {
+		return nil
+	}
+	scopes.Framework.Infof("Reverting trust domain change to %q", c.cfg.TrustDomain)
+	for name, template := range c.original {
+		template := template
+		if err := c.update(name, func(d *kubeApiApps.Deployment) {
+			d.Spec.Template = template
+		}); err != nil {
+			return err
+		}
+		delete(c.original, name)
+	}
+	return util.ReissueWorkloadCerts(c.env, c.cfg.WorkloadNamespaces...)
+}
+
+func (c *Change) update(name string, mutate func(d *kubeApiApps.Deployment)) error {
+	ns := c.cfg.IstioNamespace
+	if err := retry.UntilSuccess(func() error {
+		d, err := c.env.GetDeployment(ns, name)
+		if err != nil {
+			return err
+		}
+		mutate(d)
+		_, err = c.env.UpdateDeployment(d)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed updating deployment %s/%s: %v", ns, name, err)
+	}
+	if err := c.env.WaitUntilDeploymentIsRolledOut(ns, name); err != nil {
+		return fmt.Errorf("deployment %s/%s was not rolled out: %v", ns, name, err)
+	}
+	return nil
+}
+
+func setTrustDomain(d *kubeApiApps.Deployment, containers []string, trustDomain string) {
+	for i := range d.Spec.Template.Spec.Containers {
+		container := &d.Spec.Template.Spec.Containers[i]
+		if !contains(containers, container.Name) || len(container.Args) == 0 {
+			continue
+		}
+		args := make([]string, 0, len(container.Args)+1)
+		for _, arg := range container.Args {
+			if !strings.HasPrefix(arg, trustDomainFlag) {
+				args = append(args, arg)
+			}
+		}
+		container.Args = append(args, trustDomainFlag+trustDomain)
+	}
+}
+
+func setEnv(d *kubeApiApps.Deployment, containerName, name, value string) {
+	for i := range d.Spec.Template.Spec.Containers {
+		container := &d.Spec.Template.Spec.Containers[i]
+		if container.Name != containerName {
+			continue
+		}
+		env := make([]kubeApiCore.EnvVar, 0, len(container.Env)+1)
+		for _, e := range container.Env {
+			if e.Name != name {
+				env = append(env, e)
+			}
+		}
+		container.Env = append(env, kubeApiCore.EnvVar{Name: name, Value: value})
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TrustDomainOf returns the trust domain of the given SPIFFE identity, or an empty string if it isn't one.
+func TrustDomainOf(principal string) string {
+	if !strings.HasPrefix(principal, "spiffe://") {
+		return ""
+	}
+	return strings.SplitN(strings.TrimPrefix(principal, "spiffe://"), "/", 2)[0]
+}
+
+// CheckSourceTrustDomain verifies that all of the responses were received over mutual TLS, from a source in one
+// of the given trust domains.
+func CheckSourceTrustDomain(resp client.ParsedResponses, trustDomains ...string) error {
+	return resp.Check(func(i int, r *client.ParsedResponse) error {
+		td := TrustDomainOf(r.SourcePrincipal)
+		if !contains(trustDomains, td) {
+			return fmt.Errorf("response[%d] source principal %q: expected trust domain in %v",
+				i, r.SourcePrincipal, trustDomains)
+		}
+		return nil
+	})
+}
+
+// CheckAccepted calls the target from the given source until the call succeeds, and verifies that the source
+// identity presented was in one of the given trust domains. This is used for asserting that workloads with
+// certificates of both the old and the new trust domain are accepted during a migration.
+func CheckAccepted(from echo.Instance, opts echo.CallOptions, trustDomains ...string) error {
+	return retry.UntilSuccess(func() error {
+		resp, err := from.Call(opts)
+		if err != nil {
+			return err
+		}
+		if err := resp.CheckOK(); err != nil {
+			return err
+		}
+		return CheckSourceTrustDomain(resp, trustDomains...)
+	})
+}
+
+// CheckAcceptedOrFail calls CheckAccepted and fails the test if an error occurs.
+func CheckAcceptedOrFail(ctx framework.TestContext, from echo.Instance, opts echo.CallOptions, trustDomains ...string) {
+	ctx.Helper()
+	if err := CheckAccepted(from, opts, trustDomains...); err != nil {
+		ctx.Fatalf("%s to %s:%s: %v", from.Config().Service, opts.Target.Config().Service, opts.PortName, err)
+	}
+}
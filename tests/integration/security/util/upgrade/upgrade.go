@@ -0,0 +1,225 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package upgrade upgrades the control plane in place, from the previous version deployed by
+// istio.SetupPrevious to the version under test, while traffic flows between echo workloads. Checks of the
+// authentication and authorization policies are run before, during and after the upgrade, to verify that
+// they are enforced continuously.
+package upgrade
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/tests/integration/security/util/traffic"
+)
+
+const defaultCheckInterval = 5 * time.Second
+
+// Check of a behavior that must hold throughout the upgrade.
+type Check struct {
+	// Name of the check in the report.
+	Name string
+
+	// Run the check once, e.g. a connection.Checker that is expected to be allowed or denied by a policy.
+	Run func() error
+}
+
+// Config of an upgrade.
+type Config struct {
+	// Istio deployment upgraded, which must have been deployed by istio.SetupPrevious.
+	Istio istio.Instance
+
+	// Override of the Istio configuration, the same as the one passed to istio.SetupPrevious.
+	Override istio.SetupConfigFn
+
+	// Traffic that is sent continuously during the upgrade.
+	Traffic []traffic.Config
+
+	// Checks run before, during and after the upgrade. They must succeed before the upgrade starts.
+	Checks []Check
+
+	// CheckInterval between successive runs of the checks during the upgrade. Defaults to 5s.
+	CheckInterval time.Duration
+}
+
+// Report of an upgrade.
+type Report struct {
+	// Time the upgrade started.
+	Time time.Time
+	// Duration until the upgraded components were ready.
+	Duration time.Duration
+	// Err from upgrading the control plane.
+	Err error
+	// Traffic sent during the upgrade.
+	Traffic []traffic.Result
+	// Checks run during the upgrade, each run recorded as a call.
+	Checks []traffic.Result
+	// After has the error of each check that failed after the upgrade, by name.
+	After map[string]error
+}
+
+// Check verifies that the upgrade succeeded, that at most maxErrors calls of each traffic generator and runs of
+// each check failed during it, and that all of the checks succeed after it.
+func (r *Report) Check(maxErrors int) error {
+	if r.Err != nil {
+		return fmt.Errorf("upgrade failed: %v", r.Err)
+	}
+	for _, t := range r.Traffic {
+		if err := t.CheckMaxErrors(maxErrors); err != nil {
+			return err
+		}
+	}
+	for _, c := range r.Checks {
+		if err := c.CheckMaxErrors(maxErrors); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(r.After))
+	for name := range r.After {
+		names = append(names, name)
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return fmt.Errorf("check %s failed after the upgrade: %v", names[0], r.After[names[0]])
+	}
+	return nil
+}
+
+// String implements fmt.Stringer
+func (r *Report) String() string {
+	sb := &strings.Builder{}
+	_, _ = fmt.Fprintf(sb, "%s upgrade: ready in %v", r.Time.Format(time.RFC3339Nano), r.Duration)
+	if r.Err != nil {
+		_, _ = fmt.Fprintf(sb, ", failed: %v", r.Err)
+	}
+	sb.WriteString("\n")
+	for _, t := range r.Traffic {
+		_, _ = fmt.Fprintf(sb, "%s\n", t)
+	}
+	for _, c := range r.Checks {
+		_, _ = fmt.Fprintf(sb, "%s\n", c)
+	}
+	for name, err := range r.After {
+		_, _ = fmt.Fprintf(sb, "%s after the upgrade: %v\n", name, err)
+	}
+	return sb.String()
+}
+
+// Run the upgrade and returns the report. Fails without upgrading if any of the checks doesn't succeed
+// beforehand. The sidecars of the workloads are not restarted, so they remain on the previous version, which
+// the version under test of the control plane is required to support.
+func Run(ctx resource.Context, cfg Config) (*Report, error) {
+	if cfg.Istio == nil {
+		return nil, fmt.Errorf("upgrade: Istio is required")
+	}
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = defaultCheckInterval
+	}
+	for _, c := range cfg.Checks {
+		if err := retry.UntilSuccess(c.Run); err != nil {
+			return nil, fmt.Errorf("upgrade: check %s failed before the upgrade: %v", c.Name, err)
+		}
+	}
+
+	generators := make([]traffic.Generator, 0, len(cfg.Traffic))
+	for _, t := range cfg.Traffic {
+		generators = append(generators, traffic.NewGenerator(t).Start())
+	}
+	checks := newChecker(cfg.Checks, cfg.CheckInterval)
+
+	scopes.Framework.Infof("upgrade: upgrading Istio")
+	report := &Report{Time: time.Now()}
+	report.Err = istio.Upgrade(ctx, cfg.Istio, cfg.Override)
+	report.Duration = time.Since(report.Time)
+
+	report.Checks = checks.stop()
+	for _, g := range generators {
+		report.Traffic = append(report.Traffic, g.Stop())
+	}
+
+	if report.Err == nil {
+		report.After = make(map[string]error)
+		for _, c := range cfg.Checks {
+			if err := retry.UntilSuccess(c.Run); err != nil {
+				report.After[c.Name] = err
+			}
+		}
+	}
+	scopes.Framework.Infof("upgrade: complete:\n%s", report)
+	return report, nil
+}
+
+// RunOrFail calls Run and fails t if an error occurs, or if the report doesn't pass Check with the given
+// maxErrors.
+func RunOrFail(t test.Failer, ctx resource.Context, cfg Config, maxErrors int) *Report {
+	t.Helper()
+	report, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatalf("upgrade.RunOrFail: %v", err)
+	}
+	if err := report.Check(maxErrors); err != nil {
+		t.Fatalf("upgrade.RunOrFail: %v\n%s", err, report)
+	}
+	return report
+}
+
+// checker runs the checks in the background, until stopped.
+type checker struct {
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	results []traffic.Result
+}
+
+func newChecker(checks []Check, interval time.Duration) *checker {
+	c := &checker{
+		stopCh:  make(chan struct{}),
+		results: make([]traffic.Result, len(checks)),
+	}
+	for i, check := range checks {
+		c.results[i].Name = check.Name
+		c.wg.Add(1)
+		go func(result *traffic.Result, run func() error) {
+			defer c.wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				start := time.Now()
+				err := run()
+				result.Records = append(result.Records, traffic.Record{Time: start, Duration: time.Since(start), Err: err})
+				select {
+				case <-c.stopCh:
+					return
+				case <-ticker.C:
+				}
+			}
+		}(&c.results[i], check.Run)
+	}
+	return c
+}
+
+// stop the checks and return their results, once the current runs completed.
+func (c *checker) stop() []traffic.Result {
+	close(c.stopCh)
+	c.wg.Wait()
+	return c.results
+}
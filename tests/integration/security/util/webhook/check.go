@@ -0,0 +1,191 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	kubeApiAdmissions "k8s.io/api/admissionregistration/v1beta1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+const (
+	// sidecarContainer is the name of the container added by the injector.
+	sidecarContainer = "istio-proxy"
+
+	// rejected is contained in the errors of the API server for requests rejected because a webhook failed.
+	rejected = "failed calling"
+
+	probePod = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: %s
+  labels:
+    app: webhook-probe
+spec:
+  containers:
+  - name: probe
+    image: k8s.gcr.io/pause:3.1
+`
+
+	probeConfig = `
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: %s
+spec:
+  host: webhook-probe.%s.svc.cluster.local
+`
+
+	defaultTimeout = 2 * time.Minute
+)
+
+var probes int64
+
+// Check verifies that the cluster behaves as the failure policy of the broken webhook requires, for pods or
+// configuration created in ns.
+func Check(ctx resource.Context, w Webhook, ns namespace.Instance) error {
+	policy, err := FailurePolicy(ctx, w)
+	if err != nil {
+		return err
+	}
+	if w.Mutating {
+		return CheckInjection(ctx, ns, policy)
+	}
+	return CheckValidation(ctx, ns, policy)
+}
+
+// CheckOrFail calls Check and fails t if an error occurs.
+func CheckOrFail(t test.Failer, ctx resource.Context, w Webhook, ns namespace.Instance) {
+	t.Helper()
+	if err := Check(ctx, w, ns); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CheckInjection verifies that, while the injector is broken, pods created in ns are rejected with the Fail
+// policy, and created without a sidecar with the Ignore policy. Injection must be enabled for ns. Since the
+// API server notices the breakage with a delay, pods are created until the expected behavior is observed, and
+// deleted again.
+func CheckInjection(ctx resource.Context, ns namespace.Instance, policy kubeApiAdmissions.FailurePolicyType) error {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return fmt.Errorf("webhook: only supported in the %s environment", environment.Kube)
+	}
+	return retry.UntilSuccess(func() error {
+		name := probeName()
+		applyErr := env.ApplyContents(ns.Name(), fmt.Sprintf(probePod, name))
+		if applyErr == nil {
+			defer func() { _ = env.DeletePod(ns.Name(), name) }()
+		}
+
+		switch policy {
+		case kubeApiAdmissions.Fail:
+			if applyErr == nil {
+				return fmt.Errorf("pod %s/%s was created, expected it to be rejected", ns.Name(), name)
+			}
+			return checkRejected(applyErr)
+		case kubeApiAdmissions.Ignore:
+			if applyErr != nil {
+				return fmt.Errorf("pod %s/%s was not created: %v", ns.Name(), name, applyErr)
+			}
+			pod, err := env.GetPod(ns.Name(), name)
+			if err != nil {
+				return err
+			}
+			for _, c := range pod.Spec.Containers {
+				if c.Name == sidecarContainer {
+					return fmt.Errorf("pod %s/%s was injected, expected it to have no sidecar", ns.Name(), name)
+				}
+			}
+			return nil
+		default:
+			return fmt.Errorf("webhook: unknown failure policy %q", policy)
+		}
+	}, retry.Timeout(defaultTimeout))
+}
+
+// CheckInjectionOrFail calls CheckInjection and fails t if an error occurs.
+func CheckInjectionOrFail(t test.Failer, ctx resource.Context, ns namespace.Instance,
+	policy kubeApiAdmissions.FailurePolicyType) {
+	t.Helper()
+	if err := CheckInjection(ctx, ns, policy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// CheckValidation verifies that, while the validation webhook is broken, valid configuration applied to ns is
+// rejected with the Fail policy, and accepted with the Ignore policy. Since the API server notices the
+// breakage with a delay, configuration is applied until the expected behavior is observed, and deleted again.
+func CheckValidation(ctx resource.Context, ns namespace.Instance, policy kubeApiAdmissions.FailurePolicyType) error {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return fmt.Errorf("webhook: only supported in the %s environment", environment.Kube)
+	}
+	return retry.UntilSuccess(func() error {
+		cfg := fmt.Sprintf(probeConfig, probeName(), ns.Name())
+		applyErr := env.ApplyContents(ns.Name(), cfg)
+		if applyErr == nil {
+			defer func() { _ = env.DeleteContents(ns.Name(), cfg) }()
+		}
+
+		switch policy {
+		case kubeApiAdmissions.Fail:
+			if applyErr == nil {
+				return fmt.Errorf("configuration was accepted in %s, expected it to be rejected", ns.Name())
+			}
+			return checkRejected(applyErr)
+		case kubeApiAdmissions.Ignore:
+			if applyErr != nil {
+				return fmt.Errorf("configuration was rejected in %s: %v", ns.Name(), applyErr)
+			}
+			return nil
+		default:
+			return fmt.Errorf("webhook: unknown failure policy %q", policy)
+		}
+	}, retry.Timeout(defaultTimeout))
+}
+
+// CheckValidationOrFail calls CheckValidation and fails t if an error occurs.
+func CheckValidationOrFail(t test.Failer, ctx resource.Context, ns namespace.Instance,
+	policy kubeApiAdmissions.FailurePolicyType) {
+	t.Helper()
+	if err := CheckValidation(ctx, ns, policy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkRejected verifies that the request was rejected because the webhook failed, rather than for any other
+// reason.
+func checkRejected(err error) error {
+	if !strings.Contains(err.Error(), rejected) {
+		return fmt.Errorf("rejected, but not because the webhook failed: %v", err)
+	}
+	return nil
+}
+
+func probeName() string {
+	return fmt.Sprintf("webhook-probe-%d", atomic.AddInt64(&probes, 1))
+}
@@ -0,0 +1,305 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook temporarily breaks the sidecar injection and configuration validation webhooks, by scaling
+// down the deployments serving them or by corrupting the CA bundles of their configurations, and verifies
+// that the cluster then behaves as their failure policies require: with Fail, pods and configuration are
+// rejected; with Ignore, pods are created without sidecars and configuration is accepted without validation.
+package webhook
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	kubeApiAdmissions "k8s.io/api/admissionregistration/v1beta1"
+
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/framework/components/environment"
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/resource"
+	"istio.io/istio/pkg/test/scopes"
+	"istio.io/istio/pkg/test/util/retry"
+)
+
+// Webhook of the control plane.
+type Webhook struct {
+	// Configuration is the name of the webhook configuration.
+	Configuration string
+
+	// Deployment serving the webhook, in the Istio namespace.
+	Deployment string
+
+	// Mutating is set if the configuration is a MutatingWebhookConfiguration, and unset if it's a
+	// ValidatingWebhookConfiguration.
+	Mutating bool
+}
+
+var (
+	// Injector is the sidecar injection webhook.
+	Injector = Webhook{
+		Configuration: "istio-sidecar-injector",
+		Deployment:    "istio-sidecar-injector",
+		Mutating:      true,
+	}
+
+	// Validation is the configuration validation webhook, served by Galley.
+	Validation = Webhook{
+		Configuration: "istio-galley",
+		Deployment:    "istio-galley",
+	}
+)
+
+// String implements fmt.Stringer
+func (w Webhook) String() string {
+	return w.Configuration
+}
+
+// Fault breaking a webhook.
+type Fault string
+
+const (
+	// Unavailable scales the deployment serving the webhook down to 0 replicas, so that the API server can't
+	// reach it.
+	Unavailable Fault = "unavailable"
+
+	// BadCABundle replaces the CA bundle of the webhook configuration with an unrelated CA, so that the API
+	// server can't verify the certificate of the webhook. Galley reconciles its webhook configuration and
+	// would revert the change, so this is only supported for the Injector.
+	BadCABundle Fault = "bad CA bundle"
+)
+
+// Breakage of a webhook, which lasts until it's restored.
+type Breakage struct {
+	env     *kube.Environment
+	ns      string
+	webhook Webhook
+	fault   Fault
+
+	// replicas of the deployment before it was scaled down.
+	replicas int
+	// caBundles of the webhooks of the configuration before they were replaced, by webhook name.
+	caBundles map[string][]byte
+
+	once sync.Once
+	err  error
+}
+
+// Break the webhook of the control plane deployed to istioNamespace with the given fault. It stays broken
+// until it's restored. Only supported in the Kubernetes environment.
+func Break(ctx resource.Context, istioNamespace string, w Webhook, f Fault) (*Breakage, error) {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return nil, fmt.Errorf("webhook: only supported in the %s environment", environment.Kube)
+	}
+	b := &Breakage{
+		env:     env,
+		ns:      istioNamespace,
+		webhook: w,
+		fault:   f,
+	}
+
+	scopes.Framework.Infof("webhook: breaking %s: %s", w, f)
+	switch f {
+	case Unavailable:
+		d, err := env.GetDeployment(istioNamespace, w.Deployment)
+		if err != nil {
+			return nil, err
+		}
+		b.replicas = 1
+		if d.Spec.Replicas != nil {
+			b.replicas = int(*d.Spec.Replicas)
+		}
+		if err := scale(env, istioNamespace, w.Deployment, 0); err != nil {
+			return nil, err
+		}
+	case BadCABundle:
+		if !w.Mutating {
+			return nil, fmt.Errorf("webhook: %s is not supported for %s, which is reconciled by %s",
+				f, w, w.Deployment)
+		}
+		bundle, err := unrelatedCA()
+		if err != nil {
+			return nil, err
+		}
+		b.caBundles, err = setCABundles(env, w.Configuration, func(string) []byte { return bundle })
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("webhook: unknown fault %q", f)
+	}
+	return b, nil
+}
+
+// BreakOrFail calls Break and fails t if an error occurs.
+func BreakOrFail(t test.Failer, ctx resource.Context, istioNamespace string, w Webhook, f Fault) *Breakage {
+	t.Helper()
+	b, err := Break(ctx, istioNamespace, w, f)
+	if err != nil {
+		t.Fatalf("webhook.BreakOrFail: %v", err)
+	}
+	return b
+}
+
+// Restore the webhook, and wait until it's served again. Restoring more than once has no effect.
+func (b *Breakage) Restore() error {
+	b.once.Do(func() {
+		scopes.Framework.Infof("webhook: restoring %s", b.webhook)
+		switch b.fault {
+		case Unavailable:
+			b.err = scale(b.env, b.ns, b.webhook.Deployment, b.replicas)
+		case BadCABundle:
+			_, b.err = setCABundles(b.env, b.webhook.Configuration, func(name string) []byte {
+				return b.caBundles[name]
+			})
+		}
+		if b.err != nil {
+			b.err = fmt.Errorf("webhook: failed restoring %s: %v", b.webhook, b.err)
+		}
+	})
+	return b.err
+}
+
+// RestoreOrFail calls Restore and fails t if an error occurs.
+func (b *Breakage) RestoreOrFail(t test.Failer) {
+	t.Helper()
+	if err := b.Restore(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// RestoreAfter restores the webhook in the background once the delay elapsed, e.g. to verify that pods created
+// while the injector is slow to become available are injected once it is. The returned channel receives the
+// result of Restore.
+func (b *Breakage) RestoreAfter(delay time.Duration) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		time.Sleep(delay)
+		ch <- b.Restore()
+	}()
+	return ch
+}
+
+// FailurePolicy returns the failure policy of the webhook, which must be the same for all of the webhooks of
+// its configuration. An unset policy defaults to Ignore.
+func FailurePolicy(ctx resource.Context, w Webhook) (kubeApiAdmissions.FailurePolicyType, error) {
+	env, ok := ctx.Environment().(*kube.Environment)
+	if !ok {
+		return "", fmt.Errorf("webhook: only supported in the %s environment", environment.Kube)
+	}
+
+	var policies []*kubeApiAdmissions.FailurePolicyType
+	if w.Mutating {
+		cfg, err := env.GetMutatingWebhookConfiguration(w.Configuration)
+		if err != nil {
+			return "", err
+		}
+		for _, wh := range cfg.Webhooks {
+			policies = append(policies, wh.FailurePolicy)
+		}
+	} else {
+		cfg, err := env.GetValidatingWebhookConfiguration(w.Configuration)
+		if err != nil {
+			return "", err
+		}
+		for _, wh := range cfg.Webhooks {
+			policies = append(policies, wh.FailurePolicy)
+		}
+	}
+	if len(policies) == 0 {
+		return "", fmt.Errorf("webhook: %s has no webhooks", w)
+	}
+
+	var policy kubeApiAdmissions.FailurePolicyType
+	for i, p := range policies {
+		current := kubeApiAdmissions.Ignore
+		if p != nil {
+			current = *p
+		}
+		if i > 0 && current != policy {
+			return "", fmt.Errorf("webhook: the webhooks of %s have different failure policies: %s and %s",
+				w, policy, current)
+		}
+		policy = current
+	}
+	return policy, nil
+}
+
+// FailurePolicyOrFail calls FailurePolicy and fails t if an error occurs.
+func FailurePolicyOrFail(t test.Failer, ctx resource.Context, w Webhook) kubeApiAdmissions.FailurePolicyType {
+	t.Helper()
+	p, err := FailurePolicy(ctx, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func scale(env *kube.Environment, ns, deployment string, replicas int) error {
+	if err := env.ScaleDeployment(ns, deployment, replicas); err != nil {
+		return err
+	}
+	return env.WaitUntilDeploymentIsRolledOut(ns, deployment)
+}
+
+// setCABundles sets the CA bundle of each webhook of the mutating webhook configuration to the one returned
+// for its name, and returns the previous ones by name.
+func setCABundles(env *kube.Environment, name string, bundle func(webhook string) []byte) (map[string][]byte, error) {
+	var previous map[string][]byte
+	err := retry.UntilSuccess(func() error {
+		cfg, err := env.GetMutatingWebhookConfiguration(name)
+		if err != nil {
+			return err
+		}
+		previous = make(map[string][]byte, len(cfg.Webhooks))
+		for i, wh := range cfg.Webhooks {
+			previous[wh.Name] = wh.ClientConfig.CABundle
+			cfg.Webhooks[i].ClientConfig.CABundle = bundle(wh.Name)
+		}
+		// Retry on conflicts with the injector, which patches the CA bundle when its certificate changes.
+		_, err = env.UpdateMutatingWebhookConfiguration(cfg)
+		return err
+	})
+	return previous, err
+}
+
+// unrelatedCA returns the PEM encoded certificate of a new self-signed CA.
+func unrelatedCA() ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"Istio Test"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+
+	kubeApiMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pkg/test/framework/components/environment/kube"
+	"istio.io/istio/pkg/test/framework/components/namespace"
+)
+
+const (
+	// IstioSecretPrefix is the name prefix of the secrets holding the workload certificates issued by Citadel.
+	IstioSecretPrefix = "istio."
+)
+
+// ReissueWorkloadCerts deletes the workload certificate secrets of the given namespaces, so that Citadel issues
+// new ones with its current configuration. The sidecars pick up the new certificates without restarting.
+func ReissueWorkloadCerts(env *kube.Environment, namespaces ...namespace.Instance) error {
+	for _, ns := range namespaces {
+		list, err := env.GetSecret(ns.Name()).List(kubeApiMeta.ListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, s := range list.Items {
+			if strings.HasPrefix(s.Name, IstioSecretPrefix) {
+				if err := env.DeleteSecret(ns.Name(), s.Name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
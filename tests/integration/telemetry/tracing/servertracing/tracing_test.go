@@ -23,6 +23,7 @@ import (
 	"istio.io/istio/pkg/test/framework"
 	"istio.io/istio/pkg/test/framework/components/environment"
 	"istio.io/istio/pkg/test/framework/components/istio"
+	"istio.io/istio/pkg/test/framework/components/zipkin"
 	"istio.io/istio/pkg/test/util/retry"
 	util "istio.io/istio/tests/integration/mixer"
 	"istio.io/istio/tests/integration/telemetry/tracing"
@@ -68,9 +69,6 @@ func setupConfig(cfg *istio.Config) {
 	if cfg == nil {
 		return
 	}
-	cfg.Values["tracing.enabled"] = "true"
-	cfg.Values["tracing.provider"] = "zipkin"
-	cfg.Values["global.enableTracing"] = "true"
+	zipkin.SetupConfig(cfg)
 	cfg.Values["global.disablePolicyChecks"] = "true"
-	cfg.Values["pilot.traceSampling"] = "100.0"
 }
@@ -23,7 +23,7 @@ docker: build-linux docker.all
 
 # Add new docker targets to the end of the DOCKER_TARGETS list.
 DOCKER_TARGETS:=docker.pilot docker.proxy_debug docker.proxytproxy docker.proxyv2 docker.app docker.app_sidecar docker.test_policybackend \
-	docker.proxy_init docker.mixer docker.mixer_codegen docker.citadel docker.galley docker.sidecar_injector docker.kubectl docker.node-agent-k8s
+	docker.proxy_init docker.mixer docker.mixer_codegen docker.citadel docker.galley docker.sidecar_injector docker.kubectl docker.node-agent-k8s docker.test_jwks docker.test_extauthz docker.test_externalca docker.test_oidc docker.test_stackdriver docker.test_sds
 
 $(ISTIO_DOCKER) $(ISTIO_DOCKER_TAR):
 	mkdir -p $@
@@ -195,6 +195,42 @@ docker.test_policybackend: mixer/docker/Dockerfile.test_policybackend
 docker.test_policybackend: $(ISTIO_OUT_LINUX)/policybackend
 	$(DOCKER_RULE)
 
+# Fake JWKS server for security integration tests
+docker.test_jwks: BUILD_ARGS=--build-arg BASE_VERSION=${BASE_VERSION}
+docker.test_jwks: pkg/test/fakes/jwks/docker/Dockerfile.test_jwks
+docker.test_jwks: $(ISTIO_OUT_LINUX)/jwksserver
+	$(DOCKER_RULE)
+
+# Fake external authorization server for security integration tests
+docker.test_extauthz: BUILD_ARGS=--build-arg BASE_VERSION=${BASE_VERSION}
+docker.test_extauthz: pkg/test/fakes/extauthz/docker/Dockerfile.test_extauthz
+docker.test_extauthz: $(ISTIO_OUT_LINUX)/extauthzserver
+	$(DOCKER_RULE)
+
+# Fake external CA for security integration tests
+docker.test_externalca: BUILD_ARGS=--build-arg BASE_VERSION=${BASE_VERSION}
+docker.test_externalca: pkg/test/fakes/externalca/docker/Dockerfile.test_externalca
+docker.test_externalca: $(ISTIO_OUT_LINUX)/externalcaserver
+	$(DOCKER_RULE)
+
+# Fake OpenID Connect provider for security integration tests
+docker.test_oidc: BUILD_ARGS=--build-arg BASE_VERSION=${BASE_VERSION}
+docker.test_oidc: pkg/test/fakes/oidc/docker/Dockerfile.test_oidc
+docker.test_oidc: $(ISTIO_OUT_LINUX)/oidcserver
+	$(DOCKER_RULE)
+
+# Fake Stackdriver server for telemetry integration tests
+docker.test_stackdriver: BUILD_ARGS=--build-arg BASE_VERSION=${BASE_VERSION}
+docker.test_stackdriver: pkg/test/fakes/stackdriver/docker/Dockerfile.test_stackdriver
+docker.test_stackdriver: $(ISTIO_OUT_LINUX)/stackdriverserver
+	$(DOCKER_RULE)
+
+# Fake external SDS server for security integration tests
+docker.test_sds: BUILD_ARGS=--build-arg BASE_VERSION=${BASE_VERSION}
+docker.test_sds: pkg/test/fakes/sds/docker/Dockerfile.test_sds
+docker.test_sds: $(ISTIO_OUT_LINUX)/sdsserver
+	$(DOCKER_RULE)
+
 docker.kubectl: BUILD_ARGS=--build-arg BASE_VERSION=${BASE_VERSION}
 docker.kubectl: docker/Dockerfile$$(suffix $$@)
 	$(DOCKER_RULE)